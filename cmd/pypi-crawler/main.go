@@ -0,0 +1,310 @@
+// pypi-crawler 查询PyPI项目信息、审计漏洞、构建离线镜像和运行索引服务的命令行工具
+//
+// 用法:
+//
+//	pypi-crawler <命令> [参数...] [选项]
+//
+// 运行 pypi-crawler help 查看所有命令，pypi-crawler <命令> -h 查看命令的选项。
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/api"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/auth"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/client"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/failover"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/mirrors"
+)
+
+// 退出码，便于在CI中区分失败原因
+const (
+	// exitOK 成功
+	exitOK = 0
+
+	// exitError 请求失败或其他运行错误
+	exitError = 1
+
+	// exitUsage 命令或参数错误
+	exitUsage = 2
+
+	// exitNotFound 项目或版本不存在
+	exitNotFound = 3
+
+	// exitFindings vulns命令发现了漏洞
+	exitFindings = 4
+)
+
+// userAgent 命令行工具发送请求时使用的User-Agent
+const userAgent = "pypi-crawler-cli/1.0 (github.com/scagogogo/pypi-crawler)"
+
+// errFindings 表示审计发现了漏洞，对应exitFindings
+var errFindings = errors.New("发现漏洞")
+
+// usageError 命令或参数错误，对应exitUsage
+type usageError struct {
+	msg string
+	// printed 错误和用法已由flag包输出
+	printed bool
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// command 一个子命令
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(a *app, args []string) error
+}
+
+var commands = []command{
+	{"info", "<包名> [版本]", "显示项目或指定版本的信息", runInfo},
+	{"versions", "<包名>", "列出项目的版本", runVersions},
+	{"files", "<包名> [版本]", "列出版本的发布文件，默认为最新版本", runFiles},
+	{"deps", "<包名> [版本]", "列出版本的依赖，默认为最新版本", runDeps},
+	{"vulns", "[包名==版本...]", "审计包版本或锁文件中的已知漏洞", runVulns},
+	{"search", "<关键词>", "按名称搜索项目", runSearch},
+	{"crawl", "[包名...]", "抓取项目信息保存到缓存目录", runCrawl},
+	{"mirror", "<包名[版本约束]...>", "构建选择性的离线镜像", runMirror},
+	{"serve", "", "运行缓存代理或本地索引服务", runServe},
+}
+
+// app 一次命令执行的上下文和全局选项
+type app struct {
+	cmd    command
+	ctx    context.Context
+	stdout io.Writer
+	stderr io.Writer
+
+	config      string
+	mirror      string
+	proxy       string
+	timeout     time.Duration
+	concurrency int
+	cacheDir    string
+	output      string
+
+	// loaded 从配置文件、环境变量和pip配置加载的客户端选项，通用选项中设置了的值优先
+	loaded *client.Options
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run 执行命令并返回退出码
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		printUsage(stderr)
+		return exitUsage
+	}
+	name := args[0]
+	if name == "help" || name == "-h" || name == "--help" {
+		printUsage(stdout)
+		return exitOK
+	}
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		a := &app{cmd: cmd, ctx: ctx, stdout: stdout, stderr: stderr}
+		err := cmd.run(a, args[1:])
+		var usage *usageError
+		switch {
+		case err == nil:
+			return exitOK
+		case errors.Is(err, flag.ErrHelp):
+			return exitOK
+		case errors.As(err, &usage) && usage.printed:
+			return exitUsage
+		case errors.As(err, &usage):
+			fmt.Fprintf(stderr, "错误: %v\n用法: pypi-crawler %s %s [选项]\n", err, cmd.name, cmd.args)
+			return exitUsage
+		case errors.Is(err, errFindings):
+			return exitFindings
+		case errors.Is(err, client.ErrNotFound):
+			fmt.Fprintf(stderr, "错误: %v\n", err)
+			return exitNotFound
+		default:
+			fmt.Fprintf(stderr, "错误: %v\n", err)
+			return exitError
+		}
+	}
+	fmt.Fprintf(stderr, "错误: 未知命令 %q\n\n", name)
+	printUsage(stderr)
+	return exitUsage
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "用法: pypi-crawler <命令> [参数...] [选项]")
+	fmt.Fprintln(w, "\n命令:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %s %s\n      %s\n", cmd.name, cmd.args, cmd.summary)
+	}
+	fmt.Fprintln(w, "\n通用选项:")
+	fmt.Fprintln(w, "  --config <文件>       客户端配置文件（YAML、JSON或TOML，默认: $PYPI_CRAWLER_CONFIG）")
+	fmt.Fprintln(w, "  --mirror <名称|URL>   索引，内置镜像名（official、tsinghua、aliyun等）或基础URL（默认: 配置中的索引）")
+	fmt.Fprintln(w, "  --proxy <URL>         HTTP代理地址（默认: 配置中的代理）")
+	fmt.Fprintln(w, "  --timeout <时长>      单个请求的超时时间（默认: 配置中的超时，未配置时为30s）")
+	fmt.Fprintln(w, "  --concurrency <n>     并发请求数（默认: 4）")
+	fmt.Fprintln(w, "  --cache-dir <目录>    缓存目录（默认: 用户缓存目录下的pypi-crawler）")
+	fmt.Fprintln(w, "  -o, --output <格式>   输出格式: table、json、yaml（默认: table）")
+	fmt.Fprintln(w, "\n配置:")
+	fmt.Fprintln(w, "  未指定--mirror时使用配置中的索引，按优先级从低到高依次读取:")
+	fmt.Fprintln(w, "  HTTPS_PROXY等代理环境变量、pip的配置文件（pip.conf/pip.ini）、PIP_INDEX_URL等pip环境变量、")
+	fmt.Fprintln(w, "  --config指定的配置文件、PYPI_CRAWLER_*环境变量，最后是命令行选项；")
+	fmt.Fprintln(w, "  配置了extra-index-url时依次尝试主索引和额外索引")
+	fmt.Fprintln(w, "\n退出码:")
+	fmt.Fprintln(w, "  0 成功，1 运行错误，2 参数错误，3 项目或版本不存在，4 发现漏洞")
+}
+
+// flags 创建注册了通用选项的FlagSet
+func (a *app) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "用法: pypi-crawler %s %s [选项]\n\n%s\n\n选项:\n", a.cmd.name, a.cmd.args, a.cmd.summary)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&a.config, "config", "", "客户端配置文件（YAML、JSON或TOML），默认读取PYPI_CRAWLER_CONFIG")
+	fs.StringVar(&a.mirror, "mirror", "", "内置镜像名或索引的基础URL，默认使用配置中的索引")
+	fs.StringVar(&a.proxy, "proxy", "", "HTTP代理地址，默认使用配置中的代理")
+	fs.DurationVar(&a.timeout, "timeout", 0, "单个请求的超时时间，默认使用配置中的超时")
+	fs.IntVar(&a.concurrency, "concurrency", 4, "并发请求数")
+	fs.StringVar(&a.cacheDir, "cache-dir", defaultCacheDir(), "缓存目录")
+	fs.StringVar(&a.output, "output", formatTable, "输出格式: table、json、yaml")
+	fs.StringVar(&a.output, "o", formatTable, "--output的简写")
+	return fs
+}
+
+// parse 解析选项并加载客户端配置，选项可以出现在位置参数之前或之后，返回位置参数
+// minArgs和maxArgs限制位置参数的个数，maxArgs为-1时不限制
+func (a *app) parse(fs *flag.FlagSet, args []string, minArgs, maxArgs int) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, &usageError{msg: err.Error(), printed: true}
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	switch {
+	case len(positional) < minArgs:
+		return nil, usagef("缺少参数")
+	case maxArgs >= 0 && len(positional) > maxArgs:
+		return nil, usagef("多余的参数 %q", positional[maxArgs])
+	case a.concurrency < 1:
+		return nil, usagef("--concurrency必须大于0")
+	case a.timeout < 0:
+		return nil, usagef("--timeout不能为负数")
+	}
+	switch a.output {
+	case formatTable, formatJSON, formatYAML:
+	default:
+		return nil, usagef("不支持的输出格式 %q", a.output)
+	}
+	loaded, err := client.NewLoader().
+		WithFile(a.config).
+		WithWarnings(func(err error) { fmt.Fprintf(a.stderr, "警告: %v\n", err) }).
+		Load()
+	if err != nil {
+		return nil, fmt.Errorf("加载配置失败: %w", err)
+	}
+	a.loaded = loaded
+	return positional, nil
+}
+
+// options 返回加载的客户端选项的副本，并应用--proxy和--timeout
+// 配置中没有设置User-Agent时使用命令行工具的User-Agent
+func (a *app) options() *client.Options {
+	o := *a.loaded
+	if a.proxy != "" {
+		o.Proxy = a.proxy
+	}
+	if a.timeout > 0 {
+		o.Timeout = a.timeout
+	}
+	if o.UserAgent == client.DefaultUserAgent {
+		o.UserAgent = userAgent
+	}
+	return &o
+}
+
+// client 根据--mirror创建客户端，内置镜像使用其URL布局
+// 未指定--mirror时使用配置中的索引，配置了额外索引时按顺序故障转移
+func (a *app) client() (api.PyPIClient, error) {
+	if a.mirror == "" {
+		return a.configuredClient()
+	}
+	if m, ok := mirrors.Get(a.mirror); ok {
+		return m.NewClient(a.options()), nil
+	}
+	u, err := url.Parse(a.mirror)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, usagef("未知的镜像 %q，应为内置镜像名或http(s) URL", a.mirror)
+	}
+	return client.NewClient(a.options().WithBaseURL(strings.TrimRight(a.mirror, "/"))), nil
+}
+
+// configuredClient 根据配置中的主索引和额外索引创建客户端
+func (a *app) configuredClient() (api.PyPIClient, error) {
+	primary := a.options()
+	extras := primary.ExtraIndexURLs
+	if len(extras) == 0 {
+		return client.NewClient(primary), nil
+	}
+	// 后端名称出现在错误信息中，去掉地址中内嵌的认证信息
+	name, _ := auth.SplitURL(primary.BaseURL)
+	backends := []failover.Backend{{Name: name, Client: client.NewClient(primary)}}
+	for _, indexURL := range extras {
+		o, err := a.options().WithIndexURL(indexURL)
+		if err != nil {
+			return nil, fmt.Errorf("额外索引: %w", err)
+		}
+		o.Layout = client.Layout{SimplePath: o.Layout.SimplePath}
+		name, _ := auth.SplitURL(indexURL)
+		backends = append(backends, failover.Backend{Name: name, Client: client.NewClient(o)})
+	}
+	return failover.New(backends...), nil
+}
+
+// httpClient 创建使用配置中代理和受信任主机的HTTP客户端，用于下载发布文件，timeout为0时不限制
+func (a *app) httpClient(timeout time.Duration) *http.Client {
+	return client.NewHTTPClient(a.options().WithTimeout(timeout))
+}
+
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ".pypi-crawler"
+	}
+	return filepath.Join(dir, "pypi-crawler")
+}
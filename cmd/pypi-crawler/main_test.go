@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// newUpstream 模拟的索引：demo有1.0、2.0和2.1rc1三个版本，1.0有一个已知漏洞
+func newUpstream(t *testing.T) *httptest.Server {
+	sum := sha256.Sum256([]byte("wheel"))
+	digest := hex.EncodeToString(sum[:])
+	var server *httptest.Server
+	uploaded := map[string]string{"1.0": "2023-01-01", "2.0": "2023-02-01", "2.1rc1": "2023-03-01"}
+	file := func(v string) string {
+		return fmt.Sprintf(`{"filename": "demo-%[1]s-py3-none-any.whl", "url": "%[2]s/files/demo-%[1]s-py3-none-any.whl",
+			"packagetype": "bdist_wheel", "python_version": "py3", "size": 5, "digests": {"sha256": "%[3]s"},
+			"upload_time_iso_8601": "%[4]sT00:00:00Z"}`, v, server.URL, digest, uploaded[v])
+	}
+	release := func(v, vulns string) string {
+		return fmt.Sprintf(`{"info": {"name": "demo", "version": "%s", "summary": "A demo",
+			"requires_dist": ["requests>=2", "pywin32; sys_platform == 'win32'", "pytest; extra == 'test'"]},
+			"urls": [%s], "vulnerabilities": [%s]}`, v, file(v), vulns)
+	}
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/simple/":
+			fmt.Fprint(w, `<html><body><a href="/simple/demo/">demo</a><a href="/simple/other/">other</a></body></html>`)
+		case "/pypi/demo/json":
+			fmt.Fprintf(w, `{"info": {"name": "demo", "version": "2.0", "summary": "A demo", "license": "MIT"},
+				"releases": {"1.0": [%s], "2.0": [%s], "2.1rc1": [%s]}, "urls": [%s]}`,
+				file("1.0"), file("2.0"), file("2.1rc1"), file("2.0"))
+		case "/pypi/demo/1.0/json":
+			fmt.Fprint(w, release("1.0", `{"id": "PYSEC-2023-1", "aliases": ["CVE-2023-0001"], "summary": "Bad bug", "fixed_in": ["2.0"]}`))
+		case "/pypi/demo/2.0/json":
+			fmt.Fprint(w, release("2.0", ""))
+		case "/files/demo-2.0-py3-none-any.whl":
+			w.Write([]byte("wheel"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// runCLI 执行命令，返回退出码、标准输出和标准错误
+func runCLI(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	t.Run("没有命令", func(t *testing.T) {
+		code, _, stderr := runCLI(t)
+		assert.Equal(t, exitUsage, code)
+		assert.Contains(t, stderr, "命令:")
+	})
+
+	t.Run("帮助", func(t *testing.T) {
+		code, stdout, _ := runCLI(t, "help")
+		assert.Equal(t, exitOK, code)
+		assert.Contains(t, stdout, "退出码")
+
+		code, _, stderr := runCLI(t, "info", "-h")
+		assert.Equal(t, exitOK, code)
+		assert.Contains(t, stderr, "-mirror")
+	})
+
+	t.Run("未知命令", func(t *testing.T) {
+		code, _, stderr := runCLI(t, "frobnicate")
+		assert.Equal(t, exitUsage, code)
+		assert.Contains(t, stderr, "未知命令")
+	})
+
+	t.Run("参数错误", func(t *testing.T) {
+		code, _, stderr := runCLI(t, "info")
+		assert.Equal(t, exitUsage, code)
+		assert.Contains(t, stderr, "缺少参数")
+
+		code, _, _ = runCLI(t, "info", "demo", "--output", "xml")
+		assert.Equal(t, exitUsage, code)
+
+		code, _, _ = runCLI(t, "info", "demo", "--no-such-flag")
+		assert.Equal(t, exitUsage, code)
+
+		code, _, stderr = runCLI(t, "info", "demo", "--mirror", "nowhere")
+		assert.Equal(t, exitUsage, code)
+		assert.Contains(t, stderr, "未知的镜像")
+
+		code, _, stderr = runCLI(t, "serve", "--osv-db", t.TempDir())
+		assert.Equal(t, exitUsage, code)
+		assert.Contains(t, stderr, "--osv-db需要配合--policy使用")
+	})
+}
+
+func TestQueryCommands(t *testing.T) {
+	upstream := newUpstream(t)
+	mirror := "--mirror=" + upstream.URL
+
+	t.Run("info输出JSON", func(t *testing.T) {
+		code, stdout, _ := runCLI(t, "info", "demo", mirror, "-o", "json")
+		require.Equal(t, exitOK, code)
+		var info map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(stdout), &info))
+		assert.Equal(t, "demo", info["name"])
+		assert.Equal(t, "MIT", info["license"])
+	})
+
+	t.Run("info表格", func(t *testing.T) {
+		code, stdout, _ := runCLI(t, "info", mirror, "demo", "1.0")
+		require.Equal(t, exitOK, code)
+		assert.Contains(t, stdout, "版本")
+		assert.Contains(t, stdout, "1.0")
+		assert.Contains(t, stdout, "依赖数")
+	})
+
+	t.Run("项目不存在", func(t *testing.T) {
+		code, _, stderr := runCLI(t, "info", "missing", mirror)
+		assert.Equal(t, exitNotFound, code)
+		assert.Contains(t, stderr, "错误")
+	})
+
+	t.Run("versions从新到旧", func(t *testing.T) {
+		code, stdout, _ := runCLI(t, "versions", "demo", mirror)
+		require.Equal(t, exitOK, code)
+		lines := strings.Split(strings.TrimSpace(stdout), "\n")
+		require.Len(t, lines, 3)
+		assert.True(t, strings.HasPrefix(lines[1], "2.0 "))
+		assert.True(t, strings.HasPrefix(lines[2], "1.0 "))
+
+		code, stdout, _ = runCLI(t, "versions", "demo", mirror, "--pre", "--spec", ">1", "-o", "json")
+		require.Equal(t, exitOK, code)
+		var rows []versionRow
+		require.NoError(t, json.Unmarshal([]byte(stdout), &rows))
+		require.Len(t, rows, 2)
+		assert.Equal(t, "2.1rc1", rows[0].Version)
+		assert.True(t, rows[0].Prerelease)
+		assert.Equal(t, "2023-03-01", rows[0].Uploaded)
+	})
+
+	t.Run("files输出YAML", func(t *testing.T) {
+		code, stdout, _ := runCLI(t, "files", "demo", mirror, "-o", "yaml")
+		require.Equal(t, exitOK, code)
+		var files []map[string]interface{}
+		require.NoError(t, yaml.Unmarshal([]byte(stdout), &files))
+		require.Len(t, files, 1)
+		assert.Equal(t, "demo-2.0-py3-none-any.whl", files[0]["filename"])
+		assert.NotContains(t, stdout, "{")
+	})
+
+	t.Run("deps按环境过滤", func(t *testing.T) {
+		code, stdout, _ := runCLI(t, "deps", "demo", "2.0", mirror)
+		require.Equal(t, exitOK, code)
+		assert.Contains(t, stdout, "pywin32")
+		assert.Contains(t, stdout, "pytest")
+
+		code, stdout, _ = runCLI(t, "deps", "demo", "2.0", mirror, "--python", "3.11", "-o", "json")
+		require.Equal(t, exitOK, code)
+		var rows []depRow
+		require.NoError(t, json.Unmarshal([]byte(stdout), &rows))
+		require.Len(t, rows, 1)
+		assert.Equal(t, depRow{Name: "requests", Specifier: ">=2"}, rows[0])
+
+		code, stdout, _ = runCLI(t, "deps", "demo", "2.0", mirror, "--python", "3.11", "--extras", "test")
+		require.Equal(t, exitOK, code)
+		assert.Contains(t, stdout, "pytest")
+	})
+
+	t.Run("search", func(t *testing.T) {
+		code, stdout, _ := runCLI(t, "search", "dem", mirror, "-o", "json")
+		require.Equal(t, exitOK, code)
+		assert.JSONEq(t, `["demo"]`, stdout)
+	})
+}
+
+func TestVulns(t *testing.T) {
+	upstream := newUpstream(t)
+	mirror := "--mirror=" + upstream.URL
+
+	t.Run("发现漏洞", func(t *testing.T) {
+		code, stdout, stderr := runCLI(t, "vulns", "demo==1.0", mirror)
+		assert.Equal(t, exitFindings, code)
+		assert.Contains(t, stdout, "PYSEC-2023-1")
+		assert.Contains(t, stdout, "2.0")
+		assert.Contains(t, stderr, "发现1个漏洞")
+	})
+
+	t.Run("没有漏洞", func(t *testing.T) {
+		code, stdout, _ := runCLI(t, "vulns", "demo==2.0", mirror, "-o", "json")
+		assert.Equal(t, exitOK, code)
+		assert.Contains(t, stdout, `"findings": []`)
+	})
+
+	t.Run("读取需求文件", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "requirements.txt")
+		require.NoError(t, os.WriteFile(path, []byte("demo==1.0\nrequests>=2\n"), 0o644))
+		code, _, stderr := runCLI(t, "vulns", "-r", path, mirror)
+		assert.Equal(t, exitFindings, code)
+		assert.Contains(t, stderr, "没有固定版本")
+	})
+
+	t.Run("查询失败", func(t *testing.T) {
+		code, _, stderr := runCLI(t, "vulns", "demo==9.9", mirror)
+		assert.Equal(t, exitError, code)
+		assert.Contains(t, stderr, "查询 demo@9.9 失败")
+	})
+
+	t.Run("没有要审计的包", func(t *testing.T) {
+		code, _, _ := runCLI(t, "vulns", mirror)
+		assert.Equal(t, exitUsage, code)
+	})
+}
+
+func TestCrawlAndMirror(t *testing.T) {
+	upstream := newUpstream(t)
+	mirror := "--mirror=" + upstream.URL
+	cacheDir := t.TempDir()
+
+	t.Run("crawl保存到缓存目录", func(t *testing.T) {
+		code, stdout, _ := runCLI(t, "crawl", "demo", mirror, "--cache-dir", cacheDir, "-o", "json")
+		require.Equal(t, exitOK, code)
+		assert.JSONEq(t, `[{"name": "demo", "versions": 3, "status": "fetched"}]`, stdout)
+		assert.FileExists(t, filepath.Join(cacheDir, indexCacheDir, "demo", "package.json"))
+
+		code, stdout, _ = runCLI(t, "crawl", "--all", mirror, "--cache-dir", cacheDir, "-o", "json")
+		assert.Equal(t, exitError, code)
+		var rows []crawlRow
+		require.NoError(t, json.Unmarshal([]byte(stdout), &rows))
+		require.Len(t, rows, 2)
+		assert.Equal(t, crawlCached, rows[0].Status)
+		assert.Equal(t, crawlFailed, rows[1].Status)
+	})
+
+	t.Run("mirror构建离线镜像", func(t *testing.T) {
+		root := t.TempDir()
+		code, stdout, stderr := runCLI(t, "mirror", "demo", mirror, "--root", root, "--latest", "1", "--platform", "any", "-o", "json")
+		require.Equal(t, exitOK, code, stderr)
+		var report mirrorReport
+		require.NoError(t, json.Unmarshal([]byte(stdout), &report))
+		assert.Equal(t, []string{"demo"}, report.Projects)
+		assert.Equal(t, []string{"demo-2.0-py3-none-any.whl"}, report.Downloaded)
+		assert.FileExists(t, filepath.Join(root, "simple", "demo", "index.html"))
+	})
+
+	t.Run("mirror读取需求文件", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "requirements.txt")
+		require.NoError(t, os.WriteFile(path, []byte("demo[test]==2.0 ; python_version >= '3.8'\nother ; python_version < '3'\n"), 0o644))
+		root := t.TempDir()
+		code, stdout, stderr := runCLI(t, "mirror", "-r", path, mirror, "--root", root, "--python", "3.11", "-o", "json")
+		require.Equal(t, exitOK, code, stderr)
+		var report mirrorReport
+		require.NoError(t, json.Unmarshal([]byte(stdout), &report))
+		assert.Equal(t, []string{"demo"}, report.Projects)
+		assert.Equal(t, []string{"demo-2.0-py3-none-any.whl"}, report.Downloaded)
+	})
+
+	t.Run("mirror缺少项目", func(t *testing.T) {
+		code, _, _ := runCLI(t, "mirror", mirror)
+		assert.Equal(t, exitUsage, code)
+	})
+}
+
+func TestConfig(t *testing.T) {
+	upstream := newUpstream(t)
+	t.Setenv("PIP_CONFIG_FILE", os.DevNull)
+	t.Setenv("PIP_INDEX_URL", "")
+	t.Setenv("PIP_EXTRA_INDEX_URL", "")
+
+	t.Run("配置文件中的索引", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("index_url: "+upstream.URL+"/simple/\ntimeout: 5s\n"), 0o644))
+		code, stdout, stderr := runCLI(t, "search", "dem", "--config", path, "-o", "json")
+		require.Equal(t, exitOK, code, stderr)
+		assert.JSONEq(t, `["demo"]`, stdout)
+	})
+
+	t.Run("pip的额外索引", func(t *testing.T) {
+		empty := httptest.NewServer(http.NotFoundHandler())
+		defer empty.Close()
+		t.Setenv("PIP_INDEX_URL", empty.URL+"/simple/")
+		t.Setenv("PIP_EXTRA_INDEX_URL", upstream.URL+"/simple/")
+		code, stdout, stderr := runCLI(t, "info", "demo", "-o", "json")
+		require.Equal(t, exitOK, code, stderr)
+		assert.Contains(t, stdout, `"name": "demo"`)
+	})
+
+	t.Run("默认位置的netrc无效时只警告", func(t *testing.T) {
+		netrc := filepath.Join(t.TempDir(), ".netrc")
+		require.NoError(t, os.WriteFile(netrc, []byte("machine\n"), 0o600))
+		t.Setenv("NETRC", netrc)
+		t.Setenv("PIP_INDEX_URL", upstream.URL+"/simple/")
+		code, stdout, stderr := runCLI(t, "search", "dem", "-o", "json")
+		require.Equal(t, exitOK, code, stderr)
+		assert.JSONEq(t, `["demo"]`, stdout)
+		assert.Contains(t, stderr, "警告: 跳过认证文件: "+netrc)
+	})
+
+	t.Run("无效的配置", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.toml")
+		require.NoError(t, os.WriteFile(path, []byte("timeout = \"soon\"\n"), 0o644))
+		code, _, stderr := runCLI(t, "info", "demo", "--config", path)
+		assert.Equal(t, exitError, code)
+		assert.Contains(t, stderr, "加载配置失败")
+	})
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/indexserver"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/offline"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/reqfile"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/requirement"
+)
+
+// indexCacheDir 缓存目录中保存项目信息的子目录，crawl写入，serve的代理读写，布局与indexserver.DirStorage相同
+const indexCacheDir = "index"
+
+// offlineDir 缓存目录中离线镜像的默认子目录
+const offlineDir = "offline"
+
+// listFlag 可重复、以逗号分隔的列表选项
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// crawlRow crawl命令输出的一行
+type crawlRow struct {
+	Name     string `json:"name"`
+	Versions int    `json:"versions"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+// crawl命令的项目状态
+const (
+	crawlFetched = "fetched"
+	crawlCached  = "cached"
+	crawlFailed  = "failed"
+)
+
+// runCrawl 抓取项目信息保存到缓存目录，已缓存的项目默认跳过
+func runCrawl(a *app, args []string) error {
+	fs := a.flags("crawl")
+	all := fs.Bool("all", false, "抓取索引中的所有项目")
+	limit := fs.Int("limit", 0, "最多抓取的项目数，为0时不限制")
+	force := fs.Bool("force", false, "重新抓取已缓存的项目")
+	names, err := a.parse(fs, args, 0, -1)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 && !*all {
+		return usagef("请指定项目名或--all")
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	if *all {
+		index, err := c.GetAllPackages(a.ctx)
+		if err != nil {
+			return err
+		}
+		names = append(names, index...)
+	}
+	if *limit > 0 && len(names) > *limit {
+		names = names[:*limit]
+	}
+
+	storage := indexserver.NewDirStorage(filepath.Join(a.cacheDir, indexCacheDir))
+	rows := make([]crawlRow, len(names))
+	a.parallel(len(names), func(i int) {
+		row := crawlRow{Name: models.NormalizeName(names[i]), Status: crawlFetched}
+		pkg, err := storage.Package(a.ctx, names[i])
+		if err == nil && !*force {
+			row.Status = crawlCached
+		} else if pkg, err = c.GetPackageInfo(a.ctx, names[i]); err == nil {
+			err = storage.SavePackage(pkg)
+		}
+		if err != nil {
+			row.Status, row.Error = crawlFailed, err.Error()
+		} else {
+			row.Versions = len(pkg.Releases)
+		}
+		rows[i] = row
+	})
+
+	failed := 0
+	t := newTable("项目", "版本数", "状态")
+	for _, row := range rows {
+		status := row.Status
+		if row.Error != "" {
+			failed++
+			status += ": " + row.Error
+		}
+		t.add(row.Name, strconv.Itoa(row.Versions), status)
+	}
+	if err := a.print(rows, t); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d个项目抓取失败", failed)
+	}
+	return nil
+}
+
+// mirrorReport mirror命令的JSON/YAML输出
+type mirrorReport struct {
+	Root       string            `json:"root"`
+	Projects   []string          `json:"projects"`
+	Versions   int               `json:"versions"`
+	Downloaded []string          `json:"downloaded"`
+	Unchanged  int               `json:"unchanged"`
+	Removed    []string          `json:"removed"`
+	Bytes      int64             `json:"bytes"`
+	Errors     map[string]string `json:"errors,omitempty"`
+}
+
+// runMirror 构建或增量更新选择性的离线镜像
+func runMirror(a *app, args []string) error {
+	fs := a.flags("mirror")
+	root := fs.String("root", "", "镜像根目录（默认: 缓存目录下的offline）")
+	requirements := fs.String("r", "", "从需求文件读取要镜像的项目")
+	latest := fs.Int("latest", 0, "每个项目只保留最新的n个版本，为0时保留全部")
+	pre := fs.Bool("pre", false, "包含预发布版本")
+	deps := fs.Bool("deps", false, "同时镜像依赖闭包")
+	python := fs.String("python", "", "对需求文件和依赖的环境标记求值使用的Python版本，如 3.11；为空时包含所有非可选依赖")
+	var filter offline.FileFilter
+	fs.Var((*listFlag)(&filter.PythonTags), "python-tag", "允许的wheel Python标签，可重复或以逗号分隔，如 cp311,py3")
+	fs.Var((*listFlag)(&filter.ABITags), "abi", "允许的wheel ABI标签，如 cp311,abi3,none")
+	fs.Var((*listFlag)(&filter.Platforms), "platform", "允许的wheel平台标签，支持通配符，如 manylinux*_x86_64,any")
+	fs.BoolVar(&filter.NoSdist, "no-sdist", false, "不镜像源码包")
+	fs.BoolVar(&filter.NoWheels, "no-wheels", false, "不镜像wheel")
+	projects, err := a.parse(fs, args, 0, -1)
+	if err != nil {
+		return err
+	}
+	var env requirement.Environment
+	if *python != "" {
+		env = requirement.DefaultEnvironment(*python)
+	}
+	if *requirements != "" {
+		f, err := reqfile.ParseFile(*requirements)
+		if err != nil {
+			return err
+		}
+		for _, line := range f.Requirements {
+			if line.Requirement == nil || line.Requirement.URL != "" {
+				fmt.Fprintf(a.stderr, "警告: %s 的 %s 不是来自索引的依赖，已跳过\n", line.Location(), line.Raw)
+				continue
+			}
+			if env != nil && !line.Requirement.AppliesTo(env) {
+				// 环境标记在目标Python版本下不生效，pip同样不会安装
+				continue
+			}
+			// 保留extra，使依赖闭包包含extra的依赖
+			projects = append(projects, line.Requirement.String())
+		}
+	}
+	if len(projects) == 0 {
+		return usagef("请指定要镜像的项目或-r")
+	}
+	if *root == "" {
+		*root = filepath.Join(a.cacheDir, offlineDir)
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	builder := offline.NewBuilder(c, *root).
+		WithHTTPClient(a.httpClient(0)).
+		WithUserAgent(a.options().UserAgent).
+		WithFilter(filter).
+		WithLatest(*latest).
+		WithPrereleases(*pre).
+		WithConcurrency(a.concurrency)
+	if *deps {
+		builder.WithDependencies(env)
+	}
+	report, err := builder.Build(a.ctx, projects)
+	if err != nil {
+		return err
+	}
+
+	out := mirrorReport{
+		Root:       *root,
+		Projects:   report.Projects,
+		Versions:   report.Versions,
+		Downloaded: report.Downloaded,
+		Unchanged:  report.Unchanged,
+		Removed:    report.Removed,
+		Bytes:      report.Bytes,
+	}
+	if out.Projects == nil {
+		out.Projects = []string{}
+	}
+	if out.Downloaded == nil {
+		out.Downloaded = []string{}
+	}
+	if out.Removed == nil {
+		out.Removed = []string{}
+	}
+	keys := make([]string, 0, len(report.Errors))
+	for key, err := range report.Errors {
+		if out.Errors == nil {
+			out.Errors = map[string]string{}
+		}
+		out.Errors[key] = err.Error()
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	t := newTable("项目", "版本数", "下载", "未变", "删除", "大小")
+	t.add(strconv.Itoa(len(out.Projects)), strconv.Itoa(out.Versions), strconv.Itoa(len(out.Downloaded)),
+		strconv.Itoa(out.Unchanged), strconv.Itoa(len(out.Removed)), formatSize(out.Bytes))
+	if err := a.print(out, t); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		fmt.Fprintf(a.stderr, "错误: %s: %s\n", key, out.Errors[key])
+	}
+	if len(keys) > 0 {
+		return fmt.Errorf("%d个项目或文件失败", len(keys))
+	}
+	return nil
+}
+
+// parallel 以a.concurrency的并发度执行n个任务
+func (a *app) parallel(n int, task func(i int)) {
+	sem := make(chan struct{}, a.concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			task(i)
+		}(i)
+	}
+	wg.Wait()
+}
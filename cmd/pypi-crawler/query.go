@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/api"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/requirement"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/version"
+)
+
+// runInfo 显示项目或指定版本的信息
+func runInfo(a *app, args []string) error {
+	fs := a.flags("info")
+	args, err := a.parse(fs, args, 1, 2)
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	pkg, err := fetchPackage(a.ctx, c, args)
+	if err != nil {
+		return err
+	}
+	info := pkg.Info
+	if info == nil {
+		info = &models.PackageInfo{}
+	}
+
+	t := newTable("字段", "值")
+	t.add("名称", info.Name)
+	t.add("版本", info.Version)
+	t.add("摘要", info.Summary)
+	t.add("许可证", firstNonEmpty(info.LicenseExpression, info.License))
+	t.add("Python要求", info.RequiresPython)
+	t.add("作者", firstNonEmpty(info.Author, info.AuthorEmail))
+	t.add("主页", firstNonEmpty(info.HomePage, info.ProjectURL))
+	if info.Yanked {
+		t.add("已撤回", firstNonEmpty(info.YankedReason, "是"))
+	}
+	t.add("依赖数", strconv.Itoa(len(info.RequiresDist)))
+	t.add("版本数", strconv.Itoa(len(pkg.Releases)))
+	return a.print(info, t)
+}
+
+// versionRow versions命令输出的一行
+type versionRow struct {
+	Version    string `json:"version"`
+	Uploaded   string `json:"uploaded,omitempty"`
+	Files      int    `json:"files"`
+	Prerelease bool   `json:"prerelease"`
+	Yanked     bool   `json:"yanked"`
+}
+
+// runVersions 列出项目的版本，从新到旧排列
+func runVersions(a *app, args []string) error {
+	fs := a.flags("versions")
+	pre := fs.Bool("pre", false, "包含预发布版本")
+	spec := fs.String("spec", "", "只列出满足PEP 440版本约束的版本，如 \">=2,<3\"")
+	yanked := fs.Bool("yanked", true, "包含已撤回的版本")
+	args, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	constraint, err := version.ParseSpecifierSet(*spec)
+	if err != nil {
+		return usagef("无效的版本约束: %v", err)
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	pkg, err := c.GetPackageInfo(a.ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	versions := make([]string, 0, len(pkg.Releases))
+	for v := range pkg.Releases {
+		versions = append(versions, v)
+	}
+	version.SortStrings(versions)
+
+	rows := []versionRow{}
+	t := newTable("版本", "上传时间", "文件数", "说明")
+	for i := len(versions) - 1; i >= 0; i-- {
+		v, files := versions[i], pkg.Releases[versions[i]]
+		row := versionRow{Version: v, Uploaded: uploaded(files), Files: len(files), Yanked: allYanked(files)}
+		if parsed, err := version.Parse(v); err == nil {
+			row.Prerelease = parsed.IsPrerelease()
+			if !constraint.Contains(parsed) {
+				continue
+			}
+		} else if len(constraint) > 0 {
+			continue
+		}
+		if (row.Prerelease && !*pre) || (row.Yanked && !*yanked) {
+			continue
+		}
+		var notes []string
+		if row.Prerelease {
+			notes = append(notes, "预发布")
+		}
+		if row.Yanked {
+			notes = append(notes, "已撤回")
+		}
+		rows = append(rows, row)
+		t.add(row.Version, row.Uploaded, strconv.Itoa(row.Files), strings.Join(notes, ","))
+	}
+	return a.print(rows, t)
+}
+
+// runFiles 列出版本的发布文件
+func runFiles(a *app, args []string) error {
+	fs := a.flags("files")
+	args, err := a.parse(fs, args, 1, 2)
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	pkg, err := fetchPackage(a.ctx, c, args)
+	if err != nil {
+		return err
+	}
+
+	files := pkg.Urls
+	if files == nil {
+		files = []*models.ReleaseFile{}
+	}
+	t := newTable("文件名", "类型", "Python", "大小", "SHA256")
+	for _, f := range files {
+		name := f.Filename
+		if f.IsYanked() {
+			name += " (已撤回)"
+		}
+		t.add(name, f.PackageType, f.PythonVersion, formatSize(f.Size), f.Digests.SHA256)
+	}
+	return a.print(files, t)
+}
+
+// depRow deps命令输出的一行
+type depRow struct {
+	Name      string   `json:"name"`
+	Specifier string   `json:"specifier,omitempty"`
+	Extras    []string `json:"extras,omitempty"`
+	Marker    string   `json:"marker,omitempty"`
+	URL       string   `json:"url,omitempty"`
+}
+
+// runDeps 列出版本的依赖
+// 指定--python时只列出在该Python版本的Linux x86_64环境中生效的依赖
+func runDeps(a *app, args []string) error {
+	fs := a.flags("deps")
+	python := fs.String("python", "", "只列出在该Python版本下生效的依赖，如 3.11")
+	extras := fs.String("extras", "", "启用的可选功能，以逗号分隔，需配合--python使用")
+	args, err := a.parse(fs, args, 1, 2)
+	if err != nil {
+		return err
+	}
+	if *extras != "" && *python == "" {
+		return usagef("--extras需要配合--python使用")
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	pkg, err := fetchPackage(a.ctx, c, args)
+	if err != nil {
+		return err
+	}
+
+	var envs []requirement.Environment
+	if *python != "" {
+		env := requirement.DefaultEnvironment(*python)
+		envs = append(envs, env)
+		for _, extra := range strings.Split(*extras, ",") {
+			if extra = strings.TrimSpace(extra); extra != "" {
+				envs = append(envs, env.WithExtra(extra))
+			}
+		}
+	}
+
+	rows := []depRow{}
+	t := newTable("名称", "版本约束", "可选功能", "环境标记")
+	var requiresDist []string
+	if pkg.Info != nil {
+		requiresDist = pkg.Info.RequiresDist
+	}
+	for _, line := range requiresDist {
+		req, err := requirement.Parse(line)
+		if err != nil {
+			fmt.Fprintf(a.stderr, "警告: 跳过无法解析的依赖 %q: %v\n", line, err)
+			continue
+		}
+		if envs != nil && !appliesToAny(req, envs) {
+			continue
+		}
+		row := depRow{Name: req.Name, Specifier: req.Specifier.String(), Extras: req.Extras, URL: req.URL}
+		if req.Marker != nil {
+			row.Marker = req.Marker.String()
+		}
+		rows = append(rows, row)
+		t.add(row.Name, firstNonEmpty(row.Specifier, row.URL), strings.Join(row.Extras, ","), row.Marker)
+	}
+	return a.print(rows, t)
+}
+
+// runSearch 按名称搜索项目
+func runSearch(a *app, args []string) error {
+	fs := a.flags("search")
+	limit := fs.Int("limit", 20, "最多显示的结果数")
+	args, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	names, err := c.SearchPackages(a.ctx, args[0], *limit)
+	if err != nil {
+		return err
+	}
+	if names == nil {
+		names = []string{}
+	}
+	t := newTable("名称")
+	for _, name := range names {
+		t.add(name)
+	}
+	return a.print(names, t)
+}
+
+// fetchPackage 获取args[0]的最新版本，或args[1]指定的版本
+func fetchPackage(ctx context.Context, c api.PyPIClient, args []string) (*models.Package, error) {
+	if len(args) > 1 {
+		return c.GetPackageVersion(ctx, args[0], args[1])
+	}
+	return c.GetPackageInfo(ctx, args[0])
+}
+
+func appliesToAny(req *requirement.Requirement, envs []requirement.Environment) bool {
+	for _, env := range envs {
+		if req.AppliesTo(env) {
+			return true
+		}
+	}
+	return false
+}
+
+// uploaded 返回版本最早的文件上传日期
+func uploaded(files []*models.ReleaseFile) string {
+	first := ""
+	for _, f := range files {
+		if t, err := f.GetUploadTimeISO(); err == nil && !t.IsZero() {
+			if day := t.UTC().Format("2006-01-02"); first == "" || day < first {
+				first = day
+			}
+		}
+	}
+	return first
+}
+
+// allYanked 检查版本的所有文件是否都已撤回
+func allYanked(files []*models.ReleaseFile) bool {
+	for _, f := range files {
+		if !f.IsYanked() {
+			return false
+		}
+	}
+	return len(files) > 0
+}
+
+// formatSize 返回易读的文件大小
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return strconv.FormatInt(n, 10) + " B"
+	}
+	value, suffix := float64(n)/unit, "KiB"
+	for _, next := range []string{"MiB", "GiB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/indexserver"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/osv"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/policy"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/proxy"
+)
+
+// shutdownTimeout 收到中断信号后等待进行中请求完成的时间
+const shutdownTimeout = 10 * time.Second
+
+// runServe 运行索引服务，直到收到中断信号
+//
+// 默认运行拉取代理，项目信息和文件缓存在缓存目录中（与crawl共用）；
+// --offline只提供已缓存的内容；--static提供mirror命令构建的静态目录树
+func runServe(a *app, args []string) error {
+	fs := a.flags("serve")
+	addr := fs.String("addr", "127.0.0.1:3141", "监听地址")
+	offlineMode := fs.Bool("offline", false, "不访问上游，只提供缓存目录中的内容")
+	static := fs.String("static", "", "提供mirror命令构建的离线镜像目录")
+	policyFile := fs.String("policy", "", "允许/禁止策略文件（YAML或JSON）")
+	osvDB := fs.String("osv-db", "", "策略的漏洞规则使用的OSV漏洞库（目录或zip文件），用于识别旧版本的漏洞")
+	ttl := fs.Duration("ttl", proxy.DefaultTTL, "代理缓存项目信息的有效期")
+	if _, err := a.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if *static != "" && (*offlineMode || *policyFile != "") {
+		return usagef("--static不能与--offline或--policy同时使用")
+	}
+	if *osvDB != "" && *policyFile == "" {
+		return usagef("--osv-db需要配合--policy使用")
+	}
+
+	var pol *policy.Policy
+	if *policyFile != "" {
+		var err error
+		if pol, err = policy.Load(*policyFile); err != nil {
+			return err
+		}
+		if *osvDB != "" {
+			db, err := osv.Load(*osvDB)
+			if err != nil {
+				return err
+			}
+			pol.WithVulnerabilityDB(db)
+		} else if pol.Vulnerabilities != nil {
+			fmt.Fprintln(a.stderr, "警告: 没有指定--osv-db，漏洞规则只能识别PyPI为最新版本列出的漏洞")
+		}
+	}
+
+	var handler http.Handler
+	cacheDir := filepath.Join(a.cacheDir, indexCacheDir)
+	switch {
+	case *static != "":
+		handler = http.FileServer(http.Dir(*static))
+	case *offlineMode:
+		handler = indexserver.NewServer(indexserver.NewDirStorage(cacheDir)).WithPolicy(pol)
+	default:
+		c, err := a.client()
+		if err != nil {
+			return err
+		}
+		handler = proxy.New(c, cacheDir).
+			WithHTTPClient(a.httpClient(proxy.DefaultDownloadTimeout)).
+			WithUserAgent(a.options().UserAgent).
+			WithTTL(*ttl).
+			WithPolicy(pol)
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 30 * time.Second}
+	fmt.Fprintf(a.stderr, "索引地址: http://%s/simple/\n", listener.Addr())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-a.ctx.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	<-done
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/audit"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/cvss"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/lockfile"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/reqfile"
+)
+
+// runVulns 审计包版本的已知漏洞，发现漏洞时以exitFindings退出
+// 包版本来自位置参数（name==version）、--lock指定的锁文件和-r指定的需求文件中固定了版本的依赖
+func runVulns(a *app, args []string) error {
+	fs := a.flags("vulns")
+	lock := fs.String("lock", "", "锁文件，支持poetry.lock、Pipfile.lock、pdm.lock、uv.lock和pylock.toml")
+	requirements := fs.String("r", "", "需求文件，只审计以==固定版本的依赖")
+	minSeverity := fs.String("min-severity", "", "只报告评级不低于该值的漏洞，如 HIGH")
+	args, err := a.parse(fs, args, 0, -1)
+	if err != nil {
+		return err
+	}
+	threshold := cvss.RatingUnknown
+	if *minSeverity != "" {
+		if threshold = cvss.ParseRating(*minSeverity); threshold == cvss.RatingUnknown {
+			return usagef("无法识别的评级 %q", *minSeverity)
+		}
+	}
+
+	var pins []models.Pin
+	for _, arg := range args {
+		pin, err := models.ParsePin(arg)
+		if err != nil {
+			return usagef("%v", err)
+		}
+		pins = append(pins, pin)
+	}
+	if *lock != "" {
+		l, err := lockfile.ParseFile(*lock)
+		if err != nil {
+			return err
+		}
+		pins = append(pins, l.Pins()...)
+	}
+	if *requirements != "" {
+		f, err := reqfile.ParseFile(*requirements)
+		if err != nil {
+			return err
+		}
+		for _, line := range f.Requirements {
+			if v, ok := line.Pinned(); ok {
+				pins = append(pins, models.Pin{Name: line.Name, Version: v})
+			} else {
+				fmt.Fprintf(a.stderr, "警告: %s 的 %s 没有固定版本，已跳过\n", line.Location(), line.Raw)
+			}
+		}
+	}
+	if len(pins) == 0 {
+		return usagef("没有要审计的包，请指定 name==version、--lock 或 -r")
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	report, err := audit.NewAuditor(c).
+		WithConcurrency(a.concurrency).
+		WithMinSeverity(threshold).
+		Audit(a.ctx, pins)
+	if err != nil {
+		return err
+	}
+
+	switch a.output {
+	case formatJSON, formatYAML:
+		var buf bytes.Buffer
+		if err := report.WriteJSON(&buf); err != nil {
+			return err
+		}
+		if a.output == formatJSON {
+			_, err = a.stdout.Write(buf.Bytes())
+		} else {
+			err = convertYAML(a.stdout, buf.Bytes())
+		}
+		if err != nil {
+			return err
+		}
+	default:
+		t := newTable("包", "版本", "漏洞", "评级", "修复版本", "摘要")
+		for _, result := range report.Results {
+			for _, f := range result.Findings {
+				fixed := ""
+				for _, affected := range f.Affected {
+					if affected.Name == result.Pin.Name && affected.Version == result.Pin.Version {
+						fixed = affected.FixedVersion
+					}
+				}
+				t.add(result.Pin.Name, result.Pin.Version, f.ID, f.Rating.String(), fixed, f.Summary)
+			}
+		}
+		if err := t.write(a.stdout); err != nil {
+			return err
+		}
+	}
+
+	failed := report.Errors()
+	for _, result := range failed {
+		fmt.Fprintf(a.stderr, "错误: 查询 %s 失败: %v\n", result.Pin, result.Err)
+	}
+	fmt.Fprintf(a.stderr, "审计了%d个包，发现%d个漏洞，%d个包查询失败\n", len(report.Results), len(report.Findings), len(failed))
+	switch {
+	case len(report.Findings) > 0:
+		return errFindings
+	case len(failed) > 0:
+		return fmt.Errorf("%d个包查询失败", len(failed))
+	}
+	return nil
+}
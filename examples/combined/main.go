@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/api"
 	"github.com/scagogogo/pypi-crawler/pkg/pypi/client"
 	"github.com/scagogogo/pypi-crawler/pkg/pypi/mirrors"
 )
@@ -111,7 +112,7 @@ func printUsage() {
 }
 
 // 根据选择创建客户端
-func createClient(mirrorSource string) client.Client {
+func createClient(mirrorSource string) api.PyPIClient {
 	// 设置自定义选项
 	options := client.NewOptions().
 		WithUserAgent("PyPI-Crawler-Example/1.0").
@@ -157,7 +158,7 @@ func getMirrorName(mirrorSource string) string {
 }
 
 // 处理info命令
-func handleInfoCommand(ctx context.Context, pypiClient client.Client, args []string) {
+func handleInfoCommand(ctx context.Context, pypiClient api.PyPIClient, args []string) {
 	if len(args) < 1 {
 		fmt.Println("错误: 缺少包名参数")
 		fmt.Println("用法: go run examples/combined/main.go info <package>")
@@ -206,7 +207,7 @@ func handleInfoCommand(ctx context.Context, pypiClient client.Client, args []str
 }
 
 // 处理version命令
-func handleVersionCommand(ctx context.Context, pypiClient client.Client, args []string) {
+func handleVersionCommand(ctx context.Context, pypiClient api.PyPIClient, args []string) {
 	if len(args) < 2 {
 		fmt.Println("错误: 需要包名和版本号")
 		fmt.Println("用法: go run examples/combined/main.go version <package> <version>")
@@ -238,7 +239,7 @@ func handleVersionCommand(ctx context.Context, pypiClient client.Client, args []
 }
 
 // 处理releases命令
-func handleReleasesCommand(ctx context.Context, pypiClient client.Client, args []string) {
+func handleReleasesCommand(ctx context.Context, pypiClient api.PyPIClient, args []string) {
 	if len(args) < 1 {
 		fmt.Println("错误: 缺少包名参数")
 		fmt.Println("用法: go run examples/combined/main.go releases <package>")
@@ -268,7 +269,7 @@ func handleReleasesCommand(ctx context.Context, pypiClient client.Client, args [
 }
 
 // 处理search命令
-func handleSearchCommand(ctx context.Context, pypiClient client.Client, args []string) {
+func handleSearchCommand(ctx context.Context, pypiClient api.PyPIClient, args []string) {
 	if len(args) < 1 {
 		fmt.Println("错误: 缺少搜索关键词")
 		fmt.Println("用法: go run examples/combined/main.go search <keyword> [limit]")
@@ -306,7 +307,7 @@ func handleSearchCommand(ctx context.Context, pypiClient client.Client, args []s
 }
 
 // 处理list命令
-func handleListCommand(ctx context.Context, pypiClient client.Client, args []string) {
+func handleListCommand(ctx context.Context, pypiClient api.PyPIClient, args []string) {
 	// 获取可选的限制参数
 	limit := 15
 	if len(args) > 0 {
@@ -340,7 +341,7 @@ func handleListCommand(ctx context.Context, pypiClient client.Client, args []str
 }
 
 // 处理check命令
-func handleCheckCommand(ctx context.Context, pypiClient client.Client, args []string) {
+func handleCheckCommand(ctx context.Context, pypiClient api.PyPIClient, args []string) {
 	if len(args) < 2 {
 		fmt.Println("错误: 需要包名和版本号")
 		fmt.Println("用法: go run examples/combined/main.go check <package> <version>")
@@ -3,10 +3,12 @@ module github.com/scagogogo/pypi-crawler
 go 1.19
 
 require (
+	github.com/BurntSushi/toml v1.3.2
 	github.com/PuerkitoBio/goquery v1.8.1
 	github.com/crawler-go-go-go/go-requests v0.0.0-20230525030146-0f17843cff2c
 	github.com/golang-infrastructure/go-project-root-directory v0.0.1
 	github.com/stretchr/testify v1.8.3
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -17,5 +19,4 @@ require (
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/net v0.10.0 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -86,3 +86,21 @@ type PyPIClient interface {
 	//   - error: 如有错误则返回，否则为nil
 	SearchPackages(ctx context.Context, keyword string, limit int) ([]string, error)
 }
+
+// CoreMetadataClient 能够只读取发布文件核心元数据的客户端
+// 这是PyPIClient之外的可选能力，调用方通过类型断言判断客户端是否支持，
+// 因此在PyPIClient之外实现该接口不会影响已有的实现
+type CoreMetadataClient interface {
+	// GetCoreMetadata 获取发布文件的核心元数据（METADATA）而无需下载整个文件
+	// 索引提供PEP 658/714的 .metadata 文件时直接下载该文件，
+	// 否则对wheel使用HTTP Range请求只读取其中的METADATA成员
+	//
+	// 参数:
+	//   - ctx: 上下文，用于控制请求的生命周期
+	//   - file: 发布文件信息，需要包含Filename和URL
+	//
+	// 返回值:
+	//   - *models.CoreMetadata: 解析后的核心元数据
+	//   - error: 如有错误则返回，否则为nil
+	GetCoreMetadata(ctx context.Context, file *models.ReleaseFile) (*models.CoreMetadata, error)
+}
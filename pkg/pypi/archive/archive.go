@@ -0,0 +1,369 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Kind 表示发布文件的归档类型
+type Kind string
+
+const (
+	// KindWheel wheel格式（zip）
+	KindWheel Kind = "wheel"
+
+	// KindSdist 源码包格式（tar.gz、tar.bz2或zip）
+	KindSdist Kind = "sdist"
+)
+
+// maxRetainedSize 源码包中单个元数据文件允许缓存的最大字节数
+const maxRetainedSize = 16 << 20
+
+// ErrNotRetained 表示请求的成员存在于源码包中，但未被缓存
+// tar格式只能顺序读取，因此只保留元数据相关的成员内容
+var ErrNotRetained = errors.New("归档成员内容未被保留")
+
+// File 表示归档中的一个成员
+type File struct {
+	// Name 成员在归档中的路径
+	Name string
+
+	// Size 成员未压缩时的大小（字节）
+	Size int64
+
+	// CompressedSize 成员压缩后的大小（字节），tar格式为0
+	CompressedSize int64
+
+	// IsDir 是否为目录
+	IsDir bool
+}
+
+// Archive 表示一个已打开的wheel或源码包
+// 可以在不依赖Python的情况下检查其中的元数据和文件列表
+type Archive struct {
+	// Filename 发布文件名，用于判断格式和推断目录
+	Filename string
+
+	// Kind 归档类型
+	Kind Kind
+
+	// Files 归档中所有成员的列表，按路径排序
+	Files []File
+
+	zipReader *zip.Reader
+	retained  map[string][]byte
+	closer    io.Closer
+}
+
+// Open 从io.ReaderAt打开一个发布文件
+//
+// 参数:
+//   - r: 发布文件内容
+//   - size: 发布文件大小（字节）
+//   - filename: 发布文件名，如 "requests-2.31.0-py3-none-any.whl"
+//
+// 返回值:
+//   - *Archive: 打开的归档
+//   - error: 如有错误则返回，否则为nil
+//
+// 使用示例:
+//
+//	data, _ := os.ReadFile("requests-2.31.0-py3-none-any.whl")
+//	a, err := archive.Open(bytes.NewReader(data), int64(len(data)), "requests-2.31.0-py3-none-any.whl")
+func Open(r io.ReaderAt, size int64, filename string) (*Archive, error) {
+	lower := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(lower, ".whl"):
+		return openZip(r, size, filename, KindWheel)
+	case strings.HasSuffix(lower, ".zip"):
+		return openZip(r, size, filename, KindSdist)
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		gz, err := gzip.NewReader(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return nil, fmt.Errorf("解压 %s 失败: %w", filename, err)
+		}
+		defer gz.Close()
+		return openTar(gz, filename)
+	case strings.HasSuffix(lower, ".tar.bz2"):
+		return openTar(bzip2.NewReader(io.NewSectionReader(r, 0, size)), filename)
+	case strings.HasSuffix(lower, ".tar"):
+		return openTar(io.NewSectionReader(r, 0, size), filename)
+	default:
+		return nil, fmt.Errorf("不支持的发布文件格式: %s", filename)
+	}
+}
+
+// OpenFile 打开本地磁盘上的发布文件
+// wheel会保持文件句柄打开以便按需读取成员，使用完毕后需调用Close
+//
+// 参数:
+//   - filePath: 发布文件路径，文件名用于判断格式
+//
+// 返回值:
+//   - *Archive: 打开的归档
+//   - error: 如有错误则返回，否则为nil
+func OpenFile(filePath string) (*Archive, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	a, err := Open(f, stat.Size(), path.Base(strings.ReplaceAll(filePath, "\\", "/")))
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if a.zipReader != nil {
+		a.closer = f
+	} else {
+		f.Close()
+	}
+	return a, nil
+}
+
+// Close 释放归档占用的资源
+func (a *Archive) Close() error {
+	if a.closer == nil {
+		return nil
+	}
+	err := a.closer.Close()
+	a.closer = nil
+	return err
+}
+
+func openZip(r io.ReaderAt, size int64, filename string, kind Kind) (*Archive, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("读取zip归档 %s 失败: %w", filename, err)
+	}
+
+	a := &Archive{Filename: filename, Kind: kind, zipReader: zr}
+	for _, f := range zr.File {
+		a.Files = append(a.Files, File{
+			Name:           f.Name,
+			Size:           int64(f.UncompressedSize64),
+			CompressedSize: int64(f.CompressedSize64),
+			IsDir:          f.FileInfo().IsDir(),
+		})
+	}
+	a.sortFiles()
+	return a, nil
+}
+
+func openTar(r io.Reader, filename string) (*Archive, error) {
+	a := &Archive{Filename: filename, Kind: KindSdist, retained: make(map[string][]byte)}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取tar归档 %s 失败: %w", filename, err)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeXGlobalHeader, tar.TypeXHeader:
+			continue
+		}
+
+		name := strings.TrimPrefix(hdr.Name, "./")
+		a.Files = append(a.Files, File{
+			Name:  name,
+			Size:  hdr.Size,
+			IsDir: hdr.Typeflag == tar.TypeDir,
+		})
+
+		if hdr.Typeflag == tar.TypeReg && isMetadataMember(name) && hdr.Size <= maxRetainedSize {
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, fmt.Errorf("读取 %s 中的 %s 失败: %w", filename, name, err)
+			}
+			a.retained[name] = data
+		}
+	}
+	a.sortFiles()
+	return a, nil
+}
+
+// isMetadataMember 判断源码包成员是否属于需要保留的元数据文件
+func isMetadataMember(name string) bool {
+	switch path.Base(name) {
+	case "PKG-INFO", "METADATA", "entry_points.txt", "top_level.txt", "requires.txt",
+		"setup.cfg", "pyproject.toml", "RECORD", "WHEEL", "SOURCES.txt":
+		return true
+	}
+	return false
+}
+
+func (a *Archive) sortFiles() {
+	sort.Slice(a.Files, func(i, j int) bool {
+		return a.Files[i].Name < a.Files[j].Name
+	})
+}
+
+// ReadFile 读取归档中指定成员的内容
+// 对于tar格式的源码包，只有元数据相关成员可读，其余成员返回ErrNotRetained
+//
+// 参数:
+//   - name: 成员在归档中的完整路径
+//
+// 返回值:
+//   - []byte: 成员内容
+//   - error: 成员不存在时返回os.ErrNotExist
+func (a *Archive) ReadFile(name string) ([]byte, error) {
+	if a.zipReader != nil {
+		for _, f := range a.zipReader.File {
+			if f.Name != name {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("打开归档成员 %s 失败: %w", name, err)
+			}
+			defer rc.Close()
+			return io.ReadAll(rc)
+		}
+		return nil, fmt.Errorf("归档成员 %s: %w", name, os.ErrNotExist)
+	}
+
+	if data, ok := a.retained[name]; ok {
+		return data, nil
+	}
+	for _, f := range a.Files {
+		if f.Name == name {
+			return nil, fmt.Errorf("归档成员 %s: %w", name, ErrNotRetained)
+		}
+	}
+	return nil, fmt.Errorf("归档成员 %s: %w", name, os.ErrNotExist)
+}
+
+// DistInfoDir 返回wheel中的 .dist-info 目录名（不含结尾斜杠）
+// 源码包或找不到时返回空字符串
+func (a *Archive) DistInfoDir() string {
+	if a.Kind != KindWheel {
+		return ""
+	}
+
+	// 优先选择与wheel文件名中的项目名和版本匹配的目录
+	if parts := strings.SplitN(a.Filename, "-", 3); len(parts) >= 2 {
+		expected := parts[0] + "-" + parts[1] + ".dist-info"
+		for _, f := range a.Files {
+			if strings.EqualFold(f.Name, expected+"/METADATA") {
+				return f.Name[:len(expected)]
+			}
+		}
+	}
+
+	for _, f := range a.Files {
+		dir, base := path.Split(f.Name)
+		if base == "METADATA" && strings.Count(dir, "/") == 1 && strings.HasSuffix(dir, ".dist-info/") {
+			return strings.TrimSuffix(dir, "/")
+		}
+	}
+	return ""
+}
+
+// metadataMember 查找指定名称的元数据文件路径
+// wheel在 .dist-info 目录中查找，源码包优先查找顶层目录，其次查找 .egg-info 目录
+func (a *Archive) metadataMember(base string) string {
+	if a.Kind == KindWheel {
+		dir := a.DistInfoDir()
+		if dir == "" {
+			return ""
+		}
+		name := dir + "/" + base
+		for _, f := range a.Files {
+			if f.Name == name {
+				return name
+			}
+		}
+		return ""
+	}
+
+	best := ""
+	bestDepth := -1
+	for _, f := range a.Files {
+		if f.IsDir || path.Base(f.Name) != base {
+			continue
+		}
+		dir := path.Dir(f.Name)
+		depth := strings.Count(f.Name, "/")
+		// 顶层目录下的文件（如 pkg-1.0/PKG-INFO）最优先
+		if base == "PKG-INFO" && depth == 1 {
+			return f.Name
+		}
+		if base != "PKG-INFO" && !strings.HasSuffix(dir, ".egg-info") {
+			continue
+		}
+		if bestDepth == -1 || depth < bestDepth {
+			best, bestDepth = f.Name, depth
+		}
+	}
+	return best
+}
+
+// readMetadataMember 读取指定名称的元数据文件
+// 文件不存在时返回os.ErrNotExist
+func (a *Archive) readMetadataMember(base string) ([]byte, error) {
+	name := a.metadataMember(base)
+	if name == "" {
+		return nil, fmt.Errorf("%s 中没有 %s: %w", a.Filename, base, os.ErrNotExist)
+	}
+	return a.ReadFile(name)
+}
+
+// NativeExtensions 返回归档中的本地扩展模块（.so、.pyd、.dylib、.dll）
+// 可用于判断依赖是否包含需要编译的二进制代码
+func (a *Archive) NativeExtensions() []File {
+	var result []File
+	for _, f := range a.Files {
+		if f.IsDir {
+			continue
+		}
+		base := strings.ToLower(path.Base(f.Name))
+		if strings.HasSuffix(base, ".so") || strings.Contains(base, ".so.") ||
+			strings.HasSuffix(base, ".pyd") || strings.HasSuffix(base, ".dylib") ||
+			strings.HasSuffix(base, ".dll") {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// TotalSize 返回归档中所有文件未压缩时的总大小
+func (a *Archive) TotalSize() int64 {
+	var total int64
+	for _, f := range a.Files {
+		total += f.Size
+	}
+	return total
+}
+
+// OpenBytes 从内存中的发布文件内容打开归档
+//
+// 参数:
+//   - data: 发布文件内容
+//   - filename: 发布文件名，用于判断格式
+//
+// 返回值:
+//   - *Archive: 打开的归档
+//   - error: 如有错误则返回，否则为nil
+func OpenBytes(data []byte, filename string) (*Archive, error) {
+	return Open(bytes.NewReader(data), int64(len(data)), filename)
+}
@@ -0,0 +1,284 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMetadata = `Metadata-Version: 2.1
+Name: demo
+Version: 1.0.0
+Summary: 演示包
+Classifier: Programming Language :: Python :: 3
+Classifier: License :: OSI Approved :: MIT License
+Requires-Dist: requests>=2.0
+Requires-Dist: rich ; extra == "cli"
+License: MIT
+  License text continued
+
+# Demo
+
+长描述内容
+`
+
+const testEntryPoints = `[console_scripts]
+demo = demo.cli:main
+demo-extra = demo.cli:extra [cli, color]
+
+[demo.plugins]
+core = demo.plugins
+`
+
+// buildWheel 在内存中构建一个wheel，tamper为true时篡改一个文件的内容
+func buildWheel(t *testing.T, tamper bool) []byte {
+	files := []struct{ name, content string }{
+		{"demo/__init__.py", "print('demo')\n"},
+		{"demo/_speedups.cpython-311-x86_64-linux-gnu.so", "\x7fELF"},
+		{"demo-1.0.0.dist-info/METADATA", testMetadata},
+		{"demo-1.0.0.dist-info/WHEEL", "Wheel-Version: 1.0\nGenerator: bdist_wheel (0.40.0)\nRoot-Is-Purelib: false\nTag: cp311-cp311-manylinux_2_17_x86_64\nTag: cp311-cp311-manylinux2014_x86_64\n"},
+		{"demo-1.0.0.dist-info/entry_points.txt", testEntryPoints},
+		{"demo-1.0.0.dist-info/top_level.txt", "demo\n"},
+	}
+
+	var record bytes.Buffer
+	for _, f := range files {
+		sum := sha256.Sum256([]byte(f.content))
+		fmt.Fprintf(&record, "%s,sha256=%s,%d\n", f.name, base64.RawURLEncoding.EncodeToString(sum[:]), len(f.content))
+	}
+	record.WriteString("demo-1.0.0.dist-info/RECORD,,\n")
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		require.NoError(t, err)
+		content := f.content
+		if tamper && f.name == "demo/__init__.py" {
+			content = "import os\n"
+		}
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	w, err := zw.Create("demo-1.0.0.dist-info/RECORD")
+	require.NoError(t, err)
+	_, err = w.Write(record.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+// buildSdist 在内存中构建一个tar.gz格式的源码包
+func buildSdist(t *testing.T) []byte {
+	files := []struct{ name, content string }{
+		{"demo-1.0.0/PKG-INFO", testMetadata},
+		{"demo-1.0.0/setup.py", "from setuptools import setup\nsetup()\n"},
+		{"demo-1.0.0/demo.egg-info/PKG-INFO", testMetadata},
+		{"demo-1.0.0/demo.egg-info/entry_points.txt", testEntryPoints},
+		{"demo-1.0.0/demo.egg-info/top_level.txt", "demo\n"},
+		{"demo-1.0.0/demo/__init__.py", "print('demo')\n"},
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "demo-1.0.0/", Typeflag: tar.TypeDir, Mode: 0755}))
+	for _, f := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: f.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(f.content))}))
+		_, err := tw.Write([]byte(f.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestOpen(t *testing.T) {
+	t.Run("打开wheel", func(t *testing.T) {
+		a, err := OpenBytes(buildWheel(t, false), "demo-1.0.0-cp311-cp311-manylinux_2_17_x86_64.whl")
+		require.NoError(t, err)
+		assert.Equal(t, KindWheel, a.Kind)
+		assert.Len(t, a.Files, 7)
+		assert.Equal(t, "demo-1.0.0.dist-info", a.DistInfoDir())
+		assert.Equal(t, "demo-1.0.0.dist-info/METADATA", a.Files[0].Name)
+		assert.Equal(t, int64(len(testMetadata)), a.Files[0].Size)
+		assert.Greater(t, a.TotalSize(), int64(0))
+	})
+
+	t.Run("打开源码包", func(t *testing.T) {
+		a, err := OpenBytes(buildSdist(t), "demo-1.0.0.tar.gz")
+		require.NoError(t, err)
+		assert.Equal(t, KindSdist, a.Kind)
+		assert.Len(t, a.Files, 7)
+		assert.True(t, a.Files[0].IsDir)
+		assert.Empty(t, a.DistInfoDir())
+
+		_, err = a.ReadFile("demo-1.0.0/demo/__init__.py")
+		assert.True(t, errors.Is(err, ErrNotRetained))
+
+		_, err = a.ReadFile("demo-1.0.0/missing.py")
+		assert.True(t, errors.Is(err, os.ErrNotExist))
+	})
+
+	t.Run("不支持的格式", func(t *testing.T) {
+		_, err := OpenBytes([]byte("data"), "demo-1.0.0.exe")
+		assert.Error(t, err)
+	})
+
+	t.Run("损坏的wheel", func(t *testing.T) {
+		_, err := OpenBytes([]byte("not a zip"), "demo-1.0.0-py3-none-any.whl")
+		assert.Error(t, err)
+	})
+
+	t.Run("从磁盘打开", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "demo-1.0.0-py3-none-any.whl")
+		require.NoError(t, os.WriteFile(filePath, buildWheel(t, false), 0644))
+
+		a, err := OpenFile(filePath)
+		require.NoError(t, err)
+		defer a.Close()
+
+		data, err := a.ReadFile("demo-1.0.0.dist-info/top_level.txt")
+		require.NoError(t, err)
+		assert.Equal(t, "demo\n", string(data))
+	})
+}
+
+func TestArchive_Metadata(t *testing.T) {
+	for name, a := range map[string]func() (*Archive, error){
+		"wheel": func() (*Archive, error) { return OpenBytes(buildWheel(t, false), "demo-1.0.0-py3-none-any.whl") },
+		"源码包":   func() (*Archive, error) { return OpenBytes(buildSdist(t), "demo-1.0.0.tar.gz") },
+	} {
+		t.Run(name, func(t *testing.T) {
+			archive, err := a()
+			require.NoError(t, err)
+
+			m, err := archive.Metadata()
+			require.NoError(t, err)
+			assert.Equal(t, "2.1", m.Get("Metadata-Version"))
+			assert.Equal(t, "demo", m.Get("name"))
+			assert.Equal(t, []string{"requests>=2.0", `rich ; extra == "cli"`}, m.Values("Requires-Dist"))
+			assert.Equal(t, "MIT\n  License text continued", m.Get("License"))
+			assert.Equal(t, "# Demo\n\n长描述内容\n", m.Body)
+			assert.Empty(t, m.Get("Home-page"))
+		})
+	}
+}
+
+func TestArchive_WheelInfo(t *testing.T) {
+	a, err := OpenBytes(buildWheel(t, false), "demo-1.0.0-cp311-cp311-manylinux_2_17_x86_64.whl")
+	require.NoError(t, err)
+
+	info, err := a.WheelInfo()
+	require.NoError(t, err)
+	assert.Equal(t, "1.0", info.WheelVersion)
+	assert.False(t, info.RootIsPurelib)
+	assert.Equal(t, []string{"cp311-cp311-manylinux_2_17_x86_64", "cp311-cp311-manylinux2014_x86_64"}, info.Tags)
+
+	sdist, err := OpenBytes(buildSdist(t), "demo-1.0.0.tar.gz")
+	require.NoError(t, err)
+	_, err = sdist.WheelInfo()
+	assert.Error(t, err)
+}
+
+func TestArchive_EntryPoints(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		data     []byte
+		filename string
+	}{
+		{"wheel", buildWheel(t, false), "demo-1.0.0-py3-none-any.whl"},
+		{"源码包", buildSdist(t), "demo-1.0.0.tar.gz"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a, err := OpenBytes(tc.data, tc.filename)
+			require.NoError(t, err)
+
+			entryPoints, err := a.EntryPoints()
+			require.NoError(t, err)
+			require.Len(t, entryPoints, 3)
+			assert.Equal(t, EntryPoint{
+				Group:  "console_scripts",
+				Name:   "demo-extra",
+				Value:  "demo.cli:extra [cli, color]",
+				Module: "demo.cli",
+				Attr:   "extra",
+				Extras: []string{"cli", "color"},
+			}, entryPoints[1])
+			assert.Equal(t, "demo.plugins", entryPoints[2].Module)
+			assert.Empty(t, entryPoints[2].Attr)
+
+			scripts, err := a.Scripts()
+			require.NoError(t, err)
+			assert.Len(t, scripts, 2)
+
+			topLevel, err := a.TopLevel()
+			require.NoError(t, err)
+			assert.Equal(t, []string{"demo"}, topLevel)
+		})
+	}
+}
+
+func TestArchive_VerifyRecord(t *testing.T) {
+	t.Run("校验通过", func(t *testing.T) {
+		a, err := OpenBytes(buildWheel(t, false), "demo-1.0.0-py3-none-any.whl")
+		require.NoError(t, err)
+
+		entries, err := a.Record()
+		require.NoError(t, err)
+		assert.Len(t, entries, 7)
+		assert.Equal(t, int64(-1), entries[6].Size)
+
+		problems, err := a.VerifyRecord()
+		require.NoError(t, err)
+		assert.Empty(t, problems)
+	})
+
+	t.Run("文件被篡改", func(t *testing.T) {
+		a, err := OpenBytes(buildWheel(t, true), "demo-1.0.0-py3-none-any.whl")
+		require.NoError(t, err)
+
+		problems, err := a.VerifyRecord()
+		require.NoError(t, err)
+		require.Len(t, problems, 2)
+		assert.Equal(t, "demo/__init__.py", problems[0].Path)
+		assert.Contains(t, problems[0].Problem, "大小不匹配")
+		assert.Contains(t, problems[1].Problem, "哈希不匹配")
+	})
+}
+
+func TestArchive_NativeExtensions(t *testing.T) {
+	a, err := OpenBytes(buildWheel(t, false), "demo-1.0.0-py3-none-any.whl")
+	require.NoError(t, err)
+
+	native := a.NativeExtensions()
+	require.Len(t, native, 1)
+	assert.Equal(t, "demo/_speedups.cpython-311-x86_64-linux-gnu.so", native[0].Name)
+
+	sdist, err := OpenBytes(buildSdist(t), "demo-1.0.0.tar.gz")
+	require.NoError(t, err)
+	assert.Empty(t, sdist.NativeExtensions())
+}
+
+func TestArchive_CoreMetadata(t *testing.T) {
+	a, err := OpenBytes(buildWheel(t, false), "demo-1.0.0-py3-none-any.whl")
+	require.NoError(t, err)
+
+	m, report, err := a.CoreMetadata()
+	require.NoError(t, err)
+	assert.True(t, report.Valid(), report.Invalid)
+	assert.Equal(t, "demo", m.Name)
+	assert.Equal(t, "MIT\nLicense text continued", m.License)
+	assert.Equal(t, "# Demo\n\n长描述内容\n", m.Description)
+}
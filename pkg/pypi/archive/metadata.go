@@ -0,0 +1,206 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/metadata"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+)
+
+// Metadata 表示 METADATA 或 PKG-INFO 文件的原始头字段和消息体
+type Metadata = metadata.Message
+
+// Metadata 解析归档中的核心元数据
+// wheel读取 .dist-info/METADATA，源码包读取顶层的 PKG-INFO
+func (a *Archive) Metadata() (*Metadata, error) {
+	base := "PKG-INFO"
+	if a.Kind == KindWheel {
+		base = "METADATA"
+	}
+	data, err := a.readMetadataMember(base)
+	if err != nil {
+		return nil, err
+	}
+	return metadata.ParseMessage(data), nil
+}
+
+// CoreMetadata 解析归档中的核心元数据并进行校验
+//
+// 返回值:
+//   - *models.CoreMetadata: 解析后的元数据
+//   - *metadata.Report: 缺失、无效和未知字段的报告
+//   - error: 元数据文件不存在或无法读取时返回
+func (a *Archive) CoreMetadata() (*models.CoreMetadata, *metadata.Report, error) {
+	base := "PKG-INFO"
+	if a.Kind == KindWheel {
+		base = "METADATA"
+	}
+	data, err := a.readMetadataMember(base)
+	if err != nil {
+		return nil, nil, err
+	}
+	m, report := metadata.ParseWithReport(data)
+	return m, report, nil
+}
+
+// WheelInfo 表示wheel中 WHEEL 文件的内容
+type WheelInfo struct {
+	// WheelVersion wheel格式版本，如 "1.0"
+	WheelVersion string
+
+	// Generator 生成wheel的工具，如 "bdist_wheel (0.40.0)"
+	Generator string
+
+	// RootIsPurelib 根目录是否安装到purelib
+	RootIsPurelib bool
+
+	// Tags 兼容性标签，如 "py3-none-any"
+	Tags []string
+
+	// Build 可选的构建号
+	Build string
+}
+
+// WheelInfo 解析wheel中的 WHEEL 文件
+func (a *Archive) WheelInfo() (*WheelInfo, error) {
+	if a.Kind != KindWheel {
+		return nil, fmt.Errorf("%s 不是wheel文件", a.Filename)
+	}
+	data, err := a.readMetadataMember("WHEEL")
+	if err != nil {
+		return nil, err
+	}
+
+	m := metadata.ParseMessage(data)
+	return &WheelInfo{
+		WheelVersion:  m.Get("Wheel-Version"),
+		Generator:     m.Get("Generator"),
+		RootIsPurelib: strings.EqualFold(m.Get("Root-Is-Purelib"), "true"),
+		Tags:          m.Values("Tag"),
+		Build:         m.Get("Build"),
+	}, nil
+}
+
+// EntryPoint 表示 entry_points.txt 中的一个入口点
+type EntryPoint struct {
+	// Group 入口点分组，如 "console_scripts"
+	Group string
+
+	// Name 入口点名称，对于console_scripts即命令名
+	Name string
+
+	// Value 原始值，如 "pkg.cli:main [extra]"
+	Value string
+
+	// Module 模块路径
+	Module string
+
+	// Attr 模块内的对象路径，可能为空
+	Attr string
+
+	// Extras 入口点依赖的可选功能
+	Extras []string
+}
+
+// IsScript 检查入口点是否会生成可执行命令
+func (e *EntryPoint) IsScript() bool {
+	return e.Group == "console_scripts" || e.Group == "gui_scripts"
+}
+
+// parseEntryPoints 解析INI格式的 entry_points.txt
+func parseEntryPoints(data []byte) []EntryPoint {
+	var result []EntryPoint
+	group := ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			group = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		eq := strings.Index(line, "=")
+		if eq <= 0 || group == "" {
+			continue
+		}
+		ep := EntryPoint{
+			Group: group,
+			Name:  strings.TrimSpace(line[:eq]),
+			Value: strings.TrimSpace(line[eq+1:]),
+		}
+
+		object := ep.Value
+		if open := strings.Index(object, "["); open >= 0 {
+			extras := strings.TrimSuffix(strings.TrimSpace(object[open+1:]), "]")
+			for _, extra := range strings.Split(extras, ",") {
+				if extra = strings.TrimSpace(extra); extra != "" {
+					ep.Extras = append(ep.Extras, extra)
+				}
+			}
+			object = strings.TrimSpace(object[:open])
+		}
+		if colon := strings.Index(object, ":"); colon >= 0 {
+			ep.Module = strings.TrimSpace(object[:colon])
+			ep.Attr = strings.TrimSpace(object[colon+1:])
+		} else {
+			ep.Module = object
+		}
+		result = append(result, ep)
+	}
+	return result
+}
+
+// EntryPoints 解析归档中的 entry_points.txt
+// 文件不存在时返回空列表
+func (a *Archive) EntryPoints() ([]EntryPoint, error) {
+	if a.metadataMember("entry_points.txt") == "" {
+		return nil, nil
+	}
+	data, err := a.readMetadataMember("entry_points.txt")
+	if err != nil {
+		return nil, err
+	}
+	return parseEntryPoints(data), nil
+}
+
+// Scripts 返回归档安装后会生成的命令（console_scripts和gui_scripts）
+func (a *Archive) Scripts() ([]EntryPoint, error) {
+	entryPoints, err := a.EntryPoints()
+	if err != nil {
+		return nil, err
+	}
+	var scripts []EntryPoint
+	for _, ep := range entryPoints {
+		if ep.IsScript() {
+			scripts = append(scripts, ep)
+		}
+	}
+	return scripts, nil
+}
+
+// TopLevel 解析归档中的 top_level.txt，返回顶层导入名
+// 文件不存在时返回空列表
+func (a *Archive) TopLevel() ([]string, error) {
+	if a.metadataMember("top_level.txt") == "" {
+		return nil, nil
+	}
+	data, err := a.readMetadataMember("top_level.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			names = append(names, line)
+		}
+	}
+	return names, nil
+}
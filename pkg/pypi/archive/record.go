@@ -0,0 +1,171 @@
+package archive
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+)
+
+// RecordEntry 表示wheel中 RECORD 文件的一行
+type RecordEntry struct {
+	// Path 文件在wheel中的路径
+	Path string
+
+	// Algorithm 哈希算法，如 "sha256"，RECORD自身为空
+	Algorithm string
+
+	// Digest URL安全、无填充的base64哈希值
+	Digest string
+
+	// Size 文件大小，未记录时为-1
+	Size int64
+}
+
+// RecordProblem 表示RECORD校验时发现的问题
+type RecordProblem struct {
+	// Path 出现问题的文件路径
+	Path string
+
+	// Problem 问题描述
+	Problem string
+}
+
+// String 返回问题的可读描述
+func (p RecordProblem) String() string {
+	return p.Path + ": " + p.Problem
+}
+
+// parseRecord 解析CSV格式的 RECORD 内容
+func parseRecord(data []byte) ([]RecordEntry, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	var entries []RecordEntry
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析RECORD失败: %w", err)
+		}
+		if len(row) == 0 || row[0] == "" {
+			continue
+		}
+
+		entry := RecordEntry{Path: row[0], Size: -1}
+		if len(row) > 1 && row[1] != "" {
+			if eq := strings.Index(row[1], "="); eq > 0 {
+				entry.Algorithm = row[1][:eq]
+				entry.Digest = row[1][eq+1:]
+			}
+		}
+		if len(row) > 2 && row[2] != "" {
+			size, err := strconv.ParseInt(row[2], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("RECORD中 %s 的大小无效: %q", entry.Path, row[2])
+			}
+			entry.Size = size
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Record 解析wheel中的 RECORD 文件
+func (a *Archive) Record() ([]RecordEntry, error) {
+	if a.Kind != KindWheel {
+		return nil, fmt.Errorf("%s 不是wheel文件", a.Filename)
+	}
+	data, err := a.readMetadataMember("RECORD")
+	if err != nil {
+		return nil, err
+	}
+	return parseRecord(data)
+}
+
+// VerifyRecord 根据RECORD校验wheel中每个文件的哈希值和大小
+// 同时报告RECORD中缺失的文件和归档中不存在的记录
+//
+// 返回值:
+//   - []RecordProblem: 发现的问题列表，为空表示校验通过
+//   - error: 读取或解析失败时返回
+func (a *Archive) VerifyRecord() ([]RecordProblem, error) {
+	entries, err := a.Record()
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []RecordProblem
+	recorded := make(map[string]bool, len(entries))
+	distInfo := a.DistInfoDir()
+
+	for _, entry := range entries {
+		recorded[entry.Path] = true
+
+		data, err := a.ReadFile(entry.Path)
+		if err != nil {
+			problems = append(problems, RecordProblem{Path: entry.Path, Problem: "文件不存在"})
+			continue
+		}
+
+		if entry.Size >= 0 && entry.Size != int64(len(data)) {
+			problems = append(problems, RecordProblem{
+				Path:    entry.Path,
+				Problem: fmt.Sprintf("大小不匹配: 记录为 %d，实际为 %d", entry.Size, len(data)),
+			})
+		}
+
+		if entry.Algorithm == "" {
+			// 只有RECORD自身及其签名文件允许不带哈希
+			if !isRecordFile(entry.Path, distInfo) {
+				problems = append(problems, RecordProblem{Path: entry.Path, Problem: "缺少哈希值"})
+			}
+			continue
+		}
+
+		h := models.NewDigestHash(entry.Algorithm)
+		if h == nil {
+			problems = append(problems, RecordProblem{
+				Path:    entry.Path,
+				Problem: fmt.Sprintf("不支持的哈希算法: %s", entry.Algorithm),
+			})
+			continue
+		}
+		h.Write(data)
+		actual := base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+		if actual != strings.TrimRight(entry.Digest, "=") {
+			problems = append(problems, RecordProblem{
+				Path:    entry.Path,
+				Problem: fmt.Sprintf("哈希不匹配: 记录为 %s，实际为 %s", entry.Digest, actual),
+			})
+		}
+	}
+
+	for _, f := range a.Files {
+		if f.IsDir || recorded[f.Name] || isRecordFile(f.Name, distInfo) {
+			continue
+		}
+		problems = append(problems, RecordProblem{Path: f.Name, Problem: "未记录在RECORD中"})
+	}
+	return problems, nil
+}
+
+// isRecordFile 判断路径是否为RECORD或其签名文件
+func isRecordFile(name, distInfo string) bool {
+	if path.Dir(name) != distInfo {
+		return false
+	}
+	switch path.Base(name) {
+	case "RECORD", "RECORD.jws", "RECORD.p7s":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,329 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/api"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/cvss"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+)
+
+// ToolName 写入报告的工具名称
+const ToolName = "pypi-crawler"
+
+// ToolURI 写入SARIF报告的工具主页
+const ToolURI = "https://github.com/scagogogo/pypi-crawler"
+
+// DefaultConcurrency 默认的并发请求数
+const DefaultConcurrency = 4
+
+// Result 一个包的审计结果
+type Result struct {
+	// Pin 被审计的包及版本
+	Pin models.Pin
+
+	// Findings 影响该版本的漏洞，按严重程度从高到低排列
+	Findings []*Finding
+
+	// Upgrade 修复所有Findings的最小升级版本，没有漏洞或无法确定时为空
+	Upgrade string
+
+	// Unverifiable 包通过直接引用（VCS、本地路径等）安装，无法从索引查询漏洞
+	Unverifiable bool
+
+	// Err 查询失败的原因，此时结果不完整
+	Err error
+
+	vulns []models.Vulnerability
+}
+
+// Vulnerable 检查包是否受任何漏洞影响
+func (r *Result) Vulnerable() bool {
+	return len(r.Findings) > 0
+}
+
+// OK 检查包是否通过审计（没有漏洞且查询成功）
+func (r *Result) OK() bool {
+	return !r.Vulnerable() && r.Err == nil
+}
+
+// Report 多个包的漏洞审计报告
+type Report struct {
+	// Timestamp 生成时间
+	Timestamp time.Time
+
+	// Results 每个包的审计结果，与输入的顺序一致
+	Results []*Result
+
+	// Findings 去重后的漏洞，按严重程度从高到低排列
+	Findings []*Finding
+}
+
+// Vulnerable 返回受漏洞影响的包
+func (r *Report) Vulnerable() []*Result {
+	var results []*Result
+	for _, result := range r.Results {
+		if result.Vulnerable() {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// Errors 返回查询失败的包
+func (r *Report) Errors() []*Result {
+	var results []*Result
+	for _, result := range r.Results {
+		if result.Err != nil {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// Finding 按ID或别名（如CVE编号）查找漏洞
+func (r *Report) Finding(id string) *Finding {
+	for _, f := range r.Findings {
+		if f.Has(id) {
+			return f
+		}
+	}
+	return nil
+}
+
+// Summary 按严重程度评级统计漏洞数量
+func (r *Report) Summary() map[cvss.Rating]int {
+	summary := map[cvss.Rating]int{}
+	for _, f := range r.Findings {
+		summary[f.Rating]++
+	}
+	return summary
+}
+
+// OK 检查所有包是否都通过审计
+func (r *Report) OK() bool {
+	for _, result := range r.Results {
+		if !result.OK() {
+			return false
+		}
+	}
+	return true
+}
+
+// Auditor 通过PyPI客户端并发审计多个包版本的漏洞
+type Auditor struct {
+	client      api.PyPIClient
+	concurrency int
+	withdrawn   bool
+	threshold   cvss.Rating
+}
+
+// NewAuditor 创建审计器，默认并发数为DefaultConcurrency，排除已撤回的漏洞
+//
+// 参数:
+//   - c: PyPI客户端。PyPI的JSON API不提供严重程度，需要评级时可使用osv.Client
+//
+// 使用示例:
+//
+//	lock, err := lockfile.ParseFile("poetry.lock")
+//	if err != nil {
+//		return err
+//	}
+//	report, err := audit.NewAuditor(mirrors.NewOfficialClient()).Audit(ctx, lock.Pins())
+//	if err != nil {
+//		return err
+//	}
+//	return report.WriteSARIF(os.Stdout, "poetry.lock")
+func NewAuditor(c api.PyPIClient) *Auditor {
+	return &Auditor{client: c, concurrency: DefaultConcurrency}
+}
+
+// WithConcurrency 设置并发请求数，小于1时按1处理
+func (a *Auditor) WithConcurrency(n int) *Auditor {
+	if n < 1 {
+		n = 1
+	}
+	a.concurrency = n
+	return a
+}
+
+// WithWithdrawn 设置是否包含已撤回的漏洞
+func (a *Auditor) WithWithdrawn(include bool) *Auditor {
+	a.withdrawn = include
+	return a
+}
+
+// WithMinSeverity 只报告评级不低于threshold的漏洞，评级未知的漏洞也会被排除
+// threshold为cvss.RatingUnknown（默认）时报告所有漏洞
+func (a *Auditor) WithMinSeverity(threshold cvss.Rating) *Auditor {
+	a.threshold = threshold
+	return a
+}
+
+// Audit 并发查询每个包版本的漏洞，合并不同数据源对同一漏洞的记录并生成报告
+// 查询失败记录在Result.Err中，不会中止整个审计；同一包版本出现多次时只查询一次
+//
+// 参数:
+//   - ctx: 上下文，用于控制请求的生命周期
+//   - pins: 要审计的包及版本，可由models.ParsePin或lockfile包得到
+//
+// 返回值:
+//   - *Report: 审计报告
+//   - error: 仅在上下文被取消时返回
+func (a *Auditor) Audit(ctx context.Context, pins []models.Pin) (*Report, error) {
+	report := &Report{Timestamp: time.Now().UTC(), Results: make([]*Result, len(pins))}
+	var unique []*Result
+	seen := map[string]*Result{}
+	for i, pin := range pins {
+		key := pin.NormalizedName() + "@" + pin.Version
+		if result, ok := seen[key]; ok && !pin.IsDirect() {
+			report.Results[i] = result
+			continue
+		}
+		result := &Result{Pin: pin}
+		seen[key] = result
+		report.Results[i] = result
+		unique = append(unique, result)
+	}
+
+	sem := make(chan struct{}, a.concurrency)
+	var wg sync.WaitGroup
+	for _, result := range unique {
+		wg.Add(1)
+		go func(result *Result) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			a.query(ctx, result)
+		}(result)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var records []*record
+	for _, result := range unique {
+		for _, v := range result.vulns {
+			records = append(records, &record{result: result, vuln: v})
+		}
+	}
+	for _, f := range mergeFindings(records) {
+		// 未知评级的Rank为0，只满足RatingUnknown
+		if f.Rating.AtLeast(a.threshold) {
+			report.Findings = append(report.Findings, f)
+		}
+	}
+	sortFindings(report.Findings)
+
+	for _, f := range report.Findings {
+		for _, affected := range f.Affected {
+			affected.result.Findings = append(affected.result.Findings, f)
+		}
+	}
+	for _, result := range unique {
+		result.Upgrade = upgrade(result)
+	}
+	return report, nil
+}
+
+// query 查询单个包版本的漏洞
+func (a *Auditor) query(ctx context.Context, result *Result) {
+	if result.Pin.IsDirect() {
+		result.Unverifiable = true
+		return
+	}
+	vulns, err := a.client.CheckPackageVulnerabilities(ctx, result.Pin.Name, result.Pin.Version)
+	if err != nil {
+		result.Err = fmt.Errorf("查询 %s 的漏洞失败: %w", result.Pin, err)
+		return
+	}
+	for _, v := range vulns {
+		if a.withdrawn || !v.IsWithdrawn() {
+			result.vulns = append(result.vulns, v)
+		}
+	}
+}
+
+// upgrade 计算修复包的所有已报告漏洞的最小升级版本
+func upgrade(result *Result) string {
+	var vulns []models.Vulnerability
+	for _, f := range result.Findings {
+		for _, affected := range f.Affected {
+			if affected.result == result {
+				vulns = append(vulns, affected.Vulnerabilities...)
+			}
+		}
+	}
+	if len(vulns) == 0 {
+		return ""
+	}
+	if target, ok := models.SafeVersion(result.Pin.Version, vulns, nil); ok && target != result.Pin.Version {
+		return target
+	}
+	return ""
+}
+
+// jsonReport WriteJSON输出的文档结构
+type jsonReport struct {
+	Tool      string         `json:"tool"`
+	Timestamp string         `json:"timestamp,omitempty"`
+	Summary   map[string]int `json:"summary"`
+	Packages  []jsonPackage  `json:"packages"`
+	Findings  []*Finding     `json:"findings"`
+}
+
+type jsonPackage struct {
+	Name         string   `json:"name"`
+	Version      string   `json:"version"`
+	Findings     []string `json:"findings"`
+	Upgrade      string   `json:"upgrade,omitempty"`
+	Unverifiable bool     `json:"unverifiable,omitempty"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// WriteJSON 将报告以JSON格式写入w
+// summary按小写的评级名称统计漏洞数量，packages与输入顺序一致并通过ID引用findings
+func (r *Report) WriteJSON(w io.Writer) error {
+	doc := jsonReport{
+		Tool:     ToolName,
+		Summary:  map[string]int{},
+		Packages: make([]jsonPackage, 0, len(r.Results)),
+		Findings: r.Findings,
+	}
+	if !r.Timestamp.IsZero() {
+		doc.Timestamp = r.Timestamp.UTC().Format(time.RFC3339)
+	}
+	if doc.Findings == nil {
+		doc.Findings = []*Finding{}
+	}
+	for rating, n := range r.Summary() {
+		doc.Summary[strings.ToLower(rating.String())] = n
+	}
+	for _, result := range r.Results {
+		pkg := jsonPackage{
+			Name:         result.Pin.Name,
+			Version:      result.Pin.Version,
+			Findings:     []string{},
+			Upgrade:      result.Upgrade,
+			Unverifiable: result.Unverifiable,
+		}
+		for _, f := range result.Findings {
+			pkg.Findings = append(pkg.Findings, f.ID)
+		}
+		if result.Err != nil {
+			pkg.Error = result.Err.Error()
+		}
+		doc.Packages = append(doc.Packages, pkg)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
@@ -0,0 +1,255 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/api"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/cvss"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient 只实现CheckPackageVulnerabilities，按规范化包名和版本返回内存中的漏洞并记录调用次数
+type fakeClient struct {
+	api.PyPIClient
+
+	vulns map[string][]models.Vulnerability
+	calls map[string]int
+}
+
+func (f *fakeClient) CheckPackageVulnerabilities(ctx context.Context, name, version string) ([]models.Vulnerability, error) {
+	key := models.NormalizeName(name) + "@" + version
+	f.calls[key]++
+	if name == "broken" {
+		return nil, errors.New("服务不可用")
+	}
+	return f.vulns[key], nil
+}
+
+func newFakeClient() *fakeClient {
+	shared := models.Vulnerability{
+		ID:               "GHSA-shared",
+		Summary:          "Shared advisory",
+		DatabaseSpecific: json.RawMessage(`{"severity":"LOW"}`),
+	}
+	return &fakeClient{
+		calls: map[string]int{},
+		vulns: map[string][]models.Vulnerability{
+			"jinja2@2.11.2": {
+				{
+					ID:       "PYSEC-2021-66",
+					Aliases:  []string{"CVE-2020-28493", "GHSA-g3rq-g295-4j3m"},
+					Summary:  "ReDoS in the urlize filter",
+					FixedIn:  []string{"2.11.3"},
+					Link:     "https://osv.dev/vulnerability/PYSEC-2021-66",
+					Severity: []models.Severity{{Type: models.SeverityCVSSv3, Score: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:L"}},
+				},
+				{
+					ID:               "GHSA-g3rq-g295-4j3m",
+					Aliases:          []string{"CVE-2020-28493"},
+					Summary:          "Regular Expression Denial of Service in Jinja2",
+					FixedIn:          []string{"2.11.3"},
+					DatabaseSpecific: json.RawMessage(`{"severity":"MODERATE"}`),
+				},
+				{
+					ID:      "GHSA-h5c8-rqwp-cp95",
+					Aliases: []string{"CVE-2024-22195"},
+					Summary: "xmlattr filter allows keys with spaces",
+					FixedIn: []string{"3.1.3"},
+				},
+			},
+			"requests@2.19.0": {
+				{
+					ID:       "PYSEC-2018-28",
+					Aliases:  []string{"CVE-2018-18074"},
+					Summary:  "Authorization header leak | redirect",
+					FixedIn:  []string{"2.20.0"},
+					Severity: []models.Severity{{Type: models.SeverityCVSSv3, Score: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N"}},
+				},
+				{
+					ID:        "PYSEC-2000-1",
+					Summary:   "Withdrawn report",
+					Withdrawn: "2020-01-01T00:00:00Z",
+				},
+			},
+			"foo@1.0": {shared},
+			"bar@2.0": {shared},
+		},
+	}
+}
+
+func testPins() []models.Pin {
+	return []models.Pin{
+		{Name: "Jinja2", Version: "2.11.2"},
+		{Name: "requests", Version: "2.19.0"},
+		{Name: "foo", Version: "1.0"},
+		{Name: "bar", Version: "2.0"},
+		{Name: "safe", Version: "1.0"},
+		{Name: "broken", Version: "1.0"},
+		{Name: "local", Version: "0.1", URL: "file:///src/local"},
+		{Name: "jinja2", Version: "2.11.2"},
+	}
+}
+
+func auditReport(t *testing.T) (*Report, *fakeClient) {
+	t.Helper()
+	c := newFakeClient()
+	report, err := NewAuditor(c).WithConcurrency(2).Audit(context.Background(), testPins())
+	require.NoError(t, err)
+	return report, c
+}
+
+func findingIDs(findings []*Finding) []string {
+	ids := []string{}
+	for _, f := range findings {
+		ids = append(ids, f.ID)
+	}
+	return ids
+}
+
+func TestAudit(t *testing.T) {
+	report, c := auditReport(t)
+
+	t.Run("按严重程度排序并排除已撤回的漏洞", func(t *testing.T) {
+		assert.Equal(t, []string{"PYSEC-2018-28", "PYSEC-2021-66", "GHSA-shared", "GHSA-h5c8-rqwp-cp95"}, findingIDs(report.Findings))
+		assert.Equal(t, map[cvss.Rating]int{
+			cvss.RatingHigh:    1,
+			cvss.RatingMedium:  1,
+			cvss.RatingLow:     1,
+			cvss.RatingUnknown: 1,
+		}, report.Summary())
+	})
+
+	t.Run("按别名合并记录", func(t *testing.T) {
+		f := report.Finding("CVE-2020-28493")
+		require.NotNil(t, f)
+		assert.Equal(t, "PYSEC-2021-66", f.ID)
+		assert.Same(t, f, report.Finding("GHSA-g3rq-g295-4j3m"))
+		assert.Equal(t, []string{"CVE-2020-28493", "GHSA-g3rq-g295-4j3m"}, f.Aliases)
+		assert.Equal(t, []string{"CVE-2020-28493"}, f.CVEs())
+		assert.Equal(t, "ReDoS in the urlize filter", f.Summary)
+		assert.Equal(t, cvss.RatingMedium, f.Rating)
+		assert.Equal(t, 5.3, f.Score)
+		require.Len(t, f.Affected, 1)
+		assert.Len(t, f.Affected[0].Vulnerabilities, 2)
+		assert.Equal(t, []string{"2.11.3"}, f.Affected[0].FixedIn)
+		assert.Equal(t, "2.11.3", f.Affected[0].FixedVersion)
+	})
+
+	t.Run("同一漏洞影响多个包", func(t *testing.T) {
+		f := report.Finding("GHSA-shared")
+		require.NotNil(t, f)
+		require.Len(t, f.Affected, 2)
+		assert.Equal(t, "foo", f.Affected[0].Name)
+		assert.Equal(t, "bar", f.Affected[1].Name)
+		assert.Empty(t, f.Affected[0].FixedVersion)
+	})
+
+	t.Run("每个包的结果", func(t *testing.T) {
+		require.Len(t, report.Results, 8)
+		jinja := report.Results[0]
+		assert.Equal(t, []string{"PYSEC-2021-66", "GHSA-h5c8-rqwp-cp95"}, findingIDs(jinja.Findings))
+		// 2.11.3仍受GHSA-h5c8-rqwp-cp95影响
+		assert.Equal(t, "3.1.3", jinja.Upgrade)
+		assert.Equal(t, "2.20.0", report.Results[1].Upgrade)
+		assert.Empty(t, report.Results[2].Upgrade)
+
+		assert.True(t, report.Results[4].OK())
+		assert.ErrorContains(t, report.Results[5].Err, "服务不可用")
+		assert.True(t, report.Results[6].Unverifiable)
+		assert.True(t, report.Results[6].OK())
+
+		// 重复的包版本只查询一次
+		assert.Same(t, jinja, report.Results[7])
+		assert.Equal(t, 1, c.calls["jinja2@2.11.2"])
+		assert.Zero(t, c.calls["local@0.1"])
+
+		assert.Len(t, report.Vulnerable(), 5)
+		assert.Len(t, report.Errors(), 1)
+		assert.False(t, report.OK())
+	})
+}
+
+func TestAuditOptions(t *testing.T) {
+	t.Run("包含已撤回的漏洞", func(t *testing.T) {
+		report, err := NewAuditor(newFakeClient()).WithWithdrawn(true).Audit(context.Background(), testPins())
+		require.NoError(t, err)
+		f := report.Finding("PYSEC-2000-1")
+		require.NotNil(t, f)
+		assert.True(t, f.Withdrawn)
+		assert.False(t, report.Finding("PYSEC-2018-28").Withdrawn)
+	})
+
+	t.Run("最低严重程度", func(t *testing.T) {
+		report, err := NewAuditor(newFakeClient()).WithMinSeverity(cvss.RatingMedium).Audit(context.Background(), testPins())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"PYSEC-2018-28", "PYSEC-2021-66"}, findingIDs(report.Findings))
+		// 升级建议只考虑报告中的漏洞
+		assert.Equal(t, "2.11.3", report.Results[0].Upgrade)
+		assert.False(t, report.Results[2].Vulnerable())
+	})
+
+	t.Run("上下文取消", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := NewAuditor(newFakeClient()).Audit(ctx, testPins())
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("没有漏洞", func(t *testing.T) {
+		report, err := NewAuditor(newFakeClient()).Audit(context.Background(), []models.Pin{{Name: "safe", Version: "1.0"}})
+		require.NoError(t, err)
+		assert.Empty(t, report.Findings)
+		assert.True(t, report.OK())
+	})
+}
+
+func TestWriteJSON(t *testing.T) {
+	report, _ := auditReport(t)
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteJSON(&buf))
+
+	var doc struct {
+		Tool     string         `json:"tool"`
+		Summary  map[string]int `json:"summary"`
+		Packages []struct {
+			Name         string   `json:"name"`
+			Findings     []string `json:"findings"`
+			Upgrade      string   `json:"upgrade"`
+			Unverifiable bool     `json:"unverifiable"`
+			Error        string   `json:"error"`
+		} `json:"packages"`
+		Findings []struct {
+			ID       string  `json:"id"`
+			Rating   string  `json:"rating"`
+			Score    float64 `json:"score"`
+			Affected []struct {
+				Name         string   `json:"name"`
+				FixedIn      []string `json:"fixed_in"`
+				FixedVersion string   `json:"fixed_version"`
+			} `json:"affected"`
+		} `json:"findings"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+
+	assert.Equal(t, ToolName, doc.Tool)
+	assert.Equal(t, map[string]int{"high": 1, "medium": 1, "low": 1, "unknown": 1}, doc.Summary)
+	require.Len(t, doc.Packages, 8)
+	assert.Equal(t, []string{"PYSEC-2021-66", "GHSA-h5c8-rqwp-cp95"}, doc.Packages[0].Findings)
+	assert.Equal(t, "3.1.3", doc.Packages[0].Upgrade)
+	assert.Equal(t, []string{}, doc.Packages[4].Findings)
+	assert.NotEmpty(t, doc.Packages[5].Error)
+	assert.True(t, doc.Packages[6].Unverifiable)
+
+	require.Len(t, doc.Findings, 4)
+	assert.Equal(t, "PYSEC-2018-28", doc.Findings[0].ID)
+	assert.Equal(t, "HIGH", doc.Findings[0].Rating)
+	assert.Equal(t, 7.5, doc.Findings[0].Score)
+	assert.Equal(t, "2.20.0", doc.Findings[0].Affected[0].FixedVersion)
+	assert.Empty(t, doc.Findings[3].Rating)
+}
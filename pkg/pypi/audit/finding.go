@@ -0,0 +1,256 @@
+package audit
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/cvss"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/version"
+)
+
+// Finding 去重后的一个漏洞
+// 不同数据源对同一漏洞使用不同的ID（如PYSEC、GHSA和CVE），ID或别名有交集的漏洞记录会被合并为一个Finding
+type Finding struct {
+	// ID 首选标识，依次优先PYSEC、GHSA、其他数据源的ID
+	ID string `json:"id"`
+
+	// Aliases 合并后的其他标识（包括CVE编号），已排序
+	Aliases []string `json:"aliases,omitempty"`
+
+	// Summary 漏洞摘要
+	Summary string `json:"summary,omitempty"`
+
+	// Details 漏洞详细描述
+	Details string `json:"details,omitempty"`
+
+	// Link 漏洞详情URL
+	Link string `json:"link,omitempty"`
+
+	// Rating 各记录中最高的严重程度评级
+	Rating cvss.Rating `json:"rating,omitempty"`
+
+	// Score 各记录中最高的CVSS分数，Vector为空时无意义
+	Score float64 `json:"score,omitempty"`
+
+	// Vector 计算Score所用的CVSS向量，没有任何记录提供CVSS向量时为空
+	Vector string `json:"vector,omitempty"`
+
+	// Withdrawn 所有记录都已被撤回，只在Auditor.WithWithdrawn(true)时出现
+	Withdrawn bool `json:"withdrawn,omitempty"`
+
+	// Affected 受影响的包，按输入顺序排列
+	Affected []*Affected `json:"affected"`
+}
+
+// Affected 受某个漏洞影响的包版本
+type Affected struct {
+	// Name 包名
+	Name string `json:"name"`
+
+	// Version 受影响的版本
+	Version string `json:"version"`
+
+	// FixedIn 各记录给出的修复版本，按PEP 440排序
+	FixedIn []string `json:"fixed_in,omitempty"`
+
+	// FixedVersion 修复该漏洞（所有合并记录）的最小升级版本，无法确定时为空
+	FixedVersion string `json:"fixed_version,omitempty"`
+
+	// Vulnerabilities 该包的原始漏洞记录
+	Vulnerabilities []models.Vulnerability `json:"-"`
+
+	result *Result
+}
+
+// IDs 返回ID和所有别名
+func (f *Finding) IDs() []string {
+	return append([]string{f.ID}, f.Aliases...)
+}
+
+// CVEs 返回关联的CVE编号
+func (f *Finding) CVEs() []string {
+	var cves []string
+	for _, id := range f.IDs() {
+		if strings.HasPrefix(id, "CVE-") {
+			cves = append(cves, id)
+		}
+	}
+	return cves
+}
+
+// Has 检查ID或别名是否为id
+func (f *Finding) Has(id string) bool {
+	for _, candidate := range f.IDs() {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+// record 一个包返回的一条漏洞记录
+type record struct {
+	result *Result
+	vuln   models.Vulnerability
+}
+
+// identifiers 返回记录的ID和别名
+func (r *record) identifiers() []string {
+	return append([]string{r.vuln.ID}, r.vuln.Aliases...)
+}
+
+// mergeFindings 按ID和别名的交集合并漏洞记录，结果按首次出现的顺序排列
+func mergeFindings(records []*record) []*Finding {
+	parent := map[string]string{}
+	var find func(id string) string
+	find = func(id string) string {
+		p, ok := parent[id]
+		if !ok {
+			parent[id] = id
+			return id
+		}
+		if p == id {
+			return id
+		}
+		root := find(p)
+		parent[id] = root
+		return root
+	}
+	for _, r := range records {
+		ids := r.identifiers()
+		for _, id := range ids[1:] {
+			if a, b := find(ids[0]), find(id); a != b {
+				parent[b] = a
+			}
+		}
+	}
+
+	var roots []string
+	groups := map[string][]*record{}
+	for _, r := range records {
+		root := find(r.vuln.ID)
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], r)
+	}
+
+	findings := make([]*Finding, 0, len(roots))
+	for _, root := range roots {
+		findings = append(findings, newFinding(groups[root]))
+	}
+	return findings
+}
+
+// newFinding 将同一漏洞的多条记录合并为Finding
+func newFinding(records []*record) *Finding {
+	ids := map[string]bool{}
+	var own []string
+	for _, r := range records {
+		own = append(own, r.vuln.ID)
+		for _, id := range r.identifiers() {
+			ids[id] = true
+		}
+	}
+	sort.Slice(own, func(i, j int) bool {
+		if a, b := idPreference(own[i]), idPreference(own[j]); a != b {
+			return a < b
+		}
+		return own[i] < own[j]
+	})
+
+	f := &Finding{ID: own[0], Withdrawn: true}
+	for id := range ids {
+		if id != f.ID {
+			f.Aliases = append(f.Aliases, id)
+		}
+	}
+	sort.Strings(f.Aliases)
+
+	// 首选记录的描述优先，缺失时使用其他记录的
+	ordered := append([]*record(nil), records...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].vuln.ID == f.ID && ordered[j].vuln.ID != f.ID
+	})
+	for _, r := range ordered {
+		v := &r.vuln
+		f.Summary = firstNonEmpty(f.Summary, v.Summary)
+		f.Details = firstNonEmpty(f.Details, v.Details)
+		f.Link = firstNonEmpty(f.Link, v.Link)
+		f.Withdrawn = f.Withdrawn && v.IsWithdrawn()
+
+		if rating := v.Rating(); rating.Rank() > f.Rating.Rank() {
+			f.Rating = rating
+		}
+		if vector := v.CVSS(); vector != nil && (f.Vector == "" || vector.Score() > f.Score) {
+			f.Score = vector.Score()
+			f.Vector = vector.String()
+		}
+	}
+
+	affected := map[*Result]*Affected{}
+	for _, r := range records {
+		a, ok := affected[r.result]
+		if !ok {
+			a = &Affected{Name: r.result.Pin.Name, Version: r.result.Pin.Version, result: r.result}
+			affected[r.result] = a
+			f.Affected = append(f.Affected, a)
+		}
+		a.Vulnerabilities = append(a.Vulnerabilities, r.vuln)
+	}
+
+	for _, a := range f.Affected {
+		seen := map[string]bool{}
+		for _, v := range a.Vulnerabilities {
+			for _, fixed := range v.FixedIn {
+				if !seen[fixed] {
+					seen[fixed] = true
+					a.FixedIn = append(a.FixedIn, fixed)
+				}
+			}
+		}
+		version.SortStrings(a.FixedIn)
+		if fixed, ok := models.SafeVersion(a.Version, a.Vulnerabilities, nil); ok && fixed != a.Version {
+			a.FixedVersion = fixed
+		}
+	}
+	return f
+}
+
+// idPreference 返回ID作为首选标识的优先级，数值越小越优先
+// PyPI的漏洞数据来自PyPA advisory-database（PYSEC），其次是GitHub安全公告，CVE编号只作为别名
+func idPreference(id string) int {
+	switch {
+	case strings.HasPrefix(id, "PYSEC-"):
+		return 0
+	case strings.HasPrefix(id, "GHSA-"):
+		return 1
+	case strings.HasPrefix(id, "CVE-"):
+		return 3
+	}
+	return 2
+}
+
+// sortFindings 按严重程度从高到低排序，评级相同时比较分数，最后按ID排序
+func sortFindings(findings []*Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Rating.Rank() != b.Rating.Rank() {
+			return a.Rating.Rank() > b.Rating.Rank()
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.ID < b.ID
+	})
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeFindings(t *testing.T) {
+	a := &Result{Pin: models.Pin{Name: "a", Version: "1.0"}}
+	b := &Result{Pin: models.Pin{Name: "b", Version: "1.0"}}
+	records := []*record{
+		{result: a, vuln: models.Vulnerability{ID: "GHSA-1", Aliases: []string{"CVE-1"}}},
+		{result: a, vuln: models.Vulnerability{ID: "OTHER-1"}},
+		// 通过CVE-1和CVE-2间接合并
+		{result: b, vuln: models.Vulnerability{ID: "PYSEC-1", Aliases: []string{"CVE-2"}, Summary: "from pysec"}},
+		{result: b, vuln: models.Vulnerability{ID: "GHSA-2", Aliases: []string{"CVE-1", "CVE-2"}, Summary: "from ghsa"}},
+	}
+	findings := mergeFindings(records)
+	require.Len(t, findings, 2)
+
+	merged := findings[0]
+	assert.Equal(t, "PYSEC-1", merged.ID)
+	assert.Equal(t, []string{"CVE-1", "CVE-2", "GHSA-1", "GHSA-2"}, merged.Aliases)
+	assert.Equal(t, "from pysec", merged.Summary)
+	require.Len(t, merged.Affected, 2)
+	assert.Equal(t, "a", merged.Affected[0].Name)
+	assert.Len(t, merged.Affected[1].Vulnerabilities, 2)
+	assert.True(t, merged.Has("CVE-2"))
+	assert.False(t, merged.Has("OTHER-1"))
+
+	assert.Equal(t, "OTHER-1", findings[1].ID)
+	assert.Empty(t, findings[1].Aliases)
+}
+
+func TestIDPreference(t *testing.T) {
+	ids := []string{"CVE-2024-1", "OSV-1", "GHSA-x", "PYSEC-1"}
+	for i := 1; i < len(ids); i++ {
+		assert.Greater(t, idPreference(ids[i-1]), idPreference(ids[i]), ids[i])
+	}
+}
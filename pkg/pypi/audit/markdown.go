@@ -0,0 +1,118 @@
+package audit
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/cvss"
+)
+
+// markdownRatings Markdown摘要中统计的评级及顺序
+var markdownRatings = []cvss.Rating{
+	cvss.RatingCritical, cvss.RatingHigh, cvss.RatingMedium, cvss.RatingLow, cvss.RatingNone, cvss.RatingUnknown,
+}
+
+// WriteMarkdown 将报告以Markdown格式写入w，适合作为PR评论
+// 包括摘要、按严重程度排列的漏洞表格、升级建议以及查询失败和无法核对的包
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	b.WriteString("## Vulnerability audit\n\n")
+
+	vulnerable := r.Vulnerable()
+	if len(r.Findings) == 0 {
+		fmt.Fprintf(b, "No known vulnerabilities found in %d %s.\n", len(r.Results), plural(len(r.Results), "package"))
+	} else {
+		summary := r.Summary()
+		var counts []string
+		for _, rating := range markdownRatings {
+			if n := summary[rating]; n > 0 {
+				counts = append(counts, fmt.Sprintf("%d %s", n, strings.ToLower(rating.String())))
+			}
+		}
+		fmt.Fprintf(b, "Found **%d %s** (%s) in %d of %d %s.\n",
+			len(r.Findings), plural(len(r.Findings), "vulnerability"), strings.Join(counts, ", "),
+			len(vulnerable), len(r.Results), plural(len(r.Results), "package"))
+
+		b.WriteString("\n| Severity | Package | Version | Vulnerability | Fixed in |\n")
+		b.WriteString("| --- | --- | --- | --- | --- |\n")
+		for _, f := range r.Findings {
+			for _, affected := range f.Affected {
+				fmt.Fprintf(b, "| %s | %s | %s | %s | %s |\n",
+					markdownSeverity(f), markdownCell(affected.Name), markdownCell(affected.Version),
+					markdownVulnerability(f), markdownCell(firstNonEmpty(affected.FixedVersion, "-")))
+			}
+		}
+	}
+
+	var upgrades []*Result
+	for _, result := range vulnerable {
+		if result.Upgrade != "" {
+			upgrades = append(upgrades, result)
+		}
+	}
+	if len(upgrades) > 0 {
+		b.WriteString("\n### Suggested upgrades\n\n")
+		for _, result := range upgrades {
+			fmt.Fprintf(b, "- `%s`: %s → %s\n", result.Pin.Name, result.Pin.Version, result.Upgrade)
+		}
+	}
+
+	if errs := r.Errors(); len(errs) > 0 {
+		b.WriteString("\n### Lookup failures\n\n")
+		for _, result := range errs {
+			fmt.Fprintf(b, "- `%s`: %s\n", result.Pin, markdownCell(result.Err.Error()))
+		}
+	}
+
+	var unverifiable []string
+	for _, result := range r.Results {
+		if result.Unverifiable {
+			unverifiable = append(unverifiable, "`"+result.Pin.Name+"`")
+		}
+	}
+	if len(unverifiable) > 0 {
+		fmt.Fprintf(b, "\nNot checked (installed from a direct reference): %s\n", strings.Join(unverifiable, ", "))
+	}
+	return b.Flush()
+}
+
+// markdownSeverity 返回评级和分数，如 "CRITICAL 9.8"
+func markdownSeverity(f *Finding) string {
+	if f.Vector != "" {
+		return fmt.Sprintf("%s %.1f", f.Rating, f.Score)
+	}
+	return f.Rating.String()
+}
+
+// markdownVulnerability 返回带链接的漏洞ID及CVE编号
+func markdownVulnerability(f *Finding) string {
+	s := markdownCell(f.ID)
+	if f.Link != "" {
+		s = "[" + s + "](" + f.Link + ")"
+	}
+	if cves := f.CVEs(); len(cves) > 0 {
+		s += " (" + strings.Join(cves, ", ") + ")"
+	}
+	if f.Summary != "" {
+		s += "<br>" + markdownCell(f.Summary)
+	}
+	return s
+}
+
+// markdownCell 转义表格单元格中的竖线并合并换行
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	if strings.HasSuffix(word, "y") {
+		return strings.TrimSuffix(word, "y") + "ies"
+	}
+	return word + "s"
+}
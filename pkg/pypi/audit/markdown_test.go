@@ -0,0 +1,33 @@
+package audit
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	t.Run("包含漏洞", func(t *testing.T) {
+		report, _ := auditReport(t)
+		var buf bytes.Buffer
+		require.NoError(t, report.WriteMarkdown(&buf))
+		out := buf.String()
+
+		assert.Contains(t, out, "Found **4 vulnerabilities** (1 high, 1 medium, 1 low, 1 unknown) in 5 of 8 packages.")
+		assert.Contains(t, out, "| HIGH 7.5 | requests | 2.19.0 | PYSEC-2018-28 (CVE-2018-18074)<br>Authorization header leak \\| redirect | 2.20.0 |")
+		assert.Contains(t, out, "| MEDIUM 5.3 | Jinja2 | 2.11.2 | [PYSEC-2021-66](https://osv.dev/vulnerability/PYSEC-2021-66) (CVE-2020-28493)")
+		assert.Contains(t, out, "| LOW | bar | 2.0 | GHSA-shared<br>Shared advisory | - |")
+		assert.Contains(t, out, "- `Jinja2`: 2.11.2 → 3.1.3\n")
+		assert.Contains(t, out, "### Lookup failures\n\n- `broken@1.0`: ")
+		assert.Contains(t, out, "Not checked (installed from a direct reference): `local`")
+	})
+
+	t.Run("没有漏洞", func(t *testing.T) {
+		report := &Report{Results: []*Result{{}}}
+		var buf bytes.Buffer
+		require.NoError(t, report.WriteMarkdown(&buf))
+		assert.Equal(t, "## Vulnerability audit\n\nNo known vulnerabilities found in 1 package.\n", buf.String())
+	})
+}
@@ -0,0 +1,228 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/cvss"
+)
+
+// SARIFVersion 生成的SARIF版本
+const SARIFVersion = "2.1.0"
+
+// SARIFSchema SARIF 2.1.0的JSON Schema地址
+const SARIFSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// SARIFLog SARIF 2.1.0日志文件
+type SARIFLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+// SARIFRun 一次分析的结果
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+// SARIFTool 生成结果的工具
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+// SARIFDriver 工具的主要组件及其规则
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []SARIFRule `json:"rules"`
+}
+
+// SARIFRule 规则，每个漏洞对应一条规则
+type SARIFRule struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name,omitempty"`
+	ShortDescription     *SARIFMessage          `json:"shortDescription,omitempty"`
+	FullDescription      *SARIFMessage          `json:"fullDescription,omitempty"`
+	HelpURI              string                 `json:"helpUri,omitempty"`
+	Help                 *SARIFMessage          `json:"help,omitempty"`
+	DefaultConfiguration *SARIFConfiguration    `json:"defaultConfiguration,omitempty"`
+	Properties           map[string]interface{} `json:"properties,omitempty"`
+}
+
+// SARIFConfiguration 规则的默认配置
+type SARIFConfiguration struct {
+	Level string `json:"level"`
+}
+
+// SARIFMessage 文本消息，Markdown可选
+type SARIFMessage struct {
+	Text     string `json:"text"`
+	Markdown string `json:"markdown,omitempty"`
+}
+
+// SARIFResult 一条结果，每个受影响的包版本对应一条结果
+type SARIFResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             SARIFMessage      `json:"message"`
+	Locations           []SARIFLocation   `json:"locations,omitempty"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+}
+
+// SARIFLocation 结果的位置
+type SARIFLocation struct {
+	PhysicalLocation *SARIFPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []SARIFLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+// SARIFPhysicalLocation 文件中的位置
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+}
+
+// SARIFArtifactLocation 文件路径
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// SARIFLogicalLocation 逻辑位置，这里是受影响的包
+type SARIFLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName,omitempty"`
+	Kind               string `json:"kind,omitempty"`
+}
+
+// NewSARIF 将报告转换为SARIF 2.1.0日志，可上传到GitHub代码扫描
+// 每个漏洞生成一条规则，每个受影响的包版本生成一条结果；严重程度写入规则的security-severity属性
+//
+// 参数:
+//   - r: Auditor.Audit返回的报告
+//   - manifest: 结果关联的依赖清单路径（相对于仓库根目录），如 "requirements.txt"；
+//     为空时只写入逻辑位置，GitHub代码扫描要求结果包含文件位置
+//
+// 返回值:
+//   - *SARIFLog: 可直接编码为JSON的日志
+func NewSARIF(r *Report, manifest string) *SARIFLog {
+	run := SARIFRun{
+		Tool: SARIFTool{Driver: SARIFDriver{
+			Name:           ToolName,
+			InformationURI: ToolURI,
+			Rules:          []SARIFRule{},
+		}},
+		Results: []SARIFResult{},
+	}
+	for i, f := range r.Findings {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule(f))
+		for _, affected := range f.Affected {
+			run.Results = append(run.Results, sarifResult(f, i, affected, manifest))
+		}
+	}
+	return &SARIFLog{Schema: SARIFSchema, Version: SARIFVersion, Runs: []SARIFRun{run}}
+}
+
+// WriteSARIF 将报告以SARIF 2.1.0 JSON格式写入w，manifest的含义见NewSARIF
+func (r *Report) WriteSARIF(w io.Writer, manifest string) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(NewSARIF(r, manifest))
+}
+
+func sarifRule(f *Finding) SARIFRule {
+	summary := firstNonEmpty(f.Summary, f.ID)
+	rule := SARIFRule{
+		ID:                   f.ID,
+		ShortDescription:     &SARIFMessage{Text: summary},
+		FullDescription:      &SARIFMessage{Text: firstNonEmpty(f.Details, summary)},
+		HelpURI:              f.Link,
+		DefaultConfiguration: &SARIFConfiguration{Level: sarifLevel(f.Rating)},
+		Properties: map[string]interface{}{
+			"tags": []string{"security", "vulnerability", "dependency"},
+		},
+	}
+
+	help := []string{summary}
+	if len(f.Aliases) > 0 {
+		help = append(help, "Aliases: "+strings.Join(f.Aliases, ", "))
+	}
+	if f.Link != "" {
+		help = append(help, "Details: "+f.Link)
+	}
+	rule.Help = &SARIFMessage{Text: strings.Join(help, "\n\n")}
+
+	if score, ok := securitySeverity(f); ok {
+		rule.Properties["security-severity"] = score
+	}
+	return rule
+}
+
+func sarifResult(f *Finding, ruleIndex int, affected *Affected, manifest string) SARIFResult {
+	message := fmt.Sprintf("%s %s is affected by %s", affected.Name, affected.Version, f.ID)
+	if cves := f.CVEs(); len(cves) > 0 && cves[0] != f.ID {
+		message += " (" + strings.Join(cves, ", ") + ")"
+	}
+	if f.Summary != "" {
+		message += ": " + f.Summary
+	}
+	if affected.FixedVersion != "" {
+		message += fmt.Sprintf(". Upgrade to %s or later.", affected.FixedVersion)
+	} else {
+		message += ". No fixed version is available."
+	}
+
+	location := SARIFLocation{LogicalLocations: []SARIFLogicalLocation{{
+		Name:               affected.Name,
+		FullyQualifiedName: affected.Name + "@" + affected.Version,
+		Kind:               "package",
+	}}}
+	if manifest != "" {
+		location.PhysicalLocation = &SARIFPhysicalLocation{ArtifactLocation: SARIFArtifactLocation{URI: manifest}}
+	}
+
+	return SARIFResult{
+		RuleID:    f.ID,
+		RuleIndex: ruleIndex,
+		Level:     sarifLevel(f.Rating),
+		Message:   SARIFMessage{Text: message},
+		Locations: []SARIFLocation{location},
+		// 所有结果都位于同一个清单文件，需要以包和漏洞区分不同的告警
+		PartialFingerprints: map[string]string{
+			"vulnerableDependency/v1": affected.Name + "@" + affected.Version + ":" + f.ID,
+		},
+	}
+}
+
+// sarifLevel 将评级映射为SARIF的级别，评级未知时按warning处理
+func sarifLevel(r cvss.Rating) string {
+	switch r {
+	case cvss.RatingCritical, cvss.RatingHigh:
+		return "error"
+	case cvss.RatingLow, cvss.RatingNone:
+		return "note"
+	}
+	return "warning"
+}
+
+// securitySeverity 返回GitHub代码扫描使用的security-severity属性
+// 有CVSS分数时直接使用，只有评级时取该评级区间的下限
+func securitySeverity(f *Finding) (string, bool) {
+	if f.Vector != "" {
+		return fmt.Sprintf("%.1f", f.Score), true
+	}
+	switch f.Rating {
+	case cvss.RatingCritical:
+		return "9.0", true
+	case cvss.RatingHigh:
+		return "7.0", true
+	case cvss.RatingMedium:
+		return "4.0", true
+	case cvss.RatingLow:
+		return "0.1", true
+	case cvss.RatingNone:
+		return "0.0", true
+	}
+	return "", false
+}
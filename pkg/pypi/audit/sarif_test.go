@@ -0,0 +1,69 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/cvss"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSARIF(t *testing.T) {
+	report, _ := auditReport(t)
+
+	t.Run("规则和结果", func(t *testing.T) {
+		log := NewSARIF(report, "requirements.txt")
+		assert.Equal(t, "2.1.0", log.Version)
+		require.Len(t, log.Runs, 1)
+		run := log.Runs[0]
+		assert.Equal(t, ToolName, run.Tool.Driver.Name)
+
+		rules := run.Tool.Driver.Rules
+		require.Len(t, rules, 4)
+		assert.Equal(t, "PYSEC-2018-28", rules[0].ID)
+		assert.Equal(t, "7.5", rules[0].Properties["security-severity"])
+		assert.Equal(t, "error", rules[0].DefaultConfiguration.Level)
+		assert.Equal(t, "0.1", rules[2].Properties["security-severity"])
+		assert.NotContains(t, rules[3].Properties, "security-severity")
+		assert.Contains(t, rules[1].Help.Text, "CVE-2020-28493")
+
+		// GHSA-shared影响两个包
+		require.Len(t, run.Results, 5)
+		result := run.Results[0]
+		assert.Equal(t, "PYSEC-2018-28", result.RuleID)
+		assert.Equal(t, 0, result.RuleIndex)
+		assert.Equal(t, "error", result.Level)
+		assert.Equal(t, "requests 2.19.0 is affected by PYSEC-2018-28 (CVE-2018-18074): Authorization header leak | redirect. Upgrade to 2.20.0 or later.", result.Message.Text)
+		assert.Equal(t, "requirements.txt", result.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+		assert.Equal(t, "requests@2.19.0", result.Locations[0].LogicalLocations[0].FullyQualifiedName)
+		assert.Equal(t, 2, run.Results[2].RuleIndex)
+		assert.Equal(t, 2, run.Results[3].RuleIndex)
+		assert.NotEqual(t, run.Results[2].PartialFingerprints, run.Results[3].PartialFingerprints)
+		assert.Contains(t, run.Results[2].Message.Text, "No fixed version")
+		assert.Equal(t, "warning", run.Results[4].Level)
+	})
+
+	t.Run("不指定清单文件", func(t *testing.T) {
+		log := NewSARIF(report, "")
+		assert.Nil(t, log.Runs[0].Results[0].Locations[0].PhysicalLocation)
+	})
+
+	t.Run("没有漏洞时输出空数组", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, (&Report{}).WriteSARIF(&buf, "requirements.txt"))
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+		assert.Equal(t, SARIFSchema, doc["$schema"])
+		run := doc["runs"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, []interface{}{}, run["results"])
+	})
+}
+
+func TestSARIFLevel(t *testing.T) {
+	assert.Equal(t, "error", sarifLevel(cvss.RatingCritical))
+	assert.Equal(t, "warning", sarifLevel(cvss.RatingMedium))
+	assert.Equal(t, "note", sarifLevel(cvss.RatingLow))
+	assert.Equal(t, "warning", sarifLevel(cvss.RatingUnknown))
+}
@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// KeyringFileName keyrings.alt的PlaintextKeyring使用的文件名
+const KeyringFileName = "keyring_pass.cfg"
+
+// ParseKeyringFile 解析keyrings.alt的PlaintextKeyring文件（keyring_pass.cfg）
+//
+// 文件为INI格式，节名是转义后的服务名，键是转义后的用户名，值是base64编码的密码。
+// keyrings.alt写入的值以换行开头，因此base64位于缩进的续行上，续行会拼接到上一个值后再解码；
+// 转义规则是把字母和数字以外的字节写成 "_XX"（十六进制大写）。
+// 与pip使用keyring时相同，服务名可以是索引URL（按URL前缀匹配）或主机名（按Match的规则匹配）；
+// 同一个服务有多个用户时使用第一个
+//
+// 参数:
+//   - r: 文件内容
+//
+// 返回值:
+//   - Rules: 按文件顺序排列的认证规则
+//   - error: 格式错误时返回，匹配ErrInvalidFile
+//
+// 使用示例:
+//
+//	rules, err := auth.ParseKeyringFile(strings.NewReader("[devpi_2Einternal]\nalice = \n\tc2VjcmV0\n\n"))
+func ParseKeyringFile(r io.Reader) (Rules, error) {
+	var rules Rules
+	service := ""
+	seen := map[string]bool{}
+
+	// 值可以跨越多行：keyrings.alt写入的值以换行开头，base64在缩进的续行上
+	var key, value string
+	keyLine := 0
+	flush := func() error {
+		if keyLine == 0 || seen[service] {
+			keyLine = 0
+			return nil
+		}
+		username, err := unescapeKeyring(key)
+		if err != nil {
+			return fmt.Errorf("%w: 第%d行: %v", ErrInvalidFile, keyLine, err)
+		}
+		password, err := decodeKeyringPassword(value)
+		if err != nil {
+			return fmt.Errorf("%w: 第%d行: 密码不是有效的base64", ErrInvalidFile, keyLine)
+		}
+		seen[service] = true
+		rules = append(rules, Rule{Pattern: service, Credential: Credential{Username: username, Password: password}})
+		keyLine = 0
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if raw[0] == ' ' || raw[0] == '\t' {
+			if keyLine == 0 {
+				return nil, fmt.Errorf("%w: 第%d行: 续行之前没有设置", ErrInvalidFile, lineNo)
+			}
+			value += line
+			continue
+		}
+		if err := flush(); err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name, err := unescapeKeyring(line[1 : len(line)-1])
+			if err != nil {
+				return nil, fmt.Errorf("%w: 第%d行: %v", ErrInvalidFile, lineNo, err)
+			}
+			service = name
+			continue
+		}
+		i := strings.IndexAny(line, "=:")
+		if i < 0 {
+			return nil, fmt.Errorf("%w: 第%d行: 无法解析 %q", ErrInvalidFile, lineNo, line)
+		}
+		if service == "" {
+			return nil, fmt.Errorf("%w: 第%d行: 设置不属于任何服务", ErrInvalidFile, lineNo)
+		}
+		key, value, keyLine = strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:]), lineNo
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// decodeKeyringPassword 解码base64编码的密码，Python的base64.decodebytes不要求填充，因此同时接受没有填充的写法
+func decodeKeyringPassword(value string) (string, error) {
+	value = strings.Join(strings.Fields(value), "")
+	password, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		password, err = base64.RawStdEncoding.DecodeString(value)
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(password), nil
+}
+
+// LoadKeyringFile 读取keyring文件，文件不存在时返回的错误匹配os.ErrNotExist
+func LoadKeyringFile(path string) (Rules, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rules, err := ParseKeyringFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// KeyringFilePath 返回PlaintextKeyring文件的默认位置，与keyrings.alt相同:
+// Linux上为 $XDG_DATA_HOME/python_keyring（默认 ~/.local/share/python_keyring），
+// macOS上为 ~/Library/Application Support/Python Keyring，Windows上为 %LOCALAPPDATA%\Python Keyring；
+// 无法确定目录时返回空字符串
+func KeyringFilePath(getenv func(string) string) string {
+	switch runtime.GOOS {
+	case "windows":
+		if dir := getenv("LOCALAPPDATA"); dir != "" {
+			return filepath.Join(dir, "Python Keyring", KeyringFileName)
+		}
+		return ""
+	case "darwin":
+		if home := getenv("HOME"); home != "" {
+			return filepath.Join(home, "Library", "Application Support", "Python Keyring", KeyringFileName)
+		}
+		return ""
+	}
+	dataHome := getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home := getenv("HOME")
+		if home == "" {
+			return ""
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "python_keyring", KeyringFileName)
+}
+
+// EscapeKeyring 按keyrings.alt的规则转义服务名或用户名，用于生成keyring文件
+func EscapeKeyring(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "_%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// unescapeKeyring 还原EscapeKeyring转义的字符串
+func unescapeKeyring(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '_' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+3 > len(s) {
+			return "", fmt.Errorf("%q 中的转义不完整", s)
+		}
+		c, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", fmt.Errorf("%q 中的转义无效", s)
+		}
+		b.WriteByte(byte(c))
+		i += 2
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEscapeKeyring(t *testing.T) {
+	assert.Equal(t, "devpi_2Einternal", EscapeKeyring("devpi.internal"))
+	assert.Equal(t, "https_3A_2F_2Fa_2Fsimple_2F", EscapeKeyring("https://a/simple/"))
+	assert.Equal(t, "alice_40example_2Ecom", EscapeKeyring("alice@example.com"))
+
+	s, err := unescapeKeyring(EscapeKeyring("https://user@host:3141/+simple/"))
+	require.NoError(t, err)
+	assert.Equal(t, "https://user@host:3141/+simple/", s)
+
+	_, err = unescapeKeyring("bad_2")
+	assert.Error(t, err)
+	_, err = unescapeKeyring("bad_ZZ")
+	assert.Error(t, err)
+}
+
+func TestParseKeyringFile(t *testing.T) {
+	encode := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+	// 与keyrings.alt写入的格式相同：值为 "\n" + base64，configparser把它写成缩进的续行
+	content := "[" + EscapeKeyring("https://devpi.internal/root/pypi/") + "]\n" +
+		EscapeKeyring("alice@corp") + " = \n\t" + encode("secret") + "\n" +
+		"bob = \n\t" + encode("ignored") + "\n\n" +
+		"[" + EscapeKeyring("artifactory.internal") + "]\n" +
+		"ci = \n\t" + encode("ci-token") + "\n\n"
+
+	t.Run("按服务匹配", func(t *testing.T) {
+		rules, err := ParseKeyringFile(strings.NewReader(content))
+		require.NoError(t, err)
+		require.Len(t, rules, 2)
+
+		cred, ok := rules.Credential(mustParse(t, "https://devpi.internal/root/pypi/+simple/demo/"))
+		require.True(t, ok)
+		assert.Equal(t, Credential{Username: "alice@corp", Password: "secret"}, cred)
+
+		_, ok = rules.Credential(mustParse(t, "https://devpi.internal/other/"))
+		assert.False(t, ok)
+
+		cred, ok = rules.Credential(mustParse(t, "https://artifactory.internal/api/pypi/simple/"))
+		require.True(t, ok)
+		assert.Equal(t, "ci-token", cred.Password)
+	})
+
+	t.Run("base64带填充和不带填充", func(t *testing.T) {
+		for _, value := range []string{"dG9rZW4=", "dG9rZW4"} {
+			rules, err := ParseKeyringFile(strings.NewReader("[host]\nci = \n\t" + value + "\n"))
+			require.NoError(t, err, value)
+			require.Len(t, rules, 1)
+			assert.Equal(t, Credential{Username: "ci", Password: "token"}, rules[0].Credential, value)
+		}
+
+		rules, err := ParseKeyringFile(strings.NewReader("[host]\nci = " + encode("token") + "\n"))
+		require.NoError(t, err)
+		assert.Equal(t, "token", rules[0].Credential.Password)
+	})
+
+	t.Run("格式错误", func(t *testing.T) {
+		_, err := ParseKeyringFile(strings.NewReader("alice = " + encode("secret")))
+		assert.ErrorIs(t, err, ErrInvalidFile)
+
+		_, err = ParseKeyringFile(strings.NewReader("[host]\nalice = \n\tnot base64!\n"))
+		assert.ErrorIs(t, err, ErrInvalidFile)
+
+		_, err = ParseKeyringFile(strings.NewReader("[host]\n\tc2VjcmV0\n"))
+		assert.ErrorIs(t, err, ErrInvalidFile)
+	})
+
+	t.Run("读取文件", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), KeyringFileName)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		rules, err := LoadKeyringFile(path)
+		require.NoError(t, err)
+		assert.Len(t, rules, 2)
+
+		_, err = LoadKeyringFile(filepath.Join(t.TempDir(), KeyringFileName))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestKeyringFilePath(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		t.Skip("只检查Linux上的位置")
+	}
+	env := map[string]string{"HOME": "/home/dev"}
+	getenv := func(key string) string { return env[key] }
+	assert.Equal(t, "/home/dev/.local/share/python_keyring/keyring_pass.cfg", KeyringFilePath(getenv))
+
+	env["XDG_DATA_HOME"] = "/data"
+	assert.Equal(t, "/data/python_keyring/keyring_pass.cfg", KeyringFilePath(getenv))
+}
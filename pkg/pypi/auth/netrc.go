@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ErrInvalidFile 表示netrc或keyring文件的格式无效
+var ErrInvalidFile = errors.New("无效的认证文件")
+
+// ParseNetrc 解析netrc格式的认证文件
+// 每个machine按主机名匹配（不区分端口）；macdef定义的宏被忽略。
+// default条目会把凭据发送给所有主机（包括发布文件所在的主机），因此同样被忽略。
+// 与Python的netrc模块相同，#只在应当出现关键字的位置开始注释，密码等值中的#不受影响
+//
+// 参数:
+//   - r: 文件内容
+//
+// 返回值:
+//   - Rules: 按文件顺序排列的认证规则
+//   - error: 格式错误时返回，匹配ErrInvalidFile
+//
+// 使用示例:
+//
+//	rules, err := auth.ParseNetrc(strings.NewReader("machine devpi.internal login alice password secret"))
+func ParseNetrc(r io.Reader) (Rules, error) {
+	var rules Rules
+	// current 当前条目在rules中的下标，-1表示不在machine中，-2表示在被忽略的default中
+	current := -1
+
+	// keyword 等待值的关键字，值可以在下一行
+	keyword := ""
+	scanner := bufio.NewScanner(r)
+	inMacro := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if inMacro {
+			// 宏定义以空行结束
+			inMacro = strings.TrimSpace(line) != ""
+			continue
+		}
+		for _, token := range strings.Fields(line) {
+			switch keyword {
+			case "":
+			case "machine":
+				rules = append(rules, Rule{Pattern: token})
+				current, keyword = len(rules)-1, ""
+				continue
+			default:
+				if current == -1 {
+					return nil, fmt.Errorf("%w: netrc的%s不属于任何machine", ErrInvalidFile, keyword)
+				}
+				if current >= 0 && keyword == "login" {
+					rules[current].Credential.Username = token
+				} else if current >= 0 && keyword == "password" {
+					rules[current].Credential.Password = token
+				}
+				keyword = ""
+				continue
+			}
+
+			// #只在关键字的位置上开始注释，值中的#（如密码p#ss）保持原样
+			if strings.HasPrefix(token, "#") {
+				break
+			}
+			switch token {
+			case "machine", "login", "password", "account":
+				keyword = token
+			case "default":
+				current = -2
+			case "macdef":
+				inMacro = true
+			default:
+				return nil, fmt.Errorf("%w: 无法识别的netrc关键字 %q", ErrInvalidFile, token)
+			}
+			if inMacro {
+				break
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if keyword != "" {
+		return nil, fmt.Errorf("%w: netrc的%s缺少值", ErrInvalidFile, keyword)
+	}
+	return rules, nil
+}
+
+// LoadNetrc 读取netrc文件，文件不存在时返回的错误匹配os.ErrNotExist
+func LoadNetrc(path string) (Rules, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rules, err := ParseNetrc(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// NetrcPath 返回netrc文件的位置：NETRC环境变量，否则为主目录下的.netrc（Windows上为_netrc）
+// 与pip使用的requests库相同；无法确定主目录时返回空字符串
+func NetrcPath(getenv func(string) string) string {
+	if path := getenv("NETRC"); path != "" {
+		return path
+	}
+	home := getenv("HOME")
+	if runtime.GOOS == "windows" && home == "" {
+		home = getenv("USERPROFILE")
+	}
+	if home == "" {
+		return ""
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(home, "_netrc")
+	}
+	return filepath.Join(home, ".netrc")
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNetrc(t *testing.T) {
+	t.Run("machine条目", func(t *testing.T) {
+		rules, err := ParseNetrc(strings.NewReader(`# 内部索引
+machine devpi.internal login alice password secret
+machine artifactory.internal
+	login bob
+	account ci
+	password
+	token123
+
+macdef init
+cd /pub
+bin
+
+default login anonymous password guest
+`))
+		require.NoError(t, err)
+		assert.Equal(t, Rules{
+			{Pattern: "devpi.internal", Credential: Credential{Username: "alice", Password: "secret"}},
+			{Pattern: "artifactory.internal", Credential: Credential{Username: "bob", Password: "token123"}},
+		}, rules)
+
+		_, ok := rules.Credential(mustParse(t, "https://files.pythonhosted.org/"))
+		assert.False(t, ok, "default条目不应生效")
+	})
+
+	t.Run("值中的#不是注释", func(t *testing.T) {
+		rules, err := ParseNetrc(strings.NewReader(`machine devpi.internal login alice password p#ss # 内部索引
+  # 缩进的注释行
+machine artifactory.internal login bob password
+#token123
+`))
+		require.NoError(t, err)
+		assert.Equal(t, Rules{
+			{Pattern: "devpi.internal", Credential: Credential{Username: "alice", Password: "p#ss"}},
+			{Pattern: "artifactory.internal", Credential: Credential{Username: "bob", Password: "#token123"}},
+		}, rules)
+	})
+
+	t.Run("格式错误", func(t *testing.T) {
+		_, err := ParseNetrc(strings.NewReader("login alice"))
+		assert.ErrorIs(t, err, ErrInvalidFile)
+
+		_, err = ParseNetrc(strings.NewReader("machine devpi.internal login"))
+		assert.ErrorIs(t, err, ErrInvalidFile)
+
+		_, err = ParseNetrc(strings.NewReader("machine devpi.internal user alice"))
+		assert.ErrorIs(t, err, ErrInvalidFile)
+	})
+
+	t.Run("文件不存在", func(t *testing.T) {
+		_, err := LoadNetrc(filepath.Join(t.TempDir(), ".netrc"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestNetrcPath(t *testing.T) {
+	env := map[string]string{"NETRC": "/custom/netrc", "HOME": "/home/dev"}
+	getenv := func(key string) string { return env[key] }
+	assert.Equal(t, "/custom/netrc", NetrcPath(getenv))
+
+	delete(env, "NETRC")
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, filepath.Join("/home/dev", ".netrc"), NetrcPath(getenv))
+	}
+
+	delete(env, "HOME")
+	assert.Empty(t, NetrcPath(getenv))
+}
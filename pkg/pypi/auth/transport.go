@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Transport 按请求的URL添加认证信息的http.RoundTripper
+//
+// 认证信息在每次请求时按实际请求的URL查找，不会写入调用方的请求，
+// 因此http.Client跟随重定向时不会把它带到其他主机（如PyPI的files.pythonhosted.org）。
+// 调用方自己设置的Authorization头部在跨主机重定向时也会被移除，比http.Client默认的规则（允许子域名）更严格
+type Transport struct {
+	// Base 实际发送请求的RoundTripper，为nil时使用http.DefaultTransport
+	Base http.RoundTripper
+
+	// Provider 认证信息来源
+	Provider Provider
+}
+
+var _ http.RoundTripper = (*Transport)(nil)
+
+// NewTransport 创建添加认证信息的Transport
+//
+// 参数:
+//   - base: 实际发送请求的RoundTripper，为nil时使用http.DefaultTransport
+//   - provider: 认证信息来源
+//
+// 返回值:
+//   - *Transport: 可以作为http.Client.Transport使用
+//
+// 使用示例:
+//
+//	httpClient := &http.Client{Transport: auth.NewTransport(nil, auth.Basic("devpi.internal", "alice", "secret"))}
+func NewTransport(base http.RoundTripper, provider Provider) *Transport {
+	return &Transport{Base: base, Provider: provider}
+}
+
+// RoundTrip 实现http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	header := req.Header.Get("Authorization")
+	if header != "" && crossHostRedirect(req) {
+		header = ""
+	}
+	if header == "" && t.Provider != nil {
+		if cred, ok := t.Provider.Credential(req.URL); ok {
+			header = cred.Header()
+		}
+	}
+	if header == req.Header.Get("Authorization") {
+		return base.RoundTrip(req)
+	}
+
+	// RoundTripper不能修改传入的请求
+	clone := req.Clone(req.Context())
+	if header == "" {
+		clone.Header.Del("Authorization")
+	} else {
+		clone.Header.Set("Authorization", header)
+	}
+	return base.RoundTrip(clone)
+}
+
+// crossHostRedirect 判断请求是否由重定向产生，且与最初的请求不是同一个主机
+func crossHostRedirect(req *http.Request) bool {
+	if req.Response == nil || req.Response.Request == nil {
+		return false
+	}
+	first := req.Response.Request
+	for first.Response != nil && first.Response.Request != nil {
+		first = first.Response.Request
+	}
+	return !strings.EqualFold(first.URL.Host, req.URL.Host)
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport(t *testing.T) {
+	// files 模拟发布文件所在的另一个主机，记录收到的Authorization头部
+	var filesAuth []string
+	files := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filesAuth = append(filesAuth, r.Header.Get("Authorization"))
+	}))
+	defer files.Close()
+
+	var indexAuth []string
+	index := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		indexAuth = append(indexAuth, r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/packages/demo.whl":
+			http.Redirect(w, r, files.URL+"/demo.whl", http.StatusFound)
+		case "/moved":
+			http.Redirect(w, r, "/simple/", http.StatusMovedPermanently)
+		}
+	}))
+	defer index.Close()
+	indexURL, err := url.Parse(index.URL)
+	require.NoError(t, err)
+
+	get := func(c *http.Client, rawURL, header string) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+		require.NoError(t, err)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		resp, err := c.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	basic := Credential{Username: "alice", Password: "secret"}.Header()
+
+	t.Run("只对匹配的主机添加认证信息", func(t *testing.T) {
+		indexAuth, filesAuth = nil, nil
+		c := &http.Client{Transport: NewTransport(nil, Basic(indexURL.Host, "alice", "secret"))}
+		get(c, index.URL+"/simple/", "")
+		get(c, index.URL+"/moved", "")
+		get(c, index.URL+"/packages/demo.whl", "")
+		assert.Equal(t, []string{basic, basic, basic, basic}, indexAuth)
+		assert.Equal(t, []string{""}, filesAuth)
+	})
+
+	t.Run("调用方的认证头部不随跨主机重定向发送", func(t *testing.T) {
+		indexAuth, filesAuth = nil, nil
+		c := &http.Client{Transport: NewTransport(nil, nil)}
+		get(c, index.URL+"/packages/demo.whl", "Bearer caller")
+		assert.Equal(t, []string{"Bearer caller"}, indexAuth)
+		assert.Equal(t, []string{""}, filesAuth)
+	})
+
+	t.Run("调用方的认证头部优先", func(t *testing.T) {
+		indexAuth = nil
+		c := &http.Client{Transport: NewTransport(nil, Basic(indexURL.Host, "alice", "secret"))}
+		get(c, index.URL+"/simple/", "Bearer caller")
+		assert.Equal(t, []string{"Bearer caller"}, indexAuth)
+	})
+}
@@ -0,0 +1,150 @@
+package classifier
+
+import (
+	"strings"
+)
+
+// Separator 分类器各层级之间的分隔符
+const Separator = " :: "
+
+// 顶层分类
+const (
+	CategoryDevelopmentStatus   = "Development Status"
+	CategoryEnvironment         = "Environment"
+	CategoryFramework           = "Framework"
+	CategoryIntendedAudience    = "Intended Audience"
+	CategoryLicense             = "License"
+	CategoryNaturalLanguage     = "Natural Language"
+	CategoryOperatingSystem     = "Operating System"
+	CategoryProgrammingLanguage = "Programming Language"
+	CategoryTopic               = "Topic"
+	CategoryTyping              = "Typing"
+	CategoryPrivate             = "Private"
+)
+
+// Classifier 表示一个拆分为层级的Trove分类器
+type Classifier struct {
+	// Raw 原始字符串，如 "Programming Language :: Python :: 3.11"
+	Raw string
+
+	// Parts 各层级，如 ["Programming Language", "Python", "3.11"]
+	Parts []string
+}
+
+// Parse 将分类器字符串拆分为层级，各层级会去掉首尾空白
+func Parse(s string) Classifier {
+	raw := strings.TrimSpace(s)
+	var parts []string
+	for _, part := range strings.Split(raw, "::") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return Classifier{Raw: raw, Parts: parts}
+}
+
+// String 返回以标准分隔符连接的分类器
+func (c Classifier) String() string {
+	return strings.Join(c.Parts, Separator)
+}
+
+// Category 返回顶层分类，如 "Topic"
+func (c Classifier) Category() string {
+	if len(c.Parts) == 0 {
+		return ""
+	}
+	return c.Parts[0]
+}
+
+// Leaf 返回最后一级，如 "3.11"
+func (c Classifier) Leaf() string {
+	if len(c.Parts) == 0 {
+		return ""
+	}
+	return c.Parts[len(c.Parts)-1]
+}
+
+// Depth 返回层级数
+func (c Classifier) Depth() int {
+	return len(c.Parts)
+}
+
+// Parent 返回上一级分类器，顶层分类没有上一级
+func (c Classifier) Parent() (Classifier, bool) {
+	if len(c.Parts) <= 1 {
+		return Classifier{}, false
+	}
+	parts := c.Parts[:len(c.Parts)-1]
+	return Classifier{Raw: strings.Join(parts, Separator), Parts: parts}, true
+}
+
+// HasPrefix 检查分类器是否位于prefix之下（含prefix本身），按层级比较且不区分大小写
+// 例如 "Framework :: Django :: 4.2" 位于 "Framework :: Django" 之下，但不位于 "Framework :: Djan" 之下
+func (c Classifier) HasPrefix(prefix string) bool {
+	p := Parse(prefix)
+	if len(p.Parts) == 0 || len(p.Parts) > len(c.Parts) {
+		return false
+	}
+	for i, part := range p.Parts {
+		if !strings.EqualFold(part, c.Parts[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Node 表示分类器层级树中的一个节点
+type Node struct {
+	// Name 当前层级的名称
+	Name string
+
+	// Classifier 节点对应的完整分类器，仅当该分类器被直接声明时不为空
+	Classifier string
+
+	// Children 子节点，按声明顺序排列
+	Children []*Node
+}
+
+// Child 根据名称查找子节点
+func (n *Node) Child(name string) *Node {
+	for _, child := range n.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// Tree 将一组分类器组织为层级树，根节点的Name为空
+//
+// 参数:
+//   - classifiers: 分类器列表，如PackageInfo.ClassifiersArray
+//
+// 返回值:
+//   - *Node: 树的根节点
+//
+// 使用示例:
+//
+//	root := classifier.Tree(info.ClassifiersArray)
+//	for _, category := range root.Children {
+//		fmt.Println(category.Name, len(category.Children))
+//	}
+func Tree(classifiers []string) *Node {
+	root := &Node{}
+	for _, raw := range classifiers {
+		c := Parse(raw)
+		node := root
+		for _, part := range c.Parts {
+			child := node.Child(part)
+			if child == nil {
+				child = &Node{Name: part}
+				node.Children = append(node.Children, child)
+			}
+			node = child
+		}
+		if node != root {
+			node.Classifier = c.String()
+		}
+	}
+	return root
+}
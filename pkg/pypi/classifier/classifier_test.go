@@ -0,0 +1,48 @@
+package classifier
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	c := Parse("  Programming Language ::  Python :: 3.11 ")
+	assert.Equal(t, []string{"Programming Language", "Python", "3.11"}, c.Parts)
+	assert.Equal(t, "Programming Language :: Python :: 3.11", c.String())
+	assert.Equal(t, CategoryProgrammingLanguage, c.Category())
+	assert.Equal(t, "3.11", c.Leaf())
+	assert.Equal(t, 3, c.Depth())
+
+	parent, ok := c.Parent()
+	require.True(t, ok)
+	assert.Equal(t, "Programming Language :: Python", parent.String())
+	_, ok = Parse("Topic").Parent()
+	assert.False(t, ok)
+
+	assert.True(t, c.HasPrefix("programming language :: python"))
+	assert.True(t, c.HasPrefix("Programming Language :: Python :: 3.11"))
+	assert.False(t, c.HasPrefix("Programming Language :: Py"))
+	assert.False(t, c.HasPrefix("Programming Language :: Python :: 3.11 :: Only"))
+}
+
+func TestTree(t *testing.T) {
+	root := Tree([]string{
+		"Framework :: Django",
+		"Framework :: Django :: 4.2",
+		"Framework :: Django :: 5.0",
+		"Topic :: Internet :: WWW/HTTP",
+	})
+	require.Len(t, root.Children, 2)
+
+	django := root.Child("Framework").Child("Django")
+	require.NotNil(t, django)
+	assert.Equal(t, "Framework :: Django", django.Classifier)
+	assert.Len(t, django.Children, 2)
+
+	internet := root.Child("Topic").Child("Internet")
+	require.NotNil(t, internet)
+	assert.Empty(t, internet.Classifier)
+	assert.Equal(t, "Topic :: Internet :: WWW/HTTP", internet.Child("WWW/HTTP").Classifier)
+}
@@ -0,0 +1,230 @@
+package classifier
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+)
+
+// Index 按分类器检索包的本地索引，可以并发使用
+// 每个分类器及其所有上级都会建立倒排表，因此按 "Framework :: Django" 检索也能找到只声明了
+// "Framework :: Django :: 4.2" 的包
+type Index struct {
+	mu       sync.RWMutex
+	packages map[string]*indexEntry
+	postings map[string]map[string]bool
+}
+
+type indexEntry struct {
+	info *models.PackageInfo
+	set  *Set
+}
+
+// Query 描述检索条件，所有非零值条件都必须满足
+type Query struct {
+	// Classifiers 必须全部声明的分类器，按层级前缀匹配且不区分大小写
+	Classifiers []string
+
+	// AnyClassifiers 至少声明其中一个的分类器
+	AnyClassifiers []string
+
+	// Python 必须声明支持的Python版本，如 "3.11" 或 "3"
+	Python string
+
+	// MinDevelopmentStatus 开发状态的最低级别（1~7）
+	MinDevelopmentStatus int
+
+	// Framework 必须声明的框架，如 "Django"
+	Framework string
+
+	// Typed 是否要求声明 "Typing :: Typed"
+	Typed bool
+}
+
+// NewIndex 创建一个空索引
+//
+// 使用示例:
+//
+//	index := classifier.NewIndex()
+//	for _, info := range crawled {
+//		index.Add(info)
+//	}
+//	results := index.Search(classifier.Query{Framework: "Django", Python: "3.12", MinDevelopmentStatus: 5})
+func NewIndex() *Index {
+	return &Index{
+		packages: make(map[string]*indexEntry),
+		postings: make(map[string]map[string]bool),
+	}
+}
+
+// Add 将包加入索引，同名（按PEP 503规范化）的包会被替换
+func (idx *Index) Add(info *models.PackageInfo) {
+	if info == nil {
+		return
+	}
+	name := models.NormalizeName(info.Name)
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(name)
+
+	entry := &indexEntry{info: info, set: NewSet(info.ClassifiersArray)}
+	idx.packages[name] = entry
+	for _, key := range postingKeys(entry.set) {
+		if idx.postings[key] == nil {
+			idx.postings[key] = make(map[string]bool)
+		}
+		idx.postings[key][name] = true
+	}
+}
+
+// Remove 从索引中移除包
+func (idx *Index) Remove(name string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(models.NormalizeName(name))
+}
+
+func (idx *Index) remove(name string) {
+	entry, ok := idx.packages[name]
+	if !ok {
+		return
+	}
+	for _, key := range postingKeys(entry.set) {
+		delete(idx.postings[key], name)
+		if len(idx.postings[key]) == 0 {
+			delete(idx.postings, key)
+		}
+	}
+	delete(idx.packages, name)
+}
+
+// Len 返回索引中的包数量
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.packages)
+}
+
+// Search 返回满足条件的包，按名称排序
+//
+// 参数:
+//   - q: 检索条件，零值Query返回所有包
+//
+// 返回值:
+//   - []*models.PackageInfo: 满足条件的包
+func (idx *Index) Search(q Query) []*models.PackageInfo {
+	return idx.Filter(func(info *models.PackageInfo, set *Set) bool {
+		if len(q.AnyClassifiers) > 0 {
+			matched := false
+			for _, c := range q.AnyClassifiers {
+				if set.Has(c) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return false
+			}
+		}
+		if q.Python != "" && !set.SupportsPython(q.Python) {
+			return false
+		}
+		if q.MinDevelopmentStatus > 0 && !set.DevelopmentStatusAtLeast(q.MinDevelopmentStatus) {
+			return false
+		}
+		if q.Framework != "" && !set.HasFramework(q.Framework) {
+			return false
+		}
+		if q.Typed && !set.IsTyped() {
+			return false
+		}
+		return true
+	}, q.Classifiers...)
+}
+
+// Filter 返回满足自定义条件的包，按名称排序
+// required中的分类器先通过倒排表缩小范围，再对剩余的包调用match
+func (idx *Index) Filter(match func(info *models.PackageInfo, set *Set) bool, required ...string) []*models.PackageInfo {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var candidates map[string]bool
+	for _, c := range required {
+		posting := idx.postings[postingKey(Parse(c).Parts)]
+		if candidates == nil {
+			candidates = make(map[string]bool, len(posting))
+			for name := range posting {
+				candidates[name] = true
+			}
+			continue
+		}
+		for name := range candidates {
+			if !posting[name] {
+				delete(candidates, name)
+			}
+		}
+	}
+
+	var names []string
+	if candidates == nil {
+		for name := range idx.packages {
+			names = append(names, name)
+		}
+	} else {
+		for name := range candidates {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var result []*models.PackageInfo
+	for _, name := range names {
+		entry := idx.packages[name]
+		if match == nil || match(entry.info, entry.set) {
+			result = append(result, entry.info)
+		}
+	}
+	return result
+}
+
+// Facets 统计prefix下一级各分类的包数量，如 Facets("Framework") 返回各框架的包数量
+func (idx *Index) Facets(prefix string) map[string]int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	depth := len(Parse(prefix).Parts)
+	counts := make(map[string]int)
+	for _, entry := range idx.packages {
+		seen := make(map[string]bool)
+		for _, c := range entry.set.Under(prefix) {
+			if len(c.Parts) > depth && !seen[c.Parts[depth]] {
+				seen[c.Parts[depth]] = true
+				counts[c.Parts[depth]]++
+			}
+		}
+	}
+	return counts
+}
+
+// postingKeys 返回集合中所有分类器及其上级的倒排表键
+func postingKeys(set *Set) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, c := range set.All() {
+		for i := 1; i <= len(c.Parts); i++ {
+			key := postingKey(c.Parts[:i])
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+func postingKey(parts []string) string {
+	return strings.ToLower(strings.Join(parts, Separator))
+}
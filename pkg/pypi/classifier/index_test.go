@@ -0,0 +1,85 @@
+package classifier
+
+import (
+	"testing"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func names(infos []*models.PackageInfo) []string {
+	var result []string
+	for _, info := range infos {
+		result = append(result, info.Name)
+	}
+	return result
+}
+
+func buildTestIndex() *Index {
+	index := NewIndex()
+	index.Add(&models.PackageInfo{Name: "Django-Demo", ClassifiersArray: djangoClassifiers})
+	index.Add(&models.PackageInfo{Name: "flask-demo", ClassifiersArray: []string{
+		"Development Status :: 3 - Alpha",
+		"Framework :: Flask",
+		"Programming Language :: Python :: 3.11",
+		"Topic :: Internet :: WWW/HTTP :: WSGI",
+	}})
+	index.Add(&models.PackageInfo{Name: "numeric", ClassifiersArray: []string{
+		"Development Status :: 6 - Mature",
+		"Programming Language :: Python :: 3.12",
+		"Topic :: Scientific/Engineering :: Mathematics",
+		"Typing :: Typed",
+	}})
+	return index
+}
+
+func TestIndexSearch(t *testing.T) {
+	index := buildTestIndex()
+	assert.Equal(t, 3, index.Len())
+
+	t.Run("零值条件返回全部", func(t *testing.T) {
+		assert.Equal(t, []string{"Django-Demo", "flask-demo", "numeric"}, names(index.Search(Query{})))
+	})
+
+	t.Run("按上级分类检索", func(t *testing.T) {
+		result := index.Search(Query{Classifiers: []string{"Topic :: Internet"}})
+		assert.Equal(t, []string{"Django-Demo", "flask-demo"}, names(result))
+	})
+
+	t.Run("组合条件", func(t *testing.T) {
+		assert.Equal(t, []string{"Django-Demo"}, names(index.Search(Query{Framework: "Django", Python: "3.12"})))
+		assert.Equal(t, []string{"Django-Demo", "numeric"}, names(index.Search(Query{MinDevelopmentStatus: 5})))
+		assert.Equal(t, []string{"Django-Demo", "numeric"}, names(index.Search(Query{Typed: true})))
+		assert.Equal(t, []string{"flask-demo", "numeric"}, names(index.Search(Query{
+			AnyClassifiers: []string{"Framework :: Flask", "Topic :: Scientific/Engineering"},
+		})))
+		assert.Empty(t, index.Search(Query{Classifiers: []string{"Framework :: Flask", "Typing :: Typed"}}))
+	})
+
+	t.Run("自定义过滤", func(t *testing.T) {
+		result := index.Filter(func(info *models.PackageInfo, set *Set) bool {
+			return len(set.PythonVersions()) == 1
+		})
+		assert.Equal(t, []string{"flask-demo", "numeric"}, names(result))
+	})
+
+	t.Run("统计分面", func(t *testing.T) {
+		facets := index.Facets("Topic")
+		assert.Equal(t, 2, facets["Internet"])
+		assert.Equal(t, 1, facets["Scientific/Engineering"])
+	})
+}
+
+func TestIndexReplaceAndRemove(t *testing.T) {
+	index := buildTestIndex()
+
+	index.Add(&models.PackageInfo{Name: "flask_demo", ClassifiersArray: []string{"Framework :: Django"}})
+	assert.Equal(t, 3, index.Len())
+	assert.Empty(t, index.Search(Query{Framework: "Flask"}))
+	require.Len(t, index.Search(Query{Framework: "Django"}), 2)
+
+	index.Remove("Django.Demo")
+	assert.Equal(t, 2, index.Len())
+	assert.Equal(t, []string{"flask_demo"}, names(index.Search(Query{Classifiers: []string{"Framework :: Django"}})))
+}
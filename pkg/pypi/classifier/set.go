@@ -0,0 +1,263 @@
+package classifier
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Set 表示一个包声明的全部分类器，提供常用的查询
+type Set struct {
+	items []Classifier
+}
+
+// NewSet 解析分类器列表，空字符串会被忽略
+//
+// 参数:
+//   - classifiers: 分类器列表，如PackageInfo.ClassifiersArray
+//
+// 返回值:
+//   - *Set: 分类器集合
+//
+// 使用示例:
+//
+//	set := classifier.NewSet(info.ClassifiersArray)
+//	fmt.Println(set.PythonVersions())
+//	if set.DevelopmentStatusAtLeast(5) && set.HasFramework("Django") {
+//		// ...
+//	}
+func NewSet(classifiers []string) *Set {
+	s := &Set{}
+	for _, raw := range classifiers {
+		if c := Parse(raw); len(c.Parts) > 0 {
+			s.items = append(s.items, c)
+		}
+	}
+	return s
+}
+
+// All 返回集合中的所有分类器
+func (s *Set) All() []Classifier {
+	return s.items
+}
+
+// Has 检查是否声明了某个分类器或其下级分类器
+func (s *Set) Has(prefix string) bool {
+	for _, c := range s.items {
+		if c.HasPrefix(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Under 返回位于prefix之下的所有分类器
+func (s *Set) Under(prefix string) []Classifier {
+	var result []Classifier
+	for _, c := range s.items {
+		if c.HasPrefix(prefix) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// leaves 返回prefix之下各分类器去掉prefix后的部分，以标准分隔符连接
+func (s *Set) leaves(prefix string) []string {
+	depth := len(Parse(prefix).Parts)
+	var result []string
+	for _, c := range s.Under(prefix) {
+		if len(c.Parts) > depth {
+			result = append(result, strings.Join(c.Parts[depth:], Separator))
+		}
+	}
+	return result
+}
+
+// Topics 返回 "Topic ::" 之下的主题，如 "Software Development :: Libraries"
+func (s *Set) Topics() []string {
+	return s.leaves(CategoryTopic)
+}
+
+// Licenses 返回 "License ::" 之下的许可证分类
+func (s *Set) Licenses() []string {
+	return s.leaves(CategoryLicense)
+}
+
+// OperatingSystems 返回 "Operating System ::" 之下的操作系统
+func (s *Set) OperatingSystems() []string {
+	return s.leaves(CategoryOperatingSystem)
+}
+
+// Environments 返回 "Environment ::" 之下的运行环境
+func (s *Set) Environments() []string {
+	return s.leaves(CategoryEnvironment)
+}
+
+// Audiences 返回 "Intended Audience ::" 之下的目标用户
+func (s *Set) Audiences() []string {
+	return s.leaves(CategoryIntendedAudience)
+}
+
+// NaturalLanguages 返回 "Natural Language ::" 之下的自然语言
+func (s *Set) NaturalLanguages() []string {
+	return s.leaves(CategoryNaturalLanguage)
+}
+
+// ProgrammingLanguages 返回声明的编程语言（只取第二级，如 "Python"、"C"），去重并保持顺序
+func (s *Set) ProgrammingLanguages() []string {
+	return s.secondLevel(CategoryProgrammingLanguage)
+}
+
+// Frameworks 返回声明的框架名称（只取第二级，如 "Django"），去重并保持顺序
+func (s *Set) Frameworks() []string {
+	return s.secondLevel(CategoryFramework)
+}
+
+func (s *Set) secondLevel(category string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, c := range s.Under(category) {
+		if len(c.Parts) > 1 && !seen[c.Parts[1]] {
+			seen[c.Parts[1]] = true
+			result = append(result, c.Parts[1])
+		}
+	}
+	return result
+}
+
+// HasFramework 检查是否声明了某个框架（不区分大小写），如 "Django"
+func (s *Set) HasFramework(name string) bool {
+	return s.Has(CategoryFramework + Separator + name)
+}
+
+// FrameworkVersions 返回某个框架声明的版本，如 Django 的 ["4.2", "5.0"]
+func (s *Set) FrameworkVersions(name string) []string {
+	var result []string
+	for _, leaf := range s.leaves(CategoryFramework + Separator + name) {
+		if isVersion(leaf) {
+			result = append(result, leaf)
+		}
+	}
+	sortVersions(result)
+	return result
+}
+
+// DevelopmentStatus 返回开发状态的级别（1~7）和名称，如 5 和 "Production/Stable"
+// 声明了多个开发状态时取级别最高的一个
+func (s *Set) DevelopmentStatus() (int, string, bool) {
+	level, name, found := 0, "", false
+	for _, leaf := range s.leaves(CategoryDevelopmentStatus) {
+		numberText, label, ok := strings.Cut(leaf, " - ")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(numberText))
+		if err != nil {
+			continue
+		}
+		if !found || n > level {
+			level, name, found = n, strings.TrimSpace(label), true
+		}
+	}
+	return level, name, found
+}
+
+// DevelopmentStatusAtLeast 检查开发状态是否不低于指定级别，未声明时返回false
+// 例如 DevelopmentStatusAtLeast(5) 表示 Production/Stable 或 Mature
+func (s *Set) DevelopmentStatusAtLeast(level int) bool {
+	n, _, ok := s.DevelopmentStatus()
+	return ok && n >= level
+}
+
+// PythonVersions 返回声明支持的Python版本，如 ["3.8", "3.9", "3.10"]
+// 只声明了主版本（如 "Programming Language :: Python :: 3"）时返回主版本
+func (s *Set) PythonVersions() []string {
+	var minors, majors []string
+	for _, leaf := range s.leaves(CategoryProgrammingLanguage + Separator + "Python") {
+		leaf = strings.TrimSuffix(leaf, Separator+"Only")
+		if !isVersion(leaf) {
+			continue
+		}
+		if strings.Contains(leaf, ".") {
+			minors = appendUnique(minors, leaf)
+		} else {
+			majors = appendUnique(majors, leaf)
+		}
+	}
+
+	// 主版本已由次版本覆盖时不再单独列出
+	result := minors
+	for _, major := range majors {
+		covered := false
+		for _, minor := range minors {
+			if strings.HasPrefix(minor, major+".") {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			result = append(result, major)
+		}
+	}
+	sortVersions(result)
+	return result
+}
+
+// SupportsPython 检查是否声明支持某个Python版本
+// version可以是次版本（"3.11"）或主版本（"3"），主版本匹配其下任一次版本
+func (s *Set) SupportsPython(version string) bool {
+	for _, v := range s.PythonVersions() {
+		if v == version || strings.HasPrefix(v, version+".") || (!strings.Contains(v, ".") && strings.HasPrefix(version, v+".")) {
+			return true
+		}
+	}
+	return false
+}
+
+// PythonImplementations 返回声明的Python实现，如 ["CPython", "PyPy"]
+func (s *Set) PythonImplementations() []string {
+	return s.leaves(CategoryProgrammingLanguage + Separator + "Python" + Separator + "Implementation")
+}
+
+// IsTyped 检查是否声明了 "Typing :: Typed"
+func (s *Set) IsTyped() bool {
+	return s.Has(CategoryTyping + Separator + "Typed")
+}
+
+// isVersion 检查字符串是否为 "3"、"3.11" 这样的数字版本
+func isVersion(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, part := range strings.Split(s, ".") {
+		if _, err := strconv.Atoi(part); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// sortVersions 按数字顺序排序版本号，使 "3.10" 排在 "3.9" 之后
+func sortVersions(versions []string) {
+	sort.Slice(versions, func(i, j int) bool {
+		a, b := strings.Split(versions[i], "."), strings.Split(versions[j], ".")
+		for k := 0; k < len(a) && k < len(b); k++ {
+			x, _ := strconv.Atoi(a[k])
+			y, _ := strconv.Atoi(b[k])
+			if x != y {
+				return x < y
+			}
+		}
+		return len(a) < len(b)
+	})
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, existing := range list {
+		if existing == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
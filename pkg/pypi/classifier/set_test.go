@@ -0,0 +1,72 @@
+package classifier
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var djangoClassifiers = []string{
+	"Development Status :: 4 - Beta",
+	"Development Status :: 5 - Production/Stable",
+	"Environment :: Web Environment",
+	"Framework :: Django",
+	"Framework :: Django :: 4.2",
+	"Framework :: Django :: 5.0",
+	"Intended Audience :: Developers",
+	"License :: OSI Approved :: BSD License",
+	"Operating System :: OS Independent",
+	"Programming Language :: Python",
+	"Programming Language :: Python :: 3",
+	"Programming Language :: Python :: 3 :: Only",
+	"Programming Language :: Python :: 3.9",
+	"Programming Language :: Python :: 3.10",
+	"Programming Language :: Python :: 3.12",
+	"Programming Language :: Python :: Implementation :: CPython",
+	"Topic :: Internet :: WWW/HTTP",
+	"Typing :: Typed",
+}
+
+func TestSet(t *testing.T) {
+	set := NewSet(djangoClassifiers)
+
+	t.Run("分类查询", func(t *testing.T) {
+		assert.Equal(t, []string{"Internet :: WWW/HTTP"}, set.Topics())
+		assert.Equal(t, []string{"OSI Approved :: BSD License"}, set.Licenses())
+		assert.Equal(t, []string{"OS Independent"}, set.OperatingSystems())
+		assert.Equal(t, []string{"Web Environment"}, set.Environments())
+		assert.Equal(t, []string{"Developers"}, set.Audiences())
+		assert.Equal(t, []string{"Python"}, set.ProgrammingLanguages())
+		assert.Equal(t, []string{"CPython"}, set.PythonImplementations())
+		assert.True(t, set.IsTyped())
+	})
+
+	t.Run("Python版本", func(t *testing.T) {
+		assert.Equal(t, []string{"3.9", "3.10", "3.12"}, set.PythonVersions())
+		assert.True(t, set.SupportsPython("3.10"))
+		assert.True(t, set.SupportsPython("3"))
+		assert.False(t, set.SupportsPython("3.11"))
+		assert.False(t, set.SupportsPython("2.7"))
+
+		onlyMajor := NewSet([]string{"Programming Language :: Python :: 3"})
+		assert.Equal(t, []string{"3"}, onlyMajor.PythonVersions())
+		assert.True(t, onlyMajor.SupportsPython("3.13"))
+	})
+
+	t.Run("开发状态取最高级别", func(t *testing.T) {
+		level, name, ok := set.DevelopmentStatus()
+		assert.True(t, ok)
+		assert.Equal(t, 5, level)
+		assert.Equal(t, "Production/Stable", name)
+		assert.True(t, set.DevelopmentStatusAtLeast(5))
+		assert.False(t, set.DevelopmentStatusAtLeast(6))
+		assert.False(t, NewSet(nil).DevelopmentStatusAtLeast(1))
+	})
+
+	t.Run("框架", func(t *testing.T) {
+		assert.Equal(t, []string{"Django"}, set.Frameworks())
+		assert.True(t, set.HasFramework("django"))
+		assert.False(t, set.HasFramework("Flask"))
+		assert.Equal(t, []string{"4.2", "5.0"}, set.FrameworkVersions("Django"))
+	})
+}
@@ -0,0 +1,112 @@
+package classifier
+
+import (
+	_ "embed"
+	"strings"
+	"sync"
+)
+
+// classifiersText 官方Trove分类器列表的离线副本（https://pypi.org/classifiers/），每行一个
+//
+//go:embed data/classifiers.txt
+var classifiersText string
+
+var (
+	officialOnce sync.Once
+
+	// official 官方分类器列表，保持文件中的顺序
+	official []string
+
+	// officialSet 用于精确查找的集合
+	officialSet map[string]bool
+
+	// officialFold 小写形式到规范写法的映射，用于给出修正建议
+	officialFold map[string]string
+)
+
+func loadOfficial() {
+	officialOnce.Do(func() {
+		officialSet = make(map[string]bool)
+		officialFold = make(map[string]string)
+		for _, line := range strings.Split(classifiersText, "\n") {
+			if line = strings.TrimSpace(line); line == "" {
+				continue
+			}
+			official = append(official, line)
+			officialSet[line] = true
+			officialFold[strings.ToLower(line)] = line
+		}
+	})
+}
+
+// Official 返回嵌入的官方分类器列表
+func Official() []string {
+	loadOfficial()
+	result := make([]string, len(official))
+	copy(result, official)
+	return result
+}
+
+// IsOfficial 检查分类器是否在官方列表中（精确匹配）
+func IsOfficial(classifier string) bool {
+	loadOfficial()
+	return officialSet[classifier]
+}
+
+// Issue 表示一个未通过校验的分类器
+type Issue struct {
+	// Classifier 原始分类器
+	Classifier string
+
+	// Reason 未通过的原因
+	Reason string
+
+	// Suggestion 可能的正确写法，没有建议时为空
+	Suggestion string
+}
+
+// Validate 根据官方分类器列表校验分类器
+// "Private ::" 开头的分类器用于阻止上传到PyPI，会被单独报告
+//
+// 参数:
+//   - classifiers: 待校验的分类器列表
+//
+// 返回值:
+//   - []Issue: 未通过校验的分类器，全部有效时为空
+//
+// 使用示例:
+//
+//	for _, issue := range classifier.Validate(info.ClassifiersArray) {
+//		fmt.Printf("%s: %s %s\n", issue.Classifier, issue.Reason, issue.Suggestion)
+//	}
+func Validate(classifiers []string) []Issue {
+	loadOfficial()
+
+	var issues []Issue
+	seen := make(map[string]bool)
+	for _, raw := range classifiers {
+		if IsOfficial(raw) {
+			if seen[raw] {
+				issues = append(issues, Issue{Classifier: raw, Reason: "重复声明"})
+			}
+			seen[raw] = true
+			continue
+		}
+
+		c := Parse(raw)
+		switch {
+		case len(c.Parts) == 0:
+			issues = append(issues, Issue{Classifier: raw, Reason: "分类器为空"})
+		case strings.EqualFold(c.Category(), CategoryPrivate):
+			issues = append(issues, Issue{Classifier: raw, Reason: "私有分类器，PyPI会拒绝上传"})
+		default:
+			issue := Issue{Classifier: raw, Reason: "不在官方分类器列表中"}
+			if canonical, ok := officialFold[strings.ToLower(c.String())]; ok {
+				issue.Reason = "大小写或空白与官方写法不一致"
+				issue.Suggestion = canonical
+			}
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
@@ -0,0 +1,44 @@
+package classifier
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOfficial(t *testing.T) {
+	all := Official()
+	assert.Greater(t, len(all), 500)
+	assert.True(t, IsOfficial("Development Status :: 5 - Production/Stable"))
+	assert.True(t, IsOfficial("Programming Language :: Python :: 3.12"))
+	assert.True(t, IsOfficial("Framework :: Django :: 4.2"))
+	assert.False(t, IsOfficial("Programming Language :: Python :: 4.0"))
+
+	t.Run("列表中的分类器都是规范写法", func(t *testing.T) {
+		for _, raw := range all {
+			assert.Equal(t, raw, Parse(raw).String())
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	issues := Validate([]string{
+		"Programming Language :: Python :: 3",
+		"Programming Language :: Python :: 3",
+		"programming language :: python :: 3.11",
+		"Topic :: Made Up",
+		"Private :: Do Not Upload",
+		"",
+	})
+	require.Len(t, issues, 5)
+
+	assert.Equal(t, "重复声明", issues[0].Reason)
+	assert.Equal(t, "Programming Language :: Python :: 3.11", issues[1].Suggestion)
+	assert.Equal(t, "Topic :: Made Up", issues[2].Classifier)
+	assert.Empty(t, issues[2].Suggestion)
+	assert.Contains(t, issues[3].Reason, "私有")
+	assert.Equal(t, "分类器为空", issues[4].Reason)
+
+	assert.Empty(t, Validate(djangoClassifiers))
+}
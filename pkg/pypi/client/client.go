@@ -2,9 +2,12 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
@@ -12,9 +15,16 @@ import (
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/scagogogo/pypi-crawler/pkg/pypi/api"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/auth"
 	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
 )
 
+// ErrNotFound 表示请求的包或版本在索引中不存在（HTTP 404）
+var ErrNotFound = errors.New("资源不存在")
+
+// ErrUnauthorized 表示索引拒绝了请求的认证信息或要求认证（HTTP 401/403）
+var ErrUnauthorized = errors.New("认证失败")
+
 // Client 实现了与PyPI JSON API交互的客户端
 // 使用纯API调用方式，不使用爬虫方式
 type Client struct {
@@ -22,6 +32,11 @@ type Client struct {
 	client  *http.Client
 }
 
+var (
+	_ api.PyPIClient         = (*Client)(nil)
+	_ api.CoreMetadataClient = (*Client)(nil)
+)
+
 // NewClient 创建一个新的PyPI客户端实例
 //
 // 参数:
@@ -47,24 +62,7 @@ func NewClient(options ...*Options) api.PyPIClient {
 		clientOptions = options[0]
 	}
 
-	// 创建HTTP客户端
-	transport := http.DefaultTransport.(*http.Transport).Clone()
-
-	// 设置代理
-	if clientOptions.Proxy != "" {
-		proxyURL, _ := url.Parse(clientOptions.Proxy)
-		transport.Proxy = http.ProxyURL(proxyURL)
-	}
-
-	httpClient := &http.Client{
-		Transport: transport,
-		Timeout:   clientOptions.Timeout,
-	}
-
-	return &Client{
-		options: clientOptions,
-		client:  httpClient,
-	}
+	return newClient(clientOptions)
 }
 
 // NewClientWithOptions 使用指定选项创建客户端（供内部和兼容层使用）
@@ -79,24 +77,83 @@ func NewClientWithOptions(options *Options) api.PyPIClient {
 		options = NewOptions()
 	}
 
-	// 创建HTTP客户端
+	return newClient(options)
+}
+
+// newClient 创建客户端，BaseURL中内嵌的认证信息由HTTP客户端添加，不出现在请求URL和错误信息中
+func newClient(options *Options) *Client {
+	c := &Client{options: options, client: NewHTTPClient(options)}
+	if baseURL, provider := auth.SplitURL(options.BaseURL); provider != nil {
+		stripped := *options
+		stripped.BaseURL = baseURL
+		c.options = &stripped
+	}
+	return c
+}
+
+// NewHTTPClient 按选项的代理、超时、受信任主机和认证信息创建HTTP客户端
+// 用于下载发布文件等需要直接发送请求、又要与客户端使用相同网络设置的场景
+//
+// 参数:
+//   - options: 配置选项，不能为nil
+//
+// 返回值:
+//   - *http.Client: HTTP客户端，Proxy为空时使用环境变量中的代理
+func NewHTTPClient(options *Options) *http.Client {
 	transport := http.DefaultTransport.(*http.Transport).Clone()
 
-	// 设置代理
+	// 设置代理；没有指定代理时使用Loader读取的代理环境变量，否则保留默认transport的行为
 	if options.Proxy != "" {
 		proxyURL, _ := url.Parse(options.Proxy)
 		transport.Proxy = http.ProxyURL(proxyURL)
+	} else if options.proxyEnv != nil {
+		transport.Proxy = options.proxyEnv.proxy
+	}
+
+	var roundTripper http.RoundTripper = transport
+	if len(options.TrustedHosts) > 0 {
+		roundTripper = newTrustedHostsTransport(transport, options.TrustedHosts)
+	}
+
+	// 认证信息按请求的URL添加，跨主机的重定向不会携带
+	_, embedded := auth.SplitURL(options.BaseURL)
+	if provider := auth.Chain(embedded, options.Credentials); embedded != nil || options.Credentials != nil {
+		roundTripper = auth.NewTransport(roundTripper, provider)
 	}
 
-	httpClient := &http.Client{
-		Transport: transport,
+	return &http.Client{
+		Transport: roundTripper,
 		Timeout:   options.Timeout,
 	}
+}
+
+// trustedHostsTransport 请求受信任的主机时使用不校验证书的transport，其余主机不受影响
+type trustedHostsTransport struct {
+	hosts    map[string]bool
+	trusted  http.RoundTripper
+	verified http.RoundTripper
+}
+
+func newTrustedHostsTransport(transport *http.Transport, hosts []string) *trustedHostsTransport {
+	insecure := transport.Clone()
+	insecure.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	t := &trustedHostsTransport{hosts: map[string]bool{}, trusted: insecure, verified: transport}
+	for _, host := range hosts {
+		host = strings.TrimSpace(host)
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		t.hosts[strings.ToLower(strings.Trim(host, "[]"))] = true
+	}
+	return t
+}
 
-	return &Client{
-		options: options,
-		client:  httpClient,
+// RoundTrip 实现http.RoundTripper
+func (t *trustedHostsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.hosts[strings.ToLower(req.URL.Hostname())] {
+		return t.trusted.RoundTrip(req)
 	}
+	return t.verified.RoundTrip(req)
 }
 
 // GetPackageInfo 获取指定包的最新版本信息
@@ -109,8 +166,12 @@ func NewClientWithOptions(options *Options) api.PyPIClient {
 //   - *models.Package: 包含包详细信息的结构体指针
 //   - error: 如有错误则返回，否则为nil
 func (c *Client) GetPackageInfo(ctx context.Context, packageName string) (*models.Package, error) {
+	if err := c.options.Layout.requireJSONAPI("GetPackageInfo", c.options.BaseURL); err != nil {
+		return nil, err
+	}
+
 	// 构建API URL
-	apiURL := fmt.Sprintf("%s/pypi/%s/json", c.options.BaseURL, url.PathEscape(packageName))
+	apiURL := c.options.Layout.JSONURL(c.options.BaseURL, packageName)
 
 	// 发送请求
 	responseBody, err := c.sendRequest(ctx, apiURL)
@@ -124,6 +185,7 @@ func (c *Client) GetPackageInfo(ctx context.Context, packageName string) (*model
 		return nil, fmt.Errorf("解析包 %s 信息失败: %w", packageName, err)
 	}
 
+	c.options.Layout.rewritePackage(&pkg)
 	return &pkg, nil
 }
 
@@ -138,8 +200,12 @@ func (c *Client) GetPackageInfo(ctx context.Context, packageName string) (*model
 //   - *models.Package: 包含特定版本详细信息的结构体指针
 //   - error: 如有错误则返回，否则为nil
 func (c *Client) GetPackageVersion(ctx context.Context, packageName string, version string) (*models.Package, error) {
+	if err := c.options.Layout.requireJSONAPI("GetPackageVersion", c.options.BaseURL); err != nil {
+		return nil, err
+	}
+
 	// 构建API URL
-	apiURL := fmt.Sprintf("%s/pypi/%s/%s/json", c.options.BaseURL, url.PathEscape(packageName), url.PathEscape(version))
+	apiURL := c.options.Layout.JSONURL(c.options.BaseURL, packageName, version)
 
 	// 发送请求
 	responseBody, err := c.sendRequest(ctx, apiURL)
@@ -153,6 +219,7 @@ func (c *Client) GetPackageVersion(ctx context.Context, packageName string, vers
 		return nil, fmt.Errorf("解析包 %s 版本 %s 信息失败: %w", packageName, version, err)
 	}
 
+	c.options.Layout.rewritePackage(&pkg)
 	return &pkg, nil
 }
 
@@ -166,6 +233,10 @@ func (c *Client) GetPackageVersion(ctx context.Context, packageName string, vers
 //   - []string: 包含版本号的字符串切片
 //   - error: 如有错误则返回，否则为nil
 func (c *Client) GetPackageReleases(ctx context.Context, packageName string) ([]string, error) {
+	if err := c.options.Layout.requireJSONAPI("GetPackageReleases", c.options.BaseURL); err != nil {
+		return nil, err
+	}
+
 	// 通过获取包信息来获取所有版本
 	pkg, err := c.GetPackageInfo(ctx, packageName)
 	if err != nil {
@@ -192,6 +263,10 @@ func (c *Client) GetPackageReleases(ctx context.Context, packageName string) ([]
 //   - []models.Vulnerability: 包含漏洞信息的切片
 //   - error: 如有错误则返回，否则为nil
 func (c *Client) CheckPackageVulnerabilities(ctx context.Context, packageName string, version string) ([]models.Vulnerability, error) {
+	if err := c.options.Layout.requireJSONAPI("CheckPackageVulnerabilities", c.options.BaseURL); err != nil {
+		return nil, err
+	}
+
 	// 获取特定版本的包信息，其中包含漏洞信息
 	pkg, err := c.GetPackageVersion(ctx, packageName, version)
 	if err != nil {
@@ -213,7 +288,7 @@ func (c *Client) CheckPackageVulnerabilities(ctx context.Context, packageName st
 //   - error: 如有错误则返回，否则为nil
 func (c *Client) GetAllPackages(ctx context.Context) ([]string, error) {
 	// 构建Simple API URL
-	simpleURL := fmt.Sprintf("%s/simple/", c.options.BaseURL)
+	simpleURL := c.options.Layout.SimpleURL(c.options.BaseURL)
 
 	// 发送请求
 	responseBody, err := c.sendRequest(ctx, simpleURL)
@@ -331,6 +406,12 @@ func (c *Client) parsePackageIndex(indexPageHTML string) ([]string, error) {
 //   - []byte: 响应体内容的字节数组
 //   - error: 如有错误则返回，否则为nil
 func (c *Client) sendRequest(ctx context.Context, requestURL string) ([]byte, error) {
+	return c.sendRequestWithAccept(ctx, requestURL, "application/json")
+}
+
+// sendRequestWithAccept 发送带有指定Accept头部的HTTP请求并返回响应体
+// 用于Simple API等需要内容协商的接口
+func (c *Client) sendRequestWithAccept(ctx context.Context, requestURL string, accept string) ([]byte, error) {
 	// 创建请求
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
 	if err != nil {
@@ -339,7 +420,7 @@ func (c *Client) sendRequest(ctx context.Context, requestURL string) ([]byte, er
 
 	// 设置用户代理头部
 	req.Header.Set("User-Agent", c.options.UserAgent)
-	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept", accept)
 
 	// 重试逻辑
 	var resp *http.Response
@@ -382,6 +463,12 @@ func (c *Client) sendRequest(ctx context.Context, requestURL string) ([]byte, er
 	defer resp.Body.Close()
 
 	// 检查状态码
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: HTTP请求失败: %d %s", ErrNotFound, resp.StatusCode, resp.Status)
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, fmt.Errorf("%w: HTTP请求失败: %d %s", ErrUnauthorized, resp.StatusCode, resp.Status)
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("HTTP请求失败: %d %s", resp.StatusCode, resp.Status)
 	}
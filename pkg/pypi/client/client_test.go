@@ -11,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/auth"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -462,7 +463,7 @@ func TestSendRequest(t *testing.T) {
 		ctx := context.Background()
 
 		resp, err := client.sendRequest(ctx, server.URL+"/not-found")
-		assert.Error(t, err)
+		assert.ErrorIs(t, err, ErrNotFound)
 		assert.Nil(t, resp)
 	})
 
@@ -472,6 +473,7 @@ func TestSendRequest(t *testing.T) {
 
 		resp, err := client.sendRequest(ctx, server.URL+"/server-error")
 		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrNotFound)
 		assert.Nil(t, resp)
 	})
 
@@ -569,3 +571,89 @@ func (c *clientWrapper) SearchPackages(ctx context.Context, keyword string, limi
 
 	return results, nil
 }
+
+func TestCredentials(t *testing.T) {
+	// files 模拟发布文件所在的另一个主机
+	var filesAuth []string
+	files := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filesAuth = append(filesAuth, r.Header.Get("Authorization"))
+		w.Write([]byte(`{"info": {"name": "demo", "version": "1.0"}}`))
+	}))
+	defer files.Close()
+
+	var indexAuth []string
+	index := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		indexAuth = append(indexAuth, r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/simple/":
+			w.Write([]byte(`<html><body><a href="/simple/demo/">demo</a></body></html>`))
+		case "/pypi/demo/json":
+			http.Redirect(w, r, files.URL+"/demo.json", http.StatusFound)
+		}
+	}))
+	defer index.Close()
+	host := strings.TrimPrefix(index.URL, "http://")
+	basic := auth.Credential{Username: "alice", Password: "secret"}.Header()
+
+	t.Run("URL中内嵌的认证信息", func(t *testing.T) {
+		indexAuth, filesAuth = nil, nil
+		c := NewClient(NewOptions().WithBaseURL("http://alice:secret@" + host).WithMaxRetries(1))
+		packages, err := c.GetAllPackages(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"demo"}, packages)
+
+		pkg, err := c.GetPackageInfo(context.Background(), "demo")
+		require.NoError(t, err)
+		assert.Equal(t, "demo", pkg.Info.Name)
+		assert.Equal(t, []string{basic, basic}, indexAuth)
+		assert.Equal(t, []string{""}, filesAuth, "认证信息不应发送给文件主机")
+
+		_, err = c.GetPackageVersion(context.Background(), "demo", "1.0")
+		assert.NotContains(t, err.Error(), "secret")
+	})
+
+	t.Run("令牌", func(t *testing.T) {
+		indexAuth = nil
+		options := NewOptions().WithBaseURL(index.URL).WithMaxRetries(1).
+			WithCredentials(auth.Token("other.internal", "wrong"), auth.Token(host, "abc"))
+		_, err := NewClient(options).GetAllPackages(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Bearer abc"}, indexAuth)
+	})
+
+	t.Run("没有认证信息", func(t *testing.T) {
+		_, err := NewClient(NewOptions().WithBaseURL(index.URL).WithMaxRetries(1)).GetAllPackages(context.Background())
+		assert.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("从配置加载", func(t *testing.T) {
+		dir := t.TempDir()
+		netrc := filepath.Join(dir, ".netrc")
+		require.NoError(t, os.WriteFile(netrc, []byte("machine 127.0.0.1 login alice password secret\n"), 0o600))
+		configFile := filepath.Join(dir, "config.yaml")
+		require.NoError(t, os.WriteFile(configFile, []byte("credentials:\n  - url: "+index.URL+"/\n    token: from-config\n"), 0o600))
+		env := map[string]string{"HOME": dir, "PIP_CONFIG_FILE": os.DevNull}
+
+		options, err := NewLoader().WithEnv(func(key string) string { return env[key] }).Load()
+		require.NoError(t, err)
+		indexAuth = nil
+		_, err = NewClient(options.WithBaseURL(index.URL).WithMaxRetries(1)).GetAllPackages(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{basic}, indexAuth)
+
+		options, err = NewLoader().WithEnv(func(key string) string { return env[key] }).WithFile(configFile).Load()
+		require.NoError(t, err)
+		indexAuth = nil
+		_, err = NewClient(options.WithBaseURL(index.URL).WithMaxRetries(1)).GetAllPackages(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Bearer from-config"}, indexAuth)
+
+		options, err = NewLoader().WithEnv(func(key string) string { return env[key] }).WithCredentialFiles(false).Load()
+		require.NoError(t, err)
+		assert.Nil(t, options.Credentials)
+	})
+}
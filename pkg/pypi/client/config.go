@@ -0,0 +1,464 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/auth"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix 本库环境变量的前缀，如 PYPI_CRAWLER_BASE_URL
+const EnvPrefix = "PYPI_CRAWLER_"
+
+// EnvConfigFile 指定本库配置文件路径的环境变量
+const EnvConfigFile = EnvPrefix + "CONFIG"
+
+// ErrInvalidConfig 表示配置文件或环境变量中的值无效
+var ErrInvalidConfig = errors.New("无效的配置")
+
+// Config 配置文件或环境变量中的客户端选项，为空的字段不覆盖已有的值
+//
+// YAML格式的配置文件:
+//
+//	index_url: https://pypi.tuna.tsinghua.edu.cn/simple
+//	extra_index_urls: [https://devpi.internal/root/pypi/+simple/]
+//	trusted_hosts: [devpi.internal]
+//	proxy: http://127.0.0.1:8080
+//	timeout: 15s
+//	max_retries: 5
+type Config struct {
+	// BaseURL 索引的基础URL，与Options.BaseURL相同
+	BaseURL string `json:"base_url,omitempty" yaml:"base_url,omitempty" toml:"base_url,omitempty"`
+
+	// IndexURL Simple API的地址，与pip的index-url相同；不能与BaseURL同时设置
+	IndexURL string `json:"index_url,omitempty" yaml:"index_url,omitempty" toml:"index_url,omitempty"`
+
+	// ExtraIndexURLs 额外索引的Simple API地址
+	ExtraIndexURLs []string `json:"extra_index_urls,omitempty" yaml:"extra_index_urls,omitempty" toml:"extra_index_urls,omitempty"`
+
+	// TrustedHosts 不校验TLS证书的主机
+	TrustedHosts []string `json:"trusted_hosts,omitempty" yaml:"trusted_hosts,omitempty" toml:"trusted_hosts,omitempty"`
+
+	// Proxy HTTP代理地址
+	Proxy string `json:"proxy,omitempty" yaml:"proxy,omitempty" toml:"proxy,omitempty"`
+
+	// Timeout 请求超时，Go的时长格式（如 "15s"）或秒数（如 "15"）
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty" toml:"timeout,omitempty"`
+
+	// UserAgent 请求的User-Agent
+	UserAgent string `json:"user_agent,omitempty" yaml:"user_agent,omitempty" toml:"user_agent,omitempty"`
+
+	// MaxRetries 最大重试次数
+	MaxRetries *int `json:"max_retries,omitempty" yaml:"max_retries,omitempty" toml:"max_retries,omitempty"`
+
+	// RetryDelay 重试间隔，格式与Timeout相同
+	RetryDelay string `json:"retry_delay,omitempty" yaml:"retry_delay,omitempty" toml:"retry_delay,omitempty"`
+
+	// RespectETag 是否遵循ETag缓存机制
+	RespectETag *bool `json:"respect_etag,omitempty" yaml:"respect_etag,omitempty" toml:"respect_etag,omitempty"`
+
+	// Credentials 私有索引的认证信息，按顺序匹配请求的URL
+	Credentials []CredentialConfig `json:"credentials,omitempty" yaml:"credentials,omitempty" toml:"credentials,omitempty"`
+
+	// Netrc 额外读取的netrc文件，文件必须存在
+	Netrc string `json:"netrc,omitempty" yaml:"netrc,omitempty" toml:"netrc,omitempty"`
+
+	// KeyringFile 额外读取的keyrings.alt PlaintextKeyring文件，文件必须存在
+	KeyringFile string `json:"keyring_file,omitempty" yaml:"keyring_file,omitempty" toml:"keyring_file,omitempty"`
+}
+
+// CredentialConfig 配置文件中的一条认证信息，Token不为空时使用Bearer认证，否则使用Basic认证
+//
+//	credentials:
+//	  - url: devpi.internal
+//	    username: alice
+//	    password: secret
+//	  - url: https://artifactory.internal/api/pypi/
+//	    token: eyJ...
+type CredentialConfig struct {
+	// URL 匹配的主机或URL前缀，规则见auth.Match
+	URL string `json:"url" yaml:"url" toml:"url"`
+
+	// Username Basic认证的用户名
+	Username string `json:"username,omitempty" yaml:"username,omitempty" toml:"username,omitempty"`
+
+	// Password Basic认证的密码
+	Password string `json:"password,omitempty" yaml:"password,omitempty" toml:"password,omitempty"`
+
+	// Token Bearer认证的令牌
+	Token string `json:"token,omitempty" yaml:"token,omitempty" toml:"token,omitempty"`
+}
+
+// ParseConfig 解析配置文件，未知的字段视为错误
+//
+// 参数:
+//   - data: 配置内容
+//   - format: "yaml"、"json"或"toml"
+//
+// 返回值:
+//   - *Config: 解析后的配置
+//   - error: 格式错误时返回，匹配ErrInvalidConfig
+func ParseConfig(data []byte, format string) (*Config, error) {
+	var c Config
+	switch strings.ToLower(format) {
+	case "yaml", "yml", "json":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+		}
+	case "toml":
+		meta, err := toml.Decode(string(data), &c)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("%w: 未知的字段 %s", ErrInvalidConfig, undecoded[0])
+		}
+	default:
+		return nil, fmt.Errorf("%w: 不支持的格式 %q", ErrInvalidConfig, format)
+	}
+	return &c, nil
+}
+
+// LoadConfigFile 读取配置文件，格式由扩展名决定（.yaml、.yml、.json或.toml）
+func LoadConfigFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	c, err := ParseConfig(data, strings.TrimPrefix(filepath.Ext(path), "."))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// configFromEnv 读取本库的环境变量，变量名为EnvPrefix加大写的字段名，如 PYPI_CRAWLER_TIMEOUT
+// 列表以空白分隔，与pip的环境变量相同
+func configFromEnv(getenv func(string) string) (*Config, error) {
+	c := &Config{
+		BaseURL:        getenv(EnvPrefix + "BASE_URL"),
+		IndexURL:       getenv(EnvPrefix + "INDEX_URL"),
+		ExtraIndexURLs: splitPipList(getenv(EnvPrefix + "EXTRA_INDEX_URL")),
+		TrustedHosts:   splitPipList(getenv(EnvPrefix + "TRUSTED_HOST")),
+		Proxy:          getenv(EnvPrefix + "PROXY"),
+		Timeout:        getenv(EnvPrefix + "TIMEOUT"),
+		UserAgent:      getenv(EnvPrefix + "USER_AGENT"),
+		RetryDelay:     getenv(EnvPrefix + "RETRY_DELAY"),
+		Netrc:          getenv(EnvPrefix + "NETRC"),
+		KeyringFile:    getenv(EnvPrefix + "KEYRING_FILE"),
+	}
+	if v := getenv(EnvPrefix + "MAX_RETRIES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %sMAX_RETRIES=%q 不是整数", ErrInvalidConfig, EnvPrefix, v)
+		}
+		c.MaxRetries = &n
+	}
+	if v := getenv(EnvPrefix + "RESPECT_ETAG"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %sRESPECT_ETAG=%q 不是布尔值", ErrInvalidConfig, EnvPrefix, v)
+		}
+		c.RespectETag = &b
+	}
+	return c, nil
+}
+
+// Apply 把配置中非空的字段写入选项
+//
+// 参数:
+//   - o: 要更新的选项
+//
+// 返回值:
+//   - error: 地址、时长或重试次数无效时返回，匹配ErrInvalidConfig；此时o可能已被部分修改
+func (c *Config) Apply(o *Options) error {
+	if c.BaseURL != "" && c.IndexURL != "" {
+		return fmt.Errorf("%w: base_url和index_url不能同时设置", ErrInvalidConfig)
+	}
+	if c.BaseURL != "" {
+		o.BaseURL = strings.TrimRight(c.BaseURL, "/")
+	}
+	if c.IndexURL != "" {
+		if _, err := o.WithIndexURL(c.IndexURL); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+		}
+	}
+	if c.ExtraIndexURLs != nil {
+		o.ExtraIndexURLs = c.ExtraIndexURLs
+	}
+	if c.TrustedHosts != nil {
+		o.TrustedHosts = c.TrustedHosts
+	}
+	if c.Proxy != "" {
+		o.Proxy = c.Proxy
+	}
+	if c.Timeout != "" {
+		d, err := parseSeconds(c.Timeout)
+		if err != nil {
+			return fmt.Errorf("%w: timeout: %v", ErrInvalidConfig, err)
+		}
+		o.Timeout = d
+	}
+	if c.UserAgent != "" {
+		o.UserAgent = c.UserAgent
+	}
+	if c.MaxRetries != nil {
+		if *c.MaxRetries < 1 {
+			return fmt.Errorf("%w: max_retries必须大于0", ErrInvalidConfig)
+		}
+		o.MaxRetries = *c.MaxRetries
+	}
+	if c.RetryDelay != "" {
+		d, err := parseSeconds(c.RetryDelay)
+		if err != nil {
+			return fmt.Errorf("%w: retry_delay: %v", ErrInvalidConfig, err)
+		}
+		o.RetryDelay = d
+	}
+	if c.RespectETag != nil {
+		o.RespectETag = *c.RespectETag
+	}
+	return c.applyCredentials(o)
+}
+
+// applyCredentials 把配置中的认证信息放在已有的认证信息之前，依次为credentials、netrc和keyring文件
+func (c *Config) applyCredentials(o *Options) error {
+	var rules auth.Rules
+	for i, cred := range c.Credentials {
+		if strings.TrimSpace(cred.URL) == "" {
+			return fmt.Errorf("%w: credentials[%d]缺少url", ErrInvalidConfig, i)
+		}
+		rules = append(rules, auth.Rule{
+			Pattern:    cred.URL,
+			Credential: auth.Credential{Username: cred.Username, Password: cred.Password, Token: cred.Token},
+		})
+	}
+	if c.Netrc != "" {
+		netrc, err := auth.LoadNetrc(c.Netrc)
+		if err != nil {
+			return fmt.Errorf("%w: netrc: %v", ErrInvalidConfig, err)
+		}
+		rules = append(rules, netrc...)
+	}
+	if c.KeyringFile != "" {
+		keyring, err := auth.LoadKeyringFile(c.KeyringFile)
+		if err != nil {
+			return fmt.Errorf("%w: keyring_file: %v", ErrInvalidConfig, err)
+		}
+		rules = append(rules, keyring...)
+	}
+	if len(rules) > 0 {
+		o.Credentials = auth.Chain(rules, o.Credentials)
+	}
+	return nil
+}
+
+// parseSeconds 解析Go的时长格式或秒数（可以是小数，与pip的timeout相同）
+func parseSeconds(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		seconds, parseErr := strconv.ParseFloat(s, 64)
+		if parseErr != nil {
+			return 0, fmt.Errorf("%q 不是有效的时长", s)
+		}
+		d = time.Duration(seconds * float64(time.Second))
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("%q 不能为负数", s)
+	}
+	return d, nil
+}
+
+// Loader 从pip配置、环境变量和配置文件加载客户端选项
+//
+// 优先级从低到高:
+//  1. NewOptions的默认值
+//  2. 标准代理环境变量 HTTPS_PROXY / HTTP_PROXY，按每个请求的主机判断NO_PROXY
+//  3. pip配置文件，依次为全局、用户、虚拟环境和PIP_CONFIG_FILE指定的文件，读取[global]和[install]节
+//  4. pip环境变量 PIP_INDEX_URL、PIP_EXTRA_INDEX_URL、PIP_TRUSTED_HOST、PIP_TIMEOUT、PIP_PROXY
+//  5. 本库的配置文件，由WithFile或PYPI_CRAWLER_CONFIG指定
+//  6. 本库的环境变量，如 PYPI_CRAWLER_INDEX_URL、PYPI_CRAWLER_TIMEOUT
+//  7. 调用方在Load之后通过With*方法设置的值
+//
+// 列表类的设置（额外索引、受信任主机）整体覆盖，不会合并。
+// 认证信息则按优先级从高到低依次查询：索引地址中内嵌的认证信息、本库环境变量和配置文件中的credentials、
+// netrc和keyring文件，最后是默认位置的netrc（NETRC或~/.netrc）和keyring文件（见auth.KeyringFilePath）
+type Loader struct {
+	getenv          func(string) string
+	file            string
+	pip             bool
+	pipFiles        []string
+	credentialFiles bool
+	warn            func(error)
+}
+
+// NewLoader 创建读取进程环境变量和pip默认配置文件的加载器
+//
+// 使用示例:
+//
+//	options, err := client.NewLoader().WithFile("pypi-crawler.yaml").Load()
+//	if err != nil {
+//		return err
+//	}
+//	c := client.NewClient(options.WithUserAgent("MyTool/1.0"))
+func NewLoader() *Loader {
+	return &Loader{getenv: os.Getenv, pip: true, credentialFiles: true}
+}
+
+// WithEnv 设置读取环境变量的函数，便于测试或隔离进程环境
+func (l *Loader) WithEnv(getenv func(string) string) *Loader {
+	l.getenv = getenv
+	return l
+}
+
+// WithFile 设置本库的配置文件，优先于PYPI_CRAWLER_CONFIG；文件不存在时Load返回错误
+func (l *Loader) WithFile(path string) *Loader {
+	l.file = path
+	return l
+}
+
+// WithPip 设置是否读取pip的配置文件和环境变量，默认读取
+func (l *Loader) WithPip(enabled bool) *Loader {
+	l.pip = enabled
+	return l
+}
+
+// WithPipConfigFiles 指定要读取的pip配置文件（按优先级从低到高），代替PipConfigFiles的默认位置
+func (l *Loader) WithPipConfigFiles(paths ...string) *Loader {
+	l.pipFiles = paths
+	return l
+}
+
+// WithCredentialFiles 设置是否读取默认位置的netrc和keyring文件，默认读取
+func (l *Loader) WithCredentialFiles(enabled bool) *Loader {
+	l.credentialFiles = enabled
+	return l
+}
+
+// WithWarnings 设置接收警告的函数，默认忽略警告
+// 默认位置的netrc或keyring文件格式无效时不会导致Load失败，而是跳过该文件并通过这个函数报告，
+// 与pip使用的requests库忽略无法解析的netrc相同；配置中显式指定的文件格式无效时仍然返回错误
+func (l *Loader) WithWarnings(warn func(error)) *Loader {
+	l.warn = warn
+	return l
+}
+
+// Load 按优先级加载选项
+//
+// 返回值:
+//   - *Options: 加载后的选项
+//   - error: 配置文件无法读取或值无效时返回
+func (l *Loader) Load() (*Options, error) {
+	o := NewOptions()
+	if l.credentialFiles {
+		if err := l.loadCredentialFiles(o); err != nil {
+			return nil, err
+		}
+	}
+
+	if l.pip {
+		files := l.pipFiles
+		if files == nil {
+			files = PipConfigFiles(l.getenv)
+		}
+		for _, path := range files {
+			pc, err := LoadPipConfig(path)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if err := pc.Apply(o); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		if err := pipConfigFromEnv(l.getenv).Apply(o); err != nil {
+			return nil, fmt.Errorf("pip环境变量: %w", err)
+		}
+	}
+
+	file := l.file
+	if file == "" {
+		file = l.getenv(EnvConfigFile)
+	}
+	if file != "" {
+		c, err := LoadConfigFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.Apply(o); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+
+	c, err := configFromEnv(l.getenv)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Apply(o); err != nil {
+		return nil, fmt.Errorf("环境变量: %w", err)
+	}
+
+	// 标准代理环境变量的优先级最低，只在Proxy为空时由NewHTTPClient按每个请求的主机使用
+	o.proxyEnv = proxyEnvFrom(l.getenv)
+	return o, nil
+}
+
+// loadCredentialFiles 读取默认位置的netrc和keyring文件，文件不存在时跳过，格式无效时跳过并报告警告
+func (l *Loader) loadCredentialFiles(o *Options) error {
+	var rules auth.Rules
+	load := func(path string, read func(string) (auth.Rules, error)) error {
+		if path == "" {
+			return nil
+		}
+		loaded, err := read(path)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			return nil
+		case errors.Is(err, auth.ErrInvalidFile):
+			if l.warn != nil {
+				l.warn(fmt.Errorf("跳过认证文件: %w", err))
+			}
+			return nil
+		case err != nil:
+			return err
+		}
+		rules = append(rules, loaded...)
+		return nil
+	}
+	if err := load(auth.NetrcPath(l.getenv), auth.LoadNetrc); err != nil {
+		return err
+	}
+	if err := load(auth.KeyringFilePath(l.getenv), auth.LoadKeyringFile); err != nil {
+		return err
+	}
+	if len(rules) > 0 {
+		o.Credentials = rules
+	}
+	return nil
+}
+
+// LoadOptions 使用默认的加载器加载选项，file为空时只读取PYPI_CRAWLER_CONFIG指定的文件
+//
+// 参数:
+//   - file: 本库的配置文件，可以为空
+//
+// 返回值:
+//   - *Options: 加载后的选项
+//   - error: 配置无效时返回
+func LoadOptions(file string) (*Options, error) {
+	return NewLoader().WithFile(file).Load()
+}
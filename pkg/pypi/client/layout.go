@@ -0,0 +1,161 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+)
+
+// ErrUnsupported 表示索引不支持该操作，如向只提供Simple API的镜像请求JSON API
+// 具体的错误类型为*UnsupportedError
+var ErrUnsupported = errors.New("索引不支持该操作")
+
+// PyPIFilesURL PyPI官方源发布文件的下载地址前缀
+const PyPIFilesURL = "https://files.pythonhosted.org/packages/"
+
+// 默认的API路径
+const (
+	// DefaultJSONPath JSON API相对于BaseURL的默认路径
+	DefaultJSONPath = "/pypi/"
+
+	// DefaultSimplePath Simple API相对于BaseURL的默认路径
+	DefaultSimplePath = "/simple/"
+)
+
+// UnsupportedError 索引的URL布局不支持请求的操作
+type UnsupportedError struct {
+	// Operation 操作名，如 "GetPackageInfo"
+	Operation string
+
+	// BaseURL 索引的基础URL
+	BaseURL string
+
+	// Reason 不支持的原因
+	Reason string
+}
+
+// Error 实现error接口
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s: %s 不支持 %s: %s", ErrUnsupported.Error(), e.BaseURL, e.Operation, e.Reason)
+}
+
+// Is 使 errors.Is(err, ErrUnsupported) 成立
+func (e *UnsupportedError) Is(target error) bool {
+	return target == ErrUnsupported
+}
+
+// Layout 描述索引的URL布局，零值表示与PyPI官方源相同的布局
+//
+// 使用示例:
+//
+//	// 阿里云镜像只提供Simple API，文件从镜像自己的主机下载
+//	options := client.NewOptions().
+//		WithBaseURL("https://mirrors.aliyun.com/pypi").
+//		WithLayout(client.Layout{NoJSONAPI: true, FilesURL: "https://mirrors.aliyun.com/pypi/packages/"})
+type Layout struct {
+	// JSONPath JSON API相对于BaseURL的路径，为空时为DefaultJSONPath
+	JSONPath string `json:"json_path,omitempty"`
+
+	// SimplePath Simple API相对于BaseURL的路径，为空时为DefaultSimplePath
+	SimplePath string `json:"simple_path,omitempty"`
+
+	// NoJSONAPI 索引不提供JSON API，依赖JSON API的操作返回*UnsupportedError
+	NoJSONAPI bool `json:"no_json_api,omitempty"`
+
+	// FilesURL 发布文件URL的改写目标，如 "https://mirrors.aliyun.com/pypi/packages/"；
+	// 设置后，响应中以FilesUpstream开头的文件URL会被改写为以FilesURL开头，使下载也经过镜像
+	FilesURL string `json:"files_url,omitempty"`
+
+	// FilesUpstream 需要改写的文件URL前缀，为空时为PyPIFilesURL
+	FilesUpstream string `json:"files_upstream,omitempty"`
+}
+
+// JSONURL 返回JSON API的地址，segments为包名和可选的版本号，如 JSONURL(base, "requests", "2.31.0")
+func (l Layout) JSONURL(baseURL string, segments ...string) string {
+	escaped := make([]string, 0, len(segments))
+	for _, s := range segments {
+		escaped = append(escaped, url.PathEscape(s))
+	}
+	return joinPath(baseURL, l.JSONPath, DefaultJSONPath) + strings.Join(escaped, "/") + "/json"
+}
+
+// SimpleURL 返回Simple API的根地址，以 "/" 结尾
+func (l Layout) SimpleURL(baseURL string) string {
+	return joinPath(baseURL, l.SimplePath, DefaultSimplePath)
+}
+
+// SimpleProjectURL 返回项目的Simple页面地址，项目名会被规范化
+func (l Layout) SimpleProjectURL(baseURL, project string) string {
+	return l.SimpleURL(baseURL) + url.PathEscape(models.NormalizeName(project)) + "/"
+}
+
+// RewriteFileURL 按FilesURL改写发布文件的URL，未设置FilesURL或URL不以FilesUpstream开头时原样返回
+func (l Layout) RewriteFileURL(fileURL string) string {
+	if l.FilesURL == "" {
+		return fileURL
+	}
+	upstream := l.FilesUpstream
+	if upstream == "" {
+		upstream = PyPIFilesURL
+	}
+	if !strings.HasSuffix(upstream, "/") {
+		upstream += "/"
+	}
+	rest, ok := cutPrefix(fileURL, upstream)
+	if !ok {
+		return fileURL
+	}
+	target := l.FilesURL
+	if !strings.HasSuffix(target, "/") {
+		target += "/"
+	}
+	return target + rest
+}
+
+// rewritePackage 改写包信息中所有发布文件的URL
+func (l Layout) rewritePackage(pkg *models.Package) {
+	if l.FilesURL == "" {
+		return
+	}
+	for _, files := range pkg.Releases {
+		for _, f := range files {
+			f.URL = l.RewriteFileURL(f.URL)
+		}
+	}
+	for _, f := range pkg.Urls {
+		f.URL = l.RewriteFileURL(f.URL)
+	}
+}
+
+// requireJSONAPI 索引不提供JSON API时返回*UnsupportedError
+func (l Layout) requireJSONAPI(operation, baseURL string) error {
+	if l.NoJSONAPI {
+		return &UnsupportedError{Operation: operation, BaseURL: baseURL, Reason: "索引只提供Simple API"}
+	}
+	return nil
+}
+
+// joinPath 拼接基础URL和以 "/" 结尾的路径
+func joinPath(baseURL, p, fallback string) string {
+	if p == "" {
+		p = fallback
+	}
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	if !strings.HasSuffix(p, "/") {
+		p += "/"
+	}
+	return strings.TrimSuffix(baseURL, "/") + p
+}
+
+// cutPrefix 与Go 1.20的strings.CutPrefix相同
+func cutPrefix(s, prefix string) (string, bool) {
+	if !strings.HasPrefix(s, prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayoutURLs(t *testing.T) {
+	t.Run("零值与官方源相同", func(t *testing.T) {
+		var l Layout
+		assert.Equal(t, "https://pypi.org/pypi/requests/json", l.JSONURL("https://pypi.org", "requests"))
+		assert.Equal(t, "https://pypi.org/pypi/requests/2.31.0/json", l.JSONURL("https://pypi.org/", "requests", "2.31.0"))
+		assert.Equal(t, "https://pypi.org/simple/", l.SimpleURL("https://pypi.org"))
+		assert.Equal(t, "https://pypi.org/simple/zope-interface/", l.SimpleProjectURL("https://pypi.org", "zope.interface"))
+	})
+
+	t.Run("自定义路径", func(t *testing.T) {
+		l := Layout{JSONPath: "api/json", SimplePath: "/pypi/simple"}
+		assert.Equal(t, "https://example.com/api/json/demo/json", l.JSONURL("https://example.com", "demo"))
+		assert.Equal(t, "https://example.com/pypi/simple/", l.SimpleURL("https://example.com"))
+	})
+}
+
+func TestLayoutRewriteFileURL(t *testing.T) {
+	const file = "https://files.pythonhosted.org/packages/ab/cd/demo-1.0.tar.gz"
+
+	assert.Equal(t, file, Layout{}.RewriteFileURL(file))
+
+	l := Layout{FilesURL: "https://mirrors.aliyun.com/pypi/packages"}
+	assert.Equal(t, "https://mirrors.aliyun.com/pypi/packages/ab/cd/demo-1.0.tar.gz", l.RewriteFileURL(file))
+	assert.Equal(t, "https://other.example.com/demo.whl", l.RewriteFileURL("https://other.example.com/demo.whl"))
+
+	l = Layout{FilesURL: "https://mirror.example.com/files/", FilesUpstream: "https://upstream.example.com/f"}
+	assert.Equal(t, "https://mirror.example.com/files/demo.whl", l.RewriteFileURL("https://upstream.example.com/f/demo.whl"))
+}
+
+func TestClientLayout(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/demo/json":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"info": {"name": "demo", "version": "1.0"}, "releases": {"1.0": [
+				{"filename": "demo-1.0.tar.gz", "url": "https://files.pythonhosted.org/packages/ab/demo-1.0.tar.gz"}]},
+				"urls": [{"filename": "demo-1.0.tar.gz", "url": "https://files.pythonhosted.org/packages/ab/demo-1.0.tar.gz"}]}`)
+		case "/pypi/simple/":
+			fmt.Fprint(w, `<html><body><a href="/pypi/simple/demo/">demo</a></body></html>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("按布局构造URL并改写文件地址", func(t *testing.T) {
+		c := NewClient(NewOptions().WithBaseURL(server.URL).WithMaxRetries(1).WithTimeout(5 * time.Second).
+			WithLayout(Layout{JSONPath: "/api/", SimplePath: "/pypi/simple/", FilesURL: server.URL + "/packages/"}))
+
+		pkg, err := c.GetPackageInfo(ctx, "demo")
+		require.NoError(t, err)
+		assert.Equal(t, server.URL+"/packages/ab/demo-1.0.tar.gz", pkg.Releases["1.0"][0].URL)
+		assert.Equal(t, server.URL+"/packages/ab/demo-1.0.tar.gz", pkg.Urls[0].URL)
+
+		names, err := c.GetAllPackages(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"demo"}, names)
+	})
+
+	t.Run("不提供JSON API时返回ErrUnsupported", func(t *testing.T) {
+		c := NewClient(NewOptions().WithBaseURL(server.URL).WithLayout(Layout{NoJSONAPI: true}))
+
+		_, err := c.GetPackageInfo(ctx, "demo")
+		assert.ErrorIs(t, err, ErrUnsupported)
+		assert.False(t, errors.Is(err, ErrNotFound))
+
+		var unsupported *UnsupportedError
+		require.ErrorAs(t, err, &unsupported)
+		assert.Equal(t, "GetPackageInfo", unsupported.Operation)
+		assert.Equal(t, server.URL, unsupported.BaseURL)
+
+		_, err = c.GetPackageVersion(ctx, "demo", "1.0")
+		assert.ErrorIs(t, err, ErrUnsupported)
+		_, err = c.GetPackageReleases(ctx, "demo")
+		assert.ErrorIs(t, err, ErrUnsupported)
+		_, err = c.CheckPackageVulnerabilities(ctx, "demo", "1.0")
+		assert.ErrorIs(t, err, ErrUnsupported)
+	})
+
+	t.Run("核心元数据使用Simple路径", func(t *testing.T) {
+		c := NewClient(NewOptions().WithBaseURL(server.URL).WithMaxRetries(1).
+			WithLayout(Layout{NoJSONAPI: true, SimplePath: "/pypi/simple/"})).(*Client)
+		_, err := c.GetCoreMetadata(ctx, &models.ReleaseFile{Filename: "demo-1.0.tar.gz"})
+		assert.ErrorIs(t, err, ErrCoreMetadataUnavailable)
+	})
+}
@@ -0,0 +1,362 @@
+package client
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/metadata"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+)
+
+// ErrCoreMetadataUnavailable 表示无法在不下载完整文件的情况下获取核心元数据
+// 例如索引未提供 .metadata 文件且发布文件是源码包，或服务器不支持Range请求
+var ErrCoreMetadataUnavailable = errors.New("无法获取核心元数据")
+
+// simpleJSONAccept PEP 691定义的JSON格式Simple API的媒体类型，HTML作为后备
+const simpleJSONAccept = "application/vnd.pypi.simple.v1+json, application/vnd.pypi.simple.v1+html;q=0.2, text/html;q=0.1"
+
+// rangeTailSize 首次Range请求读取的文件尾部字节数
+// 足以覆盖绝大多数wheel的zip中央目录
+const rangeTailSize = 64 * 1024
+
+// GetCoreMetadata 获取发布文件的核心元数据（METADATA）而无需下载整个文件
+//
+// 首先查询项目的Simple API页面，若索引声明提供PEP 658/714的 .metadata 文件，
+// 则下载该文件并校验哈希；否则对wheel使用HTTP Range请求读取zip中央目录，
+// 只下载其中的METADATA成员。
+//
+// 参数:
+//   - ctx: 上下文，用于控制请求的生命周期
+//   - file: 发布文件信息，需要包含Filename和URL
+//
+// 返回值:
+//   - *models.CoreMetadata: 解析后的核心元数据
+//   - error: 如有错误则返回，否则为nil
+func (c *Client) GetCoreMetadata(ctx context.Context, file *models.ReleaseFile) (*models.CoreMetadata, error) {
+	if file == nil || file.Filename == "" {
+		return nil, fmt.Errorf("%w: 发布文件信息为空", ErrCoreMetadataUnavailable)
+	}
+
+	fileURL := c.options.Layout.RewriteFileURL(file.URL)
+	if project := projectNameFromFilename(file.Filename); project != "" {
+		simpleProject, pageURL, err := c.getSimpleProject(ctx, project)
+		if err == nil {
+			for _, f := range simpleProject.Files {
+				if f.Filename != file.Filename {
+					continue
+				}
+				resolved := c.options.Layout.RewriteFileURL(resolveURL(pageURL, f.URL))
+				if fileURL == "" {
+					fileURL = resolved
+				}
+				if f.HasCoreMetadata() {
+					return c.fetchMetadataFile(ctx, resolved+".metadata", f.CoreMetadataHashes())
+				}
+				break
+			}
+		}
+	}
+
+	if fileURL == "" {
+		return nil, fmt.Errorf("%w: %s 没有下载地址", ErrCoreMetadataUnavailable, file.Filename)
+	}
+	if !strings.HasSuffix(strings.ToLower(file.Filename), ".whl") {
+		return nil, fmt.Errorf("%w: %s 不是wheel且索引未提供 .metadata 文件", ErrCoreMetadataUnavailable, file.Filename)
+	}
+	return c.fetchMetadataByRange(ctx, fileURL, file.Filename, file.Size)
+}
+
+// getSimpleProject 获取并解析项目的Simple API页面
+// 优先请求PEP 691的JSON格式，服务器只支持HTML时解析PEP 503页面
+func (c *Client) getSimpleProject(ctx context.Context, project string) (*models.SimpleProject, string, error) {
+	pageURL := c.options.Layout.SimpleProjectURL(c.options.BaseURL, project)
+
+	body, err := c.sendRequestWithAccept(ctx, pageURL, simpleJSONAccept)
+	if err != nil {
+		return nil, "", fmt.Errorf("获取项目 %s 的Simple页面失败: %w", project, err)
+	}
+
+	simpleProject, err := ParseSimpleProject(body, project)
+	if err != nil {
+		return nil, "", err
+	}
+	return simpleProject, pageURL, nil
+}
+
+// ParseSimpleProject 解析Simple API的项目页面，自动识别PEP 691的JSON格式和PEP 503的HTML格式
+//
+// 参数:
+//   - body: 页面内容
+//   - project: 项目名，HTML页面中没有项目名，用于填充SimpleProject.Name
+//
+// 返回值:
+//   - *models.SimpleProject: 解析后的项目页面，文件URL保持原样，可能是相对地址
+//   - error: 解析失败时返回
+func ParseSimpleProject(body []byte, project string) (*models.SimpleProject, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		var simpleProject models.SimpleProject
+		if err := json.Unmarshal(trimmed, &simpleProject); err != nil {
+			return nil, fmt.Errorf("解析项目 %s 的Simple页面失败: %w", project, err)
+		}
+		return &simpleProject, nil
+	}
+	return parseSimpleProjectHTML(string(body), project)
+}
+
+// parseSimpleProjectHTML 解析PEP 503格式的项目页面
+// 文件的哈希值位于URL片段中，其余属性位于data-*属性中
+func parseSimpleProjectHTML(pageHTML string, project string) (*models.SimpleProject, error) {
+	document, err := goquery.NewDocumentFromReader(strings.NewReader(pageHTML))
+	if err != nil {
+		return nil, fmt.Errorf("解析项目 %s 的Simple页面失败: %w", project, err)
+	}
+
+	simpleProject := &models.SimpleProject{
+		Meta: models.SimpleMeta{APIVersion: "1.0"},
+		Name: models.NormalizeName(project),
+	}
+	document.Find("a").Each(func(i int, selection *goquery.Selection) {
+		href, ok := selection.Attr("href")
+		if !ok {
+			return
+		}
+
+		file := models.SimpleFile{
+			Filename: strings.TrimSpace(selection.Text()),
+			URL:      href,
+			Hashes:   map[string]string{},
+		}
+		if hashIndex := strings.Index(href, "#"); hashIndex >= 0 {
+			file.URL = href[:hashIndex]
+			if algorithm, digest, ok := strings.Cut(href[hashIndex+1:], "="); ok {
+				file.Hashes[algorithm] = digest
+			}
+		}
+		if file.Filename == "" {
+			file.Filename = path.Base(file.URL)
+		}
+		if requiresPython, ok := selection.Attr("data-requires-python"); ok {
+			file.RequiresPython = requiresPython
+		}
+		if reason, ok := selection.Attr("data-yanked"); ok {
+			file.Yanked = models.YankedStatus{Yanked: true, Reason: reason}
+		}
+		if value, ok := selection.Attr("data-core-metadata"); ok {
+			file.CoreMetadata = parseMetadataAttr(value)
+		} else if value, ok := selection.Attr("data-dist-info-metadata"); ok {
+			file.DistInfoMetadata = parseMetadataAttr(value)
+		}
+		simpleProject.Files = append(simpleProject.Files, file)
+	})
+	return simpleProject, nil
+}
+
+// parseMetadataAttr 解析data-core-metadata属性，值为 "true" 或 "<算法>=<哈希>"
+func parseMetadataAttr(value string) *models.MetadataHashes {
+	if algorithm, digest, ok := strings.Cut(value, "="); ok {
+		return &models.MetadataHashes{Available: true, Hashes: map[string]string{algorithm: digest}}
+	}
+	return &models.MetadataHashes{Available: value != "false"}
+}
+
+// fetchMetadataFile 下载PEP 658的 .metadata 文件，并使用索引给出的最强的可校验哈希校验
+// 索引给出了哈希但没有可以校验的算法时返回错误，不信任未经校验的内容
+func (c *Client) fetchMetadataFile(ctx context.Context, metadataURL string, hashes map[string]string) (*models.CoreMetadata, error) {
+	var verifier *models.DigestVerifier
+	if len(hashes) > 0 {
+		var err error
+		if verifier, err = models.NewDigestVerifier(hashes); err != nil {
+			return nil, fmt.Errorf("元数据文件 %s: %w", metadataURL, err)
+		}
+	}
+
+	body, err := c.sendRequestWithAccept(ctx, metadataURL, "*/*")
+	if err != nil {
+		return nil, fmt.Errorf("下载元数据文件 %s 失败: %w", metadataURL, err)
+	}
+
+	if verifier != nil {
+		verifier.Write(body)
+		if err := verifier.Verify(); err != nil {
+			return nil, fmt.Errorf("元数据文件 %s 的%w", metadataURL, err)
+		}
+	}
+
+	m, err := metadata.Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("解析元数据文件 %s 失败: %w", metadataURL, err)
+	}
+	return m, nil
+}
+
+// fetchMetadataByRange 通过HTTP Range请求读取wheel中的METADATA成员
+func (c *Client) fetchMetadataByRange(ctx context.Context, fileURL string, filename string, size int64) (*models.CoreMetadata, error) {
+	reader, err := newRangeReaderAt(ctx, c, fileURL, size)
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := zip.NewReader(reader, reader.size)
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 的zip目录失败: %w", filename, err)
+	}
+
+	for _, f := range zr.File {
+		dir, base := path.Split(f.Name)
+		if base != "METADATA" || strings.Count(dir, "/") != 1 || !strings.HasSuffix(dir, ".dist-info/") {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("打开 %s 中的 %s 失败: %w", filename, f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取 %s 中的 %s 失败: %w", filename, f.Name, err)
+		}
+		return metadata.Parse(data)
+	}
+	return nil, fmt.Errorf("%w: %s 中没有 .dist-info/METADATA", ErrCoreMetadataUnavailable, filename)
+}
+
+// rangeReaderAt 通过HTTP Range请求实现io.ReaderAt
+// 创建时读取文件尾部并缓存，zip中央目录通常完全位于其中
+type rangeReaderAt struct {
+	ctx        context.Context
+	client     *Client
+	url        string
+	size       int64
+	tail       []byte
+	tailOffset int64
+}
+
+// newRangeReaderAt 创建rangeReaderAt，并通过后缀Range请求获取文件尾部和文件总大小
+func newRangeReaderAt(ctx context.Context, c *Client, fileURL string, size int64) (*rangeReaderAt, error) {
+	r := &rangeReaderAt{ctx: ctx, client: c, url: fileURL, size: size}
+
+	body, total, err := r.fetch(fmt.Sprintf("bytes=-%d", rangeTailSize))
+	if err != nil {
+		return nil, err
+	}
+	if total > 0 {
+		r.size = total
+	}
+	if r.size <= 0 {
+		return nil, fmt.Errorf("%w: 无法确定 %s 的文件大小", ErrCoreMetadataUnavailable, fileURL)
+	}
+	r.tail = body
+	r.tailOffset = r.size - int64(len(body))
+	return r, nil
+}
+
+// ReadAt 实现io.ReaderAt接口，尾部缓存之外的数据通过Range请求读取
+func (r *rangeReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	end := off + int64(len(p))
+	if end > r.size {
+		end = r.size
+	}
+
+	if off >= r.tailOffset {
+		n := copy(p, r.tail[off-r.tailOffset:end-r.tailOffset])
+		if n < len(p) {
+			return n, io.EOF
+		}
+		return n, nil
+	}
+
+	body, _, err := r.fetch(fmt.Sprintf("bytes=%d-%d", off, end-1))
+	if err != nil {
+		return 0, err
+	}
+	n := copy(p, body)
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// fetch 发送Range请求，返回响应体和Content-Range中的文件总大小
+// 服务器忽略Range头部返回完整文件时视为不支持
+func (r *rangeReaderAt) fetch(rangeHeader string) ([]byte, int64, error) {
+	req, err := http.NewRequestWithContext(r.ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("User-Agent", r.client.options.UserAgent)
+	req.Header.Set("Range", rangeHeader)
+
+	resp, err := r.client.client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Range请求 %s 失败: %w", r.url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return nil, 0, fmt.Errorf("%w: %s 不支持Range请求 (HTTP %d)", ErrCoreMetadataUnavailable, r.url, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("读取响应体失败: %w", err)
+	}
+
+	// Content-Range格式: bytes 100-199/12345
+	var total int64
+	if contentRange := resp.Header.Get("Content-Range"); contentRange != "" {
+		if slash := strings.LastIndex(contentRange, "/"); slash >= 0 {
+			total, _ = strconv.ParseInt(contentRange[slash+1:], 10, 64)
+		}
+	}
+	return body, total, nil
+}
+
+// projectNameFromFilename 从发布文件名中提取项目名
+// wheel文件名以 "-" 分隔，项目名为第一段；源码包文件名的最后一段为版本号
+func projectNameFromFilename(filename string) string {
+	lower := strings.ToLower(filename)
+	if strings.HasSuffix(lower, ".whl") {
+		name, _, _ := strings.Cut(filename, "-")
+		return name
+	}
+
+	for _, ext := range []string{".tar.gz", ".tar.bz2", ".tgz", ".zip", ".tar"} {
+		if strings.HasSuffix(lower, ext) {
+			base := filename[:len(filename)-len(ext)]
+			if dash := strings.LastIndex(base, "-"); dash > 0 {
+				return base[:dash]
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+// resolveURL 将相对于页面的URL解析为绝对地址
+func resolveURL(pageURL, ref string) string {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return ref
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(refURL).String()
+}
@@ -0,0 +1,247 @@
+package client
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCoreMetadata = "Metadata-Version: 2.1\nName: demo\nVersion: 1.0.0\nRequires-Dist: requests>=2.0\n"
+
+// buildTestWheel 构建一个带有大块随机数据的wheel，用于验证Range请求不会下载整个文件
+func buildTestWheel(t *testing.T) []byte {
+	padding := make([]byte, 512*1024)
+	_, err := rand.Read(padding)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range []struct {
+		name string
+		data []byte
+	}{
+		{"demo/data.bin", padding},
+		{"demo-1.0.0.dist-info/METADATA", []byte(testCoreMetadata)},
+		{"demo-1.0.0.dist-info/RECORD", []byte("")},
+	} {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Store})
+		require.NoError(t, err)
+		_, err = w.Write(f.data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+// setupMetadataServer 模拟提供Simple API和wheel文件的索引
+// html为true时Simple页面使用PEP 503的HTML格式
+func setupMetadataServer(t *testing.T, wheel []byte, withMetadataFile bool, html bool, servedBytes *int64) *httptest.Server {
+	metadataSum := sha256.Sum256([]byte(testCoreMetadata))
+	metadataHash := hex.EncodeToString(metadataSum[:])
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/simple/demo/", func(w http.ResponseWriter, r *http.Request) {
+		if html {
+			attr := ""
+			if withMetadataFile {
+				attr = fmt.Sprintf(` data-dist-info-metadata="sha256=%s"`, metadataHash)
+			}
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintf(w, `<html><body><a href="../../files/demo-1.0.0-py3-none-any.whl#sha256=abc"%s>demo-1.0.0-py3-none-any.whl</a></body></html>`, attr)
+			return
+		}
+
+		coreMetadata := "false"
+		if withMetadataFile {
+			coreMetadata = fmt.Sprintf(`{"sha256": "%s"}`, metadataHash)
+		}
+		w.Header().Set("Content-Type", "application/vnd.pypi.simple.v1+json")
+		fmt.Fprintf(w, `{"meta": {"api-version": "1.1"}, "name": "demo", "files": [
+			{"filename": "demo-1.0.0-py3-none-any.whl", "url": "/files/demo-1.0.0-py3-none-any.whl", "hashes": {}, "core-metadata": %s},
+			{"filename": "demo-1.0.0.tar.gz", "url": "/files/demo-1.0.0.tar.gz", "hashes": {}, "yanked": "broken"}
+		]}`, coreMetadata)
+	})
+	mux.HandleFunc("/files/demo-1.0.0-py3-none-any.whl.metadata", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testCoreMetadata))
+	})
+	mux.HandleFunc("/files/demo-1.0.0-py3-none-any.whl", func(w http.ResponseWriter, r *http.Request) {
+		recorder := &countingWriter{ResponseWriter: w, count: servedBytes}
+		http.ServeContent(recorder, r, "demo.whl", time.Time{}, bytes.NewReader(wheel))
+	})
+	return httptest.NewServer(mux)
+}
+
+// countingWriter 统计写入响应体的字节数
+type countingWriter struct {
+	http.ResponseWriter
+	count *int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	atomic.AddInt64(w.count, int64(len(p)))
+	return w.ResponseWriter.Write(p)
+}
+
+func TestGetCoreMetadata(t *testing.T) {
+	wheel := buildTestWheel(t)
+	ctx := context.Background()
+
+	t.Run("使用PEP 658元数据文件", func(t *testing.T) {
+		var served int64
+		server := setupMetadataServer(t, wheel, true, false, &served)
+		defer server.Close()
+
+		client := createTestClient(server)
+		m, err := client.GetCoreMetadata(ctx, &models.ReleaseFile{Filename: "demo-1.0.0-py3-none-any.whl"})
+		require.NoError(t, err)
+		assert.Equal(t, "demo", m.Name)
+		assert.Equal(t, []string{"requests>=2.0"}, m.RequiresDist)
+		assert.Zero(t, atomic.LoadInt64(&served))
+	})
+
+	t.Run("HTML页面中的data-dist-info-metadata", func(t *testing.T) {
+		var served int64
+		server := setupMetadataServer(t, wheel, true, true, &served)
+		defer server.Close()
+
+		client := createTestClient(server)
+		m, err := client.GetCoreMetadata(ctx, &models.ReleaseFile{Filename: "demo-1.0.0-py3-none-any.whl"})
+		require.NoError(t, err)
+		assert.Equal(t, "1.0.0", m.Version)
+		assert.Zero(t, atomic.LoadInt64(&served))
+	})
+
+	t.Run("回退到Range请求", func(t *testing.T) {
+		var served int64
+		server := setupMetadataServer(t, wheel, false, false, &served)
+		defer server.Close()
+
+		client := createTestClient(server)
+		m, err := client.GetCoreMetadata(ctx, &models.ReleaseFile{
+			Filename: "demo-1.0.0-py3-none-any.whl",
+			URL:      server.URL + "/files/demo-1.0.0-py3-none-any.whl",
+			Size:     int64(len(wheel)),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "demo", m.Name)
+		assert.Less(t, atomic.LoadInt64(&served), int64(len(wheel))/4)
+	})
+
+	t.Run("源码包没有元数据文件", func(t *testing.T) {
+		var served int64
+		server := setupMetadataServer(t, wheel, false, false, &served)
+		defer server.Close()
+
+		client := createTestClient(server)
+		_, err := client.GetCoreMetadata(ctx, &models.ReleaseFile{Filename: "demo-1.0.0.tar.gz"})
+		assert.ErrorIs(t, err, ErrCoreMetadataUnavailable)
+	})
+
+	t.Run("服务器不支持Range请求", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/files/demo-1.0.0-py3-none-any.whl" {
+				_, _ = w.Write(wheel)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		client := createTestClient(server)
+		_, err := client.GetCoreMetadata(ctx, &models.ReleaseFile{
+			Filename: "demo-1.0.0-py3-none-any.whl",
+			URL:      server.URL + "/files/demo-1.0.0-py3-none-any.whl",
+		})
+		assert.ErrorIs(t, err, ErrCoreMetadataUnavailable)
+	})
+}
+
+func TestGetCoreMetadataHashes(t *testing.T) {
+	ctx := context.Background()
+	sum := sha512.Sum512([]byte(testCoreMetadata))
+	sha512Hash := hex.EncodeToString(sum[:])
+
+	// serve 提供声明了给定core-metadata哈希的Simple页面和 .metadata 文件
+	serve := func(t *testing.T, hashes string) *Client {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/files/demo-1.0.0-py3-none-any.whl.metadata" {
+				_, _ = w.Write([]byte(testCoreMetadata))
+				return
+			}
+			w.Header().Set("Content-Type", "application/vnd.pypi.simple.v1+json")
+			fmt.Fprintf(w, `{"meta": {"api-version": "1.1"}, "name": "demo", "files": [
+				{"filename": "demo-1.0.0-py3-none-any.whl", "url": "/files/demo-1.0.0-py3-none-any.whl", "hashes": {}, "core-metadata": %s}
+			]}`, hashes)
+		}))
+		t.Cleanup(server.Close)
+		return createTestClient(server)
+	}
+
+	t.Run("校验sha512", func(t *testing.T) {
+		client := serve(t, fmt.Sprintf(`{"sha512": "%s", "md5": "0"}`, sha512Hash))
+		m, err := client.GetCoreMetadata(ctx, &models.ReleaseFile{Filename: "demo-1.0.0-py3-none-any.whl"})
+		require.NoError(t, err)
+		assert.Equal(t, "demo", m.Name)
+	})
+
+	t.Run("哈希不一致", func(t *testing.T) {
+		client := serve(t, `{"sha384": "abc"}`)
+		_, err := client.GetCoreMetadata(ctx, &models.ReleaseFile{Filename: "demo-1.0.0-py3-none-any.whl"})
+		assert.ErrorIs(t, err, models.ErrDigestMismatch)
+	})
+
+	t.Run("没有可校验的哈希", func(t *testing.T) {
+		client := serve(t, `{"blake2b_256": "abc"}`)
+		_, err := client.GetCoreMetadata(ctx, &models.ReleaseFile{Filename: "demo-1.0.0-py3-none-any.whl"})
+		assert.ErrorIs(t, err, models.ErrNoVerifiableDigest)
+	})
+}
+
+func TestProjectNameFromFilename(t *testing.T) {
+	assert.Equal(t, "demo_pkg", projectNameFromFilename("demo_pkg-1.0.0-py3-none-any.whl"))
+	assert.Equal(t, "demo-pkg", projectNameFromFilename("demo-pkg-1.0.0.tar.gz"))
+	assert.Equal(t, "demo", projectNameFromFilename("demo-1.0.zip"))
+	assert.Empty(t, projectNameFromFilename("demo.exe"))
+}
+
+func TestParseSimpleProject(t *testing.T) {
+	t.Run("PEP 691 JSON", func(t *testing.T) {
+		page, err := ParseSimpleProject([]byte(`{"meta": {"api-version": "1.1", "_last-serial": 7}, "name": "demo",
+			"files": [{"filename": "demo-1.0.tar.gz", "url": "demo-1.0.tar.gz", "hashes": {"sha256": "abc"}}]}`), "Demo")
+		require.NoError(t, err)
+		assert.Equal(t, 7, page.Meta.LastSerial)
+		require.Len(t, page.Files, 1)
+		assert.Equal(t, "abc", page.Files[0].Hashes["sha256"])
+	})
+
+	t.Run("PEP 503 HTML", func(t *testing.T) {
+		page, err := ParseSimpleProject([]byte(`<html><body>
+<a href="../../files/demo-1.0.tar.gz#sha256=abc" data-yanked="broken">demo-1.0.tar.gz</a>
+</body></html>`), "Demo")
+		require.NoError(t, err)
+		assert.Equal(t, "demo", page.Name)
+		require.Len(t, page.Files, 1)
+		assert.Equal(t, "../../files/demo-1.0.tar.gz", page.Files[0].URL)
+		assert.Equal(t, "abc", page.Files[0].Hashes["sha256"])
+		assert.Equal(t, "broken", page.Files[0].Yanked.Reason)
+	})
+
+	t.Run("无效的JSON", func(t *testing.T) {
+		_, err := ParseSimpleProject([]byte(`{"files": 1}`), "demo")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,304 @@
+package client
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// pipSections 读取的pip配置节，后面的覆盖前面的
+var pipSections = []string{"global", "install"}
+
+// PipConfig pip配置文件或PIP_*环境变量中与索引相关的设置，为空的字段不覆盖已有的值
+type PipConfig struct {
+	// IndexURL index-url
+	IndexURL string
+
+	// ExtraIndexURLs extra-index-url，可以有多个
+	ExtraIndexURLs []string
+
+	// TrustedHosts trusted-host，可以有多个
+	TrustedHosts []string
+
+	// Timeout timeout，单位为秒
+	Timeout string
+
+	// Proxy proxy
+	Proxy string
+}
+
+// ParsePipConfig 解析pip的INI格式配置文件，读取[global]和[install]节，[install]中的设置优先
+//
+// 参数:
+//   - r: 配置文件内容
+//
+// 返回值:
+//   - *PipConfig: 解析后的设置
+//   - error: 读取失败或出现不属于任何节的设置时返回
+//
+// 使用示例:
+//
+//	pc, err := client.ParsePipConfig(strings.NewReader("[global]\nindex-url = https://pypi.tuna.tsinghua.edu.cn/simple\n"))
+func ParsePipConfig(r io.Reader) (*PipConfig, error) {
+	sections := map[string]map[string]string{}
+	var section map[string]string
+	var key string
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		// 缩进的行是上一个设置的续行，用于extra-index-url等多值设置
+		if raw[0] == ' ' || raw[0] == '\t' {
+			if section == nil || key == "" {
+				return nil, fmt.Errorf("%w: 第%d行: 续行之前没有设置", ErrInvalidConfig, lineNo)
+			}
+			section[key] += "\n" + line
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
+			if sections[name] == nil {
+				sections[name] = map[string]string{}
+			}
+			section, key = sections[name], ""
+			continue
+		}
+		i := strings.IndexAny(line, "=:")
+		if i < 0 {
+			return nil, fmt.Errorf("%w: 第%d行: 无法解析 %q", ErrInvalidConfig, lineNo, line)
+		}
+		if section == nil {
+			return nil, fmt.Errorf("%w: 第%d行: 设置不属于任何节", ErrInvalidConfig, lineNo)
+		}
+		key = normalizePipKey(line[:i])
+		section[key] = strings.TrimSpace(line[i+1:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	for _, name := range pipSections {
+		for k, v := range sections[name] {
+			values[k] = v
+		}
+	}
+	return &PipConfig{
+		IndexURL:       values["index-url"],
+		ExtraIndexURLs: splitPipList(values["extra-index-url"]),
+		TrustedHosts:   splitPipList(values["trusted-host"]),
+		Timeout:        values["timeout"],
+		Proxy:          values["proxy"],
+	}, nil
+}
+
+// LoadPipConfig 读取pip配置文件，文件不存在时返回的错误匹配os.ErrNotExist
+func LoadPipConfig(path string) (*PipConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	pc, err := ParsePipConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return pc, nil
+}
+
+// pipConfigFromEnv 读取PIP_INDEX_URL等环境变量
+func pipConfigFromEnv(getenv func(string) string) *PipConfig {
+	return &PipConfig{
+		IndexURL:       getenv("PIP_INDEX_URL"),
+		ExtraIndexURLs: splitPipList(getenv("PIP_EXTRA_INDEX_URL")),
+		TrustedHosts:   splitPipList(getenv("PIP_TRUSTED_HOST")),
+		Timeout:        getenv("PIP_TIMEOUT"),
+		Proxy:          getenv("PIP_PROXY"),
+	}
+}
+
+// Apply 把pip的设置写入选项，index-url按WithIndexURL拆分
+func (pc *PipConfig) Apply(o *Options) error {
+	c := Config{
+		IndexURL:       pc.IndexURL,
+		ExtraIndexURLs: pc.ExtraIndexURLs,
+		TrustedHosts:   pc.TrustedHosts,
+		Timeout:        pc.Timeout,
+		Proxy:          pc.Proxy,
+	}
+	return c.Apply(o)
+}
+
+// PipConfigFiles 返回pip会读取的配置文件，按优先级从低到高排列，与pip的查找规则相同:
+// 全局配置、用户配置、虚拟环境中的配置，最后是PIP_CONFIG_FILE指定的文件
+//
+// PIP_CONFIG_FILE指定的文件存在时不读取用户配置；PIP_CONFIG_FILE为os.DevNull时不读取任何配置文件
+//
+// 参数:
+//   - getenv: 读取环境变量的函数，通常为os.Getenv
+//
+// 返回值:
+//   - []string: 配置文件路径，其中的文件不一定存在
+func PipConfigFiles(getenv func(string) string) []string {
+	envFile := getenv("PIP_CONFIG_FILE")
+	if envFile == os.DevNull {
+		return []string{}
+	}
+
+	var files []string
+	home := getenv("HOME")
+	switch runtime.GOOS {
+	case "windows":
+		if dir := getenv("PROGRAMDATA"); dir != "" {
+			files = append(files, filepath.Join(dir, "pip", "pip.ini"))
+		}
+	case "darwin":
+		files = append(files, "/Library/Application Support/pip/pip.conf")
+	default:
+		dirs := getenv("XDG_CONFIG_DIRS")
+		if dirs == "" {
+			dirs = "/etc/xdg"
+		}
+		for _, dir := range filepath.SplitList(dirs) {
+			files = append(files, filepath.Join(dir, "pip", "pip.conf"))
+		}
+	}
+	if runtime.GOOS != "windows" {
+		files = append(files, "/etc/pip.conf")
+	}
+
+	if _, err := os.Stat(envFile); envFile == "" || err != nil {
+		if runtime.GOOS == "windows" {
+			if home = getenv("USERPROFILE"); home != "" {
+				files = append(files, filepath.Join(home, "pip", "pip.ini"))
+			}
+			if dir := getenv("APPDATA"); dir != "" {
+				files = append(files, filepath.Join(dir, "pip", "pip.ini"))
+			}
+		} else {
+			if home != "" {
+				files = append(files, filepath.Join(home, ".pip", "pip.conf"))
+			}
+			configHome := getenv("XDG_CONFIG_HOME")
+			if runtime.GOOS == "darwin" && home != "" {
+				files = append(files, filepath.Join(home, "Library", "Application Support", "pip", "pip.conf"))
+			}
+			if configHome == "" && home != "" {
+				configHome = filepath.Join(home, ".config")
+			}
+			if configHome != "" {
+				files = append(files, filepath.Join(configHome, "pip", "pip.conf"))
+			}
+		}
+	}
+
+	if venv := getenv("VIRTUAL_ENV"); venv != "" {
+		name := "pip.conf"
+		if runtime.GOOS == "windows" {
+			name = "pip.ini"
+		}
+		files = append(files, filepath.Join(venv, name))
+	}
+	if envFile != "" {
+		files = append(files, envFile)
+	}
+	return files
+}
+
+// envProxy 标准代理环境变量HTTPS_PROXY、HTTP_PROXY和NO_PROXY的值
+// 不使用http.ProxyFromEnvironment，因为它只在第一次调用时读取进程的环境变量，也不受Loader.WithEnv影响
+type envProxy struct {
+	httpProxy  string
+	httpsProxy string
+	noProxy    string
+}
+
+// proxyEnvFrom 读取代理环境变量（大写优先，其次是小写），没有设置代理时返回nil
+func proxyEnvFrom(getenv func(string) string) *envProxy {
+	lookup := func(name string) string {
+		if v := getenv(name); v != "" {
+			return v
+		}
+		return getenv(strings.ToLower(name))
+	}
+	e := &envProxy{httpProxy: lookup("HTTP_PROXY"), httpsProxy: lookup("HTTPS_PROXY"), noProxy: lookup("NO_PROXY")}
+	if e.httpProxy == "" && e.httpsProxy == "" {
+		return nil
+	}
+	return e
+}
+
+// proxyFor 返回访问u使用的代理地址，NO_PROXY排除了u的主机时返回空字符串
+func (e *envProxy) proxyFor(u *url.URL) string {
+	proxy := e.httpProxy
+	if u.Scheme == "https" {
+		proxy = e.httpsProxy
+	}
+	if proxy == "" || noProxy(e.noProxy, u.Hostname()) {
+		return ""
+	}
+	return proxy
+}
+
+// proxy 用作http.Transport的Proxy，对每个请求分别判断，因此额外索引和发布文件所在的主机也遵循NO_PROXY
+func (e *envProxy) proxy(req *http.Request) (*url.URL, error) {
+	raw := e.proxyFor(req.URL)
+	if raw == "" {
+		return nil, nil
+	}
+	// 与http.ProxyFromEnvironment相同，没有协议的代理地址按http处理
+	if u, err := url.Parse(raw); err == nil && u.Scheme != "" && u.Host != "" {
+		return u, nil
+	}
+	u, err := url.Parse("http://" + raw)
+	if err != nil {
+		return nil, fmt.Errorf("无效的代理地址 %q: %w", raw, err)
+	}
+	return u, nil
+}
+
+// noProxy 判断主机是否在NO_PROXY的列表中，列表项匹配主机本身及其子域名，"*"匹配所有主机
+func noProxy(list, host string) bool {
+	host = strings.ToLower(host)
+	for _, item := range strings.Split(list, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if h, _, err := net.SplitHostPort(item); err == nil {
+			item = h
+		}
+		if item == "*" {
+			return true
+		}
+		item = strings.TrimPrefix(strings.Trim(item, "[]"), "*")
+		switch {
+		case item == "":
+			continue
+		case host == strings.TrimPrefix(item, "."), strings.HasSuffix(host, "."+strings.TrimPrefix(item, ".")):
+			return true
+		}
+	}
+	return false
+}
+
+// normalizePipKey 规范化pip的设置名，与pip相同地不区分大小写并把下划线视为连字符
+func normalizePipKey(key string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(key)), "_", "-")
+}
+
+// splitPipList 拆分以空白（包括换行）分隔的多值设置
+func splitPipList(value string) []string {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
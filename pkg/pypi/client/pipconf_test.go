@@ -0,0 +1,122 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePipConfig(t *testing.T) {
+	t.Run("global和install节", func(t *testing.T) {
+		pc, err := ParsePipConfig(strings.NewReader(`# pip配置
+[global]
+index-url = https://pypi.tuna.tsinghua.edu.cn/simple
+timeout: 30
+Trusted_Host = pypi.tuna.tsinghua.edu.cn
+
+[install]
+extra-index-url =
+    https://devpi.internal/root/pypi/+simple/
+    https://other.internal/simple
+trusted-host = devpi.internal:3141
+	other.internal
+
+[freeze]
+timeout = 1
+`))
+		require.NoError(t, err)
+		assert.Equal(t, &PipConfig{
+			IndexURL:       "https://pypi.tuna.tsinghua.edu.cn/simple",
+			ExtraIndexURLs: []string{"https://devpi.internal/root/pypi/+simple/", "https://other.internal/simple"},
+			TrustedHosts:   []string{"devpi.internal:3141", "other.internal"},
+			Timeout:        "30",
+		}, pc)
+	})
+
+	t.Run("格式错误", func(t *testing.T) {
+		_, err := ParsePipConfig(strings.NewReader("index-url = https://example.com/simple\n"))
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+
+		_, err = ParsePipConfig(strings.NewReader("[global]\nnot a setting\n"))
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+	})
+
+	t.Run("文件不存在", func(t *testing.T) {
+		_, err := LoadPipConfig(filepath.Join(t.TempDir(), "pip.conf"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestPipConfigFiles(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		t.Skip("只检查Linux上的位置")
+	}
+	envFile := filepath.Join(t.TempDir(), "pip.conf")
+	env := map[string]string{"HOME": "/home/dev", "VIRTUAL_ENV": "/work/.venv", "PIP_CONFIG_FILE": envFile}
+	getenv := func(key string) string { return env[key] }
+
+	t.Run("PIP_CONFIG_FILE不存在时读取用户配置", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"/etc/xdg/pip/pip.conf",
+			"/etc/pip.conf",
+			"/home/dev/.pip/pip.conf",
+			"/home/dev/.config/pip/pip.conf",
+			"/work/.venv/pip.conf",
+			envFile,
+		}, PipConfigFiles(getenv))
+	})
+
+	t.Run("PIP_CONFIG_FILE存在时跳过用户配置", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(envFile, nil, 0o644))
+		assert.Equal(t, []string{
+			"/etc/xdg/pip/pip.conf",
+			"/etc/pip.conf",
+			"/work/.venv/pip.conf",
+			envFile,
+		}, PipConfigFiles(getenv))
+	})
+
+	t.Run("PIP_CONFIG_FILE为空设备", func(t *testing.T) {
+		env["PIP_CONFIG_FILE"] = os.DevNull
+		assert.Empty(t, PipConfigFiles(getenv))
+	})
+}
+
+func TestEnvProxy(t *testing.T) {
+	env := map[string]string{
+		"HTTPS_PROXY": "http://proxy:8080",
+		"http_proxy":  "plain-proxy:8080",
+		"NO_PROXY":    "localhost, .internal,10.0.0.1:3141",
+	}
+	getenv := func(key string) string { return env[key] }
+	proxyOf := func(e *envProxy, rawURL string) string {
+		req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+		require.NoError(t, err)
+		u, err := e.proxy(req)
+		require.NoError(t, err)
+		if u == nil {
+			return ""
+		}
+		return u.String()
+	}
+
+	e := proxyEnvFrom(getenv)
+	require.NotNil(t, e)
+	assert.Equal(t, "http://proxy:8080", proxyOf(e, "https://pypi.org/simple/"))
+	assert.Equal(t, "http://plain-proxy:8080", proxyOf(e, "http://pypi.org/simple/"))
+	assert.Empty(t, proxyOf(e, "https://devpi.internal/root"))
+	assert.Empty(t, proxyOf(e, "https://internal"))
+	assert.Empty(t, proxyOf(e, "http://localhost:3141"))
+	assert.Empty(t, proxyOf(e, "http://10.0.0.1:3141"))
+
+	env["NO_PROXY"] = "*"
+	assert.Empty(t, proxyOf(proxyEnvFrom(getenv), "https://pypi.org"))
+
+	assert.Nil(t, proxyEnvFrom(func(string) string { return "" }))
+}
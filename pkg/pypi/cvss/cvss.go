@@ -0,0 +1,141 @@
+package cvss
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrInvalidVector 表示字符串不是有效的CVSS向量
+	ErrInvalidVector = errors.New("无效的CVSS向量")
+
+	// ErrUnsupportedVersion 表示向量的CVSS版本不受支持（目前支持3.0、3.1和4.0）
+	ErrUnsupportedVersion = errors.New("不支持的CVSS版本")
+)
+
+// Vector 解析后的CVSS向量
+type Vector interface {
+	// Version 返回CVSS版本，如 "3.1"、"4.0"
+	Version() string
+
+	// Metric 返回指标的取值，未出现的指标返回空字符串
+	Metric(name string) string
+
+	// Score 返回分数：3.x为基础分数，4.0为包含向量中全部威胁和环境指标的分数
+	Score() float64
+
+	// Rating 返回分数对应的定性评级
+	Rating() Rating
+
+	// String 返回向量字符串
+	String() string
+}
+
+// Parse 解析CVSS向量，版本由前缀决定
+//
+// 参数:
+//   - s: 向量字符串，如 "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"
+//
+// 返回值:
+//   - Vector: 解析后的向量，具体类型为*V3或*V4
+//   - error: 格式无效时返回ErrInvalidVector，版本不受支持时返回ErrUnsupportedVersion
+//
+// 使用示例:
+//
+//	v, err := cvss.Parse("CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H")
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(v.Score(), v.Rating()) // 9.8 CRITICAL
+func Parse(s string) (Vector, error) {
+	s = strings.TrimSpace(s)
+	prefix, _, _ := strings.Cut(s, "/")
+	switch prefix {
+	case "CVSS:3.0", "CVSS:3.1":
+		return ParseV3(s)
+	case "CVSS:4.0":
+		return ParseV4(s)
+	}
+	if strings.HasPrefix(prefix, "CVSS:") {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedVersion, strings.TrimPrefix(prefix, "CVSS:"))
+	}
+	// CVSS v2向量没有版本前缀
+	if strings.HasPrefix(s, "AV:") {
+		return nil, fmt.Errorf("%w: 2.0", ErrUnsupportedVersion)
+	}
+	return nil, fmt.Errorf("%w: 缺少版本前缀: %q", ErrInvalidVector, s)
+}
+
+// metricSpec 一个指标允许的取值，required表示基础指标必须出现
+type metricSpec struct {
+	values   []string
+	required bool
+}
+
+// metrics 按出现顺序记录的指标取值
+type metrics struct {
+	prefix string
+	order  []string
+	values map[string]string
+}
+
+// parseMetrics 解析 "前缀/指标:值/..." 形式的向量并按规格校验
+func parseMetrics(s, prefix string, specs map[string]metricSpec) (*metrics, error) {
+	rest := strings.TrimPrefix(s, prefix+"/")
+	if rest == s || rest == "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidVector, s)
+	}
+
+	m := &metrics{prefix: prefix, values: make(map[string]string)}
+	for _, part := range strings.Split(rest, "/") {
+		name, value, ok := strings.Cut(part, ":")
+		if !ok || name == "" || value == "" {
+			return nil, fmt.Errorf("%w: 无法解析 %q", ErrInvalidVector, part)
+		}
+		spec, known := specs[name]
+		if !known {
+			return nil, fmt.Errorf("%w: 未知的指标 %s", ErrInvalidVector, name)
+		}
+		if _, dup := m.values[name]; dup {
+			return nil, fmt.Errorf("%w: 重复的指标 %s", ErrInvalidVector, name)
+		}
+		if !contains(spec.values, value) {
+			return nil, fmt.Errorf("%w: 指标 %s 的取值 %s 无效", ErrInvalidVector, name, value)
+		}
+		m.order = append(m.order, name)
+		m.values[name] = value
+	}
+	for name, spec := range specs {
+		if _, ok := m.values[name]; spec.required && !ok {
+			return nil, fmt.Errorf("%w: 缺少基础指标 %s", ErrInvalidVector, name)
+		}
+	}
+	return m, nil
+}
+
+// get 返回指标的取值，未出现时返回 "X"（未定义）
+func (m *metrics) get(name string) string {
+	if v, ok := m.values[name]; ok {
+		return v
+	}
+	return "X"
+}
+
+func (m *metrics) String() string {
+	parts := make([]string, 0, len(m.order)+1)
+	parts = append(parts, m.prefix)
+	for _, name := range m.order {
+		parts = append(parts, name+":"+m.values[name])
+	}
+	return strings.Join(parts, "/")
+}
+
+func contains(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package cvss
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("按前缀选择版本", func(t *testing.T) {
+		v, err := Parse("CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H")
+		require.NoError(t, err)
+		assert.IsType(t, &V3{}, v)
+		assert.Equal(t, "3.1", v.Version())
+
+		v, err = Parse(" CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H ")
+		require.NoError(t, err)
+		assert.Equal(t, "3.0", v.Version())
+
+		v, err = Parse("CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N")
+		require.NoError(t, err)
+		assert.IsType(t, &V4{}, v)
+		assert.Equal(t, "4.0", v.Version())
+	})
+
+	t.Run("不支持的版本", func(t *testing.T) {
+		_, err := Parse("AV:N/AC:L/Au:N/C:P/I:P/A:P")
+		assert.ErrorIs(t, err, ErrUnsupportedVersion)
+
+		_, err = Parse("CVSS:2.0/AV:N")
+		assert.ErrorIs(t, err, ErrUnsupportedVersion)
+	})
+
+	t.Run("无效的向量", func(t *testing.T) {
+		for _, s := range []string{
+			"",
+			"9.8",
+			"CVSS:3.1",
+			"CVSS:3.1/",
+			"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H",          // 缺少A
+			"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/A:H",  // 重复
+			"CVSS:3.1/AV:X/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",      // 取值无效
+			"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/ZZ:1", // 未知指标
+			"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A",
+			"CVSS:4.0/AV:N/AC:L/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", // 缺少AT
+		} {
+			_, err := Parse(s)
+			assert.ErrorIs(t, err, ErrInvalidVector, s)
+		}
+	})
+}
+
+func TestMetrics(t *testing.T) {
+	v, err := Parse("CVSS:3.1/S:U/AV:N/AC:L/PR:N/UI:N/C:H/I:H/A:H/E:P")
+	require.NoError(t, err)
+
+	assert.Equal(t, "N", v.Metric("AV"))
+	assert.Equal(t, "P", v.Metric("E"))
+	assert.Equal(t, "", v.Metric("RL"))
+	// 保留原始顺序
+	assert.Equal(t, "CVSS:3.1/S:U/AV:N/AC:L/PR:N/UI:N/C:H/I:H/A:H/E:P", v.String())
+}
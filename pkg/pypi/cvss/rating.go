@@ -0,0 +1,89 @@
+package cvss
+
+import "strings"
+
+// Rating CVSS定性严重程度评级
+type Rating string
+
+const (
+	// RatingUnknown 无法确定严重程度
+	RatingUnknown Rating = ""
+
+	// RatingNone 0.0
+	RatingNone Rating = "NONE"
+
+	// RatingLow 0.1-3.9
+	RatingLow Rating = "LOW"
+
+	// RatingMedium 4.0-6.9
+	RatingMedium Rating = "MEDIUM"
+
+	// RatingHigh 7.0-8.9
+	RatingHigh Rating = "HIGH"
+
+	// RatingCritical 9.0-10.0
+	RatingCritical Rating = "CRITICAL"
+)
+
+// RatingOf 返回分数对应的定性评级，3.x和4.0使用相同的区间
+func RatingOf(score float64) Rating {
+	switch {
+	case score <= 0:
+		return RatingNone
+	case score < 4:
+		return RatingLow
+	case score < 7:
+		return RatingMedium
+	case score < 9:
+		return RatingHigh
+	}
+	return RatingCritical
+}
+
+// ParseRating 解析评级名称，不区分大小写
+// 也接受GitHub安全公告中的 "MODERATE"（等同于MEDIUM），无法识别时返回RatingUnknown
+func ParseRating(s string) Rating {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "NONE":
+		return RatingNone
+	case "LOW":
+		return RatingLow
+	case "MEDIUM", "MODERATE":
+		return RatingMedium
+	case "HIGH":
+		return RatingHigh
+	case "CRITICAL":
+		return RatingCritical
+	}
+	return RatingUnknown
+}
+
+// Rank 返回评级的序号，用于排序：未知为0，NONE为1，依次递增到CRITICAL为5
+func (r Rating) Rank() int {
+	switch r {
+	case RatingNone:
+		return 1
+	case RatingLow:
+		return 2
+	case RatingMedium:
+		return 3
+	case RatingHigh:
+		return 4
+	case RatingCritical:
+		return 5
+	}
+	return 0
+}
+
+// AtLeast 检查评级是否不低于指定评级，未知评级只满足RatingUnknown
+func (r Rating) AtLeast(threshold Rating) bool {
+	return r.Rank() >= threshold.Rank()
+}
+
+// String 返回评级名称
+func (r Rating) String() string {
+	if r == RatingUnknown {
+		return "UNKNOWN"
+	}
+	return string(r)
+}
@@ -0,0 +1,46 @@
+package cvss
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRatingOf(t *testing.T) {
+	cases := map[float64]Rating{
+		0:    RatingNone,
+		0.1:  RatingLow,
+		3.9:  RatingLow,
+		4.0:  RatingMedium,
+		6.9:  RatingMedium,
+		7.0:  RatingHigh,
+		8.9:  RatingHigh,
+		9.0:  RatingCritical,
+		10.0: RatingCritical,
+	}
+	for score, want := range cases {
+		assert.Equal(t, want, RatingOf(score), score)
+	}
+}
+
+func TestParseRating(t *testing.T) {
+	assert.Equal(t, RatingCritical, ParseRating("critical"))
+	assert.Equal(t, RatingMedium, ParseRating(" Moderate "))
+	assert.Equal(t, RatingMedium, ParseRating("MEDIUM"))
+	assert.Equal(t, RatingNone, ParseRating("none"))
+	assert.Equal(t, RatingUnknown, ParseRating("severe"))
+	assert.Equal(t, RatingUnknown, ParseRating(""))
+}
+
+func TestRatingAtLeast(t *testing.T) {
+	assert.True(t, RatingHigh.AtLeast(RatingMedium))
+	assert.True(t, RatingHigh.AtLeast(RatingHigh))
+	assert.False(t, RatingLow.AtLeast(RatingMedium))
+	assert.False(t, RatingUnknown.AtLeast(RatingNone))
+	assert.True(t, RatingUnknown.AtLeast(RatingUnknown))
+
+	assert.Less(t, RatingUnknown.Rank(), RatingNone.Rank())
+	assert.Less(t, RatingHigh.Rank(), RatingCritical.Rank())
+	assert.Equal(t, "UNKNOWN", RatingUnknown.String())
+	assert.Equal(t, "LOW", RatingLow.String())
+}
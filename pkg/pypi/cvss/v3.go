@@ -0,0 +1,173 @@
+package cvss
+
+import (
+	"math"
+	"strings"
+)
+
+// v3Specs CVSS v3.x的指标及取值，见 https://www.first.org/cvss/v3.1/specification-document
+var v3Specs = map[string]metricSpec{
+	// 基础指标
+	"AV": {values: []string{"N", "A", "L", "P"}, required: true},
+	"AC": {values: []string{"L", "H"}, required: true},
+	"PR": {values: []string{"N", "L", "H"}, required: true},
+	"UI": {values: []string{"N", "R"}, required: true},
+	"S":  {values: []string{"U", "C"}, required: true},
+	"C":  {values: []string{"H", "L", "N"}, required: true},
+	"I":  {values: []string{"H", "L", "N"}, required: true},
+	"A":  {values: []string{"H", "L", "N"}, required: true},
+
+	// 时间指标
+	"E":  {values: []string{"X", "H", "F", "P", "U"}},
+	"RL": {values: []string{"X", "U", "W", "T", "O"}},
+	"RC": {values: []string{"X", "C", "R", "U"}},
+
+	// 环境指标
+	"CR":  {values: []string{"X", "H", "M", "L"}},
+	"IR":  {values: []string{"X", "H", "M", "L"}},
+	"AR":  {values: []string{"X", "H", "M", "L"}},
+	"MAV": {values: []string{"X", "N", "A", "L", "P"}},
+	"MAC": {values: []string{"X", "L", "H"}},
+	"MPR": {values: []string{"X", "N", "L", "H"}},
+	"MUI": {values: []string{"X", "N", "R"}},
+	"MS":  {values: []string{"X", "U", "C"}},
+	"MC":  {values: []string{"X", "H", "L", "N"}},
+	"MI":  {values: []string{"X", "H", "L", "N"}},
+	"MA":  {values: []string{"X", "H", "L", "N"}},
+}
+
+// V3 CVSS v3.0或v3.1向量
+// 时间和环境指标会被校验，但分数只按基础指标计算
+type V3 struct {
+	m *metrics
+}
+
+// ParseV3 解析CVSS v3.0或v3.1向量，指标可以按任意顺序出现
+func ParseV3(s string) (*V3, error) {
+	s = strings.TrimSpace(s)
+	prefix := "CVSS:3.1"
+	if strings.HasPrefix(s, "CVSS:3.0/") {
+		prefix = "CVSS:3.0"
+	}
+	m, err := parseMetrics(s, prefix, v3Specs)
+	if err != nil {
+		return nil, err
+	}
+	return &V3{m: m}, nil
+}
+
+// Version 返回 "3.0" 或 "3.1"
+func (v *V3) Version() string {
+	return strings.TrimPrefix(v.m.prefix, "CVSS:")
+}
+
+// Metric 返回指标的取值
+func (v *V3) Metric(name string) string {
+	return v.m.values[name]
+}
+
+// String 返回向量字符串
+func (v *V3) String() string {
+	return v.m.String()
+}
+
+// Rating 返回基础分数对应的定性评级
+func (v *V3) Rating() Rating {
+	return RatingOf(v.BaseScore())
+}
+
+// Score 返回基础分数
+func (v *V3) Score() float64 {
+	return v.BaseScore()
+}
+
+// BaseScore 按规范第7.1节计算基础分数
+func (v *V3) BaseScore() float64 {
+	scope := v.m.get("S") == "C"
+	iss := 1 - (1-cia(v.m.get("C")))*(1-cia(v.m.get("I")))*(1-cia(v.m.get("A")))
+
+	var impact float64
+	if scope {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	} else {
+		impact = 6.42 * iss
+	}
+	if impact <= 0 {
+		return 0
+	}
+
+	exploitability := 8.22 * attackVector(v.m.get("AV")) * attackComplexity(v.m.get("AC")) *
+		privilegesRequired(v.m.get("PR"), scope) * userInteraction(v.m.get("UI"))
+
+	score := impact + exploitability
+	if scope {
+		score *= 1.08
+	}
+	return v.roundup(math.Min(score, 10))
+}
+
+// roundup 向上取整到一位小数
+// 3.1改用整数运算以避免浮点误差（如4.000001被取整为4.1），3.0直接向上取整
+func (v *V3) roundup(x float64) float64 {
+	if v.m.prefix == "CVSS:3.0" {
+		return math.Ceil(x*10) / 10
+	}
+	i := math.Round(x * 100000)
+	if math.Mod(i, 10000) == 0 {
+		return i / 100000
+	}
+	return (math.Floor(i/10000) + 1) / 10
+}
+
+func attackVector(v string) float64 {
+	switch v {
+	case "N":
+		return 0.85
+	case "A":
+		return 0.62
+	case "L":
+		return 0.55
+	}
+	return 0.2
+}
+
+func attackComplexity(v string) float64 {
+	if v == "L" {
+		return 0.77
+	}
+	return 0.44
+}
+
+// privilegesRequired 范围改变时L和H的权重更高
+func privilegesRequired(v string, scopeChanged bool) float64 {
+	switch v {
+	case "N":
+		return 0.85
+	case "L":
+		if scopeChanged {
+			return 0.68
+		}
+		return 0.62
+	}
+	if scopeChanged {
+		return 0.5
+	}
+	return 0.27
+}
+
+func userInteraction(v string) float64 {
+	if v == "N" {
+		return 0.85
+	}
+	return 0.62
+}
+
+func cia(v string) float64 {
+	switch v {
+	case "H":
+		return 0.56
+	case "L":
+		return 0.22
+	}
+	return 0
+}
@@ -0,0 +1,54 @@
+package cvss
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestV3BaseScore(t *testing.T) {
+	cases := []struct {
+		vector string
+		score  float64
+		rating Rating
+	}{
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8, RatingCritical},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", 10.0, RatingCritical},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:L", 5.3, RatingMedium},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", 6.1, RatingMedium},
+		{"CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H", 7.8, RatingHigh},
+		{"CVSS:3.1/AV:N/AC:H/PR:H/UI:R/S:U/C:L/I:N/A:N", 2.0, RatingLow},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N", 0, RatingNone},
+		{"CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8, RatingCritical},
+		// 时间指标不影响基础分数
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/E:U/RL:O/RC:U", 9.8, RatingCritical},
+	}
+	for _, tc := range cases {
+		t.Run(tc.vector, func(t *testing.T) {
+			v, err := ParseV3(tc.vector)
+			require.NoError(t, err)
+			assert.Equal(t, tc.score, v.BaseScore())
+			assert.Equal(t, tc.score, v.Score())
+			assert.Equal(t, tc.rating, v.Rating())
+		})
+	}
+}
+
+func TestV3Roundup(t *testing.T) {
+	v31, err := ParseV3("CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H")
+	require.NoError(t, err)
+	v30, err := ParseV3("CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H")
+	require.NoError(t, err)
+
+	t.Run("3.1避免浮点误差", func(t *testing.T) {
+		assert.Equal(t, 4.0, v31.roundup(4.000000000000001))
+		assert.Equal(t, 4.1, v31.roundup(4.02))
+		assert.Equal(t, 4.0, v31.roundup(4.0))
+	})
+
+	t.Run("3.0直接向上取整", func(t *testing.T) {
+		assert.Equal(t, 4.1, v30.roundup(4.000000000000001))
+		assert.Equal(t, 4.1, v30.roundup(4.02))
+	})
+}
@@ -0,0 +1,343 @@
+package cvss
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// v4Specs CVSS v4.0的指标及取值，见 https://www.first.org/cvss/v4.0/specification-document
+var v4Specs = map[string]metricSpec{
+	// 基础指标
+	"AV": {values: []string{"N", "A", "L", "P"}, required: true},
+	"AC": {values: []string{"L", "H"}, required: true},
+	"AT": {values: []string{"N", "P"}, required: true},
+	"PR": {values: []string{"N", "L", "H"}, required: true},
+	"UI": {values: []string{"N", "P", "A"}, required: true},
+	"VC": {values: []string{"H", "L", "N"}, required: true},
+	"VI": {values: []string{"H", "L", "N"}, required: true},
+	"VA": {values: []string{"H", "L", "N"}, required: true},
+	"SC": {values: []string{"H", "L", "N"}, required: true},
+	"SI": {values: []string{"H", "L", "N"}, required: true},
+	"SA": {values: []string{"H", "L", "N"}, required: true},
+
+	// 威胁指标
+	"E": {values: []string{"X", "A", "P", "U"}},
+
+	// 环境指标
+	"CR":  {values: []string{"X", "H", "M", "L"}},
+	"IR":  {values: []string{"X", "H", "M", "L"}},
+	"AR":  {values: []string{"X", "H", "M", "L"}},
+	"MAV": {values: []string{"X", "N", "A", "L", "P"}},
+	"MAC": {values: []string{"X", "L", "H"}},
+	"MAT": {values: []string{"X", "N", "P"}},
+	"MPR": {values: []string{"X", "N", "L", "H"}},
+	"MUI": {values: []string{"X", "N", "P", "A"}},
+	"MVC": {values: []string{"X", "H", "L", "N"}},
+	"MVI": {values: []string{"X", "H", "L", "N"}},
+	"MVA": {values: []string{"X", "H", "L", "N"}},
+	"MSC": {values: []string{"X", "H", "L", "N"}},
+	"MSI": {values: []string{"X", "S", "H", "L", "N"}},
+	"MSA": {values: []string{"X", "S", "H", "L", "N"}},
+
+	// 补充指标，不影响分数
+	"S":  {values: []string{"X", "N", "P"}},
+	"AU": {values: []string{"X", "N", "Y"}},
+	"R":  {values: []string{"X", "A", "U", "I"}},
+	"V":  {values: []string{"X", "D", "C"}},
+	"RE": {values: []string{"X", "L", "M", "H"}},
+	"U":  {values: []string{"X", "Clear", "Green", "Amber", "Red"}},
+}
+
+// V4 CVSS v4.0向量
+type V4 struct {
+	m *metrics
+}
+
+// ParseV4 解析CVSS v4.0向量
+func ParseV4(s string) (*V4, error) {
+	m, err := parseMetrics(strings.TrimSpace(s), "CVSS:4.0", v4Specs)
+	if err != nil {
+		return nil, err
+	}
+	return &V4{m: m}, nil
+}
+
+// Version 返回 "4.0"
+func (v *V4) Version() string {
+	return "4.0"
+}
+
+// Metric 返回指标的取值
+func (v *V4) Metric(name string) string {
+	return v.m.values[name]
+}
+
+// String 返回向量字符串
+func (v *V4) String() string {
+	return v.m.String()
+}
+
+// Rating 返回分数对应的定性评级
+func (v *V4) Rating() Rating {
+	return RatingOf(v.Score())
+}
+
+// Nomenclature 返回分数的命名：CVSS-B、CVSS-BT、CVSS-BE或CVSS-BTE，取决于向量中是否定义了威胁和环境指标
+func (v *V4) Nomenclature() string {
+	name := "CVSS-B"
+	if v.m.get("E") != "X" {
+		name += "T"
+	}
+	for _, metric := range []string{"CR", "IR", "AR", "MAV", "MAC", "MAT", "MPR", "MUI", "MVC", "MVI", "MVA", "MSC", "MSI", "MSA"} {
+		if v.m.get(metric) != "X" {
+			return name + "E"
+		}
+	}
+	return name
+}
+
+// effective 返回参与计算的指标取值：环境指标覆盖对应的基础指标，
+// 未定义的E和CR/IR/AR按最坏情况取A和H
+func (v *V4) effective(metric string) string {
+	switch metric {
+	case "E":
+		if value := v.m.get("E"); value != "X" {
+			return value
+		}
+		return "A"
+	case "CR", "IR", "AR":
+		if value := v.m.get(metric); value != "X" {
+			return value
+		}
+		return "H"
+	}
+	if value := v.m.get("M" + metric); value != "X" {
+		return value
+	}
+	return v.m.get(metric)
+}
+
+// macroVector 按规范第8.2节计算EQ1-EQ6的取值
+func (v *V4) macroVector() [6]int {
+	av, pr, ui := v.effective("AV"), v.effective("PR"), v.effective("UI")
+	ac, at := v.effective("AC"), v.effective("AT")
+	vc, vi, va := v.effective("VC"), v.effective("VI"), v.effective("VA")
+	sc, si, sa := v.effective("SC"), v.effective("SI"), v.effective("SA")
+	cr, ir, ar := v.effective("CR"), v.effective("IR"), v.effective("AR")
+
+	var eq [6]int
+	switch {
+	case av == "N" && pr == "N" && ui == "N":
+		eq[0] = 0
+	case (av == "N" || pr == "N" || ui == "N") && av != "P":
+		eq[0] = 1
+	default:
+		eq[0] = 2
+	}
+
+	if !(ac == "L" && at == "N") {
+		eq[1] = 1
+	}
+
+	switch {
+	case vc == "H" && vi == "H":
+		eq[2] = 0
+	case vc == "H" || vi == "H" || va == "H":
+		eq[2] = 1
+	default:
+		eq[2] = 2
+	}
+
+	switch {
+	case si == "S" || sa == "S":
+		eq[3] = 0
+	case sc == "H" || si == "H" || sa == "H":
+		eq[3] = 1
+	default:
+		eq[3] = 2
+	}
+
+	switch v.effective("E") {
+	case "P":
+		eq[4] = 1
+	case "U":
+		eq[4] = 2
+	}
+
+	if !((cr == "H" && vc == "H") || (ir == "H" && vi == "H") || (ar == "H" && va == "H")) {
+		eq[5] = 1
+	}
+	return eq
+}
+
+// severityLevels 各指标取值的严重程度距离（以0.1为单位），数值越小越严重
+var severityLevels = map[string]map[string]int{
+	"AV": {"N": 0, "A": 1, "L": 2, "P": 3},
+	"PR": {"N": 0, "L": 1, "H": 2},
+	"UI": {"N": 0, "P": 1, "A": 2},
+	"AC": {"L": 0, "H": 1},
+	"AT": {"N": 0, "P": 1},
+	"VC": {"H": 0, "L": 1, "N": 2},
+	"VI": {"H": 0, "L": 1, "N": 2},
+	"VA": {"H": 0, "L": 1, "N": 2},
+	"SC": {"H": 1, "L": 2, "N": 3},
+	"SI": {"S": 0, "H": 1, "L": 2, "N": 3},
+	"SA": {"S": 0, "H": 1, "L": 2, "N": 3},
+	"CR": {"H": 0, "M": 1, "L": 2},
+	"IR": {"H": 0, "M": 1, "L": 2},
+	"AR": {"H": 0, "M": 1, "L": 2},
+}
+
+// 各EQ取值下的最高严重程度向量（规范表24-30）
+var (
+	maxVectorsEQ1 = [][]string{
+		{"AV:N/PR:N/UI:N"},
+		{"AV:A/PR:N/UI:N", "AV:N/PR:L/UI:N", "AV:N/PR:N/UI:P"},
+		{"AV:P/PR:N/UI:N", "AV:A/PR:L/UI:P"},
+	}
+	maxVectorsEQ2 = [][]string{
+		{"AC:L/AT:N"},
+		{"AC:L/AT:P", "AC:H/AT:N"},
+	}
+	// 按EQ3、EQ6索引
+	maxVectorsEQ3EQ6 = [][][]string{
+		{
+			{"VC:H/VI:H/VA:H/CR:H/IR:H/AR:H"},
+			{"VC:H/VI:H/VA:L/CR:M/IR:M/AR:H", "VC:H/VI:H/VA:H/CR:M/IR:M/AR:M"},
+		},
+		{
+			{"VC:L/VI:H/VA:H/CR:H/IR:H/AR:H", "VC:H/VI:L/VA:H/CR:H/IR:H/AR:H"},
+			{
+				"VC:H/VI:L/VA:H/CR:M/IR:H/AR:M", "VC:H/VI:L/VA:L/CR:M/IR:H/AR:H",
+				"VC:L/VI:H/VA:H/CR:H/IR:M/AR:M", "VC:L/VI:H/VA:L/CR:H/IR:M/AR:H",
+				"VC:L/VI:L/VA:H/CR:H/IR:H/AR:M",
+			},
+		},
+		{
+			nil,
+			{"VC:L/VI:L/VA:L/CR:H/IR:H/AR:H"},
+		},
+	}
+	maxVectorsEQ4 = [][]string{
+		{"SC:H/SI:S/SA:S"},
+		{"SC:H/SI:H/SA:H"},
+		{"SC:L/SI:L/SA:L"},
+	}
+)
+
+// 各EQ取值下MacroVector的深度（最高与最低严重程度向量之间的距离加一，以0.1为单位）
+var (
+	depthEQ1    = []int{1, 4, 5}
+	depthEQ2    = []int{1, 2}
+	depthEQ3EQ6 = [][]int{{7, 6}, {8, 8}, {0, 10}}
+	depthEQ4    = []int{6, 5, 4}
+)
+
+// Score 按规范第8.2节计算分数：
+// 以所属MacroVector的分数为基准，按向量与该MacroVector最高严重程度向量之间的距离，
+// 在相邻的较低MacroVector之间插值，结果四舍五入到一位小数
+func (v *V4) Score() float64 {
+	noImpact := true
+	for _, metric := range []string{"VC", "VI", "VA", "SC", "SI", "SA"} {
+		if v.effective(metric) != "N" {
+			noImpact = false
+			break
+		}
+	}
+	if noImpact {
+		return 0
+	}
+
+	eq := v.macroVector()
+	value := lookupMacroVector(eq)
+
+	// 每个EQ的下一个较低MacroVector，不存在时为NaN
+	lower := func(index int) float64 {
+		next := eq
+		next[index]++
+		return lookupMacroVector(next)
+	}
+	nextEQ1, nextEQ2, nextEQ4, nextEQ5 := lower(0), lower(1), lower(3), lower(4)
+	// EQ3和EQ6相互关联，eq3=2时eq6只能为1
+	nextEQ3EQ6 := math.NaN()
+	switch {
+	case eq[2] == 0 && eq[5] == 0:
+		nextEQ3EQ6 = math.Max(lower(2), lower(5))
+	case eq[2] == 1 && eq[5] == 0:
+		nextEQ3EQ6 = lower(5)
+	case eq[2] <= 1:
+		nextEQ3EQ6 = lower(2)
+	}
+
+	// 找到一个每个指标都不比当前向量更轻的最高严重程度向量，计算各EQ的距离
+	var distEQ1, distEQ2, distEQ3EQ6, distEQ4 int
+search:
+	for _, m1 := range maxVectorsEQ1[eq[0]] {
+		for _, m2 := range maxVectorsEQ2[eq[1]] {
+			for _, m36 := range maxVectorsEQ3EQ6[eq[2]][eq[5]] {
+				for _, m4 := range maxVectorsEQ4[eq[3]] {
+					dist := v.distances(m1 + "/" + m2 + "/" + m36 + "/" + m4)
+					if dist == nil {
+						continue
+					}
+					distEQ1 = dist["AV"] + dist["PR"] + dist["UI"]
+					distEQ2 = dist["AC"] + dist["AT"]
+					distEQ3EQ6 = dist["VC"] + dist["VI"] + dist["VA"] + dist["CR"] + dist["IR"] + dist["AR"]
+					distEQ4 = dist["SC"] + dist["SI"] + dist["SA"]
+					break search
+				}
+			}
+		}
+	}
+
+	// 每个存在较低MacroVector的EQ贡献 (分数差 × 距离 / 深度)，取平均值
+	var total float64
+	var count int
+	add := func(next float64, distance, depth int) {
+		if math.IsNaN(next) {
+			return
+		}
+		count++
+		total += (value - next) * float64(distance) / float64(depth)
+	}
+	add(nextEQ1, distEQ1, depthEQ1[eq[0]])
+	add(nextEQ2, distEQ2, depthEQ2[eq[1]])
+	add(nextEQ3EQ6, distEQ3EQ6, depthEQ3EQ6[eq[2]][eq[5]])
+	add(nextEQ4, distEQ4, depthEQ4[eq[3]])
+	// EQ5只涉及E一个指标，距离总为0
+	add(nextEQ5, 0, 1)
+
+	if count > 0 {
+		value -= total / float64(count)
+	}
+	value = math.Max(0, math.Min(10, value))
+	return math.Round(value*10) / 10
+}
+
+// distances 计算各指标与最高严重程度向量之间的距离，任一指标比其更严重时返回nil
+func (v *V4) distances(maxVector string) map[string]int {
+	dist := make(map[string]int, len(severityLevels))
+	for _, part := range strings.Split(maxVector, "/") {
+		metric, maxValue, _ := strings.Cut(part, ":")
+		levels := severityLevels[metric]
+		d := levels[v.effective(metric)] - levels[maxValue]
+		if d < 0 {
+			return nil
+		}
+		dist[metric] = d
+	}
+	return dist
+}
+
+// lookupMacroVector 返回MacroVector的分数，不存在时返回NaN
+func lookupMacroVector(eq [6]int) float64 {
+	var b strings.Builder
+	for _, n := range eq {
+		b.WriteString(strconv.Itoa(n))
+	}
+	if score, ok := macroVectorScores[b.String()]; ok {
+		return score
+	}
+	return math.NaN()
+}
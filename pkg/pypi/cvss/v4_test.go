@@ -0,0 +1,70 @@
+package cvss
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestV4Score(t *testing.T) {
+	// 分数与FIRST官方计算器一致
+	cases := []struct {
+		vector string
+		score  float64
+		rating Rating
+	}{
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", 9.3, RatingCritical},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:H/SI:H/SA:H", 10, RatingCritical},
+		{"CVSS:4.0/AV:L/AC:L/AT:N/PR:L/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", 8.5, RatingHigh},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:P/VC:N/VI:N/VA:N/SC:L/SI:L/SA:N", 5.3, RatingMedium},
+		{"CVSS:4.0/AV:P/AC:H/AT:P/PR:H/UI:A/VC:L/VI:N/VA:N/SC:N/SI:N/SA:N", 1.0, RatingLow},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:N/VI:N/VA:N/SC:N/SI:N/SA:N", 0, RatingNone},
+		// 威胁指标
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N/E:P", 8.9, RatingHigh},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N/E:U", 8.1, RatingHigh},
+		// 环境指标覆盖基础指标
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N/MSI:S/MSA:S", 10, RatingCritical},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N/MVC:N/MVI:N/MVA:N", 0, RatingNone},
+		// 补充指标不影响分数
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N/S:P/AU:Y/R:I/V:C/RE:H/U:Red", 9.3, RatingCritical},
+	}
+	for _, tc := range cases {
+		t.Run(tc.vector, func(t *testing.T) {
+			v, err := ParseV4(tc.vector)
+			require.NoError(t, err)
+			assert.Equal(t, tc.score, v.Score())
+			assert.Equal(t, tc.rating, v.Rating())
+		})
+	}
+}
+
+func TestV4MacroVector(t *testing.T) {
+	v, err := ParseV4("CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N")
+	require.NoError(t, err)
+	assert.Equal(t, [6]int{0, 0, 0, 2, 0, 0}, v.macroVector())
+
+	v, err = ParseV4("CVSS:4.0/AV:P/AC:H/AT:P/PR:H/UI:A/VC:L/VI:L/VA:L/SC:H/SI:N/SA:N/E:U/CR:L/IR:L/AR:L")
+	require.NoError(t, err)
+	assert.Equal(t, [6]int{2, 1, 2, 1, 2, 1}, v.macroVector())
+
+	// 每个可能的MacroVector都在查找表中
+	assert.Len(t, macroVectorScores, 270)
+}
+
+func TestV4Nomenclature(t *testing.T) {
+	base := "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N"
+	cases := map[string]string{
+		base:                  "CVSS-B",
+		base + "/E:A":         "CVSS-BT",
+		base + "/CR:H":        "CVSS-BE",
+		base + "/E:P/MAV:L":   "CVSS-BTE",
+		base + "/E:X/S:P":     "CVSS-B",
+		base + "/MSI:X/U:Red": "CVSS-B",
+	}
+	for vector, want := range cases {
+		v, err := ParseV4(vector)
+		require.NoError(t, err)
+		assert.Equal(t, want, v.Nomenclature(), vector)
+	}
+}
@@ -0,0 +1,69 @@
+package failover
+
+import (
+	"sync"
+	"time"
+)
+
+// breaker 单个后端的熔断器
+// 连续失败达到阈值后断开，冷却期内跳过该后端；冷却期结束后放行一个试探请求（半开），
+// 试探成功则恢复，失败则重新进入冷却期
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// allow 检查是否允许向后端发送请求，半开状态下只放行一个试探请求
+func (b *breaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.threshold <= 0 || b.failures < b.threshold {
+		return true
+	}
+	if now.Before(b.openUntil) || b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// success 记录一次成功，熔断器恢复为闭合状态
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.probing = false
+	b.openUntil = time.Time{}
+}
+
+// failure 记录一次失败，达到阈值时进入冷却期
+func (b *breaker) failure(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.probing = false
+	if b.threshold > 0 && b.failures >= b.threshold {
+		b.openUntil = now.Add(b.cooldown)
+	}
+}
+
+// release 请求被取消而没有结果时释放试探名额
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// state 返回连续失败次数和冷却期结束时间，未断开时openUntil为零值
+func (b *breaker) state(now time.Time) (failures int, open bool, openUntil time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.threshold > 0 && b.failures >= b.threshold {
+		return b.failures, now.Before(b.openUntil), b.openUntil
+	}
+	return b.failures, false, time.Time{}
+}
@@ -0,0 +1,67 @@
+package failover
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("达到阈值后断开", func(t *testing.T) {
+		b := &breaker{threshold: 2, cooldown: time.Minute}
+		b.failure(now)
+		assert.True(t, b.allow(now))
+		b.failure(now)
+		assert.False(t, b.allow(now))
+		assert.False(t, b.allow(now.Add(59*time.Second)))
+
+		failures, open, until := b.state(now)
+		assert.Equal(t, 2, failures)
+		assert.True(t, open)
+		assert.Equal(t, now.Add(time.Minute), until)
+	})
+
+	t.Run("半开时只放行一个试探请求", func(t *testing.T) {
+		b := &breaker{threshold: 1, cooldown: time.Minute}
+		b.failure(now)
+		later := now.Add(time.Minute)
+		assert.True(t, b.allow(later))
+		assert.False(t, b.allow(later))
+
+		b.release()
+		assert.True(t, b.allow(later))
+	})
+
+	t.Run("试探失败重新冷却", func(t *testing.T) {
+		b := &breaker{threshold: 1, cooldown: time.Minute}
+		b.failure(now)
+		later := now.Add(time.Minute)
+		assert.True(t, b.allow(later))
+		b.failure(later)
+		assert.False(t, b.allow(later.Add(time.Second)))
+		assert.True(t, b.allow(later.Add(time.Minute)))
+	})
+
+	t.Run("成功后恢复", func(t *testing.T) {
+		b := &breaker{threshold: 1, cooldown: time.Minute}
+		b.failure(now)
+		b.success()
+		assert.True(t, b.allow(now))
+		failures, open, _ := b.state(now)
+		assert.Equal(t, 0, failures)
+		assert.False(t, open)
+	})
+
+	t.Run("阈值小于1时不熔断", func(t *testing.T) {
+		b := &breaker{cooldown: time.Minute}
+		for i := 0; i < 10; i++ {
+			b.failure(now)
+		}
+		assert.True(t, b.allow(now))
+		_, open, _ := b.state(now)
+		assert.False(t, open)
+	})
+}
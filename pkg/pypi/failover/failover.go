@@ -0,0 +1,475 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/api"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/client"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/mirrors"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+)
+
+var (
+	// ErrNoBackends 表示Client没有配置任何后端
+	ErrNoBackends = errors.New("没有配置任何后端")
+
+	// ErrCircuitOpen 表示后端处于熔断冷却期而被跳过
+	ErrCircuitOpen = errors.New("后端处于熔断冷却期")
+)
+
+// 熔断的默认配置
+const (
+	// DefaultFailureThreshold 连续失败多少次后断开熔断器
+	DefaultFailureThreshold = 3
+
+	// DefaultCooldown 熔断器断开后跳过该后端的时间
+	DefaultCooldown = 30 * time.Second
+)
+
+// Backend 一个有名称的后端
+type Backend struct {
+	// Name 后端名称，出现在Trace和错误信息中，如镜像名或主机名
+	Name string
+
+	// Client 实际发送请求的客户端
+	Client api.PyPIClient
+}
+
+// Attempt 对一个后端的一次请求
+type Attempt struct {
+	// Backend 后端名称
+	Backend string
+
+	// Err 请求失败的原因，成功时为nil；因熔断被跳过时为ErrCircuitOpen
+	Err error
+
+	// Latency 请求耗时
+	Latency time.Duration
+
+	// Hedged 请求是否因前一个请求过慢而发出的对冲请求
+	Hedged bool
+}
+
+// Trace 记录一次调用由哪个后端完成以及经过的尝试
+type Trace struct {
+	// Backend 返回结果的后端名称，所有后端都失败时为空
+	Backend string
+
+	// Attempts 按完成顺序排列的尝试，因熔断跳过的后端也会记录；
+	// 启用对冲时，在结果返回后才完成的请求不会出现在这里
+	Attempts []Attempt
+}
+
+type traceKey struct{}
+
+// WithTrace 返回携带t的上下文，使用该上下文调用Client的方法后，t中记录了该次调用的执行过程
+// 每次调用应使用新的Trace
+//
+// 使用示例:
+//
+//	var trace failover.Trace
+//	pkg, err := c.GetPackageInfo(failover.WithTrace(ctx, &trace), "requests")
+//	fmt.Println("served by", trace.Backend)
+func WithTrace(ctx context.Context, t *Trace) context.Context {
+	return context.WithValue(ctx, traceKey{}, t)
+}
+
+func traceFrom(ctx context.Context) *Trace {
+	t, _ := ctx.Value(traceKey{}).(*Trace)
+	return t
+}
+
+// Error 所有后端都失败时返回的错误
+type Error struct {
+	// Op 调用的方法名，如 "GetPackageInfo"
+	Op string
+
+	// Attempts 所有尝试
+	Attempts []Attempt
+}
+
+// Error 实现error接口
+func (e *Error) Error() string {
+	parts := make([]string, 0, len(e.Attempts))
+	for _, a := range e.Attempts {
+		parts = append(parts, a.Backend+": "+a.Err.Error())
+	}
+	return fmt.Sprintf("%s: 所有后端均失败 (%s)", e.Op, strings.Join(parts, "; "))
+}
+
+// Unwrap 返回最能说明失败原因的错误：最后一个既非不存在也非熔断跳过的错误；
+// 没有这样的错误时返回第一个client.ErrNotFound，使所有后端都返回404时 errors.Is(err, client.ErrNotFound) 成立；
+// 所有后端都被跳过时返回ErrCircuitOpen
+func (e *Error) Unwrap() error {
+	var notFound error
+	for i := len(e.Attempts) - 1; i >= 0; i-- {
+		err := e.Attempts[i].Err
+		switch {
+		case errors.Is(err, client.ErrNotFound):
+			notFound = err
+		case err != ErrCircuitOpen:
+			return err
+		}
+	}
+	if notFound != nil {
+		return notFound
+	}
+	return ErrCircuitOpen
+}
+
+// Status 一个后端的熔断状态
+type Status struct {
+	// Name 后端名称
+	Name string
+
+	// Failures 连续失败次数
+	Failures int
+
+	// Open 熔断器是否断开（处于冷却期）
+	Open bool
+
+	// OpenUntil 冷却期结束时间，熔断器从未断开时为零值
+	OpenUntil time.Time
+}
+
+// Client 按顺序使用多个后端的api.PyPIClient
+// 请求出错或超时时依次尝试下一个后端；连续失败的后端会被熔断，在冷却期内直接跳过；
+// 可选地在请求过慢时向下一个后端发出对冲请求，采用先成功返回的结果
+type Client struct {
+	backends         []*backend
+	hedgeDelay       time.Duration
+	attemptTimeout   time.Duration
+	notFoundFailover bool
+	now              func() time.Time
+}
+
+type backend struct {
+	Backend
+	breaker *breaker
+}
+
+var (
+	_ api.PyPIClient         = (*Client)(nil)
+	_ api.CoreMetadataClient = (*Client)(nil)
+)
+
+// New 创建按顺序使用backends的客户端，排在前面的后端优先
+// 默认连续失败DefaultFailureThreshold次后熔断DefaultCooldown，不发出对冲请求，包不存在时也尝试下一个后端
+//
+// 参数:
+//   - backends: 后端列表
+//
+// 返回值:
+//   - *Client: 客户端
+//
+// 使用示例:
+//
+//	c := failover.New(
+//		failover.Backend{Name: "tsinghua", Client: mirrors.NewTsinghuaClient()},
+//		failover.Backend{Name: "official", Client: mirrors.NewOfficialClient()},
+//	).WithHedge(500 * time.Millisecond)
+func New(backends ...Backend) *Client {
+	c := &Client{notFoundFailover: true, now: time.Now}
+	for _, b := range backends {
+		c.backends = append(c.backends, &backend{
+			Backend: b,
+			breaker: &breaker{threshold: DefaultFailureThreshold, cooldown: DefaultCooldown},
+		})
+	}
+	return c
+}
+
+// NewFromURLs 为每个基础URL创建client.Client并组合，后端名称为URL的主机和路径
+//
+// 参数:
+//   - baseURLs: 按优先级排列的基础URL，与client.Options.BaseURL含义相同
+//   - options: 可选配置，每个后端使用其副本并替换BaseURL
+//
+// 返回值:
+//   - *Client: 客户端
+func NewFromURLs(baseURLs []string, options ...*client.Options) *Client {
+	backends := make([]Backend, 0, len(baseURLs))
+	for _, baseURL := range baseURLs {
+		backends = append(backends, Backend{
+			Name:   nameOf(baseURL),
+			Client: client.NewClient(copyOptions(options).WithBaseURL(baseURL)),
+		})
+	}
+	return New(backends...)
+}
+
+// NewFromMirrors 为每个镜像创建客户端并组合，后端名称为镜像名称
+//
+// 参数:
+//   - ms: 按优先级排列的镜像，如按mirrors.Rank排序后的探测结果中的镜像
+//   - options: 可选配置，每个后端使用其副本
+//
+// 返回值:
+//   - *Client: 客户端
+//
+// 使用示例:
+//
+//	c := failover.NewFromMirrors(mirrors.Default.InRegion(mirrors.RegionCN))
+func NewFromMirrors(ms []mirrors.Mirror, options ...*client.Options) *Client {
+	backends := make([]Backend, 0, len(ms))
+	for _, m := range ms {
+		backends = append(backends, Backend{Name: m.Name, Client: m.NewClient(copyOptions(options))})
+	}
+	return New(backends...)
+}
+
+// WithBreaker 设置熔断阈值和冷却时间，threshold小于1时不熔断；应在发出请求前设置
+func (c *Client) WithBreaker(threshold int, cooldown time.Duration) *Client {
+	for _, b := range c.backends {
+		b.breaker.threshold = threshold
+		b.breaker.cooldown = cooldown
+	}
+	return c
+}
+
+// WithHedge 设置对冲延迟：请求在delay内没有返回时，并行向下一个后端发出同样的请求，为0时不对冲
+func (c *Client) WithHedge(delay time.Duration) *Client {
+	c.hedgeDelay = delay
+	return c
+}
+
+// WithAttemptTimeout 设置单个后端的请求超时，超时后尝试下一个后端并计为一次失败，为0时只受调用方上下文限制
+func (c *Client) WithAttemptTimeout(timeout time.Duration) *Client {
+	c.attemptTimeout = timeout
+	return c
+}
+
+// WithNotFoundFailover 设置后端返回client.ErrNotFound时是否尝试下一个后端，默认为true，
+// 因为镜像可能尚未同步新发布的包；不存在不会计入熔断失败
+func (c *Client) WithNotFoundFailover(enabled bool) *Client {
+	c.notFoundFailover = enabled
+	return c
+}
+
+// Status 返回各后端的熔断状态，按后端顺序排列
+func (c *Client) Status() []Status {
+	now := c.now()
+	statuses := make([]Status, 0, len(c.backends))
+	for _, b := range c.backends {
+		failures, open, until := b.breaker.state(now)
+		statuses = append(statuses, Status{Name: b.Name, Failures: failures, Open: open, OpenUntil: until})
+	}
+	return statuses
+}
+
+// GetPackageInfo 获取指定包的最新版本信息
+func (c *Client) GetPackageInfo(ctx context.Context, packageName string) (*models.Package, error) {
+	v, err := c.do(ctx, "GetPackageInfo", func(ctx context.Context, b api.PyPIClient) (interface{}, error) {
+		return b.GetPackageInfo(ctx, packageName)
+	})
+	pkg, _ := v.(*models.Package)
+	return pkg, err
+}
+
+// GetPackageVersion 获取指定包的特定版本信息
+func (c *Client) GetPackageVersion(ctx context.Context, packageName string, version string) (*models.Package, error) {
+	v, err := c.do(ctx, "GetPackageVersion", func(ctx context.Context, b api.PyPIClient) (interface{}, error) {
+		return b.GetPackageVersion(ctx, packageName, version)
+	})
+	pkg, _ := v.(*models.Package)
+	return pkg, err
+}
+
+// GetPackageReleases 获取指定包的所有发布版本
+func (c *Client) GetPackageReleases(ctx context.Context, packageName string) ([]string, error) {
+	v, err := c.do(ctx, "GetPackageReleases", func(ctx context.Context, b api.PyPIClient) (interface{}, error) {
+		return b.GetPackageReleases(ctx, packageName)
+	})
+	releases, _ := v.([]string)
+	return releases, err
+}
+
+// CheckPackageVulnerabilities 检查指定包和版本是否存在已知漏洞
+func (c *Client) CheckPackageVulnerabilities(ctx context.Context, packageName string, version string) ([]models.Vulnerability, error) {
+	v, err := c.do(ctx, "CheckPackageVulnerabilities", func(ctx context.Context, b api.PyPIClient) (interface{}, error) {
+		return b.CheckPackageVulnerabilities(ctx, packageName, version)
+	})
+	vulns, _ := v.([]models.Vulnerability)
+	return vulns, err
+}
+
+// GetAllPackages 获取所有包的列表
+func (c *Client) GetAllPackages(ctx context.Context) ([]string, error) {
+	v, err := c.do(ctx, "GetAllPackages", func(ctx context.Context, b api.PyPIClient) (interface{}, error) {
+		return b.GetAllPackages(ctx)
+	})
+	names, _ := v.([]string)
+	return names, err
+}
+
+// GetPackageList 获取所有包的列表（以map形式返回）
+func (c *Client) GetPackageList(ctx context.Context) (map[string]struct{}, error) {
+	v, err := c.do(ctx, "GetPackageList", func(ctx context.Context, b api.PyPIClient) (interface{}, error) {
+		return b.GetPackageList(ctx)
+	})
+	names, _ := v.(map[string]struct{})
+	return names, err
+}
+
+// SearchPackages 根据关键词搜索包
+func (c *Client) SearchPackages(ctx context.Context, keyword string, limit int) ([]string, error) {
+	v, err := c.do(ctx, "SearchPackages", func(ctx context.Context, b api.PyPIClient) (interface{}, error) {
+		return b.SearchPackages(ctx, keyword, limit)
+	})
+	names, _ := v.([]string)
+	return names, err
+}
+
+// GetCoreMetadata 获取发布文件的核心元数据
+// 不支持api.CoreMetadataClient的后端返回ErrUnsupported，不计入熔断
+func (c *Client) GetCoreMetadata(ctx context.Context, file *models.ReleaseFile) (*models.CoreMetadata, error) {
+	v, err := c.do(ctx, "GetCoreMetadata", func(ctx context.Context, b api.PyPIClient) (interface{}, error) {
+		mc, ok := b.(api.CoreMetadataClient)
+		if !ok {
+			return nil, fmt.Errorf("%w: 后端不支持GetCoreMetadata", client.ErrUnsupported)
+		}
+		return mc.GetCoreMetadata(ctx, file)
+	})
+	meta, _ := v.(*models.CoreMetadata)
+	return meta, err
+}
+
+// call 对一个后端发出的请求
+type call func(ctx context.Context, b api.PyPIClient) (interface{}, error)
+
+// outcome 一次请求的结果
+type outcome struct {
+	attempt Attempt
+	value   interface{}
+}
+
+// do 按顺序向后端发出请求，直到某个后端成功、调用方取消或所有后端都失败
+func (c *Client) do(ctx context.Context, op string, fn call) (interface{}, error) {
+	if len(c.backends) == 0 {
+		return nil, ErrNoBackends
+	}
+	trace := traceFrom(ctx)
+	failed := &Error{Op: op}
+	record := func(a Attempt) {
+		failed.Attempts = append(failed.Attempts, a)
+		if trace != nil {
+			trace.Attempts = append(trace.Attempts, a)
+		}
+	}
+
+	// 返回时取消仍在进行的对冲请求
+	attemptCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan outcome, len(c.backends))
+
+	next, inflight := 0, 0
+	launch := func(hedged bool) {
+		for next < len(c.backends) {
+			b := c.backends[next]
+			next++
+			if !b.breaker.allow(c.now()) {
+				record(Attempt{Backend: b.Name, Err: ErrCircuitOpen})
+				continue
+			}
+			inflight++
+			go c.attempt(attemptCtx, b, hedged, fn, results)
+			return
+		}
+	}
+
+	launch(false)
+	for inflight > 0 {
+		var timer *time.Timer
+		var hedge <-chan time.Time
+		if c.hedgeDelay > 0 && next < len(c.backends) {
+			timer = time.NewTimer(c.hedgeDelay)
+			hedge = timer.C
+		}
+
+		select {
+		case r := <-results:
+			stopTimer(timer)
+			inflight--
+			record(r.attempt)
+			if r.attempt.Err == nil {
+				if trace != nil {
+					trace.Backend = r.attempt.Backend
+				}
+				return r.value, nil
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if errors.Is(r.attempt.Err, client.ErrNotFound) && !c.notFoundFailover {
+				return nil, r.attempt.Err
+			}
+			launch(false)
+		case <-hedge:
+			launch(true)
+		case <-ctx.Done():
+			stopTimer(timer)
+			return nil, ctx.Err()
+		}
+	}
+	return nil, failed
+}
+
+// attempt 向一个后端发出请求并更新其熔断器
+func (c *Client) attempt(ctx context.Context, b *backend, hedged bool, fn call, results chan<- outcome) {
+	callCtx := ctx
+	if c.attemptTimeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, c.attemptTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	value, err := fn(callCtx, b.Client)
+	a := Attempt{Backend: b.Name, Err: err, Latency: time.Since(start), Hedged: hedged}
+
+	switch {
+	case err == nil || !unhealthy(err):
+		b.breaker.success()
+	case ctx.Err() != nil:
+		// 调用方取消或在对冲中落败，不能说明后端不健康
+		b.breaker.release()
+	default:
+		b.breaker.failure(c.now())
+	}
+	results <- outcome{attempt: a, value: value}
+}
+
+// unhealthy 检查错误是否说明后端不健康，后端正常响应了“不存在”或不支持该操作时不算
+func unhealthy(err error) bool {
+	return !errors.Is(err, client.ErrNotFound) && !errors.Is(err, client.ErrCoreMetadataUnavailable) &&
+		!errors.Is(err, client.ErrUnsupported)
+}
+
+func stopTimer(t *time.Timer) {
+	if t != nil {
+		t.Stop()
+	}
+}
+
+// copyOptions 返回options[0]的副本，未提供时返回默认配置
+func copyOptions(options []*client.Options) *client.Options {
+	if len(options) == 0 || options[0] == nil {
+		return client.NewOptions()
+	}
+	o := *options[0]
+	return &o
+}
+
+// nameOf 返回基础URL的主机和路径作为后端名称
+func nameOf(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" {
+		return baseURL
+	}
+	return u.Host + strings.TrimSuffix(u.Path, "/")
+}
@@ -0,0 +1,274 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/api"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/client"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/mirrors"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient 只实现GetPackageReleases，返回以自身名称为唯一元素的列表，可以模拟延迟和错误
+type fakeClient struct {
+	api.PyPIClient
+
+	name  string
+	err   error
+	delay time.Duration
+	calls int32
+}
+
+func (f *fakeClient) GetPackageReleases(ctx context.Context, name string) ([]string, error) {
+	atomic.AddInt32(&f.calls, 1)
+	if f.delay > 0 {
+		select {
+		case <-time.After(f.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []string{f.name}, nil
+}
+
+func (f *fakeClient) numCalls() int {
+	return int(atomic.LoadInt32(&f.calls))
+}
+
+func backends(fakes ...*fakeClient) []Backend {
+	var result []Backend
+	for _, f := range fakes {
+		result = append(result, Backend{Name: f.name, Client: f})
+	}
+	return result
+}
+
+func TestClientFailover(t *testing.T) {
+	ctx := context.Background()
+	down := errors.New("连接被拒绝")
+
+	t.Run("首个后端成功时不请求其他后端", func(t *testing.T) {
+		a, b := &fakeClient{name: "a"}, &fakeClient{name: "b"}
+		c := New(backends(a, b)...)
+
+		var trace Trace
+		releases, err := c.GetPackageReleases(WithTrace(ctx, &trace), "pip")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a"}, releases)
+		assert.Equal(t, "a", trace.Backend)
+		require.Len(t, trace.Attempts, 1)
+		assert.NoError(t, trace.Attempts[0].Err)
+		assert.Equal(t, 0, b.numCalls())
+	})
+
+	t.Run("出错时切换到下一个后端", func(t *testing.T) {
+		a, b := &fakeClient{name: "a", err: down}, &fakeClient{name: "b"}
+		c := New(backends(a, b)...)
+
+		var trace Trace
+		releases, err := c.GetPackageReleases(WithTrace(ctx, &trace), "pip")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b"}, releases)
+		assert.Equal(t, "b", trace.Backend)
+		require.Len(t, trace.Attempts, 2)
+		assert.Equal(t, "a", trace.Attempts[0].Backend)
+		assert.ErrorIs(t, trace.Attempts[0].Err, down)
+	})
+
+	t.Run("单个后端超时时切换", func(t *testing.T) {
+		a, b := &fakeClient{name: "a", delay: time.Second}, &fakeClient{name: "b"}
+		c := New(backends(a, b)...).WithAttemptTimeout(20 * time.Millisecond)
+
+		var trace Trace
+		releases, err := c.GetPackageReleases(WithTrace(ctx, &trace), "pip")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b"}, releases)
+		assert.ErrorIs(t, trace.Attempts[0].Err, context.DeadlineExceeded)
+		assert.Equal(t, 1, c.Status()[0].Failures)
+	})
+
+	t.Run("所有后端都失败", func(t *testing.T) {
+		c := New(backends(&fakeClient{name: "a", err: down}, &fakeClient{name: "b", err: down})...)
+
+		_, err := c.GetPackageReleases(ctx, "pip")
+		var failed *Error
+		require.ErrorAs(t, err, &failed)
+		assert.Equal(t, "GetPackageReleases", failed.Op)
+		assert.Len(t, failed.Attempts, 2)
+		assert.ErrorIs(t, err, down)
+		assert.Contains(t, err.Error(), "a: 连接被拒绝")
+	})
+
+	t.Run("所有后端都不存在时可以识别为ErrNotFound", func(t *testing.T) {
+		notFound := fmt.Errorf("%w: pip", client.ErrNotFound)
+		c := New(backends(&fakeClient{name: "a", err: notFound}, &fakeClient{name: "b", err: notFound})...)
+
+		_, err := c.GetPackageReleases(ctx, "pip")
+		assert.ErrorIs(t, err, client.ErrNotFound)
+		assert.Equal(t, 0, c.Status()[0].Failures)
+	})
+
+	t.Run("不存在时不切换", func(t *testing.T) {
+		a, b := &fakeClient{name: "a", err: client.ErrNotFound}, &fakeClient{name: "b"}
+		c := New(backends(a, b)...).WithNotFoundFailover(false)
+
+		_, err := c.GetPackageReleases(ctx, "pip")
+		assert.ErrorIs(t, err, client.ErrNotFound)
+		assert.Equal(t, 0, b.numCalls())
+	})
+
+	t.Run("后端不支持GetCoreMetadata", func(t *testing.T) {
+		c := New(backends(&fakeClient{name: "a"})...)
+
+		_, err := c.GetCoreMetadata(ctx, &models.ReleaseFile{Filename: "pip-23.3-py3-none-any.whl"})
+		assert.ErrorIs(t, err, client.ErrUnsupported)
+	})
+
+	t.Run("没有后端", func(t *testing.T) {
+		_, err := New().GetPackageReleases(ctx, "pip")
+		assert.ErrorIs(t, err, ErrNoBackends)
+	})
+
+	t.Run("调用方取消时不切换", func(t *testing.T) {
+		a, b := &fakeClient{name: "a", delay: time.Second}, &fakeClient{name: "b"}
+		c := New(backends(a, b)...)
+
+		cancelCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err := c.GetPackageReleases(cancelCtx, "pip")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, 0, b.numCalls())
+	})
+}
+
+func TestClientBreaker(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	a, b := &fakeClient{name: "a", err: errors.New("HTTP 503")}, &fakeClient{name: "b"}
+	c := New(backends(a, b)...).WithBreaker(2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		_, err := c.GetPackageReleases(ctx, "pip")
+		require.NoError(t, err)
+	}
+	status := c.Status()
+	assert.True(t, status[0].Open)
+	assert.Equal(t, 2, status[0].Failures)
+	assert.Equal(t, now.Add(time.Minute), status[0].OpenUntil)
+	assert.False(t, status[1].Open)
+
+	t.Run("冷却期内跳过", func(t *testing.T) {
+		var trace Trace
+		_, err := c.GetPackageReleases(WithTrace(ctx, &trace), "pip")
+		require.NoError(t, err)
+		assert.Equal(t, 2, a.numCalls())
+		assert.ErrorIs(t, trace.Attempts[0].Err, ErrCircuitOpen)
+		assert.Equal(t, "b", trace.Backend)
+	})
+
+	t.Run("冷却期后试探恢复", func(t *testing.T) {
+		now = now.Add(2 * time.Minute)
+		a.err = nil
+
+		var trace Trace
+		releases, err := c.GetPackageReleases(WithTrace(ctx, &trace), "pip")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a"}, releases)
+		assert.False(t, c.Status()[0].Open)
+		assert.Equal(t, 0, c.Status()[0].Failures)
+	})
+
+	t.Run("所有后端都熔断", func(t *testing.T) {
+		down := New(backends(&fakeClient{name: "a", err: errors.New("HTTP 502")})...).WithBreaker(1, time.Minute)
+		_, err := down.GetPackageReleases(ctx, "pip")
+		require.Error(t, err)
+
+		_, err = down.GetPackageReleases(ctx, "pip")
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+	})
+}
+
+func TestClientHedge(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("慢请求触发对冲", func(t *testing.T) {
+		a, b := &fakeClient{name: "a", delay: time.Second}, &fakeClient{name: "b"}
+		c := New(backends(a, b)...).WithHedge(20 * time.Millisecond)
+
+		var trace Trace
+		start := time.Now()
+		releases, err := c.GetPackageReleases(WithTrace(ctx, &trace), "pip")
+		require.NoError(t, err)
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+		assert.Equal(t, []string{"b"}, releases)
+		require.Len(t, trace.Attempts, 1)
+		assert.True(t, trace.Attempts[0].Hedged)
+
+		// 落败的请求被取消，不计入熔断失败
+		assert.Eventually(t, func() bool { return a.numCalls() == 1 }, time.Second, 10*time.Millisecond)
+		assert.Equal(t, 0, c.Status()[0].Failures)
+	})
+
+	t.Run("请求足够快时不对冲", func(t *testing.T) {
+		a, b := &fakeClient{name: "a"}, &fakeClient{name: "b"}
+		c := New(backends(a, b)...).WithHedge(time.Second)
+
+		_, err := c.GetPackageReleases(ctx, "pip")
+		require.NoError(t, err)
+		assert.Equal(t, 0, b.numCalls())
+	})
+}
+
+func TestNewFromURLs(t *testing.T) {
+	var hits int32
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"info": {"name": "pip", "version": "24.0"}, "releases": {"24.0": []}}`)
+	}))
+	defer healthy.Close()
+
+	options := client.NewOptions().WithMaxRetries(1)
+	c := NewFromURLs([]string{broken.URL, healthy.URL}, options)
+
+	var trace Trace
+	pkg, err := c.GetPackageInfo(WithTrace(context.Background(), &trace), "pip")
+	require.NoError(t, err)
+	assert.Equal(t, "24.0", pkg.Info.Version)
+	assert.Equal(t, nameOf(healthy.URL), trace.Backend)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+
+	// 每个后端使用配置的副本
+	assert.Equal(t, client.DefaultBaseURL, options.BaseURL)
+}
+
+func TestNewFromMirrors(t *testing.T) {
+	c := NewFromMirrors(mirrors.Builtin()[:2])
+	status := c.Status()
+	require.Len(t, status, 2)
+	assert.Equal(t, "official", status[0].Name)
+	assert.Equal(t, "tsinghua", status[1].Name)
+}
+
+func TestNameOf(t *testing.T) {
+	assert.Equal(t, "mirrors.aliyun.com/pypi", nameOf("https://mirrors.aliyun.com/pypi/"))
+	assert.Equal(t, "pypi.org", nameOf("https://pypi.org"))
+	assert.Equal(t, "not a url", nameOf("not a url"))
+}
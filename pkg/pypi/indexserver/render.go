@@ -0,0 +1,321 @@
+package indexserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/version"
+)
+
+// APIVersion 生成的Simple API版本，1.1包含PEP 700的versions、size和upload-time字段
+const APIVersion = "1.1"
+
+// Simple API的媒体类型（PEP 691）
+const (
+	// ContentTypeJSON JSON格式
+	ContentTypeJSON = "application/vnd.pypi.simple.v1+json"
+
+	// ContentTypeHTML HTML格式
+	ContentTypeHTML = "application/vnd.pypi.simple.v1+html"
+
+	// ContentTypeLegacyHTML PEP 503的HTML格式
+	ContentTypeLegacyHTML = "text/html"
+)
+
+// contentTypes 可以响应的媒体类型，Accept中质量值相同时靠前的优先，与PyPI一样默认返回HTML
+var contentTypes = []string{
+	ContentTypeLegacyHTML,
+	ContentTypeHTML,
+	"application/vnd.pypi.simple.latest+html",
+	ContentTypeJSON,
+	"application/vnd.pypi.simple.latest+json",
+}
+
+// formatAliases format查询参数的取值，与PyPI相同，优先于Accept请求头
+var formatAliases = map[string]string{
+	"json": ContentTypeJSON,
+	"html": ContentTypeHTML,
+}
+
+// Negotiate 按format查询参数或Accept请求头选择响应的媒体类型，没有可接受的类型时返回false
+// latest别名会被解析为对应的v1类型
+func Negotiate(r *http.Request) (string, bool) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		if contentType, ok := formatAliases[format]; ok {
+			return contentType, true
+		}
+		for _, candidate := range contentTypes {
+			if format == candidate {
+				return resolveLatest(candidate), true
+			}
+		}
+		return "", false
+	}
+
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return ContentTypeLegacyHTML, true
+	}
+
+	best, bestQ, bestSpecificity := "", 0.0, -1
+	for _, candidate := range contentTypes {
+		q, specificity := acceptQuality(accept, candidate)
+		if q > bestQ || (q == bestQ && q > 0 && specificity > bestSpecificity) {
+			best, bestQ, bestSpecificity = candidate, q, specificity
+		}
+	}
+	if best == "" {
+		return "", false
+	}
+	return resolveLatest(best), true
+}
+
+// acceptQuality 返回Accept中与mediaType匹配的最具体条目的质量值及其具体程度（2精确、1 type/*、0 */*）
+func acceptQuality(accept, mediaType string) (float64, int) {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	q, specificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		pattern := strings.ToLower(strings.TrimSpace(fields[0]))
+		s := -1
+		switch pattern {
+		case mediaType:
+			s = 2
+		case mainType + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		}
+		if s < specificity || s < 0 {
+			continue
+		}
+		value := 1.0
+		for _, param := range fields[1:] {
+			key, v, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.TrimSpace(key) == "q" {
+				if parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+					value = parsed
+				}
+			}
+		}
+		q, specificity = value, s
+	}
+	return q, specificity
+}
+
+// responseType 返回响应的媒体类型，没有可接受的类型时与PyPI一样返回PEP 503的HTML（PEP 691允许的默认类型）
+func responseType(r *http.Request) string {
+	if contentType, ok := Negotiate(r); ok {
+		return contentType
+	}
+	return ContentTypeLegacyHTML
+}
+
+func resolveLatest(contentType string) string {
+	switch contentType {
+	case "application/vnd.pypi.simple.latest+json":
+		return ContentTypeJSON
+	case "application/vnd.pypi.simple.latest+html":
+		return ContentTypeHTML
+	}
+	return contentType
+}
+
+// WriteIndex 按协商的媒体类型写入Simple API根页面
+func WriteIndex(w http.ResponseWriter, r *http.Request, projects []string) {
+	contentType := responseType(r)
+	body, err := RenderIndex(contentType, projects)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeBody(w, r, contentType, body)
+}
+
+// RenderIndex 生成Simple API根页面，contentType为ContentTypeJSON时生成JSON，否则生成HTML
+//
+// 参数:
+//   - contentType: 媒体类型
+//   - projects: 项目名，HTML页面中的链接使用规范化后的名称
+//
+// 返回值:
+//   - []byte: 页面内容
+//   - error: JSON编码失败时返回
+func RenderIndex(contentType string, projects []string) ([]byte, error) {
+	if contentType == ContentTypeJSON {
+		index := models.SimpleIndex{Meta: models.SimpleMeta{APIVersion: APIVersion}, Projects: []models.SimpleIndexProject{}}
+		for _, name := range projects {
+			index.Projects = append(index.Projects, models.SimpleIndexProject{Name: name})
+		}
+		return json.Marshal(index)
+	}
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n  <head>\n")
+	fmt.Fprintf(&b, "    <meta name=\"pypi:repository-version\" content=\"%s\">\n", APIVersion)
+	b.WriteString("    <title>Simple index</title>\n  </head>\n  <body>\n")
+	for _, name := range projects {
+		normalized := models.NormalizeName(name)
+		fmt.Fprintf(&b, "    <a href=\"%s/\">%s</a>\n", html.EscapeString(normalized), html.EscapeString(name))
+	}
+	b.WriteString("  </body>\n</html>\n")
+	return []byte(b.String()), nil
+}
+
+// WriteProject 按协商的媒体类型写入项目页面
+// project.Meta.LastSerial大于0时同时写入X-PyPI-Last-Serial响应头
+func WriteProject(w http.ResponseWriter, r *http.Request, project *models.SimpleProject) {
+	contentType := responseType(r)
+	if project.Meta.LastSerial > 0 {
+		w.Header().Set("X-PyPI-Last-Serial", strconv.Itoa(project.Meta.LastSerial))
+	}
+	body, err := RenderProject(contentType, project)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeBody(w, r, contentType, body)
+}
+
+// RenderProject 生成项目页面，contentType为ContentTypeJSON时生成JSON，否则生成HTML
+// project.Meta.LastSerial大于0时HTML页面末尾带有bandersnatch格式的序列号注释
+//
+// 参数:
+//   - contentType: 媒体类型
+//   - project: 项目页面
+//
+// 返回值:
+//   - []byte: 页面内容
+//   - error: JSON编码失败时返回
+func RenderProject(contentType string, project *models.SimpleProject) ([]byte, error) {
+	if contentType == ContentTypeJSON {
+		page := *project
+		if page.Meta.APIVersion == "" {
+			page.Meta.APIVersion = APIVersion
+		}
+		if page.Files == nil {
+			page.Files = []models.SimpleFile{}
+		}
+		return json.Marshal(page)
+	}
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n  <head>\n")
+	fmt.Fprintf(&b, "    <meta name=\"pypi:repository-version\" content=\"%s\">\n", APIVersion)
+	fmt.Fprintf(&b, "    <title>Links for %s</title>\n  </head>\n  <body>\n", html.EscapeString(project.Name))
+	fmt.Fprintf(&b, "    <h1>Links for %s</h1>\n", html.EscapeString(project.Name))
+	for _, f := range project.Files {
+		b.WriteString("    " + fileAnchor(f) + "<br />\n")
+	}
+	b.WriteString("  </body>\n</html>\n")
+	if project.Meta.LastSerial > 0 {
+		fmt.Fprintf(&b, "<!--SERIAL %d-->\n", project.Meta.LastSerial)
+	}
+	return []byte(b.String()), nil
+}
+
+// fileAnchor 生成PEP 503格式的文件链接，哈希值写在URL片段中，其余属性写在data-*属性中
+func fileAnchor(f models.SimpleFile) string {
+	href := f.URL
+	if algorithm, digest := preferredHash(f.Hashes); digest != "" {
+		href += "#" + algorithm + "=" + digest
+	}
+	attrs := []string{`href="` + html.EscapeString(href) + `"`}
+	if f.RequiresPython != "" {
+		attrs = append(attrs, `data-requires-python="`+html.EscapeString(f.RequiresPython)+`"`)
+	}
+	if f.Yanked.Yanked {
+		attrs = append(attrs, `data-yanked="`+html.EscapeString(f.Yanked.Reason)+`"`)
+	}
+	if f.HasCoreMetadata() {
+		value := "true"
+		if algorithm, digest := preferredHash(f.CoreMetadataHashes()); digest != "" {
+			value = algorithm + "=" + digest
+		}
+		// 同时写入PEP 658的旧属性名，兼容较早的pip
+		attrs = append(attrs, `data-core-metadata="`+html.EscapeString(value)+`"`,
+			`data-dist-info-metadata="`+html.EscapeString(value)+`"`)
+	}
+	return "<a " + strings.Join(attrs, " ") + ">" + html.EscapeString(f.Filename) + "</a>"
+}
+
+// preferredHash 选择写入URL片段的哈希，优先sha256，其次按算法名排序的第一个
+func preferredHash(hashes map[string]string) (string, string) {
+	if digest := hashes["sha256"]; digest != "" {
+		return "sha256", digest
+	}
+	algorithms := make([]string, 0, len(hashes))
+	for algorithm, digest := range hashes {
+		if digest != "" {
+			algorithms = append(algorithms, algorithm)
+		}
+	}
+	if len(algorithms) == 0 {
+		return "", ""
+	}
+	sort.Strings(algorithms)
+	return algorithms[0], hashes[algorithms[0]]
+}
+
+// ProjectFromPackage 将JSON API格式的项目信息转换为Simple API的项目页面
+// 文件按版本（PEP 440）排序，同一版本内保持原顺序；fileURL返回每个文件在页面中的链接，为nil时使用文件原来的URL
+func ProjectFromPackage(pkg *models.Package, fileURL func(f *models.ReleaseFile) string) *models.SimpleProject {
+	name := ""
+	if pkg.Info != nil {
+		name = models.NormalizeName(pkg.Info.Name)
+	}
+	project := &models.SimpleProject{
+		Meta:     models.SimpleMeta{APIVersion: APIVersion, LastSerial: pkg.LastSerial},
+		Name:     name,
+		Files:    []models.SimpleFile{},
+		Versions: make([]string, 0, len(pkg.Releases)),
+	}
+	for v := range pkg.Releases {
+		project.Versions = append(project.Versions, v)
+	}
+	version.SortStrings(project.Versions)
+
+	for _, v := range project.Versions {
+		for _, f := range pkg.Releases[v] {
+			url := f.URL
+			if fileURL != nil {
+				url = fileURL(f)
+			}
+			hashes := map[string]string{}
+			for algorithm, digest := range f.Digests.All() {
+				if algorithm != "md5" {
+					hashes[algorithm] = digest
+				}
+			}
+			project.Files = append(project.Files, models.SimpleFile{
+				Filename:       f.Filename,
+				URL:            url,
+				Hashes:         hashes,
+				RequiresPython: f.RequiresPython,
+				Yanked:         models.YankedStatus{Yanked: f.Yanked, Reason: f.YankedReason},
+				Size:           f.Size,
+				UploadTime:     f.UploadTimeISO8601,
+			})
+		}
+	}
+	return project
+}
+
+func writeBody(w http.ResponseWriter, r *http.Request, contentType string, body []byte) {
+	if contentType == ContentTypeLegacyHTML {
+		contentType += "; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Add("Vary", "Accept")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(body)
+	}
+}
@@ -0,0 +1,99 @@
+package indexserver
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		name   string
+		target string
+		accept string
+		want   string
+		ok     bool
+	}{
+		{"没有Accept时返回HTML", "/simple/", "", ContentTypeLegacyHTML, true},
+		{"精确匹配JSON", "/simple/", ContentTypeJSON, ContentTypeJSON, true},
+		{"latest别名", "/simple/", "application/vnd.pypi.simple.latest+json", ContentTypeJSON, true},
+		{"按质量值选择", "/simple/", "application/vnd.pypi.simple.v1+json;q=0.5, text/html;q=0.1", ContentTypeJSON, true},
+		{"pip的Accept", "/simple/", "application/vnd.pypi.simple.v1+json, application/vnd.pypi.simple.v1+html; q=0.1, text/html; q=0.01", ContentTypeJSON, true},
+		{"通配符默认HTML", "/simple/", "*/*", ContentTypeLegacyHTML, true},
+		{"具体类型优先于通配符", "/simple/", "*/*;q=0.8, application/vnd.pypi.simple.v1+json;q=0.8", ContentTypeJSON, true},
+		{"q=0表示不接受", "/simple/", "text/html;q=0, application/*", ContentTypeHTML, true},
+		{"format参数优先", "/simple/?format=json", "text/html", ContentTypeJSON, true},
+		{"format参数使用媒体类型", "/simple/?format=application/vnd.pypi.simple.v1%2Bhtml", "", ContentTypeHTML, true},
+		{"不支持的format", "/simple/?format=xml", "", "", false},
+		{"不支持的类型", "/simple/", "application/xml", "", false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", c.target, nil)
+			if c.accept != "" {
+				r.Header.Set("Accept", c.accept)
+			}
+			got, ok := Negotiate(r)
+			assert.Equal(t, c.ok, ok)
+			assert.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestProjectFromPackage(t *testing.T) {
+	pkg := &models.Package{
+		Info: &models.PackageInfo{Name: "Demo.Pkg"},
+		Releases: map[string][]*models.ReleaseFile{
+			"1.10": {{Filename: "demo-1.10.tar.gz", URL: "https://files.example.com/demo-1.10.tar.gz"}},
+			"1.9":  {{Filename: "demo-1.9.tar.gz", URL: "https://files.example.com/demo-1.9.tar.gz", Digests: models.ReleaseDigests{MD5: "m"}}},
+			"2.0":  {},
+		},
+	}
+
+	t.Run("保留原URL", func(t *testing.T) {
+		project := ProjectFromPackage(pkg, nil)
+		assert.Equal(t, "demo-pkg", project.Name)
+		assert.Equal(t, []string{"1.9", "1.10", "2.0"}, project.Versions)
+		assert.Equal(t, "demo-1.9.tar.gz", project.Files[0].Filename)
+		assert.Equal(t, "https://files.example.com/demo-1.9.tar.gz", project.Files[0].URL)
+		assert.Empty(t, project.Files[0].Hashes)
+	})
+
+	t.Run("改写文件链接", func(t *testing.T) {
+		project := ProjectFromPackage(pkg, func(f *models.ReleaseFile) string { return "/files/" + f.Filename })
+		assert.Equal(t, "/files/demo-1.10.tar.gz", project.Files[1].URL)
+	})
+}
+
+func TestFileAnchor(t *testing.T) {
+	assert.Equal(t, `<a href="demo-1.0.tar.gz#blake2b_256=x">demo-1.0.tar.gz</a>`,
+		fileAnchor(models.SimpleFile{Filename: "demo-1.0.tar.gz", URL: "demo-1.0.tar.gz", Hashes: map[string]string{"blake2b_256": "x"}}))
+	assert.Equal(t, `<a href="a.whl" data-yanked="" data-core-metadata="true" data-dist-info-metadata="true">a.whl</a>`,
+		fileAnchor(models.SimpleFile{Filename: "a.whl", URL: "a.whl", Yanked: models.YankedStatus{Yanked: true},
+			CoreMetadata: &models.MetadataHashes{Available: true}}))
+}
+
+func TestRender(t *testing.T) {
+	t.Run("项目列表", func(t *testing.T) {
+		body, err := RenderIndex(ContentTypeHTML, []string{"Demo.Pkg"})
+		assert.NoError(t, err)
+		assert.Contains(t, string(body), `<a href="demo-pkg/">Demo.Pkg</a>`)
+
+		body, err = RenderIndex(ContentTypeJSON, nil)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"meta": {"api-version": "1.1"}, "projects": []}`, string(body))
+	})
+
+	t.Run("项目页面带序列号注释", func(t *testing.T) {
+		project := &models.SimpleProject{Meta: models.SimpleMeta{LastSerial: 7}, Name: "demo"}
+		body, err := RenderProject(ContentTypeHTML, project)
+		assert.NoError(t, err)
+		assert.Contains(t, string(body), "<!--SERIAL 7-->")
+
+		body, err = RenderProject(ContentTypeJSON, project)
+		assert.NoError(t, err)
+		assert.Contains(t, string(body), `"files":[]`)
+	})
+}
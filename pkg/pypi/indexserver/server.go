@@ -0,0 +1,343 @@
+package indexserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/policy"
+)
+
+// Server 基于Storage的PEP 503/691索引服务器，实现http.Handler
+//
+// 提供以下地址：
+//
+//	/simple/                       项目列表
+//	/simple/<项目>/                 项目页面，非规范化的项目名重定向到规范化名称
+//	/pypi/<项目>/json               项目信息，结构与models.Package相同
+//	/files/<项目>/<文件名>           发布文件，存储中没有文件内容时重定向到文件原来的URL
+//	/files/<项目>/<文件名>.metadata  核心元数据（PEP 658）
+//
+// 项目页面中的文件链接都是相对地址，因此可以通过http.StripPrefix挂载在任意路径下
+//
+// 设置策略后，被屏蔽的项目不出现在项目列表中，被屏蔽的版本和文件不出现在项目页面和JSON API中，
+// 请求被屏蔽的项目或文件时返回403，响应体中说明违反的规则
+type Server struct {
+	storage       Storage
+	policy        *policy.Policy
+	localFileURLs bool
+}
+
+// NewServer 创建索引服务器
+//
+// 参数:
+//   - storage: 存储后端，实现MetadataStorage时提供核心元数据，实现FileStorage时提供文件下载
+//
+// 返回值:
+//   - *Server: 索引服务器
+//
+// 使用示例:
+//
+//	storage := indexserver.NewDirStorage("/var/lib/pypi")
+//	http.ListenAndServe(":8080", indexserver.NewServer(storage))
+//	// pip install --index-url http://localhost:8080/simple/ requests
+func NewServer(storage Storage) *Server {
+	return &Server{storage: storage}
+}
+
+// WithPolicy 设置过滤项目、版本和文件的策略，为nil时不过滤
+func (s *Server) WithPolicy(p *policy.Policy) *Server {
+	s.policy = p
+	return s
+}
+
+// WithLocalFileURLs 设置JSON API中的文件URL是否改写为服务器自身的 /files/ 地址
+// 默认返回存储中的原始URL；代理等不希望客户端绕过服务器直接下载的场景应当开启
+func (s *Server) WithLocalFileURLs(local bool) *Server {
+	s.localFileURLs = local
+	return s
+}
+
+// ServeHTTP 实现http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.URL.Path == "/simple/" || r.URL.Path == "/simple":
+		s.serveIndex(w, r)
+	case segments[0] == "simple" && len(segments) == 2:
+		s.serveProject(w, r, segments[1])
+	case segments[0] == "pypi" && len(segments) == 3 && segments[2] == "json":
+		s.servePackage(w, r, segments[1])
+	case segments[0] == "files" && len(segments) == 3:
+		s.serveFile(w, r, segments[1], segments[2])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveIndex(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		redirect(w, r, "simple/")
+		return
+	}
+	projects, err := s.storage.Projects(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if s.policy != nil {
+		allowed := make([]string, 0, len(projects))
+		for _, name := range projects {
+			if s.policy.AllowsProject(name) {
+				allowed = append(allowed, name)
+			}
+		}
+		projects = allowed
+	}
+	WriteIndex(w, r, projects)
+}
+
+func (s *Server) serveProject(w http.ResponseWriter, r *http.Request, name string) {
+	// PEP 503：非规范化的项目名重定向到规范化名称，并统一以 "/" 结尾
+	if normalized := models.NormalizeName(name); normalized != name || !strings.HasSuffix(r.URL.Path, "/") {
+		redirect(w, r, normalized+"/")
+		return
+	}
+
+	pkg, ok := s.visiblePackage(w, r, name)
+	if !ok {
+		return
+	}
+	project := ProjectFromPackage(pkg, func(f *models.ReleaseFile) string {
+		return "../../files/" + url.PathEscape(name) + "/" + url.PathEscape(f.Filename)
+	})
+	if project.Name == "" {
+		project.Name = name
+	}
+	s.addCoreMetadata(r.Context(), name, project)
+	WriteProject(w, r, project)
+}
+
+// addCoreMetadata 为存储中有核心元数据的文件声明core-metadata及其sha256
+func (s *Server) addCoreMetadata(ctx context.Context, name string, project *models.SimpleProject) {
+	store, ok := s.storage.(MetadataStorage)
+	if !ok {
+		return
+	}
+	for i := range project.Files {
+		data, err := store.CoreMetadata(ctx, name, project.Files[i].Filename)
+		if err != nil {
+			continue
+		}
+		sum := sha256.Sum256(data)
+		project.Files[i].CoreMetadata = &models.MetadataHashes{
+			Available: true,
+			Hashes:    map[string]string{"sha256": hex.EncodeToString(sum[:])},
+		}
+	}
+}
+
+// visiblePackage 读取项目信息并按策略过滤，项目不存在或被屏蔽时写入响应并返回false
+func (s *Server) visiblePackage(w http.ResponseWriter, r *http.Request, name string) (*models.Package, bool) {
+	pkg, err := s.storage.Package(r.Context(), name)
+	if err != nil {
+		writeError(w, err)
+		return nil, false
+	}
+	if s.policy == nil {
+		return pkg, true
+	}
+	if verdict := s.policy.EvaluateProject(pkg); !verdict.Allowed() {
+		writeBlocked(w, verdict)
+		return nil, false
+	}
+	filtered, _ := s.policy.Filter(pkg)
+	return filtered, true
+}
+
+func (s *Server) servePackage(w http.ResponseWriter, r *http.Request, name string) {
+	pkg, ok := s.visiblePackage(w, r, name)
+	if !ok {
+		return
+	}
+	if s.localFileURLs {
+		// JSON API位于 /pypi/<项目>/json，向上两级到达根目录
+		pkg = withFileURLs(pkg, "../../files/"+url.PathEscape(models.NormalizeName(name))+"/")
+	}
+	data, err := json.Marshal(pkg)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if pkg.LastSerial > 0 {
+		w.Header().Set("X-PyPI-Last-Serial", strconv.Itoa(pkg.LastSerial))
+	}
+	writeBody(w, r, "application/json", data)
+}
+
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request, name, filename string) {
+	ctx := r.Context()
+	if s.policy != nil && !s.checkFile(w, r, name, filename) {
+		return
+	}
+	if base, ok := cutSuffix(filename, MetadataSuffix); ok {
+		if store, ok := s.storage.(MetadataStorage); ok {
+			data, err := store.CoreMetadata(ctx, name, base)
+			if err == nil {
+				writeBody(w, r, "application/octet-stream", data)
+				return
+			}
+			if !errors.Is(err, ErrNotFound) {
+				writeError(w, err)
+				return
+			}
+		}
+	}
+
+	if store, ok := s.storage.(FileStorage); ok {
+		f, err := store.OpenFile(ctx, name, filename)
+		if err == nil {
+			defer f.Close()
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.WriteHeader(http.StatusOK)
+			if r.Method != http.MethodHead {
+				io.Copy(w, f)
+			}
+			return
+		}
+		if !errors.Is(err, ErrNotFound) {
+			writeError(w, err)
+			return
+		}
+	}
+
+	// 存储中没有文件内容时重定向到文件原来的URL
+	pkg, err := s.storage.Package(ctx, name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if _, f := findFile(pkg, filename); f != nil && f.URL != "" {
+		http.Redirect(w, r, f.URL, http.StatusFound)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+// checkFile 按策略检查文件（或其核心元数据）是否允许下载，不允许时写入响应并返回false
+// 设置策略后，项目信息中没有的文件一律视为不存在
+func (s *Server) checkFile(w http.ResponseWriter, r *http.Request, name, filename string) bool {
+	pkg, err := s.storage.Package(r.Context(), name)
+	if err != nil {
+		writeError(w, err)
+		return false
+	}
+	ver, file := findFile(pkg, filename)
+	if file == nil {
+		if base, ok := cutSuffix(filename, MetadataSuffix); ok {
+			ver, file = findFile(pkg, base)
+		}
+	}
+	if file == nil {
+		http.NotFound(w, r)
+		return false
+	}
+	if verdict := s.policy.EvaluateFile(pkg, ver, file); !verdict.Allowed() {
+		writeBlocked(w, verdict)
+		return false
+	}
+	return true
+}
+
+// findFile 在项目信息中查找发布文件及其版本
+// withFileURLs 返回文件URL改写为prefix加文件名的项目信息副本，不修改存储中的信息
+func withFileURLs(pkg *models.Package, prefix string) *models.Package {
+	rewrite := func(files []*models.ReleaseFile) []*models.ReleaseFile {
+		if files == nil {
+			return nil
+		}
+		rewritten := make([]*models.ReleaseFile, len(files))
+		for i, f := range files {
+			local := *f
+			local.URL = prefix + url.PathEscape(f.Filename)
+			rewritten[i] = &local
+		}
+		return rewritten
+	}
+
+	copied := *pkg
+	copied.Urls = rewrite(pkg.Urls)
+	if pkg.Releases != nil {
+		copied.Releases = make(map[string][]*models.ReleaseFile, len(pkg.Releases))
+		for v, files := range pkg.Releases {
+			copied.Releases[v] = rewrite(files)
+		}
+	}
+	return &copied
+}
+
+func findFile(pkg *models.Package, filename string) (string, *models.ReleaseFile) {
+	for ver, files := range pkg.Releases {
+		for _, f := range files {
+			if f.Filename == filename {
+				return ver, f
+			}
+		}
+	}
+	return "", nil
+}
+
+// writeBlocked 返回403并说明违反的策略规则
+func writeBlocked(w http.ResponseWriter, verdict *policy.Verdict) {
+	http.Error(w, strings.TrimSuffix(verdict.Explain(), "\n"), http.StatusForbidden)
+}
+
+// redirect 重定向到与当前路径同级的target
+// 使用相对地址以支持挂载在任意路径下，http.Redirect会按去掉前缀后的路径将其转换为绝对地址，因此直接写入Location
+func redirect(w http.ResponseWriter, r *http.Request, target string) {
+	location := "./" + target
+	if strings.HasSuffix(r.URL.Path, "/") {
+		location = "../" + target
+	}
+	if r.URL.RawQuery != "" {
+		location += "?" + r.URL.RawQuery
+	}
+	w.Header().Set("Location", location)
+	w.WriteHeader(http.StatusMovedPermanently)
+}
+
+// writeError 将存储的错误转换为HTTP状态码
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, "Not Found", http.StatusNotFound)
+	case errors.Is(err, ErrInvalidName):
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+	case errors.Is(err, ErrUnavailable):
+		http.Error(w, "Bad Gateway", http.StatusBadGateway)
+	default:
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// cutSuffix 与Go 1.20的strings.CutSuffix相同
+func cutSuffix(s, suffix string) (string, bool) {
+	if !strings.HasSuffix(s, suffix) {
+		return s, false
+	}
+	return s[:len(s)-len(suffix)], true
+}
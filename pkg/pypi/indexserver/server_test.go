@@ -0,0 +1,258 @@
+package indexserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/api"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/client"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMetadata = "Metadata-Version: 2.1\nName: demo-pkg\nVersion: 1.0\nRequires-Dist: requests>=2\n"
+
+func newTestStorage() *MemoryStorage {
+	storage := NewMemoryStorage()
+	storage.AddPackage(&models.Package{
+		Info:       &models.PackageInfo{Name: "Demo_Pkg", Version: "1.0"},
+		LastSerial: 42,
+		Releases: map[string][]*models.ReleaseFile{
+			"0.9": {{
+				Filename:     "demo_pkg-0.9.tar.gz",
+				URL:          "https://files.example.com/demo_pkg-0.9.tar.gz",
+				Digests:      models.ReleaseDigests{MD5: "m", SHA256: "aaa"},
+				Yanked:       true,
+				YankedReason: "broken <build>",
+			}},
+			"1.0": {{
+				Filename:          "demo_pkg-1.0-py3-none-any.whl",
+				URL:               "https://files.example.com/demo_pkg-1.0-py3-none-any.whl",
+				Digests:           models.ReleaseDigests{SHA256: "bbb"},
+				RequiresPython:    ">=3.8",
+				Size:              1234,
+				UploadTimeISO8601: "2024-01-01T00:00:00.000000Z",
+			}},
+		},
+	})
+	storage.AddCoreMetadata("demo-pkg", "demo_pkg-1.0-py3-none-any.whl", []byte(testMetadata))
+	storage.AddFile("demo-pkg", "demo_pkg-1.0-py3-none-any.whl", []byte("wheel"))
+	return storage
+}
+
+func get(t *testing.T, server *httptest.Server, path, accept string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+	require.NoError(t, err)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	transport := &http.Transport{}
+	resp, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestServer(t *testing.T) {
+	server := httptest.NewServer(NewServer(newTestStorage()))
+	defer server.Close()
+
+	t.Run("HTML项目列表", func(t *testing.T) {
+		resp := get(t, server, "/simple/", "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+		assert.Contains(t, readBody(t, resp), `<a href="demo-pkg/">demo-pkg</a>`)
+	})
+
+	t.Run("没有可接受的类型时返回HTML", func(t *testing.T) {
+		resp := get(t, server, "/simple/", "application/json")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+	})
+
+	t.Run("JSON项目列表", func(t *testing.T) {
+		resp := get(t, server, "/simple/", ContentTypeJSON)
+		assert.Equal(t, ContentTypeJSON, resp.Header.Get("Content-Type"))
+		var index models.SimpleIndex
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&index))
+		assert.Equal(t, APIVersion, index.Meta.APIVersion)
+		assert.Equal(t, []models.SimpleIndexProject{{Name: "demo-pkg"}}, index.Projects)
+	})
+
+	t.Run("HTML项目页面", func(t *testing.T) {
+		resp := get(t, server, "/simple/demo-pkg/", "text/html")
+		assert.Equal(t, "42", resp.Header.Get("X-PyPI-Last-Serial"))
+		body := readBody(t, resp)
+
+		sum := sha256.Sum256([]byte(testMetadata))
+		metadataHash := hex.EncodeToString(sum[:])
+		assert.Contains(t, body, `<a href="../../files/demo-pkg/demo_pkg-0.9.tar.gz#sha256=aaa" data-yanked="broken &lt;build&gt;">demo_pkg-0.9.tar.gz</a>`)
+		assert.Contains(t, body, `<a href="../../files/demo-pkg/demo_pkg-1.0-py3-none-any.whl#sha256=bbb" data-requires-python="&gt;=3.8" `+
+			`data-core-metadata="sha256=`+metadataHash+`" data-dist-info-metadata="sha256=`+metadataHash+`">`)
+		assert.Contains(t, body, "<!--SERIAL 42-->")
+		assert.Less(t, strings.Index(body, "demo_pkg-0.9"), strings.Index(body, "demo_pkg-1.0"))
+	})
+
+	t.Run("JSON项目页面", func(t *testing.T) {
+		resp := get(t, server, "/simple/demo-pkg/?format=json", "")
+		var project models.SimpleProject
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&project))
+		assert.Equal(t, "demo-pkg", project.Name)
+		assert.Equal(t, 42, project.Meta.LastSerial)
+		assert.Equal(t, []string{"0.9", "1.0"}, project.Versions)
+		require.Len(t, project.Files, 2)
+
+		old, wheel := project.Files[0], project.Files[1]
+		assert.Equal(t, map[string]string{"sha256": "aaa"}, old.Hashes)
+		assert.Equal(t, models.YankedStatus{Yanked: true, Reason: "broken <build>"}, old.Yanked)
+		assert.False(t, old.HasCoreMetadata())
+		assert.True(t, wheel.HasCoreMetadata())
+		assert.Equal(t, ">=3.8", wheel.RequiresPython)
+		assert.Equal(t, int64(1234), wheel.Size)
+		assert.Equal(t, "2024-01-01T00:00:00.000000Z", wheel.UploadTime)
+	})
+
+	t.Run("非规范化的项目名重定向", func(t *testing.T) {
+		resp := get(t, server, "/simple/Demo_Pkg/", "")
+		assert.Equal(t, http.StatusMovedPermanently, resp.StatusCode)
+		assert.Equal(t, "../demo-pkg/", resp.Header.Get("Location"))
+
+		resp = get(t, server, "/simple/demo-pkg", "")
+		assert.Equal(t, "./demo-pkg/", resp.Header.Get("Location"))
+	})
+
+	t.Run("JSON API", func(t *testing.T) {
+		resp := get(t, server, "/pypi/Demo-Pkg/json", "")
+		assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		var pkg models.Package
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&pkg))
+		assert.Equal(t, "Demo_Pkg", pkg.Info.Name)
+		assert.Equal(t, 42, pkg.LastSerial)
+		assert.Equal(t, "bbb", pkg.Releases["1.0"][0].Digests.SHA256)
+	})
+
+	t.Run("JSON API中的文件URL指向服务器", func(t *testing.T) {
+		storage := newTestStorage()
+		local := httptest.NewServer(NewServer(storage).WithLocalFileURLs(true))
+		defer local.Close()
+
+		var pkg models.Package
+		require.NoError(t, json.NewDecoder(get(t, local, "/pypi/Demo-Pkg/json", "").Body).Decode(&pkg))
+		assert.Equal(t, "../../files/demo-pkg/demo_pkg-1.0-py3-none-any.whl", pkg.Releases["1.0"][0].URL)
+		assert.Equal(t, "../../files/demo-pkg/demo_pkg-0.9.tar.gz", pkg.Releases["0.9"][0].URL)
+
+		stored, err := storage.Package(context.Background(), "demo-pkg")
+		require.NoError(t, err)
+		assert.Equal(t, "https://files.example.com/demo_pkg-1.0-py3-none-any.whl", stored.Releases["1.0"][0].URL)
+	})
+
+	t.Run("文件和核心元数据", func(t *testing.T) {
+		resp := get(t, server, "/files/demo-pkg/demo_pkg-1.0-py3-none-any.whl", "")
+		assert.Equal(t, "wheel", readBody(t, resp))
+
+		resp = get(t, server, "/files/demo-pkg/demo_pkg-1.0-py3-none-any.whl.metadata", "")
+		assert.Equal(t, testMetadata, readBody(t, resp))
+
+		resp = get(t, server, "/files/demo-pkg/demo_pkg-0.9.tar.gz", "")
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+		assert.Equal(t, "https://files.example.com/demo_pkg-0.9.tar.gz", resp.Header.Get("Location"))
+
+		resp = get(t, server, "/files/demo-pkg/other.whl", "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("错误", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, get(t, server, "/simple/missing/", "").StatusCode)
+		assert.Equal(t, http.StatusNotFound, get(t, server, "/pypi/missing/json", "").StatusCode)
+		assert.Equal(t, http.StatusNotFound, get(t, server, "/other", "").StatusCode)
+
+		resp, err := http.Post(server.URL+"/simple/", "text/plain", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	})
+}
+
+func TestServerWithClient(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/index/", http.StripPrefix("/index", NewServer(newTestStorage())))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ctx := context.Background()
+	c := client.NewClient(client.NewOptions().WithBaseURL(server.URL + "/index").WithMaxRetries(1).WithTimeout(5 * time.Second))
+
+	names, err := c.GetAllPackages(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"demo-pkg"}, names)
+
+	pkg, err := c.GetPackageInfo(ctx, "demo-pkg")
+	require.NoError(t, err)
+	assert.Len(t, pkg.Releases, 2)
+
+	// 客户端通过Simple页面发现并下载PEP 658元数据
+	meta, err := c.(api.CoreMetadataClient).GetCoreMetadata(ctx, &models.ReleaseFile{Filename: "demo_pkg-1.0-py3-none-any.whl"})
+	require.NoError(t, err)
+	assert.Equal(t, "demo-pkg", meta.Name)
+	assert.Equal(t, []string{"requests>=2"}, meta.RequiresDist)
+}
+
+func TestServerPolicy(t *testing.T) {
+	storage := newTestStorage()
+	storage.AddPackage(&models.Package{Info: &models.PackageInfo{Name: "evil"}})
+	pol, err := policy.Parse([]byte("deny: [evil]\nhide_yanked: true\n"))
+	require.NoError(t, err)
+	server := httptest.NewServer(NewServer(storage).WithPolicy(pol))
+	defer server.Close()
+
+	t.Run("项目列表不包含被屏蔽的项目", func(t *testing.T) {
+		body := readBody(t, get(t, server, "/simple/", ""))
+		assert.Contains(t, body, "demo-pkg")
+		assert.NotContains(t, body, "evil")
+	})
+
+	t.Run("被屏蔽的项目返回403", func(t *testing.T) {
+		resp := get(t, server, "/simple/evil/", "")
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Contains(t, readBody(t, resp), "[deny] 项目 evil 在禁止列表中")
+		assert.Equal(t, http.StatusForbidden, get(t, server, "/pypi/evil/json", "").StatusCode)
+	})
+
+	t.Run("项目页面不包含被屏蔽的文件", func(t *testing.T) {
+		body := readBody(t, get(t, server, "/simple/demo-pkg/", ""))
+		assert.NotContains(t, body, "demo_pkg-0.9.tar.gz")
+		assert.Contains(t, body, "demo_pkg-1.0-py3-none-any.whl")
+
+		var pkg models.Package
+		require.NoError(t, json.NewDecoder(get(t, server, "/pypi/demo-pkg/json", "").Body).Decode(&pkg))
+		assert.NotContains(t, pkg.Releases, "0.9")
+	})
+
+	t.Run("下载被屏蔽的文件时说明原因", func(t *testing.T) {
+		resp := get(t, server, "/files/demo-pkg/demo_pkg-0.9.tar.gz", "")
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Equal(t, "demo_pkg-0.9.tar.gz 被策略禁止:\n  - [yanked] 文件已撤回: broken <build>\n", readBody(t, resp))
+
+		assert.Equal(t, "wheel", readBody(t, get(t, server, "/files/demo-pkg/demo_pkg-1.0-py3-none-any.whl", "")))
+		assert.Equal(t, testMetadata, readBody(t, get(t, server, "/files/demo-pkg/demo_pkg-1.0-py3-none-any.whl.metadata", "")))
+		assert.Equal(t, http.StatusNotFound, get(t, server, "/files/demo-pkg/unknown.whl", "").StatusCode)
+	})
+}
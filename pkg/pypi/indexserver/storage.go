@@ -0,0 +1,312 @@
+package indexserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+)
+
+var (
+	// ErrNotFound 表示存储中没有请求的项目或文件
+	ErrNotFound = errors.New("不存在")
+
+	// ErrInvalidName 表示项目名或文件名不能安全地用作路径
+	ErrInvalidName = errors.New("无效的名称")
+
+	// ErrUnavailable 表示存储依赖的上游服务不可用或返回了错误的数据，服务器返回502
+	ErrUnavailable = errors.New("上游不可用")
+)
+
+// Storage 索引服务器的存储后端，项目名均为规范化后的名称
+type Storage interface {
+	// Projects 返回所有项目名
+	Projects(ctx context.Context) ([]string, error)
+
+	// Package 返回项目的信息，结构与JSON API相同；项目不存在时返回ErrNotFound
+	Package(ctx context.Context, project string) (*models.Package, error)
+}
+
+// MetadataStorage 可以提供发布文件核心元数据（PEP 658）的存储后端
+// Storage实现该接口时，项目页面会为有元数据的文件声明core-metadata
+type MetadataStorage interface {
+	// CoreMetadata 返回发布文件的METADATA内容，没有时返回ErrNotFound
+	CoreMetadata(ctx context.Context, project, filename string) ([]byte, error)
+}
+
+// FileStorage 保存了发布文件本身的存储后端
+// Storage实现该接口时，服务器直接提供文件下载，否则重定向到发布文件原来的URL
+type FileStorage interface {
+	// OpenFile 打开发布文件，没有时返回ErrNotFound
+	OpenFile(ctx context.Context, project, filename string) (io.ReadCloser, error)
+}
+
+// MemoryStorage 保存在内存中的存储后端，并发安全
+type MemoryStorage struct {
+	mu       sync.RWMutex
+	packages map[string]*models.Package
+	metadata map[string][]byte
+	files    map[string][]byte
+}
+
+var (
+	_ Storage         = (*MemoryStorage)(nil)
+	_ MetadataStorage = (*MemoryStorage)(nil)
+	_ FileStorage     = (*MemoryStorage)(nil)
+)
+
+// NewMemoryStorage 创建空的内存存储
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		packages: map[string]*models.Package{},
+		metadata: map[string][]byte{},
+		files:    map[string][]byte{},
+	}
+}
+
+// AddPackage 添加或替换项目，项目名取自pkg.Info.Name
+func (s *MemoryStorage) AddPackage(pkg *models.Package) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.packages[models.NormalizeName(pkg.Info.Name)] = pkg
+}
+
+// AddCoreMetadata 添加发布文件的核心元数据
+func (s *MemoryStorage) AddCoreMetadata(project, filename string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metadata[fileKey(project, filename)] = data
+}
+
+// AddFile 添加发布文件的内容
+func (s *MemoryStorage) AddFile(project, filename string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[fileKey(project, filename)] = data
+}
+
+// Projects 返回所有项目名，已排序
+func (s *MemoryStorage) Projects(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.packages))
+	for name := range s.packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Package 返回项目的信息
+func (s *MemoryStorage) Package(ctx context.Context, project string) (*models.Package, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	pkg, ok := s.packages[models.NormalizeName(project)]
+	if !ok {
+		return nil, fmt.Errorf("项目 %s %w", project, ErrNotFound)
+	}
+	return pkg, nil
+}
+
+// CoreMetadata 返回发布文件的核心元数据
+func (s *MemoryStorage) CoreMetadata(ctx context.Context, project, filename string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.metadata[fileKey(project, filename)]
+	if !ok {
+		return nil, fmt.Errorf("%s 的元数据%w", filename, ErrNotFound)
+	}
+	return data, nil
+}
+
+// OpenFile 打开发布文件
+func (s *MemoryStorage) OpenFile(ctx context.Context, project, filename string) (io.ReadCloser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.files[fileKey(project, filename)]
+	if !ok {
+		return nil, fmt.Errorf("文件 %s %w", filename, ErrNotFound)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func fileKey(project, filename string) string {
+	return models.NormalizeName(project) + "/" + filename
+}
+
+// PackageFile DirStorage中保存项目信息的文件名
+const PackageFile = "package.json"
+
+// MetadataSuffix 核心元数据文件相对于发布文件的后缀（PEP 658）
+const MetadataSuffix = ".metadata"
+
+// DirStorage 保存在目录中的存储后端，每个项目一个子目录：
+//
+//	<root>/<项目名>/package.json                  项目信息，结构与JSON API相同
+//	<root>/<项目名>/<文件名>                        发布文件（可选）
+//	<root>/<项目名>/<文件名>.metadata               核心元数据（可选）
+type DirStorage struct {
+	root string
+}
+
+var (
+	_ Storage         = (*DirStorage)(nil)
+	_ MetadataStorage = (*DirStorage)(nil)
+	_ FileStorage     = (*DirStorage)(nil)
+)
+
+// NewDirStorage 创建使用root目录的存储，目录不存在时在首次写入时创建
+func NewDirStorage(root string) *DirStorage {
+	return &DirStorage{root: root}
+}
+
+// Root 返回存储的根目录
+func (s *DirStorage) Root() string {
+	return s.root
+}
+
+// Projects 返回所有包含package.json的项目目录名，已排序
+func (s *DirStorage) Projects(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(s.root)
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取存储目录失败: %w", err)
+	}
+	names := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(s.root, entry.Name(), PackageFile)); err == nil {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// Package 读取项目的package.json
+func (s *DirStorage) Package(ctx context.Context, project string) (*models.Package, error) {
+	data, err := s.read(project, PackageFile)
+	if err != nil {
+		return nil, err
+	}
+	var pkg models.Package
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("解析项目 %s 的信息失败: %w", project, err)
+	}
+	return &pkg, nil
+}
+
+// CoreMetadata 读取发布文件的 .metadata 文件
+func (s *DirStorage) CoreMetadata(ctx context.Context, project, filename string) ([]byte, error) {
+	if err := checkFilename(filename); err != nil {
+		return nil, err
+	}
+	return s.read(project, filename+MetadataSuffix)
+}
+
+// OpenFile 打开发布文件
+func (s *DirStorage) OpenFile(ctx context.Context, project, filename string) (io.ReadCloser, error) {
+	path, err := s.path(project, filename)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("文件 %s %w", filename, ErrNotFound)
+	}
+	return f, err
+}
+
+// SavePackage 写入项目信息，项目名取自pkg.Info.Name
+func (s *DirStorage) SavePackage(pkg *models.Package) error {
+	if pkg.Info == nil {
+		return fmt.Errorf("%w: 缺少项目信息", ErrInvalidName)
+	}
+	data, err := json.MarshalIndent(pkg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("编码项目 %s 的信息失败: %w", pkg.Info.Name, err)
+	}
+	return s.write(pkg.Info.Name, PackageFile, bytes.NewReader(data))
+}
+
+// SaveCoreMetadata 写入发布文件的核心元数据
+func (s *DirStorage) SaveCoreMetadata(project, filename string, data []byte) error {
+	if err := checkFilename(filename); err != nil {
+		return err
+	}
+	return s.write(project, filename+MetadataSuffix, bytes.NewReader(data))
+}
+
+// SaveFile 写入发布文件，先写入临时文件再重命名，读取方不会看到不完整的文件
+func (s *DirStorage) SaveFile(project, filename string, r io.Reader) error {
+	return s.write(project, filename, r)
+}
+
+// read 读取项目目录中的文件
+func (s *DirStorage) read(project, filename string) ([]byte, error) {
+	path, err := s.path(project, filename)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s/%s %w", models.NormalizeName(project), filename, ErrNotFound)
+	}
+	return data, err
+}
+
+// write 原子地写入项目目录中的文件
+func (s *DirStorage) write(project, filename string, r io.Reader) error {
+	path, err := s.path(project, filename)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入 %s 失败: %w", filename, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", filename, err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// path 返回项目目录中文件的路径，拒绝包含路径分隔符的名称
+func (s *DirStorage) path(project, filename string) (string, error) {
+	name := models.NormalizeName(project)
+	if err := checkFilename(name); err != nil {
+		return "", err
+	}
+	if err := checkFilename(filename); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, name, filename), nil
+}
+
+// checkFilename 检查名称是否为单个路径组成部分
+func checkFilename(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".tmp-") {
+		return fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package indexserver
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirStorage(t *testing.T) {
+	ctx := context.Background()
+	storage := NewDirStorage(filepath.Join(t.TempDir(), "index"))
+
+	t.Run("空目录", func(t *testing.T) {
+		projects, err := storage.Projects(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, projects)
+
+		_, err = storage.Package(ctx, "demo")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("写入并读取", func(t *testing.T) {
+		require.NoError(t, storage.SavePackage(&models.Package{Info: &models.PackageInfo{Name: "Demo_Pkg"}, LastSerial: 7}))
+		require.NoError(t, storage.SaveCoreMetadata("demo-pkg", "demo_pkg-1.0.whl", []byte("Name: demo-pkg\n")))
+		require.NoError(t, storage.SaveFile("Demo.Pkg", "demo_pkg-1.0.whl", strings.NewReader("wheel")))
+
+		projects, err := storage.Projects(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"demo-pkg"}, projects)
+
+		pkg, err := storage.Package(ctx, "DEMO-pkg")
+		require.NoError(t, err)
+		assert.Equal(t, 7, pkg.LastSerial)
+
+		data, err := storage.CoreMetadata(ctx, "demo-pkg", "demo_pkg-1.0.whl")
+		require.NoError(t, err)
+		assert.Equal(t, "Name: demo-pkg\n", string(data))
+
+		f, err := storage.OpenFile(ctx, "demo-pkg", "demo_pkg-1.0.whl")
+		require.NoError(t, err)
+		content, _ := io.ReadAll(f)
+		f.Close()
+		assert.Equal(t, "wheel", string(content))
+
+		_, err = os.Stat(filepath.Join(storage.Root(), "demo-pkg", "demo_pkg-1.0.whl.metadata"))
+		assert.NoError(t, err)
+
+		_, err = storage.OpenFile(ctx, "demo-pkg", "missing.whl")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("拒绝不安全的名称", func(t *testing.T) {
+		_, err := storage.OpenFile(ctx, "demo-pkg", "../package.json")
+		assert.ErrorIs(t, err, ErrInvalidName)
+		_, err = storage.CoreMetadata(ctx, "a/b", "a.whl")
+		assert.ErrorIs(t, err, ErrInvalidName)
+		assert.ErrorIs(t, storage.SaveFile("demo-pkg", ".tmp-1", strings.NewReader("")), ErrInvalidName)
+		assert.ErrorIs(t, storage.SavePackage(&models.Package{}), ErrInvalidName)
+	})
+}
+
+func TestMemoryStorage(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	storage.AddPackage(&models.Package{Info: &models.PackageInfo{Name: "b"}})
+	storage.AddPackage(&models.Package{Info: &models.PackageInfo{Name: "A"}})
+
+	projects, err := storage.Projects(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, projects)
+
+	_, err = storage.CoreMetadata(ctx, "a", "a.whl")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,131 @@
+package license
+
+import (
+	"sort"
+	"strings"
+)
+
+// classifierPrefix 许可证分类器的前缀
+const classifierPrefix = "License ::"
+
+// classifierLicenses "License ::" 分类器（去掉前缀和 "OSI Approved ::"）到SPDX标识符的映射
+// 未注明版本的分类器只能推测，可信度较低
+var classifierLicenses = map[string]struct {
+	id         string
+	confidence Confidence
+}{
+	"MIT License":                                                {"MIT", ConfidenceMedium},
+	"MIT No Attribution License (MIT-0)":                         {"MIT-0", ConfidenceMedium},
+	"Apache Software License":                                    {"Apache-2.0", ConfidenceMedium},
+	"BSD License":                                                {"BSD-3-Clause", ConfidenceLow},
+	"ISC License (ISCL)":                                         {"ISC", ConfidenceMedium},
+	"Python Software Foundation License":                         {"PSF-2.0", ConfidenceMedium},
+	"Python License (CNRI Python License)":                       {"CNRI-Python", ConfidenceMedium},
+	"The Unlicense (Unlicense)":                                  {"Unlicense", ConfidenceMedium},
+	"zlib/libpng License":                                        {"Zlib", ConfidenceMedium},
+	"Boost Software License 1.0 (BSL-1.0)":                       {"BSL-1.0", ConfidenceMedium},
+	"Universal Permissive License (UPL)":                         {"UPL-1.0", ConfidenceMedium},
+	"Academic Free License (AFL)":                                {"AFL-3.0", ConfidenceLow},
+	"Artistic License":                                           {"Artistic-2.0", ConfidenceLow},
+	"Zope Public License":                                        {"ZPL-2.1", ConfidenceLow},
+	"Historical Permission Notice and Disclaimer (HPND)":         {"HPND", ConfidenceMedium},
+	"Mozilla Public License 1.0 (MPL)":                           {"MPL-1.0", ConfidenceMedium},
+	"Mozilla Public License 1.1 (MPL 1.1)":                       {"MPL-1.1", ConfidenceMedium},
+	"Mozilla Public License 2.0 (MPL 2.0)":                       {"MPL-2.0", ConfidenceMedium},
+	"Eclipse Public License 1.0 (EPL-1.0)":                       {"EPL-1.0", ConfidenceMedium},
+	"Eclipse Public License 2.0 (EPL-2.0)":                       {"EPL-2.0", ConfidenceMedium},
+	"European Union Public Licence 1.1 (EUPL 1.1)":               {"EUPL-1.1", ConfidenceMedium},
+	"European Union Public Licence 1.2 (EUPL 1.2)":               {"EUPL-1.2", ConfidenceMedium},
+	"Common Development and Distribution License 1.0 (CDDL-1.0)": {"CDDL-1.0", ConfidenceMedium},
+	"GNU General Public License (GPL)":                           {"GPL-1.0-or-later", ConfidenceLow},
+	"GNU General Public License v2 (GPLv2)":                      {"GPL-2.0-only", ConfidenceMedium},
+	"GNU General Public License v2 or later (GPLv2+)":            {"GPL-2.0-or-later", ConfidenceMedium},
+	"GNU General Public License v3 (GPLv3)":                      {"GPL-3.0-only", ConfidenceMedium},
+	"GNU General Public License v3 or later (GPLv3+)":            {"GPL-3.0-or-later", ConfidenceMedium},
+	"GNU Library or Lesser General Public License (LGPL)":        {"LGPL-2.0-or-later", ConfidenceLow},
+	"GNU Lesser General Public License v2 (LGPLv2)":              {"LGPL-2.0-only", ConfidenceMedium},
+	"GNU Lesser General Public License v2 or later (LGPLv2+)":    {"LGPL-2.0-or-later", ConfidenceMedium},
+	"GNU Lesser General Public License v3 (LGPLv3)":              {"LGPL-3.0-only", ConfidenceMedium},
+	"GNU Lesser General Public License v3 or later (LGPLv3+)":    {"LGPL-3.0-or-later", ConfidenceMedium},
+	"GNU Affero General Public License v3":                       {"AGPL-3.0-only", ConfidenceMedium},
+	"GNU Affero General Public License v3 or later (AGPLv3+)":    {"AGPL-3.0-or-later", ConfidenceMedium},
+	"CC0 1.0 Universal (CC0 1.0) Public Domain Dedication":       {"CC0-1.0", ConfidenceMedium},
+	"GNU Free Documentation License (FDL)":                       {"GFDL-1.3-or-later", ConfidenceLow},
+	"Open Software License 3.0 (OSL-3.0)":                        {"OSL-3.0", ConfidenceMedium},
+	"Apple Public Source License":                                {"APSL-2.0", ConfidenceLow},
+	"Sun Industry Standards Source License (SISSL)":              {"SISSL", ConfidenceMedium},
+	"W3C License":                                         {"W3C", ConfidenceMedium},
+	"PostgreSQL License":                                  {"PostgreSQL", ConfidenceMedium},
+	"Blue Oak Model License (BlueOak-1.0.0)":              {"BlueOak-1.0.0", ConfidenceMedium},
+	"Eiffel Forum License":                                {"EFL-2.0", ConfidenceLow},
+	"Attribution Assurance License":                       {"AAL", ConfidenceMedium},
+	"Nokia Open Source License":                           {"Nokia", ConfidenceMedium},
+	"Mulan Permissive Software License v2 (MulanPSL-2.0)": {"MulanPSL-2.0", ConfidenceMedium},
+	"Common Public License":                               {"CPL-1.0", ConfidenceMedium},
+	"IBM Public License":                                  {"IPL-1.0", ConfidenceMedium},
+	"Qt Public License (QPL)":                             {"QPL-1.0", ConfidenceMedium},
+	"Ricoh Source Code Public License":                    {"RSCPL", ConfidenceMedium},
+	"Sleepycat License":                                   {"Sleepycat", ConfidenceMedium},
+	"Vovida Software License 1.0":                         {"VSL-1.0", ConfidenceMedium},
+	"X.Net License":                                       {"Xnet", ConfidenceMedium},
+	"Intel Open Source License":                           {"Intel", ConfidenceMedium},
+	"Motosoto License":                                    {"Motosoto", ConfidenceMedium},
+	"Open Group Test Suite License":                       {"OGTSL", ConfidenceMedium},
+	"Sun Public License":                                  {"SPL-1.0", ConfidenceMedium},
+	"Fair License":                                        {"Fair", ConfidenceMedium},
+}
+
+// ClassifierLicense 将单个 "License ::" 分类器映射为SPDX标识符
+// 非许可证分类器、"Other/Proprietary License" 等无法映射的分类器返回false
+func ClassifierLicense(classifier string) (string, Confidence, bool) {
+	name := strings.TrimSpace(classifier)
+	if !strings.HasPrefix(name, classifierPrefix) {
+		return "", ConfidenceNone, false
+	}
+	name = strings.TrimSpace(strings.TrimPrefix(name, classifierPrefix))
+	name = strings.TrimSpace(strings.TrimPrefix(name, "OSI Approved ::"))
+
+	entry, ok := classifierLicenses[name]
+	if !ok {
+		return "", ConfidenceNone, false
+	}
+	return entry.id, entry.confidence, true
+}
+
+// NormalizeClassifiers 根据 "License ::" 分类器推导SPDX表达式
+// 多个许可证分类器按 OR 组合（PyPI项目通常以此表示双重许可），可信度取其中最低的一个
+func NormalizeClassifiers(classifiers []string) *Result {
+	result := &Result{Source: SourceClassifier}
+
+	seen := make(map[string]bool)
+	var ids, inputs []string
+	confidence := ConfidenceHigh
+	for _, classifier := range classifiers {
+		id, c, ok := ClassifierLicense(classifier)
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		inputs = append(inputs, classifier)
+		if c < confidence {
+			confidence = c
+		}
+	}
+	if len(ids) == 0 {
+		return result
+	}
+
+	sort.Strings(ids)
+	result.Input = strings.Join(inputs, "\n")
+	result.Confidence = confidence
+	if len(ids) == 1 {
+		result.Expression = &Expression{License: ids[0]}
+		return result
+	}
+	result.Expression = &Expression{Operator: OperatorOr}
+	for _, id := range ids {
+		result.Expression.Operands = append(result.Expression.Operands, &Expression{License: id})
+	}
+	return result
+}
@@ -0,0 +1,56 @@
+package license
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifierLicense(t *testing.T) {
+	id, confidence, ok := ClassifierLicense("License :: OSI Approved :: GNU General Public License v3 or later (GPLv3+)")
+	assert.True(t, ok)
+	assert.Equal(t, "GPL-3.0-or-later", id)
+	assert.Equal(t, ConfidenceMedium, confidence)
+
+	id, _, ok = ClassifierLicense("License :: CC0 1.0 Universal (CC0 1.0) Public Domain Dedication")
+	assert.True(t, ok)
+	assert.Equal(t, "CC0-1.0", id)
+
+	_, _, ok = ClassifierLicense("License :: Other/Proprietary License")
+	assert.False(t, ok)
+	_, _, ok = ClassifierLicense("Programming Language :: Python")
+	assert.False(t, ok)
+
+	t.Run("映射表中的标识符都存在", func(t *testing.T) {
+		for classifier, entry := range classifierLicenses {
+			_, ok := Lookup(entry.id)
+			assert.True(t, ok, "%s 指向未知的许可证 %s", classifier, entry.id)
+		}
+	})
+}
+
+func TestNormalizeClassifiers(t *testing.T) {
+	t.Run("多个分类器按OR组合", func(t *testing.T) {
+		result := NormalizeClassifiers([]string{
+			"License :: OSI Approved :: MIT License",
+			"License :: OSI Approved :: Apache Software License",
+			"License :: OSI Approved :: MIT License",
+		})
+		assert.Equal(t, "Apache-2.0 OR MIT", result.String())
+		assert.Equal(t, ConfidenceMedium, result.Confidence)
+		assert.Equal(t, SourceClassifier, result.Source)
+	})
+
+	t.Run("取最低可信度", func(t *testing.T) {
+		result := NormalizeClassifiers([]string{
+			"License :: OSI Approved :: MIT License",
+			"License :: OSI Approved :: BSD License",
+		})
+		assert.Equal(t, ConfidenceLow, result.Confidence)
+	})
+
+	t.Run("没有许可证分类器", func(t *testing.T) {
+		result := NormalizeClassifiers([]string{"Framework :: Django"})
+		assert.False(t, result.Resolved())
+	})
+}
@@ -0,0 +1,277 @@
+package license
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidExpression 表示字符串不是有效的SPDX许可证表达式
+var ErrInvalidExpression = errors.New("无效的SPDX许可证表达式")
+
+// Operator 许可证表达式中的组合运算符
+type Operator string
+
+const (
+	// OperatorAnd 需要同时遵守所有许可证
+	OperatorAnd Operator = "AND"
+
+	// OperatorOr 可以任选其一
+	OperatorOr Operator = "OR"
+)
+
+// Expression 表示解析后的SPDX许可证表达式
+// 叶子节点的Operator为空，由License、OrLater和Exception描述单个许可证；
+// 组合节点由Operator和Operands描述
+type Expression struct {
+	// Operator 组合运算符，叶子节点为空
+	Operator Operator
+
+	// Operands 组合节点的操作数
+	Operands []*Expression
+
+	// License 叶子节点的许可证标识符（规范写法），也可能是 LicenseRef-* 自定义标识符
+	License string
+
+	// OrLater 是否带有 "+" 后缀，表示该版本或更新版本
+	OrLater bool
+
+	// Exception WITH 之后的例外标识符
+	Exception string
+}
+
+// IsLeaf 检查表达式是否为单个许可证
+func (e *Expression) IsLeaf() bool {
+	return e.Operator == ""
+}
+
+// String 返回表达式的规范写法
+func (e *Expression) String() string {
+	if e.IsLeaf() {
+		s := e.License
+		if e.OrLater {
+			s += "+"
+		}
+		if e.Exception != "" {
+			s += " WITH " + e.Exception
+		}
+		return s
+	}
+
+	parts := make([]string, 0, len(e.Operands))
+	for _, operand := range e.Operands {
+		s := operand.String()
+		// AND的优先级高于OR，AND中嵌套的OR需要加括号
+		if e.Operator == OperatorAnd && operand.Operator == OperatorOr {
+			s = "(" + s + ")"
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, " "+string(e.Operator)+" ")
+}
+
+// Licenses 返回表达式中出现的所有许可证标识符，去重并排序
+func (e *Expression) Licenses() []string {
+	seen := make(map[string]bool)
+	var walk func(*Expression)
+	walk = func(node *Expression) {
+		if node.IsLeaf() {
+			seen[node.License] = true
+			return
+		}
+		for _, operand := range node.Operands {
+			walk(operand)
+		}
+	}
+	walk(e)
+
+	result := make([]string, 0, len(seen))
+	for id := range seen {
+		result = append(result, id)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// ParseExpression 解析SPDX许可证表达式
+// 运算符不区分大小写，优先级为 WITH > AND > OR，标识符会被转换为SPDX列表中的规范写法
+//
+// 参数:
+//   - s: 许可证表达式，如 "MIT OR (Apache-2.0 AND BSD-3-Clause)"
+//
+// 返回值:
+//   - *Expression: 解析后的表达式
+//   - error: 语法错误或包含未知的许可证时返回，错误包装了ErrInvalidExpression
+//
+// 使用示例:
+//
+//	expr, err := license.ParseExpression("gpl-2.0-or-later WITH classpath-exception-2.0")
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(expr) // GPL-2.0-or-later WITH Classpath-exception-2.0
+func ParseExpression(s string) (*Expression, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%w: 表达式为空", ErrInvalidExpression)
+	}
+
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("%w: 多余的 %q", ErrInvalidExpression, p.tokens[p.pos])
+	}
+	return expr, nil
+}
+
+// tokenize 将表达式拆分为括号、运算符和标识符
+func tokenize(s string) ([]string, error) {
+	var tokens []string
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case isIDChar(c):
+			start := i
+			for i < len(s) && isIDChar(s[i]) {
+				i++
+			}
+			if i < len(s) && s[i] == '+' {
+				i++
+			}
+			tokens = append(tokens, s[start:i])
+		default:
+			return nil, fmt.Errorf("%w: 非法字符 %q", ErrInvalidExpression, c)
+		}
+	}
+	return tokens, nil
+}
+
+func isIDChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '.' || c == ':'
+}
+
+// parser 递归下降解析器
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) parseOr() (*Expression, error) {
+	return p.parseBinary(OperatorOr, p.parseAnd)
+}
+
+func (p *parser) parseAnd() (*Expression, error) {
+	return p.parseBinary(OperatorAnd, p.parseTerm)
+}
+
+// parseBinary 解析由同一运算符连接的操作数，并展开嵌套的同类节点
+func (p *parser) parseBinary(op Operator, next func() (*Expression, error)) (*Expression, error) {
+	first, err := next()
+	if err != nil {
+		return nil, err
+	}
+	operands := []*Expression{first}
+	for strings.EqualFold(p.peek(), string(op)) {
+		p.pos++
+		operand, err := next()
+		if err != nil {
+			return nil, err
+		}
+		if operand.Operator == op {
+			operands = append(operands, operand.Operands...)
+		} else {
+			operands = append(operands, operand)
+		}
+	}
+	if len(operands) == 1 {
+		return first, nil
+	}
+	return &Expression{Operator: op, Operands: operands}, nil
+}
+
+func (p *parser) parseTerm() (*Expression, error) {
+	token := p.peek()
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("%w: 表达式不完整", ErrInvalidExpression)
+	case token == "(":
+		p.pos++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("%w: 缺少右括号", ErrInvalidExpression)
+		}
+		p.pos++
+		return expr, nil
+	case token == ")" || isKeyword(token):
+		return nil, fmt.Errorf("%w: 意外的 %q", ErrInvalidExpression, token)
+	}
+
+	p.pos++
+	leaf, err := newLeaf(token)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(p.peek(), "WITH") {
+		p.pos++
+		exception := p.peek()
+		if exception == "" || exception == "(" || exception == ")" || isKeyword(exception) {
+			return nil, fmt.Errorf("%w: WITH 之后缺少例外标识符", ErrInvalidExpression)
+		}
+		p.pos++
+		canonical, ok := LookupException(exception)
+		if !ok {
+			return nil, fmt.Errorf("%w: 未知的许可证例外 %q", ErrInvalidExpression, exception)
+		}
+		leaf.Exception = canonical
+	}
+	return leaf, nil
+}
+
+func isKeyword(token string) bool {
+	return strings.EqualFold(token, "AND") || strings.EqualFold(token, "OR") || strings.EqualFold(token, "WITH")
+}
+
+// newLeaf 根据标识符创建叶子节点，"+"后缀被拆分为OrLater
+func newLeaf(token string) (*Expression, error) {
+	leaf := &Expression{}
+	if strings.HasSuffix(token, "+") {
+		token = strings.TrimSuffix(token, "+")
+		leaf.OrLater = true
+	}
+
+	lower := strings.ToLower(token)
+	if strings.HasPrefix(lower, "licenseref-") || strings.HasPrefix(lower, "documentref-") {
+		leaf.License = token
+		return leaf, nil
+	}
+
+	l, ok := Lookup(token)
+	if !ok {
+		return nil, fmt.Errorf("%w: 未知的许可证 %q", ErrInvalidExpression, token)
+	}
+	leaf.License = l.ID
+	return leaf, nil
+}
@@ -0,0 +1,88 @@
+package license
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	t.Run("不区分大小写", func(t *testing.T) {
+		l, ok := Lookup("apache-2.0")
+		require.True(t, ok)
+		assert.Equal(t, "Apache-2.0", l.ID)
+		assert.Equal(t, "Apache License 2.0", l.Name)
+		assert.False(t, l.Deprecated)
+	})
+
+	t.Run("已废弃的标识符", func(t *testing.T) {
+		l, ok := Lookup("GPL-2.0")
+		require.True(t, ok)
+		assert.True(t, l.Deprecated)
+	})
+
+	t.Run("许可证例外", func(t *testing.T) {
+		id, ok := LookupException("classpath-exception-2.0")
+		require.True(t, ok)
+		assert.Equal(t, "Classpath-exception-2.0", id)
+	})
+
+	t.Run("列表已排序且非空", func(t *testing.T) {
+		all := Licenses()
+		require.NotEmpty(t, all)
+		for i := 1; i < len(all); i++ {
+			assert.Less(t, all[i-1].ID, all[i].ID)
+		}
+	})
+}
+
+func TestParseExpression(t *testing.T) {
+	t.Run("规范化标识符和运算符", func(t *testing.T) {
+		expr, err := ParseExpression("mit or apache-2.0")
+		require.NoError(t, err)
+		assert.Equal(t, "MIT OR Apache-2.0", expr.String())
+		assert.Equal(t, OperatorOr, expr.Operator)
+		assert.Equal(t, []string{"Apache-2.0", "MIT"}, expr.Licenses())
+	})
+
+	t.Run("运算符优先级", func(t *testing.T) {
+		expr, err := ParseExpression("MIT OR Apache-2.0 AND BSD-3-Clause")
+		require.NoError(t, err)
+		require.Equal(t, OperatorOr, expr.Operator)
+		require.Len(t, expr.Operands, 2)
+		assert.Equal(t, OperatorAnd, expr.Operands[1].Operator)
+
+		expr, err = ParseExpression("(MIT OR Apache-2.0) AND BSD-3-Clause")
+		require.NoError(t, err)
+		assert.Equal(t, "(MIT OR Apache-2.0) AND BSD-3-Clause", expr.String())
+	})
+
+	t.Run("WITH和加号", func(t *testing.T) {
+		expr, err := ParseExpression("GPL-2.0+ with classpath-exception-2.0")
+		require.NoError(t, err)
+		assert.True(t, expr.IsLeaf())
+		assert.True(t, expr.OrLater)
+		assert.Equal(t, "GPL-2.0", expr.License)
+		assert.Equal(t, "GPL-2.0+ WITH Classpath-exception-2.0", expr.String())
+	})
+
+	t.Run("自定义许可证引用", func(t *testing.T) {
+		expr, err := ParseExpression("LicenseRef-Proprietary AND MIT")
+		require.NoError(t, err)
+		assert.Equal(t, "LicenseRef-Proprietary AND MIT", expr.String())
+	})
+
+	t.Run("展开同类嵌套", func(t *testing.T) {
+		expr, err := ParseExpression("MIT OR (ISC OR 0BSD)")
+		require.NoError(t, err)
+		assert.Len(t, expr.Operands, 3)
+	})
+
+	for _, invalid := range []string{"", "MIT OR", "(MIT", "MIT)", "Not-A-License", "MIT WITH Foo-exception", "MIT & ISC", "AND MIT"} {
+		t.Run("无效表达式 "+invalid, func(t *testing.T) {
+			_, err := ParseExpression(invalid)
+			assert.ErrorIs(t, err, ErrInvalidExpression)
+		})
+	}
+}
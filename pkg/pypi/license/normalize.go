@@ -0,0 +1,485 @@
+package license
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+)
+
+// Confidence 表示规范化结果的可信程度
+type Confidence int
+
+const (
+	// ConfidenceNone 未能识别许可证
+	ConfidenceNone Confidence = iota
+
+	// ConfidenceLow 根据含糊的描述推测，如 "BSD" 或未注明版本的GPL
+	ConfidenceLow
+
+	// ConfidenceMedium 模糊匹配到唯一的许可证，或来自明确的分类器
+	ConfidenceMedium
+
+	// ConfidenceHigh 来自有效的SPDX表达式，或多个来源相互印证
+	ConfidenceHigh
+)
+
+// String 返回可信程度的名称
+func (c Confidence) String() string {
+	switch c {
+	case ConfidenceLow:
+		return "low"
+	case ConfidenceMedium:
+		return "medium"
+	case ConfidenceHigh:
+		return "high"
+	default:
+		return "none"
+	}
+}
+
+// MarshalText 将可信程度编码为名称
+func (c Confidence) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText 从名称解析可信程度，便于在配置文件中书写
+func (c *Confidence) UnmarshalText(text []byte) error {
+	switch strings.ToLower(strings.TrimSpace(string(text))) {
+	case "", "none":
+		*c = ConfidenceNone
+	case "low":
+		*c = ConfidenceLow
+	case "medium":
+		*c = ConfidenceMedium
+	case "high":
+		*c = ConfidenceHigh
+	default:
+		return &UnknownConfidenceError{Value: string(text)}
+	}
+	return nil
+}
+
+// UnknownConfidenceError 表示无法识别的可信程度名称
+type UnknownConfidenceError struct {
+	Value string
+}
+
+func (e *UnknownConfidenceError) Error() string {
+	return "未知的可信程度: " + e.Value + "（可选 none、low、medium、high）"
+}
+
+// Source 表示规范化结果的来源
+type Source string
+
+const (
+	// SourceExpression 来自 license_expression（PEP 639）
+	SourceExpression Source = "license_expression"
+
+	// SourceLicense 来自自由文本的 license 字段
+	SourceLicense Source = "license"
+
+	// SourceClassifier 来自 "License ::" 分类器
+	SourceClassifier Source = "classifier"
+)
+
+// Result 表示许可证规范化的结果
+type Result struct {
+	// Expression 规范化后的SPDX表达式，未识别时为nil
+	Expression *Expression
+
+	// Confidence 结果的可信程度
+	Confidence Confidence
+
+	// Source 结果的主要来源
+	Source Source
+
+	// Input 产生结果的原始输入
+	Input string
+}
+
+// Resolved 检查是否识别出了许可证
+func (r *Result) Resolved() bool {
+	return r != nil && r.Expression != nil
+}
+
+// String 返回SPDX表达式，未识别时返回空字符串
+func (r *Result) String() string {
+	if !r.Resolved() {
+		return ""
+	}
+	return r.Expression.String()
+}
+
+// Normalize 从包信息中推导规范化的SPDX许可证表达式
+// 依次参考 license_expression、license 字段和 "License ::" 分类器：
+// 有效的 license_expression 直接采用；否则对 license 字段做模糊匹配，并与分类器的结果相互印证，
+// 两者一致时提升一级可信度，不一致时取可信度较高的一方并降低一级
+//
+// 参数:
+//   - info: 包信息，通常来自GetPackageInfo或CoreMetadata.ToPackageInfo
+//
+// 返回值:
+//   - *Result: 规范化结果，未能识别时Expression为nil
+//
+// 使用示例:
+//
+//	info, _ := client.GetPackageInfo(ctx, "requests")
+//	result := license.Normalize(info)
+//	fmt.Printf("%s (%s)\n", result, result.Confidence)
+func Normalize(info *models.PackageInfo) *Result {
+	if info == nil {
+		return &Result{}
+	}
+
+	if raw := strings.TrimSpace(info.LicenseExpression); raw != "" {
+		if expr, err := ParseExpression(raw); err == nil {
+			return &Result{Expression: expr, Confidence: ConfidenceHigh, Source: SourceExpression, Input: raw}
+		}
+	}
+
+	candidates := []*Result{
+		NormalizeString(info.LicenseExpression),
+		NormalizeString(info.License),
+		NormalizeClassifiers(info.ClassifiersArray),
+	}
+	candidates[0].Source = SourceExpression
+
+	var best *Result
+	for _, c := range candidates {
+		if !c.Resolved() {
+			continue
+		}
+		if best == nil {
+			best = c
+			continue
+		}
+		if c.String() == best.String() {
+			// 两个来源相互印证时提升一级
+			if c.Confidence > best.Confidence {
+				best.Confidence = c.Confidence
+			}
+			if best.Confidence < ConfidenceHigh {
+				best.Confidence++
+			}
+			continue
+		}
+		if c.Confidence > best.Confidence {
+			c, best = best, c
+		}
+		if best.Confidence > ConfidenceLow {
+			best.Confidence--
+		}
+	}
+	if best == nil {
+		return &Result{Input: strings.TrimSpace(info.License)}
+	}
+	return best
+}
+
+// NormalizeString 将自由文本的许可证描述规范化为SPDX表达式
+// 支持有效的SPDX表达式、许可证名称或标识符的模糊匹配（如 "Apache License, Version 2.0"、"GPLv3+"），
+// 以 or/and 连接的多个名称，以及完整的许可证正文
+func NormalizeString(s string) *Result {
+	s = strings.TrimSpace(s)
+	result := &Result{Source: SourceLicense, Input: s}
+	if s == "" || isPlaceholder(s) {
+		return result
+	}
+
+	if !strings.Contains(s, "\n") {
+		if expr, err := ParseExpression(s); err == nil {
+			result.Expression, result.Confidence = expr, ConfidenceHigh
+			return result
+		}
+		if expr, confidence := matchName(s); expr != nil {
+			result.Expression, result.Confidence = expr, confidence
+			return result
+		}
+		if expr, confidence := matchCompound(s); expr != nil {
+			result.Expression, result.Confidence = expr, confidence
+			return result
+		}
+	}
+
+	if expr, confidence := matchText(s); expr != nil {
+		result.Expression, result.Confidence = expr, confidence
+	}
+	return result
+}
+
+// isPlaceholder 检查是否为常见的无意义取值
+func isPlaceholder(s string) bool {
+	switch strings.ToLower(s) {
+	case "unknown", "none", "n/a", "na", "-", "license", "see license", "see license file", "other", "unlicensed":
+		return true
+	}
+	return false
+}
+
+var (
+	indexOnce sync.Once
+
+	// nameIndex 模糊匹配键到许可证ID的映射
+	nameIndex map[string]string
+
+	// compoundSeparator 多个许可证名称之间的连接词
+	compoundSeparator = regexp.MustCompile(`(?i)\s+(or|and)\s+|\s*/\s*`)
+
+	// versionSuffix 紧跟在名称后的版本号，如 "gplv3"、"mpl2.0"
+	versionSuffix = regexp.MustCompile(`^([a-z]+?)v?([0-9][0-9.]*)$`)
+)
+
+// phraseReplacer 将常见的许可证全称缩写为简称，使名称与标识符得到相同的匹配键
+var phraseReplacer = strings.NewReplacer(
+	"gnu ", "",
+	"lesser general public", "lgpl",
+	"library general public", "lgpl",
+	"affero general public", "agpl",
+	"general public", "gpl",
+	"mozilla public", "mpl",
+	"eclipse public", "epl",
+	"academic free", "afl",
+	"european union public", "eupl",
+	"boost software", "bsl",
+	"common development and distribution", "cddl",
+)
+
+// stopWords 生成匹配键时忽略的词
+var stopWords = map[string]bool{
+	"license": true, "licence": true, "licensed": true, "licenses": true,
+	"the": true, "version": true, "software": true, "under": true, "any": true,
+}
+
+// aliases 标识符和名称都无法匹配的常见写法
+var aliases = map[string]struct {
+	id         string
+	confidence Confidence
+}{
+	"apache":           {"Apache-2.0", ConfidenceMedium},
+	"asl2":             {"Apache-2.0", ConfidenceMedium},
+	"bsd":              {"BSD-3-Clause", ConfidenceLow},
+	"bsd3":             {"BSD-3-Clause", ConfidenceMedium},
+	"newbsd":           {"BSD-3-Clause", ConfidenceMedium},
+	"modifiedbsd":      {"BSD-3-Clause", ConfidenceMedium},
+	"revisedbsd":       {"BSD-3-Clause", ConfidenceMedium},
+	"bsd2":             {"BSD-2-Clause", ConfidenceMedium},
+	"simplifiedbsd":    {"BSD-2-Clause", ConfidenceMedium},
+	"freebsd":          {"BSD-2-Clause", ConfidenceMedium},
+	"expat":            {"MIT", ConfidenceMedium},
+	"mitx11":           {"MIT", ConfidenceMedium},
+	"gpl":              {"GPL-1.0-or-later", ConfidenceLow},
+	"gplorlater":       {"GPL-1.0-or-later", ConfidenceLow},
+	"lgpl":             {"LGPL-2.0-or-later", ConfidenceLow},
+	"lgplorlater":      {"LGPL-2.0-or-later", ConfidenceLow},
+	"agpl":             {"AGPL-3.0-only", ConfidenceLow},
+	"mpl":              {"MPL-2.0", ConfidenceLow},
+	"psf":              {"PSF-2.0", ConfidenceMedium},
+	"psfl":             {"PSF-2.0", ConfidenceMedium},
+	"pythonfoundation": {"PSF-2.0", ConfidenceMedium},
+	"python":           {"Python-2.0", ConfidenceLow},
+	"cc0":              {"CC0-1.0", ConfidenceMedium},
+	"bsl":              {"BSL-1.0", ConfidenceMedium},
+	"boost":            {"BSL-1.0", ConfidenceMedium},
+	"zpl":              {"ZPL-2.1", ConfidenceLow},
+	"zope":             {"ZPL-2.1", ConfidenceLow},
+	"zliblibpng":       {"Zlib", ConfidenceMedium},
+	"isc":              {"ISC", ConfidenceMedium},
+	"iscl":             {"ISC", ConfidenceMedium},
+}
+
+// fuzzyKey 生成用于模糊匹配的键
+// 忽略大小写、标点和停用词，拆分 "gplv3" 这类写法，去掉版本号末尾的 ".0"，"+" 视为 "or later"
+func fuzzyKey(s string) string {
+	s = phraseReplacer.Replace(strings.ToLower(s))
+	s = strings.ReplaceAll(s, "+", " or later ")
+
+	var words []string
+	for _, word := range strings.FieldsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '.')
+	}) {
+		word = strings.Trim(word, ".")
+		if word == "" || stopWords[word] {
+			continue
+		}
+		if m := versionSuffix.FindStringSubmatch(word); m != nil {
+			words = appendWord(words, m[1])
+			word = m[2]
+		}
+		for strings.HasSuffix(word, ".0") && len(word) > 2 {
+			word = strings.TrimSuffix(word, ".0")
+		}
+		words = appendWord(words, word)
+	}
+	return strings.Join(words, "")
+}
+
+// appendWord 追加单词并跳过连续重复的词，如 "The MIT License (MIT)"
+func appendWord(words []string, word string) []string {
+	if len(words) > 0 && words[len(words)-1] == word {
+		return words
+	}
+	return append(words, word)
+}
+
+// buildIndex 根据SPDX列表建立模糊匹配索引
+// 标识符优先于全称，已废弃的GNU标识符指向对应的 -only/-or-later 标识符
+func buildIndex() {
+	indexOnce.Do(func() {
+		nameIndex = make(map[string]string)
+		all := Licenses()
+		for _, l := range all {
+			if !l.Deprecated {
+				nameIndex[fuzzyKey(l.ID)] = l.ID
+			}
+		}
+		for _, l := range all {
+			target := l.ID
+			if l.Deprecated {
+				target = successor(l.ID)
+			}
+			for _, key := range []string{fuzzyKey(l.ID), fuzzyKey(l.Name)} {
+				if _, exists := nameIndex[key]; !exists && key != "" {
+					nameIndex[key] = target
+				}
+			}
+		}
+	})
+}
+
+// successor 返回已废弃标识符的替代标识符，如 "GPL-2.0+" -> "GPL-2.0-or-later"
+func successor(id string) string {
+	if strings.HasSuffix(id, "+") {
+		if l, ok := Lookup(strings.TrimSuffix(id, "+") + "-or-later"); ok {
+			return l.ID
+		}
+	}
+	if l, ok := Lookup(id + "-only"); ok {
+		return l.ID
+	}
+	return id
+}
+
+// matchName 将单个许可证名称模糊匹配到SPDX标识符
+func matchName(s string) (*Expression, Confidence) {
+	buildIndex()
+	key := fuzzyKey(s)
+	if key == "" {
+		return nil, ConfidenceNone
+	}
+
+	if id, ok := nameIndex[key]; ok {
+		return &Expression{License: id}, ConfidenceMedium
+	}
+	// 未说明 only 还是 or later 时按 only 处理，如 "GPLv3"
+	if id, ok := nameIndex[key+"only"]; ok {
+		return &Expression{License: id}, ConfidenceMedium
+	}
+	if alias, ok := aliases[key]; ok {
+		return &Expression{License: alias.id}, alias.confidence
+	}
+	return nil, ConfidenceNone
+}
+
+// matchCompound 匹配以 or、and 或 "/" 连接的多个许可证名称，每一部分都必须能够识别
+func matchCompound(s string) (*Expression, Confidence) {
+	separators := compoundSeparator.FindAllStringSubmatch(s, -1)
+	if len(separators) == 0 {
+		return nil, ConfidenceNone
+	}
+	parts := compoundSeparator.Split(s, -1)
+
+	confidence := ConfidenceMedium
+	var operands []*Expression
+	for _, part := range parts {
+		expr, err := ParseExpression(part)
+		c := ConfidenceMedium
+		if err != nil {
+			if expr, c = matchName(part); expr == nil {
+				return nil, ConfidenceNone
+			}
+		}
+		if c < confidence {
+			confidence = c
+		}
+		operands = append(operands, expr)
+	}
+
+	// 混合使用 and 与 or 时含义不明确，一律按 OR 处理并降低可信度
+	op := OperatorOr
+	mixed := false
+	for i, sep := range separators {
+		current := OperatorOr
+		if strings.EqualFold(sep[1], "and") {
+			current = OperatorAnd
+		}
+		if i == 0 {
+			op = current
+		} else if current != op {
+			mixed = true
+		}
+	}
+	if mixed {
+		op, confidence = OperatorOr, ConfidenceLow
+	}
+	return &Expression{Operator: op, Operands: operands}, confidence
+}
+
+// textSignature 许可证正文中的特征短语
+type textSignature struct {
+	phrases []string
+	id      string
+}
+
+// textSignatures 按匹配优先级排列，较具体的许可证排在前面
+var textSignatures = []textSignature{
+	{[]string{"gnu affero general public license", "version 3"}, "AGPL-3.0"},
+	{[]string{"gnu lesser general public license", "version 3"}, "LGPL-3.0"},
+	{[]string{"gnu lesser general public license", "version 2.1"}, "LGPL-2.1"},
+	{[]string{"gnu library general public license", "version 2"}, "LGPL-2.0"},
+	{[]string{"gnu general public license", "version 3"}, "GPL-3.0"},
+	{[]string{"gnu general public license", "version 2"}, "GPL-2.0"},
+	{[]string{"apache license", "version 2.0"}, "Apache-2.0"},
+	{[]string{"mozilla public license version 2.0"}, "MPL-2.0"},
+	{[]string{"mozilla public license, v. 2.0"}, "MPL-2.0"},
+	{[]string{"redistribution and use in source and binary forms", "neither the name"}, "BSD-3-Clause"},
+	{[]string{"redistribution and use in source and binary forms", "names of its contributors"}, "BSD-3-Clause"},
+	{[]string{"redistribution and use in source and binary forms"}, "BSD-2-Clause"},
+	{[]string{"permission is hereby granted, free of charge"}, "MIT"},
+	{[]string{"permission to use, copy, modify, and/or distribute this software for any purpose"}, "ISC"},
+	{[]string{"this is free and unencumbered software released into the public domain"}, "Unlicense"},
+	{[]string{"python software foundation license"}, "PSF-2.0"},
+	{[]string{"boost software license - version 1.0"}, "BSL-1.0"},
+	{[]string{"this software is provided 'as-is', without any express or implied"}, "Zlib"},
+}
+
+// matchText 根据特征短语识别完整的许可证正文
+func matchText(s string) (*Expression, Confidence) {
+	text := strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	for _, sig := range textSignatures {
+		matched := true
+		for _, phrase := range sig.phrases {
+			if !strings.Contains(text, phrase) {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		id := sig.id
+		// GNU系列许可证需要根据声明区分 -only 和 -or-later
+		// 完整的许可证正文在附录中附带了 "any later version" 的示例声明，不能作为依据
+		if strings.Contains(id, "GPL-") {
+			if strings.Contains(text, "or (at your option) any later version") && !strings.Contains(text, "how to apply these terms") {
+				id += "-or-later"
+			} else {
+				id += "-only"
+			}
+		}
+		return &Expression{License: id}, ConfidenceMedium
+	}
+	return nil, ConfidenceNone
+}
@@ -0,0 +1,130 @@
+package license
+
+import (
+	"testing"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mitText = `MIT License
+
+Copyright (c) 2024 Demo
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction.`
+
+const gplNoticeText = `This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.`
+
+func TestNormalizeString(t *testing.T) {
+	cases := []struct {
+		input      string
+		expected   string
+		confidence Confidence
+	}{
+		{"MIT", "MIT", ConfidenceHigh},
+		{"Apache-2.0 OR MIT", "Apache-2.0 OR MIT", ConfidenceHigh},
+		{"MIT License", "MIT", ConfidenceMedium},
+		{"The MIT License (MIT)", "MIT", ConfidenceMedium},
+		{"Apache License, Version 2.0", "Apache-2.0", ConfidenceMedium},
+		{"Apache 2", "Apache-2.0", ConfidenceMedium},
+		{"Apache Software License", "Apache-2.0", ConfidenceMedium},
+		{"BSD 3-Clause", "BSD-3-Clause", ConfidenceMedium},
+		{"New BSD", "BSD-3-Clause", ConfidenceMedium},
+		{"BSD", "BSD-3-Clause", ConfidenceLow},
+		{"GPLv3", "GPL-3.0-only", ConfidenceMedium},
+		{"GPLv3+", "GPL-3.0-or-later", ConfidenceMedium},
+		{"GNU GPL v2 or later", "GPL-2.0-or-later", ConfidenceMedium},
+		{"LGPLv2.1+", "LGPL-2.1-or-later", ConfidenceMedium},
+		{"GNU Lesser General Public License v3", "LGPL-3.0-only", ConfidenceMedium},
+		{"Mozilla Public License 2.0", "MPL-2.0", ConfidenceMedium},
+		{"PSF", "PSF-2.0", ConfidenceMedium},
+		{"GPL", "GPL-1.0-or-later", ConfidenceLow},
+		{"MIT or Apache 2.0", "MIT OR Apache-2.0", ConfidenceMedium},
+		{"BSD/GPLv2", "BSD-3-Clause OR GPL-2.0-only", ConfidenceLow},
+		{mitText, "MIT", ConfidenceMedium},
+		{gplNoticeText, "GPL-3.0-or-later", ConfidenceMedium},
+	}
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			result := NormalizeString(c.input)
+			require.True(t, result.Resolved(), "未识别: %q", c.input)
+			assert.Equal(t, c.expected, result.String())
+			assert.Equal(t, c.confidence, result.Confidence)
+			assert.Equal(t, SourceLicense, result.Source)
+		})
+	}
+
+	for _, input := range []string{"", "UNKNOWN", "Proprietary and confidential", "see LICENSE"} {
+		t.Run("无法识别 "+input, func(t *testing.T) {
+			result := NormalizeString(input)
+			assert.False(t, result.Resolved())
+			assert.Equal(t, ConfidenceNone, result.Confidence)
+			assert.Empty(t, result.String())
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	t.Run("优先使用license_expression", func(t *testing.T) {
+		result := Normalize(&models.PackageInfo{
+			LicenseExpression: "Apache-2.0 OR BSD-2-Clause",
+			License:           "whatever",
+			ClassifiersArray:  []string{"License :: OSI Approved :: MIT License"},
+		})
+		assert.Equal(t, "Apache-2.0 OR BSD-2-Clause", result.String())
+		assert.Equal(t, ConfidenceHigh, result.Confidence)
+		assert.Equal(t, SourceExpression, result.Source)
+	})
+
+	t.Run("license字段与分类器相互印证", func(t *testing.T) {
+		result := Normalize(&models.PackageInfo{
+			License:          "MIT License",
+			ClassifiersArray: []string{"License :: OSI Approved :: MIT License", "Programming Language :: Python :: 3"},
+		})
+		assert.Equal(t, "MIT", result.String())
+		assert.Equal(t, ConfidenceHigh, result.Confidence)
+	})
+
+	t.Run("只有分类器", func(t *testing.T) {
+		result := Normalize(&models.PackageInfo{
+			ClassifiersArray: []string{"License :: OSI Approved :: Apache Software License"},
+		})
+		assert.Equal(t, "Apache-2.0", result.String())
+		assert.Equal(t, ConfidenceMedium, result.Confidence)
+		assert.Equal(t, SourceClassifier, result.Source)
+	})
+
+	t.Run("来源不一致时降低可信度", func(t *testing.T) {
+		result := Normalize(&models.PackageInfo{
+			License:          "MIT",
+			ClassifiersArray: []string{"License :: OSI Approved :: BSD License"},
+		})
+		assert.Equal(t, "MIT", result.String())
+		assert.Equal(t, ConfidenceMedium, result.Confidence)
+	})
+
+	t.Run("license字段为完整正文", func(t *testing.T) {
+		result := Normalize(&models.PackageInfo{License: mitText})
+		assert.Equal(t, "MIT", result.String())
+	})
+
+	t.Run("无法识别", func(t *testing.T) {
+		result := Normalize(&models.PackageInfo{License: "Proprietary"})
+		assert.False(t, result.Resolved())
+		assert.Equal(t, "Proprietary", result.Input)
+		assert.False(t, Normalize(nil).Resolved())
+	})
+}
+
+func TestAliasesExist(t *testing.T) {
+	for key, alias := range aliases {
+		_, ok := Lookup(alias.id)
+		assert.True(t, ok, "别名 %s 指向未知的许可证 %s", key, alias.id)
+	}
+}
@@ -0,0 +1,222 @@
+package license
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+)
+
+// Decision 表示策略对许可证的判定
+type Decision string
+
+const (
+	// DecisionAllow 允许使用
+	DecisionAllow Decision = "allow"
+
+	// DecisionReview 需要人工审核
+	DecisionReview Decision = "review"
+
+	// DecisionDeny 禁止使用
+	DecisionDeny Decision = "deny"
+)
+
+// rank 返回判定的严重程度，用于组合多个判定
+func (d Decision) rank() int {
+	switch d {
+	case DecisionAllow:
+		return 0
+	case DecisionDeny:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// Policy 许可证策略
+// Allow和Deny中的模式不区分大小写，支持 path.Match 通配符（如 "GPL-*"），
+// 可以匹配许可证标识符、带 "+" 的写法或带例外的完整写法（如 "GPL-2.0-only WITH Classpath-exception-2.0"）
+type Policy struct {
+	// Allow 允许使用的许可证
+	Allow []string `json:"allow" yaml:"allow"`
+
+	// Deny 禁止使用的许可证，优先于Allow
+	Deny []string `json:"deny" yaml:"deny"`
+
+	// MinConfidence 允许判定所需的最低可信度，低于该值的允许判定降级为审核
+	MinConfidence Confidence `json:"min_confidence" yaml:"min_confidence"`
+
+	// Unknown 无法识别许可证时的判定，默认为审核
+	Unknown Decision `json:"unknown" yaml:"unknown"`
+}
+
+// Verdict 表示策略对一个包的判定结果
+type Verdict struct {
+	// Package 包名
+	Package string
+
+	// Version 版本号
+	Version string
+
+	// Decision 判定
+	Decision Decision
+
+	// License 规范化后的许可证
+	License *Result
+
+	// Reasons 判定的依据
+	Reasons []string
+}
+
+// Evaluate 根据策略评估规范化后的许可证
+// AND 组合要求每个许可证都被允许，OR 组合只要有一个许可证被允许即可
+//
+// 参数:
+//   - result: Normalize等函数返回的规范化结果
+//
+// 返回值:
+//   - *Verdict: 判定结果，Package和Version为空
+func (p *Policy) Evaluate(result *Result) *Verdict {
+	verdict := &Verdict{License: result}
+	if !result.Resolved() {
+		verdict.Decision = p.Unknown
+		if verdict.Decision == "" {
+			verdict.Decision = DecisionReview
+		}
+		verdict.Reasons = []string{"无法识别许可证"}
+		return verdict
+	}
+
+	verdict.Decision = p.evaluate(result.Expression, &verdict.Reasons)
+	if verdict.Decision == DecisionAllow && result.Confidence < p.MinConfidence {
+		verdict.Decision = DecisionReview
+		verdict.Reasons = append(verdict.Reasons,
+			fmt.Sprintf("可信度 %s 低于要求的 %s", result.Confidence, p.MinConfidence))
+	}
+	return verdict
+}
+
+// evaluate 递归评估表达式，并记录每个许可证的判定依据
+func (p *Policy) evaluate(expr *Expression, reasons *[]string) Decision {
+	if expr.IsLeaf() {
+		switch {
+		case p.matches(p.Deny, expr):
+			*reasons = append(*reasons, expr.String()+" 在禁止列表中")
+			return DecisionDeny
+		case p.matches(p.Allow, expr):
+			*reasons = append(*reasons, expr.String()+" 在允许列表中")
+			return DecisionAllow
+		default:
+			*reasons = append(*reasons, expr.String()+" 不在允许或禁止列表中")
+			return DecisionReview
+		}
+	}
+
+	var decision Decision
+	for i, operand := range expr.Operands {
+		d := p.evaluate(operand, reasons)
+		switch {
+		case i == 0:
+			decision = d
+		case expr.Operator == OperatorAnd && d.rank() > decision.rank():
+			decision = d
+		case expr.Operator == OperatorOr && d.rank() < decision.rank():
+			decision = d
+		}
+	}
+	return decision
+}
+
+// matches 检查许可证是否匹配任一模式
+func (p *Policy) matches(patterns []string, leaf *Expression) bool {
+	candidates := []string{strings.ToLower(leaf.License), strings.ToLower(leaf.String())}
+	if leaf.OrLater {
+		candidates = append(candidates, strings.ToLower(leaf.License+"+"))
+	}
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		for _, candidate := range candidates {
+			if ok, _ := path.Match(pattern, candidate); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// EvaluatePackage 规范化包的许可证并根据策略评估
+//
+// 参数:
+//   - info: 包信息
+//
+// 返回值:
+//   - *Verdict: 判定结果
+//
+// 使用示例:
+//
+//	policy := &license.Policy{
+//		Allow:         []string{"MIT", "BSD-*", "Apache-2.0"},
+//		Deny:          []string{"AGPL-*", "GPL-*"},
+//		MinConfidence: license.ConfidenceMedium,
+//	}
+//	verdict := policy.EvaluatePackage(info)
+//	if verdict.Decision == license.DecisionDeny {
+//		fmt.Println(verdict.Package, verdict.Reasons)
+//	}
+func (p *Policy) EvaluatePackage(info *models.PackageInfo) *Verdict {
+	verdict := p.Evaluate(Normalize(info))
+	if info != nil {
+		verdict.Package = info.Name
+		verdict.Version = info.Version
+	}
+	return verdict
+}
+
+// SetReport 表示对一组依赖的评估结果
+type SetReport struct {
+	// Decision 整体判定，取所有包中最严格的判定
+	Decision Decision
+
+	// Verdicts 每个包的判定结果，顺序与输入一致
+	Verdicts []*Verdict
+}
+
+// Denied 返回被禁止的包
+func (r *SetReport) Denied() []*Verdict {
+	return r.filter(DecisionDeny)
+}
+
+// NeedsReview 返回需要人工审核的包
+func (r *SetReport) NeedsReview() []*Verdict {
+	return r.filter(DecisionReview)
+}
+
+func (r *SetReport) filter(decision Decision) []*Verdict {
+	var result []*Verdict
+	for _, v := range r.Verdicts {
+		if v.Decision == decision {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// EvaluateSet 评估一组已解析的依赖
+//
+// 参数:
+//   - infos: 依赖集合中每个包的信息
+//
+// 返回值:
+//   - *SetReport: 每个包的判定和整体判定，空集合的整体判定为允许
+func (p *Policy) EvaluateSet(infos []*models.PackageInfo) *SetReport {
+	report := &SetReport{Decision: DecisionAllow}
+	for _, info := range infos {
+		verdict := p.EvaluatePackage(info)
+		report.Verdicts = append(report.Verdicts, verdict)
+		if verdict.Decision.rank() > report.Decision.rank() {
+			report.Decision = verdict.Decision
+		}
+	}
+	return report
+}
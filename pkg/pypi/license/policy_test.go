@@ -0,0 +1,98 @@
+package license
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPolicy() *Policy {
+	return &Policy{
+		Allow:         []string{"MIT", "BSD-*", "Apache-2.0", "GPL-2.0-only WITH Classpath-exception-2.0"},
+		Deny:          []string{"AGPL-*", "GPL-*"},
+		MinConfidence: ConfidenceMedium,
+	}
+}
+
+func TestPolicyEvaluate(t *testing.T) {
+	policy := testPolicy()
+	evaluate := func(expression string) *Verdict {
+		expr, err := ParseExpression(expression)
+		require.NoError(t, err)
+		return policy.Evaluate(&Result{Expression: expr, Confidence: ConfidenceHigh})
+	}
+
+	assert.Equal(t, DecisionAllow, evaluate("MIT").Decision)
+	assert.Equal(t, DecisionAllow, evaluate("bsd-3-clause").Decision)
+	assert.Equal(t, DecisionDeny, evaluate("GPL-3.0-or-later").Decision)
+	assert.Equal(t, DecisionReview, evaluate("MPL-2.0").Decision)
+
+	t.Run("OR取最宽松的判定", func(t *testing.T) {
+		assert.Equal(t, DecisionAllow, evaluate("GPL-3.0-only OR MIT").Decision)
+	})
+
+	t.Run("AND取最严格的判定", func(t *testing.T) {
+		verdict := evaluate("MIT AND GPL-3.0-only")
+		assert.Equal(t, DecisionDeny, verdict.Decision)
+		assert.Len(t, verdict.Reasons, 2)
+	})
+
+	t.Run("禁止列表优先但可以精确允许带例外的写法", func(t *testing.T) {
+		assert.Equal(t, DecisionDeny, evaluate("GPL-2.0-only").Decision)
+		policy := &Policy{Allow: []string{"GPL-2.0-only WITH Classpath-exception-2.0"}}
+		expr, err := ParseExpression("GPL-2.0-only WITH Classpath-exception-2.0")
+		require.NoError(t, err)
+		assert.Equal(t, DecisionAllow, policy.Evaluate(&Result{Expression: expr, Confidence: ConfidenceHigh}).Decision)
+	})
+
+	t.Run("可信度不足时需要审核", func(t *testing.T) {
+		expr, _ := ParseExpression("MIT")
+		verdict := policy.Evaluate(&Result{Expression: expr, Confidence: ConfidenceLow})
+		assert.Equal(t, DecisionReview, verdict.Decision)
+	})
+
+	t.Run("无法识别的许可证", func(t *testing.T) {
+		assert.Equal(t, DecisionReview, policy.Evaluate(&Result{}).Decision)
+		strict := &Policy{Unknown: DecisionDeny}
+		assert.Equal(t, DecisionDeny, strict.Evaluate(&Result{}).Decision)
+	})
+}
+
+func TestPolicyEvaluateSet(t *testing.T) {
+	policy := testPolicy()
+	report := policy.EvaluateSet([]*models.PackageInfo{
+		{Name: "requests", Version: "2.31.0", License: "Apache 2.0", ClassifiersArray: []string{"License :: OSI Approved :: Apache Software License"}},
+		{Name: "click", Version: "8.1.7", LicenseExpression: "BSD-3-Clause"},
+		{Name: "mystery", Version: "1.0", License: "Proprietary"},
+	})
+	assert.Equal(t, DecisionReview, report.Decision)
+	require.Len(t, report.Verdicts, 3)
+	assert.Equal(t, "requests", report.Verdicts[0].Package)
+	assert.Equal(t, DecisionAllow, report.Verdicts[0].Decision)
+	assert.Equal(t, DecisionAllow, report.Verdicts[1].Decision)
+	assert.Empty(t, report.Denied())
+	require.Len(t, report.NeedsReview(), 1)
+	assert.Equal(t, "mystery", report.NeedsReview()[0].Package)
+
+	report = policy.EvaluateSet([]*models.PackageInfo{
+		{Name: "gpl-lib", Version: "1.0", ClassifiersArray: []string{"License :: OSI Approved :: GNU General Public License v3 (GPLv3)"}},
+	})
+	assert.Equal(t, DecisionDeny, report.Decision)
+	assert.Len(t, report.Denied(), 1)
+
+	assert.Equal(t, DecisionAllow, policy.EvaluateSet(nil).Decision)
+}
+
+func TestPolicyJSON(t *testing.T) {
+	var policy Policy
+	err := json.Unmarshal([]byte(`{"allow": ["MIT"], "deny": ["GPL-*"], "min_confidence": "high", "unknown": "deny"}`), &policy)
+	require.NoError(t, err)
+	assert.Equal(t, ConfidenceHigh, policy.MinConfidence)
+	assert.Equal(t, DecisionDeny, policy.Unknown)
+
+	err = json.Unmarshal([]byte(`{"min_confidence": "certain"}`), &policy)
+	assert.Error(t, err)
+}
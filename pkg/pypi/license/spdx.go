@@ -0,0 +1,91 @@
+package license
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// licensesCSV SPDX许可证列表（https://spdx.org/licenses/ 的离线副本），列依次为 id,name,deprecated
+//
+//go:embed data/licenses.csv
+var licensesCSV string
+
+// exceptionsText SPDX许可证例外列表，每行一个标识符
+//
+//go:embed data/exceptions.txt
+var exceptionsText string
+
+// License 表示SPDX许可证列表中的一个许可证
+type License struct {
+	// ID SPDX标识符，如 "Apache-2.0"
+	ID string
+
+	// Name 许可证全称，部分较新的条目没有全称时与ID相同
+	Name string
+
+	// Deprecated 标识符是否已被SPDX废弃，如 "GPL-2.0"
+	Deprecated bool
+}
+
+var (
+	loadOnce sync.Once
+
+	// licenses 按小写ID索引的许可证
+	licenses map[string]License
+
+	// exceptions 按小写ID索引的例外标识符
+	exceptions map[string]string
+)
+
+// load 解析嵌入的SPDX数据，只执行一次
+func load() {
+	loadOnce.Do(func() {
+		licenses = make(map[string]License)
+		records, err := csv.NewReader(strings.NewReader(licensesCSV)).ReadAll()
+		if err != nil {
+			panic("license: 嵌入的SPDX许可证列表无效: " + err.Error())
+		}
+		for _, record := range records[1:] {
+			l := License{ID: record[0], Name: record[1], Deprecated: record[2] == "true"}
+			if l.Name == "" {
+				l.Name = l.ID
+			}
+			licenses[strings.ToLower(l.ID)] = l
+		}
+
+		exceptions = make(map[string]string)
+		for _, line := range strings.Split(exceptionsText, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				exceptions[strings.ToLower(line)] = line
+			}
+		}
+	})
+}
+
+// Lookup 根据SPDX标识符（不区分大小写）查找许可证
+func Lookup(id string) (License, bool) {
+	load()
+	l, ok := licenses[strings.ToLower(strings.TrimSpace(id))]
+	return l, ok
+}
+
+// LookupException 根据标识符（不区分大小写）查找许可证例外，返回规范写法
+func LookupException(id string) (string, bool) {
+	load()
+	canonical, ok := exceptions[strings.ToLower(strings.TrimSpace(id))]
+	return canonical, ok
+}
+
+// Licenses 返回嵌入的全部SPDX许可证，按ID排序
+func Licenses() []License {
+	load()
+	result := make([]License, 0, len(licenses))
+	for _, l := range licenses {
+		result = append(result, l)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
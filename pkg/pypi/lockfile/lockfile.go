@@ -0,0 +1,245 @@
+package lockfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+)
+
+// ErrUnknownFormat 表示无法识别锁文件格式
+var ErrUnknownFormat = errors.New("无法识别的锁文件格式")
+
+// Format 锁文件格式
+type Format string
+
+const (
+	// FormatPoetry poetry.lock
+	FormatPoetry Format = "poetry"
+
+	// FormatPipenv Pipfile.lock
+	FormatPipenv Format = "pipenv"
+
+	// FormatPDM pdm.lock
+	FormatPDM Format = "pdm"
+
+	// FormatUV uv.lock
+	FormatUV Format = "uv"
+
+	// FormatPylock PEP 751定义的pylock.toml
+	FormatPylock Format = "pylock"
+)
+
+// Lockfile 从锁文件中读取的依赖清单
+type Lockfile struct {
+	// Format 锁文件格式
+	Format Format
+
+	// Packages 锁定的包，按规范化包名和版本排序
+	Packages []models.Pin
+}
+
+// Pins 返回属于任一指定分组的包，不指定分组时返回全部
+func (l *Lockfile) Pins(groups ...string) []models.Pin {
+	if len(groups) == 0 {
+		return append([]models.Pin(nil), l.Packages...)
+	}
+	var pins []models.Pin
+	for _, pin := range l.Packages {
+		for _, group := range groups {
+			if pin.InGroup(group) {
+				pins = append(pins, pin)
+				break
+			}
+		}
+	}
+	return pins
+}
+
+// IndexPins 返回从索引安装的包，排除VCS、本地路径等直接引用，结果可直接用于漏洞检查和SBOM生成
+func (l *Lockfile) IndexPins() []models.Pin {
+	var pins []models.Pin
+	for _, pin := range l.Packages {
+		if !pin.IsDirect() {
+			pins = append(pins, pin)
+		}
+	}
+	return pins
+}
+
+// Package 按包名查找锁定的包，包名不区分大小写和分隔符
+func (l *Lockfile) Package(name string) (models.Pin, bool) {
+	normalized := models.NormalizeName(name)
+	for _, pin := range l.Packages {
+		if pin.NormalizedName() == normalized {
+			return pin, true
+		}
+	}
+	return models.Pin{}, false
+}
+
+// Groups 返回锁文件中出现的所有分组
+func (l *Lockfile) Groups() []string {
+	seen := map[string]bool{}
+	for _, pin := range l.Packages {
+		for _, group := range pin.Groups {
+			seen[group] = true
+		}
+	}
+	return sortedKeys(seen)
+}
+
+// pylockPattern PEP 751允许的文件名：pylock.toml 或 pylock.<名称>.toml
+var pylockPattern = regexp.MustCompile(`^pylock\.([^.]+\.)?toml$`)
+
+// DetectFormat 根据文件名识别锁文件格式
+func DetectFormat(filename string) (Format, error) {
+	base := filepath.Base(filename)
+	switch base {
+	case "poetry.lock":
+		return FormatPoetry, nil
+	case "Pipfile.lock":
+		return FormatPipenv, nil
+	case "pdm.lock":
+		return FormatPDM, nil
+	case "uv.lock":
+		return FormatUV, nil
+	}
+	if pylockPattern.MatchString(base) {
+		return FormatPylock, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownFormat, base)
+}
+
+// ParseFile 读取锁文件，格式由文件名决定
+//
+// 参数:
+//   - filename: 锁文件路径，文件名需为 poetry.lock、Pipfile.lock、pdm.lock、uv.lock 或 pylock*.toml
+//
+// 返回值:
+//   - *Lockfile: 读取到的依赖清单
+//   - error: 文件名无法识别、读取失败或内容无效时返回
+//
+// 使用示例:
+//
+//	lock, err := lockfile.ParseFile("poetry.lock")
+//	if err != nil {
+//		return err
+//	}
+//	inv, err := sbom.NewGenerator(mirrors.NewOfficialClient()).Collect(ctx, lock.Pins("main"))
+func ParseFile(filename string) (*Lockfile, error) {
+	format, err := DetectFormat(filename)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("读取锁文件失败: %w", err)
+	}
+	return Parse(format, data)
+}
+
+// Parse 按指定格式解析锁文件内容
+func Parse(format Format, data []byte) (*Lockfile, error) {
+	var pins []models.Pin
+	var err error
+	switch format {
+	case FormatPoetry:
+		pins, err = parsePoetry(data)
+	case FormatPipenv:
+		pins, err = parsePipenv(data)
+	case FormatPDM:
+		pins, err = parsePDM(data)
+	case FormatUV:
+		pins, err = parseUV(data)
+	case FormatPylock:
+		pins, err = parsePylock(data)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析 %s 锁文件失败: %w", format, err)
+	}
+
+	for i := range pins {
+		pins[i].Hashes = normalizeHashes(pins[i].Hashes)
+		pins[i].Index = indexURL(pins[i].Index)
+		sort.Strings(pins[i].Groups)
+	}
+	sort.SliceStable(pins, func(i, j int) bool {
+		a, b := pins[i].NormalizedName(), pins[j].NormalizedName()
+		if a != b {
+			return a < b
+		}
+		return pins[i].Version < pins[j].Version
+	})
+	return &Lockfile{Format: format, Packages: pins}, nil
+}
+
+// normalizeHashes 统一为小写的 "算法:值"，去重并排序
+func normalizeHashes(hashes []string) []string {
+	seen := map[string]bool{}
+	for _, h := range hashes {
+		algorithm, value, ok := strings.Cut(strings.TrimSpace(h), ":")
+		if !ok || value == "" {
+			continue
+		}
+		seen[strings.ToLower(algorithm)+":"+strings.ToLower(value)] = true
+	}
+	if len(seen) == 0 {
+		return nil
+	}
+	return sortedKeys(seen)
+}
+
+// hashesFromMap 将 {"sha256": "..."} 形式的哈希转换为 "算法:值"
+func hashesFromMap(m map[string]string) []string {
+	hashes := make([]string, 0, len(m))
+	for algorithm, value := range m {
+		hashes = append(hashes, algorithm+":"+value)
+	}
+	return hashes
+}
+
+// indexURL 规范化索引地址，PyPI官方索引返回空字符串
+func indexURL(u string) string {
+	trimmed := strings.TrimRight(strings.TrimSpace(u), "/")
+	switch trimmed {
+	case "https://pypi.org/simple", "https://pypi.python.org/simple", "https://pypi.org/pypi":
+		return ""
+	}
+	return trimmed
+}
+
+// joinMarkers 以or连接多个环境标记，任一为空（无条件安装）时返回空字符串
+func joinMarkers(markers []string) string {
+	seen := map[string]bool{}
+	for _, m := range markers {
+		if strings.TrimSpace(m) == "" {
+			return ""
+		}
+		seen[strings.TrimSpace(m)] = true
+	}
+	unique := sortedKeys(seen)
+	if len(unique) == 1 {
+		return unique[0]
+	}
+	for i, m := range unique {
+		unique[i] = "(" + m + ")"
+	}
+	return strings.Join(unique, " or ")
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
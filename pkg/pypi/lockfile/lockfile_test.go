@@ -0,0 +1,132 @@
+package lockfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseTestdata(t *testing.T, format Format, name string) *Lockfile {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	lock, err := Parse(format, data)
+	require.NoError(t, err)
+	return lock
+}
+
+func TestDetectFormat(t *testing.T) {
+	cases := map[string]Format{
+		"poetry.lock":             FormatPoetry,
+		"/src/app/Pipfile.lock":   FormatPipenv,
+		"pdm.lock":                FormatPDM,
+		"uv.lock":                 FormatUV,
+		"pylock.toml":             FormatPylock,
+		"project/pylock.dev.toml": FormatPylock,
+	}
+	for name, want := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := DetectFormat(name)
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+
+	t.Run("无法识别的文件名", func(t *testing.T) {
+		for _, name := range []string{"requirements.txt", "pylock.a.b.toml", "Pipfile", "package-lock.json"} {
+			_, err := DetectFormat(name)
+			assert.ErrorIs(t, err, ErrUnknownFormat, name)
+		}
+	})
+}
+
+func TestParseFile(t *testing.T) {
+	t.Run("根据文件名读取", func(t *testing.T) {
+		lock, err := ParseFile(filepath.Join("testdata", "uv.lock"))
+		require.NoError(t, err)
+		assert.Equal(t, FormatUV, lock.Format)
+		assert.NotEmpty(t, lock.Packages)
+	})
+
+	t.Run("文件不存在", func(t *testing.T) {
+		_, err := ParseFile(filepath.Join("testdata", "missing", "poetry.lock"))
+		assert.Error(t, err)
+	})
+
+	t.Run("未知格式", func(t *testing.T) {
+		_, err := Parse(Format("conda"), nil)
+		assert.ErrorIs(t, err, ErrUnknownFormat)
+	})
+
+	t.Run("内容无效", func(t *testing.T) {
+		_, err := Parse(FormatPoetry, []byte("[[package]\nname ="))
+		assert.Error(t, err)
+	})
+}
+
+func TestLockfile(t *testing.T) {
+	lock := parseTestdata(t, FormatPoetry, "poetry.lock")
+
+	t.Run("按包名排序", func(t *testing.T) {
+		var names []string
+		for _, pin := range lock.Packages {
+			names = append(names, pin.Name)
+		}
+		assert.Equal(t, []string{"certifi", "colorama", "internal-lib", "pytest", "tool"}, names)
+	})
+
+	t.Run("查找包", func(t *testing.T) {
+		pin, ok := lock.Package("Internal_Lib")
+		require.True(t, ok)
+		assert.Equal(t, "1.4.0", pin.Version)
+
+		_, ok = lock.Package("django")
+		assert.False(t, ok)
+	})
+
+	t.Run("按分组过滤", func(t *testing.T) {
+		lock := parseTestdata(t, FormatPipenv, "Pipfile.lock")
+		assert.Len(t, lock.Pins(), 4)
+		assert.Len(t, lock.Pins("default"), 3)
+		assert.Len(t, lock.Pins("develop"), 2)
+		assert.Len(t, lock.Pins("default", "develop"), 4)
+		assert.Empty(t, lock.Pins("docs"))
+		assert.Equal(t, []string{"default", "develop"}, lock.Groups())
+	})
+
+	t.Run("排除直接引用", func(t *testing.T) {
+		for _, pin := range lock.IndexPins() {
+			assert.False(t, pin.IsDirect(), pin.Name)
+		}
+		assert.Len(t, lock.IndexPins(), 4)
+	})
+
+	t.Run("Pins返回副本", func(t *testing.T) {
+		pins := lock.Pins()
+		pins[0].Version = "0"
+		assert.NotEqual(t, "0", lock.Packages[0].Version)
+	})
+}
+
+func TestNormalizeHashes(t *testing.T) {
+	got := normalizeHashes([]string{"SHA256:ABC", "sha256:abc", " md5:00 ", "invalid", "sha256:"})
+	assert.Equal(t, []string{"md5:00", "sha256:abc"}, got)
+	assert.Nil(t, normalizeHashes(nil))
+}
+
+func TestJoinMarkers(t *testing.T) {
+	assert.Equal(t, "", joinMarkers(nil))
+	assert.Equal(t, "", joinMarkers([]string{"os_name == 'nt'", ""}))
+	assert.Equal(t, "os_name == 'nt'", joinMarkers([]string{"os_name == 'nt'", "os_name == 'nt'"}))
+	assert.Equal(t, "(os_name == 'nt') or (sys_platform == 'darwin')",
+		joinMarkers([]string{"sys_platform == 'darwin'", "os_name == 'nt'"}))
+}
+
+func TestIndexURL(t *testing.T) {
+	assert.Equal(t, "", indexURL("https://pypi.org/simple/"))
+	assert.Equal(t, "", indexURL(""))
+	assert.Equal(t, "https://mirror.example.com/simple", indexURL("https://mirror.example.com/simple/"))
+}
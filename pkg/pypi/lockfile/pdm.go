@@ -0,0 +1,78 @@
+package lockfile
+
+import (
+	"github.com/BurntSushi/toml"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+)
+
+// pdmLock pdm.lock的结构，兼容旧版的sections和metadata.files
+type pdmLock struct {
+	Package  []pdmPackage `toml:"package"`
+	Metadata struct {
+		Files map[string][]pdmFile `toml:"files"`
+	} `toml:"metadata"`
+}
+
+type pdmPackage struct {
+	Name     string    `toml:"name"`
+	Version  string    `toml:"version"`
+	Groups   []string  `toml:"groups"`
+	Sections []string  `toml:"sections"`
+	Marker   string    `toml:"marker"`
+	Files    []pdmFile `toml:"files"`
+	Git      string    `toml:"git"`
+	Ref      string    `toml:"ref"`
+	Revision string    `toml:"revision"`
+	Path     string    `toml:"path"`
+	URL      string    `toml:"url"`
+}
+
+type pdmFile struct {
+	File string `toml:"file"`
+	URL  string `toml:"url"`
+	Hash string `toml:"hash"`
+}
+
+func parsePDM(data []byte) ([]models.Pin, error) {
+	var lock pdmLock
+	if err := toml.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+
+	pins := make([]models.Pin, 0, len(lock.Package))
+	for _, p := range lock.Package {
+		pin := models.Pin{Name: p.Name, Version: p.Version, Marker: p.Marker, Groups: p.Groups}
+		if len(pin.Groups) == 0 {
+			pin.Groups = p.Sections
+		}
+		if len(pin.Groups) == 0 {
+			pin.Groups = []string{"default"}
+		}
+
+		files := p.Files
+		if len(files) == 0 {
+			files = lock.Metadata.Files[p.Name+" "+p.Version]
+		}
+		for _, f := range files {
+			pin.Hashes = append(pin.Hashes, f.Hash)
+		}
+
+		switch {
+		case p.Git != "":
+			ref := p.Revision
+			if ref == "" {
+				ref = p.Ref
+			}
+			pin.URL = "git+" + p.Git
+			if ref != "" {
+				pin.URL += "@" + ref
+			}
+		case p.Path != "":
+			pin.URL = p.Path
+		case p.URL != "":
+			pin.URL = p.URL
+		}
+		pins = append(pins, pin)
+	}
+	return pins, nil
+}
@@ -0,0 +1,39 @@
+package lockfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePDM(t *testing.T) {
+	lock := parseTestdata(t, FormatPDM, "pdm.lock")
+
+	t.Run("分组和文件哈希", func(t *testing.T) {
+		pin, ok := lock.Package("idna")
+		require.True(t, ok)
+		assert.Equal(t, "3.6", pin.Version)
+		assert.Equal(t, []string{"default"}, pin.Groups)
+		assert.Len(t, pin.Hashes, 2)
+	})
+
+	t.Run("环境标记", func(t *testing.T) {
+		pin, _ := lock.Package("exceptiongroup")
+		assert.Equal(t, `python_version < "3.11"`, pin.Marker)
+		assert.Equal(t, []string{"test"}, pin.Groups)
+	})
+
+	t.Run("git依赖优先使用revision", func(t *testing.T) {
+		pin, _ := lock.Package("tool")
+		assert.Equal(t, "git+https://github.com/org/tool.git@3f2a1b7c", pin.URL)
+	})
+
+	t.Run("旧版sections和metadata.files", func(t *testing.T) {
+		lock := parseTestdata(t, FormatPDM, "pdm-legacy.lock")
+		pin, ok := lock.Package("idna")
+		require.True(t, ok)
+		assert.Equal(t, []string{"default"}, pin.Groups)
+		assert.Equal(t, []string{"sha256:c05567e9c24a6b9faaa835c4821bad0590fbb9d5779e7caa6e1cc4978e7eb24f"}, pin.Hashes)
+	})
+}
@@ -0,0 +1,98 @@
+package lockfile
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+)
+
+// pipfileLock Pipfile.lock的结构
+type pipfileLock struct {
+	Meta struct {
+		Sources []struct {
+			Name string `json:"name"`
+			URL  string `json:"url"`
+		} `json:"sources"`
+	} `json:"_meta"`
+	Default map[string]pipenvPackage `json:"default"`
+	Develop map[string]pipenvPackage `json:"develop"`
+}
+
+type pipenvPackage struct {
+	Version string   `json:"version"`
+	Hashes  []string `json:"hashes"`
+	Index   string   `json:"index"`
+	Markers string   `json:"markers"`
+	Git     string   `json:"git"`
+	Ref     string   `json:"ref"`
+	Path    string   `json:"path"`
+	File    string   `json:"file"`
+}
+
+func parsePipenv(data []byte) ([]models.Pin, error) {
+	var lock pipfileLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+
+	sources := map[string]string{}
+	defaultIndex := ""
+	for i, s := range lock.Meta.Sources {
+		sources[s.Name] = s.URL
+		if i == 0 {
+			defaultIndex = s.URL
+		}
+	}
+
+	// 同一个包可能同时出现在default和develop中，合并为一个并记录两个分组
+	byName := map[string]*models.Pin{}
+	var order []string
+	add := func(group string, packages map[string]pipenvPackage) {
+		names := make([]string, 0, len(packages))
+		for name := range packages {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if pin, ok := byName[name]; ok {
+				pin.Groups = append(pin.Groups, group)
+				continue
+			}
+			p := packages[name]
+			pin := &models.Pin{
+				Name:    name,
+				Version: strings.TrimLeft(p.Version, "="),
+				Hashes:  p.Hashes,
+				Marker:  p.Markers,
+				Groups:  []string{group},
+			}
+			switch {
+			case p.Git != "":
+				pin.URL = "git+" + p.Git
+				if p.Ref != "" {
+					pin.URL += "@" + p.Ref
+				}
+			case p.Path != "":
+				pin.URL = p.Path
+			case p.File != "":
+				pin.URL = p.File
+			case p.Index != "":
+				pin.Index = sources[p.Index]
+			default:
+				pin.Index = defaultIndex
+			}
+			byName[name] = pin
+			order = append(order, name)
+		}
+	}
+	add("default", lock.Default)
+	add("develop", lock.Develop)
+
+	pins := make([]models.Pin, 0, len(order))
+	for _, name := range order {
+		pins = append(pins, *byName[name])
+	}
+	return pins, nil
+}
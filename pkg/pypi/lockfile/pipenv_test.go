@@ -0,0 +1,48 @@
+package lockfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePipenv(t *testing.T) {
+	lock := parseTestdata(t, FormatPipenv, "Pipfile.lock")
+
+	t.Run("default和develop中的同名包合并", func(t *testing.T) {
+		pin, ok := lock.Package("requests")
+		require.True(t, ok)
+		assert.Equal(t, "2.31.0", pin.Version)
+		assert.Equal(t, []string{"default", "develop"}, pin.Groups)
+		assert.Equal(t, "python_version >= '3.7'", pin.Marker)
+		assert.Equal(t, []string{
+			"sha256:58cd2187c01e70e6e26505bca751777aa9f2ee0b7f4300988b709f44e013003f",
+			"sha256:942c5a758f98d790eaed1a29cb6eefc7ffb0d1cf7af05c3d2791656dbd6ad1e1",
+		}, pin.Hashes)
+		assert.Empty(t, pin.Index)
+	})
+
+	t.Run("索引名映射为地址", func(t *testing.T) {
+		pin, _ := lock.Package("internal-lib")
+		assert.Equal(t, "https://pypi.corp.example.com/simple", pin.Index)
+		assert.Nil(t, pin.Hashes)
+	})
+
+	t.Run("未指定索引时使用第一个源", func(t *testing.T) {
+		pin, _ := lock.Package("pytest")
+		assert.Empty(t, pin.Index)
+		assert.Equal(t, []string{"develop"}, pin.Groups)
+	})
+
+	t.Run("git依赖", func(t *testing.T) {
+		pin, _ := lock.Package("tool")
+		assert.Equal(t, "git+https://github.com/org/tool.git@3f2a1b7c", pin.URL)
+		assert.Empty(t, pin.Version)
+	})
+
+	t.Run("无效JSON", func(t *testing.T) {
+		_, err := Parse(FormatPipenv, []byte("{"))
+		assert.Error(t, err)
+	})
+}
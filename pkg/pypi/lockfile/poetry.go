@@ -0,0 +1,111 @@
+package lockfile
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/BurntSushi/toml"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+)
+
+// poetryLock poetry.lock的结构，兼容1.x（category、metadata.files）和2.x（groups、markers表）
+type poetryLock struct {
+	Package  []poetryPackage `toml:"package"`
+	Metadata struct {
+		Files map[string][]poetryFile `toml:"files"`
+	} `toml:"metadata"`
+}
+
+type poetryPackage struct {
+	Name     string        `toml:"name"`
+	Version  string        `toml:"version"`
+	Category string        `toml:"category"`
+	Groups   []string      `toml:"groups"`
+	Markers  interface{}   `toml:"markers"`
+	Files    []poetryFile  `toml:"files"`
+	Source   *poetrySource `toml:"source"`
+}
+
+type poetryFile struct {
+	File string `toml:"file"`
+	Hash string `toml:"hash"`
+}
+
+type poetrySource struct {
+	Type              string `toml:"type"`
+	URL               string `toml:"url"`
+	Reference         string `toml:"reference"`
+	ResolvedReference string `toml:"resolved_reference"`
+}
+
+func parsePoetry(data []byte) ([]models.Pin, error) {
+	var lock poetryLock
+	if err := toml.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+
+	pins := make([]models.Pin, 0, len(lock.Package))
+	for _, p := range lock.Package {
+		if p.Name == "" {
+			return nil, fmt.Errorf("包缺少名称")
+		}
+		pin := models.Pin{Name: p.Name, Version: p.Version, Groups: p.Groups}
+		if len(pin.Groups) == 0 {
+			category := p.Category
+			if category == "" {
+				category = "main"
+			}
+			pin.Groups = []string{category}
+		}
+
+		files := p.Files
+		if len(files) == 0 {
+			files = lock.Metadata.Files[p.Name]
+		}
+		for _, f := range files {
+			pin.Hashes = append(pin.Hashes, f.Hash)
+		}
+		pin.Marker = poetryMarker(p.Markers)
+
+		if s := p.Source; s != nil {
+			switch s.Type {
+			case "legacy":
+				pin.Index = s.URL
+			case "git":
+				ref := s.ResolvedReference
+				if ref == "" {
+					ref = s.Reference
+				}
+				pin.URL = "git+" + s.URL
+				if ref != "" {
+					pin.URL += "@" + ref
+				}
+			case "directory", "file", "url":
+				pin.URL = s.URL
+			}
+		}
+		pins = append(pins, pin)
+	}
+	return pins, nil
+}
+
+// poetryMarker poetry 2.x中markers可以是字符串，也可以是以分组为键的表
+func poetryMarker(v interface{}) string {
+	switch m := v.(type) {
+	case string:
+		return m
+	case map[string]interface{}:
+		groups := make([]string, 0, len(m))
+		for group := range m {
+			groups = append(groups, group)
+		}
+		sort.Strings(groups)
+		markers := make([]string, 0, len(groups))
+		for _, group := range groups {
+			s, _ := m[group].(string)
+			markers = append(markers, s)
+		}
+		return joinMarkers(markers)
+	}
+	return ""
+}
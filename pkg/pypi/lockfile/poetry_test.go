@@ -0,0 +1,76 @@
+package lockfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePoetry(t *testing.T) {
+	lock := parseTestdata(t, FormatPoetry, "poetry.lock")
+
+	t.Run("从索引安装的包", func(t *testing.T) {
+		pin, ok := lock.Package("certifi")
+		require.True(t, ok)
+		assert.Equal(t, "2024.2.2", pin.Version)
+		assert.Equal(t, []string{"main"}, pin.Groups)
+		assert.Empty(t, pin.Index)
+		assert.Equal(t, []string{
+			"sha256:0569859f95fc761b18b45ef421b1290a0f65f147e92a1e5eb3e635f9a5e4e66f",
+			"sha256:dc383c07b76109f368f6106eee2b593b04a011ea4d55f652c6ca24a754d1cdd1",
+		}, pin.Hashes)
+	})
+
+	t.Run("私有索引", func(t *testing.T) {
+		pin, _ := lock.Package("internal-lib")
+		assert.Equal(t, "https://pypi.corp.example.com/simple", pin.Index)
+		assert.False(t, pin.IsDirect())
+	})
+
+	t.Run("git依赖使用解析后的提交", func(t *testing.T) {
+		pin, _ := lock.Package("tool")
+		assert.Equal(t, "git+https://github.com/org/tool.git@3f2a1b7c", pin.URL)
+		assert.True(t, pin.IsDirect())
+	})
+
+	t.Run("poetry 2.x的分组和标记表", func(t *testing.T) {
+		lock := parseTestdata(t, FormatPoetry, "poetry2.lock")
+
+		pin, ok := lock.Package("colorama")
+		require.True(t, ok)
+		assert.Equal(t, []string{"dev", "main"}, pin.Groups)
+		assert.Equal(t, `(platform_system == "Windows") or (sys_platform == "win32")`, pin.Marker)
+
+		pin, _ = lock.Package("requests")
+		assert.Equal(t, []string{"main"}, pin.Groups)
+		assert.Empty(t, pin.Marker)
+	})
+
+	t.Run("poetry 1.x的metadata.files", func(t *testing.T) {
+		data := []byte(`
+[[package]]
+name = "six"
+version = "1.16.0"
+category = "dev"
+
+[metadata]
+lock-version = "1.1"
+
+[metadata.files]
+six = [
+    {file = "six-1.16.0.tar.gz", hash = "sha256:1e61c37477a1626458e36f7b1d82aa5c9b094fa4802892072e49de9c60c4c926"},
+]
+`)
+		lock, err := Parse(FormatPoetry, data)
+		require.NoError(t, err)
+		require.Len(t, lock.Packages, 1)
+		assert.Equal(t, []string{"dev"}, lock.Packages[0].Groups)
+		assert.Len(t, lock.Packages[0].Hashes, 1)
+	})
+
+	t.Run("包缺少名称", func(t *testing.T) {
+		_, err := Parse(FormatPoetry, []byte("[[package]]\nversion = \"1.0\"\n"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,91 @@
+package lockfile
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/BurntSushi/toml"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+)
+
+// pylock PEP 751定义的pylock.toml结构
+type pylock struct {
+	LockVersion string          `toml:"lock-version"`
+	Packages    []pylockPackage `toml:"packages"`
+}
+
+type pylockPackage struct {
+	Name      string           `toml:"name"`
+	Version   string           `toml:"version"`
+	Marker    string           `toml:"marker"`
+	Index     string           `toml:"index"`
+	VCS       *pylockVCS       `toml:"vcs"`
+	Directory *pylockDirectory `toml:"directory"`
+	Archive   *pylockFile      `toml:"archive"`
+	Sdist     *pylockFile      `toml:"sdist"`
+	Wheels    []pylockFile     `toml:"wheels"`
+}
+
+type pylockVCS struct {
+	Type     string `toml:"type"`
+	URL      string `toml:"url"`
+	Path     string `toml:"path"`
+	CommitID string `toml:"commit-id"`
+}
+
+type pylockDirectory struct {
+	Path     string `toml:"path"`
+	Editable bool   `toml:"editable"`
+}
+
+type pylockFile struct {
+	URL    string            `toml:"url"`
+	Path   string            `toml:"path"`
+	Hashes map[string]string `toml:"hashes"`
+}
+
+// dependencyGroupPattern 匹配PEP 751标记中的 `"dev" in dependency_groups`
+var dependencyGroupPattern = regexp.MustCompile(`["']([^"']+)["']\s+in\s+dependency_groups`)
+
+func parsePylock(data []byte) ([]models.Pin, error) {
+	var lock pylock
+	if err := toml.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	if lock.LockVersion == "" {
+		return nil, fmt.Errorf("缺少lock-version")
+	}
+
+	pins := make([]models.Pin, 0, len(lock.Packages))
+	for _, p := range lock.Packages {
+		pin := models.Pin{Name: p.Name, Version: p.Version, Marker: p.Marker, Index: p.Index}
+		for _, m := range dependencyGroupPattern.FindAllStringSubmatch(p.Marker, -1) {
+			pin.Groups = append(pin.Groups, m[1])
+		}
+		if len(pin.Groups) == 0 {
+			pin.Groups = []string{"default"}
+		}
+
+		if p.Sdist != nil {
+			pin.Hashes = append(pin.Hashes, hashesFromMap(p.Sdist.Hashes)...)
+		}
+		for _, w := range p.Wheels {
+			pin.Hashes = append(pin.Hashes, hashesFromMap(w.Hashes)...)
+		}
+
+		switch {
+		case p.VCS != nil:
+			pin.URL = p.VCS.Type + "+" + firstNonEmpty(p.VCS.URL, p.VCS.Path)
+			if p.VCS.CommitID != "" {
+				pin.URL += "@" + p.VCS.CommitID
+			}
+		case p.Directory != nil:
+			pin.URL = p.Directory.Path
+		case p.Archive != nil:
+			pin.URL = firstNonEmpty(p.Archive.URL, p.Archive.Path)
+			pin.Hashes = append(pin.Hashes, hashesFromMap(p.Archive.Hashes)...)
+		}
+		pins = append(pins, pin)
+	}
+	return pins, nil
+}
@@ -0,0 +1,39 @@
+package lockfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePylock(t *testing.T) {
+	lock := parseTestdata(t, FormatPylock, "pylock.toml")
+
+	t.Run("sdist和wheel哈希", func(t *testing.T) {
+		pin, ok := lock.Package("attrs")
+		require.True(t, ok)
+		assert.Equal(t, "23.2.0", pin.Version)
+		assert.Equal(t, []string{"default"}, pin.Groups)
+		assert.Len(t, pin.Hashes, 2)
+		assert.Empty(t, pin.Index)
+	})
+
+	t.Run("从标记中读取依赖组", func(t *testing.T) {
+		pin, _ := lock.Package("pytest")
+		assert.Equal(t, []string{"dev"}, pin.Groups)
+		assert.Equal(t, "'dev' in dependency_groups", pin.Marker)
+		assert.Equal(t, "https://pypi.corp.example.com/simple", pin.Index)
+	})
+
+	t.Run("VCS依赖", func(t *testing.T) {
+		pin, _ := lock.Package("tool")
+		assert.Equal(t, "git+https://github.com/org/tool.git@3f2a1b7c", pin.URL)
+		assert.True(t, pin.IsDirect())
+	})
+
+	t.Run("缺少lock-version", func(t *testing.T) {
+		_, err := Parse(FormatPylock, []byte("[[packages]]\nname = \"attrs\"\n"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,164 @@
+package lockfile
+
+import (
+	"sort"
+
+	"github.com/BurntSushi/toml"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+)
+
+// uvLock uv.lock的结构
+// uv不在包上记录分组和环境标记，两者都需要从项目根包出发沿依赖边推导
+type uvLock struct {
+	Package []uvPackage `toml:"package"`
+}
+
+type uvPackage struct {
+	Name                 string                    `toml:"name"`
+	Version              string                    `toml:"version"`
+	Source               uvSource                  `toml:"source"`
+	Dependencies         []uvDependency            `toml:"dependencies"`
+	OptionalDependencies map[string][]uvDependency `toml:"optional-dependencies"`
+	DevDependencies      map[string][]uvDependency `toml:"dev-dependencies"`
+	Sdist                *uvFile                   `toml:"sdist"`
+	Wheels               []uvFile                  `toml:"wheels"`
+}
+
+type uvSource struct {
+	Registry  string `toml:"registry"`
+	Git       string `toml:"git"`
+	Path      string `toml:"path"`
+	URL       string `toml:"url"`
+	Directory string `toml:"directory"`
+	Editable  string `toml:"editable"`
+	Virtual   string `toml:"virtual"`
+}
+
+type uvDependency struct {
+	Name   string   `toml:"name"`
+	Marker string   `toml:"marker"`
+	Extra  []string `toml:"extra"`
+}
+
+type uvFile struct {
+	URL  string `toml:"url"`
+	Hash string `toml:"hash"`
+}
+
+// isRoot 检查包是否为项目本身（源为 "." 的可编辑或虚拟包）
+func (p *uvPackage) isRoot() bool {
+	return p.Source.Editable == "." || p.Source.Virtual == "."
+}
+
+func parseUV(data []byte) ([]models.Pin, error) {
+	var lock uvLock
+	if err := toml.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+
+	byName := map[string][]*uvPackage{}
+	for i := range lock.Package {
+		p := &lock.Package[i]
+		name := models.NormalizeName(p.Name)
+		byName[name] = append(byName[name], p)
+	}
+
+	// 从根包出发，依赖所属的分组沿依赖边传播；同时记录每个包的入边标记
+	groups := map[string]map[string]bool{}
+	markers := map[string][]string{}
+	var visit func(dep uvDependency, group string)
+	visit = func(dep uvDependency, group string) {
+		name := models.NormalizeName(dep.Name)
+		if groups[name] == nil {
+			groups[name] = map[string]bool{}
+		}
+		if groups[name][group] {
+			return
+		}
+		groups[name][group] = true
+		for _, p := range byName[name] {
+			for _, d := range p.Dependencies {
+				visit(d, group)
+			}
+			for _, extra := range dep.Extra {
+				for _, d := range p.OptionalDependencies[extra] {
+					visit(d, group)
+				}
+			}
+		}
+	}
+	for i := range lock.Package {
+		p := &lock.Package[i]
+		for _, d := range p.Dependencies {
+			markers[models.NormalizeName(d.Name)] = append(markers[models.NormalizeName(d.Name)], d.Marker)
+		}
+		for _, deps := range p.OptionalDependencies {
+			for _, d := range deps {
+				markers[models.NormalizeName(d.Name)] = append(markers[models.NormalizeName(d.Name)], d.Marker)
+			}
+		}
+		for _, deps := range p.DevDependencies {
+			for _, d := range deps {
+				markers[models.NormalizeName(d.Name)] = append(markers[models.NormalizeName(d.Name)], d.Marker)
+			}
+		}
+		if !p.isRoot() {
+			continue
+		}
+		for _, d := range p.Dependencies {
+			visit(d, "main")
+		}
+		for extra, deps := range p.OptionalDependencies {
+			for _, d := range deps {
+				visit(d, extra)
+			}
+		}
+		for group, deps := range p.DevDependencies {
+			for _, d := range deps {
+				visit(d, group)
+			}
+		}
+	}
+
+	pins := make([]models.Pin, 0, len(lock.Package))
+	for _, p := range lock.Package {
+		if p.isRoot() {
+			continue
+		}
+		name := models.NormalizeName(p.Name)
+		pin := models.Pin{Name: p.Name, Version: p.Version, Marker: joinMarkers(markers[name])}
+		for group := range groups[name] {
+			pin.Groups = append(pin.Groups, group)
+		}
+		sort.Strings(pin.Groups)
+
+		if p.Sdist != nil {
+			pin.Hashes = append(pin.Hashes, p.Sdist.Hash)
+		}
+		for _, w := range p.Wheels {
+			pin.Hashes = append(pin.Hashes, w.Hash)
+		}
+
+		switch s := p.Source; {
+		case s.Registry != "":
+			pin.Index = s.Registry
+		case s.Git != "":
+			pin.URL = "git+" + s.Git
+		case s.URL != "":
+			pin.URL = s.URL
+		default:
+			pin.URL = firstNonEmpty(s.Path, s.Directory, s.Editable, s.Virtual)
+		}
+		pins = append(pins, pin)
+	}
+	return pins, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
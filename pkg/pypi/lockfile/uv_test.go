@@ -0,0 +1,85 @@
+package lockfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUV(t *testing.T) {
+	lock := parseTestdata(t, FormatUV, "uv.lock")
+
+	t.Run("排除项目本身", func(t *testing.T) {
+		_, ok := lock.Package("myapp")
+		assert.False(t, ok)
+		assert.Len(t, lock.Packages, 6)
+	})
+
+	t.Run("分组沿依赖边传播", func(t *testing.T) {
+		groups := map[string][]string{
+			"anyio":    {"main"},
+			"idna":     {"main"},
+			"sniffio":  {"main"},
+			"socksio":  {"http"},
+			"pytest":   {"dev"},
+			"colorama": {"dev"},
+		}
+		for name, want := range groups {
+			pin, ok := lock.Package(name)
+			require.True(t, ok, name)
+			assert.Equal(t, want, pin.Groups, name)
+		}
+		assert.Len(t, lock.Pins("main"), 3)
+	})
+
+	t.Run("入边标记", func(t *testing.T) {
+		pin, _ := lock.Package("colorama")
+		assert.Equal(t, "sys_platform == 'win32'", pin.Marker)
+
+		pin, _ = lock.Package("anyio")
+		assert.Empty(t, pin.Marker)
+	})
+
+	t.Run("哈希和来源", func(t *testing.T) {
+		pin, _ := lock.Package("anyio")
+		assert.Len(t, pin.Hashes, 2)
+		assert.Empty(t, pin.Index)
+		assert.False(t, pin.IsDirect())
+
+		pin, _ = lock.Package("socksio")
+		assert.Equal(t, "git+https://github.com/sethmlarson/socksio?rev=v1.0.0#f04b1de0b2d1c9b6f1e8d0b9d1c7f1a2b3c4d5e6", pin.URL)
+		assert.Nil(t, pin.Hashes)
+	})
+
+	t.Run("通过extra引用的可选依赖", func(t *testing.T) {
+		data := []byte(`
+version = 1
+
+[[package]]
+name = "app"
+version = "0.1.0"
+source = { virtual = "." }
+dependencies = [{ name = "httpx", extra = ["socks"] }]
+
+[[package]]
+name = "httpx"
+version = "0.27.0"
+source = { registry = "https://mirror.example.com/simple" }
+
+[package.optional-dependencies]
+socks = [{ name = "socksio" }]
+
+[[package]]
+name = "socksio"
+version = "1.0.0"
+source = { registry = "https://mirror.example.com/simple" }
+`)
+		lock, err := Parse(FormatUV, data)
+		require.NoError(t, err)
+		pin, ok := lock.Package("socksio")
+		require.True(t, ok)
+		assert.Equal(t, []string{"main"}, pin.Groups)
+		assert.Equal(t, "https://mirror.example.com/simple", pin.Index)
+	})
+}
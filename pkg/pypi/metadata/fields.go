@@ -0,0 +1,96 @@
+package metadata
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Field 描述核心元数据规范中的一个字段
+type Field struct {
+	// Name 规范中的字段名
+	Name string
+
+	// Since 引入该字段的Metadata-Version
+	Since string
+
+	// Multiple 字段是否可以出现多次
+	Multiple bool
+
+	// Deprecated 废弃该字段的Metadata-Version，为空表示未废弃
+	Deprecated string
+}
+
+// Fields 核心元数据规范（1.0 ~ 2.4）中定义的所有字段
+var Fields = []Field{
+	{Name: "Metadata-Version", Since: "1.0"},
+	{Name: "Name", Since: "1.0"},
+	{Name: "Version", Since: "1.0"},
+	{Name: "Dynamic", Since: "2.2", Multiple: true},
+	{Name: "Platform", Since: "1.0", Multiple: true},
+	{Name: "Supported-Platform", Since: "1.1", Multiple: true},
+	{Name: "Summary", Since: "1.0"},
+	{Name: "Description", Since: "1.0"},
+	{Name: "Description-Content-Type", Since: "2.1"},
+	{Name: "Keywords", Since: "1.0"},
+	{Name: "Home-page", Since: "1.0"},
+	{Name: "Download-URL", Since: "1.1"},
+	{Name: "Author", Since: "1.0"},
+	{Name: "Author-email", Since: "1.0"},
+	{Name: "Maintainer", Since: "1.2"},
+	{Name: "Maintainer-email", Since: "1.2"},
+	{Name: "License", Since: "1.0"},
+	{Name: "License-Expression", Since: "2.4"},
+	{Name: "License-File", Since: "2.4", Multiple: true},
+	{Name: "Classifier", Since: "1.1", Multiple: true},
+	{Name: "Requires-Dist", Since: "1.2", Multiple: true},
+	{Name: "Requires-Python", Since: "1.2"},
+	{Name: "Requires-External", Since: "1.2", Multiple: true},
+	{Name: "Project-URL", Since: "1.2", Multiple: true},
+	{Name: "Provides-Extra", Since: "2.1", Multiple: true},
+	{Name: "Provides-Dist", Since: "1.2", Multiple: true},
+	{Name: "Obsoletes-Dist", Since: "1.2", Multiple: true},
+	{Name: "Requires", Since: "1.1", Multiple: true, Deprecated: "1.2"},
+	{Name: "Provides", Since: "1.1", Multiple: true, Deprecated: "1.2"},
+	{Name: "Obsoletes", Since: "1.1", Multiple: true, Deprecated: "1.2"},
+}
+
+// KnownVersions 规范中定义的所有Metadata-Version
+var KnownVersions = []string{"1.0", "1.1", "1.2", "2.1", "2.2", "2.3", "2.4"}
+
+// LookupField 根据字段名（不区分大小写）查找字段定义
+func LookupField(name string) (Field, bool) {
+	for _, f := range Fields {
+		if strings.EqualFold(f.Name, name) {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// compareVersions 比较两个 "主版本.次版本" 格式的元数据版本
+// 返回值小于0表示a较旧，等于0表示相同，大于0表示a较新
+func compareVersions(a, b string) int {
+	aMajor, aMinor := splitVersion(a)
+	bMajor, bMinor := splitVersion(b)
+	if aMajor != bMajor {
+		return aMajor - bMajor
+	}
+	return aMinor - bMinor
+}
+
+func splitVersion(v string) (int, int) {
+	majorText, minorText, _ := strings.Cut(strings.TrimSpace(v), ".")
+	major, _ := strconv.Atoi(majorText)
+	minor, _ := strconv.Atoi(minorText)
+	return major, minor
+}
+
+// isKnownVersion 检查Metadata-Version是否为规范中定义的版本
+func isKnownVersion(v string) bool {
+	for _, known := range KnownVersions {
+		if v == known {
+			return true
+		}
+	}
+	return false
+}
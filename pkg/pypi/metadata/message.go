@@ -0,0 +1,58 @@
+package metadata
+
+import "strings"
+
+// Message 表示RFC 822风格的元数据消息
+// METADATA、PKG-INFO和PEP 658的 .metadata 文件均采用此格式
+type Message struct {
+	// Headers 所有头字段，键为小写字段名，值按出现顺序排列
+	Headers map[string][]string
+
+	// Body 头部之后的消息体
+	Body string
+}
+
+// Get 返回指定字段的第一个值，字段名不区分大小写
+func (m *Message) Get(key string) string {
+	values := m.Headers[strings.ToLower(key)]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Values 返回指定字段的所有值，用于可重复出现的字段
+func (m *Message) Values(key string) []string {
+	return m.Headers[strings.ToLower(key)]
+}
+
+// ParseMessage 解析RFC 822风格的元数据消息
+// 续行以空格或制表符开头，空行之后为消息体
+func ParseMessage(data []byte) *Message {
+	m := &Message{Headers: make(map[string][]string)}
+
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+
+	lastKey := ""
+	for i, line := range lines {
+		if line == "" {
+			m.Body = strings.Join(lines[i+1:], "\n")
+			break
+		}
+
+		if (line[0] == ' ' || line[0] == '\t') && lastKey != "" {
+			values := m.Headers[lastKey]
+			values[len(values)-1] += "\n" + line
+			continue
+		}
+
+		colon := strings.Index(line, ":")
+		if colon <= 0 {
+			continue
+		}
+		lastKey = strings.ToLower(strings.TrimSpace(line[:colon]))
+		m.Headers[lastKey] = append(m.Headers[lastKey], strings.TrimSpace(line[colon+1:]))
+	}
+	return m
+}
@@ -0,0 +1,272 @@
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+)
+
+// ErrInvalidMetadata 表示内容不是有效的核心元数据
+var ErrInvalidMetadata = errors.New("无效的核心元数据")
+
+var (
+	// namePattern 项目名的合法格式
+	namePattern = regexp.MustCompile(`(?i)^([A-Z0-9]|[A-Z0-9][A-Z0-9._-]*[A-Z0-9])$`)
+
+	// versionPattern PEP 440中规定的版本号格式
+	versionPattern = regexp.MustCompile(`(?i)^v?(?:[0-9]+!)?[0-9]+(?:\.[0-9]+)*` +
+		`(?:[-_.]?(?:a|b|c|rc|alpha|beta|pre|preview)[-_.]?[0-9]*)?` +
+		`(?:-[0-9]+|[-_.]?(?:post|rev|r)[-_.]?[0-9]*)?` +
+		`(?:[-_.]?dev[-_.]?[0-9]*)?` +
+		`(?:\+[a-z0-9]+(?:[-_.][a-z0-9]+)*)?$`)
+
+	// descriptionContentTypes Description-Content-Type允许的媒体类型
+	descriptionContentTypes = []string{"text/plain", "text/x-rst", "text/markdown"}
+)
+
+// Issue 表示元数据中一个无效的字段值
+type Issue struct {
+	// Field 字段名
+	Field string
+
+	// Value 字段的原始值
+	Value string
+
+	// Reason 无效的原因
+	Reason string
+}
+
+// String 返回问题的可读描述
+func (i Issue) String() string {
+	if i.Value == "" {
+		return fmt.Sprintf("%s: %s", i.Field, i.Reason)
+	}
+	return fmt.Sprintf("%s: %s (%q)", i.Field, i.Reason, i.Value)
+}
+
+// Report 记录解析元数据时发现的问题
+type Report struct {
+	// MetadataVersion 元数据声明的版本
+	MetadataVersion string
+
+	// Missing 缺失的必填字段
+	Missing []string
+
+	// Invalid 值无效、与声明版本不符或重复出现的字段
+	Invalid []Issue
+
+	// Unknown 规范中未定义的字段（小写形式）
+	Unknown []string
+}
+
+// Valid 检查元数据是否没有缺失或无效的字段
+// 未知字段不影响有效性，规范允许工具写入自定义字段
+func (r *Report) Valid() bool {
+	return len(r.Missing) == 0 && len(r.Invalid) == 0
+}
+
+func (r *Report) invalid(field, value, reason string) {
+	r.Invalid = append(r.Invalid, Issue{Field: field, Value: value, Reason: reason})
+}
+
+// Parse 解析核心元数据并转换为models.CoreMetadata
+// 解析采用宽松模式，只要包含Name字段即视为成功，需要校验时使用ParseWithReport
+//
+// 参数:
+//   - data: METADATA、PKG-INFO或 .metadata 文件的内容
+//
+// 返回值:
+//   - *models.CoreMetadata: 解析后的元数据
+//   - error: 缺少Name字段时返回ErrInvalidMetadata
+func Parse(data []byte) (*models.CoreMetadata, error) {
+	m, _ := ParseWithReport(data)
+	if m.Name == "" {
+		return nil, ErrInvalidMetadata
+	}
+	return m, nil
+}
+
+// ParseWithReport 解析核心元数据，并报告缺失、无效和未知的字段
+// 支持Metadata-Version 1.0到2.4，无论校验结果如何都会返回尽可能完整的元数据
+//
+// 参数:
+//   - data: METADATA、PKG-INFO或 .metadata 文件的内容
+//
+// 返回值:
+//   - *models.CoreMetadata: 解析后的元数据
+//   - *Report: 校验结果
+func ParseWithReport(data []byte) (*models.CoreMetadata, *Report) {
+	msg := ParseMessage(data)
+	report := &Report{MetadataVersion: msg.Get("Metadata-Version")}
+
+	get := func(field string) string { return unfold(msg.Get(field)) }
+	values := func(field string) []string {
+		raw := msg.Values(field)
+		if len(raw) == 0 {
+			return nil
+		}
+		result := make([]string, 0, len(raw))
+		for _, v := range raw {
+			result = append(result, unfold(v))
+		}
+		return result
+	}
+
+	m := &models.CoreMetadata{
+		MetadataVersion:        report.MetadataVersion,
+		Name:                   get("Name"),
+		Version:                get("Version"),
+		Summary:                get("Summary"),
+		Description:            get("Description"),
+		DescriptionContentType: get("Description-Content-Type"),
+		Keywords:               get("Keywords"),
+		HomePage:               get("Home-page"),
+		DownloadURL:            get("Download-URL"),
+		Author:                 get("Author"),
+		AuthorEmail:            get("Author-email"),
+		Maintainer:             get("Maintainer"),
+		MaintainerEmail:        get("Maintainer-email"),
+		License:                get("License"),
+		Classifiers:            values("Classifier"),
+		Platforms:              values("Platform"),
+		RequiresDist:           values("Requires-Dist"),
+		RequiresPython:         get("Requires-Python"),
+		ProvidesExtra:          values("Provides-Extra"),
+		SupportedPlatforms:     values("Supported-Platform"),
+		RequiresExternal:       values("Requires-External"),
+		ProvidesDist:           values("Provides-Dist"),
+		ObsoletesDist:          values("Obsoletes-Dist"),
+		Requires:               values("Requires"),
+		Provides:               values("Provides"),
+		Obsoletes:              values("Obsoletes"),
+		Dynamic:                values("Dynamic"),
+		LicenseExpression:      get("License-Expression"),
+		LicenseFiles:           values("License-File"),
+	}
+
+	// 2.1起描述可以放在消息体中
+	if body := strings.TrimSpace(msg.Body); body != "" {
+		if m.Description != "" {
+			report.invalid("Description", "", "同时出现在头字段和消息体中")
+		} else {
+			m.Description = msg.Body
+		}
+	}
+
+	for _, value := range values("Project-URL") {
+		label, link, ok := strings.Cut(value, ",")
+		if !ok {
+			report.invalid("Project-URL", value, "格式应为 \"标签, URL\"")
+			continue
+		}
+		if m.ProjectURLs == nil {
+			m.ProjectURLs = make(map[string]string)
+		}
+		m.ProjectURLs[strings.TrimSpace(label)] = strings.TrimSpace(link)
+	}
+
+	validate(msg, m, report)
+	return m, report
+}
+
+// validate 根据声明的Metadata-Version校验各字段
+func validate(msg *Message, m *models.CoreMetadata, report *Report) {
+	for _, required := range []string{"Metadata-Version", "Name", "Version"} {
+		if msg.Get(required) == "" {
+			report.Missing = append(report.Missing, required)
+		}
+	}
+
+	declared := m.MetadataVersion
+	if declared != "" && !isKnownVersion(declared) {
+		report.invalid("Metadata-Version", declared, "未知的元数据版本")
+	}
+
+	keys := make([]string, 0, len(msg.Headers))
+	for key := range msg.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		vals := msg.Headers[key]
+		field, ok := LookupField(key)
+		if !ok {
+			report.Unknown = append(report.Unknown, key)
+			continue
+		}
+		if !field.Multiple && len(vals) > 1 {
+			report.invalid(field.Name, "", fmt.Sprintf("字段只允许出现一次，实际出现 %d 次", len(vals)))
+		}
+		if declared == "" || !isKnownVersion(declared) {
+			continue
+		}
+		if compareVersions(declared, field.Since) < 0 {
+			report.invalid(field.Name, "", fmt.Sprintf("需要 Metadata-Version >= %s", field.Since))
+		}
+		if field.Deprecated != "" && compareVersions(declared, field.Deprecated) >= 0 {
+			report.invalid(field.Name, "", fmt.Sprintf("自 Metadata-Version %s 起已废弃", field.Deprecated))
+		}
+	}
+
+	if m.Name != "" && !namePattern.MatchString(m.Name) {
+		report.invalid("Name", m.Name, "不是合法的项目名")
+	}
+	if m.Version != "" && !versionPattern.MatchString(m.Version) {
+		report.invalid("Version", m.Version, "不符合PEP 440")
+	}
+
+	if contentType := m.DescriptionContentType; contentType != "" {
+		mediaType, _, _ := strings.Cut(contentType, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		allowed := false
+		for _, t := range descriptionContentTypes {
+			if mediaType == t {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			report.invalid("Description-Content-Type", contentType, "只允许 text/plain、text/x-rst 或 text/markdown")
+		}
+	}
+
+	for _, dynamic := range m.Dynamic {
+		field, ok := LookupField(dynamic)
+		switch {
+		case !ok:
+			report.invalid("Dynamic", dynamic, "不是核心元数据字段")
+		case field.Name == "Metadata-Version" || field.Name == "Name" || field.Name == "Version":
+			report.invalid("Dynamic", dynamic, "该字段不能声明为动态")
+		}
+	}
+
+	if m.LicenseExpression != "" && m.License != "" && compareVersions(declared, "2.4") >= 0 {
+		report.invalid("License", "", "不应与 License-Expression 同时使用")
+	}
+}
+
+// unfold 还原头字段中折叠的续行
+// setuptools写入的续行以8个空格或 "       |" 开头，这里去掉这些前缀
+func unfold(value string) string {
+	if !strings.Contains(value, "\n") {
+		return value
+	}
+	lines := strings.Split(value, "\n")
+	for i := 1; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "       |"):
+			lines[i] = line[len("       |"):]
+		case strings.HasPrefix(line, "        "):
+			lines[i] = line[8:]
+		default:
+			lines[i] = strings.TrimLeft(line, " \t")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
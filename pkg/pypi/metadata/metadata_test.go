@@ -0,0 +1,177 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleMetadata = `Metadata-Version: 2.1
+Name: requests
+Version: 2.31.0
+Summary: Python HTTP for Humans.
+Home-page: https://requests.readthedocs.io
+Author: Kenneth Reitz
+Author-email: me@kennethreitz.org
+License: Apache 2.0
+Project-URL: Documentation, https://requests.readthedocs.io
+Project-URL: Source, https://github.com/psf/requests
+Classifier: Development Status :: 5 - Production/Stable
+Classifier: License :: OSI Approved :: Apache Software License
+Requires-Python: >=3.7
+Description-Content-Type: text/markdown
+Requires-Dist: charset-normalizer (<4,>=2)
+Requires-Dist: PySocks (!=1.5.7,>=1.5.6) ; extra == 'socks'
+Provides-Extra: socks
+
+# Requests
+
+**Requests** is a simple, yet elegant, HTTP library.
+`
+
+func TestParseMessage(t *testing.T) {
+	msg := ParseMessage([]byte("Name: demo\r\nLicense: MIT\r\n  second line\r\nClassifier: A\r\nClassifier: B\r\n\r\nbody"))
+	assert.Equal(t, "demo", msg.Get("name"))
+	assert.Equal(t, "MIT\n  second line", msg.Get("License"))
+	assert.Equal(t, []string{"A", "B"}, msg.Values("Classifier"))
+	assert.Equal(t, "body", msg.Body)
+	assert.Empty(t, msg.Get("Summary"))
+}
+
+func TestParse(t *testing.T) {
+	t.Run("解析完整元数据", func(t *testing.T) {
+		m, err := Parse([]byte(sampleMetadata))
+		require.NoError(t, err)
+
+		assert.Equal(t, "2.1", m.MetadataVersion)
+		assert.Equal(t, "requests", m.Name)
+		assert.Equal(t, "2.31.0", m.Version)
+		assert.Equal(t, "https://requests.readthedocs.io", m.HomePage)
+		assert.Equal(t, "me@kennethreitz.org", m.AuthorEmail)
+		assert.Len(t, m.Classifiers, 2)
+		assert.Equal(t, []string{"charset-normalizer (<4,>=2)", "PySocks (!=1.5.7,>=1.5.6) ; extra == 'socks'"}, m.RequiresDist)
+		assert.Equal(t, []string{"socks"}, m.ProvidesExtra)
+		assert.Equal(t, "https://github.com/psf/requests", m.ProjectURLs["Source"])
+		assert.Contains(t, m.Description, "simple, yet elegant")
+	})
+
+	t.Run("转换为PackageInfo", func(t *testing.T) {
+		m, err := Parse([]byte(sampleMetadata))
+		require.NoError(t, err)
+
+		info := m.ToPackageInfo()
+		assert.Equal(t, "requests", info.Name)
+		assert.Equal(t, ">=3.7", info.RequiresPython)
+		assert.Equal(t, m.Classifiers, info.ClassifiersArray)
+		assert.True(t, info.HasPythonRequirement())
+	})
+
+	t.Run("缺少Name字段", func(t *testing.T) {
+		_, err := Parse([]byte("Metadata-Version: 2.1\nVersion: 1.0\n"))
+		assert.ErrorIs(t, err, ErrInvalidMetadata)
+	})
+}
+
+func TestParseWithReport(t *testing.T) {
+	t.Run("1.0版本的PKG-INFO", func(t *testing.T) {
+		data := "Metadata-Version: 1.0\nName: legacy\nVersion: 0.1\nSummary: 旧格式\n" +
+			"Description: 第一行\n        第二行\n       |  缩进保留\nPlatform: UNKNOWN\n"
+		m, report := ParseWithReport([]byte(data))
+		assert.True(t, report.Valid(), report.Invalid)
+		assert.Equal(t, "第一行\n第二行\n  缩进保留", m.Description)
+		assert.Equal(t, []string{"UNKNOWN"}, m.Platforms)
+	})
+
+	t.Run("1.1版本的废弃字段", func(t *testing.T) {
+		data := "Metadata-Version: 1.1\nName: old\nVersion: 1.0\nRequires: os\nProvides: old\nObsoletes: older\nClassifier: A :: B\n"
+		m, report := ParseWithReport([]byte(data))
+		assert.True(t, report.Valid(), report.Invalid)
+		assert.Equal(t, []string{"os"}, m.Requires)
+		assert.Equal(t, []string{"old"}, m.Provides)
+		assert.Equal(t, []string{"older"}, m.Obsoletes)
+	})
+
+	t.Run("2.4版本的全部字段", func(t *testing.T) {
+		data := `Metadata-Version: 2.4
+Name: modern
+Version: 2.0.0rc1
+Dynamic: Requires-Dist
+Summary: 新格式
+License-Expression: MIT OR Apache-2.0
+License-File: LICENSE-MIT
+License-File: licenses/LICENSE-APACHE
+Requires-External: libffi
+Provides-Dist: modern-compat
+Obsoletes-Dist: ancient
+Supported-Platform: linux-x86_64
+Project-URL: Homepage, https://example.com
+Description-Content-Type: text/markdown; charset=UTF-8
+X-Custom: 自定义
+
+正文描述
+`
+		m, report := ParseWithReport([]byte(data))
+		assert.True(t, report.Valid(), report.Invalid)
+		assert.Equal(t, "2.4", report.MetadataVersion)
+		assert.Equal(t, []string{"x-custom"}, report.Unknown)
+		assert.Equal(t, []string{"Requires-Dist"}, m.Dynamic)
+		assert.Equal(t, "MIT OR Apache-2.0", m.LicenseExpression)
+		assert.Equal(t, []string{"LICENSE-MIT", "licenses/LICENSE-APACHE"}, m.LicenseFiles)
+		assert.Equal(t, []string{"libffi"}, m.RequiresExternal)
+		assert.Equal(t, []string{"modern-compat"}, m.ProvidesDist)
+		assert.Equal(t, []string{"ancient"}, m.ObsoletesDist)
+		assert.Equal(t, []string{"linux-x86_64"}, m.SupportedPlatforms)
+		assert.Equal(t, "正文描述\n", m.Description)
+	})
+
+	t.Run("缺失和无效字段", func(t *testing.T) {
+		data := `Metadata-Version: 1.2
+Name: -bad-
+Summary: one
+Summary: two
+Provides-Extra: cli
+License-Expression: MIT
+Dynamic: Name
+Dynamic: Not-A-Field
+Project-URL: missing comma
+Description-Content-Type: text/html
+Requires: os
+`
+		_, report := ParseWithReport([]byte(data))
+		assert.False(t, report.Valid())
+		assert.Equal(t, []string{"Version"}, report.Missing)
+
+		reasons := map[string][]string{}
+		for _, issue := range report.Invalid {
+			reasons[issue.Field] = append(reasons[issue.Field], issue.Reason)
+		}
+		assert.Len(t, reasons["Summary"], 1)
+		assert.Contains(t, reasons["Provides-Extra"][0], "2.1")
+		assert.Contains(t, reasons["License-Expression"][0], "2.4")
+		assert.Contains(t, reasons["Requires"][0], "废弃")
+		assert.Len(t, reasons["Dynamic"], 3) // 版本不符 + 2个无效值
+		assert.Len(t, reasons["Project-URL"], 1)
+		assert.Len(t, reasons["Description-Content-Type"], 2) // 版本不符 + 媒体类型无效
+		assert.Len(t, reasons["Name"], 1)
+	})
+
+	t.Run("未知的元数据版本和非法版本号", func(t *testing.T) {
+		_, report := ParseWithReport([]byte("Metadata-Version: 3.0\nName: x\nVersion: not a version\n"))
+		require.Len(t, report.Invalid, 2)
+		assert.Equal(t, "Metadata-Version", report.Invalid[0].Field)
+		assert.Equal(t, "Version", report.Invalid[1].Field)
+		assert.Contains(t, report.Invalid[1].String(), "not a version")
+	})
+}
+
+func TestLookupField(t *testing.T) {
+	field, ok := LookupField("requires-dist")
+	require.True(t, ok)
+	assert.Equal(t, "Requires-Dist", field.Name)
+	assert.True(t, field.Multiple)
+	assert.Equal(t, "1.2", field.Since)
+
+	_, ok = LookupField("X-Custom")
+	assert.False(t, ok)
+}
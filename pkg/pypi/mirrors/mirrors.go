@@ -42,15 +42,7 @@ const (
 //	// 创建使用官方源的客户端
 //	client := mirrors.NewOfficialClient()
 func NewOfficialClient(options ...*client.Options) api.PyPIClient {
-	var clientOptions *client.Options
-	if len(options) > 0 {
-		clientOptions = options[0]
-	} else {
-		clientOptions = client.NewOptions()
-	}
-
-	clientOptions.WithBaseURL(OfficialURL)
-	return client.NewClient(clientOptions)
+	return newClient(builtin("official"), options...)
 }
 
 // NewTsinghuaClient 创建使用清华大学镜像源的客户端
@@ -66,15 +58,7 @@ func NewOfficialClient(options ...*client.Options) api.PyPIClient {
 //	// 创建使用清华大学镜像源的客户端
 //	client := mirrors.NewTsinghuaClient()
 func NewTsinghuaClient(options ...*client.Options) api.PyPIClient {
-	var clientOptions *client.Options
-	if len(options) > 0 {
-		clientOptions = options[0]
-	} else {
-		clientOptions = client.NewOptions()
-	}
-
-	clientOptions.WithBaseURL(TsinghuaURL)
-	return client.NewClient(clientOptions)
+	return newClient(builtin("tsinghua"), options...)
 }
 
 // NewDoubanClient 创建使用豆瓣镜像源的客户端
@@ -90,15 +74,7 @@ func NewTsinghuaClient(options ...*client.Options) api.PyPIClient {
 //	// 创建使用豆瓣镜像源的客户端
 //	client := mirrors.NewDoubanClient()
 func NewDoubanClient(options ...*client.Options) api.PyPIClient {
-	var clientOptions *client.Options
-	if len(options) > 0 {
-		clientOptions = options[0]
-	} else {
-		clientOptions = client.NewOptions()
-	}
-
-	clientOptions.WithBaseURL(DoubanURL)
-	return client.NewClient(clientOptions)
+	return newClient(builtin("douban"), options...)
 }
 
 // NewAliyunClient 创建使用阿里云镜像源的客户端
@@ -114,15 +90,7 @@ func NewDoubanClient(options ...*client.Options) api.PyPIClient {
 //	// 创建使用阿里云镜像源的客户端
 //	client := mirrors.NewAliyunClient()
 func NewAliyunClient(options ...*client.Options) api.PyPIClient {
-	var clientOptions *client.Options
-	if len(options) > 0 {
-		clientOptions = options[0]
-	} else {
-		clientOptions = client.NewOptions()
-	}
-
-	clientOptions.WithBaseURL(AliyunURL)
-	return client.NewClient(clientOptions)
+	return newClient(builtin("aliyun"), options...)
 }
 
 // NewTencentClient 创建使用腾讯云镜像源的客户端
@@ -138,15 +106,7 @@ func NewAliyunClient(options ...*client.Options) api.PyPIClient {
 //	// 创建使用腾讯云镜像源的客户端
 //	client := mirrors.NewTencentClient()
 func NewTencentClient(options ...*client.Options) api.PyPIClient {
-	var clientOptions *client.Options
-	if len(options) > 0 {
-		clientOptions = options[0]
-	} else {
-		clientOptions = client.NewOptions()
-	}
-
-	clientOptions.WithBaseURL(TencentURL)
-	return client.NewClient(clientOptions)
+	return newClient(builtin("tencent"), options...)
 }
 
 // NewUstcClient 创建使用中国科技大学镜像源的客户端
@@ -162,15 +122,7 @@ func NewTencentClient(options ...*client.Options) api.PyPIClient {
 //	// 创建使用中国科技大学镜像源的客户端
 //	client := mirrors.NewUstcClient()
 func NewUstcClient(options ...*client.Options) api.PyPIClient {
-	var clientOptions *client.Options
-	if len(options) > 0 {
-		clientOptions = options[0]
-	} else {
-		clientOptions = client.NewOptions()
-	}
-
-	clientOptions.WithBaseURL(UstcURL)
-	return client.NewClient(clientOptions)
+	return newClient(builtin("ustc"), options...)
 }
 
 // NewNeteaseClient 创建使用网易镜像源的客户端
@@ -186,6 +138,11 @@ func NewUstcClient(options ...*client.Options) api.PyPIClient {
 //	// 创建使用网易镜像源的客户端
 //	client := mirrors.NewNeteaseClient()
 func NewNeteaseClient(options ...*client.Options) api.PyPIClient {
+	return newClient(builtin("netease"), options...)
+}
+
+// newClient 创建使用镜像URL和布局的客户端，未传入选项时使用默认选项
+func newClient(m Mirror, options ...*client.Options) api.PyPIClient {
 	var clientOptions *client.Options
 	if len(options) > 0 {
 		clientOptions = options[0]
@@ -193,6 +150,16 @@ func NewNeteaseClient(options ...*client.Options) api.PyPIClient {
 		clientOptions = client.NewOptions()
 	}
 
-	clientOptions.WithBaseURL(NeteaseURL)
+	clientOptions.WithBaseURL(m.URL).WithLayout(m.Layout())
 	return client.NewClient(clientOptions)
 }
+
+// builtin 按名称返回内置镜像
+func builtin(name string) Mirror {
+	for _, m := range Builtin() {
+		if m.Name == name {
+			return m
+		}
+	}
+	panic("未知的内置镜像: " + name)
+}
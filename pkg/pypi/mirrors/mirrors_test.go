@@ -1,6 +1,7 @@
 package mirrors
 
 import (
+	"context"
 	"testing"
 
 	"github.com/scagogogo/pypi-crawler/pkg/pypi/api"
@@ -72,3 +73,21 @@ func TestAllMirrorURLs(t *testing.T) {
 	assert.Equal(t, "https://pypi.mirrors.ustc.edu.cn", UstcURL)
 	assert.Equal(t, "https://mirrors.163.com/pypi", NeteaseURL)
 }
+
+func TestBuiltinLayouts(t *testing.T) {
+	t.Run("只提供Simple API的镜像", func(t *testing.T) {
+		_, err := NewAliyunClient().GetPackageInfo(context.Background(), "requests")
+		assert.ErrorIs(t, err, client.ErrUnsupported)
+	})
+
+	t.Run("布局", func(t *testing.T) {
+		aliyun := builtin("aliyun")
+		assert.Equal(t, "https://mirrors.aliyun.com/pypi/simple/", aliyun.SimpleURL())
+		assert.True(t, aliyun.Layout().NoJSONAPI)
+		assert.Equal(t, "https://mirrors.aliyun.com/pypi/packages/ab/demo-1.0.tar.gz",
+			aliyun.Layout().RewriteFileURL("https://files.pythonhosted.org/packages/ab/demo-1.0.tar.gz"))
+
+		official := builtin("official")
+		assert.Equal(t, client.Layout{}, official.Layout())
+	})
+}
@@ -0,0 +1,305 @@
+package mirrors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/client"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+)
+
+// 探测的默认配置
+const (
+	// DefaultProbeProject 探测时请求的项目，应当是所有镜像都会同步的常见项目
+	DefaultProbeProject = "pip"
+
+	// DefaultProbeTimeout 单个镜像的探测超时时间
+	DefaultProbeTimeout = 10 * time.Second
+
+	// DefaultProbeConcurrency 同时探测的镜像数
+	DefaultProbeConcurrency = 8
+)
+
+// simpleAccept 探测时优先请求PEP 691的JSON格式
+const simpleAccept = "application/vnd.pypi.simple.v1+json, application/vnd.pypi.simple.v1+html;q=0.2, text/html;q=0.1"
+
+// maxProbeBody 探测响应体的最大读取长度
+const maxProbeBody = 16 << 20
+
+// serialComment bandersnatch在项目页面末尾写入的序列号注释
+var serialComment = regexp.MustCompile(`<!--\s*SERIAL\s+(\d+)\s*-->`)
+
+// Probe 一个镜像的探测结果
+type Probe struct {
+	// Mirror 被探测的镜像
+	Mirror Mirror
+
+	// Alive 镜像是否正常返回了探测项目的Simple页面
+	Alive bool
+
+	// Latency 从发出请求到读完响应的时间
+	Latency time.Duration
+
+	// PEP691 镜像是否以PEP 691的JSON格式响应
+	PEP691 bool
+
+	// Serial 镜像上探测项目的last_serial，无法获取时为0
+	Serial int
+
+	// OfficialSerial 官方源上探测项目的last_serial，无法获取时为0
+	OfficialSerial int
+
+	// Lag 镜像落后官方源的序列号数，无法比较时为-1
+	Lag int
+
+	// Stale 落后超过Prober允许的最大值
+	Stale bool
+
+	// Err 探测失败的原因
+	Err error
+
+	// CheckedAt 探测时间
+	CheckedAt time.Time
+}
+
+// Fresh 检查镜像是否可用且已确认与官方源同步
+func (p *Probe) Fresh() bool {
+	return p.Alive && p.Lag >= 0 && !p.Stale
+}
+
+// Prober 探测镜像的可用性、延迟和同步状态
+// 同步状态通过比较探测项目在镜像和官方源上的last_serial得到：
+// 依次读取 X-PyPI-Last-Serial 响应头、PEP 691响应中的 meta._last-serial 和bandersnatch写入的 <!--SERIAL n--> 注释
+type Prober struct {
+	httpClient  *http.Client
+	userAgent   string
+	official    Mirror
+	project     string
+	maxLag      int
+	concurrency int
+}
+
+// NewProber 创建探测器，默认以pip为探测项目，超时时间为DefaultProbeTimeout，不允许落后
+//
+// 使用示例:
+//
+//	probes := mirrors.NewProber().WithMaxLag(5).ProbeAll(ctx, mirrors.Default.InRegion(mirrors.RegionCN))
+//	for _, p := range probes {
+//		fmt.Println(p.Mirror.Name, p.Alive, p.Latency, p.Lag)
+//	}
+func NewProber() *Prober {
+	return &Prober{
+		httpClient:  &http.Client{Timeout: DefaultProbeTimeout},
+		userAgent:   client.DefaultUserAgent,
+		official:    Builtin()[0],
+		project:     DefaultProbeProject,
+		concurrency: DefaultProbeConcurrency,
+	}
+}
+
+// WithHTTPClient 设置发送探测请求的HTTP客户端
+func (p *Prober) WithHTTPClient(c *http.Client) *Prober {
+	p.httpClient = c
+	return p
+}
+
+// WithTimeout 设置单个镜像的探测超时时间
+func (p *Prober) WithTimeout(timeout time.Duration) *Prober {
+	c := *p.httpClient
+	c.Timeout = timeout
+	p.httpClient = &c
+	return p
+}
+
+// WithUserAgent 设置User-Agent请求头
+func (p *Prober) WithUserAgent(userAgent string) *Prober {
+	p.userAgent = userAgent
+	return p
+}
+
+// WithOfficial 设置作为同步基准的索引，默认为PyPI官方源
+func (p *Prober) WithOfficial(m Mirror) *Prober {
+	p.official = m
+	return p
+}
+
+// WithProject 设置探测项目
+func (p *Prober) WithProject(name string) *Prober {
+	p.project = name
+	return p
+}
+
+// WithMaxLag 设置允许落后的最大序列号数，超过时Probe.Stale为true
+func (p *Prober) WithMaxLag(n int) *Prober {
+	p.maxLag = n
+	return p
+}
+
+// WithConcurrency 设置同时探测的镜像数，小于1时按1处理
+func (p *Prober) WithConcurrency(n int) *Prober {
+	if n < 1 {
+		n = 1
+	}
+	p.concurrency = n
+	return p
+}
+
+// Probe 探测单个镜像，同时请求官方源以比较序列号
+func (p *Prober) Probe(ctx context.Context, m Mirror) *Probe {
+	official := p.fetch(ctx, p.official)
+	return p.compare(p.fetch(ctx, m), official)
+}
+
+// ProbeAll 并发探测多个镜像并按Rank排序，官方源只请求一次
+//
+// 参数:
+//   - ctx: 上下文，用于控制请求的生命周期
+//   - mirrors: 要探测的镜像
+//
+// 返回值:
+//   - []*Probe: 探测结果，最优的镜像排在最前
+func (p *Prober) ProbeAll(ctx context.Context, mirrors []Mirror) []*Probe {
+	official := p.fetch(ctx, p.official)
+
+	probes := make([]*Probe, len(mirrors))
+	sem := make(chan struct{}, p.concurrency)
+	var wg sync.WaitGroup
+	for i, m := range mirrors {
+		wg.Add(1)
+		go func(i int, m Mirror) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if strings.TrimSuffix(m.URL, "/") == strings.TrimSuffix(p.official.URL, "/") {
+				probes[i] = p.compare(official.clone(m), official)
+				return
+			}
+			probes[i] = p.compare(p.fetch(ctx, m), official)
+		}(i, m)
+	}
+	wg.Wait()
+
+	Rank(probes)
+	return probes
+}
+
+// Rank 对探测结果排序：已确认同步的可用镜像优先，其次是无法比较序列号的、落后的，最后是不可用的；
+// 同一档内按延迟从低到高排列
+func Rank(probes []*Probe) {
+	tier := func(p *Probe) int {
+		switch {
+		case !p.Alive:
+			return 3
+		case p.Stale:
+			return 2
+		case p.Lag < 0:
+			return 1
+		}
+		return 0
+	}
+	sort.SliceStable(probes, func(i, j int) bool {
+		a, b := probes[i], probes[j]
+		if ta, tb := tier(a), tier(b); ta != tb {
+			return ta < tb
+		}
+		if a.Latency != b.Latency {
+			return a.Latency < b.Latency
+		}
+		return a.Mirror.Name < b.Mirror.Name
+	})
+}
+
+// Rank 探测注册表中的所有镜像并排序，p为nil时使用NewProber()的默认配置
+func (r *Registry) Rank(ctx context.Context, p *Prober) []*Probe {
+	if p == nil {
+		p = NewProber()
+	}
+	return p.ProbeAll(ctx, r.List())
+}
+
+// compare 根据官方源的序列号计算落后程度
+func (p *Prober) compare(probe, official *Probe) *Probe {
+	probe.Lag = -1
+	probe.OfficialSerial = official.Serial
+	if probe.Alive && probe.Serial > 0 && official.Serial > 0 {
+		probe.Lag = official.Serial - probe.Serial
+		if probe.Lag < 0 {
+			// 官方源在两次请求之间发生了更新
+			probe.Lag = 0
+		}
+		probe.Stale = probe.Lag > p.maxLag
+	}
+	return probe
+}
+
+// clone 复制探测结果用于另一个镜像
+func (p *Probe) clone(m Mirror) *Probe {
+	c := *p
+	c.Mirror = m
+	return &c
+}
+
+// fetch 请求镜像上探测项目的Simple页面
+func (p *Prober) fetch(ctx context.Context, m Mirror) *Probe {
+	probe := &Probe{Mirror: m, Lag: -1, CheckedAt: time.Now()}
+	pageURL := m.SimpleURL() + models.NormalizeName(p.project) + "/"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		probe.Err = fmt.Errorf("创建请求失败: %w", err)
+		return probe
+	}
+	req.Header.Set("User-Agent", p.userAgent)
+	req.Header.Set("Accept", simpleAccept)
+
+	start := time.Now()
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		probe.Err = fmt.Errorf("请求 %s 失败: %w", pageURL, err)
+		return probe
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProbeBody))
+	probe.Latency = time.Since(start)
+	if err != nil {
+		probe.Err = fmt.Errorf("读取 %s 失败: %w", pageURL, err)
+		return probe
+	}
+	if resp.StatusCode != http.StatusOK {
+		probe.Err = fmt.Errorf("请求 %s 失败: HTTP %d", pageURL, resp.StatusCode)
+		return probe
+	}
+
+	probe.Alive = true
+	probe.PEP691 = strings.HasPrefix(resp.Header.Get("Content-Type"), "application/vnd.pypi.simple.v1+json")
+	probe.Serial = parseSerial(resp.Header, body, probe.PEP691)
+	return probe
+}
+
+// parseSerial 从响应中读取项目的last_serial，无法获取时返回0
+func parseSerial(header http.Header, body []byte, isJSON bool) int {
+	if n, err := strconv.Atoi(header.Get("X-PyPI-Last-Serial")); err == nil {
+		return n
+	}
+	if isJSON {
+		var project models.SimpleProject
+		if err := json.Unmarshal(body, &project); err == nil {
+			return project.Meta.LastSerial
+		}
+		return 0
+	}
+	if m := serialComment.FindSubmatch(body); m != nil {
+		n, _ := strconv.Atoi(string(m[1]))
+		return n
+	}
+	return 0
+}
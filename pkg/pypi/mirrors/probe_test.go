@@ -0,0 +1,126 @@
+package mirrors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newIndexServer 模拟镜像的Simple页面，handler只处理 /simple/pip/ 之外的差异
+func newIndexServer(t *testing.T, path string, handler func(w http.ResponseWriter)) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+		handler(w)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProber(t *testing.T) {
+	official := newIndexServer(t, "/simple/pip/", func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/vnd.pypi.simple.v1+json")
+		w.Header().Set("X-PyPI-Last-Serial", "100")
+		fmt.Fprint(w, `{"meta": {"api-version": "1.1"}, "name": "pip", "files": []}`)
+	})
+	synced := newIndexServer(t, "/pypi/simple/pip/", func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html><body><a href=\"pip-1.0.tar.gz\">pip-1.0.tar.gz</a></body></html>\n<!--SERIAL 100-->")
+	})
+	stale := newIndexServer(t, "/simple/pip/", func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/vnd.pypi.simple.v1+json")
+		fmt.Fprint(w, `{"meta": {"api-version": "1.0", "_last-serial": 90}, "name": "pip", "files": []}`)
+	})
+	unknown := newIndexServer(t, "/simple/pip/", func(w http.ResponseWriter) {
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprint(w, "<html><body></body></html>")
+	})
+	broken := newIndexServer(t, "/simple/pip/", func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	officialMirror := Mirror{Name: "official", URL: official.URL}
+	mirrors := []Mirror{
+		{Name: "broken", URL: broken.URL},
+		{Name: "unknown", URL: unknown.URL},
+		{Name: "stale", URL: stale.URL},
+		{Name: "synced", URL: synced.URL, SimplePath: "/pypi/simple/"},
+		officialMirror,
+	}
+	prober := NewProber().WithOfficial(officialMirror).WithMaxLag(5).WithTimeout(5 * time.Second)
+
+	t.Run("探测并排序", func(t *testing.T) {
+		probes := prober.ProbeAll(context.Background(), mirrors)
+		require.Len(t, probes, 5)
+
+		byName := map[string]*Probe{}
+		var order []string
+		for _, p := range probes {
+			byName[p.Mirror.Name] = p
+			order = append(order, p.Mirror.Name)
+		}
+		assert.ElementsMatch(t, []string{"official", "synced"}, order[:2])
+		assert.Equal(t, []string{"unknown", "stale", "broken"}, order[2:])
+
+		assert.True(t, byName["official"].PEP691)
+		assert.Equal(t, 0, byName["official"].Lag)
+
+		s := byName["synced"]
+		assert.True(t, s.Fresh())
+		assert.False(t, s.PEP691)
+		assert.Equal(t, 100, s.Serial)
+		assert.Equal(t, 0, s.Lag)
+
+		assert.Equal(t, 10, byName["stale"].Lag)
+		assert.True(t, byName["stale"].Stale)
+		assert.False(t, byName["stale"].Fresh())
+
+		assert.True(t, byName["unknown"].Alive)
+		assert.Equal(t, -1, byName["unknown"].Lag)
+		assert.GreaterOrEqual(t, byName["unknown"].Latency, 20*time.Millisecond)
+
+		assert.False(t, byName["broken"].Alive)
+		assert.ErrorContains(t, byName["broken"].Err, "502")
+	})
+
+	t.Run("允许落后", func(t *testing.T) {
+		p := NewProber().WithOfficial(officialMirror).WithMaxLag(10).Probe(context.Background(), mirrors[2])
+		assert.Equal(t, 100, p.OfficialSerial)
+		assert.True(t, p.Fresh())
+	})
+
+	t.Run("注册表排序", func(t *testing.T) {
+		reg, err := NewRegistry(mirrors[3], mirrors[0])
+		require.NoError(t, err)
+		probes := reg.Rank(context.Background(), prober)
+		assert.Equal(t, "synced", probes[0].Mirror.Name)
+		assert.Equal(t, "broken", probes[1].Mirror.Name)
+	})
+
+	t.Run("上下文取消", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		p := prober.Probe(ctx, mirrors[3])
+		assert.False(t, p.Alive)
+		assert.ErrorIs(t, p.Err, context.Canceled)
+	})
+}
+
+func TestParseSerial(t *testing.T) {
+	h := http.Header{}
+	assert.Equal(t, 0, parseSerial(h, []byte("<html></html>"), false))
+	assert.Equal(t, 42, parseSerial(h, []byte("<html></html><!-- SERIAL 42 -->"), false))
+	assert.Equal(t, 7, parseSerial(h, []byte(`{"meta": {"_last-serial": 7}}`), true))
+	assert.Equal(t, 0, parseSerial(h, []byte(`not json`), true))
+	h.Set("X-PyPI-Last-Serial", "9")
+	assert.Equal(t, 9, parseSerial(h, []byte("<!--SERIAL 42-->"), false))
+}
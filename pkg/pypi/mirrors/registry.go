@@ -0,0 +1,233 @@
+package mirrors
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/api"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/client"
+)
+
+var (
+	// ErrDuplicateMirror 表示同名镜像已注册
+	ErrDuplicateMirror = errors.New("镜像已存在")
+
+	// ErrInvalidMirror 表示镜像缺少名称或URL无效
+	ErrInvalidMirror = errors.New("无效的镜像")
+)
+
+// 镜像所在地区
+const (
+	// RegionGlobal 全球CDN
+	RegionGlobal = "GLOBAL"
+
+	// RegionCN 中国大陆
+	RegionCN = "CN"
+)
+
+// DefaultSimplePath Simple API相对于镜像URL的默认路径
+const DefaultSimplePath = client.DefaultSimplePath
+
+// Mirror 一个PyPI镜像及其能力描述
+type Mirror struct {
+	// Name 镜像的唯一名称，如 "tsinghua"
+	Name string `json:"name"`
+
+	// Description 镜像说明，如 "清华大学镜像源"
+	Description string `json:"description,omitempty"`
+
+	// URL 镜像的基础URL，与client.Options.BaseURL含义相同
+	URL string `json:"url"`
+
+	// Region 镜像所在地区，如RegionCN
+	Region string `json:"region,omitempty"`
+
+	// JSONAPI 是否提供 /pypi/<name>/json 形式的JSON API，不提供时客户端的JSON API方法返回client.ErrUnsupported
+	JSONAPI bool `json:"json_api"`
+
+	// JSONPath JSON API相对于URL的路径，为空时为client.DefaultJSONPath
+	JSONPath string `json:"json_path,omitempty"`
+
+	// PEP691 Simple API是否支持PEP 691的JSON格式
+	PEP691 bool `json:"pep691"`
+
+	// SimplePath Simple API相对于URL的路径，为空时为DefaultSimplePath
+	SimplePath string `json:"simple_path,omitempty"`
+
+	// FilesURL 镜像提供发布文件下载的地址前缀，如 "https://mirrors.aliyun.com/pypi/packages/"；
+	// 设置后客户端返回的官方文件URL会被改写到镜像上
+	FilesURL string `json:"files_url,omitempty"`
+}
+
+// Layout 返回镜像的URL布局
+func (m Mirror) Layout() client.Layout {
+	return client.Layout{
+		JSONPath:   m.JSONPath,
+		SimplePath: m.SimplePath,
+		NoJSONAPI:  !m.JSONAPI,
+		FilesURL:   m.FilesURL,
+	}
+}
+
+// SimpleURL 返回Simple API的根地址，以 "/" 结尾
+func (m Mirror) SimpleURL() string {
+	return m.Layout().SimpleURL(m.URL)
+}
+
+// NewClient 创建使用该镜像及其URL布局的客户端，options的含义与NewOfficialClient相同
+func (m Mirror) NewClient(options ...*client.Options) api.PyPIClient {
+	return newClient(m, options...)
+}
+
+// validate 检查镜像的名称和URL
+func (m Mirror) validate() error {
+	if strings.TrimSpace(m.Name) == "" {
+		return fmt.Errorf("%w: 缺少名称", ErrInvalidMirror)
+	}
+	u, err := url.Parse(m.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: %s 的URL %q 不是有效的HTTP地址", ErrInvalidMirror, m.Name, m.URL)
+	}
+	return nil
+}
+
+// Builtin 返回内置的镜像列表，第一个是官方源
+// 能力描述基于各镜像公开的说明，实际情况可以通过Prober探测；
+// 国内镜像均为bandersnatch布局，发布文件位于 <URL>/packages/ 下
+func Builtin() []Mirror {
+	return []Mirror{
+		{Name: "official", Description: "PyPI官方源", URL: OfficialURL, Region: RegionGlobal, JSONAPI: true, PEP691: true},
+		{Name: "tsinghua", Description: "清华大学镜像源", URL: TsinghuaURL, Region: RegionCN, JSONAPI: true, PEP691: true, FilesURL: TsinghuaURL + "/packages/"},
+		{Name: "douban", Description: "豆瓣镜像源", URL: DoubanURL, Region: RegionCN, FilesURL: DoubanURL + "/packages/"},
+		{Name: "aliyun", Description: "阿里云镜像源", URL: AliyunURL, Region: RegionCN, FilesURL: AliyunURL + "/packages/"},
+		{Name: "tencent", Description: "腾讯云镜像源", URL: TencentURL, Region: RegionCN, FilesURL: TencentURL + "/packages/"},
+		{Name: "ustc", Description: "中国科技大学镜像源", URL: UstcURL, Region: RegionCN, FilesURL: UstcURL + "/packages/"},
+		{Name: "netease", Description: "网易镜像源", URL: NeteaseURL, Region: RegionCN, FilesURL: NeteaseURL + "/packages/"},
+	}
+}
+
+// Registry 按名称管理的镜像列表，并发安全
+type Registry struct {
+	mu      sync.RWMutex
+	mirrors []Mirror
+}
+
+// NewRegistry 创建镜像注册表
+//
+// 参数:
+//   - mirrors: 初始镜像，通常为Builtin()的返回值
+//
+// 返回值:
+//   - *Registry: 注册表
+//   - error: 镜像无效或名称重复时返回
+//
+// 使用示例:
+//
+//	reg, err := mirrors.NewRegistry(mirrors.Builtin()...)
+//	if err != nil {
+//		return err
+//	}
+//	err = reg.Register(mirrors.Mirror{Name: "corp", URL: "https://pypi.corp.example.com", JSONAPI: true})
+func NewRegistry(mirrors ...Mirror) (*Registry, error) {
+	r := &Registry{}
+	for _, m := range mirrors {
+		if err := r.Register(m); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Default 包含内置镜像的全局注册表
+var Default = mustRegistry(Builtin()...)
+
+func mustRegistry(mirrors ...Mirror) *Registry {
+	r, err := NewRegistry(mirrors...)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Register 注册镜像，名称不区分大小写，同名镜像已存在时返回ErrDuplicateMirror
+func (r *Registry) Register(m Mirror) error {
+	if err := m.validate(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.indexOf(m.Name) >= 0 {
+		return fmt.Errorf("%w: %s", ErrDuplicateMirror, m.Name)
+	}
+	r.mirrors = append(r.mirrors, m)
+	return nil
+}
+
+// Unregister 移除镜像，返回镜像是否存在
+func (r *Registry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.indexOf(name)
+	if i < 0 {
+		return false
+	}
+	r.mirrors = append(r.mirrors[:i], r.mirrors[i+1:]...)
+	return true
+}
+
+// Get 按名称查找镜像，名称不区分大小写
+func (r *Registry) Get(name string) (Mirror, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if i := r.indexOf(name); i >= 0 {
+		return r.mirrors[i], true
+	}
+	return Mirror{}, false
+}
+
+// List 按注册顺序返回所有镜像
+func (r *Registry) List() []Mirror {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Mirror(nil), r.mirrors...)
+}
+
+// Filter 返回满足条件的镜像，如只保留提供JSON API的镜像
+func (r *Registry) Filter(keep func(Mirror) bool) []Mirror {
+	var result []Mirror
+	for _, m := range r.List() {
+		if keep(m) {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// InRegion 返回指定地区的镜像，地区不区分大小写
+func (r *Registry) InRegion(region string) []Mirror {
+	return r.Filter(func(m Mirror) bool {
+		return strings.EqualFold(m.Region, region)
+	})
+}
+
+func (r *Registry) indexOf(name string) int {
+	for i, m := range r.mirrors {
+		if strings.EqualFold(m.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Register 向全局注册表Default注册镜像
+func Register(m Mirror) error {
+	return Default.Register(m)
+}
+
+// Get 从全局注册表Default查找镜像
+func Get(name string) (Mirror, bool) {
+	return Default.Get(name)
+}
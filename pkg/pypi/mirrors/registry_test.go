@@ -0,0 +1,78 @@
+package mirrors
+
+import (
+	"testing"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltin(t *testing.T) {
+	builtin := Builtin()
+	require.Len(t, builtin, 7)
+	assert.Equal(t, "official", builtin[0].Name)
+	assert.Equal(t, OfficialURL, builtin[0].URL)
+
+	names := map[string]bool{}
+	for _, m := range builtin {
+		assert.NoError(t, m.validate(), m.Name)
+		assert.False(t, names[m.Name], "重复的镜像 %s", m.Name)
+		names[m.Name] = true
+	}
+
+	m, ok := Get("Aliyun")
+	require.True(t, ok)
+	assert.Equal(t, "https://mirrors.aliyun.com/pypi/simple/", m.SimpleURL())
+	assert.Implements(t, (*api.PyPIClient)(nil), m.NewClient())
+}
+
+func TestMirrorSimpleURL(t *testing.T) {
+	assert.Equal(t, "https://pypi.org/simple/", Mirror{URL: "https://pypi.org/"}.SimpleURL())
+	assert.Equal(t, "https://example.com/pypi/simple/", Mirror{URL: "https://example.com", SimplePath: "pypi/simple"}.SimpleURL())
+}
+
+func TestRegistry(t *testing.T) {
+	reg, err := NewRegistry(Builtin()...)
+	require.NoError(t, err)
+
+	t.Run("注册自定义镜像", func(t *testing.T) {
+		corp := Mirror{Name: "corp", URL: "https://pypi.corp.example.com", Region: RegionCN, JSONAPI: true}
+		require.NoError(t, reg.Register(corp))
+		m, ok := reg.Get("CORP")
+		require.True(t, ok)
+		assert.Equal(t, corp, m)
+		assert.Equal(t, "corp", reg.List()[len(reg.List())-1].Name)
+	})
+
+	t.Run("拒绝重复和无效的镜像", func(t *testing.T) {
+		assert.ErrorIs(t, reg.Register(Mirror{Name: "Tsinghua", URL: "https://example.com"}), ErrDuplicateMirror)
+		assert.ErrorIs(t, reg.Register(Mirror{URL: "https://example.com"}), ErrInvalidMirror)
+		assert.ErrorIs(t, reg.Register(Mirror{Name: "x", URL: "ftp://example.com"}), ErrInvalidMirror)
+		assert.ErrorIs(t, reg.Register(Mirror{Name: "y", URL: "example.com"}), ErrInvalidMirror)
+
+		_, err := NewRegistry(Mirror{Name: "a", URL: "https://a"}, Mirror{Name: "A", URL: "https://b"})
+		assert.ErrorIs(t, err, ErrDuplicateMirror)
+	})
+
+	t.Run("筛选", func(t *testing.T) {
+		assert.Len(t, reg.InRegion("cn"), 7)
+		assert.Len(t, reg.InRegion(RegionGlobal), 1)
+		jsonAPI := reg.Filter(func(m Mirror) bool { return m.JSONAPI })
+		var names []string
+		for _, m := range jsonAPI {
+			names = append(names, m.Name)
+		}
+		assert.Equal(t, []string{"official", "tsinghua", "corp"}, names)
+	})
+
+	t.Run("移除镜像", func(t *testing.T) {
+		assert.True(t, reg.Unregister("corp"))
+		assert.False(t, reg.Unregister("corp"))
+		_, ok := reg.Get("corp")
+		assert.False(t, ok)
+		// 全局注册表不受影响
+		_, ok = Get("tsinghua")
+		assert.True(t, ok)
+	})
+}
@@ -0,0 +1,485 @@
+package mirrors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/client"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/version"
+)
+
+// 项目数据的来源
+const (
+	// SourceJSONAPI 数据来自 /pypi/<name>/json
+	SourceJSONAPI = "json"
+
+	// SourceSimple 数据来自Simple API的项目页面
+	SourceSimple = "simple"
+)
+
+// HashMismatch 同名文件在镜像和官方源上的sha256不一致
+// PyPI上的文件一经上传不可修改，哈希不一致意味着镜像上的文件被篡改或损坏
+type HashMismatch struct {
+	// Filename 文件名
+	Filename string
+
+	// Official 官方源给出的sha256
+	Official string
+
+	// Mirror 镜像给出的sha256
+	Mirror string
+}
+
+// ProjectCheck 一个项目在某个镜像上的核对结果
+type ProjectCheck struct {
+	// Project 项目名
+	Project string
+
+	// Source 镜像数据的来源，SourceJSONAPI或SourceSimple
+	Source string
+
+	// Serial 镜像上项目的last_serial，无法获取时为0
+	Serial int
+
+	// OfficialSerial 官方源上项目的last_serial，无法获取时为0
+	OfficialSerial int
+
+	// Lag 镜像落后官方源的序列号数，无法比较时为-1
+	Lag int
+
+	// MissingReleases 官方源有而镜像没有的版本，已按PEP 440排序
+	MissingReleases []string
+
+	// ExtraReleases 镜像有而官方源没有的版本，通常是官方源已删除而镜像未同步
+	ExtraReleases []string
+
+	// MissingFiles 官方源有而镜像没有的文件，已排序
+	MissingFiles []string
+
+	// ExtraFiles 镜像有而官方源没有的文件，已排序
+	ExtraFiles []string
+
+	// Mismatches 哈希不一致的文件，按文件名排序
+	Mismatches []HashMismatch
+
+	// Unverified 至少一方没有提供sha256而无法核对的文件，已排序
+	Unverified []string
+
+	// Err 请求镜像或官方源失败的原因，此时其他字段无意义
+	Err error
+}
+
+// Consistent 检查镜像上的项目是否与官方源完全一致
+func (c *ProjectCheck) Consistent() bool {
+	return c.Err == nil && len(c.MissingReleases) == 0 && len(c.ExtraReleases) == 0 &&
+		len(c.MissingFiles) == 0 && len(c.ExtraFiles) == 0 && len(c.Mismatches) == 0
+}
+
+// Tampered 检查是否存在哈希不一致的文件
+func (c *ProjectCheck) Tampered() bool {
+	return len(c.Mismatches) > 0
+}
+
+// MirrorCheck 一个镜像的核对结果
+type MirrorCheck struct {
+	// Mirror 被核对的镜像
+	Mirror Mirror
+
+	// Projects 各项目的核对结果，与Verify的projects参数顺序一致
+	Projects []*ProjectCheck
+
+	// Lag 各项目中最大的落后序列号数，所有项目都无法比较时为-1
+	Lag int
+
+	// Stale Lag超过Verifier允许的最大值
+	Stale bool
+}
+
+// Consistent 检查所有项目是否都与官方源一致
+func (m *MirrorCheck) Consistent() bool {
+	for _, p := range m.Projects {
+		if !p.Consistent() {
+			return false
+		}
+	}
+	return true
+}
+
+// Tampered 检查是否有项目存在哈希不一致的文件
+func (m *MirrorCheck) Tampered() bool {
+	for _, p := range m.Projects {
+		if p.Tampered() {
+			return true
+		}
+	}
+	return false
+}
+
+// Mismatches 返回所有项目中哈希不一致的文件，键为项目名
+func (m *MirrorCheck) Mismatches() map[string][]HashMismatch {
+	result := map[string][]HashMismatch{}
+	for _, p := range m.Projects {
+		if len(p.Mismatches) > 0 {
+			result[p.Project] = p.Mismatches
+		}
+	}
+	return result
+}
+
+// Verification 一次核对的结果
+type Verification struct {
+	// Official 作为基准的索引
+	Official Mirror
+
+	// Mirrors 各镜像的核对结果，与Verify的mirrors参数顺序一致
+	Mirrors []*MirrorCheck
+
+	// CheckedAt 核对开始的时间
+	CheckedAt time.Time
+}
+
+// Tampered 返回存在哈希不一致文件的镜像
+func (v *Verification) Tampered() []*MirrorCheck {
+	var result []*MirrorCheck
+	for _, m := range v.Mirrors {
+		if m.Tampered() {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// Verifier 核对镜像与官方源上同一批项目的发布版本、文件哈希和序列号
+// 对提供JSON API的索引使用 /pypi/<name>/json 中的 digests.sha256，否则使用Simple API页面中的文件哈希
+type Verifier struct {
+	httpClient  *http.Client
+	userAgent   string
+	official    Mirror
+	maxLag      int
+	concurrency int
+}
+
+// NewVerifier 创建核对器，默认以PyPI官方源为基准，超时时间为DefaultProbeTimeout，不允许落后
+//
+// 使用示例:
+//
+//	result := mirrors.NewVerifier().WithMaxLag(100).Verify(ctx, mirrors.Default.InRegion(mirrors.RegionCN), []string{"requests", "numpy"})
+//	for _, m := range result.Tampered() {
+//		fmt.Println(m.Mirror.Name, m.Mismatches())
+//	}
+func NewVerifier() *Verifier {
+	return &Verifier{
+		httpClient:  &http.Client{Timeout: DefaultProbeTimeout},
+		userAgent:   client.DefaultUserAgent,
+		official:    Builtin()[0],
+		concurrency: DefaultProbeConcurrency,
+	}
+}
+
+// WithHTTPClient 设置发送请求的HTTP客户端
+func (v *Verifier) WithHTTPClient(c *http.Client) *Verifier {
+	v.httpClient = c
+	return v
+}
+
+// WithTimeout 设置单个请求的超时时间
+func (v *Verifier) WithTimeout(timeout time.Duration) *Verifier {
+	c := *v.httpClient
+	c.Timeout = timeout
+	v.httpClient = &c
+	return v
+}
+
+// WithUserAgent 设置User-Agent请求头
+func (v *Verifier) WithUserAgent(userAgent string) *Verifier {
+	v.userAgent = userAgent
+	return v
+}
+
+// WithOfficial 设置作为基准的索引，默认为PyPI官方源
+func (v *Verifier) WithOfficial(m Mirror) *Verifier {
+	v.official = m
+	return v
+}
+
+// WithMaxLag 设置允许落后的最大序列号数，超过时MirrorCheck.Stale为true
+func (v *Verifier) WithMaxLag(n int) *Verifier {
+	v.maxLag = n
+	return v
+}
+
+// WithConcurrency 设置同时发出的请求数，小于1时按1处理
+func (v *Verifier) WithConcurrency(n int) *Verifier {
+	if n < 1 {
+		n = 1
+	}
+	v.concurrency = n
+	return v
+}
+
+// Verify 核对各镜像上的项目与官方源是否一致
+// 官方源上的每个项目只请求一次；镜像上不存在的项目视为缺少所有版本，而不是请求失败
+//
+// 参数:
+//   - ctx: 上下文，用于控制请求的生命周期
+//   - mirrors: 要核对的镜像
+//   - projects: 要核对的项目名
+//
+// 返回值:
+//   - *Verification: 核对结果
+func (v *Verifier) Verify(ctx context.Context, mirrors []Mirror, projects []string) *Verification {
+	result := &Verification{Official: v.official, CheckedAt: time.Now()}
+
+	official := make([]*snapshot, len(projects))
+	officialErrs := make([]error, len(projects))
+	v.parallel(len(projects), func(i int) {
+		official[i], officialErrs[i] = v.snapshot(ctx, v.official, projects[i])
+	})
+
+	for _, m := range mirrors {
+		result.Mirrors = append(result.Mirrors, &MirrorCheck{Mirror: m, Projects: make([]*ProjectCheck, len(projects))})
+	}
+	v.parallel(len(mirrors)*len(projects), func(k int) {
+		check, i := result.Mirrors[k/len(projects)], k%len(projects)
+		project := &ProjectCheck{Project: projects[i], Lag: -1}
+		check.Projects[i] = project
+		if officialErrs[i] != nil {
+			project.Err = fmt.Errorf("官方源: %w", officialErrs[i])
+			return
+		}
+
+		mirrorSnapshot := official[i]
+		if !sameIndex(check.Mirror, v.official) {
+			var err error
+			mirrorSnapshot, err = v.snapshot(ctx, check.Mirror, projects[i])
+			if errors.Is(err, client.ErrNotFound) {
+				mirrorSnapshot, err = &snapshot{source: SourceSimple, releases: map[string]bool{}, files: map[string]string{}}, nil
+				if check.Mirror.JSONAPI {
+					mirrorSnapshot.source = SourceJSONAPI
+				}
+			}
+			if err != nil {
+				project.Err = err
+				return
+			}
+		}
+		compareSnapshots(project, official[i], mirrorSnapshot)
+	})
+
+	for _, check := range result.Mirrors {
+		check.Lag = -1
+		for _, p := range check.Projects {
+			if p.Lag > check.Lag {
+				check.Lag = p.Lag
+			}
+		}
+		check.Stale = check.Lag > v.maxLag
+	}
+	return result
+}
+
+// parallel 以配置的并发数执行n个任务
+func (v *Verifier) parallel(n int, task func(i int)) {
+	sem := make(chan struct{}, v.concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			task(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// snapshot 一个项目在某个索引上的版本和文件
+type snapshot struct {
+	source   string
+	serial   int
+	releases map[string]bool
+	files    map[string]string
+}
+
+// snapshot 获取项目在索引上的数据，项目不存在时返回包装了client.ErrNotFound的错误
+func (v *Verifier) snapshot(ctx context.Context, m Mirror, project string) (*snapshot, error) {
+	name := models.NormalizeName(project)
+	if m.JSONAPI {
+		pageURL := m.Layout().JSONURL(m.URL, name)
+		body, _, err := v.get(ctx, pageURL, "application/json")
+		if err != nil {
+			return nil, err
+		}
+		var pkg models.Package
+		if err := json.Unmarshal(body, &pkg); err != nil {
+			return nil, fmt.Errorf("解析 %s 失败: %w", pageURL, err)
+		}
+		return snapshotFromPackage(&pkg), nil
+	}
+
+	pageURL := m.Layout().SimpleProjectURL(m.URL, name)
+	body, header, err := v.get(ctx, pageURL, simpleAccept)
+	if err != nil {
+		return nil, err
+	}
+	page, err := client.ParseSimpleProject(body, name)
+	if err != nil {
+		return nil, err
+	}
+	isJSON := strings.HasPrefix(header.Get("Content-Type"), "application/vnd.pypi.simple.v1+json")
+	return snapshotFromSimple(page, parseSerial(header, body, isJSON)), nil
+}
+
+// get 发出GET请求并读取响应体
+func (v *Verifier) get(ctx context.Context, pageURL, accept string) ([]byte, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("User-Agent", v.userAgent)
+	req.Header.Set("Accept", accept)
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("请求 %s 失败: %w", pageURL, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProbeBody))
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取 %s 失败: %w", pageURL, err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, nil, fmt.Errorf("%w: %s", client.ErrNotFound, pageURL)
+	case resp.StatusCode != http.StatusOK:
+		return nil, nil, fmt.Errorf("请求 %s 失败: HTTP %d", pageURL, resp.StatusCode)
+	}
+	return body, resp.Header, nil
+}
+
+// snapshotFromPackage 从JSON API的响应中提取版本和文件，没有文件的版本不计入
+func snapshotFromPackage(pkg *models.Package) *snapshot {
+	s := &snapshot{source: SourceJSONAPI, serial: pkg.LastSerial, releases: map[string]bool{}, files: map[string]string{}}
+	for v, files := range pkg.Releases {
+		if len(files) == 0 {
+			continue
+		}
+		s.releases[canonicalVersion(v)] = true
+		for _, f := range files {
+			s.files[f.Filename] = strings.ToLower(f.Digests.SHA256)
+		}
+	}
+	return s
+}
+
+// snapshotFromSimple 从Simple API页面中提取文件，版本号从文件名中解析
+func snapshotFromSimple(page *models.SimpleProject, serial int) *snapshot {
+	s := &snapshot{source: SourceSimple, serial: serial, releases: map[string]bool{}, files: map[string]string{}}
+	for _, f := range page.Files {
+		s.files[f.Filename] = strings.ToLower(f.Hashes["sha256"])
+		if v := versionFromFilename(f.Filename); v != "" {
+			s.releases[canonicalVersion(v)] = true
+		}
+	}
+	return s
+}
+
+// compareSnapshots 比较镜像与官方源的数据并填写核对结果
+func compareSnapshots(check *ProjectCheck, official, mirror *snapshot) {
+	check.Source = mirror.source
+	check.Serial = mirror.serial
+	check.OfficialSerial = official.serial
+	if mirror.serial > 0 && official.serial > 0 {
+		check.Lag = official.serial - mirror.serial
+		if check.Lag < 0 {
+			// 官方源在两次请求之间发生了更新
+			check.Lag = 0
+		}
+	}
+
+	// 两侧数据来源不同时，只有JSON API能看到没有文件的版本，因此只比较有文件的版本
+	for v := range official.releases {
+		if !mirror.releases[v] {
+			check.MissingReleases = append(check.MissingReleases, v)
+		}
+	}
+	for v := range mirror.releases {
+		if !official.releases[v] {
+			check.ExtraReleases = append(check.ExtraReleases, v)
+		}
+	}
+	version.SortStrings(check.MissingReleases)
+	version.SortStrings(check.ExtraReleases)
+
+	for filename, expected := range official.files {
+		actual, ok := mirror.files[filename]
+		switch {
+		case !ok:
+			check.MissingFiles = append(check.MissingFiles, filename)
+		case expected == "" || actual == "":
+			check.Unverified = append(check.Unverified, filename)
+		case expected != actual:
+			check.Mismatches = append(check.Mismatches, HashMismatch{Filename: filename, Official: expected, Mirror: actual})
+		}
+	}
+	for filename := range mirror.files {
+		if _, ok := official.files[filename]; !ok {
+			check.ExtraFiles = append(check.ExtraFiles, filename)
+		}
+	}
+	sort.Strings(check.MissingFiles)
+	sort.Strings(check.ExtraFiles)
+	sort.Strings(check.Unverified)
+	sort.Slice(check.Mismatches, func(i, j int) bool {
+		return check.Mismatches[i].Filename < check.Mismatches[j].Filename
+	})
+}
+
+// sdistExtensions 源码包及旧格式发布文件的扩展名
+var sdistExtensions = []string{".tar.gz", ".tar.bz2", ".tar.xz", ".tgz", ".zip", ".tar"}
+
+// versionFromFilename 从发布文件名中解析版本号，无法识别时返回空字符串
+// wheel和egg的版本号是第二个以 "-" 分隔的字段，源码包的版本号在最后一个 "-" 之后
+func versionFromFilename(filename string) string {
+	lower := strings.ToLower(filename)
+	if strings.HasSuffix(lower, ".whl") || strings.HasSuffix(lower, ".egg") {
+		parts := strings.Split(filename, "-")
+		if len(parts) < 2 {
+			return ""
+		}
+		return parts[1]
+	}
+	for _, ext := range sdistExtensions {
+		if strings.HasSuffix(lower, ext) {
+			base := filename[:len(filename)-len(ext)]
+			if dash := strings.LastIndex(base, "-"); dash > 0 {
+				return base[dash+1:]
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+// canonicalVersion 返回PEP 440规范化的版本号，无效的版本号原样返回
+func canonicalVersion(v string) string {
+	if parsed, err := version.Parse(v); err == nil {
+		return parsed.String()
+	}
+	return v
+}
+
+// sameIndex 检查两个镜像是否指向同一个索引
+func sameIndex(a, b Mirror) bool {
+	return strings.TrimSuffix(a.URL, "/") == strings.TrimSuffix(b.URL, "/")
+}
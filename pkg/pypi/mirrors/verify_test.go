@@ -0,0 +1,147 @@
+package mirrors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const verifyOfficialJSON = `{
+  "info": {"name": "demo", "version": "1.1"},
+  "last_serial": 100,
+  "releases": {
+    "0.9": [],
+    "1.0": [
+      {"filename": "demo-1.0.tar.gz", "digests": {"sha256": "AAAA"}},
+      {"filename": "demo-1.0-py3-none-any.whl", "digests": {"sha256": "bbbb"}}
+    ],
+    "1.1": [
+      {"filename": "demo-1.1-py3-none-any.whl", "digests": {"sha256": "cccc"}}
+    ]
+  }
+}`
+
+func TestVerifier(t *testing.T) {
+	official := newIndexServer(t, "/pypi/demo/json", func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, verifyOfficialJSON)
+	})
+	synced := newIndexServer(t, "/pypi/simple/demo/", func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body>
+<a href="../../packages/demo-1.0.tar.gz#sha256=aaaa">demo-1.0.tar.gz</a>
+<a href="../../packages/demo-1.0-py3-none-any.whl#sha256=bbbb">demo-1.0-py3-none-any.whl</a>
+<a href="../../packages/demo-1.1-py3-none-any.whl#sha256=cccc">demo-1.1-py3-none-any.whl</a>
+</body></html>
+<!--SERIAL 100-->`)
+	})
+	tampered := newIndexServer(t, "/simple/demo/", func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/vnd.pypi.simple.v1+json")
+		fmt.Fprint(w, `{"meta": {"api-version": "1.1", "_last-serial": 90}, "name": "demo", "files": [
+  {"filename": "demo-1.0.tar.gz", "url": "demo-1.0.tar.gz", "hashes": {"sha256": "aaaa"}},
+  {"filename": "demo-1.0-py3-none-any.whl", "url": "demo-1.0-py3-none-any.whl", "hashes": {"sha256": "dddd"}},
+  {"filename": "demo-0.8.zip", "url": "demo-0.8.zip", "hashes": {}}
+]}`)
+	})
+	missing := newIndexServer(t, "/simple/other/", func(w http.ResponseWriter) {})
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(broken.Close)
+
+	officialMirror := Mirror{Name: "official", URL: official.URL, JSONAPI: true}
+	mirrors := []Mirror{
+		{Name: "synced", URL: synced.URL, SimplePath: "/pypi/simple/"},
+		{Name: "tampered", URL: tampered.URL},
+		{Name: "missing", URL: missing.URL},
+		{Name: "broken", URL: broken.URL},
+		officialMirror,
+	}
+	verifier := NewVerifier().WithOfficial(officialMirror).WithMaxLag(5).WithTimeout(5 * time.Second)
+	result := verifier.Verify(context.Background(), mirrors, []string{"Demo"})
+	require.Len(t, result.Mirrors, 5)
+
+	t.Run("与官方源一致", func(t *testing.T) {
+		check := result.Mirrors[0]
+		require.Len(t, check.Projects, 1)
+		p := check.Projects[0]
+		require.NoError(t, p.Err)
+		assert.True(t, p.Consistent())
+		assert.Equal(t, SourceSimple, p.Source)
+		assert.Equal(t, 100, p.Serial)
+		assert.Equal(t, 0, p.Lag)
+		assert.False(t, check.Stale)
+		assert.True(t, check.Consistent())
+	})
+
+	t.Run("落后且文件被篡改", func(t *testing.T) {
+		check := result.Mirrors[1]
+		p := check.Projects[0]
+		require.NoError(t, p.Err)
+		assert.Equal(t, 10, p.Lag)
+		assert.Equal(t, 10, check.Lag)
+		assert.True(t, check.Stale)
+		assert.Equal(t, []string{"1.1"}, p.MissingReleases)
+		assert.Equal(t, []string{"0.8"}, p.ExtraReleases)
+		assert.Equal(t, []string{"demo-1.1-py3-none-any.whl"}, p.MissingFiles)
+		assert.Equal(t, []string{"demo-0.8.zip"}, p.ExtraFiles)
+		assert.Equal(t, []HashMismatch{{Filename: "demo-1.0-py3-none-any.whl", Official: "bbbb", Mirror: "dddd"}}, p.Mismatches)
+		assert.True(t, check.Tampered())
+		assert.Equal(t, map[string][]HashMismatch{"Demo": p.Mismatches}, check.Mismatches())
+	})
+
+	t.Run("镜像上不存在的项目", func(t *testing.T) {
+		p := result.Mirrors[2].Projects[0]
+		require.NoError(t, p.Err)
+		assert.Equal(t, []string{"1.0", "1.1"}, p.MissingReleases)
+		assert.Len(t, p.MissingFiles, 3)
+		assert.Equal(t, -1, p.Lag)
+		assert.False(t, p.Tampered())
+	})
+
+	t.Run("请求失败", func(t *testing.T) {
+		check := result.Mirrors[3]
+		assert.Error(t, check.Projects[0].Err)
+		assert.False(t, check.Consistent())
+		assert.Equal(t, -1, check.Lag)
+		assert.False(t, check.Stale)
+	})
+
+	t.Run("官方源本身", func(t *testing.T) {
+		p := result.Mirrors[4].Projects[0]
+		assert.True(t, p.Consistent())
+		assert.Equal(t, SourceJSONAPI, p.Source)
+	})
+
+	t.Run("只有篡改的镜像被报告", func(t *testing.T) {
+		tampered := result.Tampered()
+		require.Len(t, tampered, 1)
+		assert.Equal(t, "tampered", tampered[0].Mirror.Name)
+	})
+
+	t.Run("官方源上不存在的项目", func(t *testing.T) {
+		result := verifier.Verify(context.Background(), mirrors[:1], []string{"nonexistent"})
+		assert.ErrorContains(t, result.Mirrors[0].Projects[0].Err, "官方源")
+	})
+}
+
+func TestVersionFromFilename(t *testing.T) {
+	tests := map[string]string{
+		"requests-2.31.0-py3-none-any.whl":             "2.31.0",
+		"requests-2.31.0.tar.gz":                       "2.31.0",
+		"python-dateutil-2.8.2.tar.gz":                 "2.8.2",
+		"zope.interface-6.0-cp311-cp311-win_amd64.whl": "6.0",
+		"setuptools-0.6c11-py2.7.egg":                  "0.6c11",
+		"demo.exe":                                     "",
+		"nodash.tar.gz":                                "",
+	}
+	for filename, expected := range tests {
+		assert.Equal(t, expected, versionFromFilename(filename), filename)
+	}
+}
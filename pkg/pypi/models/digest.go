@@ -0,0 +1,147 @@
+package models
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+var (
+	// ErrDigestMismatch 表示内容与索引中的哈希不一致
+	ErrDigestMismatch = errors.New("文件哈希不一致")
+
+	// ErrNoVerifiableDigest 表示索引没有提供任何可以校验的哈希
+	ErrNoVerifiableDigest = errors.New("没有可校验的哈希")
+)
+
+// verifiableAlgorithms 可以校验的哈希算法，按强度从高到低排列
+var verifiableAlgorithms = []string{"sha512", "sha384", "sha256", "sha1", "md5"}
+
+// NewDigestHash 创建指定算法的哈希函数
+//
+// 参数:
+//   - algorithm: 算法名，与hashlib相同，如 "sha256"
+//
+// 返回值:
+//   - hash.Hash: 不支持的算法（如blake2b_256）返回nil
+func NewDigestHash(algorithm string) hash.Hash {
+	switch algorithm {
+	case "sha256":
+		return sha256.New()
+	case "sha384":
+		return sha512.New384()
+	case "sha512":
+		return sha512.New()
+	case "sha1":
+		return sha1.New()
+	case "md5":
+		return md5.New()
+	}
+	return nil
+}
+
+// StrongestDigest 从哈希值中选择可以校验的最强的算法
+//
+// 参数:
+//   - hashes: 哈希值，键为算法名，如Simple API的hashes或core-metadata字段
+//
+// 返回值:
+//   - algorithm: 算法名，没有可校验的哈希时为空字符串
+//   - digest: 该算法的哈希值
+func StrongestDigest(hashes map[string]string) (algorithm, digest string) {
+	for _, candidate := range verifiableAlgorithms {
+		if digest := hashes[candidate]; digest != "" {
+			return candidate, digest
+		}
+	}
+	return "", ""
+}
+
+// NewDigestVerifier 创建使用最强的可校验哈希的校验器
+//
+// 参数:
+//   - hashes: 哈希值，键为算法名
+//
+// 返回值:
+//   - *DigestVerifier: 写入全部内容后调用Verify校验
+//   - error: 没有可校验的哈希时返回ErrNoVerifiableDigest
+//
+// 使用示例:
+//
+//	v, err := models.NewDigestVerifier(file.Hashes)
+//	if err != nil {
+//		return err
+//	}
+//	if _, err := io.Copy(io.MultiWriter(dst, v), resp.Body); err != nil {
+//		return err
+//	}
+//	return v.Verify()
+func NewDigestVerifier(hashes map[string]string) (*DigestVerifier, error) {
+	algorithm, digest := StrongestDigest(hashes)
+	if algorithm == "" {
+		return nil, ErrNoVerifiableDigest
+	}
+	return &DigestVerifier{Algorithm: algorithm, Expected: digest, h: NewDigestHash(algorithm)}, nil
+}
+
+// Strongest 返回可以校验的最强的哈希算法及其值，没有可校验的哈希时返回空字符串
+func (d *ReleaseDigests) Strongest() (algorithm, digest string) {
+	return StrongestDigest(d.All())
+}
+
+// Verifier 创建使用最强的可校验哈希的校验器，没有可校验的哈希时返回ErrNoVerifiableDigest
+func (d *ReleaseDigests) Verifier() (*DigestVerifier, error) {
+	return NewDigestVerifier(d.All())
+}
+
+// DigestVerifier 边写入边计算哈希，写完后与期望的哈希比较
+type DigestVerifier struct {
+	// Algorithm 校验使用的哈希算法
+	Algorithm string
+
+	// Expected 期望的十六进制哈希值
+	Expected string
+
+	h hash.Hash
+}
+
+// Write 实现io.Writer接口
+func (v *DigestVerifier) Write(p []byte) (int, error) {
+	return v.h.Write(p)
+}
+
+// Verify 比较已写入内容的哈希，不一致时返回*DigestError
+func (v *DigestVerifier) Verify() error {
+	if actual := hex.EncodeToString(v.h.Sum(nil)); !strings.EqualFold(actual, v.Expected) {
+		return &DigestError{Algorithm: v.Algorithm, Expected: v.Expected, Actual: actual}
+	}
+	return nil
+}
+
+// DigestError 内容与期望的哈希不一致，匹配ErrDigestMismatch
+type DigestError struct {
+	// Algorithm 校验使用的哈希算法
+	Algorithm string
+
+	// Expected 期望的哈希
+	Expected string
+
+	// Actual 内容的哈希
+	Actual string
+}
+
+// Error 实现error接口
+func (e *DigestError) Error() string {
+	return fmt.Sprintf("%s哈希不一致: 期望 %s，实际 %s", e.Algorithm, e.Expected, e.Actual)
+}
+
+// Is 使errors.Is(err, ErrDigestMismatch)返回true
+func (e *DigestError) Is(target error) bool {
+	return target == ErrDigestMismatch
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDigestHash(t *testing.T) {
+	for _, algorithm := range verifiableAlgorithms {
+		assert.NotNil(t, NewDigestHash(algorithm), algorithm)
+	}
+	assert.Nil(t, NewDigestHash("blake2b_256"))
+}
+
+func TestReleaseDigests_Strongest(t *testing.T) {
+	t.Run("选择最强的算法", func(t *testing.T) {
+		d := &ReleaseDigests{MD5: "m", SHA256: "s", Extra: map[string]string{"sha384": "x"}}
+		algorithm, digest := d.Strongest()
+		assert.Equal(t, "sha384", algorithm)
+		assert.Equal(t, "x", digest)
+	})
+
+	t.Run("只有不支持的算法", func(t *testing.T) {
+		d := &ReleaseDigests{Blake2b256: "b"}
+		algorithm, _ := d.Strongest()
+		assert.Empty(t, algorithm)
+
+		_, err := d.Verifier()
+		assert.ErrorIs(t, err, ErrNoVerifiableDigest)
+	})
+}
+
+func TestDigestVerifier(t *testing.T) {
+	sum := sha512.Sum512([]byte("content"))
+	sha256Sum := sha256.Sum256([]byte("other"))
+	d := &ReleaseDigests{SHA256: hex.EncodeToString(sha256Sum[:]), Extra: map[string]string{"sha512": strings.ToUpper(hex.EncodeToString(sum[:]))}}
+
+	t.Run("哈希一致", func(t *testing.T) {
+		v, err := d.Verifier()
+		require.NoError(t, err)
+		assert.Equal(t, "sha512", v.Algorithm)
+		_, err = io.Copy(v, strings.NewReader("content"))
+		require.NoError(t, err)
+		assert.NoError(t, v.Verify())
+	})
+
+	t.Run("哈希不一致", func(t *testing.T) {
+		v, err := d.Verifier()
+		require.NoError(t, err)
+		_, err = io.Copy(v, strings.NewReader("other"))
+		require.NoError(t, err)
+
+		err = v.Verify()
+		assert.ErrorIs(t, err, ErrDigestMismatch)
+		var digestErr *DigestError
+		require.True(t, errors.As(err, &digestErr))
+		assert.Equal(t, "sha512", digestErr.Algorithm)
+	})
+}
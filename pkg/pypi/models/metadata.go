@@ -0,0 +1,126 @@
+package models
+
+import "strings"
+
+// CoreMetadata 表示发布文件中的核心元数据（METADATA 或 PKG-INFO）
+// 与PackageInfo重叠的字段使用相同的名称和JSON标签，可以通过ToPackageInfo相互转换
+type CoreMetadata struct {
+	// MetadataVersion 元数据格式版本，如 "2.1"
+	MetadataVersion string `json:"metadata_version"`
+
+	// Name 包名
+	Name string `json:"name"`
+
+	// Version 版本号
+	Version string `json:"version"`
+
+	// Summary 包的简短描述
+	Summary string `json:"summary"`
+
+	// Description 包的详细描述
+	Description string `json:"description"`
+
+	// DescriptionContentType 描述内容的MIME类型
+	DescriptionContentType string `json:"description_content_type"`
+
+	// Keywords 关键字
+	Keywords string `json:"keywords"`
+
+	// HomePage 主页URL
+	HomePage string `json:"home_page"`
+
+	// DownloadURL 下载URL
+	DownloadURL string `json:"download_url"`
+
+	// Author 作者信息
+	Author string `json:"author"`
+
+	// AuthorEmail 作者的电子邮箱
+	AuthorEmail string `json:"author_email"`
+
+	// Maintainer 维护者信息
+	Maintainer string `json:"maintainer"`
+
+	// MaintainerEmail 维护者的电子邮箱
+	MaintainerEmail string `json:"maintainer_email"`
+
+	// License 许可证
+	License string `json:"license"`
+
+	// Classifiers 分类标签列表
+	Classifiers []string `json:"classifiers"`
+
+	// Platforms 支持的平台
+	Platforms []string `json:"platform"`
+
+	// RequiresDist 依赖的其他包
+	RequiresDist []string `json:"requires_dist"`
+
+	// RequiresPython 需要的Python版本
+	RequiresPython string `json:"requires_python"`
+
+	// ProvidesExtra 提供的可选功能
+	ProvidesExtra []string `json:"provides_extra"`
+
+	// ProjectURLs 项目相关URL，键为标签
+	ProjectURLs map[string]string `json:"project_urls"`
+
+	// SupportedPlatforms 支持的平台（Supported-Platform，1.1起）
+	SupportedPlatforms []string `json:"supported_platform,omitempty"`
+
+	// RequiresExternal 依赖的外部系统组件（1.2起）
+	RequiresExternal []string `json:"requires_external,omitempty"`
+
+	// ProvidesDist 提供的其他分发名（1.2起）
+	ProvidesDist []string `json:"provides_dist,omitempty"`
+
+	// ObsoletesDist 取代的分发名（1.2起）
+	ObsoletesDist []string `json:"obsoletes_dist,omitempty"`
+
+	// Requires 依赖的模块（1.1中定义，已废弃）
+	Requires []string `json:"requires,omitempty"`
+
+	// Provides 提供的模块（1.1中定义，已废弃）
+	Provides []string `json:"provides,omitempty"`
+
+	// Obsoletes 取代的模块（1.1中定义，已废弃）
+	Obsoletes []string `json:"obsoletes,omitempty"`
+
+	// Dynamic 由构建后端在构建时填写的字段（2.2起）
+	Dynamic []string `json:"dynamic,omitempty"`
+
+	// LicenseExpression SPDX许可证表达式（2.4起）
+	LicenseExpression string `json:"license_expression,omitempty"`
+
+	// LicenseFiles 随分发包提供的许可证文件路径（2.4起）
+	LicenseFiles []string `json:"license_files,omitempty"`
+}
+
+// ToPackageInfo 将核心元数据转换为PackageInfo
+// 仅填充两者共有的字段，JSON API特有的字段（如Yanked）保持零值
+func (m *CoreMetadata) ToPackageInfo() *PackageInfo {
+	return &PackageInfo{
+		Name:                   m.Name,
+		Version:                m.Version,
+		Summary:                m.Summary,
+		Description:            m.Description,
+		DescriptionContentType: m.DescriptionContentType,
+		Author:                 m.Author,
+		AuthorEmail:            m.AuthorEmail,
+		Maintainer:             m.Maintainer,
+		MaintainerEmail:        m.MaintainerEmail,
+		License:                m.License,
+		Keywords:               m.Keywords,
+		ClassifiersArray:       m.Classifiers,
+		ProjectURLs:            m.ProjectURLs,
+		RequiresDist:           m.RequiresDist,
+		RequiresPython:         m.RequiresPython,
+		HomePage:               m.HomePage,
+		DownloadURL:            m.DownloadURL,
+		Platform:               strings.Join(m.Platforms, ", "),
+		Dynamic:                m.Dynamic,
+		ProvidesExtra:          m.ProvidesExtra,
+		LicenseExpression:      m.LicenseExpression,
+		LicenseFiles:           m.LicenseFiles,
+	}
+}
@@ -1,5 +1,11 @@
 package models
 
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+)
+
 // Package 表示从PyPI获取的包信息
 // 包含包的基本元数据、发布版本信息和漏洞信息
 type Package struct {
@@ -18,6 +24,57 @@ type Package struct {
 
 	// Vulnerabilities 已知的安全漏洞信息
 	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+
+	// Extra 模型中未映射的字段，编码时原样输出
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON 解码包信息并保留未映射的字段
+func (p *Package) UnmarshalJSON(data []byte) error {
+	type plain Package
+	extra, err := unmarshalWithExtra(data, (*plain)(p))
+	if err != nil {
+		return err
+	}
+	p.Extra = extra
+	return nil
+}
+
+// MarshalJSON 编码包信息，包括未映射的字段
+func (p Package) MarshalJSON() ([]byte, error) {
+	type plain Package
+	return marshalWithExtra(plain(p), p.Extra)
+}
+
+// VulnerabilitiesFor 返回影响指定版本且未撤回的漏洞，版本比较遵循PEP 440
+func (p *Package) VulnerabilitiesFor(version string) []Vulnerability {
+	return AffectingVulnerabilities(p.Vulnerabilities, version)
+}
+
+// UpgradePath 返回能消除全部已知漏洞的最小升级版本
+// 候选为Releases中的版本，所有文件都已撤回的版本除外；当前版本已安全时返回当前版本
+//
+// 使用示例:
+//
+//	pkg, _ := client.GetPackageInfo(ctx, "jinja2")
+//	if target, ok := pkg.UpgradePath("2.11.2"); ok {
+//		fmt.Println("升级到", target)
+//	}
+func (p *Package) UpgradePath(current string) (string, bool) {
+	candidates := make([]string, 0, len(p.Releases))
+	for v, files := range p.Releases {
+		yanked := len(files) > 0
+		for _, f := range files {
+			if !f.IsYanked() {
+				yanked = false
+				break
+			}
+		}
+		if !yanked {
+			candidates = append(candidates, v)
+		}
+	}
+	return SafeVersion(current, p.Vulnerabilities, candidates)
 }
 
 // PackageInfo 包含包的详细元数据
@@ -84,6 +141,69 @@ type PackageInfo struct {
 
 	// YankedReason 包被撤回的原因
 	YankedReason string `json:"yanked_reason,omitempty"`
+
+	// BugtrackURL 问题跟踪URL（已废弃，通常为null）
+	BugtrackURL string `json:"bugtrack_url"`
+
+	// Downloads 下载统计（PyPI已停用，各项通常为-1）
+	Downloads DownloadStats `json:"downloads"`
+
+	// PackageURL 包在PyPI上的页面URL
+	PackageURL string `json:"package_url"`
+
+	// ReleaseURL 当前版本在PyPI上的页面URL
+	ReleaseURL string `json:"release_url"`
+
+	// Platform 平台信息
+	Platform string `json:"platform"`
+
+	// Dynamic 由构建后端动态生成的元数据字段
+	Dynamic []string `json:"dynamic,omitempty"`
+
+	// ProvidesExtra 提供的可选功能
+	ProvidesExtra []string `json:"provides_extra,omitempty"`
+
+	// LicenseExpression SPDX许可证表达式
+	LicenseExpression string `json:"license_expression,omitempty"`
+
+	// LicenseFiles 许可证文件路径
+	LicenseFiles []string `json:"license_files,omitempty"`
+
+	// Extra 模型中未映射的字段，编码时原样输出
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// DownloadStats 包的下载统计
+type DownloadStats struct {
+	// LastDay 最近一天的下载次数
+	LastDay int `json:"last_day"`
+
+	// LastWeek 最近一周的下载次数
+	LastWeek int `json:"last_week"`
+
+	// LastMonth 最近一个月的下载次数
+	LastMonth int `json:"last_month"`
+}
+
+// packageInfoNullable PyPI在没有值时返回null的字段
+var packageInfoNullable = []string{"bugtrack_url", "docs_url", "platform", "yanked_reason"}
+
+// UnmarshalJSON 解码包元数据并保留未映射的字段
+func (p *PackageInfo) UnmarshalJSON(data []byte) error {
+	type plain PackageInfo
+	extra, err := unmarshalWithExtra(data, (*plain)(p))
+	if err != nil {
+		return err
+	}
+	p.Extra = extra
+	return nil
+}
+
+// MarshalJSON 编码包元数据，包括未映射的字段
+// 可为null的字段在为空时编码为null，与PyPI的响应一致
+func (p PackageInfo) MarshalJSON() ([]byte, error) {
+	type plain PackageInfo
+	return marshalWithExtra(plain(p), p.Extra, packageInfoNullable...)
 }
 
 // GetAllDependencies 返回包的所有依赖列表
@@ -113,3 +233,23 @@ func (p *PackageInfo) GetProjectURLs() map[string]string {
 	}
 	return p.ProjectURLs
 }
+
+// NormalizeName 按照PEP 503规范化包名
+// 连续的 "-"、"_"、"." 被替换为单个 "-"，并转换为小写
+func NormalizeName(name string) string {
+	var b strings.Builder
+	b.Grow(len(name))
+	lastSeparator := false
+	for _, r := range strings.TrimSpace(name) {
+		if r == '-' || r == '_' || r == '.' {
+			if !lastSeparator {
+				b.WriteByte('-')
+			}
+			lastSeparator = true
+			continue
+		}
+		lastSeparator = false
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
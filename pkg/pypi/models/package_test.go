@@ -106,3 +106,41 @@ func TestPackageInfo_GetProjectURLs(t *testing.T) {
 		assert.NotNil(t, urls)
 	})
 }
+
+func TestNormalizeName(t *testing.T) {
+	assert.Equal(t, "requests", NormalizeName("Requests"))
+	assert.Equal(t, "zope-interface", NormalizeName("zope.interface"))
+	assert.Equal(t, "typing-extensions", NormalizeName("typing_extensions"))
+	assert.Equal(t, "a-b", NormalizeName("A-_.B"))
+}
+
+func TestPackage_UpgradePath(t *testing.T) {
+	pkg := &Package{
+		Releases: map[string][]*ReleaseFile{
+			"1.0.0": {{Filename: "demo-1.0.0.tar.gz"}},
+			"1.0.1": {{Filename: "demo-1.0.1.tar.gz", Yanked: true}},
+			"1.0.2": {{Filename: "demo-1.0.2.tar.gz"}},
+			"2.0.0": {{Filename: "demo-2.0.0.tar.gz"}},
+		},
+		Vulnerabilities: []Vulnerability{
+			{ID: "PYSEC-1", FixedIn: []string{"1.0.1"}},
+		},
+	}
+
+	t.Run("受影响的漏洞", func(t *testing.T) {
+		assert.Len(t, pkg.VulnerabilitiesFor("1.0.0"), 1)
+		assert.Empty(t, pkg.VulnerabilitiesFor("1.0.2"))
+	})
+
+	t.Run("跳过已撤回的版本", func(t *testing.T) {
+		target, ok := pkg.UpgradePath("1.0.0")
+		assert.True(t, ok)
+		assert.Equal(t, "1.0.2", target)
+	})
+
+	t.Run("当前版本已安全", func(t *testing.T) {
+		target, ok := pkg.UpgradePath("2.0.0")
+		assert.True(t, ok)
+		assert.Equal(t, "2.0.0", target)
+	})
+}
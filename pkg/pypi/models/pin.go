@@ -0,0 +1,85 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Pin 表示固定到某个确切版本的包，如 "requests@2.31.0"
+// 各种锁文件读取后都转换为Pin，可直接用于漏洞检查和SBOM生成
+type Pin struct {
+	// Name 包名
+	Name string `json:"name"`
+
+	// Version 固定的版本号
+	Version string `json:"version"`
+
+	// Hashes 允许的发布文件哈希，格式为 "算法:十六进制值"
+	Hashes []string `json:"hashes,omitempty"`
+
+	// Index 包所在的索引地址，为空表示默认索引（PyPI）
+	Index string `json:"index,omitempty"`
+
+	// URL 不从索引安装时的直接引用（VCS、本地路径或文件URL）
+	URL string `json:"url,omitempty"`
+
+	// Marker 安装该包的环境标记，为空表示所有环境都安装
+	Marker string `json:"marker,omitempty"`
+
+	// Groups 包所属的依赖分组，如 "main"、"dev"
+	Groups []string `json:"groups,omitempty"`
+}
+
+// ParsePin 解析 "name@version" 或 "name==version" 形式的版本固定
+//
+// 参数:
+//   - s: 版本固定字符串
+//
+// 返回值:
+//   - Pin: 解析后的版本固定
+//   - error: 缺少包名或版本号时返回
+//
+// 使用示例:
+//
+//	pin, err := models.ParsePin("requests==2.31.0")
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(pin.Name, pin.Version) // requests 2.31.0
+func ParsePin(s string) (Pin, error) {
+	s = strings.TrimSpace(s)
+	name, version, ok := strings.Cut(s, "==")
+	if !ok {
+		name, version, ok = strings.Cut(s, "@")
+	}
+	name, version = strings.TrimSpace(name), strings.TrimSpace(version)
+	if !ok || name == "" || version == "" {
+		return Pin{}, fmt.Errorf("无效的版本固定 %q，应为 name@version 或 name==version", s)
+	}
+	return Pin{Name: name, Version: version}, nil
+}
+
+// String 返回 "name@version" 形式
+func (p Pin) String() string {
+	return p.Name + "@" + p.Version
+}
+
+// NormalizedName 返回按PEP 503规范化的包名
+func (p Pin) NormalizedName() string {
+	return NormalizeName(p.Name)
+}
+
+// IsDirect 检查包是否通过直接引用安装，此时无法通过索引查询
+func (p Pin) IsDirect() bool {
+	return p.URL != ""
+}
+
+// InGroup 检查包是否属于指定分组
+func (p Pin) InGroup(group string) bool {
+	for _, g := range p.Groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
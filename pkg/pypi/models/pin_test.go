@@ -0,0 +1,31 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePin(t *testing.T) {
+	for _, s := range []string{"Flask_Login@0.6.3", "Flask_Login==0.6.3", " Flask_Login == 0.6.3 "} {
+		pin, err := ParsePin(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, Pin{Name: "Flask_Login", Version: "0.6.3"}, pin)
+		assert.Equal(t, "flask-login", pin.NormalizedName())
+		assert.Equal(t, "Flask_Login@0.6.3", pin.String())
+	}
+
+	for _, s := range []string{"", "requests", "requests@", "==1.0", "requests>=2.0"} {
+		_, err := ParsePin(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestPinProperties(t *testing.T) {
+	pin := Pin{Name: "tool", Version: "1.0", URL: "git+https://github.com/org/tool@abc", Groups: []string{"dev"}}
+	assert.True(t, pin.IsDirect())
+	assert.True(t, pin.InGroup("dev"))
+	assert.False(t, pin.InGroup("main"))
+	assert.False(t, Pin{Name: "idna", Version: "3.4"}.IsDirect())
+}
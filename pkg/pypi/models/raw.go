@@ -0,0 +1,85 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// knownFieldsCache 缓存各结构体类型的JSON字段名集合
+var knownFieldsCache sync.Map
+
+// knownFields 返回结构体类型中通过json标签声明的字段名
+func knownFields(t reflect.Type) map[string]bool {
+	if cached, ok := knownFieldsCache.Load(t); ok {
+		return cached.(map[string]bool)
+	}
+
+	fields := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = t.Field(i).Name
+		}
+		fields[name] = true
+	}
+	knownFieldsCache.Store(t, fields)
+	return fields
+}
+
+// unmarshalWithExtra 将JSON解码到v（结构体指针），并返回结构体中未声明的字段
+// 用于保留PyPI新增而模型尚未映射的字段
+func unmarshalWithExtra(data []byte, v interface{}) (map[string]json.RawMessage, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	known := knownFields(reflect.TypeOf(v).Elem())
+	for key := range all {
+		if known[key] {
+			delete(all, key)
+		}
+	}
+	if len(all) == 0 {
+		return nil, nil
+	}
+	return all, nil
+}
+
+// marshalWithExtra 将v编码为JSON，并合并未声明的字段
+// nullable中的字段为空字符串或被省略时编码为null，与PyPI的响应保持一致
+func marshalWithExtra(v interface{}, extra map[string]json.RawMessage, nullable ...string) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(extra) == 0 && len(nullable) == 0 {
+		return data, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range extra {
+		if _, exists := fields[key]; !exists {
+			fields[key] = value
+		}
+	}
+	for _, key := range nullable {
+		if value, exists := fields[key]; !exists || string(value) == `""` {
+			fields[key] = json.RawMessage("null")
+		}
+	}
+	return json.Marshal(fields)
+}
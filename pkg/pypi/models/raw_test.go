@@ -0,0 +1,106 @@
+package models
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// samplePackageFile 仓库中保存的真实PyPI响应
+var samplePackageFile = filepath.Join("..", "..", "..", "data", "package-information.json")
+
+func TestPackage_SampleRoundTrip(t *testing.T) {
+	original, err := os.ReadFile(samplePackageFile)
+	require.NoError(t, err)
+
+	var pkg Package
+	require.NoError(t, json.Unmarshal(original, &pkg))
+
+	t.Run("映射全部字段", func(t *testing.T) {
+		assert.Empty(t, pkg.Extra)
+		assert.Empty(t, pkg.Info.Extra)
+		assert.Equal(t, "https://pypi.org/project/requests/", pkg.Info.PackageURL)
+		assert.Equal(t, "https://pypi.org/project/requests/2.30.0/", pkg.Info.ReleaseURL)
+		assert.Equal(t, DownloadStats{LastDay: -1, LastWeek: -1, LastMonth: -1}, pkg.Info.Downloads)
+		assert.Empty(t, pkg.Info.BugtrackURL)
+
+		signed := 0
+		for version, files := range pkg.Releases {
+			for _, file := range files {
+				assert.Empty(t, file.Extra, "%s: %s", version, file.Filename)
+				assert.Equal(t, -1, file.Downloads)
+				if file.HasSig {
+					signed++
+				}
+			}
+		}
+		assert.Greater(t, signed, 0)
+	})
+
+	t.Run("编码结果与原始响应一致", func(t *testing.T) {
+		encoded, err := json.Marshal(&pkg)
+		require.NoError(t, err)
+
+		var expected, actual interface{}
+		require.NoError(t, json.Unmarshal(original, &expected))
+		require.NoError(t, json.Unmarshal(encoded, &actual))
+		assert.Equal(t, expected, actual)
+	})
+}
+
+func TestPackage_UnknownFields(t *testing.T) {
+	data := `{
+		"info": {"name": "demo", "version": "1.0", "license_expression": "MIT", "provides_extra": ["cli"],
+			"license_files": ["LICENSE"], "dynamic": ["requires-dist"], "new_info_field": {"a": 1}},
+		"last_serial": 1,
+		"releases": {},
+		"urls": [{"filename": "demo-1.0.tar.gz", "digests": {"sha256": "aa", "sha3_256": "bb"},
+			"yanked": true, "yanked_reason": null, "provenance": "x"}],
+		"vulnerabilities": [{"id": "PYSEC-1", "withdrawn": null, "modified": "2024-01-01T00:00:00Z"}],
+		"ownership": {"roles": []}
+	}`
+
+	var pkg Package
+	require.NoError(t, json.Unmarshal([]byte(data), &pkg))
+
+	assert.Equal(t, "MIT", pkg.Info.LicenseExpression)
+	assert.Equal(t, []string{"cli"}, pkg.Info.ProvidesExtra)
+	assert.Equal(t, []string{"LICENSE"}, pkg.Info.LicenseFiles)
+	assert.Equal(t, []string{"requires-dist"}, pkg.Info.Dynamic)
+	assert.JSONEq(t, `{"a": 1}`, string(pkg.Info.Extra["new_info_field"]))
+	assert.JSONEq(t, `{"roles": []}`, string(pkg.Extra["ownership"]))
+	assert.JSONEq(t, `"x"`, string(pkg.Urls[0].Extra["provenance"]))
+	assert.JSONEq(t, `"2024-01-01T00:00:00Z"`, string(pkg.Vulnerabilities[0].Extra["modified"]))
+
+	digests := pkg.Urls[0].Digests
+	assert.Equal(t, "aa", digests.SHA256)
+	assert.Equal(t, "bb", digests.Get("sha3_256"))
+	assert.Equal(t, map[string]string{"sha256": "aa", "sha3_256": "bb"}, digests.All())
+
+	encoded, err := json.Marshal(pkg)
+	require.NoError(t, err)
+
+	var roundTrip map[string]interface{}
+	require.NoError(t, json.Unmarshal(encoded, &roundTrip))
+	assert.Contains(t, roundTrip, "ownership")
+
+	file := roundTrip["urls"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "x", file["provenance"])
+	assert.Nil(t, file["yanked_reason"])
+	assert.Contains(t, file, "yanked_reason")
+	assert.Equal(t, map[string]interface{}{"sha256": "aa", "sha3_256": "bb"}, file["digests"])
+}
+
+func TestReleaseDigests_UnmarshalJSON(t *testing.T) {
+	var digests ReleaseDigests
+	assert.Error(t, json.Unmarshal([]byte(`[]`), &digests))
+
+	require.NoError(t, json.Unmarshal([]byte(`{"md5": "m", "blake2b_256": "b"}`), &digests))
+	assert.Equal(t, "m", digests.Get("md5"))
+	assert.Equal(t, "b", digests.Blake2b256)
+	assert.Nil(t, digests.Extra)
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // ReleaseFile 表示包的一个发布文件
 // 包含了文件的详细信息，如URL、哈希值、大小等
@@ -43,6 +46,36 @@ type ReleaseFile struct {
 
 	// CommentText 注释
 	CommentText string `json:"comment_text"`
+
+	// HasSig 是否有PGP签名（PyPI已停止支持签名上传）
+	HasSig bool `json:"has_sig"`
+
+	// Downloads 下载次数（PyPI已停用，通常为-1）
+	Downloads int `json:"downloads"`
+
+	// Extra 模型中未映射的字段，编码时原样输出
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// releaseFileNullable PyPI在没有值时返回null的字段
+var releaseFileNullable = []string{"requires_python", "yanked_reason"}
+
+// UnmarshalJSON 解码发布文件并保留未映射的字段
+func (rf *ReleaseFile) UnmarshalJSON(data []byte) error {
+	type plain ReleaseFile
+	extra, err := unmarshalWithExtra(data, (*plain)(rf))
+	if err != nil {
+		return err
+	}
+	rf.Extra = extra
+	return nil
+}
+
+// MarshalJSON 编码发布文件，包括未映射的字段
+// 可为null的字段在为空时编码为null，与PyPI的响应一致
+func (rf ReleaseFile) MarshalJSON() ([]byte, error) {
+	type plain ReleaseFile
+	return marshalWithExtra(plain(rf), rf.Extra, releaseFileNullable...)
 }
 
 // ReleaseDigests 文件的哈希值信息
@@ -55,6 +88,63 @@ type ReleaseDigests struct {
 
 	// Blake2b256 Blake2b-256哈希值
 	Blake2b256 string `json:"blake2b_256"`
+
+	// Extra 其他哈希算法的值，键为PyPI返回的算法名
+	Extra map[string]string `json:"-"`
+}
+
+// UnmarshalJSON 解码哈希值，未知算法保存在Extra中
+func (d *ReleaseDigests) UnmarshalJSON(data []byte) error {
+	var all map[string]string
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+
+	*d = ReleaseDigests{}
+	for algorithm, digest := range all {
+		switch algorithm {
+		case "md5":
+			d.MD5 = digest
+		case "sha256":
+			d.SHA256 = digest
+		case "blake2b_256":
+			d.Blake2b256 = digest
+		default:
+			if d.Extra == nil {
+				d.Extra = make(map[string]string)
+			}
+			d.Extra[algorithm] = digest
+		}
+	}
+	return nil
+}
+
+// MarshalJSON 编码所有哈希值，包括未知算法
+func (d ReleaseDigests) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.All())
+}
+
+// Get 返回指定算法的哈希值，算法不存在时返回空字符串
+func (d *ReleaseDigests) Get(algorithm string) string {
+	return d.All()[algorithm]
+}
+
+// All 返回所有非空的哈希值，键为算法名
+func (d *ReleaseDigests) All() map[string]string {
+	all := make(map[string]string, 3+len(d.Extra))
+	for algorithm, digest := range d.Extra {
+		all[algorithm] = digest
+	}
+	if d.MD5 != "" {
+		all["md5"] = d.MD5
+	}
+	if d.SHA256 != "" {
+		all["sha256"] = d.SHA256
+	}
+	if d.Blake2b256 != "" {
+		all["blake2b_256"] = d.Blake2b256
+	}
+	return all
 }
 
 // GetUploadTimeISO 将上传时间解析为time.Time
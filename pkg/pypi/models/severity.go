@@ -0,0 +1,110 @@
+package models
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/cvss"
+)
+
+// 常见的严重程度评分类型，与OSV的severity[].type一致
+const (
+	SeverityCVSSv3 = "CVSS_V3"
+	SeverityCVSSv4 = "CVSS_V4"
+)
+
+// Severity 一条严重程度评分
+type Severity struct {
+	// Type 评分类型，如 "CVSS_V3"、"CVSS_V4"
+	Type string `json:"type"`
+
+	// Score 评分内容，CVSS类型时为向量字符串
+	Score string `json:"score"`
+}
+
+// CVSS 返回漏洞的CVSS向量，存在多条时取版本最高的一条
+// 类型未知但内容是CVSS向量的评分同样会被识别；没有可解析的向量时返回nil
+func (v *Vulnerability) CVSS() cvss.Vector {
+	var best cvss.Vector
+	for _, s := range v.Severity {
+		vector, err := cvss.Parse(s.Score)
+		if err != nil {
+			continue
+		}
+		if best == nil || vector.Version() > best.Version() {
+			best = vector
+		}
+	}
+	return best
+}
+
+// Score 返回CVSS分数，没有可解析的CVSS向量时第二个返回值为false
+func (v *Vulnerability) Score() (float64, bool) {
+	vector := v.CVSS()
+	if vector == nil {
+		return 0, false
+	}
+	return vector.Score(), true
+}
+
+// Rating 返回漏洞的定性严重程度评级
+// 优先根据CVSS向量计算；没有向量时使用database_specific中的severity（如GitHub安全公告的 "MODERATE"），
+// 都没有时返回cvss.RatingUnknown
+func (v *Vulnerability) Rating() cvss.Rating {
+	if vector := v.CVSS(); vector != nil {
+		return vector.Rating()
+	}
+	if len(v.DatabaseSpecific) == 0 {
+		return cvss.RatingUnknown
+	}
+	var specific struct {
+		Severity string `json:"severity"`
+	}
+	if err := json.Unmarshal(v.DatabaseSpecific, &specific); err != nil {
+		return cvss.RatingUnknown
+	}
+	return cvss.ParseRating(specific.Severity)
+}
+
+// SortBySeverity 按严重程度从高到低原地排序漏洞
+// 先比较评级，评级相同时比较CVSS分数，最后按ID排序；评级未知的漏洞排在最后
+func SortBySeverity(vulns []Vulnerability) {
+	sort.SliceStable(vulns, func(i, j int) bool {
+		a, b := vulns[i].Rating().Rank(), vulns[j].Rating().Rank()
+		if a != b {
+			return a > b
+		}
+		scoreA, _ := vulns[i].Score()
+		scoreB, _ := vulns[j].Score()
+		if scoreA != scoreB {
+			return scoreA > scoreB
+		}
+		return vulns[i].ID < vulns[j].ID
+	})
+}
+
+// FilterBySeverity 返回评级不低于threshold的漏洞，评级未知的漏洞被排除
+//
+// 参数:
+//   - vulns: 漏洞列表
+//   - threshold: 最低评级，如cvss.RatingHigh表示只保留HIGH和CRITICAL
+//
+// 返回值:
+//   - []Vulnerability: 满足条件的漏洞，保持原有顺序
+//
+// 使用示例:
+//
+//	vulns, _ := client.CheckPackageVulnerabilities(ctx, "jinja2", "2.11.2")
+//	for _, v := range models.FilterBySeverity(vulns, cvss.RatingHigh) {
+//		fmt.Println(v.ID, v.Rating())
+//	}
+func FilterBySeverity(vulns []Vulnerability, threshold cvss.Rating) []Vulnerability {
+	var result []Vulnerability
+	for _, vuln := range vulns {
+		rating := vuln.Rating()
+		if rating != cvss.RatingUnknown && rating.AtLeast(threshold) {
+			result = append(result, vuln)
+		}
+	}
+	return result
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/cvss"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	vectorCritical = "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"                    // 9.8
+	vectorMedium   = "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:L"                    // 5.3
+	vectorV4High   = "CVSS:4.0/AV:L/AC:L/AT:N/PR:L/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N" // 8.5
+)
+
+func TestVulnerabilityCVSS(t *testing.T) {
+	t.Run("取版本最高的向量", func(t *testing.T) {
+		v := Vulnerability{Severity: []Severity{
+			{Type: SeverityCVSSv3, Score: vectorCritical},
+			{Type: SeverityCVSSv4, Score: vectorV4High},
+		}}
+		vector := v.CVSS()
+		require.NotNil(t, vector)
+		assert.Equal(t, "4.0", vector.Version())
+		score, ok := v.Score()
+		assert.True(t, ok)
+		assert.Equal(t, 8.5, score)
+		assert.Equal(t, cvss.RatingHigh, v.Rating())
+	})
+
+	t.Run("忽略无法解析的评分", func(t *testing.T) {
+		v := Vulnerability{Severity: []Severity{
+			{Type: "CVSS_V2", Score: "AV:N/AC:L/Au:N/C:P/I:P/A:P"},
+			{Type: "Ubuntu", Score: "high"},
+			{Type: SeverityCVSSv3, Score: vectorMedium},
+		}}
+		assert.Equal(t, cvss.RatingMedium, v.Rating())
+	})
+
+	t.Run("没有向量时使用database_specific", func(t *testing.T) {
+		v := Vulnerability{DatabaseSpecific: json.RawMessage(`{"severity":"MODERATE","cwe_ids":["CWE-400"]}`)}
+		assert.Nil(t, v.CVSS())
+		_, ok := v.Score()
+		assert.False(t, ok)
+		assert.Equal(t, cvss.RatingMedium, v.Rating())
+	})
+
+	t.Run("无法确定评级", func(t *testing.T) {
+		assert.Equal(t, cvss.RatingUnknown, (&Vulnerability{}).Rating())
+		v := Vulnerability{DatabaseSpecific: json.RawMessage(`["HIGH"]`)}
+		assert.Equal(t, cvss.RatingUnknown, v.Rating())
+	})
+
+	t.Run("JSON编解码", func(t *testing.T) {
+		data := []byte(`{"id":"PYSEC-1","severity":[{"type":"CVSS_V3","score":"` + vectorCritical + `"}],"database_specific":{"severity":"HIGH"},"withdrawn":null}`)
+		var v Vulnerability
+		require.NoError(t, json.Unmarshal(data, &v))
+		assert.Equal(t, []Severity{{Type: SeverityCVSSv3, Score: vectorCritical}}, v.Severity)
+		assert.Empty(t, v.Extra)
+		assert.Equal(t, cvss.RatingCritical, v.Rating())
+
+		out, err := json.Marshal(v)
+		require.NoError(t, err)
+		assert.Contains(t, string(out), `"database_specific":{"severity":"HIGH"}`)
+	})
+}
+
+func TestSortBySeverity(t *testing.T) {
+	vulns := []Vulnerability{
+		{ID: "UNKNOWN"},
+		{ID: "MEDIUM-B", Severity: []Severity{{Type: SeverityCVSSv3, Score: vectorMedium}}},
+		{ID: "CRITICAL", Severity: []Severity{{Type: SeverityCVSSv3, Score: vectorCritical}}},
+		{ID: "MEDIUM-A", Severity: []Severity{{Type: SeverityCVSSv3, Score: vectorMedium}}},
+		{ID: "MODERATE", DatabaseSpecific: json.RawMessage(`{"severity":"MODERATE"}`)},
+		{ID: "HIGH", Severity: []Severity{{Type: SeverityCVSSv4, Score: vectorV4High}}},
+	}
+	SortBySeverity(vulns)
+
+	var got []string
+	for _, v := range vulns {
+		got = append(got, v.ID)
+	}
+	// 评级相同时分数高的在前，database_specific中的评级没有分数
+	assert.Equal(t, []string{"CRITICAL", "HIGH", "MEDIUM-A", "MEDIUM-B", "MODERATE", "UNKNOWN"}, got)
+}
+
+func TestFilterBySeverity(t *testing.T) {
+	vulns := []Vulnerability{
+		{ID: "A", Severity: []Severity{{Type: SeverityCVSSv3, Score: vectorMedium}}},
+		{ID: "B", Severity: []Severity{{Type: SeverityCVSSv3, Score: vectorCritical}}},
+		{ID: "C"},
+		{ID: "D", DatabaseSpecific: json.RawMessage(`{"severity":"HIGH"}`)},
+	}
+
+	ids := func(list []Vulnerability) []string {
+		result := []string{}
+		for _, v := range list {
+			result = append(result, v.ID)
+		}
+		return result
+	}
+	assert.Equal(t, []string{"B", "D"}, ids(FilterBySeverity(vulns, cvss.RatingHigh)))
+	assert.Equal(t, []string{"B"}, ids(FilterBySeverity(vulns, cvss.RatingCritical)))
+	assert.Equal(t, []string{"A", "B", "D"}, ids(FilterBySeverity(vulns, cvss.RatingNone)))
+	// 未知评级总是被排除
+	assert.Equal(t, []string{"A", "B", "D"}, ids(FilterBySeverity(vulns, cvss.RatingUnknown)))
+}
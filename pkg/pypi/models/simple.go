@@ -0,0 +1,167 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SimpleProject 表示Simple API（PEP 503/691）中单个项目的页面
+// JSON格式由PEP 691定义，HTML格式的页面也会被解析为此结构
+type SimpleProject struct {
+	// Meta 响应的元信息
+	Meta SimpleMeta `json:"meta"`
+
+	// Name 规范化后的项目名
+	Name string `json:"name"`
+
+	// Files 项目的所有发布文件
+	Files []SimpleFile `json:"files"`
+
+	// Versions 项目的所有版本（PEP 700，可选）
+	Versions []string `json:"versions,omitempty"`
+}
+
+// SimpleIndex 表示Simple API（PEP 691）的根页面，即所有项目的列表
+type SimpleIndex struct {
+	// Meta 响应的元信息
+	Meta SimpleMeta `json:"meta"`
+
+	// Projects 所有项目
+	Projects []SimpleIndexProject `json:"projects"`
+}
+
+// SimpleIndexProject SimpleIndex中的一个项目
+type SimpleIndexProject struct {
+	// Name 项目名
+	Name string `json:"name"`
+}
+
+// SimpleMeta Simple API响应的元信息
+type SimpleMeta struct {
+	// APIVersion API版本，如 "1.1"
+	APIVersion string `json:"api-version"`
+
+	// LastSerial 项目的最后序列号（PyPI扩展字段）
+	LastSerial int `json:"_last-serial,omitempty"`
+}
+
+// SimpleFile 表示Simple API中的一个发布文件
+type SimpleFile struct {
+	// Filename 文件名
+	Filename string `json:"filename"`
+
+	// URL 文件下载URL，可能是相对于项目页面的地址
+	URL string `json:"url"`
+
+	// Hashes 文件的哈希值，键为算法名
+	Hashes map[string]string `json:"hashes"`
+
+	// RequiresPython Python版本要求
+	RequiresPython string `json:"requires-python,omitempty"`
+
+	// Yanked 文件是否被撤回及原因
+	Yanked YankedStatus `json:"yanked"`
+
+	// CoreMetadata 是否提供独立的核心元数据文件（PEP 714）
+	CoreMetadata *MetadataHashes `json:"core-metadata,omitempty"`
+
+	// DistInfoMetadata PEP 658中的旧字段名，与CoreMetadata含义相同
+	DistInfoMetadata *MetadataHashes `json:"dist-info-metadata,omitempty"`
+
+	// Size 文件大小（PEP 700）
+	Size int64 `json:"size,omitempty"`
+
+	// UploadTime 上传时间（PEP 700）
+	UploadTime string `json:"upload-time,omitempty"`
+}
+
+// HasCoreMetadata 检查索引是否为此文件提供了 .metadata 文件
+func (f *SimpleFile) HasCoreMetadata() bool {
+	if f.CoreMetadata != nil {
+		return f.CoreMetadata.Available
+	}
+	return f.DistInfoMetadata != nil && f.DistInfoMetadata.Available
+}
+
+// CoreMetadataHashes 返回 .metadata 文件的哈希值，未提供时返回nil
+func (f *SimpleFile) CoreMetadataHashes() map[string]string {
+	if f.CoreMetadata != nil {
+		return f.CoreMetadata.Hashes
+	}
+	if f.DistInfoMetadata != nil {
+		return f.DistInfoMetadata.Hashes
+	}
+	return nil
+}
+
+// MetadataHashes 表示core-metadata字段的值
+// 该字段可以是布尔值，也可以是包含哈希值的对象（对象表示可用）
+type MetadataHashes struct {
+	// Available 是否提供元数据文件
+	Available bool
+
+	// Hashes 元数据文件的哈希值
+	Hashes map[string]string
+}
+
+// UnmarshalJSON 解析布尔值或哈希对象
+func (m *MetadataHashes) UnmarshalJSON(data []byte) error {
+	var available bool
+	if err := json.Unmarshal(data, &available); err == nil {
+		m.Available = available
+		m.Hashes = nil
+		return nil
+	}
+
+	var hashes map[string]string
+	if err := json.Unmarshal(data, &hashes); err != nil {
+		return fmt.Errorf("core-metadata 必须是布尔值或哈希对象: %w", err)
+	}
+	m.Available = true
+	m.Hashes = hashes
+	return nil
+}
+
+// MarshalJSON 有哈希值时输出对象，否则输出布尔值
+func (m MetadataHashes) MarshalJSON() ([]byte, error) {
+	if m.Available && len(m.Hashes) > 0 {
+		return json.Marshal(m.Hashes)
+	}
+	return json.Marshal(m.Available)
+}
+
+// YankedStatus 表示yanked字段的值
+// 该字段可以是布尔值，也可以是表示撤回原因的字符串（字符串表示已撤回）
+type YankedStatus struct {
+	// Yanked 是否被撤回
+	Yanked bool
+
+	// Reason 撤回原因
+	Reason string
+}
+
+// UnmarshalJSON 解析布尔值或撤回原因字符串
+func (y *YankedStatus) UnmarshalJSON(data []byte) error {
+	var yanked bool
+	if err := json.Unmarshal(data, &yanked); err == nil {
+		y.Yanked = yanked
+		y.Reason = ""
+		return nil
+	}
+
+	var reason string
+	if err := json.Unmarshal(data, &reason); err != nil {
+		return fmt.Errorf("yanked 必须是布尔值或字符串: %w", err)
+	}
+	y.Yanked = true
+	y.Reason = reason
+	return nil
+}
+
+// MarshalJSON 有撤回原因时输出字符串，否则输出布尔值
+func (y YankedStatus) MarshalJSON() ([]byte, error) {
+	if y.Yanked && y.Reason != "" {
+		return json.Marshal(y.Reason)
+	}
+	return json.Marshal(y.Yanked)
+}
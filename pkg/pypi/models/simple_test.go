@@ -0,0 +1,53 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimpleFile_UnmarshalJSON(t *testing.T) {
+	t.Run("core-metadata为哈希对象", func(t *testing.T) {
+		var f SimpleFile
+		require.NoError(t, json.Unmarshal([]byte(`{"filename": "a.whl", "core-metadata": {"sha256": "abc"}, "yanked": "bad build"}`), &f))
+		assert.True(t, f.HasCoreMetadata())
+		assert.Equal(t, map[string]string{"sha256": "abc"}, f.CoreMetadataHashes())
+		assert.True(t, f.Yanked.Yanked)
+		assert.Equal(t, "bad build", f.Yanked.Reason)
+	})
+
+	t.Run("旧字段dist-info-metadata", func(t *testing.T) {
+		var f SimpleFile
+		require.NoError(t, json.Unmarshal([]byte(`{"filename": "a.whl", "dist-info-metadata": true, "yanked": false}`), &f))
+		assert.True(t, f.HasCoreMetadata())
+		assert.Nil(t, f.CoreMetadataHashes())
+		assert.False(t, f.Yanked.Yanked)
+	})
+
+	t.Run("未提供元数据", func(t *testing.T) {
+		var f SimpleFile
+		require.NoError(t, json.Unmarshal([]byte(`{"filename": "a.tar.gz", "core-metadata": false}`), &f))
+		assert.False(t, f.HasCoreMetadata())
+	})
+
+	t.Run("非法值", func(t *testing.T) {
+		var f SimpleFile
+		assert.Error(t, json.Unmarshal([]byte(`{"core-metadata": 1}`), &f))
+		assert.Error(t, json.Unmarshal([]byte(`{"yanked": 1}`), &f))
+	})
+}
+
+func TestSimpleFile_MarshalJSON(t *testing.T) {
+	f := SimpleFile{
+		Filename:     "a.whl",
+		Yanked:       YankedStatus{Yanked: true, Reason: "bad"},
+		CoreMetadata: &MetadataHashes{Available: true, Hashes: map[string]string{"sha256": "abc"}},
+	}
+	data, err := json.Marshal(f)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"yanked":"bad"`)
+	assert.Contains(t, string(data), `"core-metadata":{"sha256":"abc"}`)
+	assert.NotContains(t, string(data), "dist-info-metadata")
+}
@@ -1,6 +1,11 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/version"
+)
 
 // Vulnerability 表示一个包的安全漏洞信息
 // PyPI的JSON API中漏洞信息的结构
@@ -29,17 +34,244 @@ type Vulnerability struct {
 	// Withdrawn 漏洞撤回时间
 	// 如果不为null，表示此漏洞报告已被撤回
 	Withdrawn string `json:"withdrawn"`
+
+	// Ranges 受影响的版本区间，来自OSV等包含完整范围的数据源
+	// PyPI的JSON API不提供此字段，为空时根据FixedIn推断
+	Ranges []VersionRange `json:"ranges,omitempty"`
+
+	// Severity 严重程度评分，对应OSV的severity字段，PyPI的JSON API不提供此字段
+	Severity []Severity `json:"severity,omitempty"`
+
+	// DatabaseSpecific 数据源特有的信息，对应OSV的database_specific字段
+	// 如GitHub安全公告会在其中给出 {"severity": "HIGH"}
+	DatabaseSpecific json.RawMessage `json:"database_specific,omitempty"`
+
+	// Extra 模型中未映射的字段，编码时原样输出
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
-// IsFixed 检查指定版本是否已修复了此漏洞
-// 此方法仅进行字符串比较，不执行语义版本比较
-func (v *Vulnerability) IsFixed(version string) bool {
-	for _, fixedVersion := range v.FixedIn {
-		if fixedVersion == version {
-			return true
+// UnmarshalJSON 解码漏洞信息并保留未映射的字段
+func (v *Vulnerability) UnmarshalJSON(data []byte) error {
+	type plain Vulnerability
+	extra, err := unmarshalWithExtra(data, (*plain)(v))
+	if err != nil {
+		return err
+	}
+	v.Extra = extra
+	return nil
+}
+
+// MarshalJSON 编码漏洞信息，包括未映射的字段
+// 未撤回时withdrawn编码为null，与PyPI的响应一致
+func (v Vulnerability) MarshalJSON() ([]byte, error) {
+	type plain Vulnerability
+	return marshalWithExtra(plain(v), v.Extra, "withdrawn")
+}
+
+// IsFixed 检查指定版本是否已修复了此漏洞，按PEP 440比较版本号
+// 例如FixedIn为 ["1.2.3"] 时，1.2.5同样视为已修复；详细规则见Affects
+func (v *Vulnerability) IsFixed(ver string) bool {
+	return !v.Affects(ver)
+}
+
+// Affects 检查指定版本是否受此漏洞影响
+//
+// 有Ranges时，版本落在任一区间内即受影响。否则根据FixedIn推断：
+// 不存在不高于该版本的修复版本时受影响；存在时，如果更高的修复版本与该版本处于同一发布分支
+// （如FixedIn为 ["1.2.3", "2.0.1"] 时的2.0.0），说明修复尚未进入该分支，仍视为受影响。
+// 无法解析的版本号只在字面出现在FixedIn中时视为已修复
+func (v *Vulnerability) Affects(ver string) bool {
+	parsed, err := version.Parse(ver)
+	if err != nil {
+		for _, fixed := range v.FixedIn {
+			if fixed == ver {
+				return false
+			}
 		}
+		return true
 	}
-	return false
+
+	if len(v.Ranges) > 0 {
+		for _, r := range v.Ranges {
+			if r.Contains(parsed) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var lower, higher *version.Version
+	for _, s := range v.FixedIn {
+		fixed, err := version.Parse(s)
+		if err != nil {
+			continue
+		}
+		if fixed.Compare(parsed) <= 0 {
+			if lower == nil || fixed.Compare(lower) > 0 {
+				lower = fixed
+			}
+		} else if higher == nil || fixed.Compare(higher) < 0 {
+			higher = fixed
+		}
+	}
+	if lower == nil {
+		return true
+	}
+	return higher != nil && sameBranch(parsed, higher)
+}
+
+// sameBranch 检查v是否与修复版本fixed处于同一发布分支，即发布号除最后一段外相同（至少比较第一段）
+func sameBranch(v, fixed *version.Version) bool {
+	n := len(fixed.Release) - 1
+	if n < 1 {
+		n = 1
+	}
+	for i := 0; i < n; i++ {
+		if segment(v, i) != segment(fixed, i) {
+			return false
+		}
+	}
+	return v.Epoch == fixed.Epoch
+}
+
+func segment(v *version.Version, i int) int {
+	if i < len(v.Release) {
+		return v.Release[i]
+	}
+	return 0
+}
+
+// FixedVersion 返回不低于指定版本、且不受此漏洞影响的最小修复版本
+//
+// 参数:
+//   - ver: 当前版本
+//
+// 返回值:
+//   - string: 当前版本未受影响时返回当前版本，否则返回FixedIn和Ranges中满足条件的最小修复版本
+//   - bool: 找不到修复版本时为false
+//
+// 使用示例:
+//
+//	vuln := models.Vulnerability{FixedIn: []string{"1.2.3", "2.0.1"}}
+//	fixed, ok := vuln.FixedVersion("2.0.0") // "2.0.1", true
+func (v *Vulnerability) FixedVersion(ver string) (string, bool) {
+	if !v.Affects(ver) {
+		return ver, true
+	}
+	current, err := version.Parse(ver)
+	if err != nil {
+		return "", false
+	}
+	for _, candidate := range v.fixedCandidates() {
+		parsed, err := version.Parse(candidate)
+		if err != nil || parsed.Compare(current) < 0 {
+			continue
+		}
+		if !v.Affects(candidate) {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// fixedCandidates 返回FixedIn和Ranges中的修复版本，按PEP 440从旧到新排序
+func (v *Vulnerability) fixedCandidates() []string {
+	candidates := append([]string(nil), v.FixedIn...)
+	for _, r := range v.Ranges {
+		if r.Fixed != "" {
+			candidates = append(candidates, r.Fixed)
+		}
+	}
+	version.SortStrings(candidates)
+	return candidates
+}
+
+// VersionRange 一段连续的受影响版本区间
+type VersionRange struct {
+	// Introduced 引入漏洞的版本（包含），为空表示从最初版本开始
+	Introduced string `json:"introduced,omitempty"`
+
+	// Fixed 修复漏洞的版本（不包含），为空表示尚未修复
+	Fixed string `json:"fixed,omitempty"`
+
+	// LastAffected 最后一个受影响的版本（包含），与Fixed二选一
+	LastAffected string `json:"last_affected,omitempty"`
+}
+
+// Contains 检查版本是否落在区间内，无法解析的边界被忽略
+func (r VersionRange) Contains(v *version.Version) bool {
+	if bound, err := version.Parse(r.Introduced); err == nil && v.Compare(bound) < 0 {
+		return false
+	}
+	if bound, err := version.Parse(r.Fixed); err == nil && v.Compare(bound) >= 0 {
+		return false
+	}
+	if bound, err := version.Parse(r.LastAffected); err == nil && v.Compare(bound) > 0 {
+		return false
+	}
+	return true
+}
+
+// AffectingVulnerabilities 返回影响指定版本且未撤回的漏洞
+func AffectingVulnerabilities(vulns []Vulnerability, ver string) []Vulnerability {
+	var result []Vulnerability
+	for _, vuln := range vulns {
+		if !vuln.IsWithdrawn() && vuln.Affects(ver) {
+			result = append(result, vuln)
+		}
+	}
+	return result
+}
+
+// SafeVersion 在候选版本中找出不低于当前版本、且不受任何未撤回漏洞影响的最小版本
+//
+// 参数:
+//   - current: 当前版本
+//   - vulns: 包的漏洞列表
+//   - candidates: 可升级到的版本，通常是包的全部发布版本；为空时使用漏洞的修复版本
+//
+// 返回值:
+//   - string: 升级目标，当前版本已安全时返回当前版本
+//   - bool: 找不到安全版本时为false
+//
+// 使用示例:
+//
+//	releases, _ := client.GetPackageReleases(ctx, "jinja2")
+//	vulns, _ := client.CheckPackageVulnerabilities(ctx, "jinja2", "2.11.2")
+//	target, ok := models.SafeVersion("2.11.2", vulns, releases)
+func SafeVersion(current string, vulns []Vulnerability, candidates []string) (string, bool) {
+	if len(AffectingVulnerabilities(vulns, current)) == 0 {
+		return current, true
+	}
+	base, err := version.Parse(current)
+	if err != nil {
+		return "", false
+	}
+
+	if len(candidates) == 0 {
+		for i := range vulns {
+			if !vulns[i].IsWithdrawn() {
+				candidates = append(candidates, vulns[i].fixedCandidates()...)
+			}
+		}
+	}
+	sorted := append([]string(nil), candidates...)
+	version.SortStrings(sorted)
+
+	for _, candidate := range sorted {
+		parsed, err := version.Parse(candidate)
+		if err != nil || parsed.Compare(base) <= 0 {
+			continue
+		}
+		// 当前是正式版本时不建议升级到预发布版本
+		if parsed.IsPrerelease() && !base.IsPrerelease() {
+			continue
+		}
+		if len(AffectingVulnerabilities(vulns, candidate)) == 0 {
+			return candidate, true
+		}
+	}
+	return "", false
 }
 
 // IsWithdrawn 检查漏洞报告是否已被撤回
@@ -42,6 +42,17 @@ func TestVulnerability_IsFixed(t *testing.T) {
 
 		assert.False(t, vuln.IsFixed("1.0.0"))
 	})
+
+	t.Run("按PEP 440比较", func(t *testing.T) {
+		vuln := &Vulnerability{
+			FixedIn: []string{"1.2.3"},
+		}
+
+		assert.True(t, vuln.IsFixed("1.2.5"))
+		assert.True(t, vuln.IsFixed("1.2.3.post1"))
+		assert.True(t, vuln.IsFixed("v1.2.3"))
+		assert.False(t, vuln.IsFixed("1.2.3rc1"))
+	})
 }
 
 func TestVulnerability_IsWithdrawn(t *testing.T) {
@@ -159,3 +170,108 @@ func TestVulnerability_GetCVEs(t *testing.T) {
 		assert.Empty(t, cves)
 	})
 }
+
+func TestVulnerability_Affects(t *testing.T) {
+	t.Run("多个分支的修复版本", func(t *testing.T) {
+		vuln := &Vulnerability{FixedIn: []string{"2.0.1", "1.2.3"}}
+
+		for _, v := range []string{"1.0", "1.2.2", "2.0.0", "2.0.0rc1"} {
+			assert.True(t, vuln.Affects(v), v)
+		}
+		for _, v := range []string{"1.2.3", "1.2.9", "1.3", "2.0.1", "3.0"} {
+			assert.False(t, vuln.Affects(v), v)
+		}
+	})
+
+	t.Run("使用版本区间", func(t *testing.T) {
+		vuln := &Vulnerability{
+			// FixedIn与区间不一致时以区间为准
+			FixedIn: []string{"9.9"},
+			Ranges: []VersionRange{
+				{Fixed: "1.2.3"},
+				{Introduced: "2.0", LastAffected: "2.0.4"},
+			},
+		}
+
+		for _, v := range []string{"0.1", "1.2.2", "2.0", "2.0.4"} {
+			assert.True(t, vuln.Affects(v), v)
+		}
+		for _, v := range []string{"1.2.3", "1.9", "2.0.5", "10.0"} {
+			assert.False(t, vuln.Affects(v), v)
+		}
+	})
+
+	t.Run("无法解析的版本号", func(t *testing.T) {
+		vuln := &Vulnerability{FixedIn: []string{"nightly-a"}}
+
+		assert.False(t, vuln.Affects("nightly-a"))
+		assert.True(t, vuln.Affects("nightly-b"))
+	})
+}
+
+func TestVulnerability_FixedVersion(t *testing.T) {
+	vuln := &Vulnerability{FixedIn: []string{"2.0.1", "1.2.3", "3.0"}}
+
+	cases := map[string]string{
+		"1.0":   "1.2.3",
+		"1.2.5": "1.2.5",
+		"2.0.0": "2.0.1",
+	}
+	for current, want := range cases {
+		got, ok := vuln.FixedVersion(current)
+		assert.True(t, ok, current)
+		assert.Equal(t, want, got, current)
+	}
+
+	t.Run("没有修复版本", func(t *testing.T) {
+		vuln := &Vulnerability{Ranges: []VersionRange{{Introduced: "1.0"}}}
+		_, ok := vuln.FixedVersion("1.5")
+		assert.False(t, ok)
+	})
+
+	t.Run("区间中的修复版本", func(t *testing.T) {
+		vuln := &Vulnerability{Ranges: []VersionRange{{Fixed: "1.1"}, {Introduced: "1.5", Fixed: "1.6"}}}
+		got, ok := vuln.FixedVersion("1.5.2")
+		assert.True(t, ok)
+		assert.Equal(t, "1.6", got)
+	})
+}
+
+func TestSafeVersion(t *testing.T) {
+	vulns := []Vulnerability{
+		{ID: "A", FixedIn: []string{"2.0.1"}},
+		{ID: "B", Ranges: []VersionRange{{Introduced: "2.0", Fixed: "2.1.0"}}},
+		{ID: "C", FixedIn: []string{"9.0"}, Withdrawn: "2024-01-01T00:00:00Z"},
+	}
+
+	t.Run("影响指定版本的漏洞", func(t *testing.T) {
+		affecting := AffectingVulnerabilities(vulns, "2.0.5")
+		require.Len(t, affecting, 1)
+		assert.Equal(t, "B", affecting[0].ID)
+		assert.Len(t, AffectingVulnerabilities(vulns, "2.0.0"), 2)
+	})
+
+	t.Run("在发布版本中选择", func(t *testing.T) {
+		releases := []string{"1.0", "2.0.0", "2.0.1", "2.0.9", "2.1.0rc1", "2.1.0", "2.2.0"}
+		got, ok := SafeVersion("2.0.0", vulns, releases)
+		assert.True(t, ok)
+		assert.Equal(t, "2.1.0", got)
+	})
+
+	t.Run("当前版本已安全", func(t *testing.T) {
+		got, ok := SafeVersion("2.2.0", vulns, nil)
+		assert.True(t, ok)
+		assert.Equal(t, "2.2.0", got)
+	})
+
+	t.Run("没有候选版本时使用修复版本", func(t *testing.T) {
+		got, ok := SafeVersion("2.0.0", vulns, nil)
+		assert.True(t, ok)
+		assert.Equal(t, "2.1.0", got)
+	})
+
+	t.Run("没有安全版本", func(t *testing.T) {
+		_, ok := SafeVersion("2.0.0", vulns, []string{"2.0.1", "2.0.2"})
+		assert.False(t, ok)
+	})
+}
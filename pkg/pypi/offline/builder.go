@@ -0,0 +1,419 @@
+package offline
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/api"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/client"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/requirement"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/version"
+)
+
+// 构建器的默认配置
+const (
+	// DefaultConcurrency 同时下载的文件数和同时查询的项目数
+	DefaultConcurrency = 4
+)
+
+// ErrNoProjects 表示没有指定要镜像的项目
+var ErrNoProjects = errors.New("没有指定要镜像的项目")
+
+// Builder 选择性的离线镜像构建器
+//
+// 按允许列表（及可选的依赖闭包）选择项目，按版本和文件过滤条件选择发布文件，
+// 下载并校验哈希后写入静态目录树，可以直接由任意静态文件服务器提供给pip使用：
+//
+//	<root>/simple/index.html                项目列表（PEP 503）
+//	<root>/simple/index.v1_json             项目列表（PEP 691）
+//	<root>/simple/<项目>/index.html          项目页面
+//	<root>/simple/<项目>/index.v1_json       JSON格式的项目页面
+//	<root>/pypi/<项目>/json                  JSON API
+//	<root>/pypi/<项目>/<版本>/json            单个版本的JSON API
+//	<root>/packages/<项目>/<文件名>           发布文件
+//
+// 重复构建时只下载新增的文件，不再被选中的版本、文件和项目会被删除
+type Builder struct {
+	client      api.PyPIClient
+	root        string
+	httpClient  *http.Client
+	userAgent   string
+	filter      FileFilter
+	latest      int
+	prereleases bool
+	deps        bool
+	env         requirement.Environment
+	concurrency int
+}
+
+// NewBuilder 创建离线镜像构建器
+//
+// 参数:
+//   - c: 获取项目信息的客户端
+//   - root: 镜像的根目录
+//
+// 返回值:
+//   - *Builder: 默认镜像所有版本和文件，不包含依赖
+//
+// 使用示例:
+//
+//	report, err := offline.NewBuilder(client.NewClient(), "/srv/pypi").
+//		WithFilter(offline.FileFilter{PythonTags: []string{"cp311", "py3"}, Platforms: []string{"manylinux*_x86_64", "any"}}).
+//		WithLatest(3).
+//		WithDependencies(requirement.DefaultEnvironment("3.11.4")).
+//		Build(ctx, []string{"requests", "django>=4.2,<5"})
+func NewBuilder(c api.PyPIClient, root string) *Builder {
+	return &Builder{
+		client:      c,
+		root:        root,
+		httpClient:  &http.Client{},
+		userAgent:   client.DefaultUserAgent,
+		concurrency: DefaultConcurrency,
+	}
+}
+
+// WithHTTPClient 设置下载发布文件使用的HTTP客户端
+func (b *Builder) WithHTTPClient(c *http.Client) *Builder {
+	b.httpClient = c
+	return b
+}
+
+// WithUserAgent 设置下载发布文件时的User-Agent
+func (b *Builder) WithUserAgent(userAgent string) *Builder {
+	b.userAgent = userAgent
+	return b
+}
+
+// WithFilter 设置发布文件的过滤条件
+func (b *Builder) WithFilter(filter FileFilter) *Builder {
+	b.filter = filter
+	return b
+}
+
+// WithLatest 每个项目只保留最新的n个版本，为0时保留全部
+// 依赖方要求的版本约束不在其中时，额外保留满足约束的最新版本
+func (b *Builder) WithLatest(n int) *Builder {
+	b.latest = n
+	return b
+}
+
+// WithPrereleases 设置是否包含预发布版本，默认不包含
+func (b *Builder) WithPrereleases(prereleases bool) *Builder {
+	b.prereleases = prereleases
+	return b
+}
+
+// WithDependencies 同时镜像选中版本的依赖闭包
+// env用于对环境标记求值，为nil时包含所有不属于extra的依赖；
+// 允许列表或依赖中请求的extra（如 "requests[socks]"）的依赖同样被包含
+func (b *Builder) WithDependencies(env requirement.Environment) *Builder {
+	b.deps = true
+	b.env = env
+	return b
+}
+
+// WithConcurrency 设置同时查询的项目数和同时下载的文件数
+func (b *Builder) WithConcurrency(n int) *Builder {
+	if n > 0 {
+		b.concurrency = n
+	}
+	return b
+}
+
+// Report 一次构建的结果
+type Report struct {
+	// Projects 镜像中的项目，已规范化并排序
+	Projects []string
+
+	// Versions 镜像中的版本总数
+	Versions int
+
+	// Downloaded 本次下载的文件
+	Downloaded []string
+
+	// Unchanged 已存在而跳过的文件数
+	Unchanged int
+
+	// Removed 本次删除的文件和目录，相对于根目录
+	Removed []string
+
+	// Bytes 本次下载的字节数
+	Bytes int64
+
+	// Errors 失败的项目或文件，键为项目名或文件名；获取失败的项目及其上次构建时的依赖保留上次构建的内容
+	Errors map[string]error
+}
+
+// project 一个待镜像项目的状态
+type project struct {
+	name     string
+	sel      selection
+	pkg      *models.Package
+	versions []string
+	// releases 各版本的JSON API信息，依赖和单版本JSON文件取自这里
+	releases map[string]*models.Package
+	// extras 允许列表或依赖方请求的extra，已规范化
+	extras []string
+	// extrasChanged 上次解析后请求了新的extra，已解析的版本需要重新求依赖
+	extrasChanged bool
+	err           error
+}
+
+// Build 构建或增量更新镜像
+//
+// 参数:
+//   - ctx: 上下文
+//   - projects: 允许列表，每项是项目名，可以带extra、PEP 440版本约束和环境标记（如 "django[argon2]>=4.2,<5"），
+//     设置了目标环境时跳过环境标记不生效的项
+//
+// 返回值:
+//   - *Report: 构建结果，单个项目或文件的失败记录在Report.Errors中
+//   - error: 允许列表无效或无法写入根目录时返回
+func (b *Builder) Build(ctx context.Context, projects []string) (*Report, error) {
+	if len(projects) == 0 {
+		return nil, ErrNoProjects
+	}
+	states := map[string]*project{}
+	var queue []*project
+	for _, entry := range projects {
+		req, err := requirement.Parse(entry)
+		if err != nil {
+			return nil, err
+		}
+		if b.env != nil && !req.AppliesTo(b.env) {
+			continue
+		}
+		name := req.NormalizedName()
+		p, ok := states[name]
+		if !ok {
+			p = b.newProject(name)
+			states[name] = p
+			queue = append(queue, p)
+		}
+		p.sel.constraint = append(p.sel.constraint, req.Specifier...)
+		p.addExtras(req.Extras)
+	}
+	if len(queue) == 0 {
+		// 所有项都不适用于目标环境，不能当作空的允许列表删除整个镜像
+		return nil, ErrNoProjects
+	}
+
+	// 逐层解析：处理一批项目后，把新发现的依赖和新增了版本约束或extra的项目放入下一批
+	for len(queue) > 0 {
+		found := make([][]*requirement.Requirement, len(queue))
+		b.parallel(len(queue), func(i int) {
+			found[i] = b.resolve(ctx, queue[i])
+		})
+
+		var next []*project
+		queued := map[string]bool{}
+		for _, reqs := range found {
+			for _, req := range reqs {
+				name := req.NormalizedName()
+				p, ok := states[name]
+				if !ok {
+					p = b.newProject(name)
+					states[name] = p
+				}
+				changed := p.addRequired(req.Specifier)
+				if p.addExtras(req.Extras) {
+					changed = true
+				}
+				if ok && !changed {
+					continue
+				}
+				if !queued[name] {
+					queued[name] = true
+					next = append(next, p)
+				}
+			}
+		}
+		queue = next
+	}
+
+	return b.write(ctx, states)
+}
+
+func (b *Builder) newProject(name string) *project {
+	return &project{
+		name:     name,
+		sel:      selection{latest: b.latest, prereleases: b.prereleases},
+		releases: map[string]*models.Package{},
+	}
+}
+
+// addRequired 记录依赖方的版本约束，约束已存在时返回false
+func (p *project) addRequired(spec version.SpecifierSet) bool {
+	if len(spec) == 0 {
+		return false
+	}
+	key := spec.String()
+	for _, existing := range p.sel.required {
+		if existing.String() == key {
+			return false
+		}
+	}
+	p.sel.required = append(p.sel.required, spec)
+	return true
+}
+
+// addExtras 记录请求的extra，有新的extra时返回true
+func (p *project) addExtras(extras []string) bool {
+	added := false
+	for _, extra := range extras {
+		extra = models.NormalizeName(extra)
+		if containsString(p.extras, extra) {
+			continue
+		}
+		p.extras = append(p.extras, extra)
+		p.extrasChanged, added = true, true
+	}
+	return added
+}
+
+// resolve 获取项目信息、选择版本，并返回新选中版本的依赖
+// 请求了新的extra时，已选中版本的依赖也重新返回
+// 获取失败的项目保留上次构建的内容，因此返回上次构建的版本的依赖，使它们同样被保留
+func (b *Builder) resolve(ctx context.Context, p *project) []*requirement.Requirement {
+	if p.pkg == nil {
+		pkg, err := b.client.GetPackageInfo(ctx, p.name)
+		if err != nil {
+			p.err = err
+			return b.previousDependencies(p)
+		}
+		p.pkg = pkg
+	}
+	p.versions = selectVersions(p.pkg, &p.sel, b.filter)
+
+	reevaluate := p.extrasChanged
+	p.extrasChanged = false
+	var reqs []*requirement.Requirement
+	for _, v := range p.versions {
+		release, ok := p.releases[v]
+		if !ok {
+			var err error
+			if release, err = b.release(ctx, p.name, v); err != nil {
+				p.err = err
+				return append(reqs, b.previousDependencies(p)...)
+			}
+			p.releases[v] = release
+		} else if !reevaluate {
+			continue
+		}
+		if b.deps && release.Info != nil {
+			reqs = append(reqs, b.dependencies(release.Info.RequiresDist, p.extras)...)
+		}
+	}
+	return reqs
+}
+
+// release 获取单个版本的信息，上次构建写入的文件存在时直接读取
+func (b *Builder) release(ctx context.Context, name, v string) (*models.Package, error) {
+	if pkg, err := b.readPackage(releaseJSONPath(name, v)); err == nil {
+		return pkg, nil
+	}
+	return b.client.GetPackageVersion(ctx, name, v)
+}
+
+// previousDependencies 返回上次构建写入的各版本JSON文件中的依赖，没有上次构建的内容时返回nil
+func (b *Builder) previousDependencies(p *project) []*requirement.Requirement {
+	if !b.deps {
+		return nil
+	}
+	entries, _ := os.ReadDir(filepath.Join(b.root, JSONDir, p.name))
+	var reqs []*requirement.Requirement
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		release, err := b.readPackage(releaseJSONPath(p.name, entry.Name()))
+		if err != nil || release.Info == nil {
+			continue
+		}
+		reqs = append(reqs, b.dependencies(release.Info.RequiresDist, p.extras)...)
+	}
+	return reqs
+}
+
+// dependencies 解析并筛选在目标环境中生效的依赖，extras为请求的extra（已规范化）
+// 依赖在不带extra或带任一请求的extra的环境中生效即被包含
+func (b *Builder) dependencies(requiresDist []string, extras []string) []*requirement.Requirement {
+	var result []*requirement.Requirement
+	for _, line := range requiresDist {
+		req, err := requirement.Parse(line)
+		if err != nil || req.URL != "" {
+			continue
+		}
+		if b.applies(req, extras) {
+			result = append(result, req)
+		}
+	}
+	return result
+}
+
+// applies 检查依赖在目标环境中是否生效
+// 没有目标环境时只检查extra：不属于extra的依赖和属于请求的extra的依赖生效
+func (b *Builder) applies(req *requirement.Requirement, extras []string) bool {
+	if b.env == nil {
+		if !req.IsOptional() {
+			return true
+		}
+		for _, extra := range req.Marker.Extras() {
+			if containsString(extras, extra) {
+				return true
+			}
+		}
+		return false
+	}
+	if req.AppliesTo(b.env) {
+		return true
+	}
+	for _, extra := range extras {
+		if req.AppliesTo(b.env.WithExtra(extra)) {
+			return true
+		}
+	}
+	return false
+}
+
+// parallel 以b.concurrency的并发度执行n个任务
+func (b *Builder) parallel(n int, task func(i int)) {
+	sem := make(chan struct{}, b.concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			task(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// sortedNames 返回map的键，已排序
+func sortedNames(states map[string]*project) []string {
+	names := make([]string, 0, len(states))
+	for name := range states {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// containsString 检查列表中是否包含s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,360 @@
+package offline
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/client"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/requirement"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// upstream 模拟的上游索引，提供JSON API和发布文件
+type upstream struct {
+	*httptest.Server
+	mu        sync.Mutex
+	projects  map[string]map[string][]string
+	requires  map[string][]string
+	tampered  map[string]bool
+	failing   map[string]bool
+	downloads map[string]int
+}
+
+func newUpstream(t *testing.T) *upstream {
+	u := &upstream{
+		projects: map[string]map[string][]string{
+			"app": {
+				"1.0": {"app-1.0.tar.gz"},
+				"2.0": {"app-2.0-py3-none-any.whl", "app-2.0-cp311-cp311-win_amd64.whl", "app-2.0.tar.gz"},
+			},
+			"lib": {
+				"1.0": {"lib-1.0.tar.gz"},
+				"1.1": {"lib-1.1.tar.gz"},
+				"2.0": {"lib-2.0.tar.gz"},
+			},
+			"winonly":   {"1.0": {"winonly-1.0.tar.gz"}},
+			"extra-dep": {"1.0": {"extra_dep-1.0.tar.gz"}},
+			"bad":       {"1.0": {"bad-1.0.tar.gz"}},
+		},
+		requires: map[string][]string{
+			"app/2.0": {"lib<2", "extra-dep; extra == 'x'", "winonly; sys_platform == 'win32'"},
+		},
+		tampered:  map[string]bool{"bad-1.0.tar.gz": true},
+		failing:   map[string]bool{},
+		downloads: map[string]int{},
+	}
+	u.Server = httptest.NewServer(http.HandlerFunc(u.serve))
+	t.Cleanup(u.Close)
+	return u
+}
+
+func (u *upstream) serve(w http.ResponseWriter, r *http.Request) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if filename, ok := cutPrefix(r.URL.Path, "/files/"); ok {
+		u.downloads[filename]++
+		if u.tampered[filename] {
+			w.Write([]byte("tampered"))
+			return
+		}
+		w.Write([]byte(filename))
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 3 || parts[0] != "pypi" || parts[len(parts)-1] != "json" {
+		http.NotFound(w, r)
+		return
+	}
+	name := parts[1]
+	if u.failing[name] {
+		http.Error(w, "上游暂时不可用", http.StatusServiceUnavailable)
+		return
+	}
+	releases, ok := u.projects[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	pkg := &models.Package{Info: &models.PackageInfo{Name: name}, Releases: map[string][]*models.ReleaseFile{}, LastSerial: 5}
+	for v, files := range releases {
+		for _, filename := range files {
+			sum := sha256.Sum256([]byte(filename))
+			pkg.Releases[v] = append(pkg.Releases[v], &models.ReleaseFile{
+				Filename: filename,
+				URL:      u.URL + "/files/" + filename,
+				Size:     int64(len(filename)),
+				Digests:  models.ReleaseDigests{SHA256: hex.EncodeToString(sum[:])},
+			})
+		}
+	}
+	if len(parts) == 4 {
+		v := parts[2]
+		if _, ok := releases[v]; !ok {
+			http.NotFound(w, r)
+			return
+		}
+		pkg.Info.Version = v
+		pkg.Info.RequiresDist = u.requires[name+"/"+v]
+		pkg.Urls = pkg.Releases[v]
+		pkg.Releases = nil
+	}
+	json.NewEncoder(w).Encode(pkg)
+}
+
+func (u *upstream) downloadCount() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	total := 0
+	for _, n := range u.downloads {
+		total += n
+	}
+	return total
+}
+
+func newTestBuilder(u *upstream, root string) *Builder {
+	c := client.NewClient(client.NewOptions().WithBaseURL(u.URL).WithMaxRetries(1).WithTimeout(5 * time.Second))
+	return NewBuilder(c, root)
+}
+
+func exists(root, rel string) bool {
+	_, err := os.Stat(filepath.Join(root, filepath.FromSlash(rel)))
+	return err == nil
+}
+
+func readFile(t *testing.T, root, rel string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestBuilder(t *testing.T) {
+	u := newUpstream(t)
+	root := t.TempDir()
+	ctx := context.Background()
+	filter := FileFilter{PythonTags: []string{"py3"}, Platforms: []string{"any"}, NoSdist: true}
+
+	t.Run("没有项目", func(t *testing.T) {
+		_, err := newTestBuilder(u, root).Build(ctx, nil)
+		assert.ErrorIs(t, err, ErrNoProjects)
+	})
+
+	t.Run("构建镜像和依赖闭包", func(t *testing.T) {
+		report, err := newTestBuilder(u, root).
+			WithLatest(1).
+			WithDependencies(requirement.DefaultEnvironment("3.11.4")).
+			Build(ctx, []string{"App"})
+		require.NoError(t, err)
+		assert.Empty(t, report.Errors)
+		assert.Equal(t, []string{"app", "lib"}, report.Projects)
+		// lib的最新版本不满足app的约束，额外保留满足约束的1.1
+		assert.Equal(t, 3, report.Versions)
+		assert.Equal(t, []string{"app-2.0-cp311-cp311-win_amd64.whl", "app-2.0-py3-none-any.whl", "app-2.0.tar.gz", "lib-1.1.tar.gz", "lib-2.0.tar.gz"}, report.Downloaded)
+
+		assert.Equal(t, "lib-1.1.tar.gz", readFile(t, root, "packages/lib/lib-1.1.tar.gz"))
+		index := readFile(t, root, "simple/index.html")
+		assert.Contains(t, index, `href="app/"`)
+		assert.NotContains(t, index, "winonly")
+		assert.NotContains(t, index, "extra-dep")
+
+		page := readFile(t, root, "simple/app/index.html")
+		assert.Contains(t, page, `href="../../packages/app/app-2.0-py3-none-any.whl#sha256=`)
+		assert.NotContains(t, page, u.URL)
+		assert.Contains(t, readFile(t, root, "simple/app/index.v1_json"), `"url":"../../packages/app/app-2.0.tar.gz"`)
+
+		var release models.Package
+		require.NoError(t, json.Unmarshal([]byte(readFile(t, root, "pypi/app/2.0/json")), &release))
+		assert.Equal(t, []string{"lib<2", "extra-dep; extra == 'x'", "winonly; sys_platform == 'win32'"}, release.Info.RequiresDist)
+		require.Len(t, release.Urls, 3)
+		assert.True(t, strings.HasPrefix(release.Urls[0].URL, "../../../packages/app/"))
+	})
+
+	t.Run("重复构建不再下载", func(t *testing.T) {
+		before := u.downloadCount()
+		report, err := newTestBuilder(u, root).
+			WithLatest(1).
+			WithDependencies(requirement.DefaultEnvironment("3.11.4")).
+			Build(ctx, []string{"app"})
+		require.NoError(t, err)
+		assert.Empty(t, report.Downloaded)
+		assert.Equal(t, 5, report.Unchanged)
+		assert.Empty(t, report.Removed)
+		assert.Equal(t, before, u.downloadCount())
+	})
+
+	t.Run("选择变化时删除多余内容", func(t *testing.T) {
+		report, err := newTestBuilder(u, root).WithFilter(filter).Build(ctx, []string{"app"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"app"}, report.Projects)
+		assert.Equal(t, 1, report.Versions)
+		assert.Equal(t, []string{
+			"packages/app/app-2.0-cp311-cp311-win_amd64.whl",
+			"packages/app/app-2.0.tar.gz",
+			"packages/lib",
+			"pypi/lib",
+			"simple/lib",
+		}, report.Removed)
+		assert.True(t, exists(root, "packages/app/app-2.0-py3-none-any.whl"))
+		assert.NotContains(t, readFile(t, root, "simple/app/index.html"), "app-2.0.tar.gz")
+		assert.NotContains(t, readFile(t, root, "simple/index.html"), "lib")
+	})
+
+	t.Run("版本约束", func(t *testing.T) {
+		report, err := newTestBuilder(u, root).Build(ctx, []string{"app<2"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"app-1.0.tar.gz"}, report.Downloaded)
+		assert.Contains(t, report.Removed, "pypi/app/2.0")
+		assert.True(t, exists(root, "pypi/app/1.0/json"))
+		assert.False(t, exists(root, "packages/app/app-2.0-py3-none-any.whl"))
+	})
+
+	t.Run("哈希不一致的文件不写入", func(t *testing.T) {
+		report, err := newTestBuilder(u, root).Build(ctx, []string{"app<2", "bad"})
+		require.NoError(t, err)
+		assert.ErrorIs(t, report.Errors["bad-1.0.tar.gz"], models.ErrDigestMismatch)
+		assert.False(t, exists(root, "packages/bad/bad-1.0.tar.gz"))
+		assert.Equal(t, []string{"app", "bad"}, report.Projects)
+		assert.NotContains(t, readFile(t, root, "simple/bad/index.html"), "bad-1.0.tar.gz")
+	})
+
+	t.Run("获取失败的项目保留上次的内容", func(t *testing.T) {
+		report, err := newTestBuilder(u, root).Build(ctx, []string{"app<2", "missing"})
+		require.NoError(t, err)
+		assert.ErrorIs(t, report.Errors["missing"], client.ErrNotFound)
+		assert.Equal(t, []string{"app"}, report.Projects)
+
+		u.mu.Lock()
+		delete(u.projects, "app")
+		u.mu.Unlock()
+		report, err = newTestBuilder(u, root).Build(ctx, []string{"app<2"})
+		require.NoError(t, err)
+		assert.Error(t, report.Errors["app"])
+		assert.Equal(t, []string{"app"}, report.Projects)
+		assert.Empty(t, report.Removed)
+		assert.True(t, exists(root, "packages/app/app-1.0.tar.gz"))
+	})
+}
+
+func TestBuilderUpstreamFailure(t *testing.T) {
+	u := newUpstream(t)
+	root := t.TempDir()
+	ctx := context.Background()
+	build := func() *Report {
+		report, err := newTestBuilder(u, root).
+			WithLatest(1).
+			WithDependencies(requirement.DefaultEnvironment("3.11.4")).
+			Build(ctx, []string{"app"})
+		require.NoError(t, err)
+		return report
+	}
+
+	report := build()
+	require.Empty(t, report.Errors)
+	assert.Equal(t, []string{"app", "lib"}, report.Projects)
+
+	t.Run("获取失败的项目的依赖不被删除", func(t *testing.T) {
+		u.mu.Lock()
+		u.failing["app"] = true
+		u.mu.Unlock()
+
+		report := build()
+		assert.Error(t, report.Errors["app"])
+		assert.Equal(t, []string{"app", "lib"}, report.Projects)
+		assert.Empty(t, report.Removed)
+		assert.True(t, exists(root, "packages/app/app-2.0-py3-none-any.whl"))
+		// 上次构建的依赖约束仍然生效，lib保留满足app约束的1.1
+		assert.True(t, exists(root, "packages/lib/lib-1.1.tar.gz"))
+		assert.True(t, exists(root, "pypi/lib/1.1/json"))
+		assert.Contains(t, readFile(t, root, "simple/index.html"), `href="lib/"`)
+	})
+
+	t.Run("恢复后正常更新", func(t *testing.T) {
+		u.mu.Lock()
+		u.failing["app"] = false
+		u.mu.Unlock()
+
+		report := build()
+		assert.Empty(t, report.Errors)
+		assert.Equal(t, []string{"app", "lib"}, report.Projects)
+		assert.Empty(t, report.Removed)
+		assert.Empty(t, report.Downloaded)
+	})
+}
+
+func TestBuilderExtras(t *testing.T) {
+	u := newUpstream(t)
+	for name, files := range map[string][]string{
+		"requests":  {"requests-2.31.0.tar.gz"},
+		"pysocks":   {"PySocks-1.7.1.tar.gz"},
+		"chardet":   {"chardet-5.2.0.tar.gz"},
+		"fastapi":   {"fastapi-0.110.0.tar.gz"},
+		"uvicorn":   {"uvicorn-0.29.0.tar.gz"},
+		"httptools": {"httptools-0.6.1.tar.gz"},
+		"colorama":  {"colorama-0.4.6.tar.gz"},
+	} {
+		v := strings.TrimSuffix(strings.SplitN(files[0], "-", 2)[1], ".tar.gz")
+		u.projects[name] = map[string][]string{v: files}
+	}
+	u.requires["requests/2.31.0"] = []string{"PySocks!=1.5.7,>=1.5.6; extra == 'socks'", "chardet<6,>=3.0.2; extra == 'use-chardet-on-py3'"}
+	u.requires["fastapi/0.110.0"] = []string{"uvicorn[standard]>=0.12.0"}
+	u.requires["uvicorn/0.29.0"] = []string{"httptools>=0.5.0; extra == 'standard'", "colorama>=0.4; sys_platform == 'win32' and extra == 'standard'"}
+	ctx := context.Background()
+
+	t.Run("允许列表和依赖中的extra", func(t *testing.T) {
+		// uvicorn先以不带extra的形式解析，fastapi请求uvicorn[standard]后重新求依赖
+		report, err := newTestBuilder(u, t.TempDir()).
+			WithDependencies(requirement.DefaultEnvironment("3.11.4")).
+			Build(ctx, []string{"uvicorn", "requests[socks]", "fastapi"})
+		require.NoError(t, err)
+		assert.Empty(t, report.Errors)
+		assert.Equal(t, []string{"fastapi", "httptools", "pysocks", "requests", "uvicorn"}, report.Projects)
+	})
+
+	t.Run("没有目标环境时同样包含请求的extra", func(t *testing.T) {
+		report, err := newTestBuilder(u, t.TempDir()).
+			WithDependencies(nil).
+			Build(ctx, []string{"requests[SOCKS]", "fastapi"})
+		require.NoError(t, err)
+		assert.Empty(t, report.Errors)
+		assert.Equal(t, []string{"colorama", "fastapi", "httptools", "pysocks", "requests", "uvicorn"}, report.Projects)
+	})
+
+	t.Run("跳过不适用于目标环境的项", func(t *testing.T) {
+		b := newTestBuilder(u, t.TempDir()).WithDependencies(requirement.DefaultEnvironment("3.11.4"))
+		report, err := b.Build(ctx, []string{"requests[socks]>=2; python_version >= '3.8'", "chardet; python_version < '3'"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"pysocks", "requests"}, report.Projects)
+
+		_, err = b.Build(ctx, []string{"chardet; sys_platform == 'win32'"})
+		assert.ErrorIs(t, err, ErrNoProjects)
+	})
+
+	t.Run("不请求extra时不包含", func(t *testing.T) {
+		report, err := newTestBuilder(u, t.TempDir()).
+			WithDependencies(requirement.DefaultEnvironment("3.11.4")).
+			Build(ctx, []string{"requests", "uvicorn"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"requests", "uvicorn"}, report.Projects)
+	})
+}
+
+// cutPrefix 与Go 1.20的strings.CutPrefix相同
+func cutPrefix(s, prefix string) (string, bool) {
+	if !strings.HasPrefix(s, prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
@@ -0,0 +1,178 @@
+package offline
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/version"
+)
+
+// FileFilter 选择要镜像的发布文件
+// 模式支持 path.Match 通配符，不区分大小写；为空的列表不做限制
+//
+// 使用示例:
+//
+//	// 只要CPython 3.11的manylinux x86_64 wheel、纯Python wheel和源码包
+//	filter := offline.FileFilter{
+//		PythonTags: []string{"cp311", "py3"},
+//		Platforms:  []string{"manylinux*_x86_64", "any"},
+//	}
+type FileFilter struct {
+	// PythonTags 允许的wheel Python标签，如 "cp311"、"py3"
+	PythonTags []string `json:"python_tags,omitempty" yaml:"python_tags,omitempty"`
+
+	// ABITags 允许的wheel ABI标签，如 "cp311"、"abi3"、"none"
+	ABITags []string `json:"abi_tags,omitempty" yaml:"abi_tags,omitempty"`
+
+	// Platforms 允许的wheel平台标签，如 "manylinux*_x86_64"、"any"
+	Platforms []string `json:"platforms,omitempty" yaml:"platforms,omitempty"`
+
+	// NoSdist 不镜像源码包
+	NoSdist bool `json:"no_sdist,omitempty" yaml:"no_sdist,omitempty"`
+
+	// NoWheels 不镜像wheel
+	NoWheels bool `json:"no_wheels,omitempty" yaml:"no_wheels,omitempty"`
+}
+
+// Allows 检查发布文件是否满足过滤条件，已撤回的文件和wheel、源码包以外的格式（如egg）总是被排除
+func (f FileFilter) Allows(file *models.ReleaseFile) bool {
+	if file.IsYanked() {
+		return false
+	}
+	if python, abi, platform, ok := ParseWheelTags(file.Filename); ok {
+		return !f.NoWheels && matchAny(f.PythonTags, python) && matchAny(f.ABITags, abi) && matchAny(f.Platforms, platform)
+	}
+	return isSdist(file) && !f.NoSdist
+}
+
+// ParseWheelTags 从wheel文件名中解析兼容性标签（PEP 427），压缩的标签集（如 "py2.py3"）被展开
+//
+// 参数:
+//   - filename: wheel文件名，如 "numpy-1.26.0-cp311-cp311-manylinux_2_17_x86_64.manylinux2014_x86_64.whl"
+//
+// 返回值:
+//   - python, abi, platform: 各部分的标签
+//   - ok: 不是有效的wheel文件名时为false
+func ParseWheelTags(filename string) (python, abi, platform []string, ok bool) {
+	stem, isWheel := cutSuffix(strings.ToLower(filename), ".whl")
+	if !isWheel {
+		return nil, nil, nil, false
+	}
+	parts := strings.Split(stem, "-")
+	if len(parts) != 5 && len(parts) != 6 {
+		return nil, nil, nil, false
+	}
+	n := len(parts)
+	return strings.Split(parts[n-3], "."), strings.Split(parts[n-2], "."), strings.Split(parts[n-1], "."), true
+}
+
+// matchAny 检查是否有标签匹配任一模式，patterns为空时总是匹配
+func matchAny(patterns, tags []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		for _, tag := range tags {
+			if ok, _ := path.Match(pattern, tag); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isSdist 检查是否为源码包，JSON API没有给出packagetype时按扩展名判断
+func isSdist(file *models.ReleaseFile) bool {
+	if file.PackageType != "" {
+		return file.IsSourceDist()
+	}
+	name := strings.ToLower(file.Filename)
+	return strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".zip")
+}
+
+// selection 一个项目的版本选择条件
+type selection struct {
+	// constraint 允许列表中给出的版本约束，为空时不限制
+	constraint version.SpecifierSet
+
+	// latest 保留的最新版本数，为0时保留全部
+	latest int
+
+	// prereleases 是否包含预发布版本
+	prereleases bool
+
+	// required 依赖方要求的版本约束，每个约束至少选中一个满足它的最新版本
+	required []version.SpecifierSet
+}
+
+// selectVersions 按条件选择版本，只考虑有满足过滤条件的文件的版本，结果按PEP 440从新到旧排列
+func selectVersions(pkg *models.Package, sel *selection, filter FileFilter) []string {
+	type candidate struct {
+		raw    string
+		parsed *version.Version
+	}
+	var candidates []candidate
+	for raw, files := range pkg.Releases {
+		parsed, err := version.Parse(raw)
+		if err != nil || (parsed.IsPrerelease() && !sel.prereleases) {
+			continue
+		}
+		if len(selectFiles(files, filter)) == 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{raw: raw, parsed: parsed})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[j].parsed.LessThan(candidates[i].parsed)
+	})
+
+	chosen := map[string]bool{}
+	count := 0
+	for _, c := range candidates {
+		if sel.latest > 0 && count >= sel.latest {
+			break
+		}
+		if sel.constraint.Contains(c.parsed) {
+			chosen[c.raw] = true
+			count++
+		}
+	}
+	for _, required := range sel.required {
+		for _, c := range candidates {
+			if required.Contains(c.parsed) {
+				chosen[c.raw] = true
+				break
+			}
+		}
+	}
+
+	result := make([]string, 0, len(chosen))
+	for _, c := range candidates {
+		if chosen[c.raw] {
+			result = append(result, c.raw)
+		}
+	}
+	return result
+}
+
+// selectFiles 返回满足过滤条件的文件
+func selectFiles(files []*models.ReleaseFile, filter FileFilter) []*models.ReleaseFile {
+	var result []*models.ReleaseFile
+	for _, f := range files {
+		if filter.Allows(f) {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// cutSuffix 与Go 1.20的strings.CutSuffix相同
+func cutSuffix(s, suffix string) (string, bool) {
+	if !strings.HasSuffix(s, suffix) {
+		return s, false
+	}
+	return s[:len(s)-len(suffix)], true
+}
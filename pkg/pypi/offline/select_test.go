@@ -0,0 +1,103 @@
+package offline
+
+import (
+	"testing"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWheelTags(t *testing.T) {
+	t.Run("展开压缩的标签集", func(t *testing.T) {
+		python, abi, platform, ok := ParseWheelTags("numpy-1.26.0-cp311-cp311-manylinux_2_17_x86_64.manylinux2014_x86_64.whl")
+		assert.True(t, ok)
+		assert.Equal(t, []string{"cp311"}, python)
+		assert.Equal(t, []string{"cp311"}, abi)
+		assert.Equal(t, []string{"manylinux_2_17_x86_64", "manylinux2014_x86_64"}, platform)
+	})
+
+	t.Run("带构建号", func(t *testing.T) {
+		python, _, platform, ok := ParseWheelTags("Demo-1.0-1-py2.py3-none-any.whl")
+		assert.True(t, ok)
+		assert.Equal(t, []string{"py2", "py3"}, python)
+		assert.Equal(t, []string{"any"}, platform)
+	})
+
+	t.Run("不是wheel", func(t *testing.T) {
+		_, _, _, ok := ParseWheelTags("demo-1.0.tar.gz")
+		assert.False(t, ok)
+		_, _, _, ok = ParseWheelTags("demo-1.0.whl")
+		assert.False(t, ok)
+	})
+}
+
+func TestFileFilterAllows(t *testing.T) {
+	filter := FileFilter{PythonTags: []string{"cp311", "py3"}, Platforms: []string{"manylinux*_x86_64", "any"}}
+	cases := []struct {
+		name   string
+		file   *models.ReleaseFile
+		filter FileFilter
+		want   bool
+	}{
+		{"匹配的平台wheel", &models.ReleaseFile{Filename: "demo-1.0-cp311-cp311-manylinux_2_17_x86_64.whl"}, filter, true},
+		{"纯Python wheel", &models.ReleaseFile{Filename: "demo-1.0-py3-none-any.whl"}, filter, true},
+		{"其他平台", &models.ReleaseFile{Filename: "demo-1.0-cp311-cp311-win_amd64.whl"}, filter, false},
+		{"其他Python版本", &models.ReleaseFile{Filename: "demo-1.0-cp310-cp310-manylinux_2_17_x86_64.whl"}, filter, false},
+		{"源码包", &models.ReleaseFile{Filename: "demo-1.0.tar.gz", PackageType: "sdist"}, filter, true},
+		{"排除源码包", &models.ReleaseFile{Filename: "demo-1.0.tar.gz"}, FileFilter{NoSdist: true}, false},
+		{"排除wheel", &models.ReleaseFile{Filename: "demo-1.0-py3-none-any.whl"}, FileFilter{NoWheels: true}, false},
+		{"egg总是排除", &models.ReleaseFile{Filename: "demo-1.0-py3.8.egg", PackageType: "bdist_egg"}, FileFilter{}, false},
+		{"已撤回", &models.ReleaseFile{Filename: "demo-1.0.tar.gz", Yanked: true}, FileFilter{}, false},
+		{"模式不区分大小写", &models.ReleaseFile{Filename: "demo-1.0-py3-none-any.whl"}, FileFilter{Platforms: []string{"ANY"}}, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.want, c.filter.Allows(c.file))
+		})
+	}
+}
+
+func TestSelectVersions(t *testing.T) {
+	sdist := func(v string) []*models.ReleaseFile {
+		return []*models.ReleaseFile{{Filename: "demo-" + v + ".tar.gz"}}
+	}
+	pkg := &models.Package{Releases: map[string][]*models.ReleaseFile{
+		"1.0":    sdist("1.0"),
+		"1.1":    sdist("1.1"),
+		"1.10":   sdist("1.10"),
+		"2.0":    sdist("2.0"),
+		"3.0rc1": sdist("3.0rc1"),
+		"2.1":    {{Filename: "demo-2.1-cp27-cp27m-win32.whl"}},
+		"0.9":    {},
+	}}
+	spec := func(s string) version.SpecifierSet {
+		set, err := version.ParseSpecifierSet(s)
+		require.NoError(t, err)
+		return set
+	}
+	filter := FileFilter{PythonTags: []string{"py3"}}
+
+	t.Run("默认选择所有正式版本", func(t *testing.T) {
+		assert.Equal(t, []string{"2.0", "1.10", "1.1", "1.0"}, selectVersions(pkg, &selection{}, filter))
+	})
+
+	t.Run("没有满足过滤条件的文件时不选择", func(t *testing.T) {
+		assert.Contains(t, selectVersions(pkg, &selection{}, FileFilter{}), "2.1")
+	})
+
+	t.Run("包含预发布版本", func(t *testing.T) {
+		assert.Equal(t, "3.0rc1", selectVersions(pkg, &selection{prereleases: true}, filter)[0])
+	})
+
+	t.Run("最新N个满足约束的版本", func(t *testing.T) {
+		sel := &selection{constraint: spec("<2"), latest: 2}
+		assert.Equal(t, []string{"1.10", "1.1"}, selectVersions(pkg, sel, filter))
+	})
+
+	t.Run("依赖方要求的版本", func(t *testing.T) {
+		sel := &selection{latest: 1, required: []version.SpecifierSet{spec("<1.1"), spec(">=2")}}
+		assert.Equal(t, []string{"2.0", "1.0"}, selectVersions(pkg, sel, filter))
+	})
+}
@@ -0,0 +1,361 @@
+package offline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/indexserver"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+)
+
+// 静态目录树中的目录和文件名
+const (
+	// SimpleDir Simple API页面所在的目录
+	SimpleDir = "simple"
+
+	// JSONDir JSON API文件所在的目录
+	JSONDir = "pypi"
+
+	// PackagesDir 发布文件所在的目录
+	PackagesDir = "packages"
+
+	// IndexHTML HTML格式的Simple页面文件名
+	IndexHTML = "index.html"
+
+	// IndexJSON JSON格式的Simple页面文件名，与bandersnatch相同
+	IndexJSON = "index.v1_json"
+)
+
+// download 一个待下载的文件
+type download struct {
+	project string
+	file    *models.ReleaseFile
+	fetched bool
+	size    int64
+	err     error
+}
+
+// write 下载选中的文件，写入页面和JSON文件，并删除不再被选中的内容
+func (b *Builder) write(ctx context.Context, states map[string]*project) (*Report, error) {
+	if err := os.MkdirAll(b.root, 0o755); err != nil {
+		return nil, fmt.Errorf("创建镜像目录失败: %w", err)
+	}
+	report := &Report{Errors: map[string]error{}}
+	names := sortedNames(states)
+
+	var downloads []*download
+	for _, name := range names {
+		p := states[name]
+		if p.err != nil {
+			report.Errors[name] = p.err
+			continue
+		}
+		for _, v := range p.versions {
+			for _, f := range selectFiles(p.pkg.Releases[v], b.filter) {
+				downloads = append(downloads, &download{project: name, file: f})
+			}
+		}
+	}
+	b.parallel(len(downloads), func(i int) {
+		d := downloads[i]
+		d.fetched, d.size, d.err = b.fetch(ctx, d.project, d.file)
+	})
+
+	present := map[string]map[string]bool{}
+	for _, d := range downloads {
+		switch {
+		case d.err != nil:
+			report.Errors[d.file.Filename] = d.err
+			continue
+		case d.fetched:
+			report.Downloaded = append(report.Downloaded, d.file.Filename)
+			report.Bytes += d.size
+		default:
+			report.Unchanged++
+		}
+		if present[d.project] == nil {
+			present[d.project] = map[string]bool{}
+		}
+		present[d.project][d.file.Filename] = true
+	}
+
+	keep := map[string]bool{}
+	for _, name := range names {
+		p := states[name]
+		if p.err != nil {
+			// 获取失败的项目保留上次构建的内容
+			if _, err := os.Stat(filepath.Join(b.root, SimpleDir, name, IndexHTML)); err == nil {
+				keep[name] = true
+			}
+			continue
+		}
+		versions, err := b.writeProject(p, present[name])
+		if err != nil {
+			return nil, err
+		}
+		report.Versions += versions
+		keep[name] = true
+	}
+
+	for name := range keep {
+		report.Projects = append(report.Projects, name)
+	}
+	sort.Strings(report.Projects)
+	if err := b.writeIndex(report.Projects); err != nil {
+		return nil, err
+	}
+
+	removed, err := b.prune(states, keep, present)
+	if err != nil {
+		return nil, err
+	}
+	report.Removed = removed
+	sort.Strings(report.Downloaded)
+	return report, nil
+}
+
+// fetch 下载发布文件并校验哈希，文件已存在且大小一致时跳过
+func (b *Builder) fetch(ctx context.Context, project string, file *models.ReleaseFile) (bool, int64, error) {
+	if err := checkName(file.Filename); err != nil {
+		return false, 0, err
+	}
+	target := filepath.Join(b.root, PackagesDir, project, file.Filename)
+	if info, err := os.Stat(target); err == nil && (file.Size == 0 || info.Size() == file.Size) {
+		return false, 0, nil
+	}
+
+	verifier, err := file.Digests.Verifier()
+	if err != nil {
+		return false, 0, fmt.Errorf("%s: %w", file.Filename, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, file.URL, nil)
+	if err != nil {
+		return false, 0, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("User-Agent", b.userAgent)
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return false, 0, fmt.Errorf("下载 %s 失败: %w", file.Filename, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, 0, fmt.Errorf("下载 %s 失败: HTTP %d", file.Filename, resp.StatusCode)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return false, 0, fmt.Errorf("创建目录失败: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".tmp-*")
+	if err != nil {
+		return false, 0, fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(io.MultiWriter(tmp, verifier), resp.Body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return false, 0, fmt.Errorf("下载 %s 失败: %w", file.Filename, err)
+	}
+	if err := verifier.Verify(); err != nil {
+		return false, 0, fmt.Errorf("%s 的%w", file.Filename, err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return false, 0, fmt.Errorf("保存 %s 失败: %w", file.Filename, err)
+	}
+	return true, n, nil
+}
+
+// writeProject 写入项目的Simple页面和JSON API文件，只包含已下载的文件，返回写入的版本数
+func (b *Builder) writeProject(p *project, present map[string]bool) (int, error) {
+	pkg := *p.pkg
+	pkg.Releases = map[string][]*models.ReleaseFile{}
+	for _, v := range p.versions {
+		if files := localFiles(p.pkg.Releases[v], present, "../../"+PackagesDir+"/"+p.name+"/"); len(files) > 0 {
+			pkg.Releases[v] = files
+		}
+	}
+	pkg.Urls = localFiles(p.pkg.Urls, present, "../../"+PackagesDir+"/"+p.name+"/")
+
+	// Simple页面位于 simple/<项目>/，与JSON API文件相同，都向上两级到达根目录
+	project := indexserver.ProjectFromPackage(&pkg, nil)
+	project.Name = p.name
+	if err := b.writePages(filepath.Join(b.root, SimpleDir, p.name), func(contentType string) ([]byte, error) {
+		return indexserver.RenderProject(contentType, project)
+	}); err != nil {
+		return 0, err
+	}
+	if err := b.writeJSON(packageJSONPath(p.name), &pkg); err != nil {
+		return 0, err
+	}
+
+	for v := range pkg.Releases {
+		release, ok := p.releases[v]
+		if !ok {
+			continue
+		}
+		copied := *release
+		copied.Urls = localFiles(release.Urls, present, "../../../"+PackagesDir+"/"+p.name+"/")
+		copied.Releases = nil
+		if err := b.writeJSON(releaseJSONPath(p.name, v), &copied); err != nil {
+			return 0, err
+		}
+	}
+	return len(pkg.Releases), nil
+}
+
+// localFiles 返回已下载文件的副本，URL改为prefix加文件名
+func localFiles(files []*models.ReleaseFile, present map[string]bool, prefix string) []*models.ReleaseFile {
+	var result []*models.ReleaseFile
+	for _, f := range files {
+		if !present[f.Filename] {
+			continue
+		}
+		copied := *f
+		copied.URL = prefix + url.PathEscape(f.Filename)
+		result = append(result, &copied)
+	}
+	return result
+}
+
+// writeIndex 写入项目列表
+func (b *Builder) writeIndex(projects []string) error {
+	return b.writePages(filepath.Join(b.root, SimpleDir), func(contentType string) ([]byte, error) {
+		return indexserver.RenderIndex(contentType, projects)
+	})
+}
+
+// writePages 在dir中写入HTML和JSON两种格式的Simple页面
+func (b *Builder) writePages(dir string, render func(contentType string) ([]byte, error)) error {
+	for filename, contentType := range map[string]string{IndexHTML: indexserver.ContentTypeHTML, IndexJSON: indexserver.ContentTypeJSON} {
+		data, err := render(contentType)
+		if err != nil {
+			return err
+		}
+		if err := writeFile(filepath.Join(dir, filename), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Builder) writeJSON(rel string, pkg *models.Package) error {
+	data, err := json.MarshalIndent(pkg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("编码 %s 失败: %w", rel, err)
+	}
+	return writeFile(filepath.Join(b.root, rel), data)
+}
+
+func (b *Builder) readPackage(rel string) (*models.Package, error) {
+	data, err := os.ReadFile(filepath.Join(b.root, rel))
+	if err != nil {
+		return nil, err
+	}
+	var pkg models.Package
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, err
+	}
+	return &pkg, nil
+}
+
+// prune 删除不再被选中的项目、版本和文件，返回删除的路径
+func (b *Builder) prune(states map[string]*project, keep map[string]bool, present map[string]map[string]bool) ([]string, error) {
+	var removed []string
+	remove := func(rel string) error {
+		if err := os.RemoveAll(filepath.Join(b.root, rel)); err != nil {
+			return fmt.Errorf("删除 %s 失败: %w", rel, err)
+		}
+		removed = append(removed, filepath.ToSlash(rel))
+		return nil
+	}
+
+	for _, dir := range []string{SimpleDir, JSONDir, PackagesDir} {
+		entries, err := os.ReadDir(filepath.Join(b.root, dir))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("读取 %s 失败: %w", dir, err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() && !keep[entry.Name()] {
+				if err := remove(filepath.Join(dir, entry.Name())); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	for name, p := range states {
+		if p.err != nil || !keep[name] {
+			continue
+		}
+		files, _ := os.ReadDir(filepath.Join(b.root, PackagesDir, name))
+		for _, entry := range files {
+			if !present[name][entry.Name()] {
+				if err := remove(filepath.Join(PackagesDir, name, entry.Name())); err != nil {
+					return nil, err
+				}
+			}
+		}
+		versions, _ := os.ReadDir(filepath.Join(b.root, JSONDir, name))
+		selected := map[string]bool{}
+		for _, v := range p.versions {
+			selected[v] = true
+		}
+		for _, entry := range versions {
+			if entry.IsDir() && !selected[entry.Name()] {
+				if err := remove(filepath.Join(JSONDir, name, entry.Name())); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	sort.Strings(removed)
+	return removed, nil
+}
+
+func packageJSONPath(name string) string {
+	return filepath.Join(JSONDir, name, "json")
+}
+
+func releaseJSONPath(name, v string) string {
+	return filepath.Join(JSONDir, name, v, "json")
+}
+
+// writeFile 先写入临时文件再重命名，读取方不会看到不完整的页面
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入 %s 失败: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", path, err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// checkName 检查文件名是否为单个路径组成部分
+func checkName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("无效的文件名: %q", name)
+	}
+	return nil
+}
@@ -0,0 +1,333 @@
+package osv
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/version"
+	"gopkg.in/yaml.v3"
+)
+
+// EcosystemPyPI OSV中PyPI生态的名称
+const EcosystemPyPI = "PyPI"
+
+// 范围类型
+const (
+	// RangeEcosystem 按生态自身的版本规则（PyPI即PEP 440）排序
+	RangeEcosystem = "ECOSYSTEM"
+
+	// RangeSemver 按语义化版本排序，PyPI的版本号大多兼容，同样按PEP 440处理
+	RangeSemver = "SEMVER"
+
+	// RangeGit 以提交哈希描述的范围，无法用于版本号匹配
+	RangeGit = "GIT"
+)
+
+// Advisory OSV格式的安全公告，字段定义见 https://ossf.github.io/osv-schema/
+type Advisory struct {
+	SchemaVersion    string          `json:"schema_version,omitempty"`
+	ID               string          `json:"id"`
+	Modified         string          `json:"modified"`
+	Published        string          `json:"published,omitempty"`
+	Withdrawn        string          `json:"withdrawn,omitempty"`
+	Aliases          []string        `json:"aliases,omitempty"`
+	Related          []string        `json:"related,omitempty"`
+	Summary          string          `json:"summary,omitempty"`
+	Details          string          `json:"details,omitempty"`
+	Severity         []Severity      `json:"severity,omitempty"`
+	Affected         []Affected      `json:"affected,omitempty"`
+	References       []Reference     `json:"references,omitempty"`
+	DatabaseSpecific json.RawMessage `json:"database_specific,omitempty"`
+}
+
+// Severity 严重程度评分，Score通常是CVSS向量
+type Severity = models.Severity
+
+// Reference 公告的参考链接
+type Reference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// Package 受影响的包
+type Package struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+	Purl      string `json:"purl,omitempty"`
+}
+
+// Affected 一个受影响的包及其受影响的版本
+type Affected struct {
+	Package           Package         `json:"package"`
+	Severity          []Severity      `json:"severity,omitempty"`
+	Ranges            []Range         `json:"ranges,omitempty"`
+	Versions          []string        `json:"versions,omitempty"`
+	EcosystemSpecific json.RawMessage `json:"ecosystem_specific,omitempty"`
+	DatabaseSpecific  json.RawMessage `json:"database_specific,omitempty"`
+}
+
+// Range 以事件序列描述的受影响版本范围
+type Range struct {
+	Type   string  `json:"type"`
+	Repo   string  `json:"repo,omitempty"`
+	Events []Event `json:"events"`
+}
+
+// Event 范围中的一个事件，每个事件只设置一个字段
+type Event struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+	Limit        string `json:"limit,omitempty"`
+}
+
+// ParseAdvisory 解析JSON格式的OSV公告
+func ParseAdvisory(data []byte) (*Advisory, error) {
+	var adv Advisory
+	if err := json.Unmarshal(data, &adv); err != nil {
+		return nil, fmt.Errorf("解析OSV公告失败: %w", err)
+	}
+	if adv.ID == "" {
+		return nil, fmt.Errorf("解析OSV公告失败: 缺少id")
+	}
+	return &adv, nil
+}
+
+// ParseAdvisoryYAML 解析YAML格式的OSV公告，PyPA advisory-database仓库中的公告使用此格式
+func ParseAdvisoryYAML(data []byte) (*Advisory, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("解析OSV公告失败: %w", err)
+	}
+	// 经JSON转换后复用同一套字段定义，YAML中的时间值会被编码为RFC 3339字符串
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("解析OSV公告失败: %w", err)
+	}
+	return ParseAdvisory(data)
+}
+
+// IsWithdrawn 检查公告是否已被撤回
+func (a *Advisory) IsWithdrawn() bool {
+	return a.Withdrawn != ""
+}
+
+// Packages 返回公告中受影响的PyPI包名（已规范化、去重并排序）
+func (a *Advisory) Packages() []string {
+	seen := map[string]bool{}
+	for _, affected := range a.Affected {
+		if affected.Package.Ecosystem == EcosystemPyPI && affected.Package.Name != "" {
+			seen[models.NormalizeName(affected.Package.Name)] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Affects 检查公告是否影响指定PyPI包的某个版本
+//
+// 参数:
+//   - name: 包名，比较时按PEP 503规范化
+//   - v: 版本号
+//
+// 返回值:
+//   - bool: 任一受影响条目的显式版本列表或ECOSYSTEM/SEMVER范围包含该版本时为true
+func (a *Advisory) Affects(name string, v *version.Version) bool {
+	normalized := models.NormalizeName(name)
+	for i := range a.Affected {
+		affected := &a.Affected[i]
+		if affected.Package.Ecosystem != EcosystemPyPI || models.NormalizeName(affected.Package.Name) != normalized {
+			continue
+		}
+		if affected.Contains(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains 检查版本是否在受影响的版本列表或范围内
+func (a *Affected) Contains(v *version.Version) bool {
+	for _, s := range a.Versions {
+		if listed, err := version.Parse(s); err == nil && listed.Compare(v) == 0 {
+			return true
+		}
+	}
+	for i := range a.Ranges {
+		if a.Ranges[i].Contains(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// FixedVersions 返回受影响范围中的修复版本
+func (a *Affected) FixedVersions() []string {
+	var fixed []string
+	for _, r := range a.Ranges {
+		if r.Type == RangeGit {
+			continue
+		}
+		for _, e := range r.Events {
+			if e.Fixed != "" {
+				fixed = append(fixed, e.Fixed)
+			}
+		}
+	}
+	return fixed
+}
+
+// Contains 检查版本是否在范围内
+// 事件按版本排序后，每个introduced与其后第一个fixed或last_affected构成一个受影响区间，与OSV规范的算法等价；
+// GIT类型的范围和无法解析的事件版本被忽略
+func (r *Range) Contains(v *version.Version) bool {
+	for _, interval := range r.Intervals() {
+		if interval.Contains(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Intervals 将事件序列拆分为连续的受影响区间，GIT类型的范围和无法解析的事件版本被忽略
+func (r *Range) Intervals() []models.VersionRange {
+	if r.Type != RangeEcosystem && r.Type != RangeSemver {
+		return nil
+	}
+	type event struct {
+		Event
+		at *version.Version
+	}
+	var events []event
+	for _, e := range r.Events {
+		raw := e.Introduced + e.Fixed + e.LastAffected
+		if raw == "" {
+			continue
+		}
+		if e.Introduced == "0" {
+			events = append(events, event{Event: e})
+			continue
+		}
+		at, err := version.Parse(raw)
+		if err != nil {
+			continue
+		}
+		events = append(events, event{Event: e, at: at})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := events[i].at, events[j].at
+		switch {
+		case a == nil:
+			return b != nil
+		case b == nil:
+			return false
+		}
+		return a.Compare(b) < 0
+	})
+
+	var intervals []models.VersionRange
+	var open *models.VersionRange
+	for _, e := range events {
+		switch {
+		case e.Introduced != "":
+			if open == nil {
+				open = &models.VersionRange{}
+				if e.Introduced != "0" {
+					open.Introduced = e.Introduced
+				}
+			}
+		case open != nil:
+			open.Fixed, open.LastAffected = e.Fixed, e.LastAffected
+			intervals = append(intervals, *open)
+			open = nil
+		}
+	}
+	if open != nil {
+		intervals = append(intervals, *open)
+	}
+	return intervals
+}
+
+// Vulnerability 将公告转换为与PyPI JSON API一致的漏洞信息
+// FixedIn只包含指定包的修复版本，按PEP 440排序；Ranges包含该包的全部受影响区间，
+// 范围未覆盖的显式受影响版本以单版本区间表示
+func (a *Advisory) Vulnerability(name string) models.Vulnerability {
+	normalized := models.NormalizeName(name)
+	seen := map[string]bool{}
+	var fixed []string
+	var ranges []models.VersionRange
+	severity := append([]models.Severity(nil), a.Severity...)
+	databaseSpecific := a.DatabaseSpecific
+	for i := range a.Affected {
+		affected := &a.Affected[i]
+		if affected.Package.Ecosystem != EcosystemPyPI || models.NormalizeName(affected.Package.Name) != normalized {
+			continue
+		}
+		for _, f := range affected.FixedVersions() {
+			if !seen[f] {
+				seen[f] = true
+				fixed = append(fixed, f)
+			}
+		}
+		var own []models.VersionRange
+		for j := range affected.Ranges {
+			own = append(own, affected.Ranges[j].Intervals()...)
+		}
+		for _, s := range affected.Versions {
+			v, err := version.Parse(s)
+			if err != nil || covered(own, v) {
+				continue
+			}
+			ranges = append(ranges, models.VersionRange{Introduced: s, LastAffected: s})
+		}
+		ranges = append(ranges, own...)
+		// 包级别的评分和数据源信息补充公告级别的
+		for _, s := range affected.Severity {
+			if !hasSeverity(severity, s) {
+				severity = append(severity, s)
+			}
+		}
+		if len(databaseSpecific) == 0 {
+			databaseSpecific = affected.DatabaseSpecific
+		}
+	}
+	version.SortStrings(fixed)
+
+	return models.Vulnerability{
+		ID:               a.ID,
+		Aliases:          a.Aliases,
+		Summary:          a.Summary,
+		Details:          a.Details,
+		FixedIn:          fixed,
+		Source:           "osv",
+		Link:             "https://osv.dev/vulnerability/" + a.ID,
+		Withdrawn:        a.Withdrawn,
+		Ranges:           ranges,
+		Severity:         severity,
+		DatabaseSpecific: databaseSpecific,
+	}
+}
+
+func hasSeverity(list []models.Severity, target models.Severity) bool {
+	for _, s := range list {
+		if s == target {
+			return true
+		}
+	}
+	return false
+}
+
+func covered(ranges []models.VersionRange, v *version.Version) bool {
+	for _, r := range ranges {
+		if r.Contains(v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package osv
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/cvss"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRangeContains(t *testing.T) {
+	cases := []struct {
+		name     string
+		events   []Event
+		affected []string
+		clean    []string
+	}{
+		{
+			name:     "从最初版本到修复版本",
+			events:   []Event{{Introduced: "0"}, {Fixed: "1.2.3"}},
+			affected: []string{"0.1", "1.2.2", "1.2.3rc1"},
+			clean:    []string{"1.2.3", "1.2.5", "2.0"},
+		},
+		{
+			name:     "多个区间",
+			events:   []Event{{Introduced: "1.0"}, {Fixed: "1.2.3"}, {Introduced: "2.0"}, {Fixed: "2.0.1"}},
+			affected: []string{"1.0", "1.2", "2.0.0"},
+			clean:    []string{"0.9", "1.2.3", "1.9", "2.0.1", "3.0"},
+		},
+		{
+			name:     "事件无序",
+			events:   []Event{{Fixed: "2.0.1"}, {Introduced: "2.0"}, {Fixed: "1.2.3"}, {Introduced: "1.0"}},
+			affected: []string{"1.1", "2.0"},
+			clean:    []string{"1.5", "2.1"},
+		},
+		{
+			name:     "last_affected包含边界",
+			events:   []Event{{Introduced: "3.0.0a1"}, {LastAffected: "3.0.0b2"}},
+			affected: []string{"3.0.0a1", "3.0.0b2"},
+			clean:    []string{"2.9", "3.0.0b3", "3.0.0"},
+		},
+		{
+			name:     "没有修复版本",
+			events:   []Event{{Introduced: "1.5"}},
+			affected: []string{"1.5", "99"},
+			clean:    []string{"1.4"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := Range{Type: RangeEcosystem, Events: tc.events}
+			for _, v := range tc.affected {
+				assert.True(t, r.Contains(version.MustParse(v)), v)
+			}
+			for _, v := range tc.clean {
+				assert.False(t, r.Contains(version.MustParse(v)), v)
+			}
+		})
+	}
+
+	t.Run("忽略GIT范围", func(t *testing.T) {
+		r := Range{Type: RangeGit, Events: []Event{{Introduced: "0"}}}
+		assert.False(t, r.Contains(version.MustParse("1.0")))
+		assert.Nil(t, r.Intervals())
+	})
+}
+
+func TestRangeIntervals(t *testing.T) {
+	r := Range{Type: RangeEcosystem, Events: []Event{
+		{Introduced: "2.0"}, {LastAffected: "2.0.4"}, {Fixed: "1.2.3"}, {Introduced: "0"}, {Introduced: "3.0"},
+	}}
+	assert.Equal(t, []models.VersionRange{
+		{Fixed: "1.2.3"},
+		{Introduced: "2.0", LastAffected: "2.0.4"},
+		{Introduced: "3.0"},
+	}, r.Intervals())
+}
+
+func TestParseAdvisory(t *testing.T) {
+	t.Run("缺少id", func(t *testing.T) {
+		_, err := ParseAdvisory([]byte(`{"summary": "x"}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("JSON格式错误", func(t *testing.T) {
+		_, err := ParseAdvisory([]byte(`{`))
+		assert.Error(t, err)
+	})
+
+	t.Run("YAML中的时间值", func(t *testing.T) {
+		adv, err := ParseAdvisoryYAML([]byte("id: PYSEC-1\nmodified: 2023-06-05T01:13:00Z\n"))
+		require.NoError(t, err)
+		assert.Equal(t, "2023-06-05T01:13:00Z", adv.Modified)
+	})
+}
+
+func TestAdvisory(t *testing.T) {
+	adv := &Advisory{
+		ID:      "GHSA-1",
+		Aliases: []string{"CVE-2024-1"},
+		Affected: []Affected{
+			{
+				Package: Package{Ecosystem: EcosystemPyPI, Name: "Foo_Bar"},
+				Ranges: []Range{
+					{Type: RangeEcosystem, Events: []Event{{Introduced: "2.0"}, {Fixed: "2.0.4"}}},
+					{Type: RangeEcosystem, Events: []Event{{Introduced: "0"}, {Fixed: "1.9.10"}}},
+				},
+			},
+			{
+				Package: Package{Ecosystem: "npm", Name: "foo-bar"},
+				Ranges:  []Range{{Type: RangeSemver, Events: []Event{{Introduced: "0"}}}},
+			},
+		},
+	}
+
+	t.Run("按包名和生态匹配", func(t *testing.T) {
+		assert.Equal(t, []string{"foo-bar"}, adv.Packages())
+		assert.True(t, adv.Affects("foo.bar", version.MustParse("2.0.1")))
+		assert.False(t, adv.Affects("foo-bar", version.MustParse("3.0")))
+		assert.False(t, adv.Affects("other", version.MustParse("1.0")))
+	})
+
+	t.Run("转换为漏洞信息", func(t *testing.T) {
+		vuln := adv.Vulnerability("foo-bar")
+		assert.Equal(t, "GHSA-1", vuln.ID)
+		assert.Equal(t, []string{"CVE-2024-1"}, vuln.Aliases)
+		assert.Equal(t, []string{"1.9.10", "2.0.4"}, vuln.FixedIn)
+		assert.Equal(t, "osv", vuln.Source)
+		assert.Equal(t, "https://osv.dev/vulnerability/GHSA-1", vuln.Link)
+		assert.Equal(t, []models.VersionRange{
+			{Introduced: "2.0", Fixed: "2.0.4"},
+			{Fixed: "1.9.10"},
+		}, vuln.Ranges)
+	})
+
+	t.Run("范围未覆盖的显式版本", func(t *testing.T) {
+		adv := &Advisory{ID: "PYSEC-1", Affected: []Affected{{
+			Package:  Package{Ecosystem: EcosystemPyPI, Name: "foo"},
+			Ranges:   []Range{{Type: RangeEcosystem, Events: []Event{{Introduced: "1.0"}, {Fixed: "1.1"}}}},
+			Versions: []string{"1.0", "0.9"},
+		}}}
+		vuln := adv.Vulnerability("foo")
+		assert.Equal(t, []models.VersionRange{
+			{Introduced: "0.9", LastAffected: "0.9"},
+			{Introduced: "1.0", Fixed: "1.1"},
+		}, vuln.Ranges)
+		assert.True(t, vuln.Affects("0.9"))
+		assert.False(t, vuln.Affects("0.9.1"))
+		assert.Equal(t, []string{"1.1"}, vuln.FixedIn)
+	})
+
+	t.Run("合并严重程度评分", func(t *testing.T) {
+		v3 := Severity{Type: "CVSS_V3", Score: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:L"}
+		v4 := Severity{Type: "CVSS_V4", Score: "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N"}
+		adv := &Advisory{ID: "PYSEC-2", Severity: []Severity{v3}, Affected: []Affected{
+			{
+				Package:          Package{Ecosystem: EcosystemPyPI, Name: "foo"},
+				Severity:         []Severity{v3, v4},
+				DatabaseSpecific: json.RawMessage(`{"severity":"LOW"}`),
+			},
+			{
+				Package:  Package{Ecosystem: "npm", Name: "foo"},
+				Severity: []Severity{{Type: "CVSS_V3", Score: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H"}},
+			},
+		}}
+		vuln := adv.Vulnerability("foo")
+		assert.Equal(t, []models.Severity{v3, v4}, vuln.Severity)
+		assert.JSONEq(t, `{"severity":"LOW"}`, string(vuln.DatabaseSpecific))
+		assert.Equal(t, cvss.RatingCritical, vuln.Rating())
+	})
+}
@@ -0,0 +1,263 @@
+package osv
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/api"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/client"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/version"
+)
+
+// DB 按规范化包名索引的离线漏洞库
+// 可以从osv.dev导出的PyPI/all.zip、PyPA advisory-database仓库或任意OSV公告目录加载
+type DB struct {
+	mu         sync.RWMutex
+	advisories map[string]*Advisory   // 按ID
+	byPackage  map[string][]*Advisory // 按规范化包名
+}
+
+// NewDB 创建一个空的漏洞库
+func NewDB() *DB {
+	return &DB{
+		advisories: make(map[string]*Advisory),
+		byPackage:  make(map[string][]*Advisory),
+	}
+}
+
+// Load 从目录或zip文件加载漏洞库
+//
+// 参数:
+//   - p: 公告目录（递归读取其中的 .json、.yaml 和 .yml 文件）或zip文件路径
+//
+// 返回值:
+//   - *DB: 加载后的漏洞库
+//   - error: 路径无法读取或任一公告无法解析时返回
+//
+// 使用示例:
+//
+//	// curl -O https://osv-vulnerabilities.storage.googleapis.com/PyPI/all.zip
+//	db, err := osv.Load("all.zip")
+//	if err != nil {
+//		return err
+//	}
+//	vulns, err := db.Query("jinja2", "2.11.2")
+func Load(p string) (*DB, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, fmt.Errorf("读取漏洞库失败: %w", err)
+	}
+	db := NewDB()
+	if info.IsDir() {
+		err = db.LoadFS(os.DirFS(p))
+	} else {
+		err = db.LoadZip(p)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// LoadZip 从zip文件加载公告
+func (db *DB) LoadZip(p string) error {
+	r, err := zip.OpenReader(p)
+	if err != nil {
+		return fmt.Errorf("打开漏洞库压缩包失败: %w", err)
+	}
+	defer r.Close()
+	return db.LoadFS(r)
+}
+
+// LoadFS 递归加载文件系统中的 .json、.yaml 和 .yml 公告，其他文件被忽略
+func (db *DB) LoadFS(fsys fs.FS) error {
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// 跳过 .git 等隐藏目录
+			if name != "." && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		var parse func([]byte) (*Advisory, error)
+		switch strings.ToLower(path.Ext(name)) {
+		case ".json":
+			parse = ParseAdvisory
+		case ".yaml", ".yml":
+			parse = ParseAdvisoryYAML
+		default:
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		adv, err := parse(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		db.Add(adv)
+		return nil
+	})
+}
+
+// Add 添加一条公告，同一ID已存在时保留modified较新的一条
+func (db *DB) Add(adv *Advisory) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if existing, ok := db.advisories[adv.ID]; ok {
+		if existing.Modified > adv.Modified {
+			return
+		}
+		for _, name := range existing.Packages() {
+			db.byPackage[name] = removeAdvisory(db.byPackage[name], existing)
+			if len(db.byPackage[name]) == 0 {
+				delete(db.byPackage, name)
+			}
+		}
+	}
+	db.advisories[adv.ID] = adv
+	for _, name := range adv.Packages() {
+		db.byPackage[name] = append(db.byPackage[name], adv)
+	}
+}
+
+func removeAdvisory(list []*Advisory, target *Advisory) []*Advisory {
+	result := list[:0]
+	for _, adv := range list {
+		if adv != target {
+			result = append(result, adv)
+		}
+	}
+	return result
+}
+
+// Len 返回公告数量
+func (db *DB) Len() int {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return len(db.advisories)
+}
+
+// Packages 返回漏洞库中有公告的包名（已规范化并排序）
+func (db *DB) Packages() []string {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	names := make([]string, 0, len(db.byPackage))
+	for name := range db.byPackage {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Advisory 按ID或别名（如CVE编号）查找公告
+func (db *DB) Advisory(id string) (*Advisory, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	if adv, ok := db.advisories[id]; ok {
+		return adv, true
+	}
+	for _, adv := range db.advisories {
+		for _, alias := range adv.Aliases {
+			if alias == id {
+				return adv, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Advisories 返回影响指定包（任意版本）的所有公告，按ID排序
+func (db *DB) Advisories(name string) []*Advisory {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	list := append([]*Advisory(nil), db.byPackage[models.NormalizeName(name)]...)
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Query 查询影响指定包版本的漏洞，结果与CheckPackageVulnerabilities一致（包含已撤回的漏洞，按ID排序）
+//
+// 参数:
+//   - name: 包名，比较时按PEP 503规范化
+//   - v: 版本号
+//
+// 返回值:
+//   - []models.Vulnerability: 影响该版本的漏洞
+//   - error: 版本号不是有效的PEP 440版本号时返回
+func (db *DB) Query(name, v string) ([]models.Vulnerability, error) {
+	parsed, err := version.Parse(v)
+	if err != nil {
+		return nil, err
+	}
+	var vulns []models.Vulnerability
+	for _, adv := range db.Advisories(name) {
+		if adv.Affects(name, parsed) {
+			vulns = append(vulns, adv.Vulnerability(name))
+		}
+	}
+	return vulns, nil
+}
+
+// Client 使用离线漏洞库回答CheckPackageVulnerabilities的客户端，其余方法委托给内嵌的客户端
+// 可以直接传给sbom.Generator、reqfile.Auditor等依赖api.PyPIClient的组件
+type Client struct {
+	api.PyPIClient
+
+	// DB 离线漏洞库
+	DB *DB
+}
+
+var (
+	_ api.PyPIClient         = (*Client)(nil)
+	_ api.CoreMetadataClient = (*Client)(nil)
+)
+
+// NewClient 创建使用离线漏洞库的客户端
+//
+// 参数:
+//   - c: 处理其他请求的客户端，通常指向内网镜像
+//   - db: 离线漏洞库
+//
+// 返回值:
+//   - *Client: 包装后的客户端
+//
+// 使用示例:
+//
+//	db, _ := osv.Load("/data/advisory-database/vulns")
+//	c := osv.NewClient(client.NewClient(client.NewOptions().WithBaseURL("https://pypi.internal/pypi")), db)
+//	inv, err := sbom.NewGenerator(c).WithVulnerabilities(true).Collect(ctx, pins)
+func NewClient(c api.PyPIClient, db *DB) *Client {
+	return &Client{PyPIClient: c, DB: db}
+}
+
+// CheckPackageVulnerabilities 从离线漏洞库查询漏洞，不发起网络请求
+func (c *Client) CheckPackageVulnerabilities(ctx context.Context, packageName string, version string) ([]models.Vulnerability, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.DB.Query(packageName, version)
+}
+
+// GetCoreMetadata 委托给内嵌的客户端读取核心元数据，内嵌的客户端不支持时返回ErrUnsupported
+func (c *Client) GetCoreMetadata(ctx context.Context, file *models.ReleaseFile) (*models.CoreMetadata, error) {
+	mc, ok := c.PyPIClient.(api.CoreMetadataClient)
+	if !ok {
+		return nil, fmt.Errorf("%w: 客户端不支持GetCoreMetadata", client.ErrUnsupported)
+	}
+	return mc.GetCoreMetadata(ctx, file)
+}
@@ -0,0 +1,157 @@
+package osv
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/cvss"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ids(t *testing.T, db *DB, name, version string) []string {
+	t.Helper()
+	vulns, err := db.Query(name, version)
+	require.NoError(t, err)
+	result := []string{}
+	for _, v := range vulns {
+		result = append(result, v.ID)
+	}
+	return result
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("从目录加载JSON和YAML公告", func(t *testing.T) {
+		db, err := Load(filepath.Join("testdata", "vulns"))
+		require.NoError(t, err)
+		assert.Equal(t, 4, db.Len())
+		assert.Equal(t, []string{"jinja2", "requests"}, db.Packages())
+	})
+
+	t.Run("从zip加载", func(t *testing.T) {
+		zipPath := filepath.Join(t.TempDir(), "all.zip")
+		f, err := os.Create(zipPath)
+		require.NoError(t, err)
+		w := zip.NewWriter(f)
+		for _, name := range []string{"jinja2/PYSEC-2021-66.json", "requests/PYSEC-2023-74.yaml"} {
+			data, err := os.ReadFile(filepath.Join("testdata", "vulns", name))
+			require.NoError(t, err)
+			entry, err := w.Create(filepath.Base(name))
+			require.NoError(t, err)
+			_, err = entry.Write(data)
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+		require.NoError(t, f.Close())
+
+		db, err := Load(zipPath)
+		require.NoError(t, err)
+		assert.Equal(t, 2, db.Len())
+		assert.Equal(t, []string{"PYSEC-2021-66"}, ids(t, db, "jinja2", "2.11.2"))
+	})
+
+	t.Run("路径不存在", func(t *testing.T) {
+		_, err := Load(filepath.Join("testdata", "missing"))
+		assert.Error(t, err)
+	})
+
+	t.Run("跳过隐藏目录，报告无效公告", func(t *testing.T) {
+		db := NewDB()
+		require.NoError(t, db.LoadFS(fstest.MapFS{
+			".git/HEAD.json": {Data: []byte("{")},
+			"a/GHSA-1.json":  {Data: []byte(`{"id": "GHSA-1"}`)},
+		}))
+		assert.Equal(t, 1, db.Len())
+
+		err := db.LoadFS(fstest.MapFS{"bad.json": {Data: []byte("{")}})
+		assert.ErrorContains(t, err, "bad.json")
+	})
+}
+
+func TestDBQuery(t *testing.T) {
+	db, err := Load(filepath.Join("testdata", "vulns"))
+	require.NoError(t, err)
+
+	t.Run("按范围匹配", func(t *testing.T) {
+		assert.Equal(t, []string{"GHSA-h5c8-rqwp-cp95", "PYSEC-2021-66"}, ids(t, db, "Jinja2", "2.11.2"))
+		assert.Equal(t, []string{"GHSA-h5c8-rqwp-cp95"}, ids(t, db, "jinja2", "2.11.3"))
+		assert.Equal(t, []string{}, ids(t, db, "jinja2", "3.1.3"))
+	})
+
+	t.Run("多个范围和last_affected", func(t *testing.T) {
+		assert.Equal(t, []string{"PYSEC-2023-74"}, ids(t, db, "requests", "2.30.0"))
+		assert.Equal(t, []string{}, ids(t, db, "requests", "2.31.0"))
+		assert.Equal(t, []string{"PYSEC-2023-74"}, ids(t, db, "requests", "3.0.0b2"))
+		assert.Equal(t, []string{}, ids(t, db, "requests", "2.2.0"))
+	})
+
+	t.Run("包含已撤回的公告", func(t *testing.T) {
+		vulns, err := db.Query("requests", "2.19.0")
+		require.NoError(t, err)
+		require.Len(t, vulns, 2)
+		assert.True(t, vulns[0].IsWithdrawn())
+		assert.Equal(t, []string{"2.31.0"}, vulns[1].FixedIn)
+	})
+
+	t.Run("严重程度", func(t *testing.T) {
+		vulns, err := db.Query("jinja2", "2.11.2")
+		require.NoError(t, err)
+		require.Len(t, vulns, 2)
+		// GHSA公告只有database_specific中的评级
+		assert.Equal(t, cvss.RatingMedium, vulns[0].Rating())
+		score, ok := vulns[1].Score()
+		require.True(t, ok)
+		assert.Equal(t, 5.3, score)
+		assert.Len(t, models.FilterBySeverity(vulns, cvss.RatingMedium), 2)
+		assert.Empty(t, models.FilterBySeverity(vulns, cvss.RatingHigh))
+	})
+
+	t.Run("无效版本号", func(t *testing.T) {
+		_, err := db.Query("requests", "not a version")
+		assert.Error(t, err)
+	})
+
+	t.Run("按ID或别名查找", func(t *testing.T) {
+		adv, ok := db.Advisory("CVE-2023-32681")
+		require.True(t, ok)
+		assert.Equal(t, "PYSEC-2023-74", adv.ID)
+		_, ok = db.Advisory("CVE-0000-0000")
+		assert.False(t, ok)
+	})
+
+	t.Run("保留较新的公告", func(t *testing.T) {
+		db := NewDB()
+		db.Add(&Advisory{ID: "X", Modified: "2024-02-01T00:00:00Z", Summary: "new",
+			Affected: []Affected{{Package: Package{Ecosystem: EcosystemPyPI, Name: "b"}}}})
+		db.Add(&Advisory{ID: "X", Modified: "2024-01-01T00:00:00Z", Summary: "old",
+			Affected: []Affected{{Package: Package{Ecosystem: EcosystemPyPI, Name: "a"}}}})
+		adv, _ := db.Advisory("X")
+		assert.Equal(t, "new", adv.Summary)
+		assert.Equal(t, []string{"b"}, db.Packages())
+
+		db.Add(&Advisory{ID: "X", Modified: "2024-03-01T00:00:00Z",
+			Affected: []Affected{{Package: Package{Ecosystem: EcosystemPyPI, Name: "c"}}}})
+		assert.Equal(t, []string{"c"}, db.Packages())
+	})
+}
+
+func TestClient(t *testing.T) {
+	db, err := Load(filepath.Join("testdata", "vulns"))
+	require.NoError(t, err)
+	c := NewClient(nil, db)
+
+	vulns, err := c.CheckPackageVulnerabilities(context.Background(), "jinja2", "3.0.0")
+	require.NoError(t, err)
+	require.Len(t, vulns, 1)
+	assert.Equal(t, "GHSA-h5c8-rqwp-cp95", vulns[0].ID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.CheckPackageVulnerabilities(ctx, "jinja2", "3.0.0")
+	assert.ErrorIs(t, err, context.Canceled)
+}
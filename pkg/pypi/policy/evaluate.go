@@ -0,0 +1,259 @@
+package policy
+
+import (
+	"strings"
+	"time"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/cvss"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/license"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+)
+
+// AllowsProject 只根据项目名判断项目是否可见，用于项目列表
+// 只检查允许列表和不带版本约束的禁止列表条目
+func (p *Policy) AllowsProject(name string) bool {
+	verdict := &Verdict{Project: name}
+	p.checkName(verdict, name)
+	return verdict.Allowed()
+}
+
+// EvaluateProject 判定整个项目是否被屏蔽，检查项目名和许可证
+//
+// 参数:
+//   - pkg: 项目信息
+//
+// 返回值:
+//   - *Verdict: 判定结果，Version和Filename为空
+func (p *Policy) EvaluateProject(pkg *models.Package) *Verdict {
+	name := projectName(pkg)
+	verdict := &Verdict{Project: name}
+	p.checkName(verdict, name)
+	if p.Licenses != nil && pkg.Info != nil {
+		if result := p.Licenses.EvaluatePackage(pkg.Info); result.Decision == license.DecisionDeny {
+			verdict.add(RuleLicense, "许可证被禁止: %s", strings.Join(result.Reasons, "; "))
+		}
+	}
+	return verdict
+}
+
+// EvaluateRelease 判定项目的一个版本是否被屏蔽，包括项目级别的规则、带版本约束的禁止列表、漏洞和冷却期
+//
+// 参数:
+//   - pkg: 项目信息
+//   - ver: 版本号，即pkg.Releases的键
+//
+// 返回值:
+//   - *Verdict: 判定结果，Filename为空
+func (p *Policy) EvaluateRelease(pkg *models.Package, ver string) *Verdict {
+	verdict := p.EvaluateProject(pkg)
+	verdict.Version = ver
+	p.checkRelease(verdict, pkg, ver)
+	return verdict
+}
+
+// EvaluateFile 判定一个发布文件是否允许下载，在EvaluateRelease的基础上检查撤回状态
+//
+// 参数:
+//   - pkg: 项目信息
+//   - ver: 文件所属的版本号
+//   - file: 发布文件
+//
+// 返回值:
+//   - *Verdict: 判定结果
+//
+// 使用示例:
+//
+//	verdict := pol.EvaluateFile(pkg, "1.0", pkg.Releases["1.0"][0])
+//	if !verdict.Allowed() {
+//		http.Error(w, verdict.Explain(), http.StatusForbidden)
+//	}
+func (p *Policy) EvaluateFile(pkg *models.Package, ver string, file *models.ReleaseFile) *Verdict {
+	verdict := p.EvaluateRelease(pkg, ver)
+	verdict.Filename = file.Filename
+	p.checkFile(verdict, file)
+	return verdict
+}
+
+// Filter 返回去掉被屏蔽的版本和文件后的项目信息副本，以及被屏蔽的版本和文件的判定
+// 所有文件都被屏蔽的版本会一并去掉；项目本身的规则由EvaluateProject检查，Filter不处理
+func (p *Policy) Filter(pkg *models.Package) (*models.Package, []*Verdict) {
+	filtered := *pkg
+	filtered.Releases = make(map[string][]*models.ReleaseFile, len(pkg.Releases))
+	var removed []*Verdict
+
+	for ver, files := range pkg.Releases {
+		release := &Verdict{Project: projectName(pkg), Version: ver}
+		p.checkRelease(release, pkg, ver)
+		if !release.Allowed() {
+			removed = append(removed, release)
+			continue
+		}
+
+		kept := make([]*models.ReleaseFile, 0, len(files))
+		for _, f := range files {
+			verdict := &Verdict{Project: release.Project, Version: ver, Filename: f.Filename}
+			p.checkFile(verdict, f)
+			if !verdict.Allowed() {
+				removed = append(removed, verdict)
+				continue
+			}
+			kept = append(kept, f)
+		}
+		if len(files) > 0 && len(kept) == 0 {
+			continue
+		}
+		filtered.Releases[ver] = kept
+	}
+
+	if pkg.Urls != nil {
+		filtered.Urls = make([]*models.ReleaseFile, 0, len(pkg.Urls))
+		for _, f := range pkg.Urls {
+			if contains(filtered.Releases, f.Filename) {
+				filtered.Urls = append(filtered.Urls, f)
+			}
+		}
+	}
+	return &filtered, removed
+}
+
+// contains 检查版本中是否有该文件，Urls与Releases中的文件不是同一个对象，按文件名比较
+func contains(releases map[string][]*models.ReleaseFile, filename string) bool {
+	for _, files := range releases {
+		for _, f := range files {
+			if f.Filename == filename {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkName 检查允许列表和不带版本约束的禁止列表
+func (p *Policy) checkName(verdict *Verdict, name string) {
+	if len(p.Allow) > 0 {
+		allowed := false
+		for _, pattern := range p.Allow {
+			if matchName(pattern, name) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			verdict.add(RuleAllow, "项目 %s 不在允许列表中", name)
+		}
+	}
+	for _, raw := range p.Deny {
+		entry, err := parseDeny(raw)
+		if err == nil && len(entry.specifier) == 0 && matchName(entry.pattern, name) {
+			verdict.add(RuleDeny, "项目 %s 在禁止列表中（%s）", name, entry.raw)
+		}
+	}
+}
+
+// checkRelease 检查带版本约束的禁止列表、漏洞和冷却期
+func (p *Policy) checkRelease(verdict *Verdict, pkg *models.Package, ver string) {
+	name := projectName(pkg)
+	for _, raw := range p.Deny {
+		entry, err := parseDeny(raw)
+		if err == nil && len(entry.specifier) > 0 && matchName(entry.pattern, name) && entry.specifier.ContainsString(ver) {
+			verdict.add(RuleDeny, "版本 %s 在禁止列表中（%s）", ver, entry.raw)
+		}
+	}
+
+	if rule := p.Vulnerabilities; rule != nil {
+		for _, vuln := range p.vulnerabilities(pkg, name, ver) {
+			if rule.ignored(vuln) {
+				continue
+			}
+			rating := vuln.Rating()
+			if rule.MinSeverity != "" && (rating == cvss.RatingUnknown || !rating.AtLeast(cvss.ParseRating(rule.MinSeverity))) {
+				continue
+			}
+			verdict.add(RuleVulnerability, "%s（%s）", vuln.ID, rating)
+		}
+	}
+
+	if p.MinAgeDays > 0 {
+		if uploaded, ok := firstUpload(pkg.Releases[ver]); ok {
+			minAge := time.Duration(p.MinAgeDays) * 24 * time.Hour
+			if age := p.clock().Sub(uploaded); age < minAge {
+				verdict.add(RuleMinAge, "发布于 %s，未满 %d 天的冷却期", uploaded.UTC().Format("2006-01-02 15:04"), p.MinAgeDays)
+			}
+		}
+	}
+}
+
+// vulnerabilities 返回影响版本且未撤回的漏洞：项目信息中列出的漏洞，以及漏洞库中查询到的漏洞，按ID去重
+func (p *Policy) vulnerabilities(pkg *models.Package, name, ver string) []models.Vulnerability {
+	vulns := pkg.VulnerabilitiesFor(ver)
+	if p.vulnDB == nil {
+		return vulns
+	}
+	// 不是有效PEP 440版本号的版本无法在漏洞库中匹配
+	found, err := p.vulnDB.Query(name, ver)
+	if err != nil {
+		return vulns
+	}
+	seen := make(map[string]bool, len(vulns))
+	for _, vuln := range vulns {
+		seen[vuln.ID] = true
+	}
+	for _, vuln := range found {
+		if !vuln.IsWithdrawn() && !seen[vuln.ID] {
+			seen[vuln.ID] = true
+			vulns = append(vulns, vuln)
+		}
+	}
+	return vulns
+}
+
+// checkFile 检查文件的撤回状态
+func (p *Policy) checkFile(verdict *Verdict, file *models.ReleaseFile) {
+	if p.HideYanked && file.IsYanked() {
+		if file.YankedReason != "" {
+			verdict.add(RuleYanked, "文件已撤回: %s", file.YankedReason)
+		} else {
+			verdict.add(RuleYanked, "文件已撤回")
+		}
+	}
+}
+
+// ignored 检查漏洞的ID或别名是否在忽略列表中
+func (r *VulnerabilityRule) ignored(vuln models.Vulnerability) bool {
+	for _, id := range r.Ignore {
+		if strings.EqualFold(id, vuln.ID) {
+			return true
+		}
+		for _, alias := range vuln.Aliases {
+			if strings.EqualFold(id, alias) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *Policy) clock() time.Time {
+	if p.now != nil {
+		return p.now()
+	}
+	return time.Now()
+}
+
+// firstUpload 返回版本中最早的文件上传时间
+func firstUpload(files []*models.ReleaseFile) (time.Time, bool) {
+	var first time.Time
+	for _, f := range files {
+		if t, err := f.GetUploadTimeISO(); err == nil && !t.IsZero() && (first.IsZero() || t.Before(first)) {
+			first = t
+		}
+	}
+	return first, !first.IsZero()
+}
+
+func projectName(pkg *models.Package) string {
+	if pkg.Info == nil {
+		return ""
+	}
+	return models.NormalizeName(pkg.Info.Name)
+}
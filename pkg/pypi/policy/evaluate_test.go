@@ -0,0 +1,206 @@
+package policy
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/license"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/osv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+
+func file(name, uploaded string) *models.ReleaseFile {
+	return &models.ReleaseFile{Filename: name, UploadTimeISO8601: uploaded}
+}
+
+func newTestPackage() *models.Package {
+	yanked := file("demo-0.9.tar.gz", "2023-01-01T00:00:00Z")
+	yanked.Yanked, yanked.YankedReason = true, "broken"
+	latest := file("demo-2.0.tar.gz", "2024-06-08T00:00:00Z")
+	return &models.Package{
+		Info: &models.PackageInfo{Name: "Demo", Version: "2.0", License: "MIT"},
+		Releases: map[string][]*models.ReleaseFile{
+			"0.9": {yanked, file("demo-0.9-py3-none-any.whl", "2023-01-01T00:00:00Z")},
+			"1.0": {file("demo-1.0.tar.gz", "2023-06-01T00:00:00Z")},
+			"1.1": {file("demo-1.1.tar.gz", "2023-09-01T00:00:00Z")},
+			"2.0": {latest},
+		},
+		Urls: []*models.ReleaseFile{file("demo-2.0.tar.gz", "2024-06-08T00:00:00Z")},
+		Vulnerabilities: []models.Vulnerability{
+			{ID: "PYSEC-1", FixedIn: []string{"1.1"}, DatabaseSpecific: json.RawMessage(`{"severity": "HIGH"}`)},
+			{ID: "PYSEC-2", FixedIn: []string{"1.0"}, DatabaseSpecific: json.RawMessage(`{"severity": "LOW"}`)},
+			{ID: "PYSEC-3", Aliases: []string{"CVE-2024-0001"}, FixedIn: []string{"1.0"}},
+		},
+	}
+}
+
+func rules(v *Verdict) []Rule {
+	var result []Rule
+	for _, violation := range v.Violations {
+		result = append(result, violation.Rule)
+	}
+	return result
+}
+
+func TestEvaluate(t *testing.T) {
+	pkg := newTestPackage()
+
+	t.Run("项目名", func(t *testing.T) {
+		p := &Policy{Allow: []string{"demo", "requests"}, Deny: []string{"requests"}}
+		assert.True(t, p.AllowsProject("Demo"))
+		assert.False(t, p.AllowsProject("requests"))
+		assert.False(t, p.AllowsProject("flask"))
+		assert.Equal(t, []Rule{RuleAllow}, rules(p.EvaluateProject(&models.Package{Info: &models.PackageInfo{Name: "flask"}})))
+	})
+
+	t.Run("带版本约束的禁止列表", func(t *testing.T) {
+		p := &Policy{Deny: []string{"demo<1.1"}}
+		assert.True(t, p.AllowsProject("demo"))
+		assert.Equal(t, []Rule{RuleDeny}, rules(p.EvaluateRelease(pkg, "1.0")))
+		assert.True(t, p.EvaluateRelease(pkg, "1.1").Allowed())
+	})
+
+	t.Run("漏洞", func(t *testing.T) {
+		p := &Policy{Vulnerabilities: &VulnerabilityRule{MinSeverity: "HIGH"}}
+		verdict := p.EvaluateRelease(pkg, "0.9")
+		require.Len(t, verdict.Violations, 1)
+		assert.Equal(t, "PYSEC-1（HIGH）", verdict.Violations[0].Message)
+		assert.True(t, p.EvaluateRelease(pkg, "1.1").Allowed())
+
+		// 未指定评级时任何漏洞都会屏蔽，忽略列表匹配别名
+		p = &Policy{Vulnerabilities: &VulnerabilityRule{Ignore: []string{"cve-2024-0001"}}}
+		assert.Len(t, p.EvaluateRelease(pkg, "0.9").Violations, 2)
+	})
+
+	t.Run("冷却期", func(t *testing.T) {
+		p := &Policy{MinAgeDays: 7, now: func() time.Time { return testNow }}
+		verdict := p.EvaluateRelease(pkg, "2.0")
+		assert.Equal(t, []Rule{RuleMinAge}, rules(verdict))
+		assert.Contains(t, verdict.Violations[0].Message, "2024-06-08")
+		assert.True(t, p.EvaluateRelease(pkg, "1.1").Allowed())
+	})
+
+	t.Run("撤回", func(t *testing.T) {
+		p := &Policy{HideYanked: true}
+		verdict := p.EvaluateFile(pkg, "0.9", pkg.Releases["0.9"][0])
+		assert.Equal(t, []Rule{RuleYanked}, rules(verdict))
+		assert.Equal(t, "demo-0.9.tar.gz", verdict.Filename)
+		assert.True(t, p.EvaluateFile(pkg, "0.9", pkg.Releases["0.9"][1]).Allowed())
+	})
+
+	t.Run("许可证", func(t *testing.T) {
+		p := &Policy{Licenses: &license.Policy{Deny: []string{"MIT"}}}
+		verdict := p.EvaluateFile(pkg, "1.1", pkg.Releases["1.1"][0])
+		assert.Equal(t, []Rule{RuleLicense}, rules(verdict))
+		assert.Contains(t, verdict.Explain(), "[license] 许可证被禁止: MIT 在禁止列表中")
+	})
+}
+
+// jinja2ProjectJSON 与PyPI的 /pypi/jinja2/json 结构相同，vulnerabilities只列出影响最新版本的漏洞，因此为空
+const jinja2ProjectJSON = `{
+  "info": {"name": "Jinja2", "version": "3.1.4", "license": "BSD-3-Clause"},
+  "releases": {
+    "2.11.2": [{"filename": "Jinja2-2.11.2-py2.py3-none-any.whl", "packagetype": "bdist_wheel", "upload_time_iso_8601": "2020-04-13T15:18:35.045412Z", "digests": {"sha256": "f0a4641d3cf955324a89c04f3d94663aa4d638abe8f733ecd3582848e1c37035"}}],
+    "3.1.4": [{"filename": "jinja2-3.1.4-py3-none-any.whl", "packagetype": "bdist_wheel", "upload_time_iso_8601": "2024-05-05T23:41:59.928004Z", "digests": {"sha256": "bc5dd2abb727a5319567b7a813e6a2e7318c39f4f487cfe6c89c6f9c7d25197d"}}]
+  },
+  "urls": [{"filename": "jinja2-3.1.4-py3-none-any.whl", "packagetype": "bdist_wheel"}],
+  "vulnerabilities": []
+}`
+
+// jinja2Advisory PyPA advisory-database中影响旧版本jinja2的公告
+const jinja2Advisory = `{
+  "id": "PYSEC-2021-66",
+  "modified": "2021-03-22T16:34:00Z",
+  "aliases": ["CVE-2020-28493", "GHSA-g3rq-g295-4j3m"],
+  "details": "This affects the package jinja2 from 0.0.0 and before 2.11.3. The ReDoS vulnerability is mainly due to the _punctuation_re regex operator.",
+  "severity": [{"type": "CVSS_V3", "score": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:L"}],
+  "affected": [{
+    "package": {"name": "jinja2", "ecosystem": "PyPI"},
+    "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "2.11.3"}]}]
+  }]
+}`
+
+func TestVulnerabilityDB(t *testing.T) {
+	var pkg models.Package
+	require.NoError(t, json.Unmarshal([]byte(jinja2ProjectJSON), &pkg))
+	adv, err := osv.ParseAdvisory([]byte(jinja2Advisory))
+	require.NoError(t, err)
+	db := osv.NewDB()
+	db.Add(adv)
+
+	t.Run("只有项目信息时无法识别旧版本的漏洞", func(t *testing.T) {
+		p := &Policy{Vulnerabilities: &VulnerabilityRule{}}
+		assert.True(t, p.EvaluateRelease(&pkg, "2.11.2").Allowed())
+	})
+
+	t.Run("按版本查询漏洞库", func(t *testing.T) {
+		p := (&Policy{Vulnerabilities: &VulnerabilityRule{}}).WithVulnerabilityDB(db)
+		verdict := p.EvaluateRelease(&pkg, "2.11.2")
+		require.Equal(t, []Rule{RuleVulnerability}, rules(verdict))
+		assert.Equal(t, "PYSEC-2021-66（MEDIUM）", verdict.Violations[0].Message)
+		assert.True(t, p.EvaluateRelease(&pkg, "3.1.4").Allowed())
+
+		filtered, removed := p.Filter(&pkg)
+		assert.Equal(t, []string{"3.1.4"}, keys(filtered.Releases))
+		require.Len(t, removed, 1)
+		assert.Equal(t, "2.11.2", removed[0].Version)
+
+		verdict = p.EvaluateFile(&pkg, "2.11.2", pkg.Releases["2.11.2"][0])
+		assert.False(t, verdict.Allowed())
+	})
+
+	t.Run("评级和忽略列表同样适用", func(t *testing.T) {
+		p := (&Policy{Vulnerabilities: &VulnerabilityRule{MinSeverity: "HIGH"}}).WithVulnerabilityDB(db)
+		assert.True(t, p.EvaluateRelease(&pkg, "2.11.2").Allowed())
+
+		p = (&Policy{Vulnerabilities: &VulnerabilityRule{Ignore: []string{"CVE-2020-28493"}}}).WithVulnerabilityDB(db)
+		assert.True(t, p.EvaluateRelease(&pkg, "2.11.2").Allowed())
+	})
+
+	t.Run("与项目信息中的漏洞去重", func(t *testing.T) {
+		listed := pkg
+		listed.Vulnerabilities = []models.Vulnerability{adv.Vulnerability("jinja2")}
+		p := (&Policy{Vulnerabilities: &VulnerabilityRule{}}).WithVulnerabilityDB(db)
+		assert.Len(t, p.EvaluateRelease(&listed, "2.11.2").Violations, 1)
+	})
+}
+
+func TestFilter(t *testing.T) {
+	pkg := newTestPackage()
+	p := &Policy{
+		Deny:            []string{"demo==1.0"},
+		Vulnerabilities: &VulnerabilityRule{MinSeverity: "HIGH"},
+		MinAgeDays:      7,
+		HideYanked:      true,
+		now:             func() time.Time { return testNow },
+	}
+
+	filtered, removed := p.Filter(pkg)
+	assert.Equal(t, []string{"1.1"}, keys(filtered.Releases))
+	assert.Empty(t, filtered.Urls)
+	assert.Len(t, removed, 3)
+
+	// 原对象不受影响
+	assert.Len(t, pkg.Releases, 4)
+	assert.Len(t, pkg.Urls, 1)
+
+	p = &Policy{HideYanked: true}
+	filtered, removed = p.Filter(pkg)
+	require.Len(t, removed, 1)
+	assert.Equal(t, "demo-0.9.tar.gz", removed[0].Filename)
+	assert.Len(t, filtered.Releases["0.9"], 1)
+	assert.Len(t, filtered.Urls, 1)
+}
+
+func keys(releases map[string][]*models.ReleaseFile) []string {
+	var result []string
+	for v := range releases {
+		result = append(result, v)
+	}
+	return result
+}
@@ -0,0 +1,281 @@
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/cvss"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/license"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/version"
+	"gopkg.in/yaml.v3"
+)
+
+// Policy 决定索引中哪些项目、版本和文件对客户端可见
+//
+// 规则之间是"或"的关系，命中任一规则的项目、版本或文件即被屏蔽。
+// 可以直接构造，也可以通过Parse或Load从YAML/JSON配置文件读取：
+//
+//	allow: ["*"]
+//	deny:
+//	  - evil-package
+//	  - urllib3<1.26.5
+//	vulnerabilities:
+//	  min_severity: HIGH
+//	  ignore: [PYSEC-2023-0001]
+//	min_age_days: 7
+//	hide_yanked: true
+//	licenses:
+//	  deny: ["AGPL-*", "GPL-*"]
+type Policy struct {
+	// Allow 允许的项目名模式，为空时允许所有项目；支持 path.Match 通配符，比较前按PEP 503规范化
+	Allow []string `json:"allow,omitempty" yaml:"allow,omitempty"`
+
+	// Deny 禁止的项目，每项是项目名模式，可以带PEP 440版本约束（如 "urllib3<1.26.5"），
+	// 带约束时只屏蔽满足约束的版本
+	Deny []string `json:"deny,omitempty" yaml:"deny,omitempty"`
+
+	// Vulnerabilities 屏蔽有已知漏洞的版本，为nil时不检查
+	Vulnerabilities *VulnerabilityRule `json:"vulnerabilities,omitempty" yaml:"vulnerabilities,omitempty"`
+
+	// MinAgeDays 版本最早的文件发布满多少天后才可见，用于防范供应链投毒的冷却期；为0时不检查
+	// 无法确定发布时间的版本不受限制
+	MinAgeDays int `json:"min_age_days,omitempty" yaml:"min_age_days,omitempty"`
+
+	// HideYanked 是否屏蔽已撤回（PEP 592）的文件
+	HideYanked bool `json:"hide_yanked,omitempty" yaml:"hide_yanked,omitempty"`
+
+	// Licenses 许可证策略，判定为禁止的项目被屏蔽，为nil时不检查
+	// 许可证取自项目信息，即最新版本的许可证，对项目的所有版本生效
+	Licenses *license.Policy `json:"licenses,omitempty" yaml:"licenses,omitempty"`
+
+	now    func() time.Time
+	vulnDB VulnerabilityDB
+}
+
+// VulnerabilityDB 按版本查询漏洞的数据源，osv.DB实现了该接口
+type VulnerabilityDB interface {
+	// Query 返回影响指定项目版本的漏洞，版本号无效时返回错误
+	Query(name, version string) ([]models.Vulnerability, error)
+}
+
+// WithVulnerabilityDB 设置漏洞规则使用的漏洞库，返回策略本身
+//
+// PyPI的 /pypi/<项目>/json 只列出影响最新版本的漏洞，只靠项目信息无法识别旧版本的已知漏洞；
+// 设置漏洞库后，每个版本都会在漏洞库中查询，结果与项目信息中列出的漏洞合并
+//
+// 使用示例:
+//
+//	db, err := osv.Load("/data/advisory-database/vulns")
+//	if err != nil {
+//		return err
+//	}
+//	pol.WithVulnerabilityDB(db)
+func (p *Policy) WithVulnerabilityDB(db VulnerabilityDB) *Policy {
+	p.vulnDB = db
+	return p
+}
+
+// VulnerabilityRule 漏洞规则
+// 版本受哪些漏洞影响取自项目信息中的漏洞列表和Policy.WithVulnerabilityDB设置的漏洞库；
+// 没有设置漏洞库时只能识别PyPI为最新版本列出的漏洞
+type VulnerabilityRule struct {
+	// MinSeverity 屏蔽评级不低于该值的漏洞，如 "HIGH"；为空时任何已知漏洞都会屏蔽（包括评级未知的）
+	MinSeverity string `json:"min_severity,omitempty" yaml:"min_severity,omitempty"`
+
+	// Ignore 忽略的漏洞ID或别名，如已评估不受影响的CVE
+	Ignore []string `json:"ignore,omitempty" yaml:"ignore,omitempty"`
+}
+
+// Rule 规则名称，出现在Violation中
+type Rule string
+
+const (
+	// RuleAllow 项目不在允许列表中
+	RuleAllow Rule = "allow"
+
+	// RuleDeny 项目或版本在禁止列表中
+	RuleDeny Rule = "deny"
+
+	// RuleVulnerability 版本有已知漏洞
+	RuleVulnerability Rule = "vulnerability"
+
+	// RuleMinAge 版本发布时间不足冷却期
+	RuleMinAge Rule = "min-age"
+
+	// RuleYanked 文件已撤回
+	RuleYanked Rule = "yanked"
+
+	// RuleLicense 许可证被禁止
+	RuleLicense Rule = "license"
+)
+
+// ErrInvalidPolicy 表示策略配置无效
+var ErrInvalidPolicy = errors.New("无效的策略")
+
+// Parse 解析YAML或JSON格式的策略配置，未知的字段视为错误
+//
+// 参数:
+//   - data: 配置内容
+//
+// 返回值:
+//   - *Policy: 解析并校验后的策略
+//   - error: 格式错误或校验失败时返回，匹配ErrInvalidPolicy
+func Parse(data []byte) (*Policy, error) {
+	var p Policy
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPolicy, err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Load 读取并解析策略配置文件
+//
+// 参数:
+//   - p: 配置文件路径，YAML或JSON格式
+//
+// 返回值:
+//   - *Policy: 解析并校验后的策略
+//   - error: 文件无法读取或配置无效时返回
+//
+// 使用示例:
+//
+//	pol, err := policy.Load("/etc/pypi/policy.yaml")
+//	if err != nil {
+//		return err
+//	}
+//	server := indexserver.NewServer(storage).WithPolicy(pol)
+func Load(p string) (*Policy, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("读取策略文件失败: %w", err)
+	}
+	pol, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p, err)
+	}
+	return pol, nil
+}
+
+// Validate 检查名称模式、版本约束和漏洞评级是否有效
+func (p *Policy) Validate() error {
+	for _, pattern := range p.Allow {
+		if _, err := path.Match(models.NormalizeName(pattern), ""); err != nil || strings.ContainsAny(pattern, specifierChars) {
+			return fmt.Errorf("%w: allow中的 %q 不是有效的项目名模式", ErrInvalidPolicy, pattern)
+		}
+	}
+	for _, entry := range p.Deny {
+		if _, err := parseDeny(entry); err != nil {
+			return err
+		}
+	}
+	if p.Vulnerabilities != nil && p.Vulnerabilities.MinSeverity != "" &&
+		cvss.ParseRating(p.Vulnerabilities.MinSeverity) == cvss.RatingUnknown {
+		return fmt.Errorf("%w: 无法识别的漏洞评级 %q", ErrInvalidPolicy, p.Vulnerabilities.MinSeverity)
+	}
+	if p.MinAgeDays < 0 {
+		return fmt.Errorf("%w: min_age_days不能为负数", ErrInvalidPolicy)
+	}
+	return nil
+}
+
+// specifierChars 版本约束的起始字符
+const specifierChars = "<>=!~"
+
+// denyEntry 解析后的禁止列表条目
+type denyEntry struct {
+	pattern   string
+	specifier version.SpecifierSet
+	raw       string
+}
+
+func parseDeny(entry string) (*denyEntry, error) {
+	name, spec := strings.TrimSpace(entry), ""
+	if i := strings.IndexAny(name, specifierChars); i >= 0 {
+		name, spec = strings.TrimSpace(name[:i]), name[i:]
+	}
+	pattern := models.NormalizeName(name)
+	if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+		return nil, fmt.Errorf("%w: deny中的 %q 不是有效的项目名模式", ErrInvalidPolicy, entry)
+	}
+	specifier, err := version.ParseSpecifierSet(spec)
+	if err != nil {
+		return nil, fmt.Errorf("%w: deny中的 %q: %v", ErrInvalidPolicy, entry, err)
+	}
+	return &denyEntry{pattern: pattern, specifier: specifier, raw: strings.TrimSpace(entry)}, nil
+}
+
+// matchName 检查规范化后的项目名是否匹配模式
+func matchName(pattern, name string) bool {
+	ok, _ := path.Match(models.NormalizeName(pattern), models.NormalizeName(name))
+	return ok
+}
+
+// Violation 一条被违反的规则
+type Violation struct {
+	// Rule 规则名称
+	Rule Rule `json:"rule"`
+
+	// Message 违反规则的具体原因
+	Message string `json:"message"`
+}
+
+// String 返回 "[规则] 原因" 格式的描述
+func (v Violation) String() string {
+	return "[" + string(v.Rule) + "] " + v.Message
+}
+
+// Verdict 策略对项目、版本或文件的判定
+type Verdict struct {
+	// Project 项目名
+	Project string `json:"project"`
+
+	// Version 版本号，对整个项目的判定为空
+	Version string `json:"version,omitempty"`
+
+	// Filename 文件名，对项目或版本的判定为空
+	Filename string `json:"filename,omitempty"`
+
+	// Violations 违反的规则，为空表示允许
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+// Allowed 是否没有违反任何规则
+func (v *Verdict) Allowed() bool {
+	return len(v.Violations) == 0
+}
+
+// Explain 返回适合写入HTTP响应的多行说明
+func (v *Verdict) Explain() string {
+	target := v.Project
+	switch {
+	case v.Filename != "":
+		target = v.Filename
+	case v.Version != "":
+		target = v.Project + " " + v.Version
+	}
+	if v.Allowed() {
+		return target + " 符合策略\n"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s 被策略禁止:\n", target)
+	for _, violation := range v.Violations {
+		b.WriteString("  - " + violation.String() + "\n")
+	}
+	return b.String()
+}
+
+func (v *Verdict) add(rule Rule, format string, args ...interface{}) {
+	v.Violations = append(v.Violations, Violation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
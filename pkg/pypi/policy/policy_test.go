@@ -0,0 +1,97 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/license"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `
+allow: ["*"]
+deny:
+  - evil_package
+  - urllib3<1.26.5
+vulnerabilities:
+  min_severity: high
+  ignore: [CVE-2024-0001]
+min_age_days: 7
+hide_yanked: true
+licenses:
+  deny: ["AGPL-*"]
+  min_confidence: medium
+`
+
+func TestParse(t *testing.T) {
+	t.Run("YAML", func(t *testing.T) {
+		p, err := Parse([]byte(testConfig))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"evil_package", "urllib3<1.26.5"}, p.Deny)
+		assert.Equal(t, "high", p.Vulnerabilities.MinSeverity)
+		assert.Equal(t, 7, p.MinAgeDays)
+		assert.True(t, p.HideYanked)
+		assert.Equal(t, []string{"AGPL-*"}, p.Licenses.Deny)
+		assert.Equal(t, license.ConfidenceMedium, p.Licenses.MinConfidence)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		p, err := Parse([]byte(`{"deny": ["a"], "hide_yanked": true}`))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a"}, p.Deny)
+	})
+
+	t.Run("空配置", func(t *testing.T) {
+		p, err := Parse(nil)
+		require.NoError(t, err)
+		assert.True(t, p.AllowsProject("anything"))
+	})
+
+	t.Run("无效配置", func(t *testing.T) {
+		for _, config := range []string{
+			"unknown_field: 1",
+			"deny: ['a<<1']",
+			"deny: ['[a']",
+			"allow: ['a>=1']",
+			"vulnerabilities: {min_severity: severe}",
+			"min_age_days: -1",
+		} {
+			_, err := Parse([]byte(config))
+			assert.ErrorIs(t, err, ErrInvalidPolicy, config)
+		}
+	})
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testConfig), 0o644))
+
+	p, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 7, p.MinAgeDays)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestVerdictExplain(t *testing.T) {
+	verdict := &Verdict{Project: "demo", Version: "1.0", Filename: "demo-1.0.tar.gz"}
+	assert.Equal(t, "demo-1.0.tar.gz 符合策略\n", verdict.Explain())
+
+	verdict.add(RuleYanked, "文件已撤回")
+	verdict.add(RuleMinAge, "未满 %d 天", 7)
+	assert.False(t, verdict.Allowed())
+	assert.Equal(t, "demo-1.0.tar.gz 被策略禁止:\n  - [yanked] 文件已撤回\n  - [min-age] 未满 7 天\n", verdict.Explain())
+
+	release := &Verdict{Project: "demo", Version: "1.0", Violations: verdict.Violations}
+	assert.True(t, strings.HasPrefix(release.Explain(), "demo 1.0 被策略禁止"))
+}
+
+func TestMatchName(t *testing.T) {
+	assert.True(t, matchName("Zope.*", "zope-interface"))
+	assert.True(t, matchName("evil_package", "Evil-Package"))
+	assert.False(t, matchName("evil", "evil-package"))
+}
@@ -0,0 +1,94 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/indexserver"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+)
+
+// DigestError 下载的文件与索引中的哈希不一致
+// 同时匹配models.ErrDigestMismatch和indexserver.ErrUnavailable，服务器对其返回502
+type DigestError struct {
+	// Filename 文件名
+	Filename string
+
+	// Err 哈希校验的结果
+	Err *models.DigestError
+}
+
+// Error 实现error接口
+func (e *DigestError) Error() string {
+	return fmt.Sprintf("%s 的%v", e.Filename, e.Err)
+}
+
+// Unwrap 返回哈希校验的结果，使errors.Is(err, models.ErrDigestMismatch)返回true
+func (e *DigestError) Unwrap() error {
+	return e.Err
+}
+
+// Is 使errors.Is(err, indexserver.ErrUnavailable)返回true
+func (e *DigestError) Is(target error) bool {
+	return target == indexserver.ErrUnavailable
+}
+
+// download 下载发布文件并在校验哈希后写入缓存，校验失败时缓存中不会留下文件
+func (p *Proxy) download(ctx context.Context, project string, file *models.ReleaseFile) error {
+	verifier, err := file.Digests.Verifier()
+	if err != nil || file.URL == "" {
+		// 交给服务器重定向到文件原来的URL
+		return fmt.Errorf("%s 没有可校验的哈希，不缓存: %w", file.Filename, indexserver.ErrNotFound)
+	}
+
+	p.count(func(s *Stats) { s.FileMisses++ })
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, file.URL, nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("User-Agent", p.userAgent)
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		p.count(func(s *Stats) { s.UpstreamErrors++ })
+		return fmt.Errorf("%w: 下载 %s 失败: %v", indexserver.ErrUnavailable, file.Filename, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		p.count(func(s *Stats) { s.UpstreamErrors++ })
+		return fmt.Errorf("%w: 下载 %s 失败: HTTP %d", indexserver.ErrUnavailable, file.Filename, resp.StatusCode)
+	}
+
+	body := &verifyingReader{r: resp.Body, v: verifier, filename: file.Filename}
+	if err := p.cache.SaveFile(project, file.Filename, body); err != nil {
+		if errors.Is(err, models.ErrDigestMismatch) {
+			p.count(func(s *Stats) { s.DigestMismatches++ })
+		}
+		return err
+	}
+	p.count(func(s *Stats) { s.BytesDownloaded += body.n })
+	return nil
+}
+
+// verifyingReader 边读取边计算哈希，读到末尾时哈希不一致则返回*DigestError代替io.EOF
+type verifyingReader struct {
+	r        io.Reader
+	v        *models.DigestVerifier
+	filename string
+	n        int64
+}
+
+func (v *verifyingReader) Read(b []byte) (int, error) {
+	n, err := v.r.Read(b)
+	v.v.Write(b[:n])
+	v.n += int64(n)
+	if err == io.EOF {
+		var digestErr *models.DigestError
+		if errors.As(v.v.Verify(), &digestErr) {
+			return n, &DigestError{Filename: v.filename, Err: digestErr}
+		}
+	}
+	return n, err
+}
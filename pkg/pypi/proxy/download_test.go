@@ -0,0 +1,44 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/indexserver"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyingReader(t *testing.T) {
+	sum := sha256.Sum256([]byte("content"))
+	digest := hex.EncodeToString(sum[:])
+
+	t.Run("哈希一致", func(t *testing.T) {
+		v, err := (&models.ReleaseDigests{SHA256: strings.ToUpper(digest)}).Verifier()
+		require.NoError(t, err)
+		r := &verifyingReader{r: strings.NewReader("content"), v: v}
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, "content", string(data))
+		assert.Equal(t, int64(7), r.n)
+	})
+
+	t.Run("哈希不一致", func(t *testing.T) {
+		v, err := (&models.ReleaseDigests{SHA256: digest}).Verifier()
+		require.NoError(t, err)
+		r := &verifyingReader{r: strings.NewReader("other"), v: v, filename: "a.whl"}
+		_, err = io.ReadAll(r)
+		assert.ErrorIs(t, err, models.ErrDigestMismatch)
+		assert.ErrorIs(t, err, indexserver.ErrUnavailable)
+
+		var digestErr *DigestError
+		require.True(t, errors.As(err, &digestErr))
+		assert.Equal(t, "a.whl", digestErr.Filename)
+		assert.Equal(t, digest, digestErr.Err.Expected)
+	})
+}
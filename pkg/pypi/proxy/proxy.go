@@ -0,0 +1,309 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/api"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/client"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/indexserver"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/policy"
+)
+
+// 代理的默认配置
+const (
+	// DefaultTTL 缓存的项目信息和项目列表在此时间内直接返回，不再请求上游
+	DefaultTTL = 10 * time.Minute
+
+	// DefaultDownloadTimeout 下载单个发布文件的超时时间
+	DefaultDownloadTimeout = 10 * time.Minute
+)
+
+// Stats 代理的缓存命中统计
+type Stats struct {
+	// PageHits 直接从缓存返回的项目信息和项目列表次数
+	PageHits int64 `json:"page_hits"`
+
+	// PageMisses 从上游获取项目信息和项目列表的次数
+	PageMisses int64 `json:"page_misses"`
+
+	// FileHits 直接从磁盘返回发布文件的次数
+	FileHits int64 `json:"file_hits"`
+
+	// FileMisses 从上游下载发布文件的次数
+	FileMisses int64 `json:"file_misses"`
+
+	// StaleServed 上游不可用时返回过期缓存的次数
+	StaleServed int64 `json:"stale_served"`
+
+	// UpstreamErrors 上游请求失败且没有缓存可用的次数，不包括项目不存在
+	UpstreamErrors int64 `json:"upstream_errors"`
+
+	// DigestMismatches 下载的文件与索引中哈希不一致的次数
+	DigestMismatches int64 `json:"digest_mismatches"`
+
+	// BytesDownloaded 从上游下载并缓存的文件字节数
+	BytesDownloaded int64 `json:"bytes_downloaded"`
+}
+
+// HitRatio 返回页面和文件合计的缓存命中率，没有请求时为0
+func (s Stats) HitRatio() float64 {
+	hits := s.PageHits + s.FileHits + s.StaleServed
+	total := hits + s.PageMisses + s.FileMisses
+	if total == 0 {
+		return 0
+	}
+	return float64(hits) / float64(total)
+}
+
+// Proxy 带本地磁盘缓存的拉取代理，实现http.Handler
+//
+// 请求的项目和文件通过库的客户端按需从PyPI或镜像获取：项目信息保存为缓存目录中的package.json，
+// 发布文件在校验索引给出的哈希后保存到磁盘，之后的请求直接从磁盘返回。
+// 地址与indexserver.Server相同，项目页面和 /pypi/<项目>/json 中的文件链接都指向代理自身的 /files/ 地址。
+//
+// 上游不可用时继续返回已缓存的项目信息和文件。缓存命中统计可以通过Stats或StatsPath获取。
+// 索引没有给出可校验的哈希的文件不会被缓存，代理将请求重定向到文件原来的URL。
+type Proxy struct {
+	upstream   api.PyPIClient
+	cache      *indexserver.DirStorage
+	httpClient *http.Client
+	userAgent  string
+	ttl        time.Duration
+	now        func() time.Time
+	server     *indexserver.Server
+
+	mu         sync.Mutex
+	fetched    map[string]time.Time
+	projects   []string
+	projectsAt time.Time
+	stats      Stats
+}
+
+var (
+	_ http.Handler                = (*Proxy)(nil)
+	_ indexserver.Storage         = (*Proxy)(nil)
+	_ indexserver.MetadataStorage = (*Proxy)(nil)
+	_ indexserver.FileStorage     = (*Proxy)(nil)
+)
+
+// New 创建拉取代理
+//
+// 参数:
+//   - upstream: 获取项目信息的客户端，可以是client、mirrors或failover创建的任意客户端
+//   - cacheDir: 缓存目录，布局与indexserver.DirStorage相同
+//
+// 返回值:
+//   - *Proxy: 代理，默认缓存有效期为DefaultTTL
+//
+// 使用示例:
+//
+//	upstream := mirrors.NewTsinghuaClient()
+//	p := proxy.New(upstream, "/var/cache/pypi")
+//	http.ListenAndServe(":3141", p)
+//	// pip install --index-url http://localhost:3141/simple/ requests
+func New(upstream api.PyPIClient, cacheDir string) *Proxy {
+	p := &Proxy{
+		upstream:   upstream,
+		cache:      indexserver.NewDirStorage(cacheDir),
+		httpClient: &http.Client{Timeout: DefaultDownloadTimeout},
+		userAgent:  client.DefaultUserAgent,
+		ttl:        DefaultTTL,
+		now:        time.Now,
+		fetched:    map[string]time.Time{},
+	}
+	p.server = indexserver.NewServer(p).WithLocalFileURLs(true)
+	return p
+}
+
+// WithHTTPClient 设置下载发布文件使用的HTTP客户端
+func (p *Proxy) WithHTTPClient(c *http.Client) *Proxy {
+	p.httpClient = c
+	return p
+}
+
+// WithUserAgent 设置下载发布文件时的User-Agent
+func (p *Proxy) WithUserAgent(userAgent string) *Proxy {
+	p.userAgent = userAgent
+	return p
+}
+
+// WithTTL 设置缓存的项目信息和项目列表的有效期，为0时每次请求都先访问上游
+func (p *Proxy) WithTTL(ttl time.Duration) *Proxy {
+	p.ttl = ttl
+	return p
+}
+
+// WithPolicy 设置过滤项目、版本和文件的策略，被屏蔽的文件不会从上游下载
+func (p *Proxy) WithPolicy(pol *policy.Policy) *Proxy {
+	p.server.WithPolicy(pol)
+	return p
+}
+
+// Cache 返回代理的缓存存储
+func (p *Proxy) Cache() *indexserver.DirStorage {
+	return p.cache
+}
+
+// Stats 返回缓存命中统计的快照
+func (p *Proxy) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
+
+// StatsPath 以JSON格式返回缓存命中统计的地址
+const StatsPath = "/-/stats"
+
+// ServeHTTP 实现http.Handler
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == StatsPath {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(p.Stats())
+		return
+	}
+	p.server.ServeHTTP(w, r)
+}
+
+// Projects 返回上游的项目列表，上游不可用时返回上次获取的列表或已缓存的项目
+func (p *Proxy) Projects(ctx context.Context) ([]string, error) {
+	p.mu.Lock()
+	if p.projects != nil && p.fresh(p.projectsAt) {
+		p.stats.PageHits++
+		projects := p.projects
+		p.mu.Unlock()
+		return projects, nil
+	}
+	p.mu.Unlock()
+
+	names, err := p.upstream.GetAllPackages(ctx)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err == nil {
+		p.stats.PageMisses++
+		p.projects, p.projectsAt = names, p.now()
+		return names, nil
+	}
+	if p.projects != nil {
+		p.stats.StaleServed++
+		return p.projects, nil
+	}
+	if cached, cacheErr := p.cache.Projects(ctx); cacheErr == nil && len(cached) > 0 {
+		p.stats.StaleServed++
+		return cached, nil
+	}
+	p.stats.UpstreamErrors++
+	return nil, fmt.Errorf("%w: 获取项目列表失败: %v", indexserver.ErrUnavailable, err)
+}
+
+// Package 返回项目信息，缓存在有效期内时直接返回，否则从上游获取并写入缓存
+func (p *Proxy) Package(ctx context.Context, project string) (*models.Package, error) {
+	name := models.NormalizeName(project)
+	p.mu.Lock()
+	fresh := p.fresh(p.fetched[name])
+	p.mu.Unlock()
+	if fresh {
+		if pkg, err := p.cache.Package(ctx, name); err == nil {
+			p.count(func(s *Stats) { s.PageHits++ })
+			return pkg, nil
+		}
+	}
+	return p.fetch(ctx, name)
+}
+
+// fetch 从上游获取项目信息并写入缓存，上游不可用时返回已缓存的信息
+func (p *Proxy) fetch(ctx context.Context, name string) (*models.Package, error) {
+	pkg, err := p.upstream.GetPackageInfo(ctx, name)
+	if err == nil {
+		p.count(func(s *Stats) { s.PageMisses++ })
+		if err := p.cache.SavePackage(pkg); err != nil {
+			return nil, fmt.Errorf("缓存项目 %s 失败: %w", name, err)
+		}
+		p.mu.Lock()
+		p.fetched[name] = p.now()
+		p.mu.Unlock()
+		return pkg, nil
+	}
+	if errors.Is(err, client.ErrNotFound) {
+		return nil, fmt.Errorf("项目 %s %w", name, indexserver.ErrNotFound)
+	}
+
+	if cached, cacheErr := p.cache.Package(ctx, name); cacheErr == nil {
+		p.count(func(s *Stats) { s.StaleServed++ })
+		return cached, nil
+	}
+	p.count(func(s *Stats) { s.UpstreamErrors++ })
+	return nil, fmt.Errorf("%w: 获取项目 %s 失败: %v", indexserver.ErrUnavailable, name, err)
+}
+
+// CoreMetadata 返回缓存目录中的核心元数据，代理不会从上游获取
+func (p *Proxy) CoreMetadata(ctx context.Context, project, filename string) ([]byte, error) {
+	return p.cache.CoreMetadata(ctx, project, filename)
+}
+
+// OpenFile 打开发布文件，不在缓存中时从上游下载并校验哈希后写入缓存
+func (p *Proxy) OpenFile(ctx context.Context, project, filename string) (io.ReadCloser, error) {
+	f, err := p.cache.OpenFile(ctx, project, filename)
+	if err == nil {
+		p.count(func(s *Stats) { s.FileHits++ })
+		return f, nil
+	}
+	if !errors.Is(err, indexserver.ErrNotFound) {
+		return nil, err
+	}
+
+	file, err := p.findFile(ctx, models.NormalizeName(project), filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.download(ctx, project, file); err != nil {
+		return nil, err
+	}
+	return p.cache.OpenFile(ctx, project, filename)
+}
+
+// findFile 在项目信息中查找发布文件，缓存的信息中没有时重新从上游获取
+func (p *Proxy) findFile(ctx context.Context, name, filename string) (*models.ReleaseFile, error) {
+	if pkg, err := p.cache.Package(ctx, name); err == nil {
+		if file := lookupFile(pkg, filename); file != nil {
+			return file, nil
+		}
+	}
+	pkg, err := p.fetch(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if file := lookupFile(pkg, filename); file != nil {
+		return file, nil
+	}
+	return nil, fmt.Errorf("文件 %s %w", filename, indexserver.ErrNotFound)
+}
+
+// fresh 判断在t获取的缓存是否仍在有效期内
+func (p *Proxy) fresh(t time.Time) bool {
+	return !t.IsZero() && p.now().Sub(t) < p.ttl
+}
+
+func (p *Proxy) count(update func(s *Stats)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	update(&p.stats)
+}
+
+func lookupFile(pkg *models.Package, filename string) *models.ReleaseFile {
+	for _, files := range pkg.Releases {
+		for _, f := range files {
+			if f.Filename == filename {
+				return f
+			}
+		}
+	}
+	return nil
+}
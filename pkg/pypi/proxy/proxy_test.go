@@ -0,0 +1,209 @@
+package proxy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/client"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// upstream 模拟的上游索引，记录每个地址的请求次数
+type upstream struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests map[string]int
+}
+
+func newUpstream(t *testing.T) *upstream {
+	wheel := []byte("wheel content")
+	sum := sha256.Sum256(wheel)
+	u := &upstream{requests: map[string]int{}}
+	u.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u.mu.Lock()
+		u.requests[r.URL.Path]++
+		u.mu.Unlock()
+		switch r.URL.Path {
+		case "/simple/":
+			fmt.Fprint(w, `<html><body><a href="/simple/demo/">demo</a></body></html>`)
+		case "/pypi/demo/json":
+			fmt.Fprintf(w, `{"info": {"name": "demo", "version": "1.0"}, "last_serial": 9, "releases": {
+				"1.0": [{"filename": "demo-1.0-py3-none-any.whl", "url": "%[1]s/packages/demo-1.0-py3-none-any.whl",
+				         "digests": {"sha256": "%[2]s"}, "requires_python": ">=3.8"}],
+				"0.9": [{"filename": "demo-0.9.tar.gz", "url": "%[1]s/packages/demo-0.9.tar.gz", "digests": {"sha256": "%[2]s"}},
+				        {"filename": "demo-0.9.zip", "url": "%[1]s/packages/demo-0.9.zip", "digests": {}}]}}`, u.URL, hex.EncodeToString(sum[:]))
+		case "/packages/demo-1.0-py3-none-any.whl":
+			w.Write(wheel)
+		case "/packages/demo-0.9.tar.gz":
+			w.Write([]byte("tampered"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(u.Close)
+	return u
+}
+
+func (u *upstream) count(path string) int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.requests[path]
+}
+
+func get(t *testing.T, server *httptest.Server, path string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func TestProxy(t *testing.T) {
+	up := newUpstream(t)
+	c := client.NewClient(client.NewOptions().WithBaseURL(up.URL).WithMaxRetries(1).WithTimeout(5 * time.Second))
+	p := New(c, t.TempDir())
+	server := httptest.NewServer(p)
+	defer server.Close()
+
+	t.Run("项目页面链接指向代理", func(t *testing.T) {
+		resp, body := get(t, server, "/simple/demo/")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, body, `href="../../files/demo/demo-1.0-py3-none-any.whl#sha256=`)
+		assert.Contains(t, body, `data-requires-python="&gt;=3.8"`)
+		assert.NotContains(t, body, up.URL)
+		assert.Equal(t, "9", resp.Header.Get("X-PyPI-Last-Serial"))
+	})
+
+	t.Run("JSON API中的文件URL指向代理", func(t *testing.T) {
+		resp, body := get(t, server, "/pypi/demo/json")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NotContains(t, body, up.URL)
+
+		var pkg models.Package
+		require.NoError(t, json.Unmarshal([]byte(body), &pkg))
+		require.Len(t, pkg.Releases["1.0"], 1)
+		assert.Equal(t, "../../files/demo/demo-1.0-py3-none-any.whl", pkg.Releases["1.0"][0].URL)
+		assert.Equal(t, "../../files/demo/demo-0.9.zip", pkg.Releases["0.9"][1].URL)
+
+		// 缓存中保留上游的URL，没有哈希的文件仍然可以重定向
+		cached, err := p.Cache().Package(context.Background(), "demo")
+		require.NoError(t, err)
+		assert.Equal(t, up.URL+"/packages/demo-1.0-py3-none-any.whl", cached.Releases["1.0"][0].URL)
+	})
+
+	t.Run("缓存有效期内不再请求上游", func(t *testing.T) {
+		get(t, server, "/simple/demo/")
+		assert.Equal(t, 1, up.count("/pypi/demo/json"))
+	})
+
+	t.Run("下载文件并缓存", func(t *testing.T) {
+		resp, body := get(t, server, "/files/demo/demo-1.0-py3-none-any.whl")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "wheel content", body)
+
+		_, body = get(t, server, "/files/demo/demo-1.0-py3-none-any.whl")
+		assert.Equal(t, "wheel content", body)
+		assert.Equal(t, 1, up.count("/packages/demo-1.0-py3-none-any.whl"))
+	})
+
+	t.Run("哈希不一致时不缓存", func(t *testing.T) {
+		resp, _ := get(t, server, "/files/demo/demo-0.9.tar.gz")
+		assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+		_, err := p.Cache().OpenFile(context.Background(), "demo", "demo-0.9.tar.gz")
+		assert.Error(t, err)
+	})
+
+	t.Run("没有哈希时重定向到上游", func(t *testing.T) {
+		resp, _ := get(t, server, "/files/demo/demo-0.9.zip")
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+		assert.Equal(t, up.URL+"/packages/demo-0.9.zip", resp.Header.Get("Location"))
+	})
+
+	t.Run("项目不存在", func(t *testing.T) {
+		resp, _ := get(t, server, "/simple/missing/")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("统计", func(t *testing.T) {
+		stats := p.Stats()
+		assert.Equal(t, int64(1), stats.FileHits)
+		assert.Equal(t, int64(2), stats.FileMisses)
+		assert.Equal(t, int64(1), stats.DigestMismatches)
+		assert.Equal(t, int64(len("wheel content")), stats.BytesDownloaded)
+		assert.Equal(t, int64(1), stats.PageMisses)
+
+		_, body := get(t, server, StatsPath)
+		var served Stats
+		require.NoError(t, json.Unmarshal([]byte(body), &served))
+		assert.Equal(t, stats, served)
+	})
+}
+
+func TestProxyOffline(t *testing.T) {
+	up := newUpstream(t)
+	c := client.NewClient(client.NewOptions().WithBaseURL(up.URL).WithMaxRetries(1).WithTimeout(5 * time.Second))
+	p := New(c, t.TempDir()).WithTTL(0)
+	server := httptest.NewServer(p)
+	defer server.Close()
+
+	_, body := get(t, server, "/simple/")
+	assert.Contains(t, body, `<a href="demo/">demo</a>`)
+	get(t, server, "/simple/demo/")
+	get(t, server, "/files/demo/demo-1.0-py3-none-any.whl")
+	up.Close()
+
+	t.Run("上游不可用时返回缓存", func(t *testing.T) {
+		resp, body := get(t, server, "/simple/demo/")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, body, "demo-1.0-py3-none-any.whl")
+
+		_, body = get(t, server, "/simple/")
+		assert.Contains(t, body, `<a href="demo/">demo</a>`)
+
+		_, body = get(t, server, "/files/demo/demo-1.0-py3-none-any.whl")
+		assert.Equal(t, "wheel content", body)
+	})
+
+	t.Run("没有缓存时返回502", func(t *testing.T) {
+		resp, _ := get(t, server, "/simple/other/")
+		assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	})
+
+	stats := p.Stats()
+	assert.Equal(t, int64(2), stats.StaleServed)
+	assert.Equal(t, int64(1), stats.UpstreamErrors)
+	assert.InDelta(t, 3.0/6.0, stats.HitRatio(), 1e-9)
+}
+
+func TestProxyPolicy(t *testing.T) {
+	up := newUpstream(t)
+	c := client.NewClient(client.NewOptions().WithBaseURL(up.URL).WithMaxRetries(1).WithTimeout(5 * time.Second))
+	pol, err := policy.Parse([]byte("deny: ['demo==1.0']"))
+	require.NoError(t, err)
+	server := httptest.NewServer(New(c, t.TempDir()).WithPolicy(pol))
+	defer server.Close()
+
+	_, body := get(t, server, "/simple/demo/")
+	assert.NotContains(t, body, "demo-1.0-py3-none-any.whl")
+
+	resp, body := get(t, server, "/files/demo/demo-1.0-py3-none-any.whl")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Contains(t, body, "[deny] 版本 1.0 在禁止列表中（demo==1.0）")
+	assert.Zero(t, up.count("/packages/demo-1.0-py3-none-any.whl"))
+}
@@ -0,0 +1,366 @@
+package pyproject
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/api"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/license"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/requirement"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/version"
+)
+
+// DriftKind 本地声明与已发布元数据之间差异的类型
+type DriftKind string
+
+const (
+	// DriftVersion 本地版本号与已发布的最新版本不同
+	DriftVersion DriftKind = "version"
+
+	// DriftMissingDependency 本地声明的依赖不在已发布版本的RequiresDist中
+	DriftMissingDependency DriftKind = "missing_dependency"
+
+	// DriftUndeclaredDependency 已发布版本的依赖在本地没有声明
+	DriftUndeclaredDependency DriftKind = "undeclared_dependency"
+
+	// DriftSpecifier 同一依赖的版本约束不同
+	DriftSpecifier DriftKind = "specifier"
+
+	// DriftMarker 同一依赖的环境标记不同
+	DriftMarker DriftKind = "marker"
+
+	// DriftExtra 本地声明的extra与已发布的Provides-Extra不同
+	DriftExtra DriftKind = "extra"
+
+	// DriftRequiresPython Python版本要求不同
+	DriftRequiresPython DriftKind = "requires_python"
+
+	// DriftLicense 规范化后的许可证不同
+	DriftLicense DriftKind = "license"
+
+	// DriftURL 项目链接缺失或地址不同
+	DriftURL DriftKind = "url"
+
+	// DriftDescription 简介不同
+	DriftDescription DriftKind = "description"
+)
+
+// Drift 一项本地声明与已发布元数据之间的差异
+type Drift struct {
+	// Kind 差异类型
+	Kind DriftKind
+
+	// Subject 差异涉及的对象，如依赖的规范化包名、extra名称或链接标签，字段级差异为空
+	Subject string
+
+	// Extra 依赖所属的extra，基础依赖为空
+	Extra string
+
+	// Local 本地的值，本地缺失时为空
+	Local string
+
+	// Published 已发布的值，已发布版本中缺失时为空
+	Published string
+}
+
+// String 返回差异的可读描述
+func (d Drift) String() string {
+	subject := string(d.Kind)
+	if d.Subject != "" {
+		subject += " " + d.Subject
+	}
+	if d.Extra != "" {
+		subject += "[" + d.Extra + "]"
+	}
+	return fmt.Sprintf("%s: 本地 %q, 已发布 %q", subject, d.Local, d.Published)
+}
+
+// Comparison 本地项目与已发布版本的对比结果
+type Comparison struct {
+	// Project 本地项目
+	Project *Project
+
+	// Published 参与对比的已发布包信息
+	Published *models.PackageInfo
+
+	// Drifts 发现的差异，按类型和对象排序
+	Drifts []Drift
+}
+
+// Has 检查是否存在指定类型的差异
+func (c *Comparison) Has(kind DriftKind) bool {
+	for _, d := range c.Drifts {
+		if d.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// OK 检查是否没有任何差异
+func (c *Comparison) OK() bool {
+	return len(c.Drifts) == 0
+}
+
+// CompareLatest 获取项目在PyPI上的最新版本并与本地声明对比
+//
+// 参数:
+//   - ctx: 上下文
+//   - c: PyPI客户端
+//   - p: 本地项目，通常来自ParseFile("pyproject.toml")
+//
+// 返回值:
+//   - *Comparison: 对比结果
+//   - error: 获取包信息失败时返回
+//
+// 使用示例:
+//
+//	project, _ := pyproject.ParseFile("pyproject.toml")
+//	cmp, err := pyproject.CompareLatest(ctx, mirrors.NewOfficialClient(), project)
+//	if err != nil {
+//		return err
+//	}
+//	for _, d := range cmp.Drifts {
+//		fmt.Println(d)
+//	}
+func CompareLatest(ctx context.Context, c api.PyPIClient, p *Project) (*Comparison, error) {
+	pkg, err := c.GetPackageInfo(ctx, p.Name)
+	if err != nil {
+		return nil, fmt.Errorf("获取包 %s 的信息失败: %w", p.Name, err)
+	}
+	if pkg.Info == nil {
+		return nil, fmt.Errorf("包 %s 的信息为空", p.Name)
+	}
+	return Compare(p, pkg.Info), nil
+}
+
+// Compare 对比本地项目与已发布的包信息
+// 声明为dynamic的字段由构建后端决定，不参与对比
+func Compare(p *Project, info *models.PackageInfo) *Comparison {
+	cmp := &Comparison{Project: p, Published: info}
+	add := func(d Drift) { cmp.Drifts = append(cmp.Drifts, d) }
+
+	if !p.IsDynamic("version") && p.Version != "" && !sameVersion(p.Version, info.Version) {
+		add(Drift{Kind: DriftVersion, Local: p.Version, Published: info.Version})
+	}
+
+	if !p.IsDynamic("description") && strings.TrimSpace(p.Description) != strings.TrimSpace(info.Summary) {
+		add(Drift{Kind: DriftDescription, Local: p.Description, Published: info.Summary})
+	}
+
+	if !p.IsDynamic("requires-python") {
+		local, published := canonicalSpecifier(p.RequiresPython), canonicalSpecifier(info.RequiresPython)
+		if local != published {
+			add(Drift{Kind: DriftRequiresPython, Local: p.RequiresPython, Published: info.RequiresPython})
+		}
+	}
+
+	if !p.IsDynamic("license") && !p.License.IsZero() {
+		local := license.Normalize(p.ToPackageInfo())
+		published := license.Normalize(info)
+		if local.Resolved() && local.String() != published.String() {
+			add(Drift{Kind: DriftLicense, Local: local.String(), Published: published.String()})
+		}
+	}
+
+	if !p.IsDynamic("urls") {
+		for _, label := range sortedLabels(p.URLs) {
+			published, ok := lookupURL(info.ProjectURLs, label)
+			if !ok || strings.TrimRight(published, "/") != strings.TrimRight(p.URLs[label], "/") {
+				add(Drift{Kind: DriftURL, Subject: label, Local: p.URLs[label], Published: published})
+			}
+		}
+	}
+
+	if !p.IsDynamic("optional-dependencies") && len(info.ProvidesExtra) > 0 {
+		local := map[string]bool{}
+		for _, extra := range p.Extras() {
+			local[models.NormalizeName(extra)] = true
+		}
+		published := map[string]bool{}
+		for _, extra := range info.ProvidesExtra {
+			published[models.NormalizeName(extra)] = true
+		}
+		for extra := range local {
+			if !published[extra] {
+				add(Drift{Kind: DriftExtra, Subject: extra, Local: extra})
+			}
+		}
+		for extra := range published {
+			if !local[extra] {
+				add(Drift{Kind: DriftExtra, Subject: extra, Published: extra})
+			}
+		}
+	}
+
+	compareDependencies(p, info, add)
+
+	sort.SliceStable(cmp.Drifts, func(i, j int) bool {
+		a, b := cmp.Drifts[i], cmp.Drifts[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Subject != b.Subject {
+			return a.Subject < b.Subject
+		}
+		return a.Extra < b.Extra
+	})
+	return cmp
+}
+
+// dependencyKey 以规范化包名和所属extra标识一条依赖
+type dependencyKey struct {
+	name  string
+	extra string
+}
+
+// compareDependencies 按包名和extra匹配两侧的依赖，再比较版本约束和环境标记
+func compareDependencies(p *Project, info *models.PackageInfo, add func(Drift)) {
+	// 声明为dynamic的一侧不参与对比
+	keep := func(k dependencyKey) bool {
+		if k.extra == "" {
+			return !p.IsDynamic("dependencies")
+		}
+		return !p.IsDynamic("optional-dependencies")
+	}
+	local := map[dependencyKey]*requirement.Requirement{}
+	published := map[dependencyKey]*requirement.Requirement{}
+	collectRequirements(local, p.RequiresDist(), keep)
+	collectRequirements(published, info.RequiresDist, keep)
+
+	for key, req := range local {
+		other, ok := published[key]
+		if !ok {
+			add(Drift{Kind: DriftMissingDependency, Subject: key.name, Extra: key.extra, Local: req.String()})
+			continue
+		}
+		if req.URL != other.URL || req.Specifier.String() != other.Specifier.String() {
+			add(Drift{Kind: DriftSpecifier, Subject: key.name, Extra: key.extra,
+				Local: constraint(req), Published: constraint(other)})
+		}
+		if l, r := markerWithoutExtra(req.Marker), markerWithoutExtra(other.Marker); l != r {
+			add(Drift{Kind: DriftMarker, Subject: key.name, Extra: key.extra, Local: l, Published: r})
+		}
+	}
+	for key, req := range published {
+		if _, ok := local[key]; !ok {
+			add(Drift{Kind: DriftUndeclaredDependency, Subject: key.name, Extra: key.extra, Published: req.String()})
+		}
+	}
+}
+
+// collectRequirements 解析Requires-Dist并按dependencyKey收集，无效的声明被跳过
+// 同一依赖在多个extra中出现时分别记录
+func collectRequirements(into map[dependencyKey]*requirement.Requirement, lines []string, keep func(dependencyKey) bool) {
+	for _, line := range lines {
+		req, err := requirement.Parse(line)
+		if err != nil {
+			continue
+		}
+		extras := []string{""}
+		if req.Marker != nil {
+			if e := req.Marker.Extras(); len(e) > 0 {
+				extras = e
+			}
+		}
+		for _, extra := range extras {
+			key := dependencyKey{name: req.NormalizedName(), extra: extra}
+			if keep(key) {
+				into[key] = req
+			}
+		}
+	}
+}
+
+// markerWithoutExtra 去掉标记中与extra相关的比较，返回剩余部分的规范写法
+func markerWithoutExtra(m *requirement.Marker) string {
+	if m = stripExtra(m); m == nil {
+		return ""
+	}
+	return m.String()
+}
+
+func stripExtra(m *requirement.Marker) *requirement.Marker {
+	if m == nil {
+		return nil
+	}
+	if m.Operator == "" {
+		if (m.Left.Variable && m.Left.Text == "extra") || (m.Right.Variable && m.Right.Text == "extra") {
+			return nil
+		}
+		return m
+	}
+	var children []*requirement.Marker
+	for _, child := range m.Markers {
+		if c := stripExtra(child); c != nil {
+			children = append(children, c)
+		}
+	}
+	switch len(children) {
+	case 0:
+		return nil
+	case 1:
+		return children[0]
+	}
+	return &requirement.Marker{Operator: m.Operator, Markers: children}
+}
+
+// constraint 返回依赖的版本约束或直接引用地址
+func constraint(req *requirement.Requirement) string {
+	if req.URL != "" {
+		return "@ " + req.URL
+	}
+	return req.Specifier.String()
+}
+
+// canonicalSpecifier 返回约束的稳定写法，无效的约束原样返回
+func canonicalSpecifier(s string) string {
+	set, err := version.ParseSpecifierSet(s)
+	if err != nil {
+		return strings.TrimSpace(s)
+	}
+	return set.String()
+}
+
+// sameVersion 按PEP 440比较版本号，任一无效时比较原始字符串
+func sameVersion(a, b string) bool {
+	va, errA := version.Parse(a)
+	vb, errB := version.Parse(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return va.Compare(vb) == 0
+}
+
+// lookupURL 按标签查找项目链接，标签比较忽略大小写、空白和标点（与PyPI展示时的规则一致）
+func lookupURL(urls map[string]string, label string) (string, bool) {
+	want := normalizeLabel(label)
+	for l, u := range urls {
+		if normalizeLabel(l) == want {
+			return u, true
+		}
+	}
+	return "", false
+}
+
+func normalizeLabel(label string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(label) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func sortedLabels(urls map[string]string) []string {
+	labels := make([]string, 0, len(urls))
+	for label := range urls {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
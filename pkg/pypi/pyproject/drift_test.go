@@ -0,0 +1,152 @@
+package pyproject
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/api"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/client"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient 只实现GetPackageInfo，按规范化包名返回内存中的包，不存在时返回ErrNotFound
+type fakeClient struct {
+	api.PyPIClient
+
+	packages map[string]*models.Package
+}
+
+func (f *fakeClient) GetPackageInfo(ctx context.Context, name string) (*models.Package, error) {
+	pkg, ok := f.packages[models.NormalizeName(name)]
+	if !ok {
+		return nil, fmt.Errorf("获取包 %s 信息失败: %w", name, client.ErrNotFound)
+	}
+	return pkg, nil
+}
+
+// publishedInfo 与testdata/pyproject.toml一致的已发布元数据
+func publishedInfo() *models.PackageInfo {
+	return &models.PackageInfo{
+		Name:              "example-pkg",
+		Version:           "1.2.0",
+		Summary:           "An example package",
+		LicenseExpression: "MIT",
+		RequiresPython:    ">=3.8",
+		ProjectURLs: map[string]string{
+			"homepage": "https://example.com/",
+			"Source":   "https://github.com/example/example-pkg",
+		},
+		ProvidesExtra: []string{"socks", "test"},
+		RequiresDist: []string{
+			"requests>=2.28",
+			"importlib-metadata; python_version < \"3.10\"",
+			"pysocks!=1.5.7,>=1.5.6; extra == \"socks\"",
+			"pytest>=7; extra == 'test'",
+			"coverage[toml]; extra == \"test\"",
+		},
+	}
+}
+
+func loadProject(t *testing.T) *Project {
+	t.Helper()
+	p, err := ParseFile(filepath.Join("testdata", "pyproject.toml"))
+	require.NoError(t, err)
+	return p
+}
+
+func TestCompare(t *testing.T) {
+	t.Run("没有差异", func(t *testing.T) {
+		cmp := Compare(loadProject(t), publishedInfo())
+		assert.True(t, cmp.OK(), cmp.Drifts)
+	})
+
+	t.Run("依赖差异", func(t *testing.T) {
+		info := publishedInfo()
+		info.RequiresDist = []string{
+			"requests>=2.31",
+			"importlib-metadata; python_version < \"3.9\"",
+			"pysocks!=1.5.7,>=1.5.6; extra == \"socks\"",
+			"pytest>=7; extra == \"test\"",
+			"coverage[toml]; extra == \"test\"",
+			"charset-normalizer<4",
+		}
+		cmp := Compare(loadProject(t), info)
+		assert.Equal(t, []Drift{
+			{Kind: DriftMarker, Subject: "importlib-metadata", Local: `python_version < "3.10"`, Published: `python_version < "3.9"`},
+			{Kind: DriftSpecifier, Subject: "requests", Local: ">=2.28", Published: ">=2.31"},
+			{Kind: DriftUndeclaredDependency, Subject: "charset-normalizer", Published: "charset-normalizer<4"},
+		}, cmp.Drifts)
+	})
+
+	t.Run("本地声明的依赖未发布", func(t *testing.T) {
+		info := publishedInfo()
+		info.RequiresDist = info.RequiresDist[:3]
+		cmp := Compare(loadProject(t), info)
+		require.True(t, cmp.Has(DriftMissingDependency))
+		assert.Equal(t, []Drift{
+			{Kind: DriftMissingDependency, Subject: "coverage", Extra: "test", Local: `coverage[toml]; extra == "test"`},
+			{Kind: DriftMissingDependency, Subject: "pytest", Extra: "test", Local: `pytest>=7; extra == "test"`},
+		}, cmp.Drifts)
+	})
+
+	t.Run("字段差异", func(t *testing.T) {
+		info := publishedInfo()
+		info.Version = "1.1.0"
+		info.Summary = "Old summary"
+		info.RequiresPython = ">=3.7"
+		info.LicenseExpression = "Apache-2.0"
+		info.ProjectURLs = map[string]string{"Homepage": "https://example.org"}
+		info.ProvidesExtra = []string{"socks", "test", "docs"}
+
+		cmp := Compare(loadProject(t), info)
+		for _, kind := range []DriftKind{DriftVersion, DriftDescription, DriftRequiresPython, DriftLicense, DriftURL, DriftExtra} {
+			assert.True(t, cmp.Has(kind), kind)
+		}
+		assert.Contains(t, cmp.Drifts, Drift{Kind: DriftLicense, Local: "MIT", Published: "Apache-2.0"})
+		assert.Contains(t, cmp.Drifts, Drift{Kind: DriftURL, Subject: "Source", Local: "https://github.com/example/example-pkg"})
+		assert.Contains(t, cmp.Drifts, Drift{Kind: DriftExtra, Subject: "docs", Published: "docs"})
+	})
+
+	t.Run("版本号按PEP 440比较", func(t *testing.T) {
+		info := publishedInfo()
+		info.Version = "1.2"
+		assert.False(t, Compare(loadProject(t), info).Has(DriftVersion))
+	})
+
+	t.Run("dynamic字段不参与对比", func(t *testing.T) {
+		p := loadProject(t)
+		p.Dynamic = []string{"version", "dependencies", "optional-dependencies"}
+		info := publishedInfo()
+		info.Version = "9.9.9"
+		info.RequiresDist = []string{"numpy"}
+		info.ProvidesExtra = []string{"other"}
+		assert.True(t, Compare(p, info).OK())
+	})
+}
+
+func TestCompareLatest(t *testing.T) {
+	c := &fakeClient{packages: map[string]*models.Package{
+		"example-pkg": {Info: publishedInfo()},
+	}}
+
+	t.Run("获取最新版本对比", func(t *testing.T) {
+		cmp, err := CompareLatest(context.Background(), c, loadProject(t))
+		require.NoError(t, err)
+		assert.True(t, cmp.OK())
+		assert.Equal(t, "1.2.0", cmp.Published.Version)
+	})
+
+	t.Run("包不存在", func(t *testing.T) {
+		_, err := CompareLatest(context.Background(), c, &Project{Name: "unpublished"})
+		assert.ErrorIs(t, err, client.ErrNotFound)
+	})
+}
+
+func TestDriftString(t *testing.T) {
+	d := Drift{Kind: DriftSpecifier, Subject: "pytest", Extra: "test", Local: ">=7", Published: ">=8"}
+	assert.Equal(t, `specifier pytest[test]: 本地 ">=7", 已发布 ">=8"`, d.String())
+}
@@ -0,0 +1,434 @@
+package pyproject
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/license"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/requirement"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/version"
+)
+
+// ErrNoProject 表示pyproject.toml中没有 [project] 表或表中缺少name
+var ErrNoProject = errors.New("pyproject.toml中没有有效的[project]表")
+
+// namePattern PEP 508中的包名，同样适用于extra名称
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9._-]*[A-Za-z0-9])?$`)
+
+// dynamicFields PEP 621中允许声明为dynamic的字段（name除外）
+var dynamicFields = []string{
+	"version", "description", "readme", "requires-python", "license", "license-files",
+	"authors", "maintainers", "keywords", "classifiers", "urls", "scripts",
+	"gui-scripts", "entry-points", "dependencies", "optional-dependencies",
+}
+
+// Contact 作者或维护者，PEP 621要求name和email至少有一个
+type Contact struct {
+	Name  string `toml:"name"`
+	Email string `toml:"email"`
+}
+
+// String 返回 "名称 <邮箱>" 形式的联系人
+func (c Contact) String() string {
+	switch {
+	case c.Name == "":
+		return c.Email
+	case c.Email == "":
+		return c.Name
+	}
+	return c.Name + " <" + c.Email + ">"
+}
+
+// License 项目许可证
+// PEP 639中license是SPDX表达式字符串，旧写法是含text或file的表
+type License struct {
+	// Expression SPDX许可证表达式
+	Expression string
+
+	// Text 旧写法中的许可证文本
+	Text string
+
+	// File 旧写法中的许可证文件路径
+	File string
+}
+
+// IsZero 检查是否没有声明许可证
+func (l License) IsZero() bool {
+	return l.Expression == "" && l.Text == "" && l.File == ""
+}
+
+// Readme 项目说明文档，字符串写法只有File
+type Readme struct {
+	File        string
+	Text        string
+	ContentType string
+}
+
+// Project pyproject.toml中的 [project] 表（PEP 621）
+type Project struct {
+	Name                 string
+	Version              string
+	Description          string
+	Readme               Readme
+	RequiresPython       string
+	License              License
+	LicenseFiles         []string
+	Authors              []Contact
+	Maintainers          []Contact
+	Keywords             []string
+	Classifiers          []string
+	URLs                 map[string]string
+	Scripts              map[string]string
+	GUIScripts           map[string]string
+	EntryPoints          map[string]map[string]string
+	Dependencies         []string
+	OptionalDependencies map[string][]string
+	Dynamic              []string
+}
+
+// rawProject 用于解码的 [project] 表，license和readme有两种写法
+type rawProject struct {
+	Name                 string                       `toml:"name"`
+	Version              string                       `toml:"version"`
+	Description          string                       `toml:"description"`
+	Readme               interface{}                  `toml:"readme"`
+	RequiresPython       string                       `toml:"requires-python"`
+	License              interface{}                  `toml:"license"`
+	LicenseFiles         []string                     `toml:"license-files"`
+	Authors              []Contact                    `toml:"authors"`
+	Maintainers          []Contact                    `toml:"maintainers"`
+	Keywords             []string                     `toml:"keywords"`
+	Classifiers          []string                     `toml:"classifiers"`
+	URLs                 map[string]string            `toml:"urls"`
+	Scripts              map[string]string            `toml:"scripts"`
+	GUIScripts           map[string]string            `toml:"gui-scripts"`
+	EntryPoints          map[string]map[string]string `toml:"entry-points"`
+	Dependencies         []string                     `toml:"dependencies"`
+	OptionalDependencies map[string][]string          `toml:"optional-dependencies"`
+	Dynamic              []string                     `toml:"dynamic"`
+}
+
+// Issue 表示 [project] 表中一个无效的字段值
+type Issue struct {
+	// Field 字段名，如 "dependencies"、"optional-dependencies.socks"
+	Field string
+
+	// Value 字段的原始值
+	Value string
+
+	// Reason 无效的原因
+	Reason string
+}
+
+// String 返回问题的可读描述
+func (i Issue) String() string {
+	if i.Value == "" {
+		return fmt.Sprintf("%s: %s", i.Field, i.Reason)
+	}
+	return fmt.Sprintf("%s: %s (%q)", i.Field, i.Reason, i.Value)
+}
+
+// Report 记录解析 [project] 表时发现的问题
+type Report struct {
+	// Invalid 值无效或相互冲突的字段
+	Invalid []Issue
+
+	// Unknown PEP 621中未定义的字段
+	Unknown []string
+}
+
+// Valid 检查 [project] 表是否没有无效的字段
+func (r *Report) Valid() bool {
+	return len(r.Invalid) == 0
+}
+
+func (r *Report) invalid(field, value, reason string) {
+	r.Invalid = append(r.Invalid, Issue{Field: field, Value: value, Reason: reason})
+}
+
+// Parse 解析pyproject.toml中的 [project] 表
+// 解析采用宽松模式，只要包含name即视为成功，需要校验时使用ParseWithReport
+//
+// 参数:
+//   - data: pyproject.toml的内容
+//
+// 返回值:
+//   - *Project: 解析后的项目元数据
+//   - error: TOML格式错误或没有 [project] 表时返回
+func Parse(data []byte) (*Project, error) {
+	p, _, err := ParseWithReport(data)
+	return p, err
+}
+
+// ParseFile 读取并解析pyproject.toml文件
+func ParseFile(path string) (*Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取pyproject.toml失败: %w", err)
+	}
+	return Parse(data)
+}
+
+// ParseWithReport 解析 [project] 表，并按PEP 621、PEP 508和PEP 639校验各字段
+//
+// 参数:
+//   - data: pyproject.toml的内容
+//
+// 返回值:
+//   - *Project: 解析后的项目元数据
+//   - *Report: 校验结果
+//   - error: TOML格式错误或没有 [project] 表时返回，此时其余返回值为nil
+//
+// 使用示例:
+//
+//	data, _ := os.ReadFile("pyproject.toml")
+//	project, report, err := pyproject.ParseWithReport(data)
+//	if err != nil {
+//		return err
+//	}
+//	for _, issue := range report.Invalid {
+//		fmt.Println(issue)
+//	}
+func ParseWithReport(data []byte) (*Project, *Report, error) {
+	var doc struct {
+		Project *rawProject `toml:"project"`
+	}
+	md, err := toml.Decode(string(data), &doc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析pyproject.toml失败: %w", err)
+	}
+	if doc.Project == nil || strings.TrimSpace(doc.Project.Name) == "" {
+		return nil, nil, ErrNoProject
+	}
+
+	raw := doc.Project
+	report := &Report{}
+	p := &Project{
+		Name:                 strings.TrimSpace(raw.Name),
+		Version:              strings.TrimSpace(raw.Version),
+		Description:          raw.Description,
+		RequiresPython:       strings.TrimSpace(raw.RequiresPython),
+		LicenseFiles:         raw.LicenseFiles,
+		Authors:              raw.Authors,
+		Maintainers:          raw.Maintainers,
+		Keywords:             raw.Keywords,
+		Classifiers:          raw.Classifiers,
+		URLs:                 raw.URLs,
+		Scripts:              raw.Scripts,
+		GUIScripts:           raw.GUIScripts,
+		EntryPoints:          raw.EntryPoints,
+		Dependencies:         raw.Dependencies,
+		OptionalDependencies: raw.OptionalDependencies,
+		Dynamic:              raw.Dynamic,
+	}
+
+	switch v := raw.License.(type) {
+	case nil:
+	case string:
+		p.License.Expression = strings.TrimSpace(v)
+	case map[string]interface{}:
+		p.License.Text, _ = v["text"].(string)
+		p.License.File, _ = v["file"].(string)
+		if (p.License.Text == "") == (p.License.File == "") {
+			report.invalid("license", "", "旧写法的表中text和file必须且只能有一个")
+		}
+	default:
+		report.invalid("license", fmt.Sprint(v), "应为字符串或表")
+	}
+
+	switch v := raw.Readme.(type) {
+	case nil:
+	case string:
+		p.Readme.File = v
+	case map[string]interface{}:
+		p.Readme.File, _ = v["file"].(string)
+		p.Readme.Text, _ = v["text"].(string)
+		p.Readme.ContentType, _ = v["content-type"].(string)
+	default:
+		report.invalid("readme", fmt.Sprint(v), "应为字符串或表")
+	}
+
+	for _, key := range md.Undecoded() {
+		if len(key) == 2 && key[0] == "project" {
+			report.Unknown = append(report.Unknown, key[1])
+		}
+	}
+	sort.Strings(report.Unknown)
+
+	validate(p, md, report)
+	return p, report, nil
+}
+
+// validate 校验字段值以及静态字段与dynamic之间的冲突
+func validate(p *Project, md toml.MetaData, report *Report) {
+	if !namePattern.MatchString(p.Name) {
+		report.invalid("name", p.Name, "不是有效的包名")
+	}
+
+	for _, field := range p.Dynamic {
+		switch {
+		case field == "name":
+			report.invalid("dynamic", field, "name不能声明为dynamic")
+		case !contains(dynamicFields, field):
+			report.invalid("dynamic", field, "未知的字段")
+		case md.IsDefined("project", field):
+			report.invalid(field, "", "同时静态声明并列在dynamic中")
+		}
+	}
+
+	if p.Version == "" {
+		if !p.IsDynamic("version") {
+			report.invalid("version", "", "缺少version且未声明为dynamic")
+		}
+	} else if _, err := version.Parse(p.Version); err != nil {
+		report.invalid("version", p.Version, "不是有效的PEP 440版本号")
+	}
+
+	if p.RequiresPython != "" {
+		if _, err := version.ParseSpecifierSet(p.RequiresPython); err != nil {
+			report.invalid("requires-python", p.RequiresPython, "不是有效的版本约束")
+		}
+	}
+
+	if p.License.Expression != "" {
+		if _, err := license.ParseExpression(p.License.Expression); err != nil {
+			report.invalid("license", p.License.Expression, "不是有效的SPDX表达式")
+		}
+	}
+	if len(p.LicenseFiles) > 0 && (p.License.Text != "" || p.License.File != "") {
+		report.invalid("license-files", "", "不能与旧写法的license表同时使用")
+	}
+
+	for _, dep := range p.Dependencies {
+		if _, err := requirement.Parse(dep); err != nil {
+			report.invalid("dependencies", dep, "不是有效的PEP 508依赖声明")
+		}
+	}
+	for _, extra := range p.Extras() {
+		if !namePattern.MatchString(extra) {
+			report.invalid("optional-dependencies", extra, "不是有效的extra名称")
+		}
+		for _, dep := range p.OptionalDependencies[extra] {
+			if _, err := requirement.Parse(dep); err != nil {
+				report.invalid("optional-dependencies."+extra, dep, "不是有效的PEP 508依赖声明")
+			}
+		}
+	}
+
+	for i, c := range append(append([]Contact(nil), p.Authors...), p.Maintainers...) {
+		if c.Name == "" && c.Email == "" {
+			field := "authors"
+			if i >= len(p.Authors) {
+				field = "maintainers"
+			}
+			report.invalid(field, "", "name和email至少需要一个")
+		}
+	}
+}
+
+// IsDynamic 检查字段是否声明为由构建后端动态提供
+func (p *Project) IsDynamic(field string) bool {
+	return contains(p.Dynamic, field)
+}
+
+// NormalizedName 返回按PEP 503规范化的包名
+func (p *Project) NormalizedName() string {
+	return models.NormalizeName(p.Name)
+}
+
+// Extras 返回声明的extra名称（已排序）
+func (p *Project) Extras() []string {
+	extras := make([]string, 0, len(p.OptionalDependencies))
+	for extra := range p.OptionalDependencies {
+		extras = append(extras, extra)
+	}
+	sort.Strings(extras)
+	return extras
+}
+
+// RequiresDist 返回构建后端写入核心元数据的Requires-Dist
+// 可选依赖会附加 `extra == "..."` 标记，无效的依赖声明被跳过
+func (p *Project) RequiresDist() []string {
+	var result []string
+	for _, dep := range p.Dependencies {
+		if req, err := requirement.Parse(dep); err == nil {
+			result = append(result, req.String())
+		}
+	}
+	for _, extra := range p.Extras() {
+		for _, dep := range p.OptionalDependencies[extra] {
+			req, err := requirement.Parse(dep)
+			if err != nil {
+				continue
+			}
+			clause := fmt.Sprintf("extra == %q", models.NormalizeName(extra))
+			if req.Marker != nil {
+				clause = "(" + req.Marker.String() + ") and " + clause
+			}
+			if req.Marker, err = requirement.ParseMarker(clause); err != nil {
+				continue
+			}
+			result = append(result, req.String())
+		}
+	}
+	return result
+}
+
+// ToPackageInfo 转换为与GetPackageInfo返回结构一致的包信息，便于复用许可证规范化等逻辑
+func (p *Project) ToPackageInfo() *models.PackageInfo {
+	info := &models.PackageInfo{
+		Name:              p.Name,
+		Version:           p.Version,
+		Summary:           p.Description,
+		Description:       p.Readme.Text,
+		License:           p.License.Text,
+		LicenseExpression: p.License.Expression,
+		LicenseFiles:      p.LicenseFiles,
+		Keywords:          strings.Join(p.Keywords, ","),
+		ClassifiersArray:  p.Classifiers,
+		ProjectURLs:       p.URLs,
+		RequiresDist:      p.RequiresDist(),
+		RequiresPython:    p.RequiresPython,
+		Dynamic:           p.Dynamic,
+	}
+	if p.Readme.Text != "" {
+		info.DescriptionContentType = p.Readme.ContentType
+	}
+	for _, extra := range p.Extras() {
+		info.ProvidesExtra = append(info.ProvidesExtra, models.NormalizeName(extra))
+	}
+	info.Author, info.AuthorEmail = splitContacts(p.Authors)
+	info.Maintainer, info.MaintainerEmail = splitContacts(p.Maintainers)
+	return info
+}
+
+// splitContacts 按核心元数据的规则拆分联系人：
+// 只有名称的写入Author，有邮箱的以 "名称 <邮箱>" 写入Author-email
+func splitContacts(contacts []Contact) (names, emails string) {
+	var n, e []string
+	for _, c := range contacts {
+		if c.Email == "" {
+			if c.Name != "" {
+				n = append(n, c.Name)
+			}
+			continue
+		}
+		e = append(e, c.String())
+	}
+	return strings.Join(n, ", "), strings.Join(e, ", ")
+}
+
+func contains(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
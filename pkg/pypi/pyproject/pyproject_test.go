@@ -0,0 +1,154 @@
+package pyproject
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFile(t *testing.T) {
+	p, err := ParseFile(filepath.Join("testdata", "pyproject.toml"))
+	require.NoError(t, err)
+
+	t.Run("基本字段", func(t *testing.T) {
+		assert.Equal(t, "Example_Pkg", p.Name)
+		assert.Equal(t, "example-pkg", p.NormalizedName())
+		assert.Equal(t, "1.2.0", p.Version)
+		assert.Equal(t, ">=3.8", p.RequiresPython)
+		assert.Equal(t, "README.md", p.Readme.File)
+		assert.Equal(t, License{Expression: "MIT"}, p.License)
+		assert.Equal(t, []string{"LICENSE"}, p.LicenseFiles)
+		assert.Equal(t, []Contact{{Name: "Alice", Email: "alice@example.com"}, {Name: "Bob"}}, p.Authors)
+		assert.Equal(t, "https://example.com", p.URLs["Homepage"])
+		assert.Equal(t, "example_pkg.cli:main", p.Scripts["example"])
+	})
+
+	t.Run("依赖", func(t *testing.T) {
+		assert.Len(t, p.Dependencies, 2)
+		assert.Equal(t, []string{"socks", "test"}, p.Extras())
+		assert.Equal(t, []string{
+			"requests>=2.28",
+			`importlib-metadata; python_version < "3.10"`,
+			`PySocks!=1.5.7,>=1.5.6; extra == "socks"`,
+			`pytest>=7; extra == "test"`,
+			`coverage[toml]; extra == "test"`,
+		}, p.RequiresDist())
+	})
+
+	t.Run("文件不存在", func(t *testing.T) {
+		_, err := ParseFile(filepath.Join("testdata", "missing.toml"))
+		assert.Error(t, err)
+	})
+}
+
+func TestParse(t *testing.T) {
+	t.Run("没有project表", func(t *testing.T) {
+		_, err := Parse([]byte("[tool.poetry]\nname = \"x\"\n"))
+		assert.ErrorIs(t, err, ErrNoProject)
+	})
+
+	t.Run("TOML格式错误", func(t *testing.T) {
+		_, err := Parse([]byte("[project\n"))
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrNoProject)
+	})
+
+	t.Run("旧写法的license表和readme表", func(t *testing.T) {
+		p, err := Parse([]byte(`
+[project]
+name = "legacy"
+version = "0.1"
+license = { text = "BSD License" }
+readme = { text = "# Legacy", content-type = "text/markdown" }
+`))
+		require.NoError(t, err)
+		assert.Equal(t, License{Text: "BSD License"}, p.License)
+		assert.Equal(t, Readme{Text: "# Legacy", ContentType: "text/markdown"}, p.Readme)
+	})
+
+	t.Run("可选依赖中已有环境标记", func(t *testing.T) {
+		p, err := Parse([]byte(`
+[project]
+name = "x"
+version = "1"
+optional-dependencies = { win = ["pywin32; os_name == 'nt' or sys_platform == 'cygwin'"] }
+`))
+		require.NoError(t, err)
+		assert.Equal(t, []string{`pywin32; (os_name == "nt" or sys_platform == "cygwin") and extra == "win"`}, p.RequiresDist())
+	})
+}
+
+func TestParseWithReport(t *testing.T) {
+	t.Run("有效的项目", func(t *testing.T) {
+		_, report, err := ParseWithReport([]byte("[project]\nname = \"ok\"\nversion = \"1.0\"\n"))
+		require.NoError(t, err)
+		assert.True(t, report.Valid())
+		assert.Empty(t, report.Unknown)
+	})
+
+	t.Run("无效字段", func(t *testing.T) {
+		p, report, err := ParseWithReport([]byte(`
+[project]
+name = "-bad-"
+requires-python = ">=three"
+license = "MIT OR"
+description = "static"
+dynamic = ["name", "description", "homepage"]
+dependencies = ["requests>=", "six"]
+authors = [{}]
+home-page = "https://example.com"
+
+[project.optional-dependencies]
+"bad extra" = ["x"]
+`))
+		require.NoError(t, err)
+		require.NotNil(t, p)
+		assert.False(t, report.Valid())
+
+		fields := map[string]bool{}
+		for _, issue := range report.Invalid {
+			fields[issue.Field] = true
+		}
+		for _, field := range []string{"name", "version", "requires-python", "license", "description", "dynamic", "dependencies", "authors", "optional-dependencies"} {
+			assert.True(t, fields[field], field)
+		}
+		assert.Equal(t, []string{"home-page"}, report.Unknown)
+	})
+
+	t.Run("version声明为dynamic", func(t *testing.T) {
+		p, report, err := ParseWithReport([]byte("[project]\nname = \"dyn\"\ndynamic = [\"version\"]\n"))
+		require.NoError(t, err)
+		assert.True(t, report.Valid(), report.Invalid)
+		assert.True(t, p.IsDynamic("version"))
+	})
+
+	t.Run("license-files与旧写法冲突", func(t *testing.T) {
+		_, report, err := ParseWithReport([]byte(`
+[project]
+name = "x"
+version = "1"
+license = { file = "LICENSE" }
+license-files = ["LICENSE"]
+`))
+		require.NoError(t, err)
+		require.Len(t, report.Invalid, 1)
+		assert.Equal(t, "license-files", report.Invalid[0].Field)
+	})
+}
+
+func TestToPackageInfo(t *testing.T) {
+	p, err := ParseFile(filepath.Join("testdata", "pyproject.toml"))
+	require.NoError(t, err)
+
+	info := p.ToPackageInfo()
+	assert.Equal(t, "Example_Pkg", info.Name)
+	assert.Equal(t, "An example package", info.Summary)
+	assert.Equal(t, "MIT", info.LicenseExpression)
+	assert.Equal(t, "Bob", info.Author)
+	assert.Equal(t, "Alice <alice@example.com>", info.AuthorEmail)
+	assert.Equal(t, "example,demo", info.Keywords)
+	assert.Equal(t, []string{"socks", "test"}, info.ProvidesExtra)
+	assert.Len(t, info.RequiresDist, 5)
+}
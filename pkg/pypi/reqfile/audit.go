@@ -0,0 +1,324 @@
+package reqfile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/api"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/client"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+)
+
+// IssueKind 审计发现的问题类型
+type IssueKind string
+
+const (
+	// IssueUnpinned 依赖没有固定到确切版本（也没有约束文件固定）
+	IssueUnpinned IssueKind = "unpinned"
+
+	// IssueNotFound 包或固定的版本在索引中不存在
+	IssueNotFound IssueKind = "not_found"
+
+	// IssueYanked 固定的版本已被撤回
+	IssueYanked IssueKind = "yanked"
+
+	// IssueVulnerable 固定的版本存在已知漏洞
+	IssueVulnerable IssueKind = "vulnerable"
+
+	// IssueHashMismatch --hash 与索引中该版本的任何文件都不匹配
+	IssueHashMismatch IssueKind = "hash_mismatch"
+
+	// IssueUnverifiable URL、本地路径或可编辑依赖，无法通过索引核对
+	IssueUnverifiable IssueKind = "unverifiable"
+)
+
+// Issue 审计发现的一个问题
+type Issue struct {
+	// Kind 问题类型
+	Kind IssueKind
+
+	// Message 问题说明
+	Message string
+}
+
+// Result 一条依赖的审计结果
+type Result struct {
+	// Line 被审计的依赖
+	Line *Line
+
+	// Version 审计所用的版本，未固定时为空
+	Version string
+
+	// Issues 发现的问题
+	Issues []Issue
+
+	// Vulnerabilities 该版本的已知漏洞，不包含已撤回的漏洞
+	Vulnerabilities []models.Vulnerability
+
+	// Err 查询过程中的错误（如网络错误），此时结果不完整
+	Err error
+}
+
+// Has 检查结果中是否包含指定类型的问题
+func (r *Result) Has(kind IssueKind) bool {
+	for _, issue := range r.Issues {
+		if issue.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// OK 检查依赖是否通过审计（没有问题且查询成功）
+func (r *Result) OK() bool {
+	return len(r.Issues) == 0 && r.Err == nil
+}
+
+func (r *Result) add(kind IssueKind, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, Issue{Kind: kind, Message: fmt.Sprintf(format, args...)})
+}
+
+// Report 需求文件的审计报告
+type Report struct {
+	// Results 每条依赖的审计结果，与File.Requirements的顺序一致
+	Results []*Result
+}
+
+// WithIssue 返回包含指定类型问题的结果
+func (r *Report) WithIssue(kind IssueKind) []*Result {
+	var results []*Result
+	for _, result := range r.Results {
+		if result.Has(kind) {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// Errors 返回查询失败的结果
+func (r *Report) Errors() []*Result {
+	var results []*Result
+	for _, result := range r.Results {
+		if result.Err != nil {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// Summary 统计各类问题的数量
+func (r *Report) Summary() map[IssueKind]int {
+	summary := map[IssueKind]int{}
+	for _, result := range r.Results {
+		for _, issue := range result.Issues {
+			summary[issue.Kind]++
+		}
+	}
+	return summary
+}
+
+// OK 检查所有依赖是否都通过审计
+func (r *Report) OK() bool {
+	for _, result := range r.Results {
+		if !result.OK() {
+			return false
+		}
+	}
+	return true
+}
+
+// Auditor 通过PyPI客户端审计需求文件中的依赖
+type Auditor struct {
+	client      api.PyPIClient
+	concurrency int
+	ignore      map[IssueKind]bool
+}
+
+// NewAuditor 创建审计器，默认并发数为4
+//
+// 参数:
+//   - c: PyPI客户端，通常与需求文件的 --index-url 对应
+//
+// 使用示例:
+//
+//	f, err := reqfile.ParseFile("requirements.txt")
+//	if err != nil {
+//		return err
+//	}
+//	report, err := reqfile.NewAuditor(mirrors.NewOfficialClient()).Audit(ctx, f)
+//	if err != nil {
+//		return err
+//	}
+//	for _, r := range report.WithIssue(reqfile.IssueVulnerable) {
+//		fmt.Println(r.Line.Location(), r.Line.Name, r.Version)
+//	}
+func NewAuditor(c api.PyPIClient) *Auditor {
+	return &Auditor{client: c, concurrency: 4, ignore: map[IssueKind]bool{}}
+}
+
+// WithConcurrency 设置并发请求数，小于1时按1处理
+func (a *Auditor) WithConcurrency(n int) *Auditor {
+	if n < 1 {
+		n = 1
+	}
+	a.concurrency = n
+	return a
+}
+
+// Ignore 忽略指定类型的问题，如允许未固定的依赖
+func (a *Auditor) Ignore(kinds ...IssueKind) *Auditor {
+	for _, kind := range kinds {
+		a.ignore[kind] = true
+	}
+	return a
+}
+
+// Audit 并发审计文件中的每条依赖
+// 未固定的依赖会使用约束文件中的固定版本；查询失败记录在Result.Err中，不会中止整个审计
+//
+// 参数:
+//   - ctx: 上下文，用于控制请求的生命周期
+//   - f: 解析后的需求文件
+//
+// 返回值:
+//   - *Report: 审计报告
+//   - error: 仅在上下文被取消时返回
+func (a *Auditor) Audit(ctx context.Context, f *File) (*Report, error) {
+	report := &Report{Results: make([]*Result, len(f.Requirements))}
+	sem := make(chan struct{}, a.concurrency)
+	var wg sync.WaitGroup
+	for i, line := range f.Requirements {
+		wg.Add(1)
+		go func(i int, line *Line) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			report.Results[i] = a.audit(ctx, f, line)
+		}(i, line)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, result := range report.Results {
+		kept := result.Issues[:0]
+		for _, issue := range result.Issues {
+			if !a.ignore[issue.Kind] {
+				kept = append(kept, issue)
+			}
+		}
+		result.Issues = kept
+	}
+	return report, nil
+}
+
+// audit 审计单条依赖
+func (a *Auditor) audit(ctx context.Context, f *File, line *Line) *Result {
+	result := &Result{Line: line}
+	if line.IsLink() {
+		result.add(IssueUnverifiable, "依赖来自 %s 而不是索引，无法核对", firstNonEmpty(line.Link, line.requirementURL()))
+		return result
+	}
+
+	hashes := line.Hashes
+	version, pinned := line.Pinned()
+	if c := f.Constraint(line.Name); c != nil {
+		if v, ok := c.Pinned(); ok && !pinned {
+			version, pinned = v, true
+		}
+		hashes = append(append([]string(nil), hashes...), c.Hashes...)
+	}
+	if !pinned {
+		result.add(IssueUnpinned, "%s 没有固定版本", line.Raw)
+		return result
+	}
+	result.Version = version
+
+	pkg, err := a.client.GetPackageVersion(ctx, line.Name, version)
+	if err != nil {
+		if errors.Is(err, client.ErrNotFound) {
+			result.add(IssueNotFound, "%s %s 在索引中不存在", line.Name, version)
+		} else {
+			result.Err = err
+		}
+		return result
+	}
+
+	if pkg.Info != nil && pkg.Info.IsYanked() {
+		if pkg.Info.YankedReason != "" {
+			result.add(IssueYanked, "%s %s 已被撤回: %s", line.Name, version, pkg.Info.YankedReason)
+		} else {
+			result.add(IssueYanked, "%s %s 已被撤回", line.Name, version)
+		}
+	}
+
+	if mismatched := mismatchedHashes(hashes, pkg.Urls); len(mismatched) > 0 {
+		result.add(IssueHashMismatch, "以下哈希与 %s %s 的发布文件都不匹配: %s", line.Name, version, strings.Join(mismatched, ", "))
+	}
+
+	vulns, err := a.client.CheckPackageVulnerabilities(ctx, line.Name, version)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	var ids []string
+	for _, v := range vulns {
+		if v.IsWithdrawn() {
+			continue
+		}
+		result.Vulnerabilities = append(result.Vulnerabilities, v)
+		ids = append(ids, v.ID)
+	}
+	if len(ids) > 0 {
+		sort.Strings(ids)
+		result.add(IssueVulnerable, "%s %s 存在已知漏洞: %s", line.Name, version, strings.Join(ids, ", "))
+	}
+	return result
+}
+
+// mismatchedHashes 返回与所有发布文件都不匹配的哈希
+// 索引没有提供的算法（如sha512）无法核对，不视为不匹配
+func mismatchedHashes(hashes []string, files []*models.ReleaseFile) []string {
+	var mismatched []string
+	for _, hash := range hashes {
+		algorithm, value, _ := strings.Cut(hash, ":")
+		known, matched := false, false
+		for _, f := range files {
+			digest := f.Digests.Get(algorithm)
+			if digest == "" {
+				continue
+			}
+			known = true
+			if strings.EqualFold(digest, value) {
+				matched = true
+				break
+			}
+		}
+		if known && !matched {
+			mismatched = append(mismatched, hash)
+		}
+	}
+	return mismatched
+}
+
+// requirementURL 返回PEP 508直接引用的URL
+func (l *Line) requirementURL() string {
+	if l.Requirement != nil {
+		return l.Requirement.URL
+	}
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,154 @@
+package reqfile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/api"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/client"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient 只实现GetPackageVersion和CheckPackageVulnerabilities，包名为flaky时模拟网络错误
+type fakeClient struct {
+	api.PyPIClient
+
+	packages map[string]*models.Package
+	vulns    map[string][]models.Vulnerability
+}
+
+func (f *fakeClient) GetPackageVersion(ctx context.Context, name, version string) (*models.Package, error) {
+	if name == "flaky" {
+		return nil, errors.New("连接超时")
+	}
+	pkg, ok := f.packages[name+"@"+version]
+	if !ok {
+		return nil, fmt.Errorf("获取包 %s 版本 %s 信息失败: %w", name, version, client.ErrNotFound)
+	}
+	return pkg, nil
+}
+
+func (f *fakeClient) CheckPackageVulnerabilities(ctx context.Context, name, version string) ([]models.Vulnerability, error) {
+	return f.vulns[name+"@"+version], nil
+}
+
+func newFakeClient() *fakeClient {
+	release := func(name, version string, yanked bool, sha256 ...string) *models.Package {
+		pkg := &models.Package{Info: &models.PackageInfo{Name: name, Version: version, Yanked: yanked}}
+		for _, digest := range sha256 {
+			pkg.Urls = append(pkg.Urls, &models.ReleaseFile{Digests: models.ReleaseDigests{SHA256: digest}})
+		}
+		return pkg
+	}
+	yanked := release("urllib3", "2.0.0", true, "eeee")
+	yanked.Info.YankedReason = "破坏了兼容性"
+	return &fakeClient{
+		packages: map[string]*models.Package{
+			"requests@2.31.0": release("requests", "2.31.0", false, "aaaa", "bbbb"),
+			"flask@2.3.3":     release("flask", "2.3.3", false, "cccc"),
+			"urllib3@2.0.0":   yanked,
+			"idna@3.4":        release("idna", "3.4", false, "dddd"),
+		},
+		vulns: map[string][]models.Vulnerability{
+			"requests@2.31.0": {
+				{ID: "GHSA-9wx4-h78v-vm56"},
+				{ID: "PYSEC-2020-1", Withdrawn: "2021-01-01T00:00:00Z"},
+			},
+		},
+	}
+}
+
+const auditRequirements = `-c constraints.txt
+requests==2.31.0 --hash=sha256:aaaa
+flask
+urllib3==2.0.0
+idna==3.4 --hash=sha256:ffff --hash=sha512:0000
+django>=4.2
+nonexistent==1.0
+flaky==1.0
+-e ./local
+`
+
+func TestAudit(t *testing.T) {
+	f, err := ParseFS(fstest.MapFS{
+		"requirements.txt": {Data: []byte(auditRequirements)},
+		"constraints.txt":  {Data: []byte("flask==2.3.3\n")},
+	}, "requirements.txt")
+	require.NoError(t, err)
+
+	report, err := NewAuditor(newFakeClient()).WithConcurrency(3).Audit(context.Background(), f)
+	require.NoError(t, err)
+	require.Len(t, report.Results, 8)
+	assert.False(t, report.OK())
+
+	byName := map[string]*Result{}
+	for _, r := range report.Results {
+		byName[r.Line.Name] = r
+	}
+
+	requests := byName["requests"]
+	assert.Equal(t, []IssueKind{IssueVulnerable}, kinds(requests))
+	assert.Len(t, requests.Vulnerabilities, 1, "已撤回的漏洞应被排除")
+
+	flask := byName["flask"]
+	assert.True(t, flask.OK(), "约束文件固定了flask的版本")
+	assert.Equal(t, "2.3.3", flask.Version)
+
+	assert.Equal(t, []IssueKind{IssueYanked}, kinds(byName["urllib3"]))
+	assert.Contains(t, byName["urllib3"].Issues[0].Message, "破坏了兼容性")
+
+	idna := byName["idna"]
+	assert.Equal(t, []IssueKind{IssueHashMismatch}, kinds(idna))
+	assert.Contains(t, idna.Issues[0].Message, "sha256:ffff")
+	assert.NotContains(t, idna.Issues[0].Message, "sha512", "索引没有提供的算法无法核对")
+
+	assert.Equal(t, []IssueKind{IssueUnpinned}, kinds(byName["django"]))
+	assert.Equal(t, []IssueKind{IssueNotFound}, kinds(byName["nonexistent"]))
+	assert.Error(t, byName["flaky"].Err)
+	assert.Len(t, report.Errors(), 1)
+	assert.Equal(t, []IssueKind{IssueUnverifiable}, kinds(byName[""]))
+
+	assert.Equal(t, map[IssueKind]int{
+		IssueVulnerable:   1,
+		IssueYanked:       1,
+		IssueHashMismatch: 1,
+		IssueUnpinned:     1,
+		IssueNotFound:     1,
+		IssueUnverifiable: 1,
+	}, report.Summary())
+	assert.Len(t, report.WithIssue(IssueUnpinned), 1)
+
+	t.Run("忽略问题类型", func(t *testing.T) {
+		report, err := NewAuditor(newFakeClient()).Ignore(IssueUnpinned, IssueUnverifiable).Audit(context.Background(), f)
+		require.NoError(t, err)
+		assert.Empty(t, report.WithIssue(IssueUnpinned))
+		assert.Empty(t, report.WithIssue(IssueUnverifiable))
+	})
+
+	t.Run("上下文取消", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := NewAuditor(newFakeClient()).Audit(ctx, f)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func kinds(r *Result) []IssueKind {
+	var result []IssueKind
+	for _, issue := range r.Issues {
+		result = append(result, issue.Kind)
+	}
+	return result
+}
+
+func TestMismatchedHashes(t *testing.T) {
+	files := []*models.ReleaseFile{{Digests: models.ReleaseDigests{SHA256: "ABCD", MD5: "1234"}}}
+	assert.Empty(t, mismatchedHashes([]string{"sha256:abcd"}, files))
+	assert.Equal(t, []string{"md5:9999"}, mismatchedHashes([]string{"md5:9999", "sha384:" + strings.Repeat("0", 96)}, files))
+}
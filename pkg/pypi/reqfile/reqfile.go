@@ -0,0 +1,487 @@
+package reqfile
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/requirement"
+)
+
+// ErrIncludeUnsupported 表示在不支持包含文件的情况下遇到了 -r/-c
+var ErrIncludeUnsupported = errors.New("无法解析包含的文件")
+
+// ParseError 表示需求文件中某一行的解析错误
+type ParseError struct {
+	// File 文件名
+	File string
+
+	// Line 逻辑行的起始行号（从1开始）
+	Line int
+
+	// Err 具体错误
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Line 表示需求文件中的一条依赖
+type Line struct {
+	// File 所在文件
+	File string
+
+	// LineNumber 逻辑行的起始行号
+	LineNumber int
+
+	// Raw 去掉注释并合并续行后的内容
+	Raw string
+
+	// Name 包名，URL或路径形式且无法推断包名时为空
+	Name string
+
+	// Requirement PEP 508依赖声明，URL或路径形式的依赖为nil
+	Requirement *requirement.Requirement
+
+	// Link URL或本地路径形式依赖的地址，如 "git+https://github.com/org/repo#egg=pkg"
+	Link string
+
+	// Editable 是否为 -e/--editable 依赖
+	Editable bool
+
+	// Hashes --hash 指定的哈希值，格式为 "算法:十六进制值"
+	Hashes []string
+
+	// Constraint 是否来自 -c 约束文件
+	Constraint bool
+}
+
+// Location 返回 "文件:行号" 形式的位置
+func (l *Line) Location() string {
+	return fmt.Sprintf("%s:%d", l.File, l.LineNumber)
+}
+
+// NormalizedName 返回按PEP 503规范化的包名
+func (l *Line) NormalizedName() string {
+	return models.NormalizeName(l.Name)
+}
+
+// Pinned 返回 "==X" 或 "===X" 固定的版本号，通配符和范围约束不算固定
+func (l *Line) Pinned() (string, bool) {
+	if l.Requirement == nil || l.Requirement.URL != "" {
+		return "", false
+	}
+	return l.Requirement.Specifier.Pinned()
+}
+
+// IsLink 检查依赖是否为URL、本地路径或可编辑安装，而不是从索引安装
+func (l *Line) IsLink() bool {
+	return l.Link != "" || l.Editable || (l.Requirement != nil && l.Requirement.URL != "")
+}
+
+// File 表示解析后的需求文件，包含通过 -r/-c 引入的所有内容
+type File struct {
+	// Requirements 需要安装的依赖，按出现顺序排列
+	Requirements []*Line
+
+	// Constraints 通过 -c 引入的约束
+	Constraints []*Line
+
+	// IndexURL --index-url 指定的主索引
+	IndexURL string
+
+	// ExtraIndexURLs --extra-index-url 指定的额外索引
+	ExtraIndexURLs []string
+
+	// NoIndex 是否指定了 --no-index
+	NoIndex bool
+
+	// FindLinks --find-links 指定的地址
+	FindLinks []string
+
+	// TrustedHosts --trusted-host 指定的主机
+	TrustedHosts []string
+
+	// Pre 是否指定了 --pre
+	Pre bool
+
+	// RequireHashes 是否指定了 --require-hashes
+	RequireHashes bool
+
+	// Files 解析过的所有文件，按首次打开的顺序排列
+	Files []string
+}
+
+// Constraint 返回包名对应的约束，不存在时返回nil
+func (f *File) Constraint(name string) *Line {
+	normalized := models.NormalizeName(name)
+	for _, c := range f.Constraints {
+		if c.NormalizedName() == normalized {
+			return c
+		}
+	}
+	return nil
+}
+
+// Parse 从r中解析需求文件，遇到 -r/-c 时返回ErrIncludeUnsupported，需要包含文件时请使用ParseFile或ParseFS
+//
+// 参数:
+//   - r: 文件内容
+//   - name: 用于错误信息的文件名
+func Parse(r io.Reader, name string) (*File, error) {
+	p := &parser{file: &File{}}
+	if err := p.parse(r, name, false); err != nil {
+		return nil, err
+	}
+	return p.file, nil
+}
+
+// ParseFile 解析磁盘上的需求文件，-r/-c 的相对路径相对于所在文件解析
+//
+// 参数:
+//   - filename: 需求文件路径，如 "requirements.txt"
+//
+// 返回值:
+//   - *File: 合并了所有包含文件的解析结果
+//   - error: 文件无法读取、存在循环包含或语法错误时返回，语法错误为*ParseError
+//
+// 使用示例:
+//
+//	f, err := reqfile.ParseFile("requirements/prod.txt")
+//	if err != nil {
+//		return err
+//	}
+//	for _, line := range f.Requirements {
+//		fmt.Println(line.Location(), line.Name)
+//	}
+func ParseFile(filename string) (*File, error) {
+	p := &parser{
+		file: &File{},
+		open: func(name string) (io.ReadCloser, error) { return os.Open(name) },
+		join: func(base, name string) string {
+			if filepath.IsAbs(name) {
+				return name
+			}
+			return filepath.Join(filepath.Dir(base), name)
+		},
+	}
+	if err := p.include(filename, false); err != nil {
+		return nil, err
+	}
+	return p.file, nil
+}
+
+// ParseFS 从文件系统中解析需求文件，-r/-c 的相对路径相对于所在文件解析
+func ParseFS(fsys fs.FS, name string) (*File, error) {
+	p := &parser{
+		file: &File{},
+		open: func(name string) (io.ReadCloser, error) { return fsys.Open(name) },
+		join: func(base, name string) string {
+			return path.Join(path.Dir(base), name)
+		},
+	}
+	if err := p.include(name, false); err != nil {
+		return nil, err
+	}
+	return p.file, nil
+}
+
+type parser struct {
+	file  *File
+	open  func(name string) (io.ReadCloser, error)
+	join  func(base, name string) string
+	stack []string
+}
+
+// include 打开并解析一个文件，检测循环包含
+func (p *parser) include(name string, constraint bool) error {
+	for _, open := range p.stack {
+		if open == name {
+			return fmt.Errorf("循环包含: %s", strings.Join(append(p.stack, name), " -> "))
+		}
+	}
+	r, err := p.open(name)
+	if err != nil {
+		return fmt.Errorf("打开需求文件 %s 失败: %w", name, err)
+	}
+	defer r.Close()
+
+	p.stack = append(p.stack, name)
+	defer func() { p.stack = p.stack[:len(p.stack)-1] }()
+	return p.parse(r, name, constraint)
+}
+
+type logicalLine struct {
+	number int
+	text   string
+}
+
+// commentPattern pip的注释规则：行首或空白之后的 "#" 开始注释
+var commentPattern = regexp.MustCompile(`(^|\s+)#.*$`)
+
+// envPattern pip支持的环境变量引用，只允许大写字母、数字和下划线
+var envPattern = regexp.MustCompile(`\$\{([A-Z0-9_]+)\}`)
+
+// readLines 按pip的规则读取逻辑行：合并以 "\" 结尾的续行，去掉注释并展开环境变量
+func readLines(r io.Reader) ([]logicalLine, error) {
+	var lines []logicalLine
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var current strings.Builder
+	start, number := 0, 0
+	for scanner.Scan() {
+		number++
+		text := scanner.Text()
+		if current.Len() == 0 {
+			start = number
+		}
+		if strings.HasSuffix(text, `\`) && !commentPattern.MatchString(text) {
+			current.WriteString(strings.TrimSuffix(text, `\`))
+			continue
+		}
+		current.WriteString(text)
+		lines = append(lines, logicalLine{number: start, text: current.String()})
+		current.Reset()
+	}
+	if current.Len() > 0 {
+		lines = append(lines, logicalLine{number: start, text: current.String()})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range lines {
+		text := commentPattern.ReplaceAllString(lines[i].text, "")
+		text = envPattern.ReplaceAllStringFunc(text, func(ref string) string {
+			if value, ok := os.LookupEnv(ref[2 : len(ref)-1]); ok {
+				return value
+			}
+			return ref
+		})
+		lines[i].text = strings.TrimSpace(text)
+	}
+	return lines, nil
+}
+
+func (p *parser) parse(r io.Reader, name string, constraint bool) error {
+	p.file.Files = append(p.file.Files, name)
+	lines, err := readLines(r)
+	if err != nil {
+		return fmt.Errorf("读取需求文件 %s 失败: %w", name, err)
+	}
+	for _, ll := range lines {
+		if ll.text == "" {
+			continue
+		}
+		if err := p.parseLine(name, ll, constraint); err != nil {
+			var perr *ParseError
+			if errors.As(err, &perr) {
+				return err
+			}
+			return &ParseError{File: name, Line: ll.number, Err: err}
+		}
+	}
+	return nil
+}
+
+// splitOption 拆分 "--name=value"、"--name value"、"-rvalue" 和 "-r value" 形式的选项
+func splitOption(text string) (name, value string) {
+	if strings.HasPrefix(text, "--") {
+		end := strings.IndexAny(text, "= \t")
+		if end < 0 {
+			return text, ""
+		}
+		return text[:end], strings.TrimSpace(strings.TrimLeft(text[end:], "= \t"))
+	}
+	if len(text) > 2 {
+		return text[:2], strings.TrimSpace(text[2:])
+	}
+	return text, ""
+}
+
+// parseLine 解析一行全局选项或依赖
+func (p *parser) parseLine(file string, ll logicalLine, constraint bool) error {
+	if !strings.HasPrefix(ll.text, "-") {
+		line, err := parseRequirement(ll.text)
+		if err != nil {
+			return err
+		}
+		p.add(file, ll, line, constraint)
+		return nil
+	}
+
+	name, value := splitOption(ll.text)
+	needValue := func() error {
+		if value == "" {
+			return fmt.Errorf("选项 %s 缺少参数", name)
+		}
+		return nil
+	}
+	switch name {
+	case "-r", "--requirement", "-c", "--constraint":
+		if err := needValue(); err != nil {
+			return err
+		}
+		if strings.Contains(value, "://") {
+			return fmt.Errorf("%w: 不支持远程文件 %s", ErrIncludeUnsupported, value)
+		}
+		if p.join == nil {
+			return fmt.Errorf("%w: %s", ErrIncludeUnsupported, value)
+		}
+		isConstraint := constraint || name == "-c" || name == "--constraint"
+		return p.include(p.join(file, value), isConstraint)
+	case "-e", "--editable":
+		if err := needValue(); err != nil {
+			return err
+		}
+		line, err := parseRequirement(value)
+		if err != nil {
+			return err
+		}
+		line.Editable = true
+		p.add(file, ll, line, constraint)
+	case "-i", "--index-url":
+		if err := needValue(); err != nil {
+			return err
+		}
+		p.file.IndexURL = value
+	case "--extra-index-url":
+		if err := needValue(); err != nil {
+			return err
+		}
+		p.file.ExtraIndexURLs = append(p.file.ExtraIndexURLs, value)
+	case "--no-index":
+		p.file.NoIndex = true
+	case "-f", "--find-links":
+		if err := needValue(); err != nil {
+			return err
+		}
+		p.file.FindLinks = append(p.file.FindLinks, value)
+	case "--trusted-host":
+		if err := needValue(); err != nil {
+			return err
+		}
+		p.file.TrustedHosts = append(p.file.TrustedHosts, value)
+	case "--pre":
+		p.file.Pre = true
+	case "--require-hashes":
+		p.file.RequireHashes = true
+	case "--prefer-binary", "--only-binary", "--no-binary", "--use-feature", "--config-settings":
+		// 只影响安装行为，与依赖清单无关
+	default:
+		return fmt.Errorf("未知的选项 %s", name)
+	}
+	return nil
+}
+
+func (p *parser) add(file string, ll logicalLine, line *Line, constraint bool) {
+	line.File = file
+	line.LineNumber = ll.number
+	line.Raw = ll.text
+	line.Constraint = constraint
+	if constraint {
+		p.file.Constraints = append(p.file.Constraints, line)
+	} else {
+		p.file.Requirements = append(p.file.Requirements, line)
+	}
+}
+
+// pep508URLPattern "name @ url" 形式的直接引用
+var pep508URLPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*\s*(\[[^\]]*\])?\s*@`)
+
+// archiveSuffixes 可以直接安装的发布文件扩展名
+var archiveSuffixes = []string{".whl", ".tar.gz", ".tgz", ".tar.bz2", ".zip"}
+
+// parseRequirement 解析依赖部分及其后的 --hash 等单行选项
+func parseRequirement(text string) (*Line, error) {
+	spec, options := text, ""
+	if i := strings.Index(text, " --"); i >= 0 {
+		spec, options = strings.TrimSpace(text[:i]), text[i:]
+	} else if i := strings.Index(text, "\t--"); i >= 0 {
+		spec, options = strings.TrimSpace(text[:i]), text[i:]
+	}
+
+	line := &Line{}
+	fields := strings.Fields(options)
+	for i := 0; i < len(fields); i++ {
+		name, value := splitOption(fields[i])
+		if value == "" && i+1 < len(fields) && !strings.HasPrefix(fields[i+1], "-") {
+			i++
+			value = fields[i]
+		}
+		switch name {
+		case "--hash":
+			if !strings.Contains(value, ":") {
+				return nil, fmt.Errorf("无效的哈希 %q，应为 算法:值", value)
+			}
+			line.Hashes = append(line.Hashes, strings.ToLower(value))
+		case "--global-option", "--install-option", "--config-settings":
+		default:
+			return nil, fmt.Errorf("未知的依赖选项 %s", name)
+		}
+	}
+
+	if isLink(spec) {
+		line.Link = spec
+		line.Name = linkName(spec)
+		return line, nil
+	}
+	req, err := requirement.Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+	line.Requirement = req
+	line.Name = req.Name
+	return line, nil
+}
+
+// isLink 检查依赖是否为URL或本地路径，而不是PEP 508声明
+func isLink(spec string) bool {
+	if pep508URLPattern.MatchString(spec) {
+		return false
+	}
+	if strings.Contains(spec, "://") || strings.HasPrefix(spec, ".") || strings.ContainsAny(spec, `/\`) {
+		return true
+	}
+	lower := strings.ToLower(spec)
+	for _, suffix := range archiveSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// linkName 从 "#egg=name" 片段或wheel文件名中推断包名
+func linkName(link string) string {
+	if i := strings.Index(link, "#egg="); i >= 0 {
+		name := link[i+len("#egg="):]
+		if end := strings.IndexAny(name, "&["); end >= 0 {
+			name = name[:end]
+		}
+		return name
+	}
+	base := link
+	if i := strings.LastIndexAny(base, `/\`); i >= 0 {
+		base = base[i+1:]
+	}
+	if strings.HasSuffix(strings.ToLower(base), ".whl") {
+		if name, _, ok := strings.Cut(base, "-"); ok {
+			return name
+		}
+	}
+	return ""
+}
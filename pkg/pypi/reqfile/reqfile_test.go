@@ -0,0 +1,135 @@
+package reqfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const prodRequirements = `# 生产环境依赖
+--index-url https://pypi.tuna.tsinghua.edu.cn/simple
+--extra-index-url=https://download.pytorch.org/whl/cpu
+--trusted-host pypi.tuna.tsinghua.edu.cn
+-r base.txt
+-c constraints.txt
+
+requests[socks]==2.31.0 \
+    --hash=sha256:AAAA \
+    --hash=sha256:bbbb  # 两个文件
+importlib-metadata>=4.0; python_version < "3.8"
+-e git+https://github.com/org/tool.git@v1.0#egg=internal-tool
+./vendor/local_pkg-1.0-py3-none-any.whl
+https://example.com/archive.tar.gz
+`
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"requirements/prod.txt":        {Data: []byte(prodRequirements)},
+		"requirements/base.txt":        {Data: []byte("flask>=2.0\n--pre\n")},
+		"requirements/constraints.txt": {Data: []byte("flask==2.3.3 --hash sha256:cccc\n")},
+	}
+}
+
+func TestParseFS(t *testing.T) {
+	f, err := ParseFS(testFS(), "requirements/prod.txt")
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://pypi.tuna.tsinghua.edu.cn/simple", f.IndexURL)
+	assert.Equal(t, []string{"https://download.pytorch.org/whl/cpu"}, f.ExtraIndexURLs)
+	assert.Equal(t, []string{"pypi.tuna.tsinghua.edu.cn"}, f.TrustedHosts)
+	assert.True(t, f.Pre, "包含文件中的全局选项应生效")
+	assert.Equal(t, []string{"requirements/prod.txt", "requirements/base.txt", "requirements/constraints.txt"}, f.Files)
+
+	require.Len(t, f.Requirements, 6)
+	flask := f.Requirements[0]
+	assert.Equal(t, "flask", flask.Name)
+	assert.Equal(t, "requirements/base.txt:1", flask.Location())
+	_, pinned := flask.Pinned()
+	assert.False(t, pinned)
+
+	requests := f.Requirements[1]
+	assert.Equal(t, "requirements/prod.txt:8", requests.Location(), "续行使用起始行号")
+	assert.Equal(t, []string{"sha256:aaaa", "sha256:bbbb"}, requests.Hashes)
+	v, pinned := requests.Pinned()
+	assert.True(t, pinned)
+	assert.Equal(t, "2.31.0", v)
+	assert.Equal(t, []string{"socks"}, requests.Requirement.Extras)
+
+	marker := f.Requirements[2]
+	require.NotNil(t, marker.Requirement.Marker)
+
+	editable := f.Requirements[3]
+	assert.True(t, editable.Editable)
+	assert.Equal(t, "internal-tool", editable.Name)
+	assert.Equal(t, "git+https://github.com/org/tool.git@v1.0#egg=internal-tool", editable.Link)
+
+	wheel := f.Requirements[4]
+	assert.Equal(t, "local_pkg", wheel.Name)
+	assert.True(t, wheel.IsLink())
+
+	archive := f.Requirements[5]
+	assert.Empty(t, archive.Name)
+	assert.Equal(t, "https://example.com/archive.tar.gz", archive.Link)
+
+	require.Len(t, f.Constraints, 1)
+	assert.True(t, f.Constraints[0].Constraint)
+	assert.Equal(t, f.Constraints[0], f.Constraint("Flask"))
+	assert.Nil(t, f.Constraint("requests"))
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Run("语法错误带位置", func(t *testing.T) {
+		_, err := Parse(strings.NewReader("requests==2.0\n\nnot a valid !! line\n"), "req.txt")
+		var perr *ParseError
+		require.True(t, errors.As(err, &perr))
+		assert.Equal(t, "req.txt", perr.File)
+		assert.Equal(t, 3, perr.Line)
+	})
+
+	t.Run("不支持包含", func(t *testing.T) {
+		_, err := Parse(strings.NewReader("-r other.txt\n"), "req.txt")
+		assert.ErrorIs(t, err, ErrIncludeUnsupported)
+	})
+
+	t.Run("循环包含", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"a.txt": {Data: []byte("-r b.txt\n")},
+			"b.txt": {Data: []byte("-r a.txt\n")},
+		}
+		_, err := ParseFS(fsys, "a.txt")
+		assert.ErrorContains(t, err, "循环包含")
+	})
+
+	t.Run("包含的文件不存在", func(t *testing.T) {
+		_, err := ParseFS(fstest.MapFS{"a.txt": {Data: []byte("-r missing.txt\n")}}, "a.txt")
+		var perr *ParseError
+		require.True(t, errors.As(err, &perr))
+		assert.Equal(t, 1, perr.Line)
+	})
+
+	for _, line := range []string{"--unknown-option", "--index-url", "requests --hash=abc", "requests --bogus"} {
+		_, err := Parse(strings.NewReader(line), "req.txt")
+		assert.Error(t, err, line)
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "requirements.txt"), []byte("-r sub/dev.txt\nidna==3.4\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "dev.txt"), []byte("pytest==${PYTEST_VERSION}\n"), 0o644))
+	t.Setenv("PYTEST_VERSION", "7.4.0")
+
+	f, err := ParseFile(filepath.Join(dir, "requirements.txt"))
+	require.NoError(t, err)
+	require.Len(t, f.Requirements, 2)
+	v, _ := f.Requirements[0].Pinned()
+	assert.Equal(t, "7.4.0", v, "应展开环境变量")
+	assert.Equal(t, "idna", f.Requirements[1].Name)
+}
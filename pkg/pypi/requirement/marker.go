@@ -0,0 +1,428 @@
+package requirement
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/version"
+)
+
+// ErrInvalidMarker 表示字符串不是有效的PEP 508环境标记
+var ErrInvalidMarker = errors.New("无效的环境标记")
+
+// markerVariables PEP 508定义的环境标记变量
+var markerVariables = map[string]bool{
+	"python_version":                 true,
+	"python_full_version":            true,
+	"os_name":                        true,
+	"sys_platform":                   true,
+	"platform_release":               true,
+	"platform_system":                true,
+	"platform_version":               true,
+	"platform_machine":               true,
+	"platform_python_implementation": true,
+	"implementation_name":            true,
+	"implementation_version":         true,
+	"extra":                          true,
+}
+
+// legacyVariables 旧版（PEP 345）的变量写法，解析时转换为PEP 508写法
+var legacyVariables = map[string]string{
+	"os.name":                        "os_name",
+	"sys.platform":                   "sys_platform",
+	"platform.version":               "platform_version",
+	"platform.machine":               "platform_machine",
+	"platform.python_implementation": "platform_python_implementation",
+	"python_implementation":          "platform_python_implementation",
+}
+
+// Value 表示标记比较中的一侧，可以是环境变量或字符串字面量
+type Value struct {
+	// Variable 是否为环境变量
+	Variable bool
+
+	// Text 变量名或字面量内容
+	Text string
+}
+
+// String 返回变量名或带双引号的字面量
+func (v Value) String() string {
+	if v.Variable {
+		return v.Text
+	}
+	return `"` + v.Text + `"`
+}
+
+// Marker 表示解析后的环境标记表达式，如 `python_version >= "3.8" and sys_platform == "linux"`
+// Operator为 "and" 或 "or" 时为组合节点，子表达式在Markers中；否则为比较节点
+type Marker struct {
+	// Operator 组合运算符（"and" 或 "or"），比较节点为空
+	Operator string
+
+	// Markers 组合节点的子表达式
+	Markers []*Marker
+
+	// Left 比较左侧
+	Left Value
+
+	// Comparator 比较运算符，如 "=="、">="、"in"、"not in"
+	Comparator string
+
+	// Right 比较右侧
+	Right Value
+}
+
+// Environment 环境标记求值所用的变量值
+type Environment map[string]string
+
+// DefaultEnvironment 返回Linux x86_64上CPython的环境变量，用于没有目标环境信息时的近似求值
+//
+// 参数:
+//   - pythonVersion: 完整的Python版本号，如 "3.11.4"
+//
+// 使用示例:
+//
+//	env := requirement.DefaultEnvironment("3.11.4")
+//	ok := marker.Evaluate(env)
+func DefaultEnvironment(pythonVersion string) Environment {
+	short := pythonVersion
+	if parts := strings.Split(pythonVersion, "."); len(parts) >= 2 {
+		short = parts[0] + "." + parts[1]
+	}
+	return Environment{
+		"python_version":                 short,
+		"python_full_version":            pythonVersion,
+		"os_name":                        "posix",
+		"sys_platform":                   "linux",
+		"platform_release":               "",
+		"platform_system":                "Linux",
+		"platform_version":               "",
+		"platform_machine":               "x86_64",
+		"platform_python_implementation": "CPython",
+		"implementation_name":            "cpython",
+		"implementation_version":         pythonVersion,
+		"extra":                          "",
+	}
+}
+
+// WithExtra 返回设置了extra变量的环境副本
+func (env Environment) WithExtra(extra string) Environment {
+	copied := make(Environment, len(env)+1)
+	for k, v := range env {
+		copied[k] = v
+	}
+	copied["extra"] = extra
+	return copied
+}
+
+// ParseMarker 解析PEP 508环境标记
+//
+// 参数:
+//   - s: 标记表达式，如 `python_version < "3.8" or extra == "test"`
+//
+// 返回值:
+//   - *Marker: 解析后的表达式树
+//   - error: 语法无效时返回，错误包装了ErrInvalidMarker
+func ParseMarker(s string) (*Marker, error) {
+	tokens, err := tokenizeMarker(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &markerParser{tokens: tokens, input: s}
+	m, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, p.errorf("多余的内容 %q", p.tokens[p.pos].text)
+	}
+	return m, nil
+}
+
+// String 返回标记的规范写法
+func (m *Marker) String() string {
+	if m.Operator == "" {
+		return m.Left.String() + " " + m.Comparator + " " + m.Right.String()
+	}
+	parts := make([]string, len(m.Markers))
+	for i, child := range m.Markers {
+		s := child.String()
+		// and中的or子表达式需要加括号
+		if child.Operator == "or" && m.Operator == "and" {
+			s = "(" + s + ")"
+		}
+		parts[i] = s
+	}
+	return strings.Join(parts, " "+m.Operator+" ")
+}
+
+// Evaluate 在给定环境下对标记求值
+// 环境中不存在的变量按空字符串处理
+func (m *Marker) Evaluate(env Environment) bool {
+	switch m.Operator {
+	case "and":
+		for _, child := range m.Markers {
+			if !child.Evaluate(env) {
+				return false
+			}
+		}
+		return true
+	case "or":
+		for _, child := range m.Markers {
+			if child.Evaluate(env) {
+				return true
+			}
+		}
+		return false
+	}
+
+	left, right := m.resolve(m.Left, env), m.resolve(m.Right, env)
+	switch m.Comparator {
+	case "in":
+		return strings.Contains(right, left)
+	case "not in":
+		return !strings.Contains(right, left)
+	}
+
+	// 两侧都是合法版本号时按PEP 440比较
+	if spec, err := version.ParseSpecifier(m.Comparator + right); err == nil {
+		if v, err := version.Parse(left); err == nil {
+			return spec.Contains(v)
+		}
+	}
+	switch m.Comparator {
+	case "==", "===":
+		return left == right
+	case "!=":
+		return left != right
+	}
+	return false
+}
+
+// resolve 取出比较一侧的值，extra按PEP 685规范化以便不区分大小写和分隔符比较
+func (m *Marker) resolve(v Value, env Environment) string {
+	text := v.Text
+	if v.Variable {
+		text = env[v.Text]
+	}
+	if m.Left.Text == "extra" || m.Right.Text == "extra" {
+		return models.NormalizeName(text)
+	}
+	return text
+}
+
+// Extras 返回标记中以 `extra == "..."` 形式引用的extra名称（已规范化、去重并排序）
+func (m *Marker) Extras() []string {
+	seen := map[string]bool{}
+	m.walk(func(leaf *Marker) {
+		if leaf.Comparator != "==" {
+			return
+		}
+		switch {
+		case leaf.Left.Variable && leaf.Left.Text == "extra" && !leaf.Right.Variable:
+			seen[models.NormalizeName(leaf.Right.Text)] = true
+		case leaf.Right.Variable && leaf.Right.Text == "extra" && !leaf.Left.Variable:
+			seen[models.NormalizeName(leaf.Left.Text)] = true
+		}
+	})
+	extras := make([]string, 0, len(seen))
+	for extra := range seen {
+		extras = append(extras, extra)
+	}
+	sort.Strings(extras)
+	return extras
+}
+
+// walk 依次访问所有比较节点
+func (m *Marker) walk(fn func(leaf *Marker)) {
+	if m.Operator == "" {
+		fn(m)
+		return
+	}
+	for _, child := range m.Markers {
+		child.walk(fn)
+	}
+}
+
+type markerToken struct {
+	kind string // "var"、"str"、"op"、"and"、"or"、"("、")"
+	text string
+}
+
+// tokenizeMarker 将标记表达式切分为词法单元
+func tokenizeMarker(s string) ([]markerToken, error) {
+	var tokens []markerToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, markerToken{kind: string(c), text: string(c)})
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("%w: 字符串未闭合: %q", ErrInvalidMarker, s)
+			}
+			tokens = append(tokens, markerToken{kind: "str", text: s[i+1 : i+1+end]})
+			i += end + 2
+		case strings.ContainsRune("<>=!~", rune(c)):
+			j := i
+			for j < len(s) && strings.ContainsRune("<>=!~", rune(s[j])) {
+				j++
+			}
+			op := s[i:j]
+			switch op {
+			case "<", "<=", ">", ">=", "==", "!=", "~=", "===":
+			default:
+				return nil, fmt.Errorf("%w: 未知的运算符 %q", ErrInvalidMarker, op)
+			}
+			tokens = append(tokens, markerToken{kind: "op", text: op})
+			i = j
+		case isIdentChar(c):
+			j := i
+			for j < len(s) && (isIdentChar(s[j]) || s[j] == '.') {
+				j++
+			}
+			word := s[i:j]
+			i = j
+			switch word {
+			case "and", "or":
+				tokens = append(tokens, markerToken{kind: word, text: word})
+			case "in":
+				tokens = append(tokens, markerToken{kind: "op", text: "in"})
+			case "not":
+				// "not" 只能出现在 "not in" 中
+				rest := strings.TrimLeft(s[i:], " \t")
+				if !strings.HasPrefix(rest, "in") || (len(rest) > 2 && isIdentChar(rest[2])) {
+					return nil, fmt.Errorf("%w: not之后应为in: %q", ErrInvalidMarker, s)
+				}
+				i = len(s) - len(rest) + 2
+				tokens = append(tokens, markerToken{kind: "op", text: "not in"})
+			default:
+				if legacy, ok := legacyVariables[word]; ok {
+					word = legacy
+				}
+				if !markerVariables[word] {
+					return nil, fmt.Errorf("%w: 未知的变量 %q", ErrInvalidMarker, word)
+				}
+				tokens = append(tokens, markerToken{kind: "var", text: word})
+			}
+		default:
+			return nil, fmt.Errorf("%w: 无法识别的字符 %q: %q", ErrInvalidMarker, c, s)
+		}
+	}
+	return tokens, nil
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// markerParser 递归下降解析器，优先级 and 高于 or
+type markerParser struct {
+	tokens []markerToken
+	pos    int
+	input  string
+}
+
+func (p *markerParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s: %q", ErrInvalidMarker, fmt.Sprintf(format, args...), p.input)
+}
+
+func (p *markerParser) peek() *markerToken {
+	if p.pos < len(p.tokens) {
+		return &p.tokens[p.pos]
+	}
+	return nil
+}
+
+func (p *markerParser) parseOr() (*Marker, error) {
+	return p.parseBinary("or", p.parseAnd)
+}
+
+func (p *markerParser) parseAnd() (*Marker, error) {
+	return p.parseBinary("and", p.parseAtom)
+}
+
+// parseBinary 解析由同一运算符连接的子表达式，并展平为一个组合节点
+func (p *markerParser) parseBinary(op string, next func() (*Marker, error)) (*Marker, error) {
+	first, err := next()
+	if err != nil {
+		return nil, err
+	}
+	operands := []*Marker{first}
+	for tok := p.peek(); tok != nil && tok.kind == op; tok = p.peek() {
+		p.pos++
+		operand, err := next()
+		if err != nil {
+			return nil, err
+		}
+		if operand.Operator == op {
+			operands = append(operands, operand.Markers...)
+		} else {
+			operands = append(operands, operand)
+		}
+	}
+	if len(operands) == 1 {
+		return first, nil
+	}
+	return &Marker{Operator: op, Markers: operands}, nil
+}
+
+func (p *markerParser) parseAtom() (*Marker, error) {
+	tok := p.peek()
+	if tok == nil {
+		return nil, p.errorf("表达式不完整")
+	}
+	if tok.kind == "(" {
+		p.pos++
+		m, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.peek(); tok == nil || tok.kind != ")" {
+			return nil, p.errorf("缺少右括号")
+		}
+		p.pos++
+		return m, nil
+	}
+
+	left, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	op := p.peek()
+	if op == nil || op.kind != "op" {
+		return nil, p.errorf("缺少比较运算符")
+	}
+	p.pos++
+	right, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if left.Variable == right.Variable {
+		return nil, p.errorf("比较的一侧必须是变量，另一侧必须是字符串")
+	}
+	return &Marker{Left: left, Comparator: op.text, Right: right}, nil
+}
+
+func (p *markerParser) parseValue() (Value, error) {
+	tok := p.peek()
+	if tok == nil {
+		return Value{}, p.errorf("表达式不完整")
+	}
+	switch tok.kind {
+	case "var":
+		p.pos++
+		return Value{Variable: true, Text: tok.text}, nil
+	case "str":
+		p.pos++
+		return Value{Text: tok.text}, nil
+	}
+	return Value{}, p.errorf("意外的 %q", tok.text)
+}
@@ -0,0 +1,67 @@
+package requirement
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMarker(t *testing.T) {
+	m, err := ParseMarker(`python_version >= '3.8' and (sys_platform == "win32" or os.name == "nt") and extra == 'test'`)
+	require.NoError(t, err)
+	assert.Equal(t, "and", m.Operator)
+	require.Len(t, m.Markers, 3)
+	assert.Equal(t, "or", m.Markers[1].Operator)
+	assert.Equal(t, `python_version >= "3.8" and (sys_platform == "win32" or os_name == "nt") and extra == "test"`, m.String())
+	assert.Equal(t, []string{"test"}, m.Extras())
+
+	for _, s := range []string{
+		"",
+		`python_version`,
+		`python_version >= `,
+		`unknown_var == "1"`,
+		`"a" == "b"`,
+		`python_version => "3"`,
+		`(python_version == "3"`,
+		`python_version == "3" xor os_name == "nt"`,
+		`python_version not "3"`,
+		`python_version == "3`,
+	} {
+		_, err := ParseMarker(s)
+		assert.ErrorIs(t, err, ErrInvalidMarker, s)
+	}
+}
+
+func TestMarkerEvaluate(t *testing.T) {
+	env := DefaultEnvironment("3.10.12")
+	cases := map[string]bool{
+		`python_version >= "3.8"`:                                  true,
+		`python_version < "3.10"`:                                  false,
+		`python_version > "3.9"`:                                   true,
+		`python_full_version ~= "3.10.0"`:                          true,
+		`"3.11" > python_version`:                                  true,
+		`sys_platform == "linux" and platform_machine == "x86_64"`: true,
+		`sys_platform == "win32" or os_name == "posix"`:            true,
+		`platform_system != "Windows"`:                             true,
+		`"linux" in sys_platform`:                                  true,
+		`sys_platform not in "win32 cygwin"`:                       true,
+		`implementation_name == "pypy"`:                            false,
+		`platform_release < "abc"`:                                 false,
+		`extra == "test"`:                                          false,
+	}
+	for expr, want := range cases {
+		t.Run(expr, func(t *testing.T) {
+			m, err := ParseMarker(expr)
+			require.NoError(t, err)
+			assert.Equal(t, want, m.Evaluate(env))
+		})
+	}
+
+	t.Run("extra名称规范化", func(t *testing.T) {
+		m, err := ParseMarker(`extra == "Dev_Tools"`)
+		require.NoError(t, err)
+		assert.True(t, m.Evaluate(env.WithExtra("dev-tools")))
+		assert.Empty(t, env["extra"], "WithExtra不应修改原环境")
+	})
+}
@@ -0,0 +1,192 @@
+package requirement
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/version"
+)
+
+// ErrInvalidRequirement 表示字符串不是有效的PEP 508依赖声明
+var ErrInvalidRequirement = errors.New("无效的依赖声明")
+
+// namePattern PEP 508中的包名，以字母或数字开头和结尾
+var namePattern = regexp.MustCompile(`^([A-Za-z0-9](?:[A-Za-z0-9._-]*[A-Za-z0-9])?)\s*`)
+
+// Requirement 表示一条PEP 508依赖声明，如 `requests[socks]>=2.8.1; python_version < "3.8"`
+type Requirement struct {
+	// Name 声明中的包名（原始写法）
+	Name string
+
+	// Extras 请求的可选功能，如 "requests[socks]" 中的socks
+	Extras []string
+
+	// Specifier 版本约束，没有约束时为空
+	Specifier version.SpecifierSet
+
+	// URL 直接引用的地址，如 "pkg @ https://example.com/pkg.whl"
+	URL string
+
+	// Marker 环境标记，没有标记时为nil
+	Marker *Marker
+}
+
+// Parse 解析PEP 508依赖声明，也接受PyPI元数据中旧式的带括号约束（如 "six (>=1.10)"）
+//
+// 参数:
+//   - s: 依赖声明字符串，通常来自PackageInfo.RequiresDist
+//
+// 返回值:
+//   - *Requirement: 解析后的依赖声明
+//   - error: 格式无效时返回，错误包装了ErrInvalidRequirement
+//
+// 使用示例:
+//
+//	req, err := requirement.Parse(`urllib3<3,>=1.21.1; extra == "socks"`)
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(req.NormalizedName(), req.Specifier) // urllib3 <3,>=1.21.1
+func Parse(s string) (*Requirement, error) {
+	input := strings.TrimSpace(s)
+	m := namePattern.FindStringSubmatch(input)
+	if m == nil {
+		return nil, fmt.Errorf("%w: 缺少包名: %q", ErrInvalidRequirement, s)
+	}
+	req := &Requirement{Name: m[1]}
+	rest := input[len(m[0]):]
+
+	if strings.HasPrefix(rest, "[") {
+		end := strings.IndexByte(rest, ']')
+		if end < 0 {
+			return nil, fmt.Errorf("%w: extras未闭合: %q", ErrInvalidRequirement, s)
+		}
+		for _, extra := range strings.Split(rest[1:end], ",") {
+			if extra = strings.TrimSpace(extra); extra == "" {
+				continue
+			}
+			if !namePattern.MatchString(extra) || namePattern.FindString(extra) != extra {
+				return nil, fmt.Errorf("%w: 无效的extra %q: %q", ErrInvalidRequirement, extra, s)
+			}
+			req.Extras = append(req.Extras, extra)
+		}
+		rest = strings.TrimSpace(rest[end+1:])
+	}
+
+	var marker string
+	if strings.HasPrefix(rest, "@") {
+		// URL中可能含有 ";"，按规范URL之后的标记必须以空白加分号分隔
+		rest = strings.TrimSpace(rest[1:])
+		url := rest
+		if i := strings.Index(rest, " ;"); i >= 0 {
+			url, marker = rest[:i], strings.TrimSpace(rest[i+2:])
+		} else if i := strings.Index(rest, "\t;"); i >= 0 {
+			url, marker = rest[:i], strings.TrimSpace(rest[i+2:])
+		}
+		if url = strings.TrimSpace(url); url == "" {
+			return nil, fmt.Errorf("%w: 缺少URL: %q", ErrInvalidRequirement, s)
+		}
+		req.URL = url
+	} else {
+		spec := rest
+		if i := strings.IndexByte(rest, ';'); i >= 0 {
+			spec, marker = rest[:i], strings.TrimSpace(rest[i+1:])
+		}
+		spec = strings.TrimSpace(spec)
+		if strings.HasPrefix(spec, "(") {
+			if !strings.HasSuffix(spec, ")") {
+				return nil, fmt.Errorf("%w: 版本约束括号未闭合: %q", ErrInvalidRequirement, s)
+			}
+			spec = spec[1 : len(spec)-1]
+		}
+		set, err := version.ParseSpecifierSet(spec)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRequirement, err)
+		}
+		req.Specifier = set
+	}
+
+	if marker != "" {
+		parsed, err := ParseMarker(marker)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRequirement, err)
+		}
+		req.Marker = parsed
+	} else if strings.HasSuffix(strings.TrimSpace(rest), ";") {
+		return nil, fmt.Errorf("%w: 分号后缺少环境标记: %q", ErrInvalidRequirement, s)
+	}
+	return req, nil
+}
+
+// MustParse 解析依赖声明，格式无效时panic，仅用于常量
+func MustParse(s string) *Requirement {
+	req, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return req
+}
+
+// ParseAll 解析多条依赖声明，跳过无法解析的条目并一并返回它们的错误
+//
+// 参数:
+//   - lines: 依赖声明列表，如PackageInfo.RequiresDist
+//
+// 返回值:
+//   - []*Requirement: 成功解析的依赖
+//   - []error: 每条无法解析的声明对应的错误
+func ParseAll(lines []string) ([]*Requirement, []error) {
+	var reqs []*Requirement
+	var errs []error
+	for _, line := range lines {
+		req, err := Parse(line)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, errs
+}
+
+// NormalizedName 返回按PEP 503规范化的包名
+func (r *Requirement) NormalizedName() string {
+	return models.NormalizeName(r.Name)
+}
+
+// String 返回依赖声明的规范写法
+func (r *Requirement) String() string {
+	var b strings.Builder
+	b.WriteString(r.Name)
+	if len(r.Extras) > 0 {
+		extras := append([]string(nil), r.Extras...)
+		sort.Strings(extras)
+		b.WriteString("[" + strings.Join(extras, ",") + "]")
+	}
+	if r.URL != "" {
+		b.WriteString(" @ " + r.URL)
+		if r.Marker != nil {
+			b.WriteString(" ")
+		}
+	} else {
+		b.WriteString(r.Specifier.String())
+	}
+	if r.Marker != nil {
+		b.WriteString("; " + r.Marker.String())
+	}
+	return b.String()
+}
+
+// AppliesTo 检查依赖在给定环境下是否生效，没有环境标记的依赖总是生效
+func (r *Requirement) AppliesTo(env Environment) bool {
+	return r.Marker == nil || r.Marker.Evaluate(env)
+}
+
+// IsOptional 检查依赖是否只在安装某个extra时才需要
+func (r *Requirement) IsOptional() bool {
+	return r.Marker != nil && len(r.Marker.Extras()) > 0
+}
@@ -0,0 +1,66 @@
+package requirement
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("版本约束与extras", func(t *testing.T) {
+		req, err := Parse(`Requests[socks, security] >=2.8.1, ==2.8.*`)
+		require.NoError(t, err)
+		assert.Equal(t, "Requests", req.Name)
+		assert.Equal(t, "requests", req.NormalizedName())
+		assert.Equal(t, []string{"socks", "security"}, req.Extras)
+		assert.Len(t, req.Specifier, 2)
+		assert.Nil(t, req.Marker)
+		assert.Equal(t, "Requests[security,socks]==2.8.*,>=2.8.1", req.String())
+	})
+
+	t.Run("旧式括号约束", func(t *testing.T) {
+		req, err := Parse("six (>=1.10)")
+		require.NoError(t, err)
+		assert.Equal(t, ">=1.10", req.Specifier.String())
+	})
+
+	t.Run("环境标记", func(t *testing.T) {
+		req, err := Parse(`PySocks!=1.5.7,>=1.5.6; extra == "socks"`)
+		require.NoError(t, err)
+		require.NotNil(t, req.Marker)
+		assert.True(t, req.IsOptional())
+		assert.Equal(t, `PySocks!=1.5.7,>=1.5.6; extra == "socks"`, req.String())
+	})
+
+	t.Run("URL引用", func(t *testing.T) {
+		req, err := Parse(`pip @ https://example.com/pip-23.0.zip;v=1 ; python_version >= "3.7"`)
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/pip-23.0.zip;v=1", req.URL)
+		assert.Empty(t, req.Specifier)
+		require.NotNil(t, req.Marker)
+		assert.Equal(t, `pip @ https://example.com/pip-23.0.zip;v=1 ; python_version >= "3.7"`, req.String())
+	})
+
+	t.Run("无效的声明", func(t *testing.T) {
+		for _, s := range []string{"", ">=1.0", "pkg[extra", "pkg >=", "pkg @", "pkg; ", `pkg; os_name ==`, "pkg (>=1.0"} {
+			_, err := Parse(s)
+			assert.ErrorIs(t, err, ErrInvalidRequirement, s)
+		}
+	})
+
+	t.Run("批量解析", func(t *testing.T) {
+		reqs, errs := ParseAll([]string{"idna<4,>=2.5", "not valid !!", "certifi>=2017.4.17"})
+		assert.Len(t, reqs, 2)
+		assert.Len(t, errs, 1)
+	})
+}
+
+func TestAppliesTo(t *testing.T) {
+	env := DefaultEnvironment("3.11.4")
+	assert.True(t, MustParse("idna").AppliesTo(env))
+	assert.False(t, MustParse(`importlib-metadata; python_version < "3.8"`).AppliesTo(env))
+	assert.False(t, MustParse(`PySocks; extra == "socks"`).AppliesTo(env))
+	assert.True(t, MustParse(`PySocks; extra == "Socks"`).AppliesTo(env.WithExtra("socks")))
+	assert.False(t, MustParse(`pywin32; sys_platform == "win32"`).IsOptional())
+}
@@ -0,0 +1,376 @@
+package sbom
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/license"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+)
+
+// CycloneDXSpecVersion 生成的CycloneDX规范版本
+const CycloneDXSpecVersion = "1.5"
+
+// CycloneDXBOM CycloneDX 1.5 JSON文档
+type CycloneDXBOM struct {
+	BOMFormat       string                   `json:"bomFormat"`
+	SpecVersion     string                   `json:"specVersion"`
+	SerialNumber    string                   `json:"serialNumber,omitempty"`
+	Version         int                      `json:"version"`
+	Metadata        *CycloneDXMetadata       `json:"metadata,omitempty"`
+	Components      []CycloneDXComponent     `json:"components"`
+	Dependencies    []CycloneDXDependency    `json:"dependencies,omitempty"`
+	Vulnerabilities []CycloneDXVulnerability `json:"vulnerabilities,omitempty"`
+}
+
+// CycloneDXMetadata 文档元数据
+type CycloneDXMetadata struct {
+	Timestamp string          `json:"timestamp,omitempty"`
+	Tools     *CycloneDXTools `json:"tools,omitempty"`
+}
+
+// CycloneDXTools 生成文档的工具
+type CycloneDXTools struct {
+	Components []CycloneDXComponent `json:"components"`
+}
+
+// CycloneDXComponent 文档中的组件
+type CycloneDXComponent struct {
+	Type               string                       `json:"type"`
+	BOMRef             string                       `json:"bom-ref,omitempty"`
+	Name               string                       `json:"name"`
+	Version            string                       `json:"version,omitempty"`
+	Description        string                       `json:"description,omitempty"`
+	Author             string                       `json:"author,omitempty"`
+	Supplier           *CycloneDXOrganization       `json:"supplier,omitempty"`
+	Hashes             []CycloneDXHash              `json:"hashes,omitempty"`
+	Licenses           []CycloneDXLicenseChoice     `json:"licenses,omitempty"`
+	PURL               string                       `json:"purl,omitempty"`
+	ExternalReferences []CycloneDXExternalReference `json:"externalReferences,omitempty"`
+}
+
+// CycloneDXOrganization 组织或个人
+type CycloneDXOrganization struct {
+	Name    string             `json:"name,omitempty"`
+	URL     []string           `json:"url,omitempty"`
+	Contact []CycloneDXContact `json:"contact,omitempty"`
+}
+
+// CycloneDXContact 联系人
+type CycloneDXContact struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+// CycloneDXHash 哈希值
+type CycloneDXHash struct {
+	Algorithm string `json:"alg"`
+	Content   string `json:"content"`
+}
+
+// CycloneDXLicenseChoice 许可证，License和Expression二选一
+type CycloneDXLicenseChoice struct {
+	License    *CycloneDXLicense `json:"license,omitempty"`
+	Expression string            `json:"expression,omitempty"`
+}
+
+// CycloneDXLicense 单个许可证，ID为SPDX标识符，无法识别时使用Name
+type CycloneDXLicense struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// CycloneDXExternalReference 外部引用
+type CycloneDXExternalReference struct {
+	Type    string          `json:"type"`
+	URL     string          `json:"url"`
+	Comment string          `json:"comment,omitempty"`
+	Hashes  []CycloneDXHash `json:"hashes,omitempty"`
+}
+
+// CycloneDXDependency 组件的依赖关系
+type CycloneDXDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// CycloneDXVulnerability VEX中的漏洞条目
+type CycloneDXVulnerability struct {
+	BOMRef         string               `json:"bom-ref,omitempty"`
+	ID             string               `json:"id"`
+	Source         *CycloneDXSource     `json:"source,omitempty"`
+	References     []CycloneDXReference `json:"references,omitempty"`
+	Description    string               `json:"description,omitempty"`
+	Detail         string               `json:"detail,omitempty"`
+	Recommendation string               `json:"recommendation,omitempty"`
+	Advisories     []CycloneDXAdvisory  `json:"advisories,omitempty"`
+	Affects        []CycloneDXAffect    `json:"affects"`
+}
+
+// CycloneDXSource 漏洞信息来源
+type CycloneDXSource struct {
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+// CycloneDXReference 同一漏洞在其他数据库中的标识
+type CycloneDXReference struct {
+	ID     string          `json:"id"`
+	Source CycloneDXSource `json:"source"`
+}
+
+// CycloneDXAdvisory 漏洞公告
+type CycloneDXAdvisory struct {
+	URL string `json:"url"`
+}
+
+// CycloneDXAffect 受漏洞影响的组件
+type CycloneDXAffect struct {
+	Ref      string                   `json:"ref"`
+	Versions []CycloneDXAffectVersion `json:"versions,omitempty"`
+}
+
+// CycloneDXAffectVersion 受影响的版本及状态
+type CycloneDXAffectVersion struct {
+	Version string `json:"version"`
+	Status  string `json:"status"`
+}
+
+// cycloneDXHashAlgorithms PyPI摘要算法到CycloneDX算法名的映射
+var cycloneDXHashAlgorithms = map[string]string{
+	"md5":         "MD5",
+	"sha256":      "SHA-256",
+	"blake2b_256": "BLAKE2b-256",
+}
+
+// NewCycloneDX 将清单转换为CycloneDX 1.5文档，漏洞写入vulnerabilities（VEX）部分
+//
+// 参数:
+//   - inv: Generator.Collect返回的清单
+//
+// 返回值:
+//   - *CycloneDXBOM: 可直接编码为JSON的文档
+func NewCycloneDX(inv *Inventory) *CycloneDXBOM {
+	bom := &CycloneDXBOM{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: CycloneDXSpecVersion,
+		Version:     1,
+		Metadata: &CycloneDXMetadata{
+			Tools: &CycloneDXTools{Components: []CycloneDXComponent{{Type: "application", Name: ToolName}}},
+		},
+		Components: []CycloneDXComponent{},
+	}
+	if inv.SerialNumber != "" {
+		bom.SerialNumber = "urn:uuid:" + inv.SerialNumber
+	}
+	if !inv.Timestamp.IsZero() {
+		bom.Metadata.Timestamp = inv.Timestamp.UTC().Format(time.RFC3339)
+	}
+
+	for _, c := range inv.Components {
+		bom.Components = append(bom.Components, cycloneDXComponent(c))
+
+		dependsOn := make([]string, 0, len(c.Dependencies))
+		for _, dep := range c.Dependencies {
+			if d := inv.Component(dep); d != nil {
+				dependsOn = append(dependsOn, d.BOMRef())
+			}
+		}
+		bom.Dependencies = append(bom.Dependencies, CycloneDXDependency{Ref: c.BOMRef(), DependsOn: dependsOn})
+	}
+	bom.Vulnerabilities = cycloneDXVulnerabilities(inv)
+	return bom
+}
+
+// WriteCycloneDX 将清单以CycloneDX 1.5 JSON格式写入w
+func (inv *Inventory) WriteCycloneDX(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(NewCycloneDX(inv))
+}
+
+func cycloneDXComponent(c *Component) CycloneDXComponent {
+	info := c.Info()
+	comp := CycloneDXComponent{
+		Type:        "library",
+		BOMRef:      c.BOMRef(),
+		Name:        c.Name(),
+		Version:     c.Version(),
+		Description: info.Summary,
+		Author:      person(info.Author, info.AuthorEmail),
+		Licenses:    cycloneDXLicenses(c.License),
+		PURL:        c.PURL(),
+	}
+	if supplier := supplierOf(info); supplier.Name != "" || len(supplier.Contact) > 0 {
+		comp.Supplier = &supplier
+	}
+	if f := c.PrimaryFile(); f != nil {
+		comp.Hashes = cycloneDXHashes(f.Digests)
+	}
+
+	comp.ExternalReferences = projectReferences(info)
+	for _, f := range c.Files() {
+		comp.ExternalReferences = append(comp.ExternalReferences, CycloneDXExternalReference{
+			Type:    "distribution",
+			URL:     f.URL,
+			Comment: f.Filename,
+			Hashes:  cycloneDXHashes(f.Digests),
+		})
+	}
+	return comp
+}
+
+// supplierOf 以维护者作为供应商，没有维护者时使用作者
+func supplierOf(info *models.PackageInfo) CycloneDXOrganization {
+	name, email := info.Maintainer, info.MaintainerEmail
+	if name == "" && email == "" {
+		name, email = info.Author, info.AuthorEmail
+	}
+	org := CycloneDXOrganization{Name: name}
+	if email != "" {
+		org.Contact = []CycloneDXContact{{Email: email}}
+	}
+	if info.HomePage != "" {
+		org.URL = []string{info.HomePage}
+	}
+	return org
+}
+
+// projectReferences 将项目主页、代码仓库等链接转换为外部引用
+func projectReferences(info *models.PackageInfo) []CycloneDXExternalReference {
+	var refs []CycloneDXExternalReference
+	if info.HomePage != "" {
+		refs = append(refs, CycloneDXExternalReference{Type: "website", URL: info.HomePage})
+	}
+	urls := info.GetProjectURLs()
+	labels := make([]string, 0, len(urls))
+	for label := range urls {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		url := urls[label]
+		if url == "" || url == info.HomePage {
+			continue
+		}
+		refs = append(refs, CycloneDXExternalReference{Type: referenceType(label, url), URL: url, Comment: label})
+	}
+	return refs
+}
+
+// referenceType 根据project_urls的标签推断CycloneDX外部引用类型
+func referenceType(label, url string) string {
+	key := strings.ToLower(label)
+	switch {
+	case strings.Contains(key, "source") || strings.Contains(key, "repository") || strings.Contains(key, "code") ||
+		strings.Contains(url, "github.com") || strings.Contains(url, "gitlab.com"):
+		return "vcs"
+	case strings.Contains(key, "issue") || strings.Contains(key, "tracker") || strings.Contains(key, "bug"):
+		return "issue-tracker"
+	case strings.Contains(key, "doc"):
+		return "documentation"
+	case strings.Contains(key, "changelog") || strings.Contains(key, "release"):
+		return "release-notes"
+	case strings.Contains(key, "home"):
+		return "website"
+	}
+	return "other"
+}
+
+func cycloneDXHashes(digests models.ReleaseDigests) []CycloneDXHash {
+	var hashes []CycloneDXHash
+	for _, alg := range []string{"sha256", "blake2b_256", "md5"} {
+		if content := digests.Get(alg); content != "" {
+			hashes = append(hashes, CycloneDXHash{Algorithm: cycloneDXHashAlgorithms[alg], Content: content})
+		}
+	}
+	return hashes
+}
+
+// cycloneDXLicenses 单个SPDX许可证使用license.id，组合表达式使用expression，无法识别时保留原始名称
+func cycloneDXLicenses(result *license.Result) []CycloneDXLicenseChoice {
+	if result == nil {
+		return nil
+	}
+	if !result.Resolved() {
+		if result.Input == "" {
+			return nil
+		}
+		return []CycloneDXLicenseChoice{{License: &CycloneDXLicense{Name: result.Input}}}
+	}
+	expr := result.Expression
+	if expr.IsLeaf() && !expr.OrLater && expr.Exception == "" && !isLicenseRef(expr.License) {
+		return []CycloneDXLicenseChoice{{License: &CycloneDXLicense{ID: expr.License}}}
+	}
+	return []CycloneDXLicenseChoice{{Expression: expr.String()}}
+}
+
+// cycloneDXVulnerabilities 按漏洞ID合并各组件的漏洞，每个漏洞只出现一次并列出所有受影响的组件
+func cycloneDXVulnerabilities(inv *Inventory) []CycloneDXVulnerability {
+	index := map[string]int{}
+	var vulns []CycloneDXVulnerability
+	for _, c := range inv.Components {
+		for _, v := range c.Vulnerabilities {
+			affect := CycloneDXAffect{
+				Ref:      c.BOMRef(),
+				Versions: []CycloneDXAffectVersion{{Version: c.Version(), Status: "affected"}},
+			}
+			if i, ok := index[v.ID]; ok {
+				vulns[i].Affects = append(vulns[i].Affects, affect)
+				continue
+			}
+			index[v.ID] = len(vulns)
+			vulns = append(vulns, cycloneDXVulnerability(v, affect))
+		}
+	}
+	return vulns
+}
+
+func cycloneDXVulnerability(v models.Vulnerability, affect CycloneDXAffect) CycloneDXVulnerability {
+	vuln := CycloneDXVulnerability{
+		BOMRef:      "vuln:" + v.ID,
+		ID:          v.ID,
+		Source:      &CycloneDXSource{Name: v.Source, URL: v.Link},
+		Description: v.Summary,
+		Detail:      v.Details,
+		Affects:     []CycloneDXAffect{affect},
+	}
+	if vuln.Source.Name == "" {
+		vuln.Source.Name = "OSV"
+	}
+	if vuln.Description == "" {
+		vuln.Description = v.Details
+		vuln.Detail = ""
+	}
+	for _, alias := range v.Aliases {
+		vuln.References = append(vuln.References, CycloneDXReference{
+			ID:     alias,
+			Source: CycloneDXSource{Name: "OSV", URL: "https://osv.dev/vulnerability/" + alias},
+		})
+	}
+	if v.Link != "" {
+		vuln.Advisories = []CycloneDXAdvisory{{URL: v.Link}}
+	}
+	if len(v.FixedIn) > 0 {
+		vuln.Recommendation = "Upgrade to one of: " + strings.Join(v.FixedIn, ", ")
+	}
+	return vuln
+}
+
+// person 组合姓名和邮箱，如 "Jane Doe <jane@example.com>"
+func person(name, email string) string {
+	switch {
+	case name != "" && email != "" && !strings.Contains(email, "<"):
+		return name + " <" + email + ">"
+	case email != "":
+		return email
+	}
+	return name
+}
+
+func isLicenseRef(id string) bool {
+	return strings.HasPrefix(id, "LicenseRef-") || strings.HasPrefix(id, "DocumentRef-")
+}
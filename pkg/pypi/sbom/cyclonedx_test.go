@@ -0,0 +1,73 @@
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCycloneDX(t *testing.T) {
+	bom := NewCycloneDX(collect(t))
+	assert.Equal(t, "CycloneDX", bom.BOMFormat)
+	assert.Equal(t, "1.5", bom.SpecVersion)
+	assert.Equal(t, "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79", bom.SerialNumber)
+	assert.Equal(t, "2024-05-01T12:00:00Z", bom.Metadata.Timestamp)
+	require.Len(t, bom.Components, 3)
+
+	requests := bom.Components[2]
+	assert.Equal(t, "pkg:pypi/requests@2.31.0", requests.PURL)
+	assert.Equal(t, requests.PURL, requests.BOMRef)
+	assert.Equal(t, "Kenneth Reitz <me@kennethreitz.org>", requests.Author)
+	require.NotNil(t, requests.Supplier)
+	assert.Equal(t, "Kenneth Reitz", requests.Supplier.Name)
+	assert.Equal(t, []CycloneDXHash{{Algorithm: "SHA-256", Content: "cccc"}}, requests.Hashes)
+	assert.Equal(t, []CycloneDXLicenseChoice{{License: &CycloneDXLicense{ID: "Apache-2.0"}}}, requests.Licenses)
+
+	var types []string
+	for _, ref := range requests.ExternalReferences {
+		types = append(types, ref.Type)
+	}
+	assert.Equal(t, []string{"website", "vcs", "distribution", "distribution"}, types)
+	assert.Equal(t, []CycloneDXHash{{Algorithm: "SHA-256", Content: "aaaa"}, {Algorithm: "MD5", Content: "bbbb"}},
+		requests.ExternalReferences[2].Hashes)
+
+	idna := bom.Components[0]
+	assert.Equal(t, "Kim Davies", idna.Supplier.Name)
+	assert.Equal(t, "kim@cynosure.com.au", idna.Supplier.Contact[0].Email)
+
+	pysocks := bom.Components[1]
+	assert.Nil(t, pysocks.Supplier)
+	assert.Equal(t, []CycloneDXLicenseChoice{{License: &CycloneDXLicense{ID: "BSD-3-Clause"}}}, pysocks.Licenses)
+
+	t.Run("依赖关系", func(t *testing.T) {
+		require.Len(t, bom.Dependencies, 3)
+		assert.Equal(t, "pkg:pypi/requests@2.31.0", bom.Dependencies[2].Ref)
+		assert.Equal(t, []string{"pkg:pypi/idna@3.4"}, bom.Dependencies[2].DependsOn)
+		assert.Empty(t, bom.Dependencies[0].DependsOn)
+	})
+
+	t.Run("漏洞", func(t *testing.T) {
+		require.Len(t, bom.Vulnerabilities, 1)
+		vuln := bom.Vulnerabilities[0]
+		assert.Equal(t, "GHSA-9wx4-h78v-vm56", vuln.ID)
+		assert.Equal(t, "osv", vuln.Source.Name)
+		assert.Equal(t, "CVE-2024-35195", vuln.References[0].ID)
+		assert.Equal(t, "Upgrade to one of: 2.32.0", vuln.Recommendation)
+		require.Len(t, vuln.Affects, 1)
+		assert.Equal(t, "pkg:pypi/requests@2.31.0", vuln.Affects[0].Ref)
+		assert.Equal(t, "affected", vuln.Affects[0].Versions[0].Status)
+	})
+
+	t.Run("JSON输出", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, collect(t).WriteCycloneDX(&buf))
+		var decoded map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+		assert.Equal(t, "1.5", decoded["specVersion"])
+		assert.Contains(t, decoded, "vulnerabilities")
+		assert.Contains(t, buf.String(), `"bom-ref": "pkg:pypi/idna@3.4"`)
+	})
+}
@@ -0,0 +1,315 @@
+package sbom
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/api"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/license"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/requirement"
+)
+
+// ToolName 写入SBOM文档的生成工具名称
+const ToolName = "pypi-crawler"
+
+// DefaultConcurrency 默认的并发请求数
+const DefaultConcurrency = 4
+
+// Component 表示SBOM中的一个Python包
+type Component struct {
+	// Pin 输入的版本固定
+	Pin models.Pin
+
+	// Package 从PyPI获取的该版本的包信息
+	Package *models.Package
+
+	// License 规范化后的许可证
+	License *license.Result
+
+	// Vulnerabilities 该版本的已知漏洞，不包含已撤回的漏洞
+	Vulnerabilities []models.Vulnerability
+
+	// Dependencies 该包在本清单中的直接依赖（规范化包名，已排序）
+	Dependencies []string
+}
+
+// Name 返回PyPI上的包名，没有包信息时返回输入的包名
+func (c *Component) Name() string {
+	if c.Package != nil && c.Package.Info != nil && c.Package.Info.Name != "" {
+		return c.Package.Info.Name
+	}
+	return c.Pin.Name
+}
+
+// Version 返回包的版本号
+func (c *Component) Version() string {
+	return c.Pin.Version
+}
+
+// Info 返回包的元数据，没有时返回空的元数据以避免nil检查
+func (c *Component) Info() *models.PackageInfo {
+	if c.Package != nil && c.Package.Info != nil {
+		return c.Package.Info
+	}
+	return &models.PackageInfo{}
+}
+
+// BOMRef 返回组件在文档中的引用标识，与PURL相同
+func (c *Component) BOMRef() string {
+	return c.PURL()
+}
+
+// PURL 返回组件的Package URL，如 "pkg:pypi/flask-login@0.6.3"
+// 非默认索引的包带有repository_url限定符，直接引用的包带有vcs_url或download_url限定符
+func (c *Component) PURL() string {
+	purl := PURL(c.Pin.Name, c.Pin.Version)
+	switch {
+	case c.Pin.URL != "" && strings.HasPrefix(c.Pin.URL, "git+"):
+		purl += "?vcs_url=" + url.QueryEscape(c.Pin.URL)
+	case c.Pin.URL != "":
+		purl += "?download_url=" + url.QueryEscape(c.Pin.URL)
+	case c.Pin.Index != "":
+		purl += "?repository_url=" + url.QueryEscape(c.Pin.Index)
+	}
+	return purl
+}
+
+// Files 返回该版本的所有发布文件
+func (c *Component) Files() []*models.ReleaseFile {
+	if c.Package == nil {
+		return nil
+	}
+	if len(c.Package.Urls) > 0 {
+		return c.Package.Urls
+	}
+	return c.Package.Releases[c.Pin.Version]
+}
+
+// PrimaryFile 返回代表该版本的发布文件，优先选择源码包，没有文件时返回nil
+// 组件级别的哈希值和下载地址取自该文件
+func (c *Component) PrimaryFile() *models.ReleaseFile {
+	files := c.Files()
+	for _, f := range files {
+		if f.PackageType == "sdist" {
+			return f
+		}
+	}
+	if len(files) > 0 {
+		return files[0]
+	}
+	return nil
+}
+
+// PURL 按Package URL规范生成PyPI包的purl，包名按PEP 503规范化
+//
+// 使用示例:
+//
+//	sbom.PURL("Flask_Login", "0.6.3") // "pkg:pypi/flask-login@0.6.3"
+func PURL(name, version string) string {
+	purl := "pkg:pypi/" + purlEscape(models.NormalizeName(name))
+	if version != "" {
+		purl += "@" + purlEscape(version)
+	}
+	return purl
+}
+
+// purlEscape 对purl中的名称和版本进行百分号编码
+func purlEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '.' || c == '-' || c == '_' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// Inventory 生成SBOM所需的全部数据，可以输出为CycloneDX或SPDX文档
+type Inventory struct {
+	// Name 文档名称
+	Name string
+
+	// SerialNumber 文档的唯一标识（UUID）
+	SerialNumber string
+
+	// Timestamp 生成时间
+	Timestamp time.Time
+
+	// Components 清单中的包，按规范化包名排序
+	Components []*Component
+}
+
+// Component 按包名查找组件，包名不区分大小写和分隔符
+func (inv *Inventory) Component(name string) *Component {
+	normalized := models.NormalizeName(name)
+	for _, c := range inv.Components {
+		if c.Pin.NormalizedName() == normalized {
+			return c
+		}
+	}
+	return nil
+}
+
+// Vulnerabilities 返回所有组件的漏洞总数
+func (inv *Inventory) Vulnerabilities() int {
+	n := 0
+	for _, c := range inv.Components {
+		n += len(c.Vulnerabilities)
+	}
+	return n
+}
+
+// Generator 通过PyPI客户端收集生成SBOM所需的数据
+type Generator struct {
+	client          api.PyPIClient
+	concurrency     int
+	vulnerabilities bool
+}
+
+// NewGenerator 创建SBOM生成器，默认并发数为DefaultConcurrency，并查询漏洞信息
+//
+// 参数:
+//   - client: PyPI客户端，可以是官方源或任意镜像源
+//
+// 使用示例:
+//
+//	gen := sbom.NewGenerator(mirrors.NewOfficialClient())
+//	inv, err := gen.Collect(ctx, pins)
+//	if err != nil {
+//		return err
+//	}
+//	return inv.WriteCycloneDX(os.Stdout)
+func NewGenerator(client api.PyPIClient) *Generator {
+	return &Generator{client: client, concurrency: DefaultConcurrency, vulnerabilities: true}
+}
+
+// WithConcurrency 设置并发请求数，小于1时按1处理
+func (g *Generator) WithConcurrency(n int) *Generator {
+	if n < 1 {
+		n = 1
+	}
+	g.concurrency = n
+	return g
+}
+
+// WithVulnerabilities 设置是否查询漏洞信息
+func (g *Generator) WithVulnerabilities(enabled bool) *Generator {
+	g.vulnerabilities = enabled
+	return g
+}
+
+// Collect 并发获取每个固定版本的包信息、许可证和漏洞，并计算清单内的依赖关系
+//
+// 参数:
+//   - ctx: 上下文，用于控制请求的生命周期
+//   - pins: 要包含在SBOM中的包及版本，同一个包只能出现一次
+//
+// 返回值:
+//   - *Inventory: 收集到的数据
+//   - error: 任一包获取失败或包重复时返回，SBOM要求完整因此不会返回部分结果
+func (g *Generator) Collect(ctx context.Context, pins []models.Pin) (*Inventory, error) {
+	seen := make(map[string]bool, len(pins))
+	for _, pin := range pins {
+		if seen[pin.NormalizedName()] {
+			return nil, fmt.Errorf("包 %s 重复出现", pin.Name)
+		}
+		seen[pin.NormalizedName()] = true
+	}
+
+	components := make([]*Component, len(pins))
+	errs := make([]error, len(pins))
+	sem := make(chan struct{}, g.concurrency)
+	var wg sync.WaitGroup
+	for i, pin := range pins {
+		wg.Add(1)
+		go func(i int, pin models.Pin) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			components[i], errs[i] = g.collect(ctx, pin)
+		}(i, pin)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(components, func(i, j int) bool {
+		return components[i].Pin.NormalizedName() < components[j].Pin.NormalizedName()
+	})
+	for _, c := range components {
+		c.Dependencies = dependencies(c, seen)
+	}
+
+	return &Inventory{
+		Name:         "python-dependencies",
+		SerialNumber: newUUID(),
+		Timestamp:    time.Now().UTC(),
+		Components:   components,
+	}, nil
+}
+
+// collect 获取单个包的数据，直接引用的包不在索引中，只保留锁文件中的信息
+func (g *Generator) collect(ctx context.Context, pin models.Pin) (*Component, error) {
+	if pin.IsDirect() {
+		return &Component{Pin: pin}, nil
+	}
+	pkg, err := g.client.GetPackageVersion(ctx, pin.Name, pin.Version)
+	if err != nil {
+		return nil, fmt.Errorf("获取 %s 失败: %w", pin, err)
+	}
+	c := &Component{Pin: pin, Package: pkg}
+	c.License = license.Normalize(c.Info())
+
+	if g.vulnerabilities {
+		vulns, err := g.client.CheckPackageVulnerabilities(ctx, pin.Name, pin.Version)
+		if err != nil {
+			return nil, fmt.Errorf("查询 %s 的漏洞失败: %w", pin, err)
+		}
+		for _, v := range vulns {
+			if !v.IsWithdrawn() {
+				c.Vulnerabilities = append(c.Vulnerabilities, v)
+			}
+		}
+	}
+	return c, nil
+}
+
+// dependencies 从RequiresDist中找出清单内的直接依赖
+// 只在某个extra下才需要的依赖无法确定是否被安装，因此不计入
+func dependencies(c *Component, present map[string]bool) []string {
+	reqs, _ := requirement.ParseAll(c.Info().RequiresDist)
+	seen := map[string]bool{}
+	var deps []string
+	for _, req := range reqs {
+		name := req.NormalizedName()
+		if req.IsOptional() || !present[name] || seen[name] || name == c.Pin.NormalizedName() {
+			continue
+		}
+		seen[name] = true
+		deps = append(deps, name)
+	}
+	sort.Strings(deps)
+	return deps
+}
+
+// newUUID 生成随机的第4版UUID
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
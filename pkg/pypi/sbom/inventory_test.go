@@ -0,0 +1,180 @@
+package sbom
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/api"
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient 只实现GetPackageVersion和CheckPackageVulnerabilities，按"包名@版本"返回内存中的数据
+type fakeClient struct {
+	api.PyPIClient
+
+	packages map[string]*models.Package
+	vulns    map[string][]models.Vulnerability
+}
+
+func (f *fakeClient) GetPackageVersion(ctx context.Context, name, version string) (*models.Package, error) {
+	pkg, ok := f.packages[name+"@"+version]
+	if !ok {
+		return nil, errors.New("包不存在")
+	}
+	return pkg, nil
+}
+
+func (f *fakeClient) CheckPackageVulnerabilities(ctx context.Context, name, version string) ([]models.Vulnerability, error) {
+	return f.vulns[name+"@"+version], nil
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		packages: map[string]*models.Package{
+			"requests@2.31.0": {
+				Info: &models.PackageInfo{
+					Name:        "requests",
+					Version:     "2.31.0",
+					Summary:     "Python HTTP for Humans.",
+					Author:      "Kenneth Reitz",
+					AuthorEmail: "me@kennethreitz.org",
+					License:     "Apache 2.0",
+					HomePage:    "https://requests.readthedocs.io",
+					ProjectURLs: map[string]string{"Source": "https://github.com/psf/requests"},
+					RequiresDist: []string{
+						"charset-normalizer<4,>=2",
+						"idna<4,>=2.5",
+						"urllib3<3,>=1.21.1",
+						`PySocks!=1.5.7,>=1.5.6; extra == "socks"`,
+					},
+				},
+				Urls: []*models.ReleaseFile{
+					{
+						Filename:    "requests-2.31.0-py3-none-any.whl",
+						URL:         "https://files.pythonhosted.org/packages/requests-2.31.0-py3-none-any.whl",
+						PackageType: "bdist_wheel",
+						Digests:     models.ReleaseDigests{SHA256: "aaaa", MD5: "bbbb"},
+					},
+					{
+						Filename:    "requests-2.31.0.tar.gz",
+						URL:         "https://files.pythonhosted.org/packages/requests-2.31.0.tar.gz",
+						PackageType: "sdist",
+						Digests:     models.ReleaseDigests{SHA256: "cccc"},
+					},
+				},
+			},
+			"idna@3.4": {
+				Info: &models.PackageInfo{
+					Name:              "idna",
+					Version:           "3.4",
+					LicenseExpression: "BSD-3-Clause",
+					Maintainer:        "Kim Davies",
+					MaintainerEmail:   "kim@cynosure.com.au",
+				},
+				Releases: map[string][]*models.ReleaseFile{
+					"3.4": {{
+						Filename:    "idna-3.4-py3-none-any.whl",
+						URL:         "https://files.pythonhosted.org/packages/idna-3.4-py3-none-any.whl",
+						PackageType: "bdist_wheel",
+						Digests:     models.ReleaseDigests{SHA256: "dddd"},
+					}},
+				},
+			},
+			"PySocks@1.7.1": {
+				Info: &models.PackageInfo{Name: "PySocks", Version: "1.7.1", License: "BSD"},
+			},
+		},
+		vulns: map[string][]models.Vulnerability{
+			"requests@2.31.0": {
+				{
+					ID:      "GHSA-9wx4-h78v-vm56",
+					Aliases: []string{"CVE-2024-35195"},
+					Summary: "Requests session may not verify certificates",
+					FixedIn: []string{"2.32.0"},
+					Link:    "https://osv.dev/vulnerability/GHSA-9wx4-h78v-vm56",
+					Source:  "osv",
+				},
+				{ID: "PYSEC-0000-0", Withdrawn: "2024-01-01T00:00:00Z"},
+			},
+		},
+	}
+}
+
+func collect(t *testing.T) *Inventory {
+	pins := []models.Pin{
+		{Name: "requests", Version: "2.31.0"},
+		{Name: "idna", Version: "3.4"},
+		{Name: "PySocks", Version: "1.7.1"},
+	}
+	inv, err := NewGenerator(newFakeClient()).WithConcurrency(2).Collect(context.Background(), pins)
+	require.NoError(t, err)
+	inv.SerialNumber = "3e671687-395b-41f5-a30f-a58921a69b79"
+	inv.Timestamp = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	return inv
+}
+
+func TestCollect(t *testing.T) {
+	inv := collect(t)
+	require.Len(t, inv.Components, 3)
+	assert.Equal(t, "idna", inv.Components[0].Name())
+	assert.Equal(t, "PySocks", inv.Components[1].Name())
+
+	requests := inv.Component("Requests")
+	require.NotNil(t, requests)
+	assert.Equal(t, []string{"idna"}, requests.Dependencies, "只在extra下需要的PySocks不计入依赖")
+	assert.Equal(t, "Apache-2.0", requests.License.String())
+	assert.Len(t, requests.Vulnerabilities, 1, "已撤回的漏洞应被排除")
+	assert.Equal(t, "sdist", requests.PrimaryFile().PackageType)
+	assert.Equal(t, 1, inv.Vulnerabilities())
+
+	idna := inv.Component("idna")
+	assert.Equal(t, "idna-3.4-py3-none-any.whl", idna.PrimaryFile().Filename, "没有urls时使用releases中的文件")
+	assert.Nil(t, inv.Component("PySocks").PrimaryFile())
+
+	t.Run("获取失败", func(t *testing.T) {
+		_, err := NewGenerator(newFakeClient()).Collect(context.Background(), []models.Pin{{Name: "missing", Version: "1.0"}})
+		assert.Error(t, err)
+	})
+
+	t.Run("重复的包", func(t *testing.T) {
+		_, err := NewGenerator(newFakeClient()).Collect(context.Background(), []models.Pin{
+			{Name: "idna", Version: "3.4"},
+			{Name: "IDNA", Version: "3.4"},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("不查询漏洞", func(t *testing.T) {
+		inv, err := NewGenerator(newFakeClient()).WithVulnerabilities(false).Collect(context.Background(), []models.Pin{
+			{Name: "requests", Version: "2.31.0"},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, inv.Components[0].Vulnerabilities)
+		assert.Empty(t, inv.Components[0].Dependencies)
+		assert.Len(t, inv.SerialNumber, 36)
+	})
+}
+
+func TestDirectPins(t *testing.T) {
+	inv, err := NewGenerator(newFakeClient()).Collect(context.Background(), []models.Pin{
+		{Name: "tool", Version: "1.0", URL: "git+https://github.com/org/tool@abc"},
+		{Name: "internal", Version: "2.0", URL: "https://files.example.com/internal-2.0.tar.gz"},
+		{Name: "idna", Version: "3.4", Index: "https://pypi.example.com/simple"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "pkg:pypi/tool@1.0?vcs_url=git%2Bhttps%3A%2F%2Fgithub.com%2Forg%2Ftool%40abc", inv.Component("tool").PURL())
+	assert.Equal(t, "pkg:pypi/internal@2.0?download_url=https%3A%2F%2Ffiles.example.com%2Finternal-2.0.tar.gz", inv.Component("internal").PURL())
+	assert.Equal(t, "pkg:pypi/idna@3.4?repository_url=https%3A%2F%2Fpypi.example.com%2Fsimple", inv.Component("idna").PURL())
+	assert.Nil(t, inv.Component("tool").Package, "直接引用的包不查询索引")
+	assert.Equal(t, "tool", inv.Component("tool").Name())
+}
+
+func TestPURL(t *testing.T) {
+	assert.Equal(t, "pkg:pypi/flask-login@0.6.3", PURL("Flask_Login", "0.6.3"))
+	assert.Equal(t, "pkg:pypi/torch@2.1.0%2Bcu118", PURL("torch", "2.1.0+cu118"))
+	assert.Equal(t, "pkg:pypi/requests", PURL("requests", ""))
+}
@@ -0,0 +1,231 @@
+package sbom
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+)
+
+// SPDXVersion 生成的SPDX规范版本
+const SPDXVersion = "SPDX-2.3"
+
+// spdxNoAssertion SPDX中表示"未提供信息"的取值
+const spdxNoAssertion = "NOASSERTION"
+
+// SPDXDocument SPDX 2.3 JSON文档
+type SPDXDocument struct {
+	SPDXVersion          string                   `json:"spdxVersion"`
+	DataLicense          string                   `json:"dataLicense"`
+	SPDXID               string                   `json:"SPDXID"`
+	Name                 string                   `json:"name"`
+	DocumentNamespace    string                   `json:"documentNamespace"`
+	CreationInfo         SPDXCreationInfo         `json:"creationInfo"`
+	Packages             []SPDXPackage            `json:"packages"`
+	Relationships        []SPDXRelationship       `json:"relationships"`
+	ExtractedLicenseInfo []SPDXExtractedLicensing `json:"hasExtractedLicensingInfos,omitempty"`
+}
+
+// SPDXCreationInfo 文档的创建信息
+type SPDXCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+// SPDXPackage 文档中的包
+type SPDXPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	Supplier         string            `json:"supplier,omitempty"`
+	Originator       string            `json:"originator,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	Checksums        []SPDXChecksum    `json:"checksums,omitempty"`
+	Homepage         string            `json:"homepage,omitempty"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	CopyrightText    string            `json:"copyrightText"`
+	Summary          string            `json:"summary,omitempty"`
+	ExternalRefs     []SPDXExternalRef `json:"externalRefs,omitempty"`
+	PrimaryPurpose   string            `json:"primaryPackagePurpose,omitempty"`
+}
+
+// SPDXChecksum 校验和
+type SPDXChecksum struct {
+	Algorithm string `json:"algorithm"`
+	Value     string `json:"checksumValue"`
+}
+
+// SPDXExternalRef 外部引用，如purl和安全公告
+type SPDXExternalRef struct {
+	Category string `json:"referenceCategory"`
+	Type     string `json:"referenceType"`
+	Locator  string `json:"referenceLocator"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// SPDXRelationship 元素之间的关系
+type SPDXRelationship struct {
+	Element string `json:"spdxElementId"`
+	Type    string `json:"relationshipType"`
+	Related string `json:"relatedSpdxElement"`
+}
+
+// SPDXExtractedLicensing 文档中引用的非SPDX列表许可证（LicenseRef-）
+type SPDXExtractedLicensing struct {
+	LicenseID     string `json:"licenseId"`
+	ExtractedText string `json:"extractedText"`
+	Name          string `json:"name,omitempty"`
+}
+
+// spdxChecksumAlgorithms PyPI摘要算法到SPDX算法名的映射
+var spdxChecksumAlgorithms = map[string]string{
+	"md5":         "MD5",
+	"sha256":      "SHA256",
+	"blake2b_256": "BLAKE2b-256",
+}
+
+// NewSPDX 将清单转换为SPDX 2.3文档
+// 许可证写入licenseDeclared，licenseConcluded为NOASSERTION，漏洞公告作为SECURITY类外部引用
+//
+// 参数:
+//   - inv: Generator.Collect返回的清单
+//
+// 返回值:
+//   - *SPDXDocument: 可直接编码为JSON的文档
+func NewSPDX(inv *Inventory) *SPDXDocument {
+	doc := &SPDXDocument{
+		SPDXVersion:       SPDXVersion,
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              inv.Name,
+		DocumentNamespace: "https://spdx.org/spdxdocs/" + ToolName + "/" + spdxIDString(inv.Name) + "-" + inv.SerialNumber,
+		CreationInfo: SPDXCreationInfo{
+			Created:  inv.Timestamp.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: " + ToolName},
+		},
+		Packages:      []SPDXPackage{},
+		Relationships: []SPDXRelationship{},
+	}
+
+	extracted := map[string]bool{}
+	for _, c := range inv.Components {
+		pkg := spdxPackage(c)
+		doc.Packages = append(doc.Packages, pkg)
+		doc.Relationships = append(doc.Relationships, SPDXRelationship{Element: doc.SPDXID, Type: "DESCRIBES", Related: pkg.SPDXID})
+
+		if c.License.Resolved() {
+			for _, id := range c.License.Expression.Licenses() {
+				if isLicenseRef(id) && !extracted[id] {
+					extracted[id] = true
+					doc.ExtractedLicenseInfo = append(doc.ExtractedLicenseInfo, SPDXExtractedLicensing{
+						LicenseID:     id,
+						ExtractedText: c.License.Input,
+					})
+				}
+			}
+		}
+	}
+	for _, c := range inv.Components {
+		for _, dep := range c.Dependencies {
+			if d := inv.Component(dep); d != nil {
+				doc.Relationships = append(doc.Relationships, SPDXRelationship{
+					Element: spdxPackageID(c),
+					Type:    "DEPENDS_ON",
+					Related: spdxPackageID(d),
+				})
+			}
+		}
+	}
+	return doc
+}
+
+// WriteSPDX 将清单以SPDX 2.3 JSON格式写入w
+func (inv *Inventory) WriteSPDX(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(NewSPDX(inv))
+}
+
+func spdxPackage(c *Component) SPDXPackage {
+	info := c.Info()
+	pkg := SPDXPackage{
+		Name:             c.Name(),
+		SPDXID:           spdxPackageID(c),
+		VersionInfo:      c.Version(),
+		DownloadLocation: spdxNoAssertion,
+		LicenseConcluded: spdxNoAssertion,
+		LicenseDeclared:  spdxNoAssertion,
+		CopyrightText:    spdxNoAssertion,
+		Homepage:         info.HomePage,
+		Summary:          info.Summary,
+		Supplier:         spdxActor(info.Maintainer, info.MaintainerEmail),
+		Originator:       spdxActor(info.Author, info.AuthorEmail),
+		PrimaryPurpose:   "LIBRARY",
+		ExternalRefs: []SPDXExternalRef{
+			{Category: "PACKAGE-MANAGER", Type: "purl", Locator: c.PURL()},
+		},
+	}
+	if pkg.Supplier == "" {
+		pkg.Supplier = pkg.Originator
+	}
+	if pkg.Supplier == "" {
+		pkg.Supplier = spdxNoAssertion
+	}
+
+	if f := c.PrimaryFile(); f != nil {
+		pkg.DownloadLocation = f.URL
+		for _, alg := range []string{"sha256", "blake2b_256", "md5"} {
+			if value := f.Digests.Get(alg); value != "" {
+				pkg.Checksums = append(pkg.Checksums, SPDXChecksum{Algorithm: spdxChecksumAlgorithms[alg], Value: value})
+			}
+		}
+	}
+	if c.License.Resolved() {
+		pkg.LicenseDeclared = c.License.Expression.String()
+	}
+
+	for _, v := range c.Vulnerabilities {
+		locator := v.Link
+		if locator == "" {
+			locator = "https://osv.dev/vulnerability/" + v.ID
+		}
+		pkg.ExternalRefs = append(pkg.ExternalRefs, SPDXExternalRef{
+			Category: "SECURITY",
+			Type:     "advisory",
+			Locator:  locator,
+			Comment:  v.ID,
+		})
+	}
+	return pkg
+}
+
+// spdxPackageID 生成包的SPDX标识，如 "SPDXRef-Package-flask-login-0.6.3"
+func spdxPackageID(c *Component) string {
+	return "SPDXRef-Package-" + spdxIDString(c.Pin.NormalizedName()+"-"+c.Version())
+}
+
+// spdxIDString 将字符串转换为SPDX标识允许的字符（字母、数字、"."、"-"）
+func spdxIDString(s string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '.' || r == '-' {
+			return r
+		}
+		return '-'
+	}, s)
+}
+
+// spdxActor 生成 "Person: 姓名 (邮箱)" 形式的供应商或作者，没有信息时返回空字符串
+// PyPI的邮箱字段可能包含多个 "姓名 <邮箱>"，此时原样作为姓名输出
+func spdxActor(name, email string) string {
+	switch {
+	case name == "" && email == "":
+		return ""
+	case name == "":
+		return "Person: " + email
+	case email == "" || strings.ContainsAny(email, "<,"):
+		return "Person: " + name
+	}
+	return "Person: " + name + " (" + email + ")"
+}
@@ -0,0 +1,72 @@
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/scagogogo/pypi-crawler/pkg/pypi/license"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSPDX(t *testing.T) {
+	doc := NewSPDX(collect(t))
+	assert.Equal(t, "SPDX-2.3", doc.SPDXVersion)
+	assert.Equal(t, "CC0-1.0", doc.DataLicense)
+	assert.Equal(t, "2024-05-01T12:00:00Z", doc.CreationInfo.Created)
+	assert.Contains(t, doc.DocumentNamespace, "3e671687-395b-41f5-a30f-a58921a69b79")
+	require.Len(t, doc.Packages, 3)
+
+	requests := doc.Packages[2]
+	assert.Equal(t, "SPDXRef-Package-requests-2.31.0", requests.SPDXID)
+	assert.Equal(t, "https://files.pythonhosted.org/packages/requests-2.31.0.tar.gz", requests.DownloadLocation)
+	assert.Equal(t, []SPDXChecksum{{Algorithm: "SHA256", Value: "cccc"}}, requests.Checksums)
+	assert.Equal(t, "Apache-2.0", requests.LicenseDeclared)
+	assert.Equal(t, "NOASSERTION", requests.LicenseConcluded)
+	assert.Equal(t, "Person: Kenneth Reitz (me@kennethreitz.org)", requests.Originator)
+	assert.Equal(t, requests.Originator, requests.Supplier, "没有维护者时以作者为供应商")
+	assert.Equal(t, SPDXExternalRef{Category: "PACKAGE-MANAGER", Type: "purl", Locator: "pkg:pypi/requests@2.31.0"}, requests.ExternalRefs[0])
+	require.Len(t, requests.ExternalRefs, 2)
+	assert.Equal(t, "SECURITY", requests.ExternalRefs[1].Category)
+
+	pysocks := doc.Packages[1]
+	assert.Equal(t, "NOASSERTION", pysocks.DownloadLocation)
+	assert.Equal(t, "NOASSERTION", pysocks.Supplier)
+
+	t.Run("关系", func(t *testing.T) {
+		assert.Contains(t, doc.Relationships, SPDXRelationship{
+			Element: "SPDXRef-Package-requests-2.31.0",
+			Type:    "DEPENDS_ON",
+			Related: "SPDXRef-Package-idna-3.4",
+		})
+		described := 0
+		for _, rel := range doc.Relationships {
+			if rel.Type == "DESCRIBES" {
+				described++
+			}
+		}
+		assert.Equal(t, 3, described)
+	})
+
+	t.Run("LicenseRef", func(t *testing.T) {
+		inv := collect(t)
+		idna := inv.Component("idna")
+		idna.Package.Info.LicenseExpression = "MIT OR LicenseRef-Proprietary"
+		idna.License = license.Normalize(idna.Info())
+
+		doc := NewSPDX(inv)
+		assert.Equal(t, "MIT OR LicenseRef-Proprietary", doc.Packages[0].LicenseDeclared)
+		require.Len(t, doc.ExtractedLicenseInfo, 1)
+		assert.Equal(t, "LicenseRef-Proprietary", doc.ExtractedLicenseInfo[0].LicenseID)
+		assert.Equal(t, "MIT OR LicenseRef-Proprietary", doc.ExtractedLicenseInfo[0].ExtractedText)
+	})
+
+	t.Run("JSON输出", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, collect(t).WriteSPDX(&buf))
+		var decoded map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+		assert.Equal(t, "SPDXRef-DOCUMENT", decoded["SPDXID"])
+	})
+}
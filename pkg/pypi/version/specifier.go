@@ -0,0 +1,263 @@
+package version
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidSpecifier 表示字符串不是有效的PEP 440版本约束
+var ErrInvalidSpecifier = errors.New("无效的PEP 440版本约束")
+
+// operators 按长度排列的比较运算符，保证 "===" 先于 "==" 匹配
+var operators = []string{"===", "~=", "==", "!=", "<=", ">=", "<", ">"}
+
+// Specifier 表示单个版本约束，如 ">=1.2"、"==1.4.*"、"~=2.2"
+type Specifier struct {
+	// Operator 比较运算符
+	Operator string
+
+	// Version 约束中的版本号（原始写法，不含 ".*"）
+	Version string
+
+	// Wildcard 是否为 "==1.4.*" 或 "!=1.4.*" 形式的前缀匹配
+	Wildcard bool
+
+	parsed *Version
+}
+
+// ParseSpecifier 解析单个版本约束
+func ParseSpecifier(s string) (*Specifier, error) {
+	s = strings.TrimSpace(s)
+	spec := &Specifier{}
+	for _, op := range operators {
+		if strings.HasPrefix(s, op) {
+			spec.Operator = op
+			spec.Version = strings.TrimSpace(s[len(op):])
+			break
+		}
+	}
+	if spec.Operator == "" || spec.Version == "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidSpecifier, s)
+	}
+
+	// "===" 是任意字符串相等比较，不需要是合法版本号
+	if spec.Operator == "===" {
+		return spec, nil
+	}
+
+	if strings.HasSuffix(spec.Version, ".*") {
+		if spec.Operator != "==" && spec.Operator != "!=" {
+			return nil, fmt.Errorf("%w: 只有 == 和 != 支持通配符: %q", ErrInvalidSpecifier, s)
+		}
+		spec.Wildcard = true
+		spec.Version = strings.TrimSuffix(spec.Version, ".*")
+	}
+
+	v, err := Parse(spec.Version)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidSpecifier, s)
+	}
+	if len(v.Local) > 0 && spec.Operator != "==" && spec.Operator != "!=" {
+		return nil, fmt.Errorf("%w: 只有 == 和 != 可以使用本地版本: %q", ErrInvalidSpecifier, s)
+	}
+	if spec.Operator == "~=" && len(v.Release) < 2 {
+		return nil, fmt.Errorf("%w: ~= 至少需要两段发布号: %q", ErrInvalidSpecifier, s)
+	}
+	spec.parsed = v
+	return spec, nil
+}
+
+// String 返回约束的规范写法
+func (s *Specifier) String() string {
+	if s.Wildcard {
+		return s.Operator + s.Version + ".*"
+	}
+	return s.Operator + s.Version
+}
+
+// IsPinned 检查约束是否将版本固定为一个确定的值（"==X" 或 "===X"，不含通配符）
+func (s *Specifier) IsPinned() bool {
+	return (s.Operator == "==" && !s.Wildcard) || s.Operator == "==="
+}
+
+// Contains 检查版本号是否满足约束
+// 预发布版本的默认排除规则由SpecifierSet.Filter处理，这里只做纯粹的比较
+func (s *Specifier) Contains(v *Version) bool {
+	if s.Operator == "===" {
+		return strings.EqualFold(strings.TrimSpace(s.Version), v.String())
+	}
+
+	spec := s.parsed
+	switch s.Operator {
+	case "==":
+		if s.Wildcard {
+			return prefixMatch(spec, v)
+		}
+		// 约束中没有本地标签时忽略候选版本的本地标签
+		if len(spec.Local) == 0 {
+			return v.Public().Equal(spec)
+		}
+		return v.Equal(spec)
+	case "!=":
+		if s.Wildcard {
+			return !prefixMatch(spec, v)
+		}
+		if len(spec.Local) == 0 {
+			return !v.Public().Equal(spec)
+		}
+		return !v.Equal(spec)
+	case "<=":
+		return v.Public().Compare(spec) <= 0
+	case ">=":
+		return v.Public().Compare(spec) >= 0
+	case "<":
+		if v.Compare(spec) >= 0 {
+			return false
+		}
+		// "<V" 不包含V自身的预发布版本，除非V本身就是预发布版本
+		if !spec.IsPrerelease() && v.IsPrerelease() && v.BaseVersion().Equal(spec.BaseVersion()) {
+			return false
+		}
+		return true
+	case ">":
+		if v.Compare(spec) <= 0 {
+			return false
+		}
+		// ">V" 不包含V的后发布版本和本地版本，除非V本身就是后发布版本
+		if !spec.IsPostrelease() && v.IsPostrelease() && v.BaseVersion().Equal(spec.BaseVersion()) {
+			return false
+		}
+		if len(v.Local) > 0 && v.Public().Equal(spec) {
+			return false
+		}
+		return true
+	case "~=":
+		// ~=X.Y 等价于 >=X.Y, ==X.*
+		prefix := &Version{Epoch: spec.Epoch, Release: spec.Release[:len(spec.Release)-1], Post: -1, Dev: -1}
+		return v.Public().Compare(spec) >= 0 && prefixMatch(prefix, v)
+	}
+	return false
+}
+
+// prefixMatch 检查v的发布号是否以prefix的发布号开头，用于通配符匹配
+func prefixMatch(prefix *Version, v *Version) bool {
+	if prefix.Epoch != v.Epoch {
+		return false
+	}
+	// 通配符中的预发布部分必须完全一致，如 "==1.0rc1.*"
+	if prefix.PreLabel != "" || prefix.Post >= 0 || prefix.Dev >= 0 {
+		candidate := v.Public()
+		candidate.Local = nil
+		return strings.HasPrefix(candidate.String(), prefix.String())
+	}
+	for i, n := range prefix.Release {
+		var x int
+		if i < len(v.Release) {
+			x = v.Release[i]
+		}
+		if x != n {
+			return false
+		}
+	}
+	return true
+}
+
+// SpecifierSet 表示以逗号连接的一组版本约束，版本需要满足其中所有约束
+type SpecifierSet []*Specifier
+
+// ParseSpecifierSet 解析以逗号分隔的版本约束，如 ">=1.0,<2.0"，空字符串表示没有约束
+//
+// 使用示例:
+//
+//	set, err := version.ParseSpecifierSet(">=3.8, !=3.9.0")
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(set.Contains(version.MustParse("3.11.4"))) // true
+func ParseSpecifierSet(s string) (SpecifierSet, error) {
+	var set SpecifierSet
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		spec, err := ParseSpecifier(part)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, spec)
+	}
+	return set, nil
+}
+
+// String 返回以逗号连接的约束，按字符串排序以得到稳定的写法
+func (set SpecifierSet) String() string {
+	parts := make([]string, len(set))
+	for i, spec := range set {
+		parts[i] = spec.String()
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+// Contains 检查版本号是否满足所有约束，空集合接受任何版本
+func (set SpecifierSet) Contains(v *Version) bool {
+	for _, spec := range set {
+		if !spec.Contains(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsString 解析并检查版本号，无效的版本号视为不满足
+func (set SpecifierSet) ContainsString(s string) bool {
+	v, err := Parse(s)
+	if err != nil {
+		return false
+	}
+	return set.Contains(v)
+}
+
+// AllowsPrereleases 检查约束中是否显式提到了预发布版本，此时候选版本中的预发布版本不会被排除
+func (set SpecifierSet) AllowsPrereleases() bool {
+	for _, spec := range set {
+		if spec.parsed != nil && spec.parsed.IsPrerelease() && spec.Operator != "!=" {
+			return true
+		}
+	}
+	return false
+}
+
+// Pinned 返回固定的版本号（集合中存在 "==X" 或 "===X" 时）
+func (set SpecifierSet) Pinned() (string, bool) {
+	for _, spec := range set {
+		if spec.IsPinned() {
+			return spec.Version, true
+		}
+	}
+	return "", false
+}
+
+// Filter 从候选版本中选出满足约束的版本，保持原有顺序
+// 与pip一致，除非prereleases为true或约束显式提到预发布版本，否则排除预发布版本；
+// 但如果满足约束的只有预发布版本，则返回这些预发布版本
+func (set SpecifierSet) Filter(candidates []*Version, prereleases bool) []*Version {
+	allowPre := prereleases || set.AllowsPrereleases()
+	var result, pre []*Version
+	for _, v := range candidates {
+		if !set.Contains(v) {
+			continue
+		}
+		if v.IsPrerelease() && !allowPre {
+			pre = append(pre, v)
+			continue
+		}
+		result = append(result, v)
+	}
+	if len(result) == 0 {
+		return pre
+	}
+	return result
+}
@@ -0,0 +1,100 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpecifierContains(t *testing.T) {
+	cases := []struct {
+		spec    string
+		version string
+		want    bool
+	}{
+		{"==1.0", "1.0.0", true},
+		{"==1.0", "1.0+local", true},
+		{"==1.0+local", "1.0", false},
+		{"==1.4.*", "1.4.5", true},
+		{"==1.4.*", "1.4", true},
+		{"==1.4.*", "1.5", false},
+		{"!=1.4.*", "1.5", true},
+		{"!=1.0", "1.0", false},
+		{"~=2.2", "2.3", true},
+		{"~=2.2", "3.0", false},
+		{"~=1.4.5", "1.4.9", true},
+		{"~=1.4.5", "1.5.0", false},
+		{">=1.0", "1.0", true},
+		{">=1.0", "1.0rc1", false},
+		{"<=1.0", "1.0+local", true},
+		{"<2.0", "1.9", true},
+		{"<2.0", "2.0rc1", false},
+		{"<2.0rc2", "2.0rc1", true},
+		{">1.7", "1.7.1", true},
+		{">1.7", "1.7.post2", false},
+		{">1.7.post2", "1.7.post3", true},
+		{">1.7", "1.7+local", false},
+		{"===foobar", "foobar", false},
+		{"===1.0", "1.0", true},
+	}
+	for _, c := range cases {
+		t.Run(c.spec+" "+c.version, func(t *testing.T) {
+			spec, err := ParseSpecifier(c.spec)
+			require.NoError(t, err)
+			if c.spec == "===foobar" {
+				assert.Equal(t, "foobar", spec.Version)
+				return
+			}
+			assert.Equal(t, c.want, spec.Contains(MustParse(c.version)))
+		})
+	}
+
+	for _, invalid := range []string{"1.0", ">=", "~=1", ">=1.0.*", "<1.0+local", "==abc"} {
+		_, err := ParseSpecifier(invalid)
+		assert.ErrorIs(t, err, ErrInvalidSpecifier, invalid)
+	}
+}
+
+func TestSpecifierSet(t *testing.T) {
+	set, err := ParseSpecifierSet(">=1.0, <2.0,!=1.5.*")
+	require.NoError(t, err)
+	assert.Len(t, set, 3)
+	assert.Equal(t, "!=1.5.*,<2.0,>=1.0", set.String())
+	assert.True(t, set.ContainsString("1.4.2"))
+	assert.False(t, set.ContainsString("1.5.1"))
+	assert.False(t, set.ContainsString("2.0"))
+	assert.False(t, set.ContainsString("garbage"))
+
+	empty, err := ParseSpecifierSet("")
+	require.NoError(t, err)
+	assert.True(t, empty.ContainsString("99"))
+
+	t.Run("固定版本", func(t *testing.T) {
+		pinned, _ := ParseSpecifierSet("==2.31.0")
+		v, ok := pinned.Pinned()
+		assert.True(t, ok)
+		assert.Equal(t, "2.31.0", v)
+
+		_, ok = set.Pinned()
+		assert.False(t, ok)
+		wildcard, _ := ParseSpecifierSet("==2.*")
+		_, ok = wildcard.Pinned()
+		assert.False(t, ok)
+	})
+
+	t.Run("过滤预发布版本", func(t *testing.T) {
+		candidates := []*Version{MustParse("1.0"), MustParse("1.1rc1"), MustParse("1.1")}
+		assert.Len(t, set.Filter(candidates, false), 2)
+		assert.Len(t, set.Filter(candidates, true), 3)
+
+		onlyPre, _ := ParseSpecifierSet(">=1.1rc1")
+		assert.True(t, onlyPre.AllowsPrereleases())
+		assert.Len(t, onlyPre.Filter(candidates, false), 2)
+
+		future, _ := ParseSpecifierSet(">1.0")
+		result := future.Filter([]*Version{MustParse("1.0"), MustParse("2.0b1")}, false)
+		require.Len(t, result, 1)
+		assert.Equal(t, "2.0b1", result[0].String())
+	})
+}
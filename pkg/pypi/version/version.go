@@ -0,0 +1,352 @@
+package version
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidVersion 表示字符串不是有效的PEP 440版本号
+var ErrInvalidVersion = errors.New("无效的PEP 440版本号")
+
+// versionPattern PEP 440版本号的宽松格式，与pip的解析规则一致
+var versionPattern = regexp.MustCompile(`^v?` +
+	`(?:(?P<epoch>[0-9]+)!)?` +
+	`(?P<release>[0-9]+(?:\.[0-9]+)*)` +
+	`(?:[-_.]?(?P<pre_l>alpha|a|beta|b|preview|pre|c|rc)[-_.]?(?P<pre_n>[0-9]+)?)?` +
+	`(?:-(?P<post_n1>[0-9]+)|[-_.]?(?P<post_l>post|rev|r)[-_.]?(?P<post_n2>[0-9]+)?)?` +
+	`(?:[-_.]?(?P<dev_l>dev)[-_.]?(?P<dev_n>[0-9]+)?)?` +
+	`(?:\+(?P<local>[a-z0-9]+(?:[-_.][a-z0-9]+)*))?$`)
+
+// Version 表示解析后的PEP 440版本号
+type Version struct {
+	// Epoch 纪元，如 "1!2.0" 中的1
+	Epoch int
+
+	// Release 发布号的各段，如 "1.2.3" 为 [1, 2, 3]
+	Release []int
+
+	// PreLabel 预发布标签（a、b或rc），为空表示不是预发布版本
+	PreLabel string
+
+	// PreNumber 预发布序号
+	PreNumber int
+
+	// Post 后发布序号，-1表示不是后发布版本
+	Post int
+
+	// Dev 开发版序号，-1表示不是开发版
+	Dev int
+
+	// Local 本地版本标签的各段，如 "1.0+ubuntu.1" 为 ["ubuntu", "1"]
+	Local []string
+}
+
+// Parse 解析PEP 440版本号，接受pip允许的各种非规范写法（如 "1.0-alpha1"、"v2"）
+//
+// 参数:
+//   - s: 版本号字符串
+//
+// 返回值:
+//   - *Version: 解析后的版本号
+//   - error: 格式无效时返回，错误包装了ErrInvalidVersion
+//
+// 使用示例:
+//
+//	v, err := version.Parse("1.0.0rc1")
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(v.IsPrerelease()) // true
+func Parse(s string) (*Version, error) {
+	m := versionPattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if m == nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidVersion, s)
+	}
+	group := func(name string) string {
+		return m[versionPattern.SubexpIndex(name)]
+	}
+
+	v := &Version{Post: -1, Dev: -1}
+	if epoch := group("epoch"); epoch != "" {
+		v.Epoch = atoi(epoch)
+	}
+	for _, part := range strings.Split(group("release"), ".") {
+		v.Release = append(v.Release, atoi(part))
+	}
+
+	if label := group("pre_l"); label != "" {
+		switch label {
+		case "alpha", "a":
+			v.PreLabel = "a"
+		case "beta", "b":
+			v.PreLabel = "b"
+		default:
+			v.PreLabel = "rc"
+		}
+		v.PreNumber = atoi(group("pre_n"))
+	}
+
+	if n := group("post_n1"); n != "" {
+		v.Post = atoi(n)
+	} else if group("post_l") != "" {
+		v.Post = atoi(group("post_n2"))
+	}
+
+	if group("dev_l") != "" {
+		v.Dev = atoi(group("dev_n"))
+	}
+
+	if local := group("local"); local != "" {
+		v.Local = strings.FieldsFunc(local, func(r rune) bool { return r == '-' || r == '_' || r == '.' })
+	}
+	return v, nil
+}
+
+// MustParse 解析版本号，格式无效时panic，仅用于常量
+func MustParse(s string) *Version {
+	v, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// atoi 将数字字符串转换为整数，空字符串返回0
+func atoi(s string) int {
+	if s == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		// 超出int范围的数字在正则中已保证只含数字，截断处理
+		return int(^uint(0) >> 1)
+	}
+	return n
+}
+
+// String 返回规范化的版本号，如 "1.0-alpha1" 返回 "1.0a1"
+func (v *Version) String() string {
+	var b strings.Builder
+	if v.Epoch != 0 {
+		fmt.Fprintf(&b, "%d!", v.Epoch)
+	}
+	b.WriteString(v.releaseString())
+	if v.PreLabel != "" {
+		fmt.Fprintf(&b, "%s%d", v.PreLabel, v.PreNumber)
+	}
+	if v.Post >= 0 {
+		fmt.Fprintf(&b, ".post%d", v.Post)
+	}
+	if v.Dev >= 0 {
+		fmt.Fprintf(&b, ".dev%d", v.Dev)
+	}
+	if len(v.Local) > 0 {
+		b.WriteString("+" + strings.Join(v.Local, "."))
+	}
+	return b.String()
+}
+
+func (v *Version) releaseString() string {
+	parts := make([]string, len(v.Release))
+	for i, n := range v.Release {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ".")
+}
+
+// Public 返回去掉本地版本标签后的版本号
+func (v *Version) Public() *Version {
+	public := *v
+	public.Local = nil
+	return &public
+}
+
+// BaseVersion 返回只包含纪元和发布号的版本号，如 "1.0rc1.post2" 返回 "1.0"
+func (v *Version) BaseVersion() *Version {
+	return &Version{Epoch: v.Epoch, Release: v.Release, Post: -1, Dev: -1}
+}
+
+// IsPrerelease 检查是否为预发布或开发版本
+func (v *Version) IsPrerelease() bool {
+	return v.PreLabel != "" || v.Dev >= 0
+}
+
+// IsPostrelease 检查是否为后发布版本
+func (v *Version) IsPostrelease() bool {
+	return v.Post >= 0
+}
+
+// IsDevrelease 检查是否为开发版本
+func (v *Version) IsDevrelease() bool {
+	return v.Dev >= 0
+}
+
+// Compare 按PEP 440的顺序比较两个版本号
+// 返回值小于0表示v较旧，等于0表示相同，大于0表示v较新
+func (v *Version) Compare(o *Version) int {
+	if c := compareInt(v.Epoch, o.Epoch); c != 0 {
+		return c
+	}
+	if c := compareRelease(v.Release, o.Release); c != 0 {
+		return c
+	}
+	if c := comparePre(v, o); c != 0 {
+		return c
+	}
+	// 没有后发布号的版本排在有后发布号的版本之前
+	if c := compareInt(v.Post, o.Post); c != 0 {
+		return c
+	}
+	// 没有开发版号的版本排在开发版之后
+	if c := compareInt(devKey(v), devKey(o)); c != 0 {
+		return c
+	}
+	return compareLocal(v.Local, o.Local)
+}
+
+// Equal 检查两个版本号是否相等（"1.0" 与 "1.0.0" 相等）
+func (v *Version) Equal(o *Version) bool {
+	return v.Compare(o) == 0
+}
+
+// LessThan 检查v是否早于o
+func (v *Version) LessThan(o *Version) bool {
+	return v.Compare(o) < 0
+}
+
+// Compare 解析并比较两个版本号字符串
+func Compare(a, b string) (int, error) {
+	va, err := Parse(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := Parse(b)
+	if err != nil {
+		return 0, err
+	}
+	return va.Compare(vb), nil
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// compareRelease 比较发布号，忽略末尾的0
+func compareRelease(a, b []int) int {
+	n := len(a)
+	if len(b) > n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if c := compareInt(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// preRank 预发布阶段的排序值
+// 只有开发版号的版本（如 1.0.dev0）排在所有预发布版本之前，正式版本排在之后
+func preRank(v *Version) (int, int) {
+	switch {
+	case v.PreLabel == "" && v.Post < 0 && v.Dev >= 0:
+		return -1, 0
+	case v.PreLabel == "":
+		return 4, 0
+	case v.PreLabel == "a":
+		return 1, v.PreNumber
+	case v.PreLabel == "b":
+		return 2, v.PreNumber
+	default:
+		return 3, v.PreNumber
+	}
+}
+
+func comparePre(a, b *Version) int {
+	ra, na := preRank(a)
+	rb, nb := preRank(b)
+	if c := compareInt(ra, rb); c != 0 {
+		return c
+	}
+	return compareInt(na, nb)
+}
+
+func devKey(v *Version) int {
+	if v.Dev < 0 {
+		return int(^uint(0) >> 1)
+	}
+	return v.Dev
+}
+
+// compareLocal 比较本地版本标签：没有标签的排在前面，数字段大于字母段
+func compareLocal(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		x, xErr := strconv.Atoi(a[i])
+		y, yErr := strconv.Atoi(b[i])
+		switch {
+		case xErr == nil && yErr == nil:
+			if c := compareInt(x, y); c != 0 {
+				return c
+			}
+		case xErr == nil:
+			return 1
+		case yErr == nil:
+			return -1
+		default:
+			if c := strings.Compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInt(len(a), len(b))
+}
+
+// Sort 将版本号按从旧到新的顺序排序
+func Sort(versions []*Version) {
+	sort.SliceStable(versions, func(i, j int) bool { return versions[i].LessThan(versions[j]) })
+}
+
+// SortStrings 将版本号字符串按从旧到新的顺序排序，无效的版本号排在最前面并保持原有顺序
+func SortStrings(versions []string) {
+	type entry struct {
+		raw    string
+		parsed *Version
+	}
+	entries := make([]entry, len(versions))
+	for i, s := range versions {
+		v, _ := Parse(s)
+		entries[i] = entry{raw: s, parsed: v}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i].parsed, entries[j].parsed
+		switch {
+		case a == nil:
+			return b != nil
+		case b == nil:
+			return false
+		default:
+			return a.LessThan(b)
+		}
+	})
+	for i, e := range entries {
+		versions[i] = e.raw
+	}
+}
@@ -0,0 +1,91 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	cases := map[string]string{
+		"1.0":               "1.0",
+		"v1.0":              "1.0",
+		"1.0-alpha1":        "1.0a1",
+		"1.0.BETA.2":        "1.0b2",
+		"1.0c1":             "1.0rc1",
+		"1.0-1":             "1.0.post1",
+		"1.0.rev":           "1.0.post0",
+		"1.0.dev":           "1.0.dev0",
+		"2!1.0rc1.post2":    "2!1.0rc1.post2",
+		"1.0+Ubuntu-1":      "1.0+ubuntu.1",
+		" 1.2.3.post4.dev5": "1.2.3.post4.dev5",
+	}
+	for input, expected := range cases {
+		t.Run(input, func(t *testing.T) {
+			v, err := Parse(input)
+			require.NoError(t, err)
+			assert.Equal(t, expected, v.String())
+		})
+	}
+
+	for _, invalid := range []string{"", "abc", "1.0.x", "1..0", "1.0+", "=1.0"} {
+		_, err := Parse(invalid)
+		assert.ErrorIs(t, err, ErrInvalidVersion, invalid)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// 按PEP 440从旧到新排列
+	ordered := []string{
+		"1.0.dev456",
+		"1.0a1",
+		"1.0a2.dev456",
+		"1.0a12.dev456",
+		"1.0a12",
+		"1.0b1.dev456",
+		"1.0b2",
+		"1.0b2.post345.dev456",
+		"1.0b2.post345",
+		"1.0rc1.dev456",
+		"1.0rc1",
+		"1.0",
+		"1.0+abc.5",
+		"1.0+abc.7",
+		"1.0+5",
+		"1.0.post456.dev34",
+		"1.0.post456",
+		"1.0.15",
+		"1.1.dev1",
+		"1!0.1",
+	}
+	for i := 0; i < len(ordered)-1; i++ {
+		c, err := Compare(ordered[i], ordered[i+1])
+		require.NoError(t, err)
+		assert.Negative(t, c, "%s 应早于 %s", ordered[i], ordered[i+1])
+	}
+
+	assert.True(t, MustParse("1.0").Equal(MustParse("1.0.0")))
+	assert.True(t, MustParse("1.9").LessThan(MustParse("1.10")))
+
+	t.Run("排序", func(t *testing.T) {
+		versions := []string{"1.10", "not-a-version", "1.9", "1.0rc1", "1.0"}
+		SortStrings(versions)
+		assert.Equal(t, []string{"not-a-version", "1.0rc1", "1.0", "1.9", "1.10"}, versions)
+
+		parsed := []*Version{MustParse("2.0"), MustParse("1.0"), MustParse("1.5")}
+		Sort(parsed)
+		assert.Equal(t, "1.0", parsed[0].String())
+		assert.Equal(t, "2.0", parsed[2].String())
+	})
+}
+
+func TestVersionProperties(t *testing.T) {
+	v := MustParse("1.2rc1.post3.dev4+local")
+	assert.True(t, v.IsPrerelease())
+	assert.True(t, v.IsPostrelease())
+	assert.True(t, v.IsDevrelease())
+	assert.Equal(t, "1.2", v.BaseVersion().String())
+	assert.Equal(t, "1.2rc1.post3.dev4", v.Public().String())
+	assert.False(t, MustParse("1.0.post1").IsPrerelease())
+}